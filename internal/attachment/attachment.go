@@ -0,0 +1,176 @@
+// Package attachment turns documents into content parts of a chat: PDF, DOCX,
+// XLSX, CSV and HTML files, and any other text file. The text of the binary
+// formats is extracted in pure Go. PDFs are also kept whole, for the vendors
+// which read them natively, and referenced by path and hash so saved
+// conversations need not hold them.
+package attachment
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	"github.com/baalimago/clai/pkg/tools"
+	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
+)
+
+// MaxFileSize is the largest file which is attached, the limit of the
+// vendors which accept PDFs.
+const MaxFileSize = 32 << 20
+
+// ErrUnsupported is returned for files which are neither a known document
+// format nor text.
+var ErrUnsupported = errors.New("unsupported file type")
+
+// extractors of the binary formats, by lowercase extension.
+var extractors = map[string]func([]byte) (string, error){
+	".pdf":  pdfText,
+	".docx": docxText,
+	".xlsx": xlsxText,
+}
+
+// Extractable reports whether path is a binary document, such as a PDF,
+// whose text Text can extract.
+func Extractable(path string) bool {
+	_, ok := extractors[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// Text returns the text of the document at path: extracted from PDF, DOCX,
+// XLSX and HTML files, and as is from other text files.
+func Text(path string) (string, error) {
+	data, err := read(path)
+	if err != nil {
+		return "", err
+	}
+	return text(path, data)
+}
+
+func text(path string, data []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if extract, ok := extractors[ext]; ok {
+		s, err := extract(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract text of %v: %w", path, err)
+		}
+		return s, nil
+	}
+	switch {
+	case ext == ".html" || ext == ".htm" || ext == ".xhtml":
+		return tools.HTMLText(bytes.NewReader(data))
+	case strings.HasPrefix(http.DetectContentType(data[:min(len(data), 512)]), "text/"):
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("%v: %w", path, ErrUnsupported)
+	}
+}
+
+// Part returns the document at path as a content part. A PDF becomes a file
+// part carrying both the document and its extracted text, anything else a
+// text part.
+func Part(path string) (pub_models.ImageOrTextInput, error) {
+	data, err := read(path)
+	if err != nil {
+		return pub_models.ImageOrTextInput{}, err
+	}
+	name := filepath.Base(path)
+	if strings.EqualFold(filepath.Ext(path), ".pdf") {
+		s, err := pdfText(data)
+		if err != nil {
+			// Scanned or unusual PDFs are still read by the vendors which take
+			// the document itself.
+			s = fmt.Sprintf("(no text could be extracted: %v)", err)
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return pub_models.ImageOrTextInput{}, fmt.Errorf("failed to resolve attachment path: %w", err)
+		}
+		return pub_models.ImageOrTextInput{
+			Type: string(pub_models.File),
+			File: &pub_models.FileInput{
+				Filename: name,
+				FileData: pdfDataURL(data),
+				Text:     s,
+				Path:     abs,
+				SHA256:   sha256Hex(data),
+			},
+		}, nil
+	}
+	s, err := text(path, data)
+	if err != nil {
+		return pub_models.ImageOrTextInput{}, err
+	}
+	return pub_models.FileInput{Filename: name, Text: s}.AsText(), nil
+}
+
+// Restore returns msgs with the documents of the file parts read back from
+// their Path, as saved conversations don't hold them. A document which is
+// gone or changed since it was attached is left out, so its extracted text is
+// sent in its place. msgs is left unchanged.
+func Restore(msgs []pub_models.Message) []pub_models.Message {
+	ret := msgs
+	cloned := false
+	for i, m := range msgs {
+		var parts []pub_models.ImageOrTextInput
+		for j, p := range m.ContentParts {
+			if p.File == nil || p.File.FileData != "" || p.File.Path == "" {
+				continue
+			}
+			data, err := read(p.File.Path)
+			if err == nil && sha256Hex(data) != p.File.SHA256 {
+				err = errors.New("it changed since it was attached")
+			}
+			if err != nil {
+				ancli.Warnf("sending the extracted text of %v: %v\n", p.File.Filename, err)
+				continue
+			}
+			if parts == nil {
+				parts = slices.Clone(m.ContentParts)
+			}
+			f := *p.File
+			f.FileData = pdfDataURL(data)
+			parts[j].File = &f
+		}
+		if parts == nil {
+			continue
+		}
+		if !cloned {
+			ret, cloned = slices.Clone(msgs), true
+		}
+		ret[i].ContentParts = parts
+	}
+	return ret
+}
+
+func pdfDataURL(data []byte) string {
+	return "data:application/pdf;base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func read(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat attachment: %w", err)
+	}
+	if info.Size() > MaxFileSize {
+		return nil, fmt.Errorf("attachment %v is %d MiB, over the limit of %d MiB", path, info.Size()>>20, MaxFileSize>>20)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,224 @@
+package attachment
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// minimalPDF returns a PDF with one page per text, shown in Helvetica.
+func minimalPDF(pages ...string) []byte {
+	var objs []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objs = append(objs,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	)
+	for i, text := range pages {
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objs = append(objs,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		)
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return b.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPDFText(t *testing.T) {
+	got, err := pdfText(minimalPDF("Quarterly revenue", "Second page"))
+	if err != nil {
+		t.Fatalf("pdfText: %v", err)
+	}
+	want := "[page 1]\nQuarterly revenue\n[page 2]\nSecond page\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	if _, err := pdfText([]byte("%PDF-1.4\nnot really")); err == nil {
+		t.Fatal("expected an error for a malformed pdf")
+	}
+}
+
+func TestDocxText(t *testing.T) {
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Hello</w:t></w:r><w:r><w:t xml:space="preserve"> world</w:t></w:r></w:p>
+<w:p><w:r><w:t>a</w:t><w:tab/><w:t>b</w:t><w:br/><w:t>c</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>k</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>v</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+</w:body></w:document>`
+	got, err := docxText(zipArchive(t, map[string]string{"word/document.xml": doc}))
+	if err != nil {
+		t.Fatalf("docxText: %v", err)
+	}
+	want := "Hello world\na\tb\nc\nk\tv\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	if _, err := docxText(zipArchive(t, map[string]string{"other.xml": ""})); err == nil {
+		t.Fatal("expected an error without word/document.xml")
+	}
+}
+
+func TestXlsxText(t *testing.T) {
+	data := zipArchive(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sales" sheetId="1" r:id="rId1"/><sheet name="Empty" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>Region</t></si><si><r><t>To</t></r><r><t>tal, EUR</t></r></si><si><t>North</t></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2" t="inlineStr"><is><t>note</t></is></c><c r="C2"><f>SUM(1,2)</f><v>3</v></c><c r="D2" t="b"><v>1</v></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData/></worksheet>`,
+	})
+	got, err := xlsxText(data)
+	if err != nil {
+		t.Fatalf("xlsxText: %v", err)
+	}
+	want := "[sheet Sales]\nRegion,,\"Total, EUR\"\nNorth,note,3,true\n[sheet Empty]\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestText(t *testing.T) {
+	html := writeFile(t, "page.html", []byte("<html><head><title>x</title></head><body><script>js()</script><p>Visible</p></body></html>"))
+	if got, err := Text(html); err != nil || got != "Visible\n" {
+		t.Fatalf("Text(html) = %q, %v", got, err)
+	}
+	csv := writeFile(t, "data.csv", []byte("a,b\n1,2\n"))
+	if got, err := Text(csv); err != nil || got != "a,b\n1,2\n" {
+		t.Fatalf("Text(csv) = %q, %v", got, err)
+	}
+	bin := writeFile(t, "blob.bin", []byte{0x00, 0x01, 0x02, 0xff})
+	if _, err := Text(bin); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Text(bin) error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestExtractable(t *testing.T) {
+	for path, want := range map[string]bool{
+		"a.pdf": true, "b.DOCX": true, "c.xlsx": true, "d.csv": false, "e.html": false, "f.go": false,
+	} {
+		if got := Extractable(path); got != want {
+			t.Errorf("Extractable(%q) = %t, want %t", path, got, want)
+		}
+	}
+}
+
+func TestPart(t *testing.T) {
+	pdf := minimalPDF("Invoice 42")
+	got, err := Part(writeFile(t, "invoice.pdf", pdf))
+	if err != nil {
+		t.Fatalf("Part(pdf): %v", err)
+	}
+	if got.Type != string(pub_models.File) || got.File == nil {
+		t.Fatalf("Part(pdf) = %+v, want a file part", got)
+	}
+	if got.File.Filename != "invoice.pdf" || got.File.MIMEType() != "application/pdf" {
+		t.Fatalf("file part = %+v", got.File)
+	}
+	if raw, _ := base64.StdEncoding.DecodeString(got.File.RawB64()); !bytes.Equal(raw, pdf) {
+		t.Fatal("file data does not hold the pdf")
+	}
+	if !strings.Contains(got.File.Text, "Invoice 42") {
+		t.Fatalf("extracted text = %q", got.File.Text)
+	}
+	if !filepath.IsAbs(got.File.Path) || got.File.SHA256 != sha256Hex(pdf) {
+		t.Fatalf("file part does not reference the pdf: %+v", got.File)
+	}
+
+	got, err = Part(writeFile(t, "notes.txt", []byte("remember the milk")))
+	if err != nil {
+		t.Fatalf("Part(txt): %v", err)
+	}
+	if got.Type != "text" || !strings.Contains(got.Text, "notes.txt") || !strings.Contains(got.Text, "remember the milk") {
+		t.Fatalf("Part(txt) = %+v", got)
+	}
+
+	if _, err := Part(filepath.Join(t.TempDir(), "missing.pdf")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	pdf := minimalPDF("Invoice 42")
+	path := writeFile(t, "invoice.pdf", pdf)
+	part, err := Part(path)
+	if err != nil {
+		t.Fatalf("Part: %v", err)
+	}
+	saved := pub_models.WithoutFileData([]pub_models.Message{
+		{Role: "user", ContentParts: []pub_models.ImageOrTextInput{part, {Type: "text", Text: "summarise"}}},
+	})
+
+	got := Restore(saved)
+	if got[0].ContentParts[0].File.FileData != part.File.FileData {
+		t.Fatal("expected the document read back from its path")
+	}
+	if saved[0].ContentParts[0].File.FileData != "" {
+		t.Fatal("Restore changed its input")
+	}
+
+	if err := os.WriteFile(path, minimalPDF("Invoice 43"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Restore(saved); got[0].ContentParts[0].File.FileData != "" {
+		t.Fatal("expected a changed document to be left out")
+	}
+}
@@ -0,0 +1,254 @@
+package attachment
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxZipEntry caps the size of one decompressed file of a DOCX or XLSX
+// archive, so a zip bomb can't exhaust the memory.
+const maxZipEntry = 64 << 20
+
+// docxText returns the paragraphs of a DOCX document one a line, with the
+// cells of table rows separated by tabs.
+func docxText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	doc, err := zipFile(zr, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	inText := false
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			case "tc":
+				// The paragraph of the cell ended the line, the next cell
+				// continues it.
+				trimSuffix(&b, '\n')
+				b.WriteByte('\t')
+			case "tr":
+				trimSuffix(&b, '\t')
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n", nil
+}
+
+func trimSuffix(b *bytes.Buffer, c byte) {
+	if n := b.Len(); n > 0 && b.Bytes()[n-1] == c {
+		b.Truncate(n - 1)
+	}
+}
+
+// xlsxText returns every sheet of an XLSX workbook as CSV, each headed by
+// its name. Cells hold their cached values, formulas are not evaluated.
+func xlsxText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := unmarshalZipFile(zr, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := unmarshalZipFile(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	targets := map[string]string{}
+	for _, r := range rels.Relationships {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+	shared, err := sharedStrings(zr)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, s := range workbook.Sheets {
+		target, ok := targets[s.RID]
+		if !ok {
+			continue
+		}
+		rows, err := sheetRows(zr, target, shared)
+		if err != nil {
+			return "", fmt.Errorf("sheet %v: %w", s.Name, err)
+		}
+		fmt.Fprintf(&b, "[sheet %v]\n", s.Name)
+		w := csv.NewWriter(&b)
+		if err := w.WriteAll(rows); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// richText is a shared or inline string: plain, or runs of formatted text.
+type richText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (r richText) String() string {
+	s := r.T
+	for _, run := range r.Runs {
+		s += run.T
+	}
+	return s
+}
+
+func sharedStrings(zr *zip.Reader) ([]string, error) {
+	var sst struct {
+		Items []richText `xml:"si"`
+	}
+	if err := unmarshalZipFile(zr, "xl/sharedStrings.xml", &sst); err != nil {
+		// Workbooks of only numbers have no shared strings.
+		if errors.Is(err, errNotInArchive) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ret := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		ret[i] = si.String()
+	}
+	return ret, nil
+}
+
+func sheetRows(zr *zip.Reader, name string, shared []string) ([][]string, error) {
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline richText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := unmarshalZipFile(zr, name, &sheet); err != nil {
+		return nil, err
+	}
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, r := range sheet.Rows {
+		var row []string
+		for _, c := range r.Cells {
+			// Empty cells are left out of the XML, the reference places the
+			// cell in its column.
+			if col := column(c.Ref); col > len(row) {
+				row = append(row, make([]string, col-len(row))...)
+			}
+			v := c.Value
+			switch c.Type {
+			case "s":
+				if i, err := strconv.Atoi(v); err == nil && i >= 0 && i < len(shared) {
+					v = shared[i]
+				}
+			case "inlineStr":
+				v = c.Inline.String()
+			case "b":
+				v = strconv.FormatBool(v == "1")
+			}
+			row = append(row, v)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// column returns the zero based column of a cell reference such as "AB12",
+// or -1 without one.
+func column(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
+
+var errNotInArchive = errors.New("not in archive")
+
+func zipFile(zr *zip.Reader, name string) ([]byte, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", name, errNotInArchive)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxZipEntry+1))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	}
+	if len(data) > maxZipEntry {
+		return nil, fmt.Errorf("%v: over %d MiB decompressed", name, maxZipEntry>>20)
+	}
+	return data, nil
+}
+
+func unmarshalZipFile(zr *zip.Reader, name string, v any) error {
+	data, err := zipFile(zr, name)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%v: %w", name, err)
+	}
+	return nil
+}
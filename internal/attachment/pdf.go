@@ -0,0 +1,38 @@
+package attachment
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfText returns the text of every page of a PDF, each headed by its page
+// number so the model can cite it.
+func pdfText(data []byte) (ret string, err error) {
+	// The parser panics on some malformed documents.
+	defer func() {
+		if r := recover(); r != nil {
+			ret, err = "", fmt.Errorf("malformed pdf: %v", r)
+		}
+	}()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i)
+		if p.V.IsNull() {
+			continue
+		}
+		// Font names are per page, so each page decodes with its own fonts.
+		s, err := p.GetPlainText(nil)
+		if err != nil {
+			return "", fmt.Errorf("page %d: %w", i, err)
+		}
+		fmt.Fprintf(&b, "[page %d]\n%s\n", i, strings.TrimSpace(s))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,203 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+const (
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens is the max_tokens of requests which set none, as the
+	// Messages API requires it. It matches the default of the claude config.
+	anthropicMaxTokens = 8192
+)
+
+// anthropicClient uses the Anthropic Message Batches API.
+type anthropicClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+type anthropicParams struct {
+	Model     string          `json:"model"`
+	MaxTokens int             `json:"max_tokens"`
+	System    string          `json:"system,omitempty"`
+	Messages  []openAIMessage `json:"messages"`
+}
+
+type anthropicRequest struct {
+	CustomID string          `json:"custom_id"`
+	Params   anthropicParams `json:"params"`
+}
+
+type anthropicBatch struct {
+	ID               string `json:"id"`
+	ProcessingStatus string `json:"processing_status"`
+	ResultsURL       string `json:"results_url"`
+	RequestCounts    struct {
+		Processing int `json:"processing"`
+		Succeeded  int `json:"succeeded"`
+		Errored    int `json:"errored"`
+		Canceled   int `json:"canceled"`
+		Expired    int `json:"expired"`
+	} `json:"request_counts"`
+}
+
+// anthropicResult is one line of the results of a batch.
+type anthropicResult struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string `json:"type"` // "succeeded" | "errored" | "canceled" | "expired"
+		Message struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+			Usage struct {
+				InputTokens              int `json:"input_tokens"`
+				OutputTokens             int `json:"output_tokens"`
+				CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+				CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+			} `json:"usage"`
+		} `json:"message"`
+		Error struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"error"`
+	} `json:"result"`
+}
+
+func (c *anthropicClient) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func (c *anthropicClient) submit(ctx context.Context, model string, reqs []Request) (string, error) {
+	batch := struct {
+		Requests []anthropicRequest `json:"requests"`
+	}{}
+	for _, r := range reqs {
+		maxTokens := r.MaxTokens
+		if maxTokens == 0 {
+			maxTokens = anthropicMaxTokens
+		}
+		batch.Requests = append(batch.Requests, anthropicRequest{
+			CustomID: r.CustomID,
+			Params: anthropicParams{
+				Model:     model,
+				MaxTokens: maxTokens,
+				System:    r.System,
+				Messages:  []openAIMessage{{Role: "user", Content: r.Prompt}},
+			},
+		})
+	}
+	b, err := json.Marshal(batch)
+	if err != nil {
+		return "", fmt.Errorf("encode batch: %w", err)
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, c.baseURL+"/messages/batches", bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	var created anthropicBatch
+	if err := doJSON(c.http, req, &created); err != nil {
+		return "", fmt.Errorf("create batch: %w", err)
+	}
+	return created.ID, nil
+}
+
+func (c *anthropicClient) refresh(ctx context.Context, j *Job) error {
+	req, err := c.newRequest(ctx, http.MethodGet, c.baseURL+"/messages/batches/"+j.ID, nil)
+	if err != nil {
+		return err
+	}
+	var b anthropicBatch
+	if err := doJSON(c.http, req, &b); err != nil {
+		return fmt.Errorf("get batch: %w", err)
+	}
+	n := b.RequestCounts
+	j.Status = b.ProcessingStatus
+	j.Ended = b.ProcessingStatus == "ended"
+	j.Counts = Counts{
+		Total:     n.Processing + n.Succeeded + n.Errored + n.Canceled + n.Expired,
+		Succeeded: n.Succeeded,
+		Failed:    n.Errored + n.Canceled + n.Expired,
+	}
+	j.Output = b.ResultsURL
+	return nil
+}
+
+func (c *anthropicClient) results(ctx context.Context, j Job) ([]Result, error) {
+	if j.Output == "" {
+		return nil, nil
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, j.Output, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := do(c.http, req)
+	if err != nil {
+		return nil, fmt.Errorf("download results: %w", err)
+	}
+	var ret []Result
+	err = eachLine(body, func(line []byte) error {
+		var r anthropicResult
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("decode result: %w", err)
+		}
+		ret = append(ret, r.result())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (r anthropicResult) result() Result {
+	ret := Result{CustomID: r.CustomID}
+	switch r.Result.Type {
+	case "succeeded":
+	case "errored":
+		ret.Err = fmt.Sprintf("%s: %s", r.Result.Error.Error.Type, r.Result.Error.Error.Message)
+		return ret
+	default:
+		ret.Err = r.Result.Type
+		return ret
+	}
+	var text bytes.Buffer
+	for _, c := range r.Result.Message.Content {
+		if c.Type == "text" {
+			text.WriteString(c.Text)
+		}
+	}
+	ret.Text = text.String()
+	u := r.Result.Message.Usage
+	// Cache reads and writes are part of the prompt; reads are billed at the
+	// cached rate.
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	ret.Usage = pub_models.Usage{
+		PromptTokens:        prompt,
+		CompletionTokens:    u.OutputTokens,
+		TotalTokens:         prompt + u.OutputTokens,
+		PromptTokensDetails: pub_models.PromptTokensDetails{CachedTokens: u.CacheReadInputTokens},
+	}
+	return ret
+}
@@ -0,0 +1,193 @@
+// Package batch sends many independent prompts through the batch APIs of
+// OpenAI and Anthropic, which bill at half the interactive rate in exchange
+// for results within 24 hours.
+//
+// Submitted batches are tracked as one file each under <config>/batches, so
+// `clai batch status` and `clai batch fetch` can pick them up from any later
+// run. Fetched results are saved as native conversations.
+package batch
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// DirName is the directory of the config dir the batches are tracked in.
+const DirName = "batches"
+
+// Discount is the share of the interactive price the batch APIs bill.
+const Discount = 0.5
+
+// Request is one line of the input file of `clai batch submit`.
+type Request struct {
+	// CustomID identifies the request within its batch. Lines without one
+	// get "row-<line>".
+	CustomID string `json:"custom_id"`
+	Prompt   string `json:"prompt"`
+	// System is the system prompt of the request.
+	System string `json:"system,omitempty"`
+	// MaxTokens caps the reply. 0 uses the vendor default.
+	MaxTokens int `json:"max_tokens,omitempty"`
+}
+
+// Counts are the request counts of a batch.
+type Counts struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// Job is a submitted batch, as tracked in the config dir.
+type Job struct {
+	ID        string    `json:"id"`
+	Vendor    string    `json:"vendor"`
+	Model     string    `json:"model"`
+	Created   time.Time `json:"created"`
+	InputFile string    `json:"input_file"`
+	// Status is the status as reported by the vendor.
+	Status string `json:"status"`
+	// Ended is set once the vendor is done with the batch, successfully or
+	// not, so that its results can be fetched.
+	Ended  bool   `json:"ended"`
+	Counts Counts `json:"counts"`
+	// Output and Errors locate the results: the output and error file ids
+	// for OpenAI, the results url for Anthropic.
+	Output string `json:"output,omitempty"`
+	Errors string `json:"errors,omitempty"`
+	// Requests are kept so that results can be saved as conversations.
+	Requests []Request `json:"requests"`
+	// ChatIDs maps the custom ids of fetched results to their conversation,
+	// so fetching again overwrites instead of duplicating them.
+	ChatIDs map[string]string `json:"chat_ids,omitempty"`
+}
+
+// Result is the outcome of one request of a batch.
+type Result struct {
+	CustomID string
+	Text     string
+	Usage    pub_models.Usage
+	// Err is the reason the request failed, empty when it succeeded.
+	Err string
+}
+
+// customIDPattern is the custom id format Anthropic accepts, applied to every
+// vendor so an input file works with both.
+var customIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ReadRequests reads the JSONL input file at path. Blank lines are skipped.
+func ReadRequests(path string) ([]Request, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open input file: %w", err)
+	}
+	defer f.Close()
+
+	var ret []Request
+	seen := map[string]int{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		var r Request
+		if err := json.Unmarshal([]byte(raw), &r); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if strings.TrimSpace(r.Prompt) == "" {
+			return nil, fmt.Errorf("line %d: missing prompt", line)
+		}
+		if r.CustomID == "" {
+			r.CustomID = fmt.Sprintf("row-%d", line)
+		}
+		if !customIDPattern.MatchString(r.CustomID) {
+			return nil, fmt.Errorf("line %d: custom_id %q must be 1-64 letters, digits, '_' or '-'", line, r.CustomID)
+		}
+		if prev, ok := seen[r.CustomID]; ok {
+			return nil, fmt.Errorf("line %d: custom_id %q already used on line %d", line, r.CustomID, prev)
+		}
+		seen[r.CustomID] = line
+		ret = append(ret, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read input file: %w", err)
+	}
+	if len(ret) == 0 {
+		return nil, errors.New("input file holds no requests")
+	}
+	return ret, nil
+}
+
+func jobPath(confDir, id string) string {
+	return filepath.Join(confDir, DirName, id+".json")
+}
+
+// Save writes the job to the config dir confDir.
+func (j Job) Save(confDir string) error {
+	if err := os.MkdirAll(filepath.Join(confDir, DirName), 0o755); err != nil {
+		return fmt.Errorf("create batches dir: %w", err)
+	}
+	b, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal batch: %w", err)
+	}
+	if err := os.WriteFile(jobPath(confDir, j.ID), b, 0o644); err != nil {
+		return fmt.Errorf("write batch: %w", err)
+	}
+	return nil
+}
+
+// Load reads the job id from the config dir confDir.
+func Load(confDir, id string) (Job, error) {
+	if filepath.Base(id) != id || id == "." || id == ".." {
+		return Job{}, fmt.Errorf("invalid batch id %q", id)
+	}
+	b, err := os.ReadFile(jobPath(confDir, id))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Job{}, fmt.Errorf("batch %q is not tracked, see 'clai batch status'", id)
+		}
+		return Job{}, fmt.Errorf("read batch: %w", err)
+	}
+	var j Job
+	if err := json.Unmarshal(b, &j); err != nil {
+		return Job{}, fmt.Errorf("unmarshal batch %q: %w", id, err)
+	}
+	return j, nil
+}
+
+// List returns the jobs of the config dir confDir, newest first.
+func List(confDir string) ([]Job, error) {
+	entries, err := os.ReadDir(filepath.Join(confDir, DirName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list batches: %w", err)
+	}
+	var ret []Job
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		j, err := Load(confDir, strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, j)
+	}
+	sort.Slice(ret, func(i, k int) bool { return ret[i].Created.After(ret[k].Created) })
+	return ret, nil
+}
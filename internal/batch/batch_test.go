@@ -0,0 +1,266 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baalimago/clai/internal/chat"
+	"github.com/baalimago/clai/internal/cost"
+	"github.com/baalimago/clai/internal/modelcatalog"
+	"github.com/baalimago/clai/internal/routing"
+)
+
+func writeInput(t *testing.T, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "in.jsonl")
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// serveVendor points vendor at handler for the duration of the test.
+func serveVendor(t *testing.T, vendor string, handler http.HandlerFunc) string {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	prev := baseURLs[vendor]
+	baseURLs[vendor] = srv.URL
+	t.Cleanup(func() { baseURLs[vendor] = prev })
+	return srv.URL
+}
+
+func priceModel(t *testing.T, confDir, vendor, model string, price cost.ModelPriceScheme) {
+	t.Helper()
+	var c modelcatalog.Cache
+	c.Set(vendor, []modelcatalog.Model{{ID: model, Price: &price}}, time.Now())
+	if err := c.Save(confDir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadRequests(t *testing.T) {
+	t.Run("defaults custom ids to the line", func(t *testing.T) {
+		got, err := ReadRequests(writeInput(t, `{"prompt":"a"}
+
+{"custom_id":"b","prompt":"b","system":"s","max_tokens":10}
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 || got[0].CustomID != "row-1" || got[1].CustomID != "b" || got[1].System != "s" || got[1].MaxTokens != 10 {
+			t.Fatalf("unexpected requests: %+v", got)
+		}
+	})
+
+	for name, tc := range map[string]struct{ input, wantErr string }{
+		"duplicate id":   {`{"custom_id":"a","prompt":"x"}` + "\n" + `{"custom_id":"a","prompt":"y"}`, "already used on line 1"},
+		"invalid id":     {`{"custom_id":"a b","prompt":"x"}`, "must be 1-64"},
+		"missing prompt": {`{"custom_id":"a"}`, "missing prompt"},
+		"invalid json":   {`{`, "line 1"},
+		"empty":          {"\n\n", "no requests"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadRequests(writeInput(t, tc.input))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsPaths(t *testing.T) {
+	if _, err := Load(t.TempDir(), "../x"); err == nil || !strings.Contains(err.Error(), "invalid batch id") {
+		t.Fatalf("expected invalid batch id error, got %v", err)
+	}
+}
+
+func TestListNewestFirst(t *testing.T) {
+	confDir := t.TempDir()
+	now := time.Now()
+	for i, id := range []string{"old", "new"} {
+		if err := (Job{ID: id, Created: now.Add(time.Duration(i) * time.Hour)}).Save(confDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jobs, err := List(confDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != "new" || jobs[1].ID != "old" {
+		t.Fatalf("unexpected order: %+v", jobs)
+	}
+}
+
+func TestUnsupportedVendor(t *testing.T) {
+	_, err := Submit(context.Background(), t.TempDir(), "mistral-large-latest", writeInput(t, `{"prompt":"x"}`))
+	if err == nil || !strings.Contains(err.Error(), "has no batch API") {
+		t.Fatalf("expected unsupported vendor error, got %v", err)
+	}
+}
+
+func TestOpenAIRoundTrip(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "secret")
+	t.Setenv("OPENROUTER_API_KEY", "")
+	confDir := t.TempDir()
+	priceModel(t, confDir, routing.VendorOpenAI, "gpt-4o-mini", cost.ModelPriceScheme{InputUSDPerToken: 1, OutputUSDPerToken: 2})
+
+	var uploaded string
+	status := "in_progress"
+	serveVendor(t, routing.VendorOpenAI, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected bearer auth, got %q", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/files":
+			if r.FormValue("purpose") != "batch" {
+				t.Errorf("expected purpose batch, got %q", r.FormValue("purpose"))
+			}
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(f)
+			uploaded = string(b)
+			w.Write([]byte(`{"id":"file-in"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/batches":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["input_file_id"] != "file-in" || body["endpoint"] != openAIEndpoint {
+				t.Errorf("unexpected batch body: %v", body)
+			}
+			w.Write([]byte(`{"id":"batch_1","status":"validating"}`))
+		case r.URL.Path == "/batches/batch_1":
+			w.Write([]byte(`{"id":"batch_1","status":"` + status + `","output_file_id":"file-out","error_file_id":"file-err","request_counts":{"total":2,"completed":1,"failed":1}}`))
+		case r.URL.Path == "/files/file-out/content":
+			w.Write([]byte(`{"custom_id":"a","response":{"status_code":200,"body":{"choices":[{"message":{"content":"hello"}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}}}` + "\n"))
+		case r.URL.Path == "/files/file-err/content":
+			w.Write([]byte(`{"custom_id":"b","response":{"status_code":400,"body":{"error":{"message":"bad"}}}}` + "\n"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	in := writeInput(t, `{"custom_id":"a","prompt":"hi","system":"be brief"}`+"\n"+`{"custom_id":"b","prompt":"fail"}`)
+	j, err := Submit(context.Background(), confDir, "gpt-4o-mini", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.ID != "batch_1" || j.Vendor != routing.VendorOpenAI || j.Counts.Total != 2 {
+		t.Fatalf("unexpected job: %+v", j)
+	}
+	if !strings.Contains(uploaded, `"url":"/v1/chat/completions"`) || !strings.Contains(uploaded, `"role":"system","content":"be brief"`) {
+		t.Fatalf("unexpected upload: %s", uploaded)
+	}
+
+	if _, err := Fetch(context.Background(), confDir, "batch_1"); err == nil || !strings.Contains(err.Error(), "still in_progress") {
+		t.Fatalf("expected not ended error, got %v", err)
+	}
+
+	status = "completed"
+	got, err := Fetch(context.Background(), confDir, "batch_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Text != "hello" || got[0].ChatID == "" || got[1].Err != "status 400: bad" || got[1].ChatID != "" {
+		t.Fatalf("unexpected results: %+v", got)
+	}
+
+	c, err := chat.NewFileStore(confDir).Load(got[0].ChatID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Messages) != 3 || c.Messages[0].Role != "system" || c.Messages[2].Content != "hello" {
+		t.Fatalf("unexpected conversation: %+v", c.Messages)
+	}
+	if len(c.Queries) != 1 || math.Abs(c.Queries[0].CostUSD-(10+5*2)*Discount) > 1e-9 {
+		t.Fatalf("expected discounted cost, got %+v", c.Queries)
+	}
+
+	again, err := Fetch(context.Background(), confDir, "batch_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[0].ChatID != got[0].ChatID {
+		t.Fatalf("expected fetching again to reuse chat %q, got %q", got[0].ChatID, again[0].ChatID)
+	}
+}
+
+// TestAnthropicRoundTrip prices the result without a cached catalog, from the
+// price a query cached in the model config, and saves it in the configured
+// conversation store.
+func TestAnthropicRoundTrip(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "secret")
+	t.Setenv("OPENROUTER_API_KEY", "")
+	confDir := t.TempDir()
+	for name, content := range map[string]string{
+		"textConfig.json": `{"conversation-store": "sqlite"}`,
+		"anthropic_claude_claude-haiku-4-5.json": `{"model": "claude-haiku-4-5",
+			"price": {"input_usd_per_token": 1, "input_cached_usd_per_token": 0.5, "output_usd_per_token": 2}}`,
+	} {
+		if err := os.WriteFile(filepath.Join(confDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var url string
+	url = serveVendor(t, routing.VendorAnthropic, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "secret" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("unexpected auth headers: %v", r.Header)
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/messages/batches":
+			var body struct {
+				Requests []anthropicRequest `json:"requests"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if len(body.Requests) != 1 || body.Requests[0].Params.MaxTokens != anthropicMaxTokens || body.Requests[0].Params.Model != "claude-haiku-4-5" {
+				t.Errorf("unexpected batch body: %+v", body)
+			}
+			w.Write([]byte(`{"id":"msgbatch_1","processing_status":"in_progress"}`))
+		case r.URL.Path == "/messages/batches/msgbatch_1":
+			w.Write([]byte(`{"id":"msgbatch_1","processing_status":"ended","results_url":"` + url + `/results","request_counts":{"succeeded":1}}`))
+		case r.URL.Path == "/results":
+			w.Write([]byte(`{"custom_id":"row-1","result":{"type":"succeeded","message":{"content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":3,"cache_read_input_tokens":4,"output_tokens":2}}}}` + "\n"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	if _, err := Submit(context.Background(), confDir, "haiku", writeInput(t, `{"prompt":"hello"}`)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Fetch(context.Background(), confDir, "msgbatch_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Text != "hi" || got[0].Usage.PromptTokens != 7 || got[0].Usage.PromptTokensDetails.CachedTokens != 4 {
+		t.Fatalf("unexpected results: %+v", got)
+	}
+	store, err := chat.OpenStore(confDir, chat.BackendSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := store.Load(got[0].ChatID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 input tokens, 4 cached ones at half price and 2 output tokens.
+	if len(c.Messages) != 2 || len(c.Queries) != 1 || math.Abs(c.Queries[0].CostUSD-(3+4*0.5+2*2)*Discount) > 1e-9 {
+		t.Fatalf("expected a discounted two message conversation, got %+v", c)
+	}
+	if _, err := os.Stat(filepath.Join(confDir, "conversations", got[0].ChatID+".json")); !os.IsNotExist(err) {
+		t.Fatalf("expected no conversation file with the sqlite store, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/baalimago/clai/internal/routing"
+)
+
+// client talks to the batch API of one vendor.
+type client interface {
+	// submit creates a batch of reqs and returns its id.
+	submit(ctx context.Context, model string, reqs []Request) (string, error)
+	// refresh updates the status, counts and result locations of j.
+	refresh(ctx context.Context, j *Job) error
+	// results downloads the results of the ended batch j.
+	results(ctx context.Context, j Job) ([]Result, error)
+}
+
+// baseURLs are the API roots of the supported vendors.
+var baseURLs = map[string]string{
+	routing.VendorOpenAI:    "https://api.openai.com/v1",
+	routing.VendorAnthropic: "https://api.anthropic.com/v1",
+}
+
+// clientFor returns the client of vendor, keyed by its API key env var.
+func clientFor(vendor string) (client, error) {
+	switch vendor {
+	case routing.VendorOpenAI:
+		key := os.Getenv("OPENAI_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("openai: missing OPENAI_API_KEY")
+		}
+		return &openAIClient{baseURL: baseURLs[vendor], apiKey: key, http: http.DefaultClient}, nil
+	case routing.VendorAnthropic:
+		key := os.Getenv("ANTHROPIC_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("anthropic: missing ANTHROPIC_API_KEY")
+		}
+		return &anthropicClient{baseURL: baseURLs[vendor], apiKey: key, http: http.DefaultClient}, nil
+	default:
+		return nil, fmt.Errorf("vendor %q has no batch API, use an openai or anthropic model", vendor)
+	}
+}
+
+// doJSON sends req and decodes the JSON reply into out.
+func doJSON(hc *http.Client, req *http.Request, out any) error {
+	body, err := do(hc, req)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	return nil
+}
+
+// do sends req and returns the reply body, or an error for a non-2xx status.
+func do(hc *http.Client, req *http.Request) ([]byte, error) {
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: unexpected status code %v, body: %s", req.Method, req.URL.Path, res.Status, string(body))
+	}
+	return body, nil
+}
+
+// eachLine calls fn with every non-blank line of the JSONL body.
+func eachLine(body []byte, fn func([]byte) error) error {
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("read results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// openAIEndpoint is the endpoint every request of a batch is sent to.
+const openAIEndpoint = "/v1/chat/completions"
+
+// openAIClient uses the OpenAI Batch API: the requests are uploaded as a
+// JSONL file, which a batch is then created from.
+type openAIClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIBody struct {
+	Model               string          `json:"model"`
+	Messages            []openAIMessage `json:"messages"`
+	MaxCompletionTokens int             `json:"max_completion_tokens,omitempty"`
+}
+
+type openAILine struct {
+	CustomID string     `json:"custom_id"`
+	Method   string     `json:"method"`
+	URL      string     `json:"url"`
+	Body     openAIBody `json:"body"`
+}
+
+type openAIBatch struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	OutputFileID  string `json:"output_file_id"`
+	ErrorFileID   string `json:"error_file_id"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+}
+
+// openAIResult is one line of the output or error file of a batch.
+type openAIResult struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int `json:"status_code"`
+		Body       struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+			Usage pub_models.Usage `json:"usage"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *openAIClient) newRequest(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+func (c *openAIClient) submit(ctx context.Context, model string, reqs []Request) (string, error) {
+	var lines bytes.Buffer
+	enc := json.NewEncoder(&lines)
+	for _, r := range reqs {
+		var msgs []openAIMessage
+		if r.System != "" {
+			msgs = append(msgs, openAIMessage{Role: "system", Content: r.System})
+		}
+		msgs = append(msgs, openAIMessage{Role: "user", Content: r.Prompt})
+		line := openAILine{
+			CustomID: r.CustomID,
+			Method:   http.MethodPost,
+			URL:      openAIEndpoint,
+			Body:     openAIBody{Model: model, Messages: msgs, MaxCompletionTokens: r.MaxTokens},
+		}
+		if err := enc.Encode(line); err != nil {
+			return "", fmt.Errorf("encode request %q: %w", r.CustomID, err)
+		}
+	}
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	if err := mw.WriteField("purpose", "batch"); err != nil {
+		return "", fmt.Errorf("write purpose: %w", err)
+	}
+	fw, err := mw.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("create file part: %w", err)
+	}
+	if _, err := fw.Write(lines.Bytes()); err != nil {
+		return "", fmt.Errorf("write file part: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return "", fmt.Errorf("close form: %w", err)
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/files", &form, mw.FormDataContentType())
+	if err != nil {
+		return "", err
+	}
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := doJSON(c.http, req, &file); err != nil {
+		return "", fmt.Errorf("upload input file: %w", err)
+	}
+
+	create, err := json.Marshal(map[string]string{
+		"input_file_id":     file.ID,
+		"endpoint":          openAIEndpoint,
+		"completion_window": "24h",
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode batch: %w", err)
+	}
+	req, err = c.newRequest(ctx, http.MethodPost, "/batches", bytes.NewReader(create), "application/json")
+	if err != nil {
+		return "", err
+	}
+	var b openAIBatch
+	if err := doJSON(c.http, req, &b); err != nil {
+		return "", fmt.Errorf("create batch: %w", err)
+	}
+	return b.ID, nil
+}
+
+func (c *openAIClient) refresh(ctx context.Context, j *Job) error {
+	req, err := c.newRequest(ctx, http.MethodGet, "/batches/"+j.ID, nil, "")
+	if err != nil {
+		return err
+	}
+	var b openAIBatch
+	if err := doJSON(c.http, req, &b); err != nil {
+		return fmt.Errorf("get batch: %w", err)
+	}
+	j.Status = b.Status
+	switch b.Status {
+	case "completed", "failed", "expired", "cancelled":
+		j.Ended = true
+	}
+	j.Counts = Counts{Total: b.RequestCounts.Total, Succeeded: b.RequestCounts.Completed, Failed: b.RequestCounts.Failed}
+	j.Output = b.OutputFileID
+	j.Errors = b.ErrorFileID
+	return nil
+}
+
+func (c *openAIClient) results(ctx context.Context, j Job) ([]Result, error) {
+	var ret []Result
+	for _, fileID := range []string{j.Output, j.Errors} {
+		if fileID == "" {
+			continue
+		}
+		req, err := c.newRequest(ctx, http.MethodGet, "/files/"+fileID+"/content", nil, "")
+		if err != nil {
+			return nil, err
+		}
+		body, err := do(c.http, req)
+		if err != nil {
+			return nil, fmt.Errorf("download results: %w", err)
+		}
+		err = eachLine(body, func(line []byte) error {
+			var r openAIResult
+			if err := json.Unmarshal(line, &r); err != nil {
+				return fmt.Errorf("decode result: %w", err)
+			}
+			ret = append(ret, r.result())
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func (r openAIResult) result() Result {
+	ret := Result{CustomID: r.CustomID}
+	switch {
+	case r.Error != nil:
+		ret.Err = fmt.Sprintf("%s: %s", r.Error.Code, r.Error.Message)
+	case r.Response == nil:
+		ret.Err = "missing response"
+	case r.Response.Body.Error != nil:
+		ret.Err = fmt.Sprintf("status %d: %s", r.Response.StatusCode, r.Response.Body.Error.Message)
+	case r.Response.StatusCode != http.StatusOK || len(r.Response.Body.Choices) == 0:
+		ret.Err = fmt.Sprintf("status %d without a reply", r.Response.StatusCode)
+	default:
+		ret.Text = r.Response.Body.Choices[0].Message.Content
+		ret.Usage = r.Response.Body.Usage
+	}
+	return ret
+}
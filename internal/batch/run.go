@@ -0,0 +1,197 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/baalimago/clai/internal/chat"
+	"github.com/baalimago/clai/internal/chatid"
+	"github.com/baalimago/clai/internal/cost"
+	"github.com/baalimago/clai/internal/modelcatalog"
+	"github.com/baalimago/clai/internal/routing"
+	"github.com/baalimago/clai/internal/text"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// Fetched is a result of a batch, with the conversation it was saved as.
+type Fetched struct {
+	Result
+	// ChatID is empty when the request failed.
+	ChatID string
+}
+
+// Submit sends the requests of the input file at inputPath to the batch API
+// of the vendor model routes to, and tracks the batch in confDir.
+func Submit(ctx context.Context, confDir, model, inputPath string) (Job, error) {
+	res, err := routing.Resolve(model)
+	if err != nil {
+		return Job{}, fmt.Errorf("resolve model: %w", err)
+	}
+	c, err := clientFor(res.Vendor)
+	if err != nil {
+		return Job{}, err
+	}
+	reqs, err := ReadRequests(inputPath)
+	if err != nil {
+		return Job{}, fmt.Errorf("read requests: %w", err)
+	}
+	id, err := c.submit(ctx, res.Model, reqs)
+	if err != nil {
+		return Job{}, fmt.Errorf("%s: submit batch: %w", res.Vendor, err)
+	}
+	if abs, err := filepath.Abs(inputPath); err == nil {
+		inputPath = abs
+	}
+	j := Job{
+		ID:        id,
+		Vendor:    res.Vendor,
+		Model:     res.Model,
+		Created:   time.Now(),
+		InputFile: inputPath,
+		Status:    "submitted",
+		Counts:    Counts{Total: len(reqs)},
+		Requests:  reqs,
+	}
+	if err := j.Save(confDir); err != nil {
+		return Job{}, fmt.Errorf("track batch %q: %w", id, err)
+	}
+	return j, nil
+}
+
+// Refresh updates j from its vendor unless it already ended, and saves it.
+func Refresh(ctx context.Context, confDir string, j *Job) error {
+	if j.Ended {
+		return nil
+	}
+	c, err := clientFor(j.Vendor)
+	if err != nil {
+		return err
+	}
+	if err := c.refresh(ctx, j); err != nil {
+		return fmt.Errorf("%s: refresh batch %q: %w", j.Vendor, j.ID, err)
+	}
+	return j.Save(confDir)
+}
+
+// Fetch downloads the results of the ended batch id and saves each succeeded
+// request as a conversation of confDir. Fetching again overwrites the
+// conversations of the previous fetch.
+func Fetch(ctx context.Context, confDir, id string) ([]Fetched, error) {
+	j, err := Load(confDir, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := Refresh(ctx, confDir, &j); err != nil {
+		return nil, err
+	}
+	if !j.Ended {
+		return nil, fmt.Errorf("batch %q is still %s (%d/%d done), try again later", j.ID, j.Status, j.Counts.Succeeded+j.Counts.Failed, j.Counts.Total)
+	}
+	c, err := clientFor(j.Vendor)
+	if err != nil {
+		return nil, err
+	}
+	results, err := c.results(ctx, j)
+	if err != nil {
+		return nil, fmt.Errorf("%s: fetch batch %q: %w", j.Vendor, j.ID, err)
+	}
+
+	price, priced := modelPrice(ctx, confDir, j.Vendor, j.Model)
+	requests := make(map[string]Request, len(j.Requests))
+	for _, r := range j.Requests {
+		requests[r.CustomID] = r
+	}
+	if j.ChatIDs == nil {
+		j.ChatIDs = map[string]string{}
+	}
+	store, err := chat.OpenStore(confDir, chat.ConfiguredBackend(confDir))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]Fetched, 0, len(results))
+	for _, r := range results {
+		f := Fetched{Result: r}
+		req, ok := requests[r.CustomID]
+		if r.Err == "" && !ok {
+			f.Err = "unknown custom_id"
+		}
+		if f.Err != "" {
+			ret = append(ret, f)
+			continue
+		}
+		chatID := j.ChatIDs[r.CustomID]
+		if chatID == "" {
+			if chatID, err = chatid.New(); err != nil {
+				return nil, fmt.Errorf("new chat id: %w", err)
+			}
+		}
+		conv := conversation(chatID, j.Model, req, r)
+		if priced {
+			conv.Queries[0].CostUSD = price.Estimate(r.Usage) * Discount
+		} else {
+			conv.Queries = nil
+		}
+		if err := store.Save(conv); err != nil {
+			return nil, fmt.Errorf("save result %q: %w", r.CustomID, err)
+		}
+		j.ChatIDs[r.CustomID] = chatID
+		f.ChatID = chatID
+		ret = append(ret, f)
+	}
+	if err := j.Save(confDir); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// conversation returns the succeeded result r of req as a conversation,
+// with a query cost entry left for the caller to price.
+func conversation(id, model string, req Request, r Result) pub_models.Chat {
+	now := time.Now()
+	var msgs []pub_models.Message
+	if req.System != "" {
+		msgs = append(msgs, pub_models.Message{Role: "system", Content: req.System})
+	}
+	msgs = append(msgs,
+		pub_models.Message{Role: "user", Content: req.Prompt},
+		pub_models.Message{Role: "assistant", Content: r.Text},
+	)
+	usage := r.Usage
+	return pub_models.Chat{
+		Created:          now,
+		ID:               id,
+		Messages:         msgs,
+		TokenUsage:       &usage,
+		RecentTokenUsage: &usage,
+		Queries: []pub_models.QueryCost{{
+			CreatedAt:      now,
+			MessageTrigger: len(msgs) - 2,
+			Model:          model,
+			Usage:          usage,
+		}},
+	}
+}
+
+// modelPrice returns the interactive price of model: the one a query with it
+// resolves, else the one of the cached catalog of vendor (see `clai models`).
+func modelPrice(ctx context.Context, confDir, vendor, model string) (cost.ModelPriceScheme, bool) {
+	if price, err := text.ModelPrice(ctx, confDir, model); err == nil && price.HasAnyPricing() {
+		return price, true
+	}
+	return catalogPrice(confDir, vendor, model)
+}
+
+func catalogPrice(confDir, vendor, model string) (cost.ModelPriceScheme, bool) {
+	cache, err := modelcatalog.Load(confDir)
+	if err != nil {
+		return cost.ModelPriceScheme{}, false
+	}
+	for _, m := range cache.Vendors[vendor].Models {
+		if m.ID == model && m.Price != nil && m.Price.HasAnyPricing() {
+			return *m.Price, true
+		}
+	}
+	return cost.ModelPriceScheme{}, false
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/baalimago/clai/internal/batch"
+	"github.com/baalimago/clai/internal/models"
+	"github.com/baalimago/clai/internal/text"
+	"github.com/baalimago/clai/internal/utils"
+	"github.com/baalimago/go_away_boilerplate/pkg/table"
+)
+
+const batchUsage = "usage: clai [-cm <model>] batch submit <file.jsonl> | status [batch-id] | fetch <batch-id>"
+
+// batchCmd handles `clai batch submit|status|fetch`. args[0] is the command.
+func batchCmd(ctx context.Context, confDir string, flagSet Configurations, args []string) (models.Querier, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("missing subcommand, %s", batchUsage)
+	}
+	switch sub := args[1]; {
+	case sub == "submit" && len(args) == 3:
+		model := flagSet.ChatModel
+		if model == "" {
+			tConf, err := utils.LoadConfigFromFile(confDir, "textConfig.json", migrateOldChatConfig, &text.Default)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load textConfig.json: %w", err)
+			}
+			model = tConf.Model
+		}
+		j, err := batch.Submit(ctx, confDir, model, args[2])
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("submitted %d requests to %s as batch %s\ncheck on it with: clai batch status %s\n", len(j.Requests), j.Model, j.ID, j.ID)
+	case sub == "status" && len(args) <= 3:
+		var jobs []batch.Job
+		if len(args) == 3 {
+			j, err := batch.Load(confDir, args[2])
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, j)
+		} else {
+			var err error
+			if jobs, err = batch.List(confDir); err != nil {
+				return nil, err
+			}
+		}
+		for i := range jobs {
+			if err := batch.Refresh(ctx, confDir, &jobs[i]); err != nil {
+				return nil, err
+			}
+		}
+		if err := writeBatches(os.Stdout, jobs); err != nil {
+			return nil, fmt.Errorf("failed to print batches: %w", err)
+		}
+	case sub == "fetch" && len(args) == 3:
+		fetched, err := batch.Fetch(ctx, confDir, args[2])
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFetched(os.Stdout, fetched); err != nil {
+			return nil, fmt.Errorf("failed to print results: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected arguments %q, %s", args[1:], batchUsage)
+	}
+	return nil, table.ErrUserInitiatedExit
+}
+
+func writeBatches(out io.Writer, jobs []batch.Job) error {
+	if len(jobs) == 0 {
+		_, err := fmt.Fprintln(out, "No batches. Submit one with: clai batch submit <file.jsonl>")
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tVENDOR\tMODEL\tSTATUS\tSUCCEEDED\tFAILED\tTOTAL\tCREATED")
+	for _, j := range jobs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\n", j.ID, j.Vendor, j.Model, j.Status,
+			j.Counts.Succeeded, j.Counts.Failed, j.Counts.Total, j.Created.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+// writeFetched prints one JSON line per result, so that the replies can be
+// piped on while the conversations stay browsable with `clai chat list`.
+func writeFetched(out io.Writer, fetched []batch.Fetched) error {
+	enc := json.NewEncoder(out)
+	for _, f := range fetched {
+		line := struct {
+			CustomID string `json:"custom_id"`
+			ChatID   string `json:"chat_id,omitempty"`
+			Content  string `json:"content,omitempty"`
+			Error    string `json:"error,omitempty"`
+		}{f.CustomID, f.ChatID, f.Text, f.Err}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/baalimago/clai/internal/checkpoint"
 	"github.com/baalimago/clai/internal/debugflags"
 	pub_models "github.com/baalimago/clai/pkg/text/models"
 	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
@@ -41,11 +42,48 @@ func FromPath(path string) (pub_models.Chat, error) {
 	return chat, nil
 }
 
+// Persistence carries the per-session conversation persistence settings so
+// that concurrent embedded agents can differ without touching package state.
+// The zero value follows the process defaults.
+type Persistence struct {
+	// SkipIndex disables chat index I/O for this session, in addition to
+	// the process-wide SkipIndex.
+	SkipIndex bool
+	// Store replaces the filesystem under the config dir for every save,
+	// load and directory binding of this session. Nil keeps the files.
+	Store pub_models.ConversationStore
+}
+
+func (p Persistence) skipIndex() bool {
+	return p.SkipIndex || SkipIndex
+}
+
+// Save the chat as <saveAt>/<chat.ID>.json using the process defaults.
 func Save(saveAt string, chat pub_models.Chat) error {
+	return Persistence{}.Save(saveAt, chat)
+}
+
+// Save the chat in the session store, or in <saveAt> when there is none.
+func (p Persistence) Save(saveAt string, chat pub_models.Chat) error {
+	if p.Store != nil {
+		if chat.GroupKey == "" {
+			chat.GroupKey = ComputeGroupKey(chat)
+		}
+		traceChatf("saving chat to store chat_id=%q messages=%d", chat.ID, len(chat.Messages))
+		return p.Store.Save(chat)
+	}
+	return p.saveFile(saveAt, chat)
+}
+
+// saveFile writes the chat as <saveAt>/<chat.ID>.json and updates the chat
+// index unless the session skips it.
+func (p Persistence) saveFile(saveAt string, chat pub_models.Chat) error {
 	// Stamp GroupKey once on first persist (never overwritten).
 	if chat.GroupKey == "" {
 		chat.GroupKey = ComputeGroupKey(chat)
 	}
+	// The documents are referenced by path, see attachment.Restore.
+	chat.Messages = pub_models.WithoutFileData(chat.Messages)
 	b, err := json.MarshalIndent(chat, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to encode JSON: %w", err)
@@ -59,8 +97,10 @@ func Save(saveAt string, chat pub_models.Chat) error {
 	if err := os.WriteFile(fileName, b, 0o644); err != nil {
 		return fmt.Errorf("failed to write chat file: %w", err)
 	}
-	if err := upsertChatIndex(saveAt, chat); err != nil {
-		return fmt.Errorf("failed to update chat index: %w", err)
+	if !p.skipIndex() {
+		if err := upsertChatIndex(saveAt, chat); err != nil {
+			return fmt.Errorf("failed to update chat index: %w", err)
+		}
 	}
 	// Best-effort: persist out-of-band reasoning items. The conversation is already
 	// saved; a sidecar failure only costs reasoning continuity, not the chat.
@@ -69,3 +109,60 @@ func Save(saveAt string, chat pub_models.Chat) error {
 	}
 	return nil
 }
+
+// sqlite returns the session store when it is the SQLite store, which also
+// answers the chat list, lookback search and directory history. Nil means
+// the JSON files (or a store without those queries).
+func (p Persistence) sqlite() *SQLiteStore {
+	store, _ := p.Store.(*SQLiteStore)
+	return store
+}
+
+// loadConversation reads the conversation id from the SQLite store, or from
+// the conversations dir convDir when store is nil. A missing conversation
+// wraps fs.ErrNotExist either way.
+func loadConversation(store *SQLiteStore, convDir, id string) (pub_models.Chat, error) {
+	if store != nil {
+		return store.Load(id)
+	}
+	return FromPath(conversationPathFromDir(convDir, id))
+}
+
+// deleteConversation removes the conversation id from the SQLite store, or
+// from the conversations dir convDir (with its reasoning sidecars) when store
+// is nil.
+func deleteConversation(store *SQLiteStore, convDir, id string) error {
+	if store != nil {
+		if err := store.Delete(id); err != nil {
+			return err
+		}
+		removeCheckpoints(convDir, id)
+		return nil
+	}
+	if err := os.Remove(conversationPathFromDir(convDir, id)); err != nil {
+		return err
+	}
+	// Best-effort GC of the chat's reasoning sidecar directory.
+	if err := removeReasoningSidecars(convDir, id); err != nil {
+		ancli.Warnf("failed to remove reasoning sidecar for chat %q: %v", id, err)
+	}
+	removeCheckpoints(convDir, id)
+	return nil
+}
+
+// removeCheckpoints drops the file checkpoints of the deleted chat id, which
+// `clai undo` can no longer be pointed at.
+func removeCheckpoints(convDir, id string) {
+	if err := checkpoint.New(filepath.Dir(convDir)).Remove(id); err != nil {
+		ancli.Warnf("failed to remove checkpoints of chat %q: %v", id, err)
+	}
+}
+
+// conversationLocation describes where the conversation id is stored, for
+// output pointing the user at it: the SQLite database, or the JSON file.
+func conversationLocation(store *SQLiteStore, confDir, id string) string {
+	if store != nil {
+		return fmt.Sprintf("%s (chat %s)", store.Path(), id)
+	}
+	return conversationPath(confDir, id)
+}
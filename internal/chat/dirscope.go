@@ -94,7 +94,7 @@ func (cq *ChatHandler) LoadDirScope(dir string) (DirScope, error) {
 	if dir == "" {
 		return cq.LoadDirScopeFromCWD()
 	}
-	return loadDirScope(cq.confDir, dir)
+	return loadDirScope(cq.persistence.sqlite(), cq.confDir, dir)
 }
 
 func (cq *ChatHandler) LoadDirScopeFromCWD() (DirScope, error) {
@@ -102,14 +102,16 @@ func (cq *ChatHandler) LoadDirScopeFromCWD() (DirScope, error) {
 	if err != nil {
 		return DirScope{}, err
 	}
-	return loadDirScope(cq.confDir, wd)
+	return loadDirScope(cq.persistence.sqlite(), cq.confDir, wd)
 }
 
-func loadDirScope(confDir, dir string) (DirScope, error) {
-	return loadDirScopeForDir(confDir, dir)
+// loadDirScope reads the binding of dir from the SQLite store, or from the
+// dirscope files under confDir when store is nil.
+func loadDirScope(store *SQLiteStore, confDir, dir string) (DirScope, error) {
+	return loadDirScopeForDir(store, confDir, dir)
 }
 
-func loadDirScopeForDir(confDir, dir string) (DirScope, error) {
+func loadDirScopeForDir(store *SQLiteStore, confDir, dir string) (DirScope, error) {
 	if dir == "" {
 		return DirScope{}, fmt.Errorf("directory is empty")
 	}
@@ -117,6 +119,9 @@ func loadDirScopeForDir(confDir, dir string) (DirScope, error) {
 	if err != nil {
 		return DirScope{}, fmt.Errorf("canonicalize directory %q: %w", dir, err)
 	}
+	if store != nil {
+		return store.loadDirScope(dirHash(canonical))
+	}
 	bindingPath := dirscopePath(confDir, dirHash(canonical))
 
 	b, err := os.ReadFile(bindingPath)
@@ -135,10 +140,13 @@ func loadDirScopeForDir(confDir, dir string) (DirScope, error) {
 // it sets the head ChatID, refreshes abs_path + updated, upserts the chat into
 // the capped newest-first history, and persists atomically (temp + rename).
 func (cq *ChatHandler) SaveDirScope(dir, chatID string) error {
-	return saveDirScope(cq.confDir, dir, chatID)
+	return saveDirScope(cq.persistence.sqlite(), cq.confDir, dir, chatID)
 }
 
-func saveDirScope(confDir, dir, chatID string) error {
+func saveDirScope(store *SQLiteStore, confDir, dir, chatID string) error {
+	if store != nil {
+		return store.BindDir(dir, chatID)
+	}
 	canonical, err := canonicalDir(dir)
 	if err != nil {
 		return fmt.Errorf("canonicalize directory %q: %w", dir, err)
@@ -147,12 +155,16 @@ func saveDirScope(confDir, dir, chatID string) error {
 		return fmt.Errorf("ensure dirscope root %q: %w", dirscopeRoot(confDir), err)
 	}
 
-	binding, err := loadDirScopeForDir(confDir, canonical)
+	binding, err := loadDirScopeForDir(nil, confDir, canonical)
 	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return fmt.Errorf("load existing binding: %w", err)
 	}
+	return persistDirScope(confDir, nextDirScope(binding, canonical, chatID, time.Now().UTC()))
+}
 
-	now := time.Now().UTC()
+// nextDirScope promotes binding to version 2 with chatID as head: it refreshes
+// abs_path + updated and upserts the chat into the capped newest-first history.
+func nextDirScope(binding DirScope, canonical, chatID string, now time.Time) DirScope {
 	binding.Version = 2
 	binding.DirHash = dirHash(canonical)
 	binding.AbsPath = canonical
@@ -161,8 +173,7 @@ func saveDirScope(confDir, dir, chatID string) error {
 	binding.Updated = now
 
 	debugDirscopef("saving binding for %q: chat_id=%s history=%d", canonical, chatID, len(binding.History))
-
-	return persistDirScope(confDir, binding)
+	return binding
 }
 
 // upsertScopedHistory moves chatID to the front (newest-first). On a hit it
@@ -217,11 +228,17 @@ func persistDirScope(confDir string, binding DirScope) error {
 // It is used after non-reply interactions (e.g. query) to keep the directory-scoped
 // pointer up to date.
 func (cq *ChatHandler) UpdateDirScopeFromCWD(chatID string) error {
-	return UpdateDirScopeFromCWD(cq.confDir, chatID)
+	return cq.persistence.UpdateDirScopeFromCWD(cq.confDir, chatID)
 }
 
 // UpdateDirScopeFromCWD binds the current working directory to the provided chatID.
 func UpdateDirScopeFromCWD(confDir, chatID string) error {
+	return Persistence{}.UpdateDirScopeFromCWD(confDir, chatID)
+}
+
+// UpdateDirScopeFromCWD binds the current working directory to chatID in the
+// session store, or in the dirscope files under confDir when there is none.
+func (p Persistence) UpdateDirScopeFromCWD(confDir, chatID string) error {
 	if chatID == "" {
 		return fmt.Errorf("empty chatID")
 	}
@@ -229,7 +246,14 @@ func UpdateDirScopeFromCWD(confDir, chatID string) error {
 	if err != nil {
 		return err
 	}
-	return saveDirScope(confDir, wd, chatID)
+	if p.Store != nil {
+		canonical, err := canonicalDir(wd)
+		if err != nil {
+			return fmt.Errorf("canonicalize directory %q: %w", wd, err)
+		}
+		return p.Store.BindDir(canonical, chatID)
+	}
+	return saveDirScope(nil, confDir, wd, chatID)
 }
 
 // EnsureOriginDir stamps chat.OriginDir with the canonical CWD the first time the
@@ -238,11 +262,17 @@ func UpdateDirScopeFromCWD(confDir, chatID string) error {
 // adopted so a reply never rewrites the original origin. Stamping is always-on
 // and forward-only: it has no enablement switch and never overwrites a set value.
 func EnsureOriginDir(confDir string, chat *pub_models.Chat) error {
+	return Persistence{}.EnsureOriginDir(confDir, chat)
+}
+
+// EnsureOriginDir is EnsureOriginDir adopting the origin from the session
+// store, if any.
+func (p Persistence) EnsureOriginDir(confDir string, chat *pub_models.Chat) error {
 	if chat == nil || chat.OriginDir != "" {
 		return nil
 	}
 	if chat.ID != "" && chat.ID != globalScopeChatID {
-		if existing, err := FromPath(conversationPath(confDir, chat.ID)); err == nil && existing.OriginDir != "" {
+		if existing, err := p.load(confDir, chat.ID); err == nil && existing.OriginDir != "" {
 			chat.OriginDir = existing.OriginDir
 			return nil
 		}
@@ -257,6 +287,12 @@ func EnsureOriginDir(confDir string, chat *pub_models.Chat) error {
 
 // LoadDirScopeChatID loads the bound chat id for the current working directory.
 func LoadDirScopeChatID(claiConfDir string) (string, error) {
+	return Persistence{}.LoadDirScopeChatID(claiConfDir)
+}
+
+// LoadDirScopeChatID is LoadDirScopeChatID reading the binding from the
+// session's SQLite store, if any.
+func (p Persistence) LoadDirScopeChatID(claiConfDir string) (string, error) {
 	if claiConfDir == "" {
 		var err error
 		claiConfDir, err = utils.GetClaiConfigDir()
@@ -265,7 +301,7 @@ func LoadDirScopeChatID(claiConfDir string) (string, error) {
 		}
 	}
 
-	scope, err := loadDirScopeForCurrentDir(claiConfDir)
+	scope, err := loadDirScopeForCurrentDir(p.sqlite(), claiConfDir)
 	if err != nil {
 		return "", fmt.Errorf("load dir scope: %w", err)
 	}
@@ -276,7 +312,14 @@ func LoadDirScopeChatID(claiConfDir string) (string, error) {
 // directory, returning it directly. The caller receives the dirscope head chat
 // and wires it into the initial-chat context without any filesystem intermediary.
 func LoadDirScopedContext(confDir string) (pub_models.Chat, error) {
-	scope, err := loadDirScopeForCurrentDir(confDir)
+	return Persistence{}.LoadDirScopedContext(confDir)
+}
+
+// LoadDirScopedContext is LoadDirScopedContext reading from the session's
+// SQLite store, if any.
+func (p Persistence) LoadDirScopedContext(confDir string) (pub_models.Chat, error) {
+	store := p.sqlite()
+	scope, err := loadDirScopeForCurrentDir(store, confDir)
 	if err != nil {
 		return pub_models.Chat{}, fmt.Errorf("load dirscope: %w", err)
 	}
@@ -284,20 +327,19 @@ func LoadDirScopedContext(confDir string) (pub_models.Chat, error) {
 		return pub_models.Chat{}, fmt.Errorf("no directory-scoped conversation bound to current directory")
 	}
 
-	convPath := conversationPath(confDir, scope.ChatID)
-	c, err := FromPath(convPath)
+	c, err := loadConversation(store, conversationsDir(confDir), scope.ChatID)
 	if err != nil {
 		return pub_models.Chat{}, fmt.Errorf("load conversation for chat_id %q: %w", scope.ChatID, err)
 	}
 	return c, nil
 }
 
-func loadDirScopeForCurrentDir(confDir string) (DirScope, error) {
+func loadDirScopeForCurrentDir(store *SQLiteStore, confDir string) (DirScope, error) {
 	wd, err := currentWorkingDirectory()
 	if err != nil {
 		return DirScope{}, err
 	}
-	scope, err := loadDirScope(confDir, wd)
+	scope, err := loadDirScope(store, confDir, wd)
 	if err != nil {
 		return DirScope{}, fmt.Errorf("load dir scope for current working directory %q: %w", wd, err)
 	}
@@ -21,7 +21,14 @@ type LookbackDescriptor struct {
 // newest-first history entries with a statistics header. When the directory has
 // no history, Block is empty and HasHistory is false.
 func BuildLookbackDescriptor(confDir, dir string, injectCount int) (LookbackDescriptor, error) {
-	scope, err := loadDirScope(confDir, dir)
+	return Persistence{}.BuildLookbackDescriptor(confDir, dir, injectCount)
+}
+
+// BuildLookbackDescriptor is BuildLookbackDescriptor reading the binding and
+// the chat list from the session's SQLite store, if any.
+func (p Persistence) BuildLookbackDescriptor(confDir, dir string, injectCount int) (LookbackDescriptor, error) {
+	store := p.sqlite()
+	scope, err := loadDirScope(store, confDir, dir)
 	if err != nil {
 		// Missing binding => no history; not an error for the caller.
 		return LookbackDescriptor{}, nil
@@ -30,7 +37,7 @@ func BuildLookbackDescriptor(confDir, dir string, injectCount int) (LookbackDesc
 		return LookbackDescriptor{}, nil
 	}
 
-	rows, err := readChatIndex(conversationsDir(confDir))
+	rows, err := readChatIndex(store, conversationsDir(confDir))
 	if err != nil {
 		return LookbackDescriptor{}, fmt.Errorf("read chat index for descriptor: %w", err)
 	}
@@ -85,7 +92,13 @@ func BuildLookbackDescriptor(confDir, dir string, injectCount int) (LookbackDesc
 // used by the [d]ir table filter. Returns an empty set (not an error) when no
 // binding exists.
 func DirHistoryChatIDs(confDir, dir string) map[string]struct{} {
-	scope, err := loadDirScope(confDir, dir)
+	return Persistence{}.DirHistoryChatIDs(confDir, dir)
+}
+
+// DirHistoryChatIDs is DirHistoryChatIDs reading the binding from the
+// session's SQLite store, if any.
+func (p Persistence) DirHistoryChatIDs(confDir, dir string) map[string]struct{} {
+	scope, err := loadDirScope(p.sqlite(), confDir, dir)
 	if err != nil {
 		return map[string]struct{}{}
 	}
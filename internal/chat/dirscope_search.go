@@ -69,8 +69,19 @@ type bruteForceSearcher struct {
 	confDir string
 }
 
-// NewConversationSearcher returns the brute-force, dir-anchored searcher.
+// NewConversationSearcher returns the brute-force scan of the JSON files
+// under confDir.
 func NewConversationSearcher(confDir string) ConversationSearcher {
+	return Persistence{}.ConversationSearcher(confDir)
+}
+
+// ConversationSearcher returns the dir-anchored searcher of the session: the
+// SQLite store when it is the session store, else the brute-force scan of the
+// JSON files under confDir.
+func (p Persistence) ConversationSearcher(confDir string) ConversationSearcher {
+	if store := p.sqlite(); store != nil {
+		return store
+	}
 	return &bruteForceSearcher{confDir: confDir}
 }
 
@@ -78,26 +89,16 @@ func NewConversationSearcher(confDir string) ConversationSearcher {
 // chat index, raw-byte content prefilter, then parse-and-rank survivors with a
 // keyword-centred snippet, paginated.
 func (s *bruteForceSearcher) Search(req SearchRequest) (SearchResult, error) {
-	pageSize := req.PageSize
-	if pageSize <= 0 {
-		pageSize = defaultSearchPageSize
-	}
-	if pageSize > maxSearchPageSize {
-		pageSize = maxSearchPageSize
-	}
-	page := max(req.Page, 0)
-
 	dir, err := canonicalDir(req.Directory)
 	if err != nil {
 		return SearchResult{}, fmt.Errorf("canonicalize search directory %q: %w", req.Directory, err)
 	}
-	result := SearchResult{Directory: dir, Page: page, PageSize: pageSize}
 
 	tokens := tokenizeQuery(req.Query)
 	debugDirscopef("search %q in %q: tokens=%v", req.Query, dir, tokens)
 
 	convDir := conversationsDir(s.confDir)
-	rows, err := readChatIndex(convDir)
+	rows, err := readChatIndex(nil, convDir)
 	if err != nil {
 		return SearchResult{}, fmt.Errorf("read chat index: %w", err)
 	}
@@ -126,29 +127,54 @@ func (s *bruteForceSearcher) Search(req SearchRequest) (SearchResult, error) {
 		if !rawContainsAll(raw, tokens) {
 			continue
 		}
-		// Stage 2: parse (reusing the bytes already read), build matchable content
-		// excluding the leading system message, re-check AND semantics (drops phantom
-		// system-message-only hits), then rank.
+		// Stage 2: parse (reusing the bytes already read), then rank.
 		var chat pub_models.Chat
 		if err := json.Unmarshal(raw, &chat); err != nil {
 			continue
 		}
-		content, firstUser := searchableContent(chat)
-		lowerContent := strings.ToLower(content)
-		if !containsAllTokens(lowerContent, tokens) {
-			continue
+		if match, ok := rankConversation(row, chat, len(raw), tokens); ok {
+			matches = append(matches, match)
 		}
-		matches = append(matches, SearchResultRow{
-			ChatID:   row.ID,
-			Created:  row.Created,
-			Model:    row.Model,
-			MsgCount: row.MessageCount,
-			ByteSize: len(raw),
-			Score:    scoreContent(lowerContent, strings.ToLower(firstUser), tokens),
-			Snippet:  snippetFor(content, lowerContent, tokens),
-		})
 	}
 
+	debugDirscopef("search %q: %d match(es) from %d candidate(s)", req.Query, len(matches), scanned)
+	return pageSearchResult(dir, req, matches), nil
+}
+
+// rankConversation builds matchable content excluding the leading system
+// message, re-checks AND semantics (dropping phantom system-message-only
+// hits of a prefilter) and scores the conversation with a keyword-centred
+// snippet.
+func rankConversation(row chatIndexRow, chat pub_models.Chat, byteSize int, tokens []string) (SearchResultRow, bool) {
+	content, firstUser := searchableContent(chat)
+	lowerContent := strings.ToLower(content)
+	if !containsAllTokens(lowerContent, tokens) {
+		return SearchResultRow{}, false
+	}
+	return SearchResultRow{
+		ChatID:   row.ID,
+		Created:  row.Created,
+		Model:    row.Model,
+		MsgCount: row.MessageCount,
+		ByteSize: byteSize,
+		Score:    scoreContent(lowerContent, strings.ToLower(firstUser), tokens),
+		Snippet:  snippetFor(content, lowerContent, tokens),
+	}, true
+}
+
+// pageSearchResult sorts matches by score, newest first on ties, and cuts
+// the requested page.
+func pageSearchResult(dir string, req SearchRequest, matches []SearchResultRow) SearchResult {
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultSearchPageSize
+	}
+	if pageSize > maxSearchPageSize {
+		pageSize = maxSearchPageSize
+	}
+	page := max(req.Page, 0)
+	result := SearchResult{Directory: dir, Page: page, PageSize: pageSize}
+
 	sort.SliceStable(matches, func(i, j int) bool {
 		if matches[i].Score != matches[j].Score {
 			return matches[i].Score > matches[j].Score
@@ -157,19 +183,18 @@ func (s *bruteForceSearcher) Search(req SearchRequest) (SearchResult, error) {
 	})
 
 	result.TotalMatches = len(matches)
-	debugDirscopef("search %q: %d match(es) from %d candidate(s)", req.Query, len(matches), scanned)
 	start := page * pageSize
 	// start < 0 guards an int overflow from a hostile/huge page value (page*pageSize
 	// can wrap negative), which would otherwise panic on the slice below.
 	if start < 0 || start >= len(matches) {
-		return result, nil
+		return result
 	}
 	end := start + pageSize
 	if end < start || end > len(matches) {
 		end = len(matches)
 	}
 	result.Rows = matches[start:end]
-	return result, nil
+	return result
 }
 
 // tokenizeQuery splits a query into lowercased AND tokens. "Quoted phrases" are
@@ -383,6 +408,12 @@ func modelOrUnknown(model string) string {
 // filters. The leading system prompt is listed (not hidden) to keep indices
 // honest; its preview makes it obvious to skip.
 func InspectConversation(confDir, chatID string, page, pageSize int, role, match string) (string, error) {
+	return Persistence{}.InspectConversation(confDir, chatID, page, pageSize, role, match)
+}
+
+// InspectConversation is InspectConversation loading from the session store,
+// if any.
+func (p Persistence) InspectConversation(confDir, chatID string, page, pageSize int, role, match string) (string, error) {
 	if pageSize <= 0 {
 		pageSize = defaultInspectPageSize
 	}
@@ -395,7 +426,7 @@ func InspectConversation(confDir, chatID string, page, pageSize int, role, match
 	if err := validateChatID(chatID); err != nil {
 		return "", err
 	}
-	chat, err := FromPath(conversationPath(confDir, chatID))
+	chat, err := p.load(confDir, chatID)
 	if err != nil {
 		return "", fmt.Errorf("load conversation %q: %w", chatID, err)
 	}
@@ -453,15 +484,20 @@ func InspectConversation(confDir, chatID string, page, pageSize int, role, match
 }
 
 // ReadMessage returns the role-tagged content of a single message by its
-// storage-true index, plus the on-disk conversation path so a caller can surface
+// storage-true index, plus the conversation's location so a caller can surface
 // it when the content is truncated by the tool-output limit. An out-of-range
 // index or unresolvable chat id returns an error.
 func ReadMessage(confDir, chatID string, messageIndex int) (content, path string, err error) {
+	return Persistence{}.ReadMessage(confDir, chatID, messageIndex)
+}
+
+// ReadMessage is ReadMessage loading from the session store, if any.
+func (p Persistence) ReadMessage(confDir, chatID string, messageIndex int) (content, path string, err error) {
 	if err := validateChatID(chatID); err != nil {
 		return "", "", err
 	}
-	path = conversationPath(confDir, chatID)
-	chat, err := FromPath(path)
+	path = conversationLocation(p.sqlite(), confDir, chatID)
+	chat, err := p.load(confDir, chatID)
 	if err != nil {
 		return "", path, fmt.Errorf("load conversation %q: %w", chatID, err)
 	}
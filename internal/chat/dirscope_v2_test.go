@@ -187,7 +187,7 @@ func TestEnsureOriginDir_StampsOnFirstPersistAndIsImmutable(t *testing.T) {
 	}
 
 	// Index mirroring: the persisted row carries origin_dir.
-	rows, err := readChatIndex(conversationsDir(confDir))
+	rows, err := readChatIndex(nil, conversationsDir(confDir))
 	if err != nil {
 		t.Fatalf("readChatIndex: %v", err)
 	}
@@ -57,7 +57,7 @@ func LoadGlobalScope(confDir string) (pub_models.Chat, error) {
 		return pub_models.Chat{}, fmt.Errorf("stat legacy global chat %q: %w", oldPath, err)
 	}
 
-	c, err := FromPath(newPath)
+	c, err := loadConversation(nil, convDir, globalScopeChatID)
 	traceChatf("global scope msgs: %v, queries: %v", len(c.Messages), len(c.Queries))
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
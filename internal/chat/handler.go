@@ -33,13 +33,17 @@ Commands:
   l|list                          List all existing chats.
   dir                             Show legacy chat info for CWD (stable v1 output).
   dirv2                           Show chat info with total and recent token usage.
+  migrate-store <sqlite|json>     Copy all chats and directory bindings to the given storage backend.
 
 The chatID is the 5 first words of the prompt joined by underscores. Easiest
 way to get the chatID is to list all chats with 'clai chat list'. You may also select
 a chat by its index in the list of chats.
 
 The chats are found in %v/.clai/conversations, here they may be manually edited
-as JSON files.
+as JSON files. Set "conversation-store": "sqlite" in textConfig.json to keep them
+in a single SQLite database instead (see migrate-store). Set "show-reasoning": false
+in textConfig.json, or pass -show-reasoning=false, to leave the stored reasoning of
+the models out of the chat views.
 
 Examples:
   - clai chat list
@@ -59,6 +63,12 @@ type NotCyclicalImport struct {
 	UseTools   bool
 	UseProfile string
 	Model      string
+	// ConversationBackend is the "conversation-store" of the loaded text
+	// config, see OpenPersistence.
+	ConversationBackend string
+	// ShowReasoning decides if the chat views show the stored reasoning of
+	// the messages, the resolved "show-reasoning" of the text config.
+	ShowReasoning bool
 }
 
 type ChatHandler struct {
@@ -72,6 +82,12 @@ type ChatHandler struct {
 	convDir  string
 	config   NotCyclicalImport
 	raw      bool
+	// persistence is the conversation backend of the config, opened once
+	// in New.
+	persistence Persistence
+	// hideReasoning leaves the reasoning of the messages out of the chat
+	// views, see NotCyclicalImport.ShowReasoning.
+	hideReasoning bool
 
 	out   io.Writer
 	input io.Reader
@@ -101,6 +117,8 @@ func (cq *ChatHandler) actOnSubCmd(ctx context.Context) error {
 		return cq.handleListCmd(ctx)
 	case "delete", "d":
 		return cq.deleteFromPrompt()
+	case "migrate-store":
+		return cq.migrateStore()
 	case "query", "q":
 		return errors.New("not yet implemented")
 	case "dir", "dirv2":
@@ -128,7 +146,7 @@ func (cq *ChatHandler) findChatByID(potentialChatIdx string) (pub_models.Chat, e
 	firstToken := split[0]
 	chatIdx, err := strconv.Atoi(firstToken)
 	if err == nil {
-		rows, err := readChatIndex(cq.convDir)
+		rows, err := readChatIndex(cq.persistence.sqlite(), cq.convDir)
 		if err != nil {
 			return pub_models.Chat{}, fmt.Errorf("failed to read chat index: %w", err)
 		}
@@ -167,6 +185,9 @@ func (cq *ChatHandler) findChatByID(potentialChatIdx string) (pub_models.Chat, e
 }
 
 func (cq *ChatHandler) printChat(chat pub_models.Chat) error {
+	if cq.hideReasoning {
+		chat = withoutReasoning(chat)
+	}
 	// New default behavior: fast, heavily obfuscated preview.
 	// This avoids expensive glow rendering and avoids printing message bodies.
 	if err := printChatObfuscated(cq.out, chat, cq.raw, cq.dims.Width); err != nil {
@@ -184,7 +205,7 @@ func (cq *ChatHandler) cont(ctx context.Context) error {
 	// in the current directory. Fallback: globalScope.
 	if strings.TrimSpace(cq.prompt) == "" {
 		// 1) dir-scoped (CWD)
-		if dsID, err := LoadDirScopeChatID(cq.confDir); err == nil && strings.TrimSpace(dsID) != "" {
+		if dsID, err := cq.persistence.LoadDirScopeChatID(cq.confDir); err == nil && strings.TrimSpace(dsID) != "" {
 			c, err := cq.getByID(dsID)
 			if err != nil {
 				// In the case that the linked dirscope chat for some reason doesnt exist
@@ -202,7 +223,7 @@ func (cq *ChatHandler) cont(ctx context.Context) error {
 
 		// 2) globalScope
 	global_scope:
-		g, err := LoadGlobalScope(cq.confDir)
+		g, err := cq.persistence.LoadPrevQuery(cq.confDir)
 		if err != nil {
 			return fmt.Errorf("load global scope chat: %w", err)
 		}
@@ -263,20 +284,16 @@ func (cq *ChatHandler) deleteFromPrompt() error {
 	if err != nil {
 		return fmt.Errorf("failed to get chat to delete: %w", err)
 	}
-	err = os.Remove(conversationPathFromDir(cq.convDir, c.ID))
+	err = deleteConversation(cq.persistence.sqlite(), cq.convDir, c.ID)
 	if err != nil {
 		return fmt.Errorf("failed to delete chat: %w", err)
 	}
-	// Best-effort GC of the chat's reasoning sidecar directory.
-	if err := removeReasoningSidecars(cq.convDir, c.ID); err != nil {
-		ancli.Warnf("failed to remove reasoning sidecar for chat %q: %v", c.ID, err)
-	}
 	ancli.PrintOK(fmt.Sprintf("deleted chat '%v'\n", c.ID))
 	return nil
 }
 
 func (cq *ChatHandler) getByID(ID string) (pub_models.Chat, error) {
-	return FromPath(conversationPathFromDir(cq.convDir, ID))
+	return loadConversation(cq.persistence.sqlite(), cq.convDir, ID)
 }
 
 func New(q models.ChatQuerier,
@@ -303,22 +320,29 @@ func New(q models.ChatQuerier,
 		return nil, err
 	}
 
+	persistence, err := OpenPersistence(claiDir, conf.ConversationBackend)
+	if err != nil {
+		return nil, err
+	}
+
 	if out == nil {
 		out = os.Stdout
 	}
 
 	ch := &ChatHandler{
-		q:        q,
-		username: username,
-		debug:    debug,
-		subCmd:   subCmd,
-		prompt:   subPrompt,
-		confDir:  claiDir,
-		convDir:  conversationsDir(claiDir),
-		config:   conf,
-		raw:      raw,
-		out:      out,
-		dims:     utils.SessionDimensions(out),
+		q:             q,
+		username:      username,
+		debug:         debug,
+		subCmd:        subCmd,
+		prompt:        subPrompt,
+		confDir:       claiDir,
+		convDir:       conversationsDir(claiDir),
+		config:        conf,
+		raw:           raw,
+		persistence:   persistence,
+		hideReasoning: !conf.ShowReasoning,
+		out:           out,
+		dims:          utils.SessionDimensions(out),
 	}
 
 	// Macro mode: extra positional args after "chat list" become table inputs.
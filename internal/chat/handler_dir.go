@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -277,7 +276,7 @@ func (cq *ChatHandler) resolveChatDirInfo() (chatDirInfo, error) {
 			return chatDirInfo{}, fmt.Errorf("load dir scope: %w", err)
 		}
 	} else {
-		c, err := FromPath(conversationPathFromDir(cq.convDir, ds.ChatID))
+		c, err := loadConversation(cq.persistence.sqlite(), cq.convDir, ds.ChatID)
 		if err == nil {
 			info := cq.infoFromChat("dir", ds.ChatID, c)
 			if !ds.Updated.IsZero() {
@@ -296,7 +295,7 @@ func (cq *ChatHandler) resolveChatDirInfo() (chatDirInfo, error) {
 	}
 
 	// 2) Global scope
-	prev, err := FromPath(filepath.Join(cq.convDir, globalScopeFile))
+	prev, err := loadConversation(cq.persistence.sqlite(), cq.convDir, globalScopeChatID)
 	if err == nil {
 		info := cq.infoFromChat("global", globalScopeChatID, prev)
 		info.ConversationCreated = prev.Created.Format("2006-01-02T15:04:05Z07:00")
@@ -304,7 +304,7 @@ func (cq *ChatHandler) actOnChat(chat pub_models.Chat, groupKey string) error {
 		}
 		return nil
 	case "P", "p":
-		return SaveAsPreviousQuery(cq.confDir, chat)
+		return cq.persistence.SaveAsPreviousQuery(cq.confDir, chat)
 	case "Q", "q":
 		return table.ErrUserInitiatedExit
 	case "":
@@ -355,6 +355,21 @@ func (cq *ChatHandler) handleDeleteMessages(chat pub_models.Chat) error {
 }
 
 func (cq *ChatHandler) list() ([]pub_models.Chat, error) {
+	if store := cq.persistence.sqlite(); store != nil {
+		rows, err := store.indexRows()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list conversations: %w", err)
+		}
+		chats := make([]pub_models.Chat, 0, len(rows))
+		for _, row := range rows {
+			chat, err := store.Load(row.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get chat %q: %w", row.ID, err)
+			}
+			chats = append(chats, chat)
+		}
+		return chats, nil
+	}
 	files, err := os.ReadDir(cq.convDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list conversations: %w", err)
@@ -381,7 +396,7 @@ func (cq *ChatHandler) list() ([]pub_models.Chat, error) {
 }
 
 func (cq *ChatHandler) handleListCmd(ctx context.Context) error {
-	paginator, err := NewChatIndexPaginator(cq.convDir)
+	paginator, err := cq.persistence.ChatIndexPaginator(cq.convDir)
 	if err != nil {
 		return fmt.Errorf("failed to create chat index paginator: %w", err)
 	}
@@ -518,7 +533,7 @@ func (cq *ChatHandler) dirScopeRowPredicate() (func(chatListRow) bool, string, b
 	if err != nil {
 		return nil, "", false
 	}
-	ids := DirHistoryChatIDs(cq.confDir, wd)
+	ids := cq.persistence.DirHistoryChatIDs(cq.confDir, wd)
 	canonicalWd, err := canonicalDir(wd)
 	if err != nil {
 		canonicalWd = wd
@@ -823,7 +838,7 @@ func (cq *ChatHandler) printChatInfoCommon(w io.Writer, chat pub_models.Chat, gr
 	if err != nil {
 		return fmt.Errorf("failed to get clai config dir: %w", err)
 	}
-	filePath := conversationPath(claiConfDir, chat.ID)
+	filePath := conversationLocation(cq.persistence.sqlite(), claiConfDir, chat.ID)
 	if opts.foreign && chat.ID == "" {
 		filePath = "(not cloned)"
 	}
@@ -934,7 +949,7 @@ func (cq *ChatHandler) cloneForeignChat(foreign pub_models.Chat) (pub_models.Cha
 	// Preserve Created as discovered/read. Do not stamp wall clock here.
 	// Save stamps GroupKey and upserts the index itself; a second upsert with
 	// the pre-stamp copy would wipe the group_key from the index row.
-	if err := Save(cq.convDir, cloned); err != nil {
+	if err := cq.persistence.Save(cq.convDir, cloned); err != nil {
 		return pub_models.Chat{}, fmt.Errorf("failed to save cloned chat: %w", err)
 	}
 	if err := cq.UpdateDirScopeFromCWD(cloned.ID); err != nil {
@@ -998,6 +1013,9 @@ func messageEditorText(m pub_models.Message) string {
 // and returns the selection plus the page it was made on.
 func (cq *ChatHandler) selectMessagesAt(chat pub_models.Chat, onlyOneSelect bool, startPage int) ([]int, int, error) {
 	head := fmt.Sprintf(editMessageTblFormat, "Index", "Role", "Length", "Summary")
+	if cq.hideReasoning {
+		chat = withoutReasoning(chat)
+	}
 	tb := table.New(
 		table.SlicePaginator(chat.Messages),
 		func(i int, t pub_models.Message) (string, error) {
@@ -1046,7 +1064,7 @@ func (cq *ChatHandler) deleteMessageInChat(chat pub_models.Chat) error {
 		}
 		chat.Messages = filtered
 
-		if err := Save(cq.convDir, chat); err != nil {
+		if err := cq.persistence.Save(cq.convDir, chat); err != nil {
 			return fmt.Errorf("failed to save chat: %w", err)
 		}
 		ancli.Okf("modified chat: '%v', deleted messages: '%v'", chat.ID, selectedIndices)
@@ -1079,7 +1097,7 @@ func (cq *ChatHandler) editMessageInChat(chat pub_models.Chat) error {
 		}
 		selectedMessage.Content = editedString
 		chat.Messages[selectedNumber] = selectedMessage
-		if err := Save(cq.convDir, chat); err != nil {
+		if err := cq.persistence.Save(cq.convDir, chat); err != nil {
 			return fmt.Errorf("failed to save chat: %w", err)
 		}
 		ancli.Okf("modified chat: '%v', message with index: '%v'", chat.ID, selectedNumber)
@@ -0,0 +1,161 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
+)
+
+// migrationResult counts what a store migration copied.
+type migrationResult struct {
+	Conversations int
+	Bindings      int
+	Skipped       int
+	Target        string
+}
+
+// migrateStore handles `clai chat migrate-store <sqlite|json>`. The source is
+// left untouched so a migration can be retried or reverted; the backend in
+// use only changes with the "conversation-store" field of textConfig.json.
+func (cq *ChatHandler) migrateStore() error {
+	var (
+		res migrationResult
+		err error
+	)
+	target := strings.TrimSpace(cq.prompt)
+	switch target {
+	case BackendSQLite:
+		res, err = migrateToSQLite(cq.confDir)
+	case BackendJSON:
+		res, err = migrateToJSON(cq.confDir)
+	default:
+		return fmt.Errorf("unknown migration target %q, usage: clai chat migrate-store <%s|%s>", target, BackendSQLite, BackendJSON)
+	}
+	if err != nil {
+		return err
+	}
+	if res.Skipped > 0 {
+		ancli.Warnf("skipped %d unreadable conversation file(s)\n", res.Skipped)
+	}
+	ancli.PrintOK(fmt.Sprintf("migrated %d conversation(s) and %d directory binding(s) to %s\n", res.Conversations, res.Bindings, res.Target))
+	ancli.Noticef("set \"conversation-store\": %q in textConfig.json to use it\n", target)
+	return nil
+}
+
+// migrateToSQLite copies every JSON conversation (with its reasoning
+// sidecars) and every dirscope binding of confDir into the SQLite store at
+// <confDir>/conversations.db. Existing rows with the same ID are replaced.
+func migrateToSQLite(confDir string) (migrationResult, error) {
+	store, err := OpenSQLiteStore(filepath.Join(confDir, sqliteStoreFileName))
+	if err != nil {
+		return migrationResult{}, err
+	}
+	defer store.Close()
+	res := migrationResult{Target: store.Path()}
+
+	convDir := conversationsDir(confDir)
+	files, err := os.ReadDir(convDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return res, fmt.Errorf("list conversations: %w", err)
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		chat, err := FromPath(filepath.Join(convDir, file.Name()))
+		if err != nil {
+			res.Skipped++
+			continue
+		}
+		if chat.ID == "" {
+			chat.ID = strings.TrimSuffix(file.Name(), ".json")
+		}
+		if err := store.Save(chat); err != nil {
+			return res, fmt.Errorf("migrate conversation %q: %w", chat.ID, err)
+		}
+		res.Conversations++
+	}
+
+	bindings, err := os.ReadDir(dirscopeRoot(confDir))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return res, fmt.Errorf("list dirscope bindings: %w", err)
+	}
+	for _, file := range bindings {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dirscopeRoot(confDir), file.Name()))
+		if err != nil {
+			return res, fmt.Errorf("read dirscope binding %q: %w", file.Name(), err)
+		}
+		var binding DirScope
+		if err := json.Unmarshal(b, &binding); err != nil {
+			return res, fmt.Errorf("unmarshal dirscope binding %q: %w", file.Name(), err)
+		}
+		// Version 1 records carry no dir_hash; the file name is the hash.
+		if binding.DirHash == "" {
+			binding.DirHash = strings.TrimSuffix(file.Name(), ".json")
+		}
+		if err := store.putDirScope(binding); err != nil {
+			return res, err
+		}
+		res.Bindings++
+	}
+	return res, nil
+}
+
+// migrateToJSON writes every conversation and dirscope binding of the SQLite
+// store of confDir back as JSON files. The chat index cache is dropped so it
+// is rebuilt from the written files on the next listing.
+func migrateToJSON(confDir string) (migrationResult, error) {
+	dbPath := filepath.Join(confDir, sqliteStoreFileName)
+	if _, err := os.Stat(dbPath); err != nil {
+		return migrationResult{}, fmt.Errorf("find sqlite store: %w", err)
+	}
+	store, err := OpenSQLiteStore(dbPath)
+	if err != nil {
+		return migrationResult{}, err
+	}
+	defer store.Close()
+	convDir := conversationsDir(confDir)
+	res := migrationResult{Target: convDir}
+
+	if err := os.MkdirAll(dirscopeRoot(confDir), 0o755); err != nil {
+		return res, fmt.Errorf("create conversations dir: %w", err)
+	}
+	rows, err := store.indexRows()
+	if err != nil {
+		return res, err
+	}
+	for _, row := range rows {
+		chat, err := store.Load(row.ID)
+		if err != nil {
+			return res, fmt.Errorf("read conversation %q: %w", row.ID, err)
+		}
+		if err := (Persistence{SkipIndex: true}).saveFile(convDir, chat); err != nil {
+			return res, fmt.Errorf("migrate conversation %q: %w", row.ID, err)
+		}
+		res.Conversations++
+	}
+	if err := os.Remove(chatIndexPath(convDir)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return res, fmt.Errorf("drop stale chat index: %w", err)
+	}
+
+	bindings, err := store.allDirScopes()
+	if err != nil {
+		return res, err
+	}
+	for _, binding := range bindings {
+		if err := persistDirScope(confDir, binding); err != nil {
+			return res, fmt.Errorf("migrate dirscope binding %q: %w", binding.DirHash, err)
+		}
+		res.Bindings++
+	}
+	return res, nil
+}
@@ -129,7 +129,14 @@ func chatIndexRowFromChat(chat pub_models.Chat) chatIndexRow {
 	return row
 }
 
-func readChatIndex(convDir string) ([]chatIndexRow, error) {
+// readChatIndex returns the chat list rows of the conversations dir convDir,
+// or of the SQLite store when store isn't nil.
+func readChatIndex(store *SQLiteStore, convDir string) ([]chatIndexRow, error) {
+	// The SQLite store answers the listing with one query; there is no cache
+	// to read, rebuild or skip.
+	if store != nil {
+		return store.indexRows()
+	}
 	if SkipIndex {
 		return []chatIndexRow{}, nil
 	}
@@ -283,7 +290,7 @@ func upsertChatIndex(convDir string, chat pub_models.Chat) error {
 	if SkipIndex {
 		return nil
 	}
-	rows, err := readChatIndex(convDir)
+	rows, err := readChatIndex(nil, convDir)
 	if err != nil {
 		return fmt.Errorf("failed to read chat index for upsert: %w", err)
 	}
@@ -306,10 +313,17 @@ func upsertChatIndex(convDir string, chat pub_models.Chat) error {
 }
 
 func NewChatIndexPaginator(convDir string) (*ChatIndexPaginator, error) {
-	if SkipIndex {
+	return Persistence{}.ChatIndexPaginator(convDir)
+}
+
+// ChatIndexPaginator pages the chat list of the session's SQLite store, or
+// of the conversations dir convDir when there is none.
+func (p Persistence) ChatIndexPaginator(convDir string) (*ChatIndexPaginator, error) {
+	store := p.sqlite()
+	if store == nil && SkipIndex {
 		return &ChatIndexPaginator{rows: []chatIndexRow{}}, nil
 	}
-	rows, err := readChatIndex(convDir)
+	rows, err := readChatIndex(store, convDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read chat index paginator rows: %w", err)
 	}
@@ -49,7 +49,7 @@ func TestRebuildChatIndex_ComputesGroupKey(t *testing.T) {
 		t.Fatalf("remove index: %v", err)
 	}
 
-	rows, err := readChatIndex(tmp)
+	rows, err := readChatIndex(nil, tmp)
 	if err != nil {
 		t.Fatalf("readChatIndex: %v", err)
 	}
@@ -256,7 +256,7 @@ func TestReadChatIndex_AutoMigratesStaleGroupKeys(t *testing.T) {
 	}
 
 	// readChatIndex should detect stale cache and rebuild.
-	rows, err := readChatIndex(tmp)
+	rows, err := readChatIndex(nil, tmp)
 	if err != nil {
 		t.Fatalf("readChatIndex: %v", err)
 	}
@@ -311,7 +311,7 @@ func TestReadChatIndex_CorruptedCacheRecovers(t *testing.T) {
 		t.Fatalf("write corrupted cache: %v", err)
 	}
 
-	rows, err := readChatIndex(tmp)
+	rows, err := readChatIndex(nil, tmp)
 	if err != nil {
 		t.Fatalf("readChatIndex should recover from corrupted cache: %v", err)
 	}
@@ -408,7 +408,7 @@ func TestReadChatIndex_ReadonlySilentAndNoPersist(t *testing.T) {
 
 	var rows []chatIndexRow
 	stderr := testboil.CaptureStderr(t, func(t *testing.T) {
-		rows, err = readChatIndex(tmp)
+		rows, err = readChatIndex(nil, tmp)
 	})
 	if err != nil {
 		t.Fatalf("readChatIndex: %v", err)
@@ -443,7 +443,7 @@ func TestSkipIndex_ReadChatIndexReturnsEmpty(t *testing.T) {
 	SkipIndex = true
 	t.Cleanup(func() { SkipIndex = false })
 
-	rows, err := readChatIndex(tmp)
+	rows, err := readChatIndex(nil, tmp)
 	if err != nil {
 		t.Fatalf("readChatIndex: %v", err)
 	}
@@ -535,3 +535,32 @@ func TestSkipIndex_SaveSkipsIndex(t *testing.T) {
 		t.Fatal("chat_index.cache should not exist when SkipIndex is true")
 	}
 }
+
+// TestPersistence_SkipIndexIsPerCaller verifies that a Persistence with
+// SkipIndex set skips the index without touching the package-level flag, so
+// an embedded agent never disables indexing for the CLI in the same process.
+func TestPersistence_SkipIndexIsPerCaller(t *testing.T) {
+	tmp := t.TempDir()
+	ch := pub_models.Chat{
+		ID:       "my_chat",
+		Created:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Messages: []pub_models.Message{{Role: "user", Content: "hello"}},
+	}
+
+	if err := (Persistence{SkipIndex: true}).Save(tmp, ch); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if SkipIndex {
+		t.Fatal("Persistence.Save must not set the package-level SkipIndex")
+	}
+	if _, err := os.Stat(chatIndexPath(tmp)); !os.IsNotExist(err) {
+		t.Fatal("chat_index.cache should not exist when Persistence.SkipIndex is true")
+	}
+
+	if err := Save(tmp, ch); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := os.Stat(chatIndexPath(tmp)); err != nil {
+		t.Fatalf("expected the default persistence to write the index: %v", err)
+	}
+}
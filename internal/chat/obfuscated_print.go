@@ -12,14 +12,14 @@ import (
 )
 
 // messageDisplayText returns human-facing text for a message.
-// Reasoning content is included when present, wrapped in [thinking]…[/thinking].
+// Reasoning is included when present, wrapped in [thinking]…[/thinking].
 // Assistant tool-call turns are persisted with an empty Content (only ToolCalls)
 // so the model never learns the "Call: ..." format; for display we reconstruct
 // a readable line from the calls.
 func messageDisplayText(m pub_models.Message) string {
 	var parts []string
-	if m.ReasoningContent != "" {
-		parts = append(parts, "[thinking]\n"+m.ReasoningContent+"\n[/thinking]")
+	if r := reasoningDisplayText(m); r != "" {
+		parts = append(parts, "[thinking]\n"+r+"\n[/thinking]")
 	}
 	if s := m.String(); s != "" {
 		parts = append(parts, s)
@@ -37,6 +37,28 @@ func messageDisplayText(m pub_models.Message) string {
 	return ""
 }
 
+// reasoningDisplayText returns the reasoning of m: its reasoning content, else
+// the readable part of its stored reasoning items.
+func reasoningDisplayText(m pub_models.Message) string {
+	if m.ReasoningContent != "" {
+		return m.ReasoningContent
+	}
+	return pub_models.ReasoningText(m.ReasoningItems)
+}
+
+// withoutReasoning returns a copy of chat for display, without the reasoning
+// of its messages.
+func withoutReasoning(chat pub_models.Chat) pub_models.Chat {
+	msgs := make([]pub_models.Message, len(chat.Messages))
+	for i, m := range chat.Messages {
+		m.ReasoningContent = ""
+		m.ReasoningItems = nil
+		msgs[i] = m
+	}
+	chat.Messages = msgs
+	return chat
+}
+
 func messageContentLen(m pub_models.Message) int {
 	return utf8.RuneCountInString(messageDisplayText(m))
 }
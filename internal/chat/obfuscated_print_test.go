@@ -271,6 +271,36 @@ func TestPrintChatObfuscated_RendersReasoningContentInOldMessages(t *testing.T)
 	}
 }
 
+func TestPrintChat_ShowReasoningSetting(t *testing.T) {
+	t.Setenv("CLAI_CONFIG_DIR", t.TempDir())
+	msgs := []pub_models.Message{
+		{Role: "user", Content: "question"},
+		{
+			Role:           "assistant",
+			ToolCalls:      []pub_models.Call{{ID: "c1", Name: "ls"}},
+			ReasoningItems: []pub_models.ReasoningItem{{Vendor: pub_models.ReasoningVendorAnthropic, Type: pub_models.ReasoningThinking, Thinking: "stored thinking", Signature: "sig"}},
+		},
+		{Role: "tool", ToolCallID: "c1", Content: "out"},
+		{Role: "assistant", Content: "answer"},
+	}
+	for _, show := range []bool{true, false} {
+		var b strings.Builder
+		cq, err := New(nil, "", "list", nil, NotCyclicalImport{ShowReasoning: show}, true, &b, nil)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if err := cq.printChat(pub_models.Chat{ID: "c", Messages: msgs}); err != nil {
+			t.Fatalf("printChat: %v", err)
+		}
+		if got := strings.Contains(b.String(), "stored thinking"); got != show {
+			t.Fatalf("expected reasoning shown=%v, got:\n%s", show, b.String())
+		}
+	}
+	if msgs[1].ReasoningItems == nil {
+		t.Fatal("hiding the reasoning must not touch the chat")
+	}
+}
+
 // TestPrintChatObfuscated_NewFormat_LongChat verifies the new display layout:
 // first user full, 3 head truncated, 3 obfuscated bridge + gap label,
 // 3 tail truncated, last full.
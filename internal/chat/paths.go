@@ -34,14 +34,20 @@ func dirscopeRoot(confDir string) string {
 	return filepath.Join(conversationsDir(confDir), "dirs")
 }
 
-// reasoningDirFromConvDir is the per-chat sidecar directory holding opaque OpenAI
-// reasoning items, keyed off the conversations dir (as Save/FromPath operate on).
+// reasoningDirFromConvDir is the per-chat sidecar directory holding opaque
+// reasoning state, keyed off the conversations dir (as Save/FromPath operate on).
 func reasoningDirFromConvDir(convDir, chatID string) string {
 	return filepath.Join(convDir, "reasoning", chatID)
 }
 
-// reasoningFileFromConvDir is the sidecar file for one Responses turn, named by its
-// response id.
+// reasoningIndexFromConvDir is the sidecar file holding the reasoning items of
+// every message of the chat, keyed by message index.
+func reasoningIndexFromConvDir(convDir, chatID string) string {
+	return filepath.Join(reasoningDirFromConvDir(convDir, chatID), "messages.json")
+}
+
+// reasoningFileFromConvDir is the legacy sidecar file for one assistant turn,
+// named by the id of its first tool call.
 func reasoningFileFromConvDir(convDir, chatID, responseID string) string {
 	return filepath.Join(reasoningDirFromConvDir(convDir, chatID), responseID+".json")
 }
@@ -34,7 +34,7 @@ func TestReadChatIndex_DoesNotDropDirScopedChatsWhenGlobalScopeHasSameProfile(t
 		t.Fatalf("Save(global): %v", err)
 	}
 
-	rows, err := readChatIndex(convDir)
+	rows, err := readChatIndex(nil, convDir)
 	if err != nil {
 		t.Fatalf("readChatIndex: %v", err)
 	}
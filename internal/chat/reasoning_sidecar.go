@@ -5,20 +5,34 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	pub_models "github.com/baalimago/clai/pkg/text/models"
 )
 
-// The reasoning sidecar stores opaque OpenAI Responses reasoning items
-// (id + encrypted_content) out of the main conversation JSON, in
-// <conversations>/reasoning/<chatid>/<tool_call_id>.json. The first call id is
-// already part of the portable transcript, so no OpenAI-only lookup key needs to
-// leak into message JSON. Keeping the items out-of-band
-// preserves the conversation file's readability and portability, while still
-// allowing stateless (store:false) reasoning-model tool loops to replay the
-// reasoning on the next turn. All operations are best-effort: a sidecar failure
+// The reasoning sidecar stores the opaque, vendor-tagged reasoning state of a
+// chat (OpenAI encrypted reasoning items, Anthropic thinking signatures, Gemini
+// thought signatures, DeepSeek reasoning_content of tool call turns and
+// OpenRouter reasoning details) out of the main conversation JSON, in
+// <conversations>/reasoning/<chatid>/messages.json, keyed by message index.
+// Keeping the state out-of-band preserves the conversation file's readability
+// and portability, while still letting each vendor replay its own reasoning
+// when the chat is continued. All operations are best-effort: a sidecar failure
 // must never prevent the conversation itself from saving or loading.
+//
+// Sidecars written before the state was keyed by message index hold the items
+// of one assistant turn each, in <tool_call_id>.json, named by the first call
+// of the turn. They are still read, and replaced on the next save.
+
+// reasoningEntry is the reasoning state of one message. Role guards against
+// applying the state to another message after the conversation JSON was
+// edited by hand.
+type reasoningEntry struct {
+	Index int                        `json:"index"`
+	Role  string                     `json:"role"`
+	Items []pub_models.ReasoningItem `json:"items"`
+}
 
 // safeSidecarKey guards an identifier used as a filename against path traversal.
 func safeSidecarKey(id string) bool {
@@ -32,43 +46,78 @@ func safeChatID(id string) bool {
 	return safeSidecarKey(id)
 }
 
-// saveReasoningSidecars writes the reasoning items of each tool-bearing assistant
-// turn to its sidecar file. convDir is the conversations directory.
+// saveReasoningSidecars writes the reasoning items of every message to the
+// chat's sidecar, removing it once no message has any. convDir is the
+// conversations directory.
 func saveReasoningSidecars(convDir string, chat pub_models.Chat) error {
 	if !safeChatID(chat.ID) {
 		return fmt.Errorf("unsafe chat id %q", chat.ID)
 	}
-	for _, msg := range chat.Messages {
-		if msg.Role != "assistant" || len(msg.ToolCalls) == 0 || len(msg.ReasoningItems) == 0 {
-			continue
-		}
-		key := msg.ToolCalls[0].ID
-		if !safeSidecarKey(key) {
-			continue
-		}
-		dir := reasoningDirFromConvDir(convDir, chat.ID)
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			return fmt.Errorf("create reasoning dir %q: %w", dir, err)
+	var entries []reasoningEntry
+	for i, msg := range chat.Messages {
+		if len(msg.ReasoningItems) > 0 {
+			entries = append(entries, reasoningEntry{Index: i, Role: msg.Role, Items: msg.ReasoningItems})
 		}
-		b, err := json.MarshalIndent(msg.ReasoningItems, "", "  ")
-		if err != nil {
-			return fmt.Errorf("encode reasoning items: %w", err)
+	}
+	dir := reasoningDirFromConvDir(convDir, chat.ID)
+	f := reasoningIndexFromConvDir(convDir, chat.ID)
+	if len(entries) == 0 {
+		if err := os.Remove(f); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove reasoning sidecar %q: %w", f, err)
 		}
-		b = append(b, '\n')
-		f := reasoningFileFromConvDir(convDir, chat.ID, key)
-		if err := os.WriteFile(f, b, 0o644); err != nil {
-			return fmt.Errorf("write reasoning sidecar %q: %w", f, err)
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create reasoning dir %q: %w", dir, err)
+	}
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode reasoning items: %w", err)
+	}
+	b = append(b, '\n')
+	if err := os.WriteFile(f, b, 0o644); err != nil {
+		return fmt.Errorf("write reasoning sidecar %q: %w", f, err)
+	}
+	// Every item is in the index now, so the per-turn files are stale.
+	legacy, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, l := range legacy {
+		if l != f {
+			_ = os.Remove(l)
 		}
 	}
 	return nil
 }
 
-// loadReasoningSidecars repopulates ReasoningItems on each assistant turn that
-// references a sidecar. A missing sidecar (e.g. a pre-feature chat) is skipped.
+// loadReasoningSidecars repopulates ReasoningItems of the messages of chat. A
+// missing sidecar (e.g. a pre-feature chat) is skipped.
 func loadReasoningSidecars(convDir string, chat *pub_models.Chat) error {
 	if !safeChatID(chat.ID) {
 		return fmt.Errorf("unsafe chat id %q", chat.ID)
 	}
+	f := reasoningIndexFromConvDir(convDir, chat.ID)
+	b, err := os.ReadFile(f)
+	if errors.Is(err, os.ErrNotExist) {
+		return loadLegacyReasoningSidecars(convDir, chat)
+	}
+	if err != nil {
+		return fmt.Errorf("read reasoning sidecar %q: %w", f, err)
+	}
+	var entries []reasoningEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return fmt.Errorf("decode reasoning sidecar %q: %w", f, err)
+	}
+	for _, e := range entries {
+		if e.Index < 0 || e.Index >= len(chat.Messages) || chat.Messages[e.Index].Role != e.Role {
+			continue
+		}
+		chat.Messages[e.Index].ReasoningItems = e.Items
+	}
+	return nil
+}
+
+// loadLegacyReasoningSidecars reads the per-turn sidecars, named by the first
+// tool call of each assistant turn.
+func loadLegacyReasoningSidecars(convDir string, chat *pub_models.Chat) error {
 	for i := range chat.Messages {
 		msg := &chat.Messages[i]
 		if msg.Role != "assistant" || len(msg.ToolCalls) == 0 {
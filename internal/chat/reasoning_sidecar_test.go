@@ -39,8 +39,8 @@ func TestReasoningSidecar_RoundTripThroughSaveAndFromPath(t *testing.T) {
 		t.Fatalf("Save: %v", err)
 	}
 
-	// The main conversation JSON stays clean: all OpenAI-only continuity metadata
-	// is out-of-band.
+	// The main conversation JSON stays clean: all vendor continuity metadata is
+	// out-of-band.
 	raw, err := os.ReadFile(conversationPathFromDir(convDir, chat.ID))
 	if err != nil {
 		t.Fatalf("read chat json: %v", err)
@@ -52,9 +52,8 @@ func TestReasoningSidecar_RoundTripThroughSaveAndFromPath(t *testing.T) {
 		t.Fatalf("OpenAI response metadata must not leak into portable message JSON")
 	}
 
-	// Sidecar file exists at the first tool-call id, which is already part of the
-	// portable transcript and therefore needs no extra inline lookup key.
-	if _, err := os.Stat(reasoningFileFromConvDir(convDir, chat.ID, "call_1")); err != nil {
+	// The sidecar keys the items by message index.
+	if _, err := os.Stat(reasoningIndexFromConvDir(convDir, chat.ID)); err != nil {
 		t.Fatalf("expected sidecar file: %v", err)
 	}
 
@@ -78,6 +77,126 @@ func TestReasoningSidecar_RoundTripThroughSaveAndFromPath(t *testing.T) {
 	}
 }
 
+func TestReasoningSidecar_RoundTripsAnthropicThinking(t *testing.T) {
+	t.Parallel()
+
+	convDir := t.TempDir()
+	chat := reasoningChat()
+	thinking := pub_models.ReasoningItem{Type: pub_models.ReasoningThinking, Thinking: "plan", Signature: "SIGNED"}
+	chat.Messages[1].ReasoningItems = []pub_models.ReasoningItem{thinking}
+	if err := Save(convDir, chat); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := FromPath(conversationPathFromDir(convDir, chat.ID))
+	if err != nil {
+		t.Fatalf("FromPath: %v", err)
+	}
+	got := loaded.Messages[1].ReasoningItems
+	if len(got) != 1 || got[0].Type != thinking.Type || got[0].Signature != "SIGNED" || got[0].Thinking != "plan" {
+		t.Fatalf("restored thinking block mismatch: %#v", got)
+	}
+}
+
+func TestReasoningSidecar_KeysAnyMessageByIndex(t *testing.T) {
+	t.Parallel()
+
+	convDir := t.TempDir()
+	chat := reasoningChat()
+	chat.Messages = append(chat.Messages,
+		pub_models.Message{Role: "tool", ToolCallID: "call_1", Content: "out"},
+		pub_models.Message{
+			Role:           "assistant",
+			Content:        "done",
+			ReasoningItems: []pub_models.ReasoningItem{{Vendor: pub_models.ReasoningVendorGemini, Signature: "GEMINI-SIG"}},
+		},
+	)
+	if err := Save(convDir, chat); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := FromPath(conversationPathFromDir(convDir, chat.ID))
+	if err != nil {
+		t.Fatalf("FromPath: %v", err)
+	}
+	got := loaded.Messages[3].ReasoningItems
+	if len(got) != 1 || got[0].VendorTag() != pub_models.ReasoningVendorGemini || got[0].Signature != "GEMINI-SIG" {
+		t.Fatalf("expected the signature on the final answer, got %#v", got)
+	}
+	if len(loaded.Messages[2].ReasoningItems) != 0 {
+		t.Fatalf("expected no reasoning on the tool result, got %#v", loaded.Messages[2].ReasoningItems)
+	}
+}
+
+// TestReasoningSidecar_ReadsLegacyTurnFiles covers chats saved while the
+// sidecar was keyed by the first tool call id of each turn.
+func TestReasoningSidecar_ReadsLegacyTurnFiles(t *testing.T) {
+	t.Parallel()
+
+	convDir := t.TempDir()
+	chat := reasoningChat()
+	items := chat.Messages[1].ReasoningItems
+	chat.Messages[1].ReasoningItems = nil
+	if err := Save(convDir, chat); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	legacy := reasoningFileFromConvDir(convDir, chat.ID, "call_1")
+	if err := os.MkdirAll(reasoningDirFromConvDir(convDir, chat.ID), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte(`[{"id":"rs_1","encrypted_content":"SEALED-BLOB"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := FromPath(conversationPathFromDir(convDir, chat.ID))
+	if err != nil {
+		t.Fatalf("FromPath: %v", err)
+	}
+	got := loaded.Messages[1].ReasoningItems
+	if len(got) != 1 || got[0].EncryptedContent != items[0].EncryptedContent || got[0].VendorTag() != pub_models.ReasoningVendorOpenAI {
+		t.Fatalf("expected the legacy items as OpenAI items, got %#v", got)
+	}
+
+	if err := Save(convDir, loaded); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Fatalf("expected the legacy file replaced by the index, stat err=%v", err)
+	}
+	reloaded, err := FromPath(conversationPathFromDir(convDir, chat.ID))
+	if err != nil {
+		t.Fatalf("FromPath: %v", err)
+	}
+	if len(reloaded.Messages[1].ReasoningItems) != 1 {
+		t.Fatalf("expected the items from the index, got %#v", reloaded.Messages[1].ReasoningItems)
+	}
+}
+
+func TestReasoningSidecar_SkipsEntriesOfEditedMessages(t *testing.T) {
+	t.Parallel()
+
+	convDir := t.TempDir()
+	// The transcript was edited by hand: index 1 is no longer the assistant turn.
+	chat := pub_models.Chat{ID: "edited", Messages: []pub_models.Message{
+		{Role: "user", Content: "hi"},
+		{Role: "user", Content: "again"},
+	}}
+	if err := Save(convDir, chat); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := os.MkdirAll(reasoningDirFromConvDir(convDir, chat.ID), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reasoningIndexFromConvDir(convDir, chat.ID), []byte(`[{"index":1,"role":"assistant","items":[{"id":"rs_1"}]}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := FromPath(conversationPathFromDir(convDir, chat.ID))
+	if err != nil {
+		t.Fatalf("FromPath: %v", err)
+	}
+	if len(loaded.Messages[1].ReasoningItems) != 0 {
+		t.Fatalf("expected no items on the user message, got %#v", loaded.Messages[1].ReasoningItems)
+	}
+}
+
 func TestReasoningSidecar_RemoveDeletesDir(t *testing.T) {
 	t.Parallel()
 
@@ -12,12 +12,21 @@ import (
 // If dirScoped is true, it prints the most recent message from the conversation
 // bound to the current working directory (and errors if no binding exists).
 // Otherwise it prints the most recent message from the global globalScope.json.
+// The conversations are read from the backend configured in textConfig.json.
 func Replay(raw bool, dirScoped bool) error {
+	claiConfDir, err := utils.GetClaiConfigDir()
+	if err != nil {
+		return fmt.Errorf("get config dir: %w", err)
+	}
+	p, err := OpenPersistence(claiConfDir, ConfiguredBackend(claiConfDir))
+	if err != nil {
+		return err
+	}
 	if dirScoped {
-		return replayDirScoped(raw)
+		return p.replayDirScoped(claiConfDir, raw)
 	}
 
-	prevReply, err := LoadPrevQuery("")
+	prevReply, err := p.LoadPrevQuery(claiConfDir)
 	if err != nil {
 		return fmt.Errorf("failed to load previous reply: %v", err)
 	}
@@ -29,13 +38,9 @@ func Replay(raw bool, dirScoped bool) error {
 	return utils.AttemptPrettyPrint(nil, mostRecentMsg, "system", raw)
 }
 
-func replayDirScoped(raw bool) error {
-	claiConfDir, err := utils.GetClaiConfigDir()
-	if err != nil {
-		return fmt.Errorf("get config dir: %w", err)
-	}
-
-	ds, err := loadDirScopeForCurrentDir(claiConfDir)
+func (p Persistence) replayDirScoped(claiConfDir string, raw bool) error {
+	store := p.sqlite()
+	ds, err := loadDirScopeForCurrentDir(store, claiConfDir)
 	if err != nil {
 		return fmt.Errorf("load dirscope: %w", err)
 	}
@@ -43,8 +48,7 @@ func replayDirScoped(raw bool) error {
 		return errors.New("no directory-scoped conversation bound to current directory")
 	}
 
-	convPath := conversationPath(claiConfDir, ds.ChatID)
-	c, err := FromPath(convPath)
+	c, err := loadConversation(store, conversationsDir(claiConfDir), ds.ChatID)
 	if err != nil {
 		return fmt.Errorf("load conversation for chat_id %q: %w", ds.ChatID, err)
 	}
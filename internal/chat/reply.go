@@ -1,6 +1,7 @@
 package chat
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -14,10 +15,15 @@ import (
 //
 // Name kept for backwards compatibility.
 func SaveAsPreviousQuery(claiConfDir string, chat pub_models.Chat) error {
+	return Persistence{}.SaveAsPreviousQuery(claiConfDir, chat)
+}
+
+// SaveAsPreviousQuery is SaveAsPreviousQuery using the session settings p.
+func (p Persistence) SaveAsPreviousQuery(claiConfDir string, chat pub_models.Chat) error {
 	traceChatf("save previous query start conf_dir=%q chat_id=%q messages=%d profile=%q", claiConfDir, chat.ID, len(chat.Messages), chat.Profile)
 	sourceChat := chat
 	convPath := conversationsDir(claiConfDir)
-	if _, convDirExistsErr := os.Stat(convPath); convDirExistsErr != nil {
+	if _, convDirExistsErr := os.Stat(convPath); p.Store == nil && convDirExistsErr != nil {
 		if err := os.MkdirAll(convPath, 0o755); err != nil {
 			return fmt.Errorf("create conversations dir %q: %w", convPath, err)
 		}
@@ -56,19 +62,19 @@ func SaveAsPreviousQuery(claiConfDir string, chat pub_models.Chat) error {
 			Queries:          chat.Queries,
 		}
 		traceChatf("save previous query conversation path=%q conv_id=%q", convPath, convChat.ID)
-		err = Save(convPath, convChat)
+		err = p.Save(convPath, convChat)
 		if err != nil {
 			return fmt.Errorf("failed to save previous query as new conversation: %w", err)
 		}
 	}
 
 	traceChatf("save previous query global scope path=%q", globalScopePath(claiConfDir))
-	if err := Save(conversationsDir(claiConfDir), globalScopeChat); err != nil {
+	if err := p.Save(conversationsDir(claiConfDir), globalScopeChat); err != nil {
 		return fmt.Errorf("save global scope chat: %w", err)
 	}
 	if sourceChat.ID != "" && sourceChat.ID != globalScopeChatID {
 		traceChatf("save previous query update source conversation path=%q chat_id=%q", convPath, sourceChat.ID)
-		if err := Save(convPath, sourceChat); err != nil {
+		if err := p.Save(convPath, sourceChat); err != nil {
 			return fmt.Errorf("save source conversation chat: %w", err)
 		}
 	}
@@ -78,6 +84,21 @@ func SaveAsPreviousQuery(claiConfDir string, chat pub_models.Chat) error {
 // LoadPrevQuery loads the global-scoped chat.
 // Name kept for backwards compatibility.
 func LoadPrevQuery(claiConfDir string) (pub_models.Chat, error) {
+	return Persistence{}.LoadPrevQuery(claiConfDir)
+}
+
+// LoadPrevQuery is LoadPrevQuery reading from the session store, if any.
+func (p Persistence) LoadPrevQuery(claiConfDir string) (pub_models.Chat, error) {
+	if p.Store != nil {
+		c, err := p.Store.Load(globalScopeChatID)
+		if errors.Is(err, pub_models.ErrConversationNotFound) {
+			return pub_models.Chat{}, nil
+		}
+		if err != nil {
+			return pub_models.Chat{}, fmt.Errorf("load global scope chat: %w", err)
+		}
+		return c, nil
+	}
 	traceChatf("load previous query conf_dir=%q", claiConfDir)
 	c, err := LoadGlobalScope(claiConfDir)
 	if err != nil {
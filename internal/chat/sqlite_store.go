@@ -0,0 +1,607 @@
+package chat
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	_ "modernc.org/sqlite" // registers the pure-Go "sqlite" driver
+)
+
+const (
+	sqliteStoreFileName = "conversations.db"
+
+	// sqliteSchemaVersion is stored in PRAGMA user_version and bumped when
+	// the schema changes incompatibly.
+	sqliteSchemaVersion = 1
+)
+
+// sqliteSchema keeps every conversation as one chats row holding the chat
+// metadata (JSON without messages and queries), the denormalized listing
+// columns of chatIndexRow, one messages row per message and one queries row
+// per recorded query cost. The messages carry a lowercased search_text so
+// lookback search filters in SQL; reasoning items live next to their message
+// instead of in sidecar files.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS chats (
+	id                 TEXT PRIMARY KEY,
+	created            INTEGER NOT NULL,
+	source             TEXT NOT NULL DEFAULT '',
+	source_id          TEXT NOT NULL DEFAULT '',
+	profile            TEXT NOT NULL DEFAULT '',
+	model              TEXT NOT NULL DEFAULT '',
+	origin_dir         TEXT NOT NULL DEFAULT '',
+	group_key          TEXT NOT NULL DEFAULT '',
+	first_user_message TEXT NOT NULL DEFAULT '',
+	message_count      INTEGER NOT NULL DEFAULT 0,
+	total_tokens       INTEGER NOT NULL DEFAULT 0,
+	total_cost_usd     REAL NOT NULL DEFAULT 0,
+	body               BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS chats_created ON chats(created DESC);
+CREATE INDEX IF NOT EXISTS chats_origin_dir ON chats(origin_dir);
+
+CREATE TABLE IF NOT EXISTS messages (
+	chat_id     TEXT NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+	idx         INTEGER NOT NULL,
+	role        TEXT NOT NULL,
+	search_text TEXT NOT NULL DEFAULT '',
+	body        BLOB NOT NULL,
+	reasoning   BLOB,
+	PRIMARY KEY (chat_id, idx)
+);
+
+CREATE TABLE IF NOT EXISTS queries (
+	chat_id           TEXT NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+	idx               INTEGER NOT NULL,
+	created           INTEGER NOT NULL,
+	model             TEXT NOT NULL DEFAULT '',
+	cost_usd          REAL NOT NULL DEFAULT 0,
+	prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+	completion_tokens INTEGER NOT NULL DEFAULT 0,
+	total_tokens      INTEGER NOT NULL DEFAULT 0,
+	body              BLOB NOT NULL,
+	PRIMARY KEY (chat_id, idx)
+);
+CREATE INDEX IF NOT EXISTS queries_model ON queries(model);
+
+CREATE TABLE IF NOT EXISTS dirscopes (
+	dir_hash TEXT PRIMARY KEY,
+	abs_path TEXT NOT NULL DEFAULT '',
+	chat_id  TEXT NOT NULL,
+	updated  INTEGER NOT NULL,
+	body     BLOB NOT NULL
+);
+`
+
+// SQLiteStore is a ConversationStore backed by a single SQLite database. It
+// holds the conversations, their messages and query costs, the directory
+// bindings and the data the chat list and lookback search need, so listing
+// and searching are queries instead of chat_index.cache rebuilds.
+type SQLiteStore struct {
+	db   *sql.DB
+	path string
+}
+
+var _ pub_models.ConversationStore = (*SQLiteStore)(nil)
+
+// OpenSQLiteStore opens (creating if needed) the SQLite store at path.
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	// busy_timeout lets concurrent clai processes queue on the write lock
+	// instead of failing; WAL keeps readers off the writer's back.
+	// The path goes through url.URL so '?', '#' and '%' in it stay part of
+	// the file name.
+	dsn := url.URL{
+		Scheme:   "file",
+		Path:     path,
+		RawQuery: "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)",
+	}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite store %q: %w", path, err)
+	}
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("read sqlite store version %q: %w", path, err)
+	}
+	if version > sqliteSchemaVersion {
+		db.Close()
+		return nil, fmt.Errorf("sqlite store %q has schema version %d, this clai supports up to %d", path, version, sqliteSchemaVersion)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create sqlite store schema %q: %w", path, err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", sqliteSchemaVersion)); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("stamp sqlite store version %q: %w", path, err)
+	}
+	return &SQLiteStore{db: db, path: path}, nil
+}
+
+// Path returns the database file of the store.
+func (s *SQLiteStore) Path() string {
+	return s.path
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) Save(chat pub_models.Chat) error {
+	if chat.ID == "" {
+		return errors.New("chat has no ID")
+	}
+	if chat.GroupKey == "" {
+		chat.GroupKey = ComputeGroupKey(chat)
+	}
+	// The documents are referenced by path, see attachment.Restore.
+	chat.Messages = pub_models.WithoutFileData(chat.Messages)
+	row := chatIndexRowFromChat(chat)
+	meta := chat
+	meta.Messages = nil
+	meta.Queries = nil
+	body, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("encode chat: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin save: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO chats (id, created, source, source_id, profile, model, origin_dir, group_key,
+		first_user_message, message_count, total_tokens, total_cost_usd, body)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET created = excluded.created, source = excluded.source,
+		source_id = excluded.source_id, profile = excluded.profile, model = excluded.model,
+		origin_dir = excluded.origin_dir, group_key = excluded.group_key,
+		first_user_message = excluded.first_user_message, message_count = excluded.message_count,
+		total_tokens = excluded.total_tokens, total_cost_usd = excluded.total_cost_usd, body = excluded.body`,
+		row.ID, row.Created.UnixNano(), row.Source, row.SourceID, row.Profile, row.Model, row.OriginDir,
+		row.GroupKey, row.FirstUserMessage, row.MessageCount, row.TotalTokens, row.TotalCostUSD, body); err != nil {
+		return fmt.Errorf("save chat %q: %w", chat.ID, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM messages WHERE chat_id = ?`, chat.ID); err != nil {
+		return fmt.Errorf("clear messages of %q: %w", chat.ID, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM queries WHERE chat_id = ?`, chat.ID); err != nil {
+		return fmt.Errorf("clear queries of %q: %w", chat.ID, err)
+	}
+	for i, msg := range chat.Messages {
+		b, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("encode message %d: %w", i, err)
+		}
+		var reasoning []byte
+		if len(msg.ReasoningItems) > 0 {
+			if reasoning, err = json.Marshal(msg.ReasoningItems); err != nil {
+				return fmt.Errorf("encode reasoning items of message %d: %w", i, err)
+			}
+		}
+		// The leading system message is the configured prompt, which search
+		// deliberately ignores (see searchableContent).
+		searchText := ""
+		if i > 0 || msg.Role != "system" {
+			searchText = strings.ToLower(msg.String())
+		}
+		if _, err := tx.Exec(`INSERT INTO messages (chat_id, idx, role, search_text, body, reasoning) VALUES (?, ?, ?, ?, ?, ?)`,
+			chat.ID, i, msg.Role, searchText, b, reasoning); err != nil {
+			return fmt.Errorf("save message %d of %q: %w", i, chat.ID, err)
+		}
+	}
+	for i, q := range chat.Queries {
+		b, err := json.Marshal(q)
+		if err != nil {
+			return fmt.Errorf("encode query %d: %w", i, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO queries (chat_id, idx, created, model, cost_usd, prompt_tokens, completion_tokens, total_tokens, body)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			chat.ID, i, q.CreatedAt.UnixNano(), q.Model, q.CostUSD, q.Usage.PromptTokens, q.Usage.CompletionTokens, q.Usage.TotalTokens, b); err != nil {
+			return fmt.Errorf("save query %d of %q: %w", i, chat.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit save of %q: %w", chat.ID, err)
+	}
+	return nil
+}
+
+// Load returns the conversation id. A missing conversation wraps both
+// ErrConversationNotFound and fs.ErrNotExist, so callers written against the
+// JSON files keep treating it as "not found".
+func (s *SQLiteStore) Load(id string) (pub_models.Chat, error) {
+	var body []byte
+	err := s.db.QueryRow(`SELECT body FROM chats WHERE id = ?`, id).Scan(&body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pub_models.Chat{}, fmt.Errorf("load %q: %w: %w", id, pub_models.ErrConversationNotFound, fs.ErrNotExist)
+	}
+	if err != nil {
+		return pub_models.Chat{}, fmt.Errorf("load %q: %w", id, err)
+	}
+	var chat pub_models.Chat
+	if err := json.Unmarshal(body, &chat); err != nil {
+		return pub_models.Chat{}, fmt.Errorf("decode chat %q: %w", id, err)
+	}
+
+	rows, err := s.db.Query(`SELECT body, reasoning FROM messages WHERE chat_id = ? ORDER BY idx`, id)
+	if err != nil {
+		return pub_models.Chat{}, fmt.Errorf("load messages of %q: %w", id, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var msgBody, reasoning []byte
+		if err := rows.Scan(&msgBody, &reasoning); err != nil {
+			return pub_models.Chat{}, fmt.Errorf("scan message of %q: %w", id, err)
+		}
+		var msg pub_models.Message
+		if err := json.Unmarshal(msgBody, &msg); err != nil {
+			return pub_models.Chat{}, fmt.Errorf("decode message of %q: %w", id, err)
+		}
+		if len(reasoning) > 0 {
+			if err := json.Unmarshal(reasoning, &msg.ReasoningItems); err != nil {
+				return pub_models.Chat{}, fmt.Errorf("decode reasoning items of %q: %w", id, err)
+			}
+		}
+		chat.Messages = append(chat.Messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return pub_models.Chat{}, fmt.Errorf("load messages of %q: %w", id, err)
+	}
+
+	qRows, err := s.db.Query(`SELECT body FROM queries WHERE chat_id = ? ORDER BY idx`, id)
+	if err != nil {
+		return pub_models.Chat{}, fmt.Errorf("load queries of %q: %w", id, err)
+	}
+	defer qRows.Close()
+	for qRows.Next() {
+		var qBody []byte
+		if err := qRows.Scan(&qBody); err != nil {
+			return pub_models.Chat{}, fmt.Errorf("scan query of %q: %w", id, err)
+		}
+		var q pub_models.QueryCost
+		if err := json.Unmarshal(qBody, &q); err != nil {
+			return pub_models.Chat{}, fmt.Errorf("decode query of %q: %w", id, err)
+		}
+		chat.Queries = append(chat.Queries, q)
+	}
+	if err := qRows.Err(); err != nil {
+		return pub_models.Chat{}, fmt.Errorf("load queries of %q: %w", id, err)
+	}
+	return chat, nil
+}
+
+// Delete removes the conversation id with its messages and queries.
+func (s *SQLiteStore) Delete(id string) error {
+	res, err := s.db.Exec(`DELETE FROM chats WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete %q: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("delete %q: %w: %w", id, pub_models.ErrConversationNotFound, fs.ErrNotExist)
+	}
+	return nil
+}
+
+// List returns every conversation except the global-scope chat, newest
+// first.
+func (s *SQLiteStore) List() ([]pub_models.ConversationSummary, error) {
+	rows, err := s.indexRows()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]pub_models.ConversationSummary, 0, len(rows))
+	for _, row := range rows {
+		if row.ID == globalScopeChatID {
+			continue
+		}
+		ret = append(ret, summaryFromIndexRow(row))
+	}
+	return ret, nil
+}
+
+// indexRows returns the chat list rows in the shape of the JSON chat index,
+// newest first, in one query.
+func (s *SQLiteStore) indexRows() ([]chatIndexRow, error) {
+	rows, err := s.db.Query(`SELECT id, created, source, source_id, profile, model, origin_dir, group_key,
+		first_user_message, message_count, total_tokens, total_cost_usd
+		FROM chats ORDER BY created DESC, id`)
+	if err != nil {
+		return nil, fmt.Errorf("list conversations: %w", err)
+	}
+	defer rows.Close()
+	ret := make([]chatIndexRow, 0)
+	for rows.Next() {
+		var row chatIndexRow
+		var created int64
+		if err := rows.Scan(&row.ID, &created, &row.Source, &row.SourceID, &row.Profile, &row.Model,
+			&row.OriginDir, &row.GroupKey, &row.FirstUserMessage, &row.MessageCount, &row.TotalTokens,
+			&row.TotalCostUSD); err != nil {
+			return nil, fmt.Errorf("scan conversation row: %w", err)
+		}
+		row.Created = time.Unix(0, created)
+		ret = append(ret, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list conversations: %w", err)
+	}
+	return ret, nil
+}
+
+// BindDir binds dir to chatID, keeping the same newest-first history the
+// JSON dirscope files record.
+func (s *SQLiteStore) BindDir(dir, chatID string) error {
+	canonical, err := canonicalDir(dir)
+	if err != nil {
+		return fmt.Errorf("canonicalize directory %q: %w", dir, err)
+	}
+	binding, err := s.loadDirScope(dirHash(canonical))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("load existing binding: %w", err)
+	}
+	return s.putDirScope(nextDirScope(binding, canonical, chatID, time.Now().UTC()))
+}
+
+func (s *SQLiteStore) DirBinding(dir string) (string, error) {
+	canonical, err := canonicalDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("canonicalize directory %q: %w", dir, err)
+	}
+	scope, err := s.loadDirScope(dirHash(canonical))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return scope.ChatID, nil
+}
+
+func (s *SQLiteStore) loadDirScope(hash string) (DirScope, error) {
+	var body []byte
+	err := s.db.QueryRow(`SELECT body FROM dirscopes WHERE dir_hash = ?`, hash).Scan(&body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DirScope{}, fmt.Errorf("read dirscope binding %q: %w", hash, fs.ErrNotExist)
+	}
+	if err != nil {
+		return DirScope{}, fmt.Errorf("read dirscope binding %q: %w", hash, err)
+	}
+	var scope DirScope
+	if err := json.Unmarshal(body, &scope); err != nil {
+		return DirScope{}, fmt.Errorf("unmarshal dirscope binding %q: %w", hash, err)
+	}
+	return scope, nil
+}
+
+func (s *SQLiteStore) putDirScope(binding DirScope) error {
+	body, err := json.Marshal(binding)
+	if err != nil {
+		return fmt.Errorf("marshal dirscope: %w", err)
+	}
+	if _, err := s.db.Exec(`INSERT INTO dirscopes (dir_hash, abs_path, chat_id, updated, body) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(dir_hash) DO UPDATE SET abs_path = excluded.abs_path, chat_id = excluded.chat_id,
+		updated = excluded.updated, body = excluded.body`,
+		binding.DirHash, binding.AbsPath, binding.ChatID, binding.Updated.UnixNano(), body); err != nil {
+		return fmt.Errorf("save dirscope binding %q: %w", binding.DirHash, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) allDirScopes() ([]DirScope, error) {
+	rows, err := s.db.Query(`SELECT body FROM dirscopes ORDER BY dir_hash`)
+	if err != nil {
+		return nil, fmt.Errorf("list dirscope bindings: %w", err)
+	}
+	defer rows.Close()
+	var ret []DirScope
+	for rows.Next() {
+		var body []byte
+		if err := rows.Scan(&body); err != nil {
+			return nil, fmt.Errorf("scan dirscope binding: %w", err)
+		}
+		var scope DirScope
+		if err := json.Unmarshal(body, &scope); err != nil {
+			return nil, fmt.Errorf("unmarshal dirscope binding: %w", err)
+		}
+		ret = append(ret, scope)
+	}
+	return ret, rows.Err()
+}
+
+// searchCandidate is a chat list row which passed the SQL filters of a
+// search, with the stored size of the conversation.
+type searchCandidate struct {
+	row  chatIndexRow
+	size int
+}
+
+// searchCandidates returns the chat list rows anchored to dir which contain
+// every token somewhere outside their leading system message.
+func (s *SQLiteStore) searchCandidates(dir string, subtree bool, tokens []string) ([]searchCandidate, error) {
+	var where []string
+	var args []any
+	where = append(where, "id != ?")
+	args = append(args, globalScopeChatID)
+	if subtree {
+		// Byte-wise prefix: substr on TEXT would count characters.
+		where = append(where, "(origin_dir = ? OR substr(CAST(origin_dir AS BLOB), 1, ?) = CAST(? AS BLOB))")
+		prefix := strings.TrimRight(dir, string(filepath.Separator)) + string(filepath.Separator)
+		args = append(args, dir, len(prefix), prefix)
+	} else {
+		where = append(where, "origin_dir = ?")
+		args = append(args, dir)
+	}
+	for _, tok := range tokens {
+		where = append(where, "id IN (SELECT chat_id FROM messages WHERE instr(search_text, ?) > 0)")
+		args = append(args, tok)
+	}
+	// length() of a BLOB is its size in bytes, so the size is that of the
+	// stored rows without loading them.
+	rows, err := s.db.Query(`SELECT id, created, model, message_count, origin_dir,
+		length(body)
+		+ COALESCE((SELECT SUM(length(m.body) + COALESCE(length(m.reasoning), 0)) FROM messages m WHERE m.chat_id = chats.id), 0)
+		+ COALESCE((SELECT SUM(length(q.body)) FROM queries q WHERE q.chat_id = chats.id), 0)
+		FROM chats WHERE `+strings.Join(where, " AND "), args...)
+	if err != nil {
+		return nil, fmt.Errorf("search conversations: %w", err)
+	}
+	defer rows.Close()
+	var ret []searchCandidate
+	for rows.Next() {
+		var c searchCandidate
+		var created int64
+		if err := rows.Scan(&c.row.ID, &created, &c.row.Model, &c.row.MessageCount, &c.row.OriginDir, &c.size); err != nil {
+			return nil, fmt.Errorf("scan search candidate: %w", err)
+		}
+		if !originMatches(c.row.OriginDir, dir, subtree) {
+			continue
+		}
+		c.row.Created = time.Unix(0, created)
+		ret = append(ret, c)
+	}
+	return ret, rows.Err()
+}
+
+// searchMessages returns the messages of the conversation id, without their
+// reasoning items, which is all ranking a search match needs.
+func (s *SQLiteStore) searchMessages(id string) ([]pub_models.Message, error) {
+	rows, err := s.db.Query(`SELECT body FROM messages WHERE chat_id = ? ORDER BY idx`, id)
+	if err != nil {
+		return nil, fmt.Errorf("load messages of %q: %w", id, err)
+	}
+	defer rows.Close()
+	var ret []pub_models.Message
+	for rows.Next() {
+		var body []byte
+		if err := rows.Scan(&body); err != nil {
+			return nil, fmt.Errorf("scan message of %q: %w", id, err)
+		}
+		var msg pub_models.Message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return nil, fmt.Errorf("decode message of %q: %w", id, err)
+		}
+		ret = append(ret, msg)
+	}
+	return ret, rows.Err()
+}
+
+// Search implements ConversationSearcher: the directory and keyword filters
+// and the conversation sizes come from SQL, the messages of the surviving
+// conversations are ranked exactly like the brute-force searcher ranks the
+// JSON files.
+func (s *SQLiteStore) Search(req SearchRequest) (SearchResult, error) {
+	dir, err := canonicalDir(req.Directory)
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("canonicalize search directory %q: %w", req.Directory, err)
+	}
+	tokens := tokenizeQuery(req.Query)
+	candidates, err := s.searchCandidates(dir, req.Subtree, tokens)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	matches := make([]SearchResultRow, 0, len(candidates))
+	for _, c := range candidates {
+		msgs, err := s.searchMessages(c.row.ID)
+		if err != nil {
+			continue
+		}
+		if match, ok := rankConversation(c.row, pub_models.Chat{Messages: msgs}, c.size, tokens); ok {
+			matches = append(matches, match)
+		}
+	}
+	debugDirscopef("sqlite search %q in %q: %d match(es) from %d candidate(s)", req.Query, dir, len(matches), len(candidates))
+	return pageSearchResult(dir, req, matches), nil
+}
+
+// sqliteHandles shares one database handle per store file between the
+// sessions of a process, e.g. a chat handler and the querier it drives.
+var sqliteHandles sync.Map // database path -> *SQLiteStore
+
+func sharedSQLiteStore(path string) (*SQLiteStore, error) {
+	if cached, ok := sqliteHandles.Load(path); ok {
+		return cached.(*SQLiteStore), nil
+	}
+	store, err := OpenSQLiteStore(path)
+	if err != nil {
+		return nil, err
+	}
+	if actual, loaded := sqliteHandles.LoadOrStore(path, store); loaded {
+		store.Close()
+		return actual.(*SQLiteStore), nil
+	}
+	return store, nil
+}
+
+// Conversation storage backends selectable with the "conversation-store"
+// field of textConfig.json.
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// OpenPersistence returns the persistence of backend for the config dir
+// confDir: the JSON files for "" and "json", the SQLite store at
+// <confDir>/conversations.db for "sqlite". Resolve it once per command and
+// pass it down; the store is what every chat operation then uses.
+func OpenPersistence(confDir, backend string) (Persistence, error) {
+	switch backend {
+	case "", BackendJSON:
+		return Persistence{}, nil
+	case BackendSQLite:
+		store, err := sharedSQLiteStore(filepath.Join(confDir, sqliteStoreFileName))
+		if err != nil {
+			return Persistence{}, err
+		}
+		return Persistence{Store: store}, nil
+	default:
+		return Persistence{}, fmt.Errorf("unknown conversation-store %q in textConfig.json, expected %q or %q", backend, BackendJSON, BackendSQLite)
+	}
+}
+
+// OpenStore returns the ConversationStore of backend for confDir: the
+// FileStore for the JSON files, else the store of OpenPersistence.
+func OpenStore(confDir, backend string) (pub_models.ConversationStore, error) {
+	p, err := OpenPersistence(confDir, backend)
+	if err != nil {
+		return nil, err
+	}
+	if p.Store == nil {
+		return NewFileStore(confDir), nil
+	}
+	return p.Store, nil
+}
+
+// ConfiguredBackend reads the conversation backend from
+// <confDir>/textConfig.json without creating or migrating the file, for the
+// commands which don't load the text config, such as replay and the photo
+// and video modes. A missing file or field selects the JSON files.
+func ConfiguredBackend(confDir string) string {
+	b, err := os.ReadFile(filepath.Join(confDir, "textConfig.json"))
+	if err != nil {
+		return BackendJSON
+	}
+	var conf struct {
+		ConversationStore string `json:"conversation-store"`
+	}
+	if err := json.Unmarshal(b, &conf); err != nil {
+		// A broken textConfig.json is reported by the config loader; the
+		// conversations stay where they were.
+		return BackendJSON
+	}
+	return conf.ConversationStore
+}
@@ -0,0 +1,228 @@
+package chat
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/baalimago/clai/internal/checkpoint"
+	"github.com/baalimago/clai/internal/utils"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// useSQLiteBackend opens the SQLite backend of a fresh config dir.
+func useSQLiteBackend(t *testing.T) (string, Persistence) {
+	t.Helper()
+	confDir := t.TempDir()
+	if err := utils.CreateConfigDir(confDir); err != nil {
+		t.Fatalf("CreateConfigDir: %v", err)
+	}
+	p, err := OpenPersistence(confDir, BackendSQLite)
+	if err != nil {
+		t.Fatalf("OpenPersistence: %v", err)
+	}
+	t.Cleanup(func() {
+		p.sqlite().Close()
+		sqliteHandles.Delete(p.sqlite().Path())
+	})
+	return confDir, p
+}
+
+func TestSQLiteStore_PreservesReasoningQueriesAndDelete(t *testing.T) {
+	store, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "conversations.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLiteStore: %v", err)
+	}
+	defer store.Close()
+
+	ch := storeTestChat("r", time.Now())
+	ch.Messages[2].ReasoningItems = []pub_models.ReasoningItem{{ID: "rs_1", EncryptedContent: "opaque"}}
+	ch.Queries = []pub_models.QueryCost{{Model: "gpt-x"}}
+	if err := store.Save(ch); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := store.Load("r")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got.Messages) != 3 || len(got.Messages[2].ReasoningItems) != 1 || got.Messages[2].ReasoningItems[0].EncryptedContent != "opaque" {
+		t.Fatalf("expected reasoning items to survive, got %+v", got.Messages)
+	}
+	if len(got.Queries) != 1 || got.Queries[0].Model != "gpt-x" {
+		t.Fatalf("expected queries to survive, got %+v", got.Queries)
+	}
+
+	if err := store.Delete("r"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Load("r"); !errors.Is(err, fs.ErrNotExist) || !errors.Is(err, pub_models.ErrConversationNotFound) {
+		t.Fatalf("expected a not-found error after delete, got %v", err)
+	}
+}
+
+// TestSQLiteBackend_RoutesChatOperations verifies that the SQLite
+// persistence takes saving, listing, loading, searching and deleting into the
+// database without writing any conversation file.
+func TestSQLiteBackend_RoutesChatOperations(t *testing.T) {
+	confDir, p := useSQLiteBackend(t)
+	store := p.sqlite()
+	origin, err := canonicalDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("canonicalDir: %v", err)
+	}
+	for _, c := range []pub_models.Chat{
+		{ID: "alpha", OriginDir: origin, Messages: []pub_models.Message{msg("system", "sys"), msg("user", "tell me about oauth tokens")}},
+		{ID: "beta", OriginDir: origin, Messages: []pub_models.Message{msg("user", "something else")}},
+	} {
+		if err := p.Save(conversationsDir(confDir), c); err != nil {
+			t.Fatalf("Save(%q): %v", c.ID, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(conversationsDir(confDir), "alpha.json")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected no JSON file with the sqlite backend, stat err=%v", err)
+	}
+	rows, err := readChatIndex(store, conversationsDir(confDir))
+	if err != nil {
+		t.Fatalf("readChatIndex: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected both chats indexed, got %+v", rows)
+	}
+	loaded, err := loadConversation(store, conversationsDir(confDir), "alpha")
+	if err != nil || len(loaded.Messages) != 2 {
+		t.Fatalf("expected alpha from the database, got %+v (err %v)", loaded, err)
+	}
+
+	res, err := p.ConversationSearcher(confDir).Search(SearchRequest{Query: "oauth", Directory: loaded.OriginDir, Subtree: true, PageSize: 10})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if res.TotalMatches != 1 || res.Rows[0].ChatID != "alpha" {
+		t.Fatalf("expected alpha as the only match, got %+v", res)
+	}
+	if res.Rows[0].ByteSize <= len("tell me about oauth tokens") {
+		t.Fatalf("expected the stored size of alpha, got %d", res.Rows[0].ByteSize)
+	}
+
+	if err := saveDirScope(store, confDir, origin, "beta"); err != nil {
+		t.Fatalf("saveDirScope: %v", err)
+	}
+	scope, err := loadDirScopeForDir(store, confDir, origin)
+	if err != nil || scope.ChatID != "beta" {
+		t.Fatalf("expected the binding from the database, got %+v (err %v)", scope, err)
+	}
+
+	cps := checkpoint.New(confDir)
+	if _, err := cps.Snapshot("alpha", "call_1", "write_file", []string{filepath.Join(t.TempDir(), "f.txt")}); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if err := deleteConversation(store, conversationsDir(confDir), "alpha"); err != nil {
+		t.Fatalf("deleteConversation: %v", err)
+	}
+	if _, err := loadConversation(store, conversationsDir(confDir), "alpha"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected alpha to be gone, got %v", err)
+	}
+	if left, _ := cps.List("alpha"); len(left) != 0 {
+		t.Fatalf("expected the checkpoints of alpha to be gone, got %+v", left)
+	}
+}
+
+func TestOpenSQLiteStore_PathWithURLCharacters(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "we?ird#dir%20")
+	store, err := OpenSQLiteStore(filepath.Join(dir, sqliteStoreFileName))
+	if err != nil {
+		t.Fatalf("OpenSQLiteStore: %v", err)
+	}
+	defer store.Close()
+	if err := store.Save(storeTestChat("a", time.Now())); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, sqliteStoreFileName)); err != nil {
+		t.Fatalf("expected the database at the literal path: %v", err)
+	}
+}
+
+func TestMigrateStore_RoundTrip(t *testing.T) {
+	confDir := t.TempDir()
+	if err := utils.CreateConfigDir(confDir); err != nil {
+		t.Fatalf("CreateConfigDir: %v", err)
+	}
+	origin := t.TempDir()
+	seedChat(t, confDir, "one", origin, msg("user", "first"))
+	seedChat(t, confDir, "two", origin, msg("user", "second"))
+	if err := saveDirScope(nil, confDir, origin, "two"); err != nil {
+		t.Fatalf("saveDirScope: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(conversationsDir(confDir), "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatalf("write broken chat: %v", err)
+	}
+
+	res, err := migrateToSQLite(confDir)
+	if err != nil {
+		t.Fatalf("migrateToSQLite: %v", err)
+	}
+	if res.Conversations != 2 || res.Bindings != 1 || res.Skipped != 1 {
+		t.Fatalf("unexpected migration result %+v", res)
+	}
+
+	// Start over from the database alone.
+	target := t.TempDir()
+	if err := utils.CreateConfigDir(target); err != nil {
+		t.Fatalf("CreateConfigDir: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(confDir, sqliteStoreFileName))
+	if err != nil {
+		t.Fatalf("read database: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(target, sqliteStoreFileName), b, 0o644); err != nil {
+		t.Fatalf("copy database: %v", err)
+	}
+	res, err = migrateToJSON(target)
+	if err != nil {
+		t.Fatalf("migrateToJSON: %v", err)
+	}
+	if res.Conversations != 2 || res.Bindings != 1 {
+		t.Fatalf("unexpected migration result %+v", res)
+	}
+	got, err := FromPath(filepath.Join(conversationsDir(target), "two.json"))
+	if err != nil || got.Messages[0].Content != "second" {
+		t.Fatalf("expected two.json written back, got %+v (err %v)", got, err)
+	}
+	scope, err := loadDirScopeForDir(nil, target, origin)
+	if err != nil || scope.ChatID != "two" {
+		t.Fatalf("expected the binding written back, got %+v (err %v)", scope, err)
+	}
+}
+
+func TestOpenPersistence_Backends(t *testing.T) {
+	confDir := t.TempDir()
+	if _, err := OpenPersistence(confDir, "postgres"); err == nil {
+		t.Fatal("expected an unknown conversation-store to be rejected")
+	}
+	for _, backend := range []string{"", BackendJSON} {
+		p, err := OpenPersistence(confDir, backend)
+		if err != nil || p.Store != nil {
+			t.Fatalf("expected the JSON files for %q, got %+v (err %v)", backend, p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(confDir, sqliteStoreFileName)); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected no database for the JSON backend, stat err=%v", err)
+	}
+}
+
+func TestConfiguredBackend(t *testing.T) {
+	confDir := t.TempDir()
+	if backend := ConfiguredBackend(confDir); backend != BackendJSON {
+		t.Fatalf("expected json without a textConfig.json, got %q", backend)
+	}
+	if err := os.WriteFile(filepath.Join(confDir, "textConfig.json"), []byte(`{"conversation-store":"sqlite"}`), 0o644); err != nil {
+		t.Fatalf("write textConfig: %v", err)
+	}
+	if backend := ConfiguredBackend(confDir); backend != BackendSQLite {
+		t.Fatalf("expected sqlite from textConfig.json, got %q", backend)
+	}
+}
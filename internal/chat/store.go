@@ -0,0 +1,184 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+var (
+	_ pub_models.ConversationStore = (*FileStore)(nil)
+	_ pub_models.ConversationStore = (*MemoryStore)(nil)
+)
+
+// FileStore is the filesystem ConversationStore: one JSON file per
+// conversation under <ConfigDir>/conversations, the chat index next to them
+// and the dirscope bindings under <ConfigDir>/conversations/dirs. It is what
+// every session uses when no other store is configured.
+type FileStore struct {
+	ConfigDir string
+	// SkipIndex disables chat index I/O on Save, in addition to the
+	// process-wide SkipIndex. List then returns no rows.
+	SkipIndex bool
+}
+
+// NewFileStore returns the filesystem store rooted at confDir.
+func NewFileStore(confDir string) *FileStore {
+	return &FileStore{ConfigDir: confDir}
+}
+
+func (s *FileStore) Save(chat pub_models.Chat) error {
+	convDir := conversationsDir(s.ConfigDir)
+	if err := os.MkdirAll(convDir, 0o755); err != nil {
+		return fmt.Errorf("create conversations dir %q: %w", convDir, err)
+	}
+	return Persistence{SkipIndex: s.SkipIndex}.saveFile(convDir, chat)
+}
+
+func (s *FileStore) Load(id string) (pub_models.Chat, error) {
+	c, err := loadConversation(nil, conversationsDir(s.ConfigDir), id)
+	if errors.Is(err, fs.ErrNotExist) && !errors.Is(err, pub_models.ErrConversationNotFound) {
+		return pub_models.Chat{}, fmt.Errorf("load %q: %w", id, pub_models.ErrConversationNotFound)
+	}
+	return c, err
+}
+
+func (s *FileStore) List() ([]pub_models.ConversationSummary, error) {
+	if s.SkipIndex {
+		return []pub_models.ConversationSummary{}, nil
+	}
+	rows, err := readChatIndex(nil, conversationsDir(s.ConfigDir))
+	if err != nil {
+		return nil, fmt.Errorf("read chat index: %w", err)
+	}
+	ret := make([]pub_models.ConversationSummary, 0, len(rows))
+	for _, row := range rows {
+		if row.ID == globalScopeChatID {
+			continue
+		}
+		ret = append(ret, summaryFromIndexRow(row))
+	}
+	return ret, nil
+}
+
+func (s *FileStore) BindDir(dir, chatID string) error {
+	return saveDirScope(nil, s.ConfigDir, dir, chatID)
+}
+
+func (s *FileStore) DirBinding(dir string) (string, error) {
+	scope, err := loadDirScopeForDir(nil, s.ConfigDir, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return scope.ChatID, nil
+}
+
+// MemoryStore is a ConversationStore which never touches the filesystem.
+// Conversations live for as long as the store does.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	chats    map[string]pub_models.Chat
+	bindings map[string]string
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		chats:    make(map[string]pub_models.Chat),
+		bindings: make(map[string]string),
+	}
+}
+
+func (s *MemoryStore) Save(chat pub_models.Chat) error {
+	if chat.ID == "" {
+		return errors.New("chat has no ID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chats[chat.ID] = cloneChat(chat)
+	return nil
+}
+
+func (s *MemoryStore) Load(id string) (pub_models.Chat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.chats[id]
+	if !ok {
+		return pub_models.Chat{}, fmt.Errorf("load %q: %w", id, pub_models.ErrConversationNotFound)
+	}
+	return cloneChat(c), nil
+}
+
+// List returns the stored conversations, newest first. The global-scope chat
+// backing reply mode is omitted, as it is from the chat index.
+func (s *MemoryStore) List() ([]pub_models.ConversationSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ret := make([]pub_models.ConversationSummary, 0, len(s.chats))
+	for id, c := range s.chats {
+		if id == globalScopeChatID {
+			continue
+		}
+		ret = append(ret, summaryFromIndexRow(chatIndexRowFromChat(c)))
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Created.Equal(ret[j].Created) {
+			return ret[i].ID < ret[j].ID
+		}
+		return ret[i].Created.After(ret[j].Created)
+	})
+	return ret, nil
+}
+
+func (s *MemoryStore) BindDir(dir, chatID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bindings[dir] = chatID
+	return nil
+}
+
+func (s *MemoryStore) DirBinding(dir string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bindings[dir], nil
+}
+
+// cloneChat copies the slices a caller could mutate in place, so a stored
+// conversation never changes behind the store's back.
+func cloneChat(c pub_models.Chat) pub_models.Chat {
+	c.Messages = slices.Clone(c.Messages)
+	c.Queries = slices.Clone(c.Queries)
+	return c
+}
+
+func summaryFromIndexRow(row chatIndexRow) pub_models.ConversationSummary {
+	return pub_models.ConversationSummary{
+		ID:               row.ID,
+		Created:          row.Created,
+		Profile:          row.Profile,
+		Model:            row.Model,
+		MessageCount:     row.MessageCount,
+		TotalTokens:      row.TotalTokens,
+		TotalCostUSD:     row.TotalCostUSD,
+		FirstUserMessage: row.FirstUserMessage,
+		OriginDir:        row.OriginDir,
+	}
+}
+
+// load returns the conversation id from the session store, or from the
+// conversations dir under confDir when there is none.
+func (p Persistence) load(confDir, id string) (pub_models.Chat, error) {
+	if p.Store != nil {
+		return p.Store.Load(id)
+	}
+	return loadConversation(nil, conversationsDir(confDir), id)
+}
@@ -0,0 +1,205 @@
+package chat
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func storeTestChat(id string, created time.Time) pub_models.Chat {
+	return pub_models.Chat{
+		ID:      id,
+		Created: created,
+		Messages: []pub_models.Message{
+			{Role: "system", Content: "sys"},
+			{Role: "user", Content: "hello " + id},
+			{Role: "assistant", Content: "reply"},
+		},
+	}
+}
+
+func TestConversationStores_RoundTrip(t *testing.T) {
+	tests := map[string]func(t *testing.T) pub_models.ConversationStore{
+		"file":   func(t *testing.T) pub_models.ConversationStore { return NewFileStore(t.TempDir()) },
+		"memory": func(t *testing.T) pub_models.ConversationStore { return NewMemoryStore() },
+		"sqlite": func(t *testing.T) pub_models.ConversationStore {
+			store, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "conversations.db"))
+			if err != nil {
+				t.Fatalf("OpenSQLiteStore: %v", err)
+			}
+			t.Cleanup(func() { store.Close() })
+			return store
+		},
+	}
+	for name, newStore := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			for i, id := range []string{"older", "newer"} {
+				if err := store.Save(storeTestChat(id, base.Add(time.Duration(i)*time.Hour))); err != nil {
+					t.Fatalf("Save(%q): %v", id, err)
+				}
+			}
+			if err := store.Save(pub_models.Chat{ID: globalScopeChatID, Messages: []pub_models.Message{{Role: "user", Content: "g"}}}); err != nil {
+				t.Fatalf("Save(globalScope): %v", err)
+			}
+
+			got, err := store.Load("newer")
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if msg, _ := got.FirstUserMessage(); msg.Content != "hello newer" {
+				t.Fatalf("expected the saved transcript back, got %+v", got.Messages)
+			}
+			if _, err := store.Load("missing"); !errors.Is(err, pub_models.ErrConversationNotFound) {
+				t.Fatalf("expected ErrConversationNotFound, got %v", err)
+			}
+
+			summaries, err := store.List()
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			ids := map[string]pub_models.ConversationSummary{}
+			for _, s := range summaries {
+				ids[s.ID] = s
+			}
+			if len(ids) != 2 || ids["older"].FirstUserMessage != "hello older" || ids["newer"].MessageCount != 3 {
+				t.Fatalf("expected both conversations and no global scope, got %+v", summaries)
+			}
+
+			dir := t.TempDir()
+			if id, err := store.DirBinding(dir); err != nil || id != "" {
+				t.Fatalf("expected no binding yet, got %q (err %v)", id, err)
+			}
+			if err := store.BindDir(dir, "newer"); err != nil {
+				t.Fatalf("BindDir: %v", err)
+			}
+			if id, err := store.DirBinding(dir); err != nil || id != "newer" {
+				t.Fatalf("expected binding to newer, got %q (err %v)", id, err)
+			}
+		})
+	}
+}
+
+func TestPersistentStores_SaveWithoutFileData(t *testing.T) {
+	sqlite, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "conversations.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	file := &pub_models.FileInput{Filename: "a.pdf", FileData: "data:application/pdf;base64,JVBERi0=", Text: "pdf text", Path: "/docs/a.pdf", SHA256: "abc"}
+	chat := pub_models.Chat{ID: "doc", Messages: []pub_models.Message{
+		{Role: "user", ContentParts: []pub_models.ImageOrTextInput{{Type: "file", File: file}}},
+	}}
+	for name, store := range map[string]pub_models.ConversationStore{"file": NewFileStore(t.TempDir()), "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			if err := store.Save(chat); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			got, err := store.Load("doc")
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			want := pub_models.FileInput{Filename: "a.pdf", Text: "pdf text", Path: "/docs/a.pdf", SHA256: "abc"}
+			if f := got.Messages[0].ContentParts[0].File; f == nil || *f != want {
+				t.Fatalf("expected the document referenced without its data, got %+v", f)
+			}
+		})
+	}
+	if file.FileData == "" {
+		t.Fatal("Save changed the chat of the caller")
+	}
+}
+
+func TestMemoryStore_IsolatesStoredChats(t *testing.T) {
+	store := NewMemoryStore()
+	ch := storeTestChat("a", time.Now())
+	if err := store.Save(ch); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	ch.Messages[1].Content = "mutated"
+
+	got, err := store.Load("a")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	got.Messages[2].Content = "mutated too"
+	again, _ := store.Load("a")
+	if again.Messages[1].Content != "hello a" || again.Messages[2].Content != "reply" {
+		t.Fatalf("expected the stored chat to be unaffected by caller mutation, got %+v", again.Messages)
+	}
+	if err := store.Save(pub_models.Chat{}); err == nil {
+		t.Fatal("expected saving a chat without ID to fail")
+	}
+}
+
+func TestMemoryStore_ListNewestFirst(t *testing.T) {
+	store := NewMemoryStore()
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, id := range []string{"first", "second", "third"} {
+		if err := store.Save(storeTestChat(id, base.Add(time.Duration(i)*time.Minute))); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	summaries, _ := store.List()
+	if len(summaries) != 3 || summaries[0].ID != "third" || summaries[2].ID != "first" {
+		t.Fatalf("expected newest first, got %+v", summaries)
+	}
+}
+
+// TestPersistence_StoreReplacesFiles verifies that a session with a store
+// routes the previous-query save and load through it and never creates the
+// conversations directory.
+func TestPersistence_StoreReplacesFiles(t *testing.T) {
+	confDir := t.TempDir()
+	store := NewMemoryStore()
+	p := Persistence{Store: store}
+
+	ch := storeTestChat("", time.Now())
+	if err := p.SaveAsPreviousQuery(confDir, ch); err != nil {
+		t.Fatalf("SaveAsPreviousQuery: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(confDir, "conversations")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no conversations directory, stat err=%v", err)
+	}
+	summaries, _ := store.List()
+	if len(summaries) != 1 {
+		t.Fatalf("expected the promoted conversation in the store, got %+v", summaries)
+	}
+	if summaries[0].ID == "" {
+		t.Fatal("expected the promoted conversation to have an ID")
+	}
+
+	prev, err := p.LoadPrevQuery(confDir)
+	if err != nil {
+		t.Fatalf("LoadPrevQuery: %v", err)
+	}
+	if len(prev.Messages) != len(ch.Messages) {
+		t.Fatalf("expected the previous query from the store, got %+v", prev)
+	}
+	empty, err := Persistence{Store: NewMemoryStore()}.LoadPrevQuery(confDir)
+	if err != nil || len(empty.Messages) != 0 {
+		t.Fatalf("expected an empty previous query from an empty store, got %+v (err %v)", empty, err)
+	}
+
+	stored, _ := store.Load(summaries[0].ID)
+	stored.OriginDir = ""
+	if err := p.EnsureOriginDir(confDir, &stored); err != nil {
+		t.Fatalf("EnsureOriginDir: %v", err)
+	}
+	if stored.OriginDir == "" {
+		t.Fatal("expected the origin directory to be stamped")
+	}
+	if err := p.UpdateDirScopeFromCWD(confDir, summaries[0].ID); err != nil {
+		t.Fatalf("UpdateDirScopeFromCWD: %v", err)
+	}
+	wd, _ := os.Getwd()
+	canonical, _ := canonicalDir(wd)
+	if id, _ := store.DirBinding(canonical); id != summaries[0].ID {
+		t.Fatalf("expected the cwd bound in the store, got %q", id)
+	}
+}
@@ -0,0 +1,409 @@
+// Package checkpoint snapshots the files agent tools are about to write, so
+// that `clai undo` can restore them whether or not they are tracked by git.
+//
+// Checkpoints live under <config>/checkpoints/<chatID>/, one directory per
+// tool call named <seq>-<toolCallID>. Each holds a manifest.json and one blob
+// per snapshotted file. They are removed with their conversation, and pruned
+// by MaxPerChat and MaxAge.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DirName is the directory of the config dir the checkpoints are kept in.
+const DirName = "checkpoints"
+
+// MaxFileSize caps the size of a snapshotted file. Larger files are recorded
+// as skipped, so that undo can tell they were not restored.
+const MaxFileSize = 16 << 20
+
+// MaxPerChat is the amount of checkpoints kept per chat. Snapshot drops the
+// oldest ones beyond it, which can then no longer be undone to.
+const MaxPerChat = 100
+
+// MaxAge is how long the checkpoints of a chat are kept after its last one.
+// Snapshot prunes older chats when it starts the checkpoints of a chat.
+const MaxAge = 30 * 24 * time.Hour
+
+const manifestName = "manifest.json"
+
+// Entry is one path of a checkpoint, as it was before the tool call.
+type Entry struct {
+	// Path is absolute.
+	Path string `json:"path"`
+	// Existed is false for paths the tool call created. Undo removes them.
+	Existed bool        `json:"existed"`
+	Mode    fs.FileMode `json:"mode,omitempty"`
+	// Blob is the file name of the content within the checkpoint dir.
+	Blob string `json:"blob,omitempty"`
+	// Skipped is why the content of an existing file was not kept.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// Checkpoint is the state of the paths of one tool call, taken before it ran.
+type Checkpoint struct {
+	Seq        int       `json:"seq"`
+	ChatID     string    `json:"chat_id"`
+	ToolCallID string    `json:"tool_call_id"`
+	Tool       string    `json:"tool"`
+	Created    time.Time `json:"created"`
+	Entries    []Entry   `json:"entries"`
+
+	dir string
+}
+
+// Change is what undo did to one path.
+type Change struct {
+	Path string
+	// Action is "restored", "removed" or "kept".
+	Action string
+	// Reason explains a kept path.
+	Reason string
+}
+
+// Store is the checkpoint store of a config dir.
+type Store struct {
+	root string
+}
+
+// New returns the checkpoint store of the config dir confDir.
+func New(confDir string) Store {
+	return Store{root: filepath.Join(confDir, DirName)}
+}
+
+var unsafeIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+func validChatID(chatID string) error {
+	if chatID == "" || filepath.Base(chatID) != chatID || chatID == "." || chatID == ".." {
+		return fmt.Errorf("invalid chat id %q", chatID)
+	}
+	return nil
+}
+
+// Snapshot records the current state of paths, and of the missing parent
+// directories a write would create, before the tool call toolCallID of tool
+// runs in chat chatID.
+func (s Store) Snapshot(chatID, toolCallID, tool string, paths []string) (Checkpoint, error) {
+	if err := validChatID(chatID); err != nil {
+		return Checkpoint{}, err
+	}
+	existing, err := s.List(chatID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	cp := Checkpoint{
+		Seq:        1,
+		ChatID:     chatID,
+		ToolCallID: toolCallID,
+		Tool:       tool,
+		Created:    time.Now(),
+	}
+	if len(existing) > 0 {
+		cp.Seq = existing[len(existing)-1].Seq + 1
+	} else if err := s.Prune(MaxAge); err != nil {
+		return Checkpoint{}, err
+	}
+	for _, old := range existing[:max(len(existing)-MaxPerChat+1, 0)] {
+		if err := os.RemoveAll(old.dir); err != nil {
+			return Checkpoint{}, fmt.Errorf("remove checkpoint %d: %w", old.Seq, err)
+		}
+	}
+	name := fmt.Sprintf("%04d", cp.Seq)
+	if toolCallID != "" {
+		name += "-" + unsafeIDChars.ReplaceAllString(toolCallID, "_")
+	}
+	cp.dir = filepath.Join(s.root, chatID, name)
+	if err := os.MkdirAll(cp.dir, 0o755); err != nil {
+		return Checkpoint{}, fmt.Errorf("create checkpoint dir: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return Checkpoint{}, fmt.Errorf("resolve %q: %w", p, err)
+		}
+		abs = followSymlinks(abs)
+		// The path comes first and its missing parents after it, deepest
+		// first, which is the order undo removes them in.
+		for cur := abs; !seen[cur]; cur = filepath.Dir(cur) {
+			seen[cur] = true
+			e, exists, err := cp.snapshotPath(cur, len(cp.Entries))
+			if err != nil {
+				return Checkpoint{}, err
+			}
+			if e != nil {
+				cp.Entries = append(cp.Entries, *e)
+			}
+			if exists || filepath.Dir(cur) == cur {
+				break
+			}
+		}
+	}
+
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	// The manifest is written last: a checkpoint without one is incomplete
+	// and ignored.
+	if err := os.WriteFile(filepath.Join(cp.dir, manifestName), b, 0o644); err != nil {
+		return Checkpoint{}, fmt.Errorf("write checkpoint: %w", err)
+	}
+	return cp, nil
+}
+
+// followSymlinks returns the file path refers to, as the write tools write
+// through symlinks. A dangling link resolves to the target a write would
+// create, so undo removes it again.
+func followSymlinks(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return path
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	return filepath.Clean(target)
+}
+
+// snapshotPath returns the entry of path and whether it exists. Existing
+// directories need no entry, as undo never removes them.
+func (cp Checkpoint) snapshotPath(path string, n int) (*Entry, bool, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Entry{Path: path}, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return nil, true, nil
+	}
+	e := &Entry{Path: path, Existed: true, Mode: info.Mode().Perm()}
+	switch {
+	case !info.Mode().IsRegular():
+		e.Skipped = "not a regular file"
+	case info.Size() > MaxFileSize:
+		e.Skipped = fmt.Sprintf("larger than %d MiB", MaxFileSize>>20)
+	default:
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false, fmt.Errorf("read %s: %w", path, err)
+		}
+		e.Blob = strconv.Itoa(n)
+		if err := os.WriteFile(filepath.Join(cp.dir, e.Blob), b, 0o600); err != nil {
+			return nil, false, fmt.Errorf("snapshot %s: %w", path, err)
+		}
+	}
+	return e, true, nil
+}
+
+// List returns the checkpoints of chatID, oldest first.
+func (s Store) List(chatID string) ([]Checkpoint, error) {
+	if err := validChatID(chatID); err != nil {
+		return nil, err
+	}
+	chatDir := filepath.Join(s.root, chatID)
+	dirs, err := os.ReadDir(chatDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list checkpoints: %w", err)
+	}
+	var ret []Checkpoint
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(chatDir, d.Name())
+		b, err := os.ReadFile(filepath.Join(dir, manifestName))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read checkpoint %s: %w", d.Name(), err)
+		}
+		var cp Checkpoint
+		if err := json.Unmarshal(b, &cp); err != nil {
+			return nil, fmt.Errorf("unmarshal checkpoint %s: %w", d.Name(), err)
+		}
+		cp.dir = dir
+		ret = append(ret, cp)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Seq < ret[j].Seq })
+	return ret, nil
+}
+
+// Remove deletes the checkpoints of chatID, as when its conversation is
+// deleted.
+func (s Store) Remove(chatID string) error {
+	if err := validChatID(chatID); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(s.root, chatID)); err != nil {
+		return fmt.Errorf("remove checkpoints of %q: %w", chatID, err)
+	}
+	return nil
+}
+
+// Prune deletes the checkpoints of the chats which got none within maxAge.
+// Adding a checkpoint updates the modification time of the chat's dir.
+func (s Store) Prune(maxAge time.Duration) error {
+	chats, err := os.ReadDir(s.root)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("list checkpoints: %w", err)
+	}
+	cutoff := time.Now().Add(-maxAge)
+	for _, c := range chats {
+		info, err := c.Info()
+		if err != nil || !c.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := s.Remove(c.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Latest returns the chat with the most recent checkpoint.
+func (s Store) Latest() (string, error) {
+	chats, err := os.ReadDir(s.root)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("list checkpoints: %w", err)
+	}
+	var latest string
+	var latestAt time.Time
+	for _, c := range chats {
+		if !c.IsDir() {
+			continue
+		}
+		cps, err := s.List(c.Name())
+		if err != nil {
+			return "", err
+		}
+		if len(cps) > 0 && cps[len(cps)-1].Created.After(latestAt) {
+			latest, latestAt = c.Name(), cps[len(cps)-1].Created
+		}
+	}
+	if latest == "" {
+		return "", errors.New("no checkpoints found, they are taken when file writing tools run")
+	}
+	return latest, nil
+}
+
+// Undo restores the files of chatID to their state before the tool call
+// toToolCallID, or before the last tool call when it is empty. The restored
+// checkpoints are removed, newest first, so that undo can be repeated.
+func (s Store) Undo(chatID, toToolCallID string) ([]Checkpoint, []Change, error) {
+	cps, err := s.List(chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(cps) == 0 {
+		return nil, nil, fmt.Errorf("chat %q has no checkpoints", chatID)
+	}
+	from := len(cps) - 1
+	if toToolCallID != "" {
+		from = -1
+		for i, cp := range cps {
+			if cp.ToolCallID == toToolCallID {
+				from = i
+				break
+			}
+		}
+		if from < 0 {
+			return nil, nil, fmt.Errorf("chat %q has no checkpoint for tool call %q, see 'clai checkpoints %s'", chatID, toToolCallID, chatID)
+		}
+	}
+
+	var undone []Checkpoint
+	var changes []Change
+	for i := len(cps) - 1; i >= from; i-- {
+		c, err := cps[i].restore()
+		changes = append(changes, c...)
+		if err != nil {
+			return undone, changes, fmt.Errorf("restore checkpoint %d (%s %s): %w", cps[i].Seq, cps[i].Tool, cps[i].ToolCallID, err)
+		}
+		if err := os.RemoveAll(cps[i].dir); err != nil {
+			return undone, changes, fmt.Errorf("remove checkpoint %d: %w", cps[i].Seq, err)
+		}
+		undone = append(undone, cps[i])
+	}
+	return undone, changes, nil
+}
+
+func (cp Checkpoint) restore() ([]Change, error) {
+	var changes []Change
+	for _, e := range cp.Entries {
+		switch {
+		case e.Skipped != "":
+			changes = append(changes, Change{Path: e.Path, Action: "kept", Reason: e.Skipped})
+		case e.Existed:
+			b, err := os.ReadFile(filepath.Join(cp.dir, e.Blob))
+			if err != nil {
+				return changes, fmt.Errorf("read snapshot of %s: %w", e.Path, err)
+			}
+			if err := os.MkdirAll(filepath.Dir(e.Path), 0o755); err != nil {
+				return changes, fmt.Errorf("create dirs for %s: %w", e.Path, err)
+			}
+			if err := os.WriteFile(e.Path, b, e.Mode); err != nil {
+				return changes, fmt.Errorf("restore %s: %w", e.Path, err)
+			}
+			if err := os.Chmod(e.Path, e.Mode); err != nil {
+				return changes, fmt.Errorf("restore mode of %s: %w", e.Path, err)
+			}
+			changes = append(changes, Change{Path: e.Path, Action: "restored"})
+		default:
+			err := os.Remove(e.Path)
+			switch {
+			case err == nil:
+				changes = append(changes, Change{Path: e.Path, Action: "removed"})
+			case errors.Is(err, fs.ErrNotExist):
+			case isNotEmpty(e.Path):
+				changes = append(changes, Change{Path: e.Path, Action: "kept", Reason: "directory is not empty"})
+			default:
+				return changes, fmt.Errorf("remove %s: %w", e.Path, err)
+			}
+		}
+	}
+	return changes, nil
+}
+
+func isNotEmpty(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) > 0
+}
+
+// Paths returns the paths of the checkpoint, without the parent directories
+// the tool call would create.
+func (cp Checkpoint) Paths() []string {
+	var ret []string
+	for i, e := range cp.Entries {
+		// A missing parent follows its child.
+		if i > 0 && !e.Existed && strings.HasPrefix(cp.Entries[i-1].Path, e.Path+string(filepath.Separator)) {
+			continue
+		}
+		ret = append(ret, e.Path)
+	}
+	return ret
+}
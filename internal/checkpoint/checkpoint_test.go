@@ -0,0 +1,289 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(b)
+}
+
+func TestUndo_RestoresModifiedFile(t *testing.T) {
+	store := New(t.TempDir())
+	work := t.TempDir()
+	f := filepath.Join(work, "main.go")
+	writeFile(t, f, "before\n")
+	if err := os.Chmod(f, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Snapshot("chat", "call_1", "write_file", []string{f}); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	writeFile(t, f, "after\n")
+
+	undone, changes, err := store.Undo("chat", "")
+	if err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if len(undone) != 1 || len(changes) != 1 || changes[0].Action != "restored" {
+		t.Fatalf("unexpected undo result: %+v %+v", undone, changes)
+	}
+	if got := readFile(t, f); got != "before\n" {
+		t.Fatalf("expected content restored, got %q", got)
+	}
+	if info, _ := os.Stat(f); info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected mode restored, got %v", info.Mode().Perm())
+	}
+	if cps, _ := store.List("chat"); len(cps) != 0 {
+		t.Fatalf("expected the checkpoint to be consumed, got %d", len(cps))
+	}
+}
+
+func TestUndo_RestoresEditThroughSymlink(t *testing.T) {
+	store := New(t.TempDir())
+	work := t.TempDir()
+	target := filepath.Join(work, "real.go")
+	link := filepath.Join(work, "link.go")
+	writeFile(t, target, "before\n")
+	if err := os.Symlink("real.go", link); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Snapshot("chat", "call_1", "write_file", []string{link}); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	writeFile(t, link, "after\n")
+
+	if _, _, err := store.Undo("chat", ""); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if got := readFile(t, target); got != "before\n" {
+		t.Fatalf("expected target restored, got %q", got)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected the link to be kept, got %v %v", info, err)
+	}
+}
+
+func TestUndo_RemovesFileCreatedThroughDanglingSymlink(t *testing.T) {
+	store := New(t.TempDir())
+	work := t.TempDir()
+	target := filepath.Join(work, "real.go")
+	link := filepath.Join(work, "link.go")
+	if err := os.Symlink("real.go", link); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Snapshot("chat", "call_1", "write_file", []string{link}); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	writeFile(t, link, "new\n")
+
+	if _, _, err := store.Undo("chat", ""); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("expected the created target removed, got %v", err)
+	}
+	if _, err := os.Lstat(link); err != nil {
+		t.Fatalf("expected the link to be kept: %v", err)
+	}
+}
+
+func TestUndo_RemovesCreatedFileAndParents(t *testing.T) {
+	store := New(t.TempDir())
+	work := t.TempDir()
+	f := filepath.Join(work, "a", "b", "new.txt")
+
+	cp, err := store.Snapshot("chat", "call_1", "write_file", []string{f})
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if len(cp.Entries) != 3 {
+		t.Fatalf("expected the file and its two missing parents, got %+v", cp.Entries)
+	}
+	if got := cp.Paths(); len(got) != 1 || got[0] != f {
+		t.Fatalf("expected Paths to hide the parents, got %v", got)
+	}
+	writeFile(t, f, "hello\n")
+
+	if _, _, err := store.Undo("chat", ""); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(work, "a")); !os.IsNotExist(err) {
+		t.Fatalf("expected created dirs to be removed, err: %v", err)
+	}
+}
+
+func TestUndo_KeepsNonEmptyCreatedDir(t *testing.T) {
+	store := New(t.TempDir())
+	work := t.TempDir()
+	dir := filepath.Join(work, "pkg")
+
+	if _, err := store.Snapshot("chat", "call_1", "mkdir", []string{dir}); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	writeFile(t, filepath.Join(dir, "user.txt"), "written later by hand\n")
+
+	_, changes, err := store.Undo("chat", "")
+	if err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Action != "kept" || !strings.Contains(changes[0].Reason, "not empty") {
+		t.Fatalf("expected the dir to be kept, got %+v", changes)
+	}
+}
+
+func TestUndo_ToToolCallUndoesEverythingSince(t *testing.T) {
+	store := New(t.TempDir())
+	work := t.TempDir()
+	moved := filepath.Join(work, "old.txt")
+	edited := filepath.Join(work, "edit.txt")
+	writeFile(t, moved, "old\n")
+	writeFile(t, edited, "v1\n")
+
+	if _, err := store.Snapshot("chat", "call_1", "sed", []string{edited}); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, edited, "v2\n")
+	if _, err := store.Snapshot("chat", "call_2", "str_replace", []string{edited}); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, edited, "v3\n")
+	target := filepath.Join(work, "new.txt")
+	if _, err := store.Snapshot("chat", "call_3", "apply_patch", []string{moved, target}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(moved, target); err != nil {
+		t.Fatal(err)
+	}
+
+	undone, _, err := store.Undo("chat", "call_2")
+	if err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if len(undone) != 2 || undone[0].ToolCallID != "call_3" || undone[1].ToolCallID != "call_2" {
+		t.Fatalf("expected call_3 then call_2 undone, got %+v", undone)
+	}
+	if got := readFile(t, edited); got != "v2\n" {
+		t.Fatalf("expected edit.txt back to v2, got %q", got)
+	}
+	if got := readFile(t, moved); got != "old\n" {
+		t.Fatalf("expected old.txt restored, got %q", got)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("expected new.txt removed, err: %v", err)
+	}
+	cps, err := store.List("chat")
+	if err != nil || len(cps) != 1 || cps[0].ToolCallID != "call_1" {
+		t.Fatalf("expected only call_1 left, got %+v, err: %v", cps, err)
+	}
+
+	if _, _, err := store.Undo("chat", "call_9"); err == nil || !strings.Contains(err.Error(), "call_9") {
+		t.Fatalf("expected unknown tool call error, got %v", err)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	store := New(t.TempDir())
+	if _, err := store.Latest(); err == nil {
+		t.Fatal("expected error without checkpoints")
+	}
+	f := filepath.Join(t.TempDir(), "f.txt")
+	if _, err := store.Snapshot("older", "c1", "write_file", []string{f}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Snapshot("newer", "c1", "write_file", []string{f}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Latest()
+	if err != nil || got != "newer" {
+		t.Fatalf("expected newer, got %q, err: %v", got, err)
+	}
+}
+
+func TestSnapshot_RejectsPathLikeChatID(t *testing.T) {
+	store := New(t.TempDir())
+	for _, id := range []string{"", "..", "a/b"} {
+		if _, err := store.Snapshot(id, "c", "write_file", []string{"x"}); err == nil {
+			t.Errorf("expected chat id %q to be rejected", id)
+		}
+	}
+}
+
+func TestSnapshot_KeepsMaxPerChat(t *testing.T) {
+	store := New(t.TempDir())
+	f := filepath.Join(t.TempDir(), "f.txt")
+	for i := range MaxPerChat + 2 {
+		if _, err := store.Snapshot("chat", "c"+strconv.Itoa(i), "write_file", []string{f}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cps, err := store.List("chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cps) != MaxPerChat || cps[0].ToolCallID != "c2" {
+		t.Fatalf("expected the newest %d checkpoints from c2, got %d from %s", MaxPerChat, len(cps), cps[0].ToolCallID)
+	}
+}
+
+func TestSnapshot_PrunesStaleChats(t *testing.T) {
+	confDir := t.TempDir()
+	store := New(confDir)
+	f := filepath.Join(t.TempDir(), "f.txt")
+	if _, err := store.Snapshot("stale", "c1", "write_file", []string{f}); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-MaxAge - time.Hour)
+	if err := os.Chtimes(filepath.Join(confDir, DirName, "stale"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Snapshot("fresh", "c1", "write_file", []string{f}); err != nil {
+		t.Fatal(err)
+	}
+	if cps, _ := store.List("stale"); len(cps) != 0 {
+		t.Fatalf("expected stale chat pruned, got %+v", cps)
+	}
+	if cps, _ := store.List("fresh"); len(cps) != 1 {
+		t.Fatalf("expected fresh chat kept, got %+v", cps)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	store := New(t.TempDir())
+	f := filepath.Join(t.TempDir(), "f.txt")
+	if _, err := store.Snapshot("chat", "c1", "write_file", []string{f}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remove("chat"); err != nil {
+		t.Fatal(err)
+	}
+	if cps, _ := store.List("chat"); len(cps) != 0 {
+		t.Fatalf("expected no checkpoints, got %+v", cps)
+	}
+	if err := store.Remove(".."); err == nil {
+		t.Fatal("expected path-like chat id to be rejected")
+	}
+}
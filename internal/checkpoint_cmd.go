@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/baalimago/clai/internal/checkpoint"
+	"github.com/baalimago/clai/internal/models"
+	"github.com/baalimago/go_away_boilerplate/pkg/table"
+)
+
+const (
+	undoUsage        = "usage: clai undo [chatID] [--to <toolCallID>]"
+	checkpointsUsage = "usage: clai checkpoints [chatID]"
+)
+
+// undoCmd handles `clai undo`. args[0] is the command. Without a chat ID, the
+// chat with the most recent checkpoint is undone.
+func undoCmd(confDir string, args []string) (models.Querier, error) {
+	var chatID, toCallID string
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--to" || args[i] == "-to":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing tool call ID after %s, %s", args[i], undoUsage)
+			}
+			i++
+			toCallID = args[i]
+		case chatID == "":
+			chatID = args[i]
+		default:
+			return nil, fmt.Errorf("unexpected argument %q, %s", args[i], undoUsage)
+		}
+	}
+	store := checkpoint.New(confDir)
+	if chatID == "" {
+		var err error
+		if chatID, err = store.Latest(); err != nil {
+			return nil, err
+		}
+	}
+	undone, changes, err := store.Undo(chatID, toCallID)
+	if werr := writeUndo(os.Stdout, chatID, undone, changes); werr != nil && err == nil {
+		err = fmt.Errorf("failed to print undo: %w", werr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, table.ErrUserInitiatedExit
+}
+
+func writeUndo(out io.Writer, chatID string, undone []checkpoint.Checkpoint, changes []checkpoint.Change) error {
+	for _, c := range changes {
+		line := fmt.Sprintf("%-8s %s", c.Action, c.Path)
+		if c.Reason != "" {
+			line += " (" + c.Reason + ")"
+		}
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return err
+		}
+	}
+	if len(undone) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(out, "undid %d tool call(s) of chat %s\n", len(undone), chatID)
+	return err
+}
+
+// checkpointsCmd handles `clai checkpoints`. args[0] is the command. Without
+// a chat ID, the chat with the most recent checkpoint is listed.
+func checkpointsCmd(confDir string, args []string) (models.Querier, error) {
+	if len(args) > 2 {
+		return nil, fmt.Errorf("unexpected arguments %q, %s", args[1:], checkpointsUsage)
+	}
+	store := checkpoint.New(confDir)
+	var chatID string
+	if len(args) == 2 {
+		chatID = args[1]
+	} else {
+		var err error
+		if chatID, err = store.Latest(); err != nil {
+			return nil, err
+		}
+	}
+	cps, err := store.List(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeCheckpoints(os.Stdout, chatID, cps); err != nil {
+		return nil, fmt.Errorf("failed to print checkpoints: %w", err)
+	}
+	return nil, table.ErrUserInitiatedExit
+}
+
+func writeCheckpoints(out io.Writer, chatID string, cps []checkpoint.Checkpoint) error {
+	if len(cps) == 0 {
+		_, err := fmt.Fprintf(out, "Chat %s has no checkpoints.\n", chatID)
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SEQ\tTOOL CALL\tTOOL\tCREATED\tPATHS")
+	for _, cp := range cps {
+		paths := cp.Paths()
+		summary := ""
+		if len(paths) > 0 {
+			summary = paths[0]
+		}
+		if len(paths) > 1 {
+			summary += fmt.Sprintf(" (+%d more)", len(paths)-1)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", cp.Seq, cp.ToolCallID, cp.Tool, cp.Created.Format("2006-01-02 15:04"), summary)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "undo the last call with 'clai undo %s', or back to one with 'clai undo %s --to <tool call>'\n", chatID, chatID)
+	return err
+}
@@ -9,8 +9,10 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/baalimago/clai/internal/modelcatalog"
 	"github.com/baalimago/clai/internal/tools"
 	"github.com/baalimago/clai/internal/utils"
+	"github.com/baalimago/clai/internal/vendors/custom"
 	"github.com/baalimago/go_away_boilerplate/pkg/table"
 )
 
@@ -55,14 +57,17 @@ type completionFlagSpec struct {
 }
 
 var completionCommands = []string{
+	"batch",
 	"c",
 	"chat",
+	"checkpoints",
 	"completion",
 	"confdir",
 	"g",
 	"glob",
 	"h",
 	"help",
+	"models",
 	"p",
 	"photo",
 	"profiles",
@@ -74,6 +79,7 @@ var completionCommands = []string{
 	"setup",
 	"t",
 	"tools",
+	"undo",
 	"version",
 	"v",
 	"video",
@@ -90,19 +96,28 @@ var completionPromptCommands = map[string]struct{}{
 	"g":     {},
 }
 
-var completionChatSubcommands = []string{"continue", "delete", "dir", "dirv2", "help", "list"}
+var completionChatSubcommands = []string{"continue", "delete", "dir", "dirv2", "help", "list", "migrate-store"}
+
+var completionBatchSubcommands = []string{"fetch", "status", "submit"}
+
+var completionModelsSubcommands = []string{"anthropic", "gemini", "google", "mistral", "ollama", "openai", "openrouter", "routes"}
 
 var completionGlobalFlags = []completionFlagSpec{
 	{Name: "-I", TakesValue: true},
+	{Name: "-a", TakesValue: true, ValueKind: completionResultKindFile},
 	{Name: "-add-shell-context", TakesValue: true, ValueSource: "shell-context"},
 	{Name: "-asc", TakesValue: true, ValueSource: "shell-context"},
+	{Name: "-attach", TakesValue: true, ValueKind: completionResultKindFile},
 	{Name: "-chat-model", TakesValue: true, ValueSource: "model"},
 	{Name: "-cm", TakesValue: true, ValueSource: "model"},
 	{Name: "-dir-reply"},
 	{Name: "-dre"},
 	{Name: "-g", TakesValue: true},
 	{Name: "-glob", TakesValue: true},
-	{Name: "-i"},
+	{Name: "-glob-exclude", TakesValue: true},
+	{Name: "-gx", TakesValue: true},
+	{Name: "-i", TakesValue: true, ValueKind: completionResultKindFile},
+	{Name: "-image", TakesValue: true, ValueKind: completionResultKindFile},
 	{Name: "-p", TakesValue: true, ValueSource: "profile"},
 	{Name: "-pd", TakesValue: true, ValueKind: completionResultKindDir},
 	{Name: "-photo-dir", TakesValue: true, ValueKind: completionResultKindDir},
@@ -167,6 +182,18 @@ func (e completionEngine) Complete(req completionRequest) completionResponse {
 			Items:        filterPlain(current, completionChatSubcommands),
 		}
 	}
+	if len(args) == 2 && args[0] == "batch" {
+		return completionResponse{
+			ReplaceToken: current,
+			Items:        filterPlain(current, completionBatchSubcommands),
+		}
+	}
+	if len(args) == 2 && args[0] == "models" {
+		return completionResponse{
+			ReplaceToken: current,
+			Items:        filterPlain(current, completionModelsSubcommands),
+		}
+	}
 	if len(args) >= 2 && args[0] == "tools" {
 		return completionResponse{
 			ReplaceToken: current,
@@ -376,6 +403,20 @@ func discoverModelHistory(configDir string) ([]string, error) {
 	}
 
 	uniq := map[string]struct{}{}
+	// A broken vendor file is reported when a model of it is queried;
+	// completion keeps offering everything else.
+	customVendors, _ := custom.Load(configDir)
+	for _, v := range customVendors {
+		for _, m := range v.Models {
+			uniq[v.Prefix+m] = struct{}{}
+		}
+	}
+	// Likewise a broken model catalog only loses its models.
+	if catalog, err := modelcatalog.Load(configDir); err == nil {
+		for _, id := range catalog.IDs() {
+			uniq[id] = struct{}{}
+		}
+	}
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -384,7 +425,12 @@ func discoverModelHistory(configDir string) ([]string, error) {
 		if filepath.Ext(name) != ".json" {
 			continue
 		}
-		model, ok := modelFromConfigFilename(strings.TrimSuffix(name, ".json"))
+		base := strings.TrimSuffix(name, ".json")
+		if model, ok := customModelFromConfigFilename(customVendors, base); ok {
+			uniq[model] = struct{}{}
+			continue
+		}
+		model, ok := modelFromConfigFilename(base)
 		if !ok || strings.TrimSpace(model) == "" {
 			continue
 		}
@@ -399,6 +445,17 @@ func discoverModelHistory(configDir string) ([]string, error) {
 	return out, nil
 }
 
+// customModelFromConfigFilename maps <vendor>_chat_<model> model configs of
+// user-defined vendors back to their prefixed model name.
+func customModelFromConfigFilename(vendors []custom.Vendor, base string) (string, bool) {
+	for _, v := range vendors {
+		if model, ok := strings.CutPrefix(base, v.Name+"_chat_"); ok && model != "" {
+			return v.Prefix + model, true
+		}
+	}
+	return "", false
+}
+
 func modelFromConfigFilename(base string) (string, bool) {
 	switch {
 	case strings.HasPrefix(base, "openai_gpt_"):
@@ -415,6 +472,10 @@ func modelFromConfigFilename(base string) (string, bool) {
 		return strings.TrimPrefix(base, "xai_grok_"), true
 	case strings.HasPrefix(base, "mistral_mistral_"):
 		return strings.TrimPrefix(base, "mistral_mistral_"), true
+	case strings.HasPrefix(base, "azure_deployment_"):
+		return "azure:" + strings.TrimPrefix(base, "azure_deployment_"), true
+	case strings.HasPrefix(base, "bedrock_converse_"):
+		return "bedrock:" + strings.TrimPrefix(base, "bedrock_converse_"), true
 	case strings.HasPrefix(base, "openrouter_chat_"):
 		return "or:" + strings.ReplaceAll(strings.TrimPrefix(base, "openrouter_chat_"), "_", "/"), true
 	case strings.HasPrefix(base, "ollama_"):
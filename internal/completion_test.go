@@ -7,6 +7,7 @@ import (
 	"slices"
 	"testing"
 
+	"github.com/baalimago/clai/internal/modelcatalog"
 	"github.com/baalimago/clai/internal/tools"
 	pubmodels "github.com/baalimago/clai/pkg/text/models"
 )
@@ -46,22 +47,29 @@ func TestCompletionEngineComplete(t *testing.T) {
 			{
 				name:        "top level after trailing space lists commands and flags",
 				line:        []string{"clai", ""},
-				wantValues:  []string{"c", "chat", "completion", "confdir", "g", "glob", "h", "help", "p", "photo", "profiles", "q", "query", "re", "replay", "s", "setup", "t", "tools", "v", "version", "video", "-I", "-add-shell-context", "-asc", "-chat-model", "-cm", "-dir-reply", "-dre", "-g", "-glob", "-i", "-p", "-pd", "-photo-dir", "-photo-model", "-photo-prefix", "-pm", "-pp", "-profile", "-profile-path", "-prp", "-r", "-raw", "-re", "-replace", "-reply", "-t", "-tools", "-vd", "-video-dir", "-video-model", "-video-prefix", "-vm", "-vp"},
-				wantKinds:   repeatKind(completionResultKindPlain, 55),
+				wantValues:  []string{"batch", "c", "chat", "checkpoints", "completion", "confdir", "g", "glob", "h", "help", "models", "p", "photo", "profiles", "q", "query", "re", "replay", "s", "setup", "t", "tools", "undo", "v", "version", "video", "-I", "-a", "-add-shell-context", "-asc", "-attach", "-chat-model", "-cm", "-dir-reply", "-dre", "-g", "-glob", "-glob-exclude", "-gx", "-i", "-image", "-p", "-pd", "-photo-dir", "-photo-model", "-photo-prefix", "-pm", "-pp", "-profile", "-profile-path", "-prp", "-r", "-raw", "-re", "-replace", "-reply", "-t", "-tools", "-vd", "-video-dir", "-video-model", "-video-prefix", "-vm", "-vp"},
+				wantKinds:   repeatKind(completionResultKindPlain, 64),
 				wantReplace: "",
 			},
 			{
 				name:        "dash completes global flags",
 				line:        []string{"clai", "-"},
-				wantValues:  []string{"-I", "-add-shell-context", "-asc", "-chat-model", "-cm", "-dir-reply", "-dre", "-g", "-glob", "-i", "-p", "-pd", "-photo-dir", "-photo-model", "-photo-prefix", "-pm", "-pp", "-profile", "-profile-path", "-prp", "-r", "-raw", "-re", "-replace", "-reply", "-t", "-tools", "-vd", "-video-dir", "-video-model", "-video-prefix", "-vm", "-vp"},
-				wantKinds:   repeatKind(completionResultKindPlain, 33),
+				wantValues:  []string{"-I", "-a", "-add-shell-context", "-asc", "-attach", "-chat-model", "-cm", "-dir-reply", "-dre", "-g", "-glob", "-glob-exclude", "-gx", "-i", "-image", "-p", "-pd", "-photo-dir", "-photo-model", "-photo-prefix", "-pm", "-pp", "-profile", "-profile-path", "-prp", "-r", "-raw", "-re", "-replace", "-reply", "-t", "-tools", "-vd", "-video-dir", "-video-model", "-video-prefix", "-vm", "-vp"},
+				wantKinds:   repeatKind(completionResultKindPlain, 38),
 				wantReplace: "-",
 			},
 			{
 				name:        "chat subcommands",
 				line:        []string{"clai", "chat", ""},
-				wantValues:  []string{"continue", "delete", "dir", "dirv2", "help", "list"},
-				wantKinds:   repeatKind(completionResultKindPlain, 6),
+				wantValues:  []string{"continue", "delete", "dir", "dirv2", "help", "list", "migrate-store"},
+				wantKinds:   repeatKind(completionResultKindPlain, 7),
+				wantReplace: "",
+			},
+			{
+				name:        "models subcommands",
+				line:        []string{"clai", "models", ""},
+				wantValues:  []string{"anthropic", "gemini", "google", "mistral", "ollama", "openai", "openrouter", "routes"},
+				wantKinds:   repeatKind(completionResultKindPlain, 8),
 				wantReplace: "",
 			},
 			{
@@ -264,7 +272,8 @@ func TestCompletionEngineComplete_FlagShortcuts(t *testing.T) {
 		{name: "profile path long flag is suggested", prefix: "-profile-pa", wantSeen: "-profile-path"},
 		{name: "replace short flag is suggested", prefix: "-I", wantSeen: "-I"},
 		{name: "replace long flag is suggested", prefix: "-rep", wantSeen: "-replace"},
-		{name: "stdin shortcut flag is suggested", prefix: "-i", wantSeen: "-i"},
+		{name: "image short flag is suggested", prefix: "-i", wantSeen: "-i"},
+		{name: "image long flag is suggested", prefix: "-ima", wantSeen: "-image"},
 		{name: "raw short flag is suggested", prefix: "-r", wantSeen: "-r"},
 		{name: "raw long flag is suggested", prefix: "-ra", wantSeen: "-raw"},
 		{name: "reply short flag is suggested", prefix: "-re", wantSeen: "-re"},
@@ -360,6 +369,53 @@ func TestLoadCompletionData_ModelsFromConfigHistory(t *testing.T) {
 	}
 }
 
+func TestLoadCompletionData_ModelsFromCustomVendors(t *testing.T) {
+	t.Parallel()
+
+	confDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(confDir, "vendors"), 0o755); err != nil {
+		t.Fatalf("mkdir vendors: %v", err)
+	}
+	vendor := `{"prefix":"lms:","base_url":"http://localhost:1234/v1","models":["qwen3-8b"]}`
+	if err := os.WriteFile(filepath.Join(confDir, "vendors", "lms.json"), []byte(vendor), 0o644); err != nil {
+		t.Fatalf("write vendor: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(confDir, "lms_chat_gpt-oss-20b.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write model config: %v", err)
+	}
+
+	data, err := loadCompletionData(confDir)
+	if err != nil {
+		t.Fatalf("loadCompletionData: %v", err)
+	}
+	want := []string{"lms:gpt-oss-20b", "lms:qwen3-8b"}
+	if !reflect.DeepEqual(data.Models, want) {
+		t.Fatalf("models: got %v want %v", data.Models, want)
+	}
+}
+
+func TestLoadCompletionData_ModelsFromCatalog(t *testing.T) {
+	t.Parallel()
+
+	confDir := t.TempDir()
+	catalog := `{"vendors":{"ollama":{"models":[{"id":"ollama:qwen3"}]},"openai":{"models":[{"id":"gpt-4.1"}]}}}`
+	if err := os.WriteFile(filepath.Join(confDir, modelcatalog.FileName), []byte(catalog), 0o644); err != nil {
+		t.Fatalf("write catalog: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(confDir, "openai_gpt_gpt-4.1.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write model config: %v", err)
+	}
+
+	data, err := loadCompletionData(confDir)
+	if err != nil {
+		t.Fatalf("loadCompletionData: %v", err)
+	}
+	want := []string{"gpt-4.1", "ollama:qwen3"}
+	if !reflect.DeepEqual(data.Models, want) {
+		t.Fatalf("models: got %v want %v", data.Models, want)
+	}
+}
+
 func TestCompletionEngineComplete_ModelValues(t *testing.T) {
 	t.Parallel()
 
@@ -17,6 +17,22 @@ func (m ModelPriceScheme) HasAnyPricing() bool {
 	return m.InputUSDPerToken > 0 || m.InputCachedUSDPerToken > 0 || m.OutputUSDPerToken > 0
 }
 
+// Estimate returns the cost of usage in USD. Cached prompt tokens are billed
+// at InputCachedUSDPerToken, or at InputUSDPerToken when it is unset.
+func (m ModelPriceScheme) Estimate(usage pub_models.Usage) float64 {
+	cachedPromptTokens := usage.PromptTokensDetails.CachedTokens
+	nonCachedPromptTokens := max(usage.PromptTokens-cachedPromptTokens, 0)
+
+	cachedPrice := m.InputCachedUSDPerToken
+	if cachedPrice == 0 {
+		cachedPrice = m.InputUSDPerToken
+	}
+
+	return float64(nonCachedPromptTokens)*m.InputUSDPerToken +
+		float64(cachedPromptTokens)*cachedPrice +
+		float64(usage.CompletionTokens)*m.OutputUSDPerToken
+}
+
 func (m *Manager) estimateUSD(usage *pub_models.Usage) (float64, error) {
 	if usage == nil {
 		return 0, fmt.Errorf("estimate query cost: missing usage")
@@ -30,18 +46,7 @@ func (m *Manager) estimateUSD(usage *pub_models.Usage) (float64, error) {
 		return 0, fmt.Errorf("estimate query cost: missing pricing")
 	}
 
-	cachedPromptTokens := usage.PromptTokensDetails.CachedTokens
-	nonCachedPromptTokens := max(usage.PromptTokens-cachedPromptTokens, 0)
-
-	cachedPrice := m.price.InputCachedUSDPerToken
-	if cachedPrice == 0 {
-		cachedPrice = m.price.InputUSDPerToken
-	}
-
-	total := float64(nonCachedPromptTokens)*m.price.InputUSDPerToken +
-		float64(cachedPromptTokens)*cachedPrice +
-		float64(usage.CompletionTokens)*m.price.OutputUSDPerToken
-
+	total := m.price.Estimate(*usage)
 	if m.debug {
 		ancli.Noticef("found total: %v", total)
 	}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"time"
 
@@ -129,6 +130,13 @@ func (m *Manager) seekCached() (ModelPriceScheme, error) {
 	return price, nil
 }
 
+// Price resolves the price of the model like Start does: the price cached in
+// the model config file, else the fetched one. A missing config file is a
+// cache miss, and the fetched price is then not cached.
+func (m *Manager) Price(ctx context.Context) (ModelPriceScheme, error) {
+	return m.resolveModelPrice(ctx)
+}
+
 func (m *Manager) resolveModelPrice(ctx context.Context) (ModelPriceScheme, error) {
 	cached, err := m.seekCached()
 	if err == nil {
@@ -138,7 +146,8 @@ func (m *Manager) resolveModelPrice(ctx context.Context) (ModelPriceScheme, erro
 		return cached, nil
 	}
 
-	if err != nil && !errors.Is(err, errCacheMiss) {
+	noConfig := errors.Is(err, fs.ErrNotExist)
+	if !noConfig && !errors.Is(err, errCacheMiss) {
 		return ModelPriceScheme{}, fmt.Errorf("failed to find latest usable: %w", err)
 	}
 
@@ -153,6 +162,9 @@ func (m *Manager) resolveModelPrice(ctx context.Context) (ModelPriceScheme, erro
 		return ModelPriceScheme{}, fmt.Errorf("failed to fetch model: %w", err)
 	}
 
+	if noConfig {
+		return price, nil
+	}
 	err = m.storePriceScheme(price)
 	if err != nil {
 		ancli.Errf("failed to store price scheme: %v", err)
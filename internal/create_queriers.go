@@ -2,6 +2,7 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -9,11 +10,14 @@ import (
 	"github.com/baalimago/clai/internal/chat"
 	"github.com/baalimago/clai/internal/models"
 	"github.com/baalimago/clai/internal/photo"
+	"github.com/baalimago/clai/internal/routing"
 	"github.com/baalimago/clai/internal/text"
 	"github.com/baalimago/clai/internal/utils"
 	"github.com/baalimago/clai/internal/vendors"
 	"github.com/baalimago/clai/internal/vendors/anthropic"
+	"github.com/baalimago/clai/internal/vendors/bedrock"
 	"github.com/baalimago/clai/internal/vendors/berget"
+	"github.com/baalimago/clai/internal/vendors/custom"
 	"github.com/baalimago/clai/internal/vendors/deepseek"
 	"github.com/baalimago/clai/internal/vendors/gemini"
 	"github.com/baalimago/clai/internal/vendors/huggingface"
@@ -30,156 +34,113 @@ import (
 )
 
 func selectTextQuerier(ctx context.Context, conf text.Configurations) (models.Querier, bool, error) {
-	var q models.Querier
-	found := false
-
-	if conf.Model == "test" || conf.Model == "mock_test" {
-		found = true
-		qTmp, err := text.NewQuerier(ctx, conf, new(vendors.Mock{}))
+	// User-defined vendors come first, so their prefixes may shadow the
+	// built-in routing table.
+	customVendors, err := custom.Load(conf.ConfigDir)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load custom vendors: %w", err)
+	}
+	if v, ok := custom.Match(customVendors, conf.Model); ok {
+		qTmp, err := text.NewQuerier(ctx, conf, custom.New(v, conf.Model))
 		if err != nil {
-			return nil, false, fmt.Errorf("failed to create test querier: %w", err)
+			return nil, true, fmt.Errorf("failed to create text querier: %w", err)
 		}
-		q = &qTmp
+		return &qTmp, true, nil
+	}
+
+	res, err := routing.Resolve(conf.Model)
+	if errors.Is(err, routing.ErrNoRoute) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
 	}
+	// Aliases resolve to the concrete model, which names the config file.
+	conf.Model = res.Model
 
-	// Explicit prefix routing: avoids accidental matches (e.g. model name contains "gpt").
-	if strings.HasPrefix(conf.Model, "hf:") || strings.HasPrefix(conf.Model, "huggingface:") {
-		found = true
+	var q models.Querier
+	switch res.Vendor {
+	case routing.VendorMock:
+		q, err = newTextQuerier(ctx, conf, new(vendors.Mock{}))
+	case routing.VendorHuggingFace:
 		defaultCpy := huggingface.DefaultChat
 		modelName := strings.TrimPrefix(conf.Model, "hf:")
 		modelName = strings.TrimPrefix(modelName, "huggingface:")
 		defaultCpy.Model = modelName
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, found, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
-	}
-
-	if strings.Contains(conf.Model, "claude") {
-		found = true
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorAnthropic:
 		defaultCpy := anthropic.Default
 		defaultCpy.Model = conf.Model
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, found, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
-	}
-
-	if strings.HasPrefix(conf.Model, "or:") {
-		found = true
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorOpenRouter:
 		defaultCpy := openrouter.Default
 		defaultCpy.Model = conf.Model
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, found, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
-		return q, found, nil
-	}
-
-	if strings.Contains(conf.Model, "gpt") && !strings.HasPrefix(conf.Model, "or:") {
-		found = true
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorOpenAI:
 		defaultCpy := openai.GptDefault
 		defaultCpy.Model = conf.Model
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, found, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
-	}
-
-	if strings.Contains(conf.Model, "deepseek") {
-		found = true
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorDeepSeek:
 		defaultCpy := deepseek.Default
 		defaultCpy.Model = conf.Model
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, found, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
-	}
-
-	if strings.Contains(conf.Model, "mercury") {
-		found = true
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorInception:
 		defaultCpy := inception.Default
 		defaultCpy.Model = conf.Model
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, found, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
-	}
-
-	if strings.Contains(conf.Model, "grok") {
-		found = true
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorXAI:
 		defaultCpy := xai.Default
 		defaultCpy.Model = conf.Model
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, found, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
-	}
-
-	if strings.Contains(conf.Model, "mistral") ||
-		strings.Contains(conf.Model, "mixtral") ||
-		strings.Contains(conf.Model, "codestral") ||
-		strings.Contains(conf.Model, "devstral") {
-		found = true
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorMistral:
 		defaultCpy := mistral.Default
 		defaultCpy.Model = conf.Model
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, false, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
-	}
-
-	if strings.Contains(conf.Model, "gemini") {
-		found = true
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorGoogle:
 		defaultCpy := gemini.Default
 		defaultCpy.Model = conf.Model
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, false, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
-	}
-
-	// process before mistral, in case we want to use mistral for ollama
-	if strings.HasPrefix(conf.Model, "ollama:") || conf.Model == "ollama" {
-		found = true
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorOllama:
 		defaultCpy := ollama.Default
-		if len(conf.Model) > 7 {
-			defaultCpy.Model = conf.Model[7:]
-		}
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, found, fmt.Errorf("failed to create text querier: %w", err)
+		// Setup trims the "ollama:" prefix, so keep it like the config
+		// files written so far.
+		if strings.HasPrefix(conf.Model, "ollama:") {
+			defaultCpy.Model = conf.Model
 		}
-		q = &qTmp
-	} else if strings.HasPrefix(conf.Model, "novita:") {
-		found = true
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorNovita:
 		defaultCpy := novita.Default
-		defaultCpy.Model = conf.Model[7:]
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, found, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
-	} else if strings.HasPrefix(conf.Model, "berget:") {
-		found = true
+		if after, ok := strings.CutPrefix(conf.Model, "novita:"); ok {
+			defaultCpy.Model = after
+		}
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorAzure:
+		defaultCpy := openai.AzureDefault
+		defaultCpy.Deployment = strings.TrimPrefix(conf.Model, "azure:")
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorBedrock:
+		defaultCpy := bedrock.Default
+		defaultCpy.Model = strings.TrimPrefix(conf.Model, "bedrock:")
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	case routing.VendorBerget:
 		defaultCpy := berget.Default
-		defaultCpy.Model = conf.Model[7:]
-		qTmp, err := text.NewQuerier(ctx, conf, &defaultCpy)
-		if err != nil {
-			return nil, found, fmt.Errorf("failed to create text querier: %w", err)
-		}
-		q = &qTmp
+		defaultCpy.Model = strings.TrimPrefix(conf.Model, "berget:")
+		q, err = newTextQuerier(ctx, conf, &defaultCpy)
+	default:
+		return nil, false, fmt.Errorf("model %q routes to vendor %q, which has no querier", conf.Model, res.Vendor)
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	return q, true, nil
+}
+
+func newTextQuerier[C models.StreamCompleter](ctx context.Context, conf text.Configurations, dfault C) (models.Querier, error) {
+	q, err := text.NewQuerier(ctx, conf, dfault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create text querier: %w", err)
 	}
-	return q, found, nil
+	return &q, nil
 }
 
 // CreateTextQuerier by checking the model for which vendor to use, then initiating
@@ -208,9 +169,11 @@ func CreateTextQuerier(ctx context.Context, conf text.Configurations) (models.Qu
 			conf.PostProccessedPrompt,
 			conf.InitialChat.Messages,
 			chat.NotCyclicalImport{
-				UseTools:   conf.UseTools,
-				UseProfile: conf.UseProfile,
-				Model:      conf.Model,
+				UseTools:            conf.UseTools,
+				UseProfile:          conf.UseProfile,
+				Model:               conf.Model,
+				ConversationBackend: conf.ConversationBackend,
+				ShowReasoning:       conf.ShowsReasoning(),
 			},
 			conf.Raw,
 			conf.Out,
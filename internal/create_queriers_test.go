@@ -2,13 +2,20 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/baalimago/clai/internal/cost"
+	"github.com/baalimago/clai/internal/modelcatalog"
 	"github.com/baalimago/clai/internal/photo"
+	"github.com/baalimago/clai/internal/routing"
 	"github.com/baalimago/clai/internal/text"
+	"github.com/baalimago/clai/internal/vendors/custom"
 	"github.com/baalimago/clai/internal/vendors/ollama"
 	"github.com/baalimago/clai/internal/vendors/openrouter"
 	"github.com/baalimago/go_away_boilerplate/pkg/debug"
@@ -109,6 +116,16 @@ func TestSelectTextQuerier_AllVendors(t *testing.T) {
 			model: "gemini-2.0",
 			env:   nil,
 		},
+		{
+			name:  "azure",
+			model: "azure:prod-gpt-4o",
+			env:   map[string]string{"AZURE_OPENAI_API_KEY": "k", "AZURE_OPENAI_ENDPOINT": "https://example.openai.azure.com"},
+		},
+		{
+			name:  "bedrock",
+			model: "bedrock:meta.llama3-70b-instruct-v1:0",
+			env:   map[string]string{"AWS_ACCESS_KEY_ID": "k", "AWS_SECRET_ACCESS_KEY": "s", "AWS_REGION": "us-east-1"},
+		},
 		{
 			name:  "ollama-pref",
 			model: "ollama:phi",
@@ -285,3 +302,109 @@ func TestSelectTextQuerier_OllamaDeepseekPrefersOllama(t *testing.T) {
 	testboil.FailTestIfDiff(t, typed.Model.Model, conf.Model)
 	testboil.FailTestIfDiff(t, typed.Model.URL, "http://localhost:11434/v1/chat/completions")
 }
+
+func TestSelectTextQuerier_CustomVendorPrefixWins(t *testing.T) {
+	tmp := t.TempDir()
+	vendorsDir := filepath.Join(tmp, "vendors")
+	if err := os.MkdirAll(vendorsDir, 0o755); err != nil {
+		t.Fatalf("mkdir vendors: %v", err)
+	}
+	vendorFile := `{"prefix":"lms:","base_url":"http://localhost:1234/v1","pricing":{"gpt-oss-20b":{"input_usd_per_token":0.5}}}`
+	if err := os.WriteFile(filepath.Join(vendorsDir, "lms.json"), []byte(vendorFile), 0o644); err != nil {
+		t.Fatalf("write vendor: %v", err)
+	}
+
+	// "gpt" in the name would route to openai without the custom prefix.
+	conf := text.Configurations{Model: "lms:gpt-oss-20b", ConfigDir: tmp}
+	q, found, err := selectTextQuerier(context.Background(), conf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !found {
+		t.Fatal("expected found")
+	}
+	typed, ok := q.(*text.Querier[*custom.Chat])
+	if !ok {
+		t.Fatalf("expected a custom vendor querier, got: %T", q)
+	}
+	testboil.FailTestIfDiff(t, typed.Model.StreamCompleter.Model, "gpt-oss-20b")
+	testboil.FailTestIfDiff(t, typed.Model.URL, "http://localhost:1234/v1/chat/completions")
+
+	b, err := os.ReadFile(filepath.Join(tmp, "lms_chat_gpt-oss-20b.json"))
+	if err != nil {
+		t.Fatalf("expected the model config file of the custom vendor: %v", err)
+	}
+	if !strings.Contains(string(b), `"input_usd_per_token":0.5`) {
+		t.Fatalf("expected the vendor pricing in the model config, got %s", b)
+	}
+}
+
+func TestSelectTextQuerier_AmbiguousModelIsAnError(t *testing.T) {
+	conf := text.Configurations{
+		Model:     "my-claude-vs-gpt-finetune",
+		ConfigDir: t.TempDir(),
+	}
+	_, _, err := selectTextQuerier(context.Background(), conf)
+	var ambiguous *routing.AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected ambiguity error, got %v", err)
+	}
+}
+
+func TestSelectTextQuerier_AliasResolvesToConcreteModel(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "k")
+	tmp := t.TempDir()
+	conf := text.Configurations{
+		Model:     "sonnet",
+		ConfigDir: tmp,
+	}
+	q, found, err := selectTextQuerier(context.Background(), conf)
+	if err != nil || !found || q == nil {
+		t.Fatalf("expected querier, got found=%v err=%v", found, err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "anthropic_claude_claude-sonnet-4-5.json")); err != nil {
+		t.Fatalf("expected the alias target to name the model config file: %v", err)
+	}
+}
+
+func TestWriteModelRoutes_CustomVendorsFirst(t *testing.T) {
+	var out strings.Builder
+	err := writeModelRoutes(&out, []custom.Vendor{{Name: "lmstudio", Prefix: "lms:"}})
+	if err != nil {
+		t.Fatalf("writeModelRoutes: %v", err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if !strings.HasPrefix(lines[1], "custom") || !strings.Contains(lines[1], "lms:") {
+		t.Fatalf("expected the custom vendor route first, got %q", lines[1])
+	}
+	if !strings.Contains(out.String(), "claude-sonnet-4-5") {
+		t.Fatalf("expected alias targets in the output, got:\n%s", out.String())
+	}
+}
+
+func TestListModels_UnknownVendor(t *testing.T) {
+	var out strings.Builder
+	err := listModels(context.Background(), &out, t.TempDir(), "nope")
+	if err == nil || !strings.Contains(err.Error(), "openrouter") {
+		t.Fatalf("expected the known vendors in the error, got %v", err)
+	}
+}
+
+func TestWriteModels(t *testing.T) {
+	var cache modelcatalog.Cache
+	cache.Set("openrouter", []modelcatalog.Model{{
+		ID:            "or:openai/gpt-5.2",
+		ContextWindow: 400000,
+		Price:         &cost.ModelPriceScheme{InputUSDPerToken: 0.00000125, OutputUSDPerToken: 0.00001},
+		Capabilities:  modelcatalog.Capabilities{Tools: true},
+	}}, time.Now())
+	var out strings.Builder
+	if err := writeModels(&out, cache, []string{"openrouter"}); err != nil {
+		t.Fatalf("writeModels: %v", err)
+	}
+	row := strings.Fields(strings.Split(out.String(), "\n")[1])
+	want := []string{"openrouter", "or:openai/gpt-5.2", "400000", "1.25", "10.00", "tools"}
+	if strings.Join(row, " ") != strings.Join(want, " ") {
+		t.Fatalf("got row %v, want %v", row, want)
+	}
+}
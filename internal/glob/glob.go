@@ -1,17 +1,47 @@
 package glob
 
 import (
+	"bytes"
 	"fmt"
+	"io/fs"
+	"math"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/baalimago/clai/internal/attachment"
+	"github.com/baalimago/clai/internal/ignore"
+	"github.com/baalimago/clai/internal/repomap"
 	"github.com/baalimago/clai/internal/utils"
 	pub_models "github.com/baalimago/clai/pkg/text/models"
 	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
 	"github.com/baalimago/go_away_boilerplate/pkg/misc"
 )
 
+// DefaultMaxTokens is the budget of the file contents when none is configured.
+const DefaultMaxTokens = 32000
+
+const (
+	// minTruncated is the smallest head, in bytes, worth sending of a file
+	// which does not fit. Below it, the file is summarised or dropped.
+	minTruncated = 1024
+	// sniffLen is how much of a file is used to detect its MIME type.
+	sniffLen = 512
+)
+
+// Options decides which of the matched files are sent, and how much of them.
+type Options struct {
+	// Exclude are patterns in the .gitignore syntax, relative to the working
+	// directory, of files to leave out, such as "*_test.go".
+	Exclude []string
+	// MaxTokens is the budget of the file contents, estimated as four bytes a
+	// token. Zero uses DefaultMaxTokens, a negative value disables the budget.
+	MaxTokens int
+}
+
 // Setup the glob parsing. Currently this is a bit messy as it works
 // both for flag glob and arg glob. Once arg glob is deprecated, this
 // function may be cleaned up
@@ -38,11 +68,20 @@ func Setup(flagGlob string, args []string) (string, []string, error) {
 	return glob, args, nil
 }
 
-func CreateChat(glob, systemPrompt string) (pub_models.Chat, error) {
-	fileMessages, err := parseGlob(glob)
+func CreateChat(glob, systemPrompt string, opts Options) (pub_models.Chat, error) {
+	fileMessages, manifest, err := parseGlob(glob, opts)
 	if err != nil {
 		return pub_models.Chat{}, fmt.Errorf("failed to parse glob string: '%v', err: %w", glob, err)
 	}
+	if manifest.trimmed() {
+		ancli.PrintWarn(manifest.String())
+		fileMessages = append(fileMessages, pub_models.Message{
+			Role:    "user",
+			Content: manifest.String(),
+		})
+	} else if misc.Truthy(os.Getenv("DEBUG")) {
+		ancli.PrintWarn(manifest.String())
+	}
 
 	return pub_models.Chat{
 		ID:       fmt.Sprintf("glob_%v", glob),
@@ -64,34 +103,263 @@ func constructGlobMessages(globMessages []pub_models.Message) []pub_models.Messa
 	return ret
 }
 
-func parseGlob(glob string) ([]pub_models.Message, error) {
+func parseGlob(glob string, opts Options) ([]pub_models.Message, manifest, error) {
 	glob, err := utils.ReplaceTildeWithHome(glob)
 	if err != nil {
-		return nil, fmt.Errorf("parseGlob, ReplaceTildeWithHome: %w", err)
+		return nil, manifest{}, fmt.Errorf("parseGlob, ReplaceTildeWithHome: %w", err)
 	}
-	files, err := filepath.Glob(glob)
-	ret := make([]pub_models.Message, 0, len(files))
+	files, err := matchFiles(glob, opts.Exclude)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse glob: %w", err)
+		return nil, manifest{}, fmt.Errorf("failed to parse glob: %w", err)
 	}
 	if misc.Truthy(os.Getenv("DEBUG")) {
 		ancli.PrintOK(fmt.Sprintf("found %d files: %v\n", len(files), files))
 	}
 
 	if len(files) == 0 {
-		return nil, fmt.Errorf("no files found")
+		return nil, manifest{}, fmt.Errorf("no files found")
 	}
 
-	for _, file := range files {
-		data, err := os.ReadFile(file)
-		if err != nil {
-			ancli.PrintWarn(fmt.Sprintf("failed to read file: %v\n", err))
+	maxTokens := opts.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	entries := budget(readFiles(files), maxTokens)
+	ret := make([]pub_models.Message, 0, len(entries))
+	for _, e := range entries {
+		if e.status == dropped || e.status == skipped {
 			continue
 		}
 		ret = append(ret, pub_models.Message{
 			Role:    "user",
-			Content: fmt.Sprintf("{\"fileName\": \"%v\", \"data\": \"%v\"}", file, string(data)),
+			Content: fmt.Sprintf("{\"fileName\": \"%v\", \"data\": \"%v\"}", e.path, e.content),
 		})
 	}
-	return ret, nil
+	return ret, manifest{glob: glob, maxTokens: maxTokens, entries: entries}, nil
+}
+
+// matchFiles returns the files matching glob which are not ignored by a
+// .gitignore or .claiignore file, nor by exclude. Unlike filepath.Glob, **
+// matches any number of directories.
+func matchFiles(glob string, exclude []string) ([]string, error) {
+	base, rest := splitBase(filepath.ToSlash(glob))
+	re, err := ignore.Compile(rest)
+	if err != nil {
+		return nil, err
+	}
+	// Walk from the working directory when the files are below it, so the
+	// exclude patterns are relative to it.
+	root, sub := filepath.FromSlash(base), "."
+	if wd, err := os.Getwd(); err == nil {
+		if abs, err := filepath.Abs(root); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				root, sub = wd, filepath.ToSlash(rel)
+			}
+		}
+	}
+	// Without ** the depth of the matches is known, deeper dirs are skipped.
+	maxDepth := -1
+	if !strings.Contains(rest, "**") {
+		maxDepth = strings.Count(rest, "/") + 1
+	}
+
+	var files []string
+	m := ignore.New(root, ignore.GitIgnore, ignore.ClaiIgnore).ReadParents().Exclude(exclude...)
+	err = m.WalkDir(sub, func(rel string, d fs.DirEntry) error {
+		if rel == sub {
+			return nil
+		}
+		if sub != "." {
+			rel = strings.TrimPrefix(rel, sub+"/")
+		}
+		if d.IsDir() {
+			if maxDepth >= 0 && strings.Count(rel, "/")+1 >= maxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !re.MatchString(rel) {
+			return nil
+		}
+		file := filepath.Join(filepath.FromSlash(base), filepath.FromSlash(rel))
+		if !d.Type().IsRegular() {
+			// Symlinks are followed, other special files are left out.
+			if info, err := os.Stat(file); err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return files, nil
+}
+
+// splitBase splits a slash separated glob into the leading directories
+// without wildcards, and the rest.
+func splitBase(glob string) (string, string) {
+	parts := strings.Split(glob, "/")
+	i := 0
+	for i < len(parts)-1 && !strings.ContainsAny(parts[i], "*?[") {
+		i++
+	}
+	base := strings.Join(parts[:i], "/")
+	switch {
+	case base == "" && i > 0:
+		base = "/"
+	case base == "":
+		base = "."
+	}
+	return base, strings.Join(parts[i:], "/")
+}
+
+type status string
+
+const (
+	included   status = "included"
+	truncated  status = "truncated"
+	summarised status = "summarised"
+	dropped    status = "dropped"
+	skipped    status = "skipped"
+)
+
+type entry struct {
+	path    string
+	data    []byte
+	content string
+	status  status
+	// note explains the status in the manifest.
+	note string
+	// extracted is set when data is the text extracted from a document, such
+	// as a PDF, instead of the file itself.
+	extracted bool
+}
+
+func readFiles(files []string) []entry {
+	entries := make([]entry, 0, len(files))
+	for _, file := range files {
+		e := entry{path: file}
+		if attachment.Extractable(file) {
+			text, err := attachment.Text(file)
+			if err != nil {
+				ancli.PrintWarn(fmt.Sprintf("%v\n", err))
+				e.status, e.note = skipped, "no text could be extracted"
+			} else {
+				e.data, e.extracted = []byte(text), true
+			}
+			entries = append(entries, e)
+			continue
+		}
+		data, err := os.ReadFile(file)
+		switch {
+		case err != nil:
+			ancli.PrintWarn(fmt.Sprintf("failed to read file: %v\n", err))
+			e.status, e.note = skipped, "unreadable"
+		case !isText(data):
+			e.status, e.note = skipped, "binary, "+http.DetectContentType(data[:min(len(data), sniffLen)])
+		default:
+			e.data = data
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func isText(data []byte) bool {
+	return strings.HasPrefix(http.DetectContentType(data[:min(len(data), sniffLen)]), "text/")
+}
+
+// budget decides what of every readable file is sent within maxTokens. The
+// smallest files are included whole while they fit, so one large file can't
+// crowd out the rest. The files left get an equal share each of what remains:
+// their head up to a line, or a rune when it has no line break, or when the
+// share is too small for that, their declarations, or else nothing.
+func budget(entries []entry, maxTokens int) []entry {
+	var order []int
+	for i, e := range entries {
+		if e.status == "" {
+			order = append(order, i)
+		}
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return len(entries[a].data) - len(entries[b].data) })
+
+	left := maxTokens * 4
+	if maxTokens < 0 {
+		left = math.MaxInt
+	}
+	for len(order) > 0 && len(entries[order[0]].data) <= left {
+		e := &entries[order[0]]
+		e.status, e.content = included, string(e.data)
+		e.note = fmt.Sprintf("%d tokens", tokens(len(e.data)))
+		left -= len(e.data)
+		order = order[1:]
+	}
+	for n, i := range order {
+		e := &entries[i]
+		share := left / (len(order) - n)
+		if share >= minTruncated {
+			head := e.data[:share]
+			if nl := bytes.LastIndexByte(head, '\n'); nl > 0 {
+				head = head[:nl+1]
+			} else {
+				for len(head) > 0 && !utf8.RuneStart(e.data[len(head)]) {
+					head = head[:len(head)-1]
+				}
+			}
+			e.status, e.content = truncated, string(head)
+			e.note = fmt.Sprintf("first %d of %d tokens", tokens(len(head)), tokens(len(e.data)))
+		} else {
+			e.status, e.note = dropped, fmt.Sprintf("%d tokens, over budget", tokens(len(e.data)))
+			if decls := repomap.Declarations(e.path); len(decls) > 0 {
+				summary := "top-level declarations only: " + strings.Join(decls, ", ")
+				if len(summary) <= left {
+					e.status, e.content = summarised, summary
+					e.note = fmt.Sprintf("declarations of %d tokens", tokens(len(e.data)))
+				}
+			}
+		}
+		left -= len(e.content)
+	}
+	return entries
+}
+
+func tokens(n int) int {
+	return (n + 3) / 4
+}
+
+// manifest lists what became of every matched file, so both the model and
+// the user know what the context holds.
+type manifest struct {
+	glob      string
+	maxTokens int
+	entries   []entry
+}
+
+// trimmed reports whether any file was not included whole.
+func (m manifest) trimmed() bool {
+	return slices.ContainsFunc(m.entries, func(e entry) bool { return e.status != included })
+}
+
+func (m manifest) String() string {
+	var b strings.Builder
+	counts := map[status]int{}
+	for _, e := range m.entries {
+		counts[e.status]++
+	}
+	limit := "no token budget"
+	if m.maxTokens > 0 {
+		limit = fmt.Sprintf("a budget of %d tokens", m.maxTokens)
+	}
+	fmt.Fprintf(&b, "Glob manifest for '%v', %d files matched with %s: %d included, %d truncated, %d summarised, %d dropped, %d skipped.\n",
+		m.glob, len(m.entries), limit, counts[included], counts[truncated], counts[summarised], counts[dropped], counts[skipped])
+	for _, e := range m.entries {
+		note := e.note
+		if e.extracted {
+			note = "extracted text, " + note
+		}
+		fmt.Fprintf(&b, "- %v: %v (%v)\n", e.path, e.status, note)
+	}
+	return b.String()
 }
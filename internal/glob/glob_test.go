@@ -1,10 +1,16 @@
 package glob
 
 import (
+	"archive/zip"
+	"bytes"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	pub_models "github.com/baalimago/clai/pkg/text/models"
 )
@@ -34,7 +40,7 @@ func TestParseGlob(t *testing.T) {
 	}()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseGlob(tt.glob)
+			got, _, err := parseGlob(tt.glob, Options{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseGlob() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -86,7 +92,7 @@ func TestCreateChat(t *testing.T) {
 	systemPrompt := "You are a helpful assistant."
 
 	// Run the function
-	chat, err := CreateChat(glob, systemPrompt)
+	chat, err := CreateChat(glob, systemPrompt, Options{})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -103,6 +109,39 @@ func TestCreateChat(t *testing.T) {
 	}
 }
 
+func TestCreateChat_ManifestOnlyWhenTrimmed(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"a.txt": "alpha\n", "b.txt": "beta\n"})
+	t.Chdir(root)
+
+	chat, err := CreateChat("*.txt", "", Options{})
+	if err != nil {
+		t.Fatalf("CreateChat: %v", err)
+	}
+	if len(chat.Messages) != 4 {
+		t.Fatalf("expected system, two files and '#####', got %d messages", len(chat.Messages))
+	}
+
+	chat, err = CreateChat("*.txt", "", Options{MaxTokens: 1})
+	if err != nil {
+		t.Fatalf("CreateChat: %v", err)
+	}
+	last := chat.Messages[len(chat.Messages)-2]
+	if !strings.HasPrefix(last.Content, "Glob manifest for") {
+		t.Fatalf("expected the manifest before '#####', got %q", last.Content)
+	}
+}
+
+func TestBudget_TruncatesAtRuneBoundary(t *testing.T) {
+	// The 1024 byte share ends inside the last "é" of the head.
+	data := []byte("a" + strings.Repeat("é", 2000))
+	entries := budget([]entry{{path: "x.txt", data: data}}, 256)
+	e := entries[0]
+	if e.status != truncated || !utf8.ValidString(e.content) || len(e.content) != 1023 {
+		t.Fatalf("got %q of %d bytes, valid %v", e.status, len(e.content), utf8.ValidString(e.content))
+	}
+}
+
 func TestConstructGlobMessages(t *testing.T) {
 	// Set up test case
 	globMessages := []pub_models.Message{
@@ -131,3 +170,175 @@ func TestConstructGlobMessages(t *testing.T) {
 		t.Errorf("Expected user message: %s, got: %s", expectedUserMsg, messages[len(messages)-1].Content)
 	}
 }
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMatchFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":                 "vendor/\n*.pb.go\n",
+		"internal/.claiignore":       "generated/\n",
+		"main.go":                    "",
+		"internal/a.go":              "",
+		"internal/a_test.go":         "",
+		"internal/x/b.go":            "",
+		"internal/x/c.pb.go":         "",
+		"internal/generated/zz.go":   "",
+		"vendor/lib/lib.go":          "",
+		"internal/x/notes.txt":       "",
+		"internal/x/deeper/d/e/f.go": "",
+	})
+	t.Chdir(root)
+
+	for _, tc := range []struct {
+		glob    string
+		exclude []string
+		want    []string
+	}{
+		{"*.go", nil, []string{"main.go"}},
+		{"internal/**/*.go", nil, []string{"internal/a.go", "internal/a_test.go", "internal/x/b.go", "internal/x/deeper/d/e/f.go"}},
+		{"internal/**/*.go", []string{"*_test.go", "internal/x/deeper/"}, []string{"internal/a.go", "internal/x/b.go"}},
+		{"**/*.go", []string{"internal/"}, []string{"main.go"}},
+		{"internal/*/*.txt", nil, []string{"internal/x/notes.txt"}},
+		{filepath.Join(root, "internal", "x", "*.go"), nil, []string{filepath.Join(root, "internal", "x", "b.go")}},
+	} {
+		got, err := matchFiles(tc.glob, tc.exclude)
+		if err != nil {
+			t.Fatalf("matchFiles(%q): %v", tc.glob, err)
+		}
+		want := make([]string, 0, len(tc.want))
+		for _, w := range tc.want {
+			want = append(want, filepath.FromSlash(w))
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("matchFiles(%q, %q) = %q, want %q", tc.glob, tc.exclude, got, want)
+		}
+	}
+}
+
+func TestParseGlob_BudgetAndManifest(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"small.go":  "package x\n\nfunc Small() {}\n",
+		"big.txt":   strings.Repeat("a line of text\n", 1000),
+		"huge.go":   "package x\n\nfunc Huge() {}\n" + strings.Repeat("// filler comment\n", 2000),
+		"image.png": "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+	})
+	t.Chdir(root)
+
+	msgs, m, err := parseGlob("*", Options{MaxTokens: 1000})
+	if err != nil {
+		t.Fatalf("parseGlob: %v", err)
+	}
+	statuses := map[string]status{}
+	for _, e := range m.entries {
+		statuses[e.path] = e.status
+	}
+	want := map[string]status{"small.go": included, "big.txt": truncated, "huge.go": truncated, "image.png": skipped}
+	for p, s := range want {
+		if statuses[p] != s {
+			t.Errorf("%s: status %q, want %q", p, statuses[p], s)
+		}
+	}
+	total := 0
+	for _, msg := range msgs {
+		total += len(msg.Content)
+	}
+	if len(msgs) != 3 || total > 1000*4+300 {
+		t.Errorf("got %d messages of %d bytes, want 3 within the budget", len(msgs), total)
+	}
+	manifest := m.String()
+	for _, s := range []string{"4 files matched with a budget of 1000 tokens", "- image.png: skipped (binary, image/png)", "- big.txt: truncated (first "} {
+		if !strings.Contains(manifest, s) {
+			t.Errorf("manifest lacks %q:\n%s", s, manifest)
+		}
+	}
+
+	// With less budget than a useful head, Go files are summarised by their
+	// declarations and other files dropped.
+	_, m, err = parseGlob("*", Options{MaxTokens: 200})
+	if err != nil {
+		t.Fatalf("parseGlob: %v", err)
+	}
+	for _, e := range m.entries {
+		switch e.path {
+		case "huge.go":
+			if e.status != summarised || e.content != "top-level declarations only: Huge()" {
+				t.Errorf("huge.go: %q %q, want summarised", e.status, e.content)
+			}
+		case "big.txt":
+			if e.status != dropped {
+				t.Errorf("big.txt: %q, want dropped", e.status)
+			}
+		}
+	}
+
+	_, m, err = parseGlob("*", Options{MaxTokens: -1})
+	if err != nil {
+		t.Fatalf("parseGlob: %v", err)
+	}
+	if m.trimmed() != true || strings.Contains(m.String(), "truncated (") {
+		t.Errorf("without a budget only the binary may be left out:\n%s", m)
+	}
+}
+
+func TestParseGlob_ExtractsDocuments(t *testing.T) {
+	var docx bytes.Buffer
+	zw := zip.NewWriter(&docx)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>Meeting notes</w:t></w:r></w:p></w:body></w:document>`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"notes.docx":  docx.String(),
+		"broken.xlsx": "not a zip",
+	})
+	t.Chdir(root)
+
+	msgs, m, err := parseGlob("*", Options{})
+	if err != nil {
+		t.Fatalf("parseGlob: %v", err)
+	}
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Content, "Meeting notes") {
+		t.Fatalf("messages: %+v", msgs)
+	}
+	manifest := m.String()
+	for _, s := range []string{"- notes.docx: included (extracted text, ", "- broken.xlsx: skipped (no text could be extracted)"} {
+		if !strings.Contains(manifest, s) {
+			t.Errorf("manifest lacks %q:\n%s", s, manifest)
+		}
+	}
+}
+
+func TestSplitBase(t *testing.T) {
+	for _, tc := range []struct{ glob, base, rest string }{
+		{"*.go", ".", "*.go"},
+		{"internal/**/*.go", "internal", "**/*.go"},
+		{"a/b/file.go", "a/b", "file.go"},
+		{"/abs/*/x", "/abs", "*/x"},
+		{"/*.go", "/", "*.go"},
+	} {
+		base, rest := splitBase(tc.glob)
+		if base != tc.base || rest != tc.rest {
+			t.Errorf("splitBase(%q) = %q, %q, want %q, %q", tc.glob, base, rest, tc.base, tc.rest)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Package ignore matches paths against .gitignore style files, so that
+// directory walks skip what git would not track.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// GitIgnore is the ignore file name git reads in every directory.
+const GitIgnore = ".gitignore"
+
+// ClaiIgnore is read next to GitIgnore where clai collects files as context,
+// to leave out files git tracks but a model should not see.
+const ClaiIgnore = ".claiignore"
+
+type rule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher decides whether paths below root are ignored by the ignore files
+// of root and its subdirectories. The .git directory is always ignored.
+type Matcher struct {
+	root  string
+	names []string
+	// rules caches the parsed rules per directory, relative to root.
+	rules map[string][]rule
+	// parents are the rules of the directories above root, outermost first.
+	parents []parentRules
+	// excludes are patterns relative to root which apply after every file.
+	excludes []rule
+}
+
+// parentRules are the rules of a directory above root, and the path of root
+// relative to it.
+type parentRules struct {
+	prefix string
+	rules  []rule
+}
+
+// New returns a matcher reading the ignore files names, such as GitIgnore, in
+// root and every directory below it. Later names take precedence.
+func New(root string, names ...string) *Matcher {
+	return &Matcher{root: root, names: names, rules: map[string][]rule{}}
+}
+
+// ReadParents makes m also apply the ignore files of the directories above
+// root, up to the root of the git repository containing it, as git does.
+// Outside a git repository it changes nothing.
+func (m *Matcher) ReadParents() *Matcher {
+	dir, err := filepath.Abs(m.root)
+	if err != nil {
+		return m
+	}
+	var parents []parentRules
+	prefix := ""
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			slices.Reverse(parents)
+			m.parents = parents
+			return m
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return m
+		}
+		prefix = filepath.Base(dir) + "/" + prefix
+		parents = append(parents, parentRules{prefix: prefix, rules: m.read(parent)})
+		dir = parent
+	}
+}
+
+// Exclude adds patterns, in the ignore file syntax and relative to root,
+// which take precedence over the ignore files.
+func (m *Matcher) Exclude(patterns ...string) *Matcher {
+	for _, p := range patterns {
+		if r, ok := parseLine(p); ok {
+			m.excludes = append(m.excludes, r)
+		}
+	}
+	return m
+}
+
+// Compile translates a glob to a regexp matching whole slash separated paths,
+// with the semantics of the ignore files: * and ? stay within a path segment,
+// ** spans segments.
+func Compile(glob string) (*regexp.Regexp, error) {
+	return regexp.Compile("^" + globRegexp(glob) + "$")
+}
+
+// Ignored reports whether rel, a slash separated path relative to root, is
+// ignored. A path inside an ignored directory is ignored too, as in git.
+func (m *Matcher) Ignored(rel string, isDir bool) bool {
+	rel = strings.Trim(filepath.ToSlash(rel), "/")
+	if rel == "" || rel == "." {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		sub := strings.Join(parts[:i+1], "/")
+		last := i == len(parts)-1
+		if m.ignoredHere(sub, !last || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredHere matches rel against the rules of the directories above it. The
+// last matching rule decides, so a negation can re-include a path.
+func (m *Matcher) ignoredHere(rel string, isDir bool) bool {
+	if path.Base(rel) == ".git" {
+		return true
+	}
+	ignored := false
+	for _, p := range m.parents {
+		ignored = apply(p.rules, p.prefix+rel, isDir, ignored)
+	}
+	dir := ""
+	for {
+		local := strings.TrimPrefix(rel, dir)
+		local = strings.TrimPrefix(local, "/")
+		ignored = apply(m.dirRules(dir), local, isDir, ignored)
+		next := strings.IndexByte(local, '/')
+		if next < 0 {
+			break
+		}
+		if dir == "" {
+			dir = local[:next]
+		} else {
+			dir += "/" + local[:next]
+		}
+	}
+	return apply(m.excludes, rel, isDir, ignored)
+}
+
+// apply returns the verdict of the last rule matching rel, or ignored when
+// none does.
+func apply(rules []rule, rel string, isDir, ignored bool) bool {
+	for _, r := range rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+func (m *Matcher) dirRules(dir string) []rule {
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+	rules := m.read(filepath.Join(m.root, filepath.FromSlash(dir)))
+	m.rules[dir] = rules
+	return rules
+}
+
+// read parses the ignore files of dir.
+func (m *Matcher) read(dir string) []rule {
+	var rules []rule
+	for _, name := range m.names {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		rules = append(rules, parse(f)...)
+		_ = f.Close()
+	}
+	return rules
+}
+
+// parse parses the lines of an ignore file.
+func parse(r io.Reader) []rule {
+	var rules []rule
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if rl, ok := parseLine(sc.Text()); ok {
+			rules = append(rules, rl)
+		}
+	}
+	return rules
+}
+
+func parseLine(line string) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " \t")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+	var r rule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+	// A slash anywhere but at the end anchors the pattern to the directory
+	// of the ignore file. Otherwise it matches at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	expr := globRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return rule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// globRegexp translates a gitignore glob to a regular expression: * and ?
+// stay within a path segment, ** spans segments.
+func globRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// ErrStop stops a Walk early without an error.
+var ErrStop = errors.New("stop walk")
+
+// Walk calls fn for every file and directory below root which is not
+// ignored, with its slash separated path relative to root. Ignored
+// directories are not descended into.
+func (m *Matcher) Walk(fn func(rel string, d fs.DirEntry) error) error {
+	return m.WalkDir(".", fn)
+}
+
+// WalkDir is Walk, limited to dir, a slash separated path relative to root.
+func (m *Matcher) WalkDir(dir string, fn func(rel string, d fs.DirEntry) error) error {
+	start := filepath.Join(m.root, filepath.FromSlash(dir))
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == start {
+				return err
+			}
+			// Unreadable entries are skipped, like git does.
+			return nil
+		}
+		rel, err := filepath.Rel(m.root, p)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if m.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(rel, d)
+	})
+	if errors.Is(err, ErrStop) {
+		return nil
+	}
+	return err
+}
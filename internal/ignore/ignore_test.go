@@ -0,0 +1,170 @@
+package ignore
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMatcher_Ignored(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "# comment\n*.log\n!keep.log\n/build\nnode_modules/\ndocs/**/*.tmp\n\\#hash\n",
+		"sub/.gitignore": "secret.txt\n/local\n",
+	})
+	m := New(root, GitIgnore)
+	for _, tc := range []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"deep/dir/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build/out.bin", false, true},
+		{"sub/build", true, false},
+		{"node_modules", true, true},
+		{"web/node_modules/x.js", false, true},
+		{"node_modules", false, false},
+		{"docs/a/b/c.tmp", false, true},
+		{"docs/c.tmp", false, true},
+		{"c.tmp", false, false},
+		{"#hash", false, true},
+		{"sub/secret.txt", false, true},
+		{"sub/deeper/secret.txt", false, true},
+		{"secret.txt", false, false},
+		{"sub/local", true, true},
+		{"sub/x/local", true, false},
+		{".git", true, true},
+		{"main.go", false, false},
+	} {
+		if got := m.Ignored(tc.path, tc.isDir); got != tc.ignored {
+			t.Errorf("Ignored(%q, dir=%t) = %t, want %t", tc.path, tc.isDir, got, tc.ignored)
+		}
+	}
+}
+
+func TestMatcher_Walk(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":         "vendor/\n*.out\n",
+		"main.go":            "",
+		"a.out":              "",
+		"vendor/lib/lib.go":  "",
+		"pkg/x.go":           "",
+		".git/HEAD":          "",
+		"pkg/.claiignore":    "x.go\n",
+		"pkg/nested/keep.go": "",
+	})
+	var files []string
+	err := New(root, GitIgnore, ".claiignore").Walk(func(rel string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	want := []string{".gitignore", "main.go", "pkg/.claiignore", "pkg/nested/keep.go"}
+	slices.Sort(files)
+	if !slices.Equal(files, want) {
+		t.Fatalf("walked %v, want %v", files, want)
+	}
+}
+
+func TestMatcher_ReadParents(t *testing.T) {
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		".git/HEAD":         "",
+		".gitignore":        "*.gen.go\n/svc/build\n",
+		"svc/.gitignore":    "!keep.gen.go\n",
+		"svc/main.go":       "",
+		"svc/api.gen.go":    "",
+		"svc/keep.gen.go":   "",
+		"svc/build/out.txt": "",
+	})
+	sub := filepath.Join(repo, "svc")
+
+	if New(sub, GitIgnore).Ignored("api.gen.go", false) {
+		t.Fatal("rules above root must not apply without ReadParents")
+	}
+	m := New(sub, GitIgnore).ReadParents()
+	for _, tc := range []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"api.gen.go", false, true},
+		{"keep.gen.go", false, false},
+		{"build", true, true},
+		{"main.go", false, false},
+	} {
+		if got := m.Ignored(tc.path, tc.isDir); got != tc.ignored {
+			t.Errorf("Ignored(%q, dir=%t) = %t, want %t", tc.path, tc.isDir, got, tc.ignored)
+		}
+	}
+}
+
+func TestMatcher_Exclude(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".gitignore": "!*_test.go\n"})
+	m := New(root, GitIgnore).Exclude("*_test.go", "internal/gen/", "")
+	for _, tc := range []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"a/b_test.go", false, true},
+		{"internal/gen", true, true},
+		{"internal/gen/x.go", false, true},
+		{"gen/x.go", false, false},
+		{"b.go", false, false},
+	} {
+		if got := m.Ignored(tc.path, tc.isDir); got != tc.ignored {
+			t.Errorf("Ignored(%q, dir=%t) = %t, want %t", tc.path, tc.isDir, got, tc.ignored)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	for _, tc := range []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/main.go", true},
+		{"internal/**/*.go", "internal/x.go", true},
+		{"internal/**/*.go", "internal/a/b/x.go", true},
+		{"internal/**/*.go", "pkg/x.go", false},
+		{"f?le.[ch]", "file.c", true},
+		{"f?le.[!ch]", "file.c", false},
+	} {
+		re, err := Compile(tc.glob)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tc.glob, err)
+		}
+		if got := re.MatchString(tc.path); got != tc.match {
+			t.Errorf("Compile(%q).MatchString(%q) = %t, want %t", tc.glob, tc.path, got, tc.match)
+		}
+	}
+}
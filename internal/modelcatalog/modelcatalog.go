@@ -0,0 +1,165 @@
+// Package modelcatalog lists the models vendors offer and caches them in the
+// config dir, so model names can be discovered with `clai models`, offered
+// by shell completion and checked before a query is sent.
+package modelcatalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/baalimago/clai/internal/cost"
+	"github.com/baalimago/clai/internal/routing"
+)
+
+// FileName is the cache file in the config dir.
+const FileName = "modelCatalog.json"
+
+// Model is one model of a vendor's catalog. Fields the vendor's models
+// endpoint does not report are left zero.
+type Model struct {
+	// ID is the model name as passed to -cm, including any routing prefix
+	// such as "or:" or "ollama:".
+	ID            string                 `json:"id"`
+	ContextWindow int                    `json:"context_window,omitempty"`
+	Price         *cost.ModelPriceScheme `json:"price,omitempty"`
+	Capabilities  Capabilities           `json:"capabilities"`
+}
+
+// Capabilities are the features a vendor reports for a model.
+type Capabilities struct {
+	Tools            bool `json:"tools,omitempty"`
+	Vision           bool `json:"vision,omitempty"`
+	Reasoning        bool `json:"reasoning,omitempty"`
+	StructuredOutput bool `json:"structured_output,omitempty"`
+}
+
+// String lists the set capabilities, comma separated.
+func (c Capabilities) String() string {
+	var ret []string
+	if c.Tools {
+		ret = append(ret, "tools")
+	}
+	if c.Vision {
+		ret = append(ret, "vision")
+	}
+	if c.Reasoning {
+		ret = append(ret, "reasoning")
+	}
+	if c.StructuredOutput {
+		ret = append(ret, "structured_output")
+	}
+	return strings.Join(ret, ",")
+}
+
+// VendorModels is the cached catalog of one vendor.
+type VendorModels struct {
+	Fetched time.Time `json:"fetched"`
+	Models  []Model   `json:"models"`
+}
+
+// Cache is the content of FileName, keyed by routing vendor name.
+type Cache struct {
+	Vendors map[string]VendorModels `json:"vendors"`
+}
+
+// Load reads the cache of confDir. A missing cache is empty.
+func Load(confDir string) (Cache, error) {
+	b, err := os.ReadFile(filepath.Join(confDir, FileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Cache{Vendors: map[string]VendorModels{}}, nil
+		}
+		return Cache{}, fmt.Errorf("read model catalog: %w", err)
+	}
+	var c Cache
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Cache{}, fmt.Errorf("unmarshal model catalog: %w", err)
+	}
+	if c.Vendors == nil {
+		c.Vendors = map[string]VendorModels{}
+	}
+	return c, nil
+}
+
+// Save writes the cache to confDir.
+func (c Cache) Save(confDir string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal model catalog: %w", err)
+	}
+	if err := os.MkdirAll(confDir, 0o755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(confDir, FileName), b, 0o644); err != nil {
+		return fmt.Errorf("write model catalog: %w", err)
+	}
+	return nil
+}
+
+// Set replaces the models of vendor, sorted by ID.
+func (c *Cache) Set(vendor string, models []Model, fetched time.Time) {
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	if c.Vendors == nil {
+		c.Vendors = map[string]VendorModels{}
+	}
+	c.Vendors[vendor] = VendorModels{Fetched: fetched, Models: models}
+}
+
+// VendorNames returns the cached vendors, sorted.
+func (c Cache) VendorNames() []string {
+	ret := make([]string, 0, len(c.Vendors))
+	for v := range c.Vendors {
+		ret = append(ret, v)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// IDs returns the model IDs of every cached vendor.
+func (c Cache) IDs() []string {
+	var ret []string
+	for _, v := range c.VendorNames() {
+		for _, m := range c.Vendors[v].Models {
+			ret = append(ret, m.ID)
+		}
+	}
+	return ret
+}
+
+// Check reports an error when model routes to a vendor with a cached
+// catalog which does not list it. Models of vendors without a cached
+// catalog, and bare vendor names such as "ollama", are not checked.
+func (c Cache) Check(model string) error {
+	res, err := routing.Resolve(model)
+	if err != nil {
+		return nil
+	}
+	if res.Route.Kind == routing.Exact && res.Route.Target == "" {
+		return nil
+	}
+	cached, ok := c.Vendors[res.Vendor]
+	if !ok || len(cached.Models) == 0 {
+		return nil
+	}
+	var similar []string
+	for _, m := range cached.Models {
+		if m.ID == res.Model {
+			return nil
+		}
+		if len(similar) < 3 && strings.Contains(m.ID, res.Model) {
+			similar = append(similar, m.ID)
+		}
+	}
+	msg := fmt.Sprintf("model %q is not in the cached %s catalog (refresh with 'clai models %s')", res.Model, res.Vendor, res.Vendor)
+	if len(similar) > 0 {
+		msg += fmt.Sprintf(", similar: %s", strings.Join(similar, ", "))
+	}
+	return errors.New(msg)
+}
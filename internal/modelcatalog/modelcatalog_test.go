@@ -0,0 +1,213 @@
+package modelcatalog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baalimago/clai/internal/routing"
+	"github.com/baalimago/clai/internal/vendors/openrouter"
+)
+
+func serve(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestFetchMistral(t *testing.T) {
+	t.Setenv("MISTRAL_API_KEY", "secret")
+	url := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected bearer auth, got %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"data":[
+			{"id":"mistral-large-latest","max_context_length":131072,"capabilities":{"completion_chat":true,"function_calling":true}},
+			{"id":"mistral-embed","capabilities":{"completion_chat":false}},
+			{"id":"pixtral-large-latest","capabilities":{"completion_chat":true,"vision":true}}
+		]}`))
+	})
+	s, _ := SourceFor(routing.VendorMistral)
+	s.URL = url
+	got, err := s.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	// Embedding models and models which do not route to mistral are dropped.
+	if len(got) != 1 || got[0].ID != "mistral-large-latest" || got[0].ContextWindow != 131072 || !got[0].Capabilities.Tools {
+		t.Fatalf("unexpected models %+v", got)
+	}
+}
+
+func TestFetchGemini_FollowsPages(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "k")
+	url := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("key") || r.Header.Get("x-goog-api-key") != "k" {
+			t.Errorf("expected the key in the x-goog-api-key header, got query %q", r.URL.RawQuery)
+		}
+		if r.URL.Query().Get("pageToken") == "" {
+			w.Write([]byte(`{"models":[{"name":"models/gemini-2.5-pro","inputTokenLimit":1048576,"supportedGenerationMethods":["generateContent"],"thinking":true}],"nextPageToken":"2"}`))
+			return
+		}
+		w.Write([]byte(`{"models":[{"name":"models/gemini-embedding-001","supportedGenerationMethods":["embedContent"]},{"name":"models/gemini-2.5-flash","supportedGenerationMethods":["generateContent"]}]}`))
+	})
+	s, _ := SourceFor("gemini")
+	s.URL = url
+	got, err := s.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "gemini-2.5-pro" || !got[0].Capabilities.Reasoning || got[1].ID != "gemini-2.5-flash" {
+		t.Fatalf("unexpected models %+v", got)
+	}
+}
+
+func TestFetchOllama(t *testing.T) {
+	url := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"models":[{"name":"llama3:latest"},{"name":"deepseek-r1:8b"}]}`))
+	})
+	s, _ := SourceFor(routing.VendorOllama)
+	s.URL = url
+	got, err := s.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "ollama:llama3" || got[1].ID != "ollama:deepseek-r1:8b" {
+		t.Fatalf("unexpected models %+v", got)
+	}
+}
+
+func TestForConfig_OllamaHost(t *testing.T) {
+	confDir := t.TempDir()
+	s, _ := SourceFor(routing.VendorOllama)
+
+	t.Setenv("OLLAMA_HOST", "")
+	if got := s.ForConfig(confDir).URL; got != "http://localhost:11434/api/tags" {
+		t.Fatalf("expected the default host, got %q", got)
+	}
+	t.Setenv("OLLAMA_HOST", "gpu-box:11434")
+	if got := s.ForConfig(confDir).URL; got != "http://gpu-box:11434/api/tags" {
+		t.Fatalf("expected OLLAMA_HOST, got %q", got)
+	}
+	conf := filepath.Join(confDir, "ollama_ollama_llama3.json")
+	if err := os.WriteFile(conf, []byte(`{"model":"llama3","host":"https://ollama.lan/"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.ForConfig(confDir).URL; got != "https://ollama.lan/api/tags" {
+		t.Fatalf("expected the host of the model config, got %q", got)
+	}
+}
+
+func TestFetchAnthropic_DetailsFromOpenRouter(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "secret")
+	orig := openRouterModels
+	t.Cleanup(func() { openRouterModels = orig })
+	openRouterModels = func(context.Context) ([]openrouter.Model, error) {
+		return []openrouter.Model{{
+			ID:                  "anthropic/claude-sonnet-4.5",
+			ContextLength:       1000000,
+			Pricing:             openrouter.ModelPricing{Prompt: "0.000003", Completion: "0.000015"},
+			SupportedParameters: []string{"tools"},
+		}}, nil
+	}
+	url := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"id":"claude-sonnet-4-5-20250929"},{"id":"claude-opus-4-1-20250805"}]}`))
+	})
+	s, _ := SourceFor(routing.VendorAnthropic)
+	s.URL = url
+	got, err := s.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("unexpected models %+v", got)
+	}
+	sonnet := got[0]
+	if sonnet.Price == nil || sonnet.Price.InputUSDPerToken != 0.000003 || !sonnet.Capabilities.Tools || sonnet.ContextWindow != 1000000 {
+		t.Fatalf("expected the OpenRouter details of the dated snapshot, got %+v", sonnet)
+	}
+	if got[1].Price != nil {
+		t.Fatalf("expected no price for a model OpenRouter does not list, got %+v", got[1].Price)
+	}
+}
+
+func TestFetch_ReportsHTTPErrors(t *testing.T) {
+	url := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid key", http.StatusUnauthorized)
+	})
+	s, _ := SourceFor(routing.VendorOpenAI)
+	s.URL = url
+	if _, err := s.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected the status in the error, got %v", err)
+	}
+}
+
+func TestFromOpenRouter(t *testing.T) {
+	got := fromOpenRouter([]openrouter.Model{{
+		ID:                  "anthropic/claude-sonnet-4.5",
+		ContextLength:       200000,
+		Pricing:             openrouter.ModelPricing{Prompt: "0.000003", Completion: "0.000015"},
+		Architecture:        openrouter.Architecture{Modality: "text+image->text"},
+		SupportedParameters: []string{"tools", "reasoning"},
+	}})
+	want := Capabilities{Tools: true, Vision: true, Reasoning: true}
+	if len(got) != 1 || got[0].ID != "or:anthropic/claude-sonnet-4.5" || got[0].Capabilities != want || got[0].ContextWindow != 200000 {
+		t.Fatalf("unexpected models %+v", got)
+	}
+	if got[0].Price == nil || got[0].Price.OutputUSDPerToken != 0.000015 {
+		t.Fatalf("unexpected price %+v", got[0].Price)
+	}
+}
+
+func TestCache_SaveLoadAndCheck(t *testing.T) {
+	dir := t.TempDir()
+	empty, err := Load(dir)
+	if err != nil || len(empty.IDs()) != 0 {
+		t.Fatalf("expected an empty cache, got %+v (err %v)", empty, err)
+	}
+	if err := empty.Check("gpt-4.1-typo"); err != nil {
+		t.Fatalf("expected uncached vendors to pass, got %v", err)
+	}
+
+	empty.Set(routing.VendorOpenAI, []Model{{ID: "gpt-4.1-mini"}, {ID: "gpt-4.1"}}, time.Now())
+	if err := empty.Save(dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	cache, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if ids := cache.IDs(); len(ids) != 2 || ids[0] != "gpt-4.1" {
+		t.Fatalf("expected sorted ids, got %v", ids)
+	}
+	if err := cache.Check("gpt-4.1"); err != nil {
+		t.Fatalf("expected cached model to pass, got %v", err)
+	}
+	err = cache.Check("gpt-4")
+	if err == nil || !strings.Contains(err.Error(), "similar: gpt-4.1") {
+		t.Fatalf("expected an error suggesting similar models, got %v", err)
+	}
+	if err := cache.Check("claude-sonnet-4-5"); err != nil {
+		t.Fatalf("expected models of uncached vendors to pass, got %v", err)
+	}
+}
+
+func TestSource_ConfiguredWithoutKey(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := SourceFor(routing.VendorOllama)
+	if s.Configured(dir) {
+		t.Fatal("expected ollama to be unconfigured without model configs")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ollama_llama3_ollama.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if !s.Configured(dir) {
+		t.Fatal("expected a model config to configure ollama")
+	}
+}
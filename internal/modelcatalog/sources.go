@@ -0,0 +1,371 @@
+package modelcatalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/baalimago/clai/internal/cost"
+	"github.com/baalimago/clai/internal/routing"
+	"github.com/baalimago/clai/internal/vendors/ollama"
+	"github.com/baalimago/clai/internal/vendors/openrouter"
+)
+
+// Source fetches the catalog of one vendor from its models endpoint.
+type Source struct {
+	// Vendor is the routing vendor name the catalog is cached under.
+	Vendor string
+	// APIKeyEnv holds the key the endpoint is queried with. Sources without
+	// one are configured when a model config file of the vendor exists.
+	APIKeyEnv string
+	URL       string
+	// urlFor resolves URL from the config in confDir when it is empty.
+	urlFor func(confDir string) string
+	fetch  func(ctx context.Context, s Source) ([]Model, error)
+}
+
+// Sources are the vendors whose catalogs can be fetched.
+var Sources = []Source{
+	{Vendor: routing.VendorOpenAI, APIKeyEnv: "OPENAI_API_KEY", URL: "https://api.openai.com/v1/models", fetch: fetchOpenAI},
+	{Vendor: routing.VendorAnthropic, APIKeyEnv: "ANTHROPIC_API_KEY", URL: "https://api.anthropic.com/v1/models?limit=1000", fetch: fetchAnthropic},
+	{Vendor: routing.VendorGoogle, APIKeyEnv: "GEMINI_API_KEY", URL: "https://generativelanguage.googleapis.com/v1beta/models", fetch: fetchGemini},
+	{Vendor: routing.VendorMistral, APIKeyEnv: "MISTRAL_API_KEY", URL: "https://api.mistral.ai/v1/models", fetch: fetchMistral},
+	{Vendor: routing.VendorOllama, urlFor: ollamaTagsURL, fetch: fetchOllama},
+	{Vendor: routing.VendorOpenRouter, APIKeyEnv: "OPENROUTER_API_KEY", fetch: fetchOpenRouter},
+}
+
+// SourceFor returns the source of vendor. "gemini" is accepted for google.
+func SourceFor(vendor string) (Source, bool) {
+	if vendor == "gemini" {
+		vendor = routing.VendorGoogle
+	}
+	for _, s := range Sources {
+		if s.Vendor == vendor {
+			return s, true
+		}
+	}
+	return Source{}, false
+}
+
+// Configured reports whether the vendor is set up: its API key is set or,
+// for keyless vendors, a model config file of it exists in confDir.
+func (s Source) Configured(confDir string) bool {
+	if s.APIKeyEnv != "" {
+		return os.Getenv(s.APIKeyEnv) != ""
+	}
+	matches, _ := filepath.Glob(filepath.Join(confDir, s.Vendor+"_*.json"))
+	return len(matches) > 0
+}
+
+// ForConfig resolves the endpoint of s from the config in confDir, such as
+// the Ollama server of the ollama model config files.
+func (s Source) ForConfig(confDir string) Source {
+	if s.URL == "" && s.urlFor != nil {
+		s.URL = s.urlFor(confDir)
+	}
+	return s
+}
+
+// Fetch queries the vendor's models endpoint.
+func (s Source) Fetch(ctx context.Context) ([]Model, error) {
+	s = s.ForConfig("")
+	models, err := s.fetch(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s models: %w", s.Vendor, err)
+	}
+	return models, nil
+}
+
+func getJSON(ctx context.Context, rawURL string, headers map[string]string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unmarshal body: %w", err)
+	}
+	return nil
+}
+
+// routesTo reports whether id routes to vendor, so catalogs only list
+// models usable with -cm (OpenAI's also lists embedding and audio models).
+func routesTo(id, vendor string) bool {
+	res, err := routing.Resolve(id)
+	return err == nil && res.Vendor == vendor
+}
+
+func fetchOpenAI(ctx context.Context, s Source) ([]Model, error) {
+	var payload struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err := getJSON(ctx, s.URL, map[string]string{"Authorization": "Bearer " + os.Getenv(s.APIKeyEnv)}, &payload)
+	if err != nil {
+		return nil, err
+	}
+	var ret []Model
+	for _, m := range payload.Data {
+		if routesTo(m.ID, s.Vendor) {
+			ret = append(ret, Model{ID: m.ID})
+		}
+	}
+	return withOpenRouterDetails(ctx, "openai", ret), nil
+}
+
+func fetchAnthropic(ctx context.Context, s Source) ([]Model, error) {
+	var payload struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err := getJSON(ctx, s.URL, map[string]string{
+		"x-api-key":         os.Getenv(s.APIKeyEnv),
+		"anthropic-version": "2023-06-01",
+	}, &payload)
+	if err != nil {
+		return nil, err
+	}
+	var ret []Model
+	for _, m := range payload.Data {
+		if routesTo(m.ID, s.Vendor) {
+			ret = append(ret, Model{ID: m.ID})
+		}
+	}
+	return withOpenRouterDetails(ctx, "anthropic", ret), nil
+}
+
+func fetchGemini(ctx context.Context, s Source) ([]Model, error) {
+	var ret []Model
+	pageToken := ""
+	for {
+		q := url.Values{"pageSize": {"1000"}}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		var payload struct {
+			Models []struct {
+				Name                       string   `json:"name"`
+				InputTokenLimit            int      `json:"inputTokenLimit"`
+				SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+				Thinking                   bool     `json:"thinking"`
+			} `json:"models"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err := getJSON(ctx, s.URL+"?"+q.Encode(), map[string]string{"x-goog-api-key": os.Getenv(s.APIKeyEnv)}, &payload)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range payload.Models {
+			id := strings.TrimPrefix(m.Name, "models/")
+			if !slices.Contains(m.SupportedGenerationMethods, "generateContent") || !routesTo(id, s.Vendor) {
+				continue
+			}
+			ret = append(ret, Model{
+				ID:            id,
+				ContextWindow: m.InputTokenLimit,
+				Capabilities:  Capabilities{Reasoning: m.Thinking},
+			})
+		}
+		if payload.NextPageToken == "" {
+			return ret, nil
+		}
+		pageToken = payload.NextPageToken
+	}
+}
+
+func fetchMistral(ctx context.Context, s Source) ([]Model, error) {
+	var payload struct {
+		Data []struct {
+			ID               string `json:"id"`
+			MaxContextLength int    `json:"max_context_length"`
+			Capabilities     struct {
+				CompletionChat  bool `json:"completion_chat"`
+				FunctionCalling bool `json:"function_calling"`
+				Vision          bool `json:"vision"`
+			} `json:"capabilities"`
+		} `json:"data"`
+	}
+	err := getJSON(ctx, s.URL, map[string]string{"Authorization": "Bearer " + os.Getenv(s.APIKeyEnv)}, &payload)
+	if err != nil {
+		return nil, err
+	}
+	var ret []Model
+	for _, m := range payload.Data {
+		if !m.Capabilities.CompletionChat || !routesTo(m.ID, s.Vendor) {
+			continue
+		}
+		ret = append(ret, Model{
+			ID:            m.ID,
+			ContextWindow: m.MaxContextLength,
+			Capabilities: Capabilities{
+				Tools:  m.Capabilities.FunctionCalling,
+				Vision: m.Capabilities.Vision,
+			},
+		})
+	}
+	return ret, nil
+}
+
+// ollamaTagsURL lists the models of the server the ollama model configs of
+// confDir point at, see ollama.ResolveHost.
+func ollamaTagsURL(confDir string) string {
+	host := ""
+	if confDir != "" {
+		matches, _ := filepath.Glob(filepath.Join(confDir, routing.VendorOllama+"_*.json"))
+		for _, path := range matches {
+			var conf struct {
+				Host string `json:"host"`
+			}
+			b, err := os.ReadFile(path)
+			if err != nil || json.Unmarshal(b, &conf) != nil {
+				continue
+			}
+			if conf.Host != "" {
+				host = conf.Host
+				break
+			}
+		}
+	}
+	return ollama.ResolveHost(host) + "/api/tags"
+}
+
+func fetchOllama(ctx context.Context, s Source) ([]Model, error) {
+	var payload struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := getJSON(ctx, s.URL, nil, &payload); err != nil {
+		return nil, err
+	}
+	ret := make([]Model, 0, len(payload.Models))
+	for _, m := range payload.Models {
+		// Ollama resolves a bare name to its ":latest" tag.
+		ret = append(ret, Model{ID: "ollama:" + strings.TrimSuffix(m.Name, ":latest")})
+	}
+	return ret, nil
+}
+
+func fetchOpenRouter(ctx context.Context, s Source) ([]Model, error) {
+	models, err := openRouterModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fromOpenRouter(models), nil
+}
+
+// openRouterModels fetches the OpenRouter catalog, which needs no key. It is
+// replaced in tests.
+var openRouterModels = func(ctx context.Context) ([]openrouter.Model, error) {
+	catalog, err := openrouter.NewModelCatalog(os.Getenv("OPENROUTER_API_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	return catalog.FetchModels(ctx)
+}
+
+var (
+	dateSuffix  = regexp.MustCompile(`-(\d{8}|\d{4}-\d{2}-\d{2})$`)
+	versionDash = regexp.MustCompile(`(\d)-(\d)`)
+)
+
+// withOpenRouterDetails fills in the price, context window and capabilities
+// of models from OpenRouter's listing of them under prefix, as the models
+// endpoints of OpenAI and Anthropic report none of them. The same catalog
+// prices interactive queries. Models are returned as they are when the
+// catalog can't be fetched.
+func withOpenRouterDetails(ctx context.Context, prefix string, models []Model) []Model {
+	if len(models) == 0 {
+		return models
+	}
+	catalog, err := openRouterModels(ctx)
+	if err != nil {
+		return models
+	}
+	byID := make(map[string]Model, len(catalog))
+	for _, m := range fromOpenRouter(catalog) {
+		byID[strings.TrimPrefix(m.ID, "or:")] = m
+	}
+	for i, m := range models {
+		// OpenRouter drops the snapshot date and writes versions with dots:
+		// claude-sonnet-4-5-20250929 is anthropic/claude-sonnet-4.5.
+		undated := dateSuffix.ReplaceAllString(m.ID, "")
+		for _, id := range []string{m.ID, undated, versionDash.ReplaceAllString(undated, "$1.$2")} {
+			or, ok := byID[prefix+"/"+id]
+			if !ok {
+				continue
+			}
+			models[i].Price = or.Price
+			models[i].ContextWindow = or.ContextWindow
+			models[i].Capabilities = or.Capabilities
+			break
+		}
+	}
+	return models
+}
+
+func fromOpenRouter(models []openrouter.Model) []Model {
+	ret := make([]Model, 0, len(models))
+	for _, m := range models {
+		entry := Model{
+			ID:            "or:" + m.ID,
+			ContextWindow: m.ContextLength,
+			Capabilities: Capabilities{
+				Tools:            slices.Contains(m.SupportedParameters, "tools"),
+				Reasoning:        slices.Contains(m.SupportedParameters, "reasoning"),
+				StructuredOutput: slices.Contains(m.SupportedParameters, "structured_outputs"),
+			},
+		}
+		if entry.ContextWindow == 0 {
+			entry.ContextWindow = m.TopProvider.ContextLength
+		}
+		// Modality is "<inputs>-><outputs>", such as "text+image->text".
+		inputs, _, _ := strings.Cut(m.Architecture.Modality, "->")
+		entry.Capabilities.Vision = strings.Contains(inputs, "image")
+		price := cost.ModelPriceScheme{
+			InputUSDPerToken:       parsePrice(m.Pricing.Prompt),
+			OutputUSDPerToken:      parsePrice(m.Pricing.Completion),
+			InputCachedUSDPerToken: parsePrice(m.Pricing.InputCacheRead),
+		}
+		if price.HasAnyPricing() {
+			entry.Price = &price
+		}
+		ret = append(ret, entry)
+	}
+	return ret
+}
+
+// parsePrice parses OpenRouter's decimal string prices. Unparsable prices,
+// such as "-1" for variable pricing, count as unknown.
+func parsePrice(raw string) float64 {
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return v
+}
@@ -44,6 +44,30 @@ type ToolBox interface {
 	RegisterTool(pub_models.LLMTool)
 }
 
+// Thinking configures the extended thinking of a model. Zero fields leave the
+// vendor's model config untouched.
+type Thinking struct {
+	// BudgetTokens is the amount of tokens the model may think for.
+	BudgetTokens int `json:"budget_tokens,omitempty"`
+	// Interleaved lets the model think between tool calls.
+	Interleaved bool `json:"interleaved,omitempty"`
+	// Effort is "low", "medium" or "high".
+	Effort string `json:"effort,omitempty"`
+}
+
+// ThinkingConfigurer accepts thinking settings of a profile. Vendors without
+// configurable thinking do not implement it.
+type ThinkingConfigurer interface {
+	SetThinking(Thinking)
+}
+
+// VisionSupporter reports whether the model accepts image input. Vendors
+// which can't tell, such as ollama and user-defined ones, don't implement it:
+// their models are sent images with a warning.
+type VisionSupporter interface {
+	SupportsVision() bool
+}
+
 type CompletionEvent any
 
 type NoopEvent struct{}
@@ -55,7 +79,11 @@ type ReasoningEvent struct {
 	Content string
 }
 
-type StopEvent struct{}
+// StopEvent ends the stream. ReasoningItems, when set, are the continuity
+// state of a final answer, which carries no Call to hold them.
+type StopEvent struct {
+	ReasoningItems []pub_models.ReasoningItem
+}
 
 type ErrRateLimit struct {
 	ResetAt         time.Time
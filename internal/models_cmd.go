@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/baalimago/clai/internal/modelcatalog"
+	"github.com/baalimago/clai/internal/models"
+	"github.com/baalimago/clai/internal/routing"
+	"github.com/baalimago/clai/internal/utils"
+	"github.com/baalimago/clai/internal/vendors/custom"
+	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
+	"github.com/baalimago/go_away_boilerplate/pkg/table"
+)
+
+const modelsUsage = "usage: clai models [vendor|routes]"
+
+// modelsFetchTimeout bounds the catalog fetches of one `clai models` run.
+const modelsFetchTimeout = 30 * time.Second
+
+// modelsCmd handles `clai models [vendor|routes]`. args[0] is the command.
+func modelsCmd(ctx context.Context, args []string) (models.Querier, error) {
+	configDir, err := utils.GetClaiConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clai config dir: %w", err)
+	}
+	if len(args) > 2 {
+		return nil, fmt.Errorf("too many arguments, %s", modelsUsage)
+	}
+	if len(args) == 2 && args[1] == "routes" {
+		customVendors, err := custom.Load(configDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load custom vendors: %w", err)
+		}
+		if err := writeModelRoutes(os.Stdout, customVendors); err != nil {
+			return nil, fmt.Errorf("failed to print model routes: %w", err)
+		}
+		return nil, table.ErrUserInitiatedExit
+	}
+	vendor := ""
+	if len(args) == 2 {
+		vendor = args[1]
+	}
+	ctx, cancel := context.WithTimeout(ctx, modelsFetchTimeout)
+	defer cancel()
+	if err := listModels(ctx, os.Stdout, configDir, vendor); err != nil {
+		return nil, err
+	}
+	return nil, table.ErrUserInitiatedExit
+}
+
+// listModels refreshes the cached catalog of vendor, or of every configured
+// vendor when vendor is empty, and prints it. A vendor failing while all are
+// refreshed is reported and listed from the cache.
+func listModels(ctx context.Context, out io.Writer, configDir, vendor string) error {
+	var sources []modelcatalog.Source
+	if vendor != "" {
+		s, ok := modelcatalog.SourceFor(vendor)
+		if !ok {
+			known := make([]string, 0, len(modelcatalog.Sources))
+			for _, s := range modelcatalog.Sources {
+				known = append(known, s.Vendor)
+			}
+			return fmt.Errorf("unknown vendor %q, expected one of: %s", vendor, strings.Join(known, ", "))
+		}
+		sources = append(sources, s)
+	} else {
+		for _, s := range modelcatalog.Sources {
+			if s.Configured(configDir) {
+				sources = append(sources, s)
+			}
+		}
+	}
+
+	cache, err := modelcatalog.Load(configDir)
+	if err != nil {
+		return err
+	}
+	for _, s := range sources {
+		fetched, err := s.ForConfig(configDir).Fetch(ctx)
+		if err != nil {
+			if vendor != "" {
+				return err
+			}
+			ancli.PrintWarn(fmt.Sprintf("%v, listing cached models\n", err))
+			continue
+		}
+		cache.Set(s.Vendor, fetched, time.Now())
+	}
+	if err := cache.Save(configDir); err != nil {
+		return err
+	}
+
+	vendors := cache.VendorNames()
+	if vendor != "" {
+		vendors = []string{sources[0].Vendor}
+	}
+	if len(vendors) == 0 {
+		_, err := fmt.Fprintln(out, "No vendor configured. Set an API key such as OPENAI_API_KEY, or name a vendor: clai models ollama")
+		return err
+	}
+	return writeModels(out, cache, vendors)
+}
+
+func writeModels(out io.Writer, cache modelcatalog.Cache, vendors []string) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VENDOR\tMODEL\tCONTEXT\tINPUT $/1M\tOUTPUT $/1M\tCAPABILITIES")
+	for _, v := range vendors {
+		for _, m := range cache.Vendors[v].Models {
+			ctxWindow, in, outPrice := "", "", ""
+			if m.ContextWindow > 0 {
+				ctxWindow = fmt.Sprint(m.ContextWindow)
+			}
+			if m.Price != nil {
+				in = fmt.Sprintf("%.2f", m.Price.InputUSDPerToken*1e6)
+				outPrice = fmt.Sprintf("%.2f", m.Price.OutputUSDPerToken*1e6)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", v, m.ID, ctxWindow, in, outPrice, m.Capabilities)
+		}
+	}
+	return w.Flush()
+}
+
+// warnUncatalogedModel warns when model is missing from the cached catalog
+// of its vendor, which catches typos in -cm before the request fails. The
+// cache may be stale, so the model is still queried.
+func warnUncatalogedModel(configDir, model string) {
+	cache, err := modelcatalog.Load(configDir)
+	if err != nil {
+		return
+	}
+	if err := cache.Check(model); err != nil {
+		ancli.PrintWarn(err.Error() + "\n")
+	}
+}
+
+// writeModelRoutes prints the routes in the order they are tried: custom
+// vendor prefixes, then the built-in table.
+func writeModelRoutes(out io.Writer, customVendors []custom.Vendor) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tPATTERN\tVENDOR\tTARGET")
+	for _, v := range customVendors {
+		fmt.Fprintf(w, "custom\t%s\t%s\t\n", v.Prefix, v.Name)
+	}
+	for _, r := range routing.Table {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Kind, r.Pattern, r.Vendor, r.Target)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(out, "\nExact routes beat prefixes, the longest prefix wins, keywords match anywhere. Matches of several vendors at one level are an error.")
+	return err
+}
@@ -17,7 +17,11 @@ func (c *Configurations) SetupPrompts(args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to get config dir: %w", err)
 		}
-		iP, err := chat.LoadPrevQuery(confDir)
+		p, err := chat.OpenPersistence(confDir, chat.ConfiguredBackend(confDir))
+		if err != nil {
+			return err
+		}
+		iP, err := p.LoadPrevQuery(confDir)
 		if err != nil {
 			return fmt.Errorf("failed to load previous query: %w", err)
 		}
@@ -0,0 +1,259 @@
+// Package ratelimit paces model requests before they are sent. Each vendor,
+// or each bucket named in the model config files, gets one token bucket for
+// requests per minute and one for tokens per minute, shared by every querier
+// of the process and, when configured, by every clai process using the same
+// config dir.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits is the "rate_limit" object of a vendor model config file, such as
+// openai_gpt_gpt-4.1-mini.json. Zero values disable the respective bucket.
+type Limits struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	TokensPerMinute   int `json:"tokens_per_minute"`
+	// Shared makes every clai process using the config dir draw from the
+	// same buckets, coordinated through a lock file. Without it the buckets
+	// are shared within the process only.
+	Shared bool `json:"shared"`
+	// Bucket names the buckets the model draws from. Empty uses the vendor,
+	// as vendors usually limit the account, so all its models share them.
+	Bucket string `json:"bucket"`
+}
+
+// Enabled reports whether any bucket is configured.
+func (l Limits) Enabled() bool {
+	return l.RequestsPerMinute > 0 || l.TokensPerMinute > 0
+}
+
+// BucketName returns Bucket, or vendor when it is unset.
+func (l Limits) BucketName(vendor string) string {
+	if l.Bucket != "" {
+		return l.Bucket
+	}
+	return vendor
+}
+
+// LimitsFromFile reads the "rate_limit" object of the model config file at
+// path. A missing file or field yields zero Limits.
+func LimitsFromFile(path string) (Limits, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Limits{}, nil
+		}
+		return Limits{}, fmt.Errorf("read rate limit config: %w", err)
+	}
+	var conf struct {
+		RateLimit Limits `json:"rate_limit"`
+	}
+	if err := json.Unmarshal(b, &conf); err != nil {
+		return Limits{}, fmt.Errorf("unmarshal rate limit config %q: %w", path, err)
+	}
+	if conf.RateLimit.RequestsPerMinute < 0 || conf.RateLimit.TokensPerMinute < 0 {
+		return Limits{}, fmt.Errorf("rate limit config %q: limits must not be negative", path)
+	}
+	// The bucket names the shared state and lock files.
+	if b := conf.RateLimit.Bucket; b == "." || b == ".." || strings.ContainsAny(b, `/\`) {
+		return Limits{}, fmt.Errorf("rate limit config %q: invalid bucket %q", path, b)
+	}
+	return conf.RateLimit, nil
+}
+
+// state is the fill level of both buckets at Updated.
+type state struct {
+	Requests float64   `json:"requests"`
+	Tokens   float64   `json:"tokens"`
+	Updated  time.Time `json:"updated"`
+}
+
+// take refills the buckets up to now and withdraws one request and tokens
+// tokens. It returns how long to wait when either bucket holds too little, in
+// which case nothing is withdrawn. A request larger than the whole token
+// bucket is clamped to it, so it waits for a full bucket instead of forever.
+func (s *state) take(now time.Time, l Limits, tokens int) time.Duration {
+	if s.Updated.IsZero() {
+		s.Requests = float64(l.RequestsPerMinute)
+		s.Tokens = float64(l.TokensPerMinute)
+		s.Updated = now
+	}
+	elapsed := max(now.Sub(s.Updated).Minutes(), 0)
+	s.Requests = min(s.Requests+elapsed*float64(l.RequestsPerMinute), float64(l.RequestsPerMinute))
+	s.Tokens = min(s.Tokens+elapsed*float64(l.TokensPerMinute), float64(l.TokensPerMinute))
+	s.Updated = now
+
+	need := min(float64(tokens), float64(l.TokensPerMinute))
+	var wait time.Duration
+	if l.RequestsPerMinute > 0 && s.Requests < 1 {
+		wait = max(wait, refillTime(1-s.Requests, l.RequestsPerMinute))
+	}
+	if l.TokensPerMinute > 0 && s.Tokens < need {
+		wait = max(wait, refillTime(need-s.Tokens, l.TokensPerMinute))
+	}
+	if wait > 0 {
+		return wait
+	}
+	if l.RequestsPerMinute > 0 {
+		s.Requests--
+	}
+	if l.TokensPerMinute > 0 {
+		s.Tokens -= need
+	}
+	return 0
+}
+
+func refillTime(missing float64, perMinute int) time.Duration {
+	return time.Duration(missing / float64(perMinute) * float64(time.Minute))
+}
+
+// Limiter paces the requests drawing from one bucket.
+type Limiter struct {
+	// key names the bucket, and the state and lock files of shared limiters.
+	key string
+	// dir holds the lock and state files of shared limiters.
+	dir string
+
+	mu     sync.Mutex
+	limits Limits
+	local  state
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = map[string]*Limiter{}
+)
+
+// ForBucket returns the process-wide limiter of the bucket named key, see
+// Limits.BucketName. The limits of the model config read last apply to the
+// whole bucket. Limiters of shared limits keep their state in
+// confDir/ratelimit.
+func ForBucket(key string, limits Limits, confDir string) *Limiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	l, ok := limiters[key]
+	if !ok {
+		l = &Limiter{key: key}
+		limiters[key] = l
+	}
+	l.mu.Lock()
+	l.limits = limits
+	l.dir = filepath.Join(confDir, "ratelimit")
+	l.mu.Unlock()
+	return l
+}
+
+// CountsTokens reports whether Wait needs an input token estimate, so callers
+// can skip counting when only requests are limited.
+func (l *Limiter) CountsTokens() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limits.TokensPerMinute > 0
+}
+
+// Wait blocks until the buckets hold one request and tokens input
+// tokens, then withdraws them. It returns early with the context's error.
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	for {
+		wait, err := l.reserve(tokens)
+		if err != nil {
+			return err
+		}
+		if wait == 0 {
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("wait for %s rate limit: %w", l.key, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (l *Limiter) reserve(tokens int) (time.Duration, error) {
+	l.mu.Lock()
+	limits, dir := l.limits, l.dir
+	if !limits.Shared {
+		defer l.mu.Unlock()
+		if !limits.Enabled() {
+			return 0, nil
+		}
+		return l.local.take(time.Now(), limits, tokens), nil
+	}
+	// The lock file serialises shared reservations, also between the
+	// goroutines of this process, so waiting for it must not hold l.mu.
+	l.mu.Unlock()
+	return l.reserveShared(limits, dir, tokens)
+}
+
+// lockStaleAfter is how old a lock file may get before it is considered
+// left behind by a crashed process and removed.
+const lockStaleAfter = 10 * time.Second
+
+// reserveShared runs take against the state file of the limiter in dir
+// while holding its lock file, so concurrent processes withdraw from one
+// bucket.
+func (l *Limiter) reserveShared(limits Limits, dir string, tokens int) (time.Duration, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, fmt.Errorf("create rate limit dir: %w", err)
+	}
+	unlock, err := acquireLock(filepath.Join(dir, l.key+".lock"))
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	statePath := filepath.Join(dir, l.key+".json")
+	var s state
+	if b, err := os.ReadFile(statePath); err == nil {
+		// A corrupt state file starts a full bucket again.
+		_ = json.Unmarshal(b, &s)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("read rate limit state: %w", err)
+	}
+	wait := s.take(time.Now(), limits, tokens)
+	b, err := json.Marshal(s)
+	if err != nil {
+		return 0, fmt.Errorf("marshal rate limit state: %w", err)
+	}
+	if err := os.WriteFile(statePath, b, 0o644); err != nil {
+		return 0, fmt.Errorf("write rate limit state: %w", err)
+	}
+	return wait, nil
+}
+
+// acquireLock creates path exclusively, retrying until it succeeds. The
+// returned func removes the lock again.
+func acquireLock(path string) (func(), error) {
+	deadline := time.Now().Add(2 * lockStaleAfter)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("create rate limit lock: %w", err)
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("acquire rate limit lock %q: timed out", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,190 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestState_Take(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	limits := Limits{RequestsPerMinute: 2, TokensPerMinute: 1000}
+
+	var s state
+	if wait := s.take(start, limits, 400); wait != 0 {
+		t.Fatalf("expected a full bucket to admit the first request, waited %v", wait)
+	}
+	if wait := s.take(start, limits, 400); wait != 0 {
+		t.Fatalf("expected the second request to fit, waited %v", wait)
+	}
+	wait := s.take(start, limits, 100)
+	if wait != 30*time.Second {
+		t.Fatalf("expected to wait for one request to refill (30s), got %v", wait)
+	}
+	if s.Requests != 0 || s.Tokens != 200 {
+		t.Fatalf("expected a refused request to withdraw nothing, got %+v", s)
+	}
+	if wait := s.take(start.Add(30*time.Second), limits, 100); wait != 0 {
+		t.Fatalf("expected the refilled bucket to admit the request, waited %v", wait)
+	}
+}
+
+func TestState_TakeTokenBucket(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	limits := Limits{TokensPerMinute: 600}
+
+	var s state
+	if wait := s.take(start, limits, 500); wait != 0 {
+		t.Fatalf("unexpected wait %v", wait)
+	}
+	if wait := s.take(start, limits, 300); wait != 20*time.Second {
+		t.Fatalf("expected to wait for 200 tokens to refill (20s), got %v", wait)
+	}
+	// Larger than the whole bucket: clamped, so it waits for a full bucket
+	// instead of never being admitted.
+	if wait := s.take(start.Add(time.Minute), limits, 5000); wait != 0 {
+		t.Fatalf("expected an oversized request to drain a full bucket, waited %v", wait)
+	}
+}
+
+func TestLimitsFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "openai_gpt_gpt-4.1.json")
+	if err := os.WriteFile(path, []byte(`{"model":"gpt-4.1","rate_limit":{"requests_per_minute":50,"tokens_per_minute":40000,"shared":true}}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	got, err := LimitsFromFile(path)
+	if err != nil {
+		t.Fatalf("LimitsFromFile: %v", err)
+	}
+	if got != (Limits{RequestsPerMinute: 50, TokensPerMinute: 40000, Shared: true}) {
+		t.Fatalf("unexpected limits %+v", got)
+	}
+
+	missing, err := LimitsFromFile(filepath.Join(dir, "missing.json"))
+	if err != nil || missing.Enabled() {
+		t.Fatalf("expected no limits for a missing file, got %+v (err %v)", missing, err)
+	}
+	if err := os.WriteFile(path, []byte(`{"rate_limit":{"requests_per_minute":-1}}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := LimitsFromFile(path); err == nil {
+		t.Fatal("expected negative limits to be rejected")
+	}
+	if err := os.WriteFile(path, []byte(`{"rate_limit":{"requests_per_minute":1,"bucket":"../x"}}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := LimitsFromFile(path); err == nil {
+		t.Fatal("expected a bucket with a path separator to be rejected")
+	}
+}
+
+func TestLimiter_WaitHonoursContext(t *testing.T) {
+	l := &Limiter{key: "test", limits: Limits{RequestsPerMinute: 1}}
+	if err := l.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the exhausted bucket to wait until the deadline, got %v", err)
+	}
+}
+
+// TestLimiter_SharedAcrossInstances stands in for two processes: two
+// limiters of the same model config and config dir draw from one state file.
+func TestLimiter_SharedAcrossInstances(t *testing.T) {
+	dir := t.TempDir()
+	limits := Limits{RequestsPerMinute: 1, Shared: true}
+	first := &Limiter{key: "openai_gpt_gpt-4.1", dir: dir, limits: limits}
+	second := &Limiter{key: "openai_gpt_gpt-4.1", dir: dir, limits: limits}
+
+	if wait, err := first.reserve(0); err != nil || wait != 0 {
+		t.Fatalf("expected the first process to be admitted, got %v (err %v)", wait, err)
+	}
+	wait, err := second.reserve(0)
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if wait <= 0 {
+		t.Fatal("expected the second process to wait for the shared bucket")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "openai_gpt_gpt-4.1.lock")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the lock file to be released, stat err=%v", err)
+	}
+}
+
+func TestAcquireLock_RemovesStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vendor.lock")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+	old := time.Now().Add(-2 * lockStaleAfter)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("age lock: %v", err)
+	}
+	unlock, err := acquireLock(path)
+	if err != nil {
+		t.Fatalf("acquireLock: %v", err)
+	}
+	unlock()
+}
+
+// TestLimiter_SharedLockDoesNotBlockLimiter verifies that a reservation
+// waiting for another process's lock file leaves the limiter usable.
+func TestLimiter_SharedLockDoesNotBlockLimiter(t *testing.T) {
+	dir := t.TempDir()
+	l := &Limiter{key: "openai_gpt_gpt-4.1", dir: dir, limits: Limits{TokensPerMinute: 100, Shared: true}}
+	lock := filepath.Join(dir, l.key+".lock")
+	if err := os.WriteFile(lock, nil, 0o644); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.reserve(10)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	counts := make(chan bool, 1)
+	go func() { counts <- l.CountsTokens() }()
+	select {
+	case got := <-counts:
+		if !got {
+			t.Fatal("expected the token bucket to be reported")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected CountsTokens not to wait for the lock file")
+	}
+
+	if err := os.Remove(lock); err != nil {
+		t.Fatalf("remove lock: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+}
+
+func TestForBucket_SharesLimiterPerBucket(t *testing.T) {
+	confDir := t.TempDir()
+	mini := Limits{RequestsPerMinute: 5}
+	full := Limits{RequestsPerMinute: 10}
+	a := ForBucket(mini.BucketName("test-vendor-a"), mini, confDir)
+	b := ForBucket(full.BucketName("test-vendor-a"), full, confDir)
+	if a != b {
+		t.Fatal("expected the models of a vendor to share one limiter")
+	}
+	if b.limits.RequestsPerMinute != 10 {
+		t.Fatalf("expected the latest limits to win, got %+v", b.limits)
+	}
+	if ForBucket(Limits{}.BucketName("test-vendor-b"), Limits{}, confDir) == a {
+		t.Fatal("expected vendors to get separate limiters")
+	}
+	own := Limits{RequestsPerMinute: 1, Bucket: "test-own-bucket"}
+	if ForBucket(own.BucketName("test-vendor-a"), own, confDir) == a {
+		t.Fatal("expected a named bucket to get its own limiter")
+	}
+}
@@ -0,0 +1,109 @@
+package repomap
+
+import (
+	"bufio"
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// goDeclarations lists the top-level declarations of a Go file: funcs as
+// Name(), methods as Type.Name(), and types, consts and vars by name.
+func goDeclarations(filename string, src []byte) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.SkipObjectResolution)
+	if err != nil && f == nil {
+		return nil
+	}
+	var decls []string
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name + "()"
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverName(d.Recv.List[0].Type) + "." + name
+			}
+			decls = append(decls, name)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					decls = append(decls, "type "+s.Name.Name)
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.Name != "_" {
+							decls = append(decls, d.Tok.String()+" "+n.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return "?"
+	}
+}
+
+// declPatterns match the top-level declarations of other languages, the name
+// being the last group. They are heuristics: only unindented lines count,
+// except for the methods of a Python class.
+var declPatterns = func() map[string]*regexp.Regexp {
+	cLike := regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:abstract\s+|async\s+|public\s+|private\s+|protected\s+|static\s+|final\s+|sealed\s+|data\s+|open\s+)*(?:class|interface|enum|record|struct|object|trait|function\*?|fun|type|namespace)\s+([A-Za-z_$][\w$]*)`)
+	jsConst := regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:class|interface|enum|type|function\*?|const|let|var|async\s+function)\s+([A-Za-z_$][\w$]*)`)
+	python := regexp.MustCompile(`^(?:    )?(?:async\s+)?(?:def|class)\s+([A-Za-z_]\w*)`)
+	rust := regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:async\s+|const\s+|unsafe\s+|extern\s+"C"\s+)*(?:fn|struct|enum|trait|type|mod|macro_rules!|impl(?:<[^>]*>)?)\s+([A-Za-z_][\w:<>, ]*?)\s*(?:[({<;]|where|for|$)`)
+	ruby := regexp.MustCompile(`^\s*(?:def|class|module)\s+([A-Za-z_][\w.:?!]*)`)
+	c := regexp.MustCompile(`^(?:static\s+|inline\s+|extern\s+|const\s+|unsigned\s+|struct\s+)*[A-Za-z_][\w\s\*]*?\b([A-Za-z_]\w*)\s*\([^;]*$|^(?:typedef\s+)?(?:struct|enum|union|class|namespace)\s+([A-Za-z_]\w*)`)
+	shell := regexp.MustCompile(`^(?:function\s+)?([A-Za-z_][\w-]*)\s*\(\)\s*\{?`)
+	markdown := regexp.MustCompile(`^(#{1,3}\s+.+)$`)
+	return map[string]*regexp.Regexp{
+		".js": jsConst, ".jsx": jsConst, ".mjs": jsConst, ".ts": jsConst, ".tsx": jsConst,
+		".py":   python,
+		".rs":   rust,
+		".rb":   ruby,
+		".java": cLike, ".kt": cLike, ".scala": cLike, ".cs": cLike, ".swift": cLike, ".php": cLike,
+		".c": c, ".h": c, ".cc": c, ".cpp": c, ".hpp": c,
+		".sh": shell, ".bash": shell, ".zsh": shell,
+		".md": markdown,
+	}
+}()
+
+func regexpDeclarations(ext string, src []byte) []string {
+	re := declPatterns[ext]
+	var decls []string
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	sc.Buffer(make([]byte, 0, 64<<10), maxScanSize)
+	for sc.Scan() {
+		if m := re.FindStringSubmatch(sc.Text()); m != nil {
+			if name := lastGroup(m); name != "" {
+				decls = append(decls, name)
+			}
+		}
+	}
+	return decls
+}
+
+// lastGroup returns the last non-empty group of a match.
+func lastGroup(m []string) string {
+	for i := len(m) - 1; i > 0; i-- {
+		if s := strings.TrimSpace(m[i]); s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,259 @@
+// Package repomap renders a compact, token budgeted map of a repository: its
+// directory structure and the top-level declarations of its files, most
+// recently changed first.
+package repomap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/baalimago/clai/internal/ignore"
+)
+
+// DefaultTokens is the budget of a map when none is configured.
+const DefaultTokens = 2048
+
+const (
+	// maxFiles caps the files walked, so a huge tree can't stall startup.
+	maxFiles = 20000
+	// maxDeclsPerFile caps the declarations listed for one file.
+	maxDeclsPerFile = 24
+	// maxScanSize skips declaration scanning of larger files.
+	maxScanSize = 512 << 10
+	// gitLogCommits is how many commits are read to rank files by recency.
+	gitLogCommits = 300
+	// treeShare is the share of the budget the directory tree may use.
+	treeShare = 4
+)
+
+type file struct {
+	rel     string
+	modTime time.Time
+	// rank is the recency position from git, -1 when git does not know it.
+	rank int
+}
+
+// Build renders the map of the repository at root within maxTokens, where a
+// token is estimated as four bytes.
+func Build(ctx context.Context, root string, maxTokens int) (string, error) {
+	if maxTokens <= 0 {
+		maxTokens = DefaultTokens
+	}
+	files, total, err := listFiles(root)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+	rankFiles(ctx, root, files)
+
+	budget := maxTokens * 4
+	var b strings.Builder
+	fmt.Fprintf(&b, "Repository map of %s, %d files. Directories with their file counts, then files with their top-level declarations, most recently changed first.\n", filepath.Base(root), total)
+	b.WriteString(renderTree(files, budget/treeShare))
+
+	shown := 0
+	for _, f := range files {
+		line := f.rel
+		if decls := Declarations(filepath.Join(root, filepath.FromSlash(f.rel))); len(decls) > 0 {
+			line += ": " + strings.Join(decls, ", ")
+		}
+		if b.Len()+len(line)+1 > budget {
+			break
+		}
+		b.WriteString(line + "\n")
+		shown++
+	}
+	if rest := total - shown; rest > 0 {
+		fmt.Fprintf(&b, "... %d more files not shown\n", rest)
+	}
+	return b.String(), nil
+}
+
+// listFiles returns the files below root which git would not ignore, and the
+// count of them, which may exceed the returned files.
+func listFiles(root string) ([]file, int, error) {
+	var files []file
+	total := 0
+	err := ignore.New(root, ignore.GitIgnore).ReadParents().Walk(func(rel string, d fs.DirEntry) error {
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		total++
+		if len(files) >= maxFiles {
+			return nil
+		}
+		f := file{rel: rel, rank: -1}
+		if info, err := d.Info(); err == nil {
+			f.modTime = info.ModTime()
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("walk %s: %w", root, err)
+	}
+	return files, total, nil
+}
+
+// rankFiles sorts files by recency: uncommitted and untracked files first, then in the
+// order of the git log, then by modification time. Outside git repositories
+// only the modification time is used.
+func rankFiles(ctx context.Context, root string, files []file) {
+	ranks := gitRecency(ctx, root)
+	for i := range files {
+		if r, ok := ranks[files[i].rel]; ok {
+			files[i].rank = r
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		switch {
+		case a.rank >= 0 && b.rank >= 0:
+			return a.rank < b.rank
+		case a.rank >= 0 || b.rank >= 0:
+			return a.rank >= 0
+		case !a.modTime.Equal(b.modTime):
+			return a.modTime.After(b.modTime)
+		default:
+			return a.rel < b.rel
+		}
+	})
+}
+
+// gitRecency maps the paths, relative to root, of the uncommitted and
+// recently committed files to their recency rank.
+func gitRecency(ctx context.Context, root string) map[string]int {
+	ranks := map[string]int{}
+	add := func(p string) {
+		if _, ok := ranks[p]; !ok && p != "" {
+			ranks[p] = len(ranks)
+		}
+	}
+	status, err := git(ctx, root, "status", "--porcelain", "--untracked-files=all", "--", ".")
+	if err != nil {
+		return ranks
+	}
+	prefix, _ := git(ctx, root, "rev-parse", "--show-prefix")
+	prefix = strings.TrimSpace(prefix)
+	for _, line := range strings.Split(status, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		p := line[3:]
+		if _, after, ok := strings.Cut(p, " -> "); ok {
+			p = after
+		}
+		// Status paths are relative to the repository root.
+		add(strings.TrimPrefix(strings.Trim(p, `"`), prefix))
+	}
+	log, err := git(ctx, root, "log", fmt.Sprintf("-n%d", gitLogCommits), "--name-only", "--format=", "--relative", "--", ".")
+	if err != nil {
+		return ranks
+	}
+	for _, line := range strings.Split(log, "\n") {
+		add(strings.TrimSpace(line))
+	}
+	return ranks
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// renderTree renders the directories of files with their file counts, within
+// budget bytes. Deeper directories are dropped first.
+func renderTree(files []file, budget int) string {
+	counts := map[string]int{}
+	for _, f := range files {
+		d := path.Dir(f.rel)
+		counts[d]++
+		// Every ancestor is listed, also the ones without files of their own.
+		for d != "." {
+			d = path.Dir(d)
+			if _, ok := counts[d]; !ok {
+				counts[d] = 0
+			}
+		}
+	}
+	dirs := make([]string, 0, len(counts))
+	maxDepth := 0
+	for d := range counts {
+		dirs = append(dirs, d)
+		maxDepth = max(maxDepth, depth(d))
+	}
+	sort.Strings(dirs)
+	for ; maxDepth >= 0; maxDepth-- {
+		var b strings.Builder
+		hidden := 0
+		for _, d := range dirs {
+			if depth(d) > maxDepth {
+				hidden++
+				continue
+			}
+			name := path.Base(d) + "/"
+			if d == "." {
+				name = "./"
+			}
+			fmt.Fprintf(&b, "%s%s (%d)\n", strings.Repeat("  ", depth(d)), name, counts[d])
+		}
+		if hidden > 0 {
+			fmt.Fprintf(&b, "(%d deeper directories not shown)\n", hidden)
+		}
+		if b.Len() <= budget || maxDepth == 0 {
+			return b.String()
+		}
+	}
+	return ""
+}
+
+func depth(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// Declarations returns the top-level declarations of the file at p: parsed
+// for Go, matched by regexp for other known source files. Unknown, large and
+// binary files have none.
+func Declarations(p string) []string {
+	ext := strings.ToLower(filepath.Ext(p))
+	_, known := declPatterns[ext]
+	if ext != ".go" && !known {
+		return nil
+	}
+	info, err := os.Stat(p)
+	if err != nil || info.Size() > maxScanSize {
+		return nil
+	}
+	src, err := os.ReadFile(p)
+	if err != nil || bytes.IndexByte(src, 0) >= 0 {
+		return nil
+	}
+	var decls []string
+	if ext == ".go" {
+		decls = goDeclarations(p, src)
+	} else {
+		decls = regexpDeclarations(ext, src)
+	}
+	if len(decls) > maxDeclsPerFile {
+		decls = append(decls[:maxDeclsPerFile], fmt.Sprintf("+%d more", len(decls)-maxDeclsPerFile))
+	}
+	return decls
+}
@@ -0,0 +1,151 @@
+package repomap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "tester")
+	return dir
+}
+
+func TestBuild(t *testing.T) {
+	dir := initRepo(t)
+	writeFiles(t, dir, map[string]string{
+		".gitignore":      "dist/\n*.log\n",
+		"old.go":          "package x\n\nfunc Old() {}\n",
+		"pkg/server.go":   "package pkg\n\ntype Server struct{}\n\nfunc (s *Server) Start() error { return nil }\n\nconst Port = 80\n",
+		"web/app.ts":      "export class App {}\nexport function render() {}\nconst local = 1\n",
+		"tools/script.py": "class Tool:\n    def run(self):\n        pass\n\ndef main():\n    pass\n",
+		"dist/bundle.js":  "function ignored() {}\n",
+		"debug.log":       "noise\n",
+	})
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-qm", "first")
+	writeFiles(t, dir, map[string]string{"pkg/server.go": "package pkg\n\ntype Server struct{}\n"})
+	runGit(t, dir, "commit", "-qam", "second")
+	writeFiles(t, dir, map[string]string{"web/app.ts": "export class App {}\n"})
+
+	got, err := Build(context.Background(), dir, DefaultTokens)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	for _, want := range []string{
+		"5 files.",
+		"pkg/ (1)",
+		"web/app.ts: App",
+		"pkg/server.go: type Server",
+		"old.go: Old()",
+		"tools/script.py: Tool, run, main",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("map lacks %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"dist", "debug.log", ".git/"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("map contains ignored %q:\n%s", unwanted, got)
+		}
+	}
+	// The uncommitted change comes first, then the log order.
+	order := []int{
+		strings.Index(got, "web/app.ts"),
+		strings.Index(got, "pkg/server.go"),
+		strings.Index(got, "old.go"),
+	}
+	if !slices.IsSorted(order) {
+		t.Errorf("files not ranked by recency, positions %v:\n%s", order, got)
+	}
+}
+
+func TestBuild_Budget(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{}
+	for i := range 200 {
+		files[fmt.Sprintf("d%d/f%d.go", i%20, i)] = fmt.Sprintf("package d\n\nfunc F%d() {}\n", i)
+	}
+	writeFiles(t, dir, files)
+
+	got, err := Build(context.Background(), dir, 256)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(got) > 256*4+64 {
+		t.Errorf("map of %d bytes exceeds the budget:\n%s", len(got), got)
+	}
+	if !strings.Contains(got, "more files not shown") {
+		t.Errorf("map does not mention the files left out:\n%s", got)
+	}
+}
+
+func TestBuild_Empty(t *testing.T) {
+	got, err := Build(context.Background(), t.TempDir(), DefaultTokens)
+	if err != nil || got != "" {
+		t.Fatalf("Build of empty dir = %q, %v", got, err)
+	}
+}
+
+func TestRenderTree_DropsDeepDirectories(t *testing.T) {
+	files := []file{{rel: "a/b/c/d/e.go"}, {rel: "a/x.go"}, {rel: "top.go"}}
+	full := renderTree(files, 1000)
+	if !strings.Contains(full, "        d/ (1)") {
+		t.Fatalf("full tree lacks the deepest dir:\n%s", full)
+	}
+	short := renderTree(files, 40)
+	if strings.Contains(short, "d/") || !strings.Contains(short, "deeper directories not shown") {
+		t.Fatalf("budgeted tree:\n%s", short)
+	}
+}
+
+func TestRegexpDeclarations(t *testing.T) {
+	for _, tc := range []struct {
+		ext  string
+		src  string
+		want []string
+	}{
+		{".rs", "pub fn serve() {}\nstruct Conf {\n    fn inner() {}\n}\nimpl Conf {\n", []string{"serve", "Conf", "Conf"}},
+		{".c", "static int parse(const char *s) {\ntypedef struct node {\nint x;\n", []string{"parse", "node"}},
+		{".java", "public class Main {\n    public void run() {}\n}\n", []string{"Main"}},
+		{".sh", "deploy() {\n  echo\n}\nfunction clean {\n", []string{"deploy"}},
+		{".md", "# Title\ntext\n## Part\n", []string{"# Title", "## Part"}},
+	} {
+		if got := regexpDeclarations(tc.ext, []byte(tc.src)); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.ext, got, tc.want)
+		}
+	}
+}
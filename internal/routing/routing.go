@@ -0,0 +1,211 @@
+// Package routing maps text model names to the vendor serving them. The
+// table is declarative and ordered by precedence, so which API a model name
+// reaches can be read off `clai models routes` instead of from code.
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MatchKind is how a route pattern is compared with a model name. The kinds
+// are tried in the order declared: an exact route beats any prefix route,
+// and keywords are only consulted when no prefix matches.
+type MatchKind int
+
+const (
+	// Exact matches the whole model name. Aliases are exact routes.
+	Exact MatchKind = iota
+	// Prefix matches the start of the model name. The longest matching
+	// prefix wins.
+	Prefix
+	// Keyword matches anywhere in the model name, such as "gpt" in an
+	// OpenAI fine-tune "ft:gpt-4o-mini:org::id". Keywords of more than one
+	// vendor matching the same name is an error.
+	Keyword
+)
+
+func (k MatchKind) String() string {
+	switch k {
+	case Exact:
+		return "exact"
+	case Prefix:
+		return "prefix"
+	case Keyword:
+		return "keyword"
+	default:
+		return fmt.Sprintf("MatchKind(%d)", int(k))
+	}
+}
+
+// Route sends model names matching Pattern to Vendor. Alias routes carry
+// the concrete model name in Target.
+type Route struct {
+	Pattern string
+	Kind    MatchKind
+	Vendor  string
+	Target  string
+}
+
+// Vendor names, as used in model config file names.
+const (
+	VendorMock        = "mock"
+	VendorOpenAI      = "openai"
+	VendorAnthropic   = "anthropic"
+	VendorGoogle      = "google"
+	VendorMistral     = "mistral"
+	VendorDeepSeek    = "deepseek"
+	VendorInception   = "inception"
+	VendorXAI         = "xai"
+	VendorOpenRouter  = "openrouter"
+	VendorHuggingFace = "huggingface"
+	VendorOllama      = "ollama"
+	VendorNovita      = "novita"
+	VendorBerget      = "berget"
+	VendorAzure       = "azure"
+	VendorBedrock     = "bedrock"
+)
+
+// Table is the built-in routing table. User-defined vendors (see
+// vendors/custom) are matched before it.
+var Table = []Route{
+	{Pattern: "test", Kind: Exact, Vendor: VendorMock},
+	{Pattern: "mock_test", Kind: Exact, Vendor: VendorMock},
+	{Pattern: "mock", Kind: Exact, Vendor: VendorMock},
+	{Pattern: "ollama", Kind: Exact, Vendor: VendorOllama},
+	{Pattern: "novita", Kind: Exact, Vendor: VendorNovita},
+	{Pattern: "sonnet", Kind: Exact, Vendor: VendorAnthropic, Target: "claude-sonnet-4-5"},
+	{Pattern: "opus", Kind: Exact, Vendor: VendorAnthropic, Target: "claude-opus-4-1"},
+	{Pattern: "haiku", Kind: Exact, Vendor: VendorAnthropic, Target: "claude-haiku-4-5"},
+
+	{Pattern: "or:", Kind: Prefix, Vendor: VendorOpenRouter},
+	{Pattern: "hf:", Kind: Prefix, Vendor: VendorHuggingFace},
+	{Pattern: "huggingface:", Kind: Prefix, Vendor: VendorHuggingFace},
+	{Pattern: "ollama:", Kind: Prefix, Vendor: VendorOllama},
+	{Pattern: "novita:", Kind: Prefix, Vendor: VendorNovita},
+	{Pattern: "berget:", Kind: Prefix, Vendor: VendorBerget},
+	{Pattern: "azure:", Kind: Prefix, Vendor: VendorAzure},
+	{Pattern: "bedrock:", Kind: Prefix, Vendor: VendorBedrock},
+	{Pattern: "gpt-", Kind: Prefix, Vendor: VendorOpenAI},
+	{Pattern: "chatgpt-", Kind: Prefix, Vendor: VendorOpenAI},
+	{Pattern: "claude-", Kind: Prefix, Vendor: VendorAnthropic},
+	{Pattern: "gemini-", Kind: Prefix, Vendor: VendorGoogle},
+	{Pattern: "mistral-", Kind: Prefix, Vendor: VendorMistral},
+	{Pattern: "codestral", Kind: Prefix, Vendor: VendorMistral},
+	{Pattern: "devstral", Kind: Prefix, Vendor: VendorMistral},
+	{Pattern: "mixtral", Kind: Prefix, Vendor: VendorMistral},
+	{Pattern: "deepseek-", Kind: Prefix, Vendor: VendorDeepSeek},
+	{Pattern: "grok-", Kind: Prefix, Vendor: VendorXAI},
+	{Pattern: "mercury", Kind: Prefix, Vendor: VendorInception},
+
+	{Pattern: "gpt", Kind: Keyword, Vendor: VendorOpenAI},
+	{Pattern: "claude", Kind: Keyword, Vendor: VendorAnthropic},
+	{Pattern: "gemini", Kind: Keyword, Vendor: VendorGoogle},
+	{Pattern: "mistral", Kind: Keyword, Vendor: VendorMistral},
+	{Pattern: "mixtral", Kind: Keyword, Vendor: VendorMistral},
+	{Pattern: "codestral", Kind: Keyword, Vendor: VendorMistral},
+	{Pattern: "devstral", Kind: Keyword, Vendor: VendorMistral},
+	{Pattern: "deepseek", Kind: Keyword, Vendor: VendorDeepSeek},
+	{Pattern: "grok", Kind: Keyword, Vendor: VendorXAI},
+	{Pattern: "mercury", Kind: Keyword, Vendor: VendorInception},
+}
+
+// ErrNoRoute is returned for model names no route matches.
+var ErrNoRoute = errors.New("no route")
+
+// AmbiguousError is returned when routes of several vendors match a model
+// name with equal precedence.
+type AmbiguousError struct {
+	Model   string
+	Matches []Route
+}
+
+func (e *AmbiguousError) Error() string {
+	parts := make([]string, 0, len(e.Matches))
+	for _, r := range e.Matches {
+		parts = append(parts, fmt.Sprintf("%s %q -> %s", r.Kind, r.Pattern, r.Vendor))
+	}
+	return fmt.Sprintf("model %q is ambiguous: %s; use a vendor prefix such as \"or:\" or an exact model name", e.Model, strings.Join(parts, ", "))
+}
+
+// Resolution is the outcome of routing a model name.
+type Resolution struct {
+	Vendor string
+	// Model is the model name to query: the alias target for aliases, the
+	// routed name otherwise.
+	Model string
+	Route Route
+}
+
+// Resolve routes model through Table.
+func Resolve(model string) (Resolution, error) {
+	return resolve(Table, model)
+}
+
+func resolve(table []Route, model string) (Resolution, error) {
+	for _, kind := range []MatchKind{Exact, Prefix, Keyword} {
+		matches := match(table, kind, model)
+		if len(matches) == 0 {
+			continue
+		}
+		if kind == Prefix {
+			// The longest prefix is the most specific one.
+			longest := len(matches[0].Pattern)
+			for _, r := range matches {
+				longest = max(longest, len(r.Pattern))
+			}
+			matches = filter(matches, func(r Route) bool { return len(r.Pattern) == longest })
+		}
+		if vendors := distinctVendors(matches); len(vendors) > 1 {
+			return Resolution{}, &AmbiguousError{Model: model, Matches: matches}
+		}
+		r := matches[0]
+		res := Resolution{Vendor: r.Vendor, Model: model, Route: r}
+		if r.Target != "" {
+			res.Model = r.Target
+		}
+		return res, nil
+	}
+	return Resolution{}, fmt.Errorf("route model %q: %w", model, ErrNoRoute)
+}
+
+func match(table []Route, kind MatchKind, model string) []Route {
+	return filter(table, func(r Route) bool {
+		if r.Kind != kind {
+			return false
+		}
+		switch kind {
+		case Exact:
+			return model == r.Pattern
+		case Prefix:
+			return strings.HasPrefix(model, r.Pattern)
+		default:
+			return strings.Contains(model, r.Pattern)
+		}
+	})
+}
+
+func filter(routes []Route, keep func(Route) bool) []Route {
+	var ret []Route
+	for _, r := range routes {
+		if keep(r) {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+func distinctVendors(routes []Route) []string {
+	uniq := map[string]struct{}{}
+	for _, r := range routes {
+		uniq[r.Vendor] = struct{}{}
+	}
+	ret := make([]string, 0, len(uniq))
+	for v := range uniq {
+		ret = append(ret, v)
+	}
+	sort.Strings(ret)
+	return ret
+}
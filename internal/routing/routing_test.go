@@ -0,0 +1,99 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		model      string
+		wantVendor string
+		wantModel  string
+	}{
+		{"gpt-4.1", VendorOpenAI, "gpt-4.1"},
+		// Contains "test", which used to route to the mock vendor.
+		{"chatgpt-4o-latest", VendorOpenAI, "chatgpt-4o-latest"},
+		{"ft:gpt-4o-mini:org::abc", VendorOpenAI, "ft:gpt-4o-mini:org::abc"},
+		{"claude-sonnet-4-5", VendorAnthropic, "claude-sonnet-4-5"},
+		{"sonnet", VendorAnthropic, "claude-sonnet-4-5"},
+		// The vendor prefix wins over the vendor word in the name.
+		{"or:anthropic/claude-sonnet-4.5", VendorOpenRouter, "or:anthropic/claude-sonnet-4.5"},
+		{"ollama:deepseek-r1:8b", VendorOllama, "ollama:deepseek-r1:8b"},
+		{"ollama:mistral", VendorOllama, "ollama:mistral"},
+		{"hf:Qwen/Qwen2.5-72B-Instruct:novita", VendorHuggingFace, "hf:Qwen/Qwen2.5-72B-Instruct:novita"},
+		{"berget:gemma-4-31B-it", VendorBerget, "berget:gemma-4-31B-it"},
+		{"bedrock:anthropic.claude-sonnet-4-5-20250929-v1:0", VendorBedrock, "bedrock:anthropic.claude-sonnet-4-5-20250929-v1:0"},
+		{"novita:gryphe/mythomax-l2-13b", VendorNovita, "novita:gryphe/mythomax-l2-13b"},
+		{"codestral-latest", VendorMistral, "codestral-latest"},
+		{"gemini-2.5-pro", VendorGoogle, "gemini-2.5-pro"},
+		{"grok-4", VendorXAI, "grok-4"},
+		{"mercury-coder", VendorInception, "mercury-coder"},
+		{"deepseek-chat", VendorDeepSeek, "deepseek-chat"},
+		{"test", VendorMock, "test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, err := Resolve(tt.model)
+			if err != nil {
+				t.Fatalf("Resolve(%q): %v", tt.model, err)
+			}
+			if got.Vendor != tt.wantVendor || got.Model != tt.wantModel {
+				t.Fatalf("Resolve(%q) = (%q, %q), want (%q, %q)", tt.model, got.Vendor, got.Model, tt.wantVendor, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestResolve_NoRoute(t *testing.T) {
+	if _, err := Resolve("llama-70b"); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("expected ErrNoRoute, got %v", err)
+	}
+}
+
+func TestResolve_AmbiguousKeywords(t *testing.T) {
+	_, err := Resolve("my-claude-vs-gpt-finetune")
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected AmbiguousError, got %v", err)
+	}
+	if len(ambiguous.Matches) != 2 {
+		t.Fatalf("expected both keyword routes in the error, got %+v", ambiguous.Matches)
+	}
+}
+
+func TestResolve_LongestPrefixWins(t *testing.T) {
+	table := []Route{
+		{Pattern: "a:", Kind: Prefix, Vendor: "short"},
+		{Pattern: "a:b:", Kind: Prefix, Vendor: "long"},
+		{Pattern: "x:", Kind: Prefix, Vendor: "one"},
+		{Pattern: "x:", Kind: Prefix, Vendor: "other"},
+	}
+	got, err := resolve(table, "a:b:model")
+	if err != nil || got.Vendor != "long" {
+		t.Fatalf("expected the longest prefix to win, got %+v (err %v)", got, err)
+	}
+	var ambiguous *AmbiguousError
+	if _, err := resolve(table, "x:model"); !errors.As(err, &ambiguous) {
+		t.Fatalf("expected equal prefixes of two vendors to be ambiguous, got %v", err)
+	}
+}
+
+// TestTable_Consistent guards the built-in table against routes that could
+// never be told apart.
+func TestTable_Consistent(t *testing.T) {
+	seen := map[string]string{}
+	for _, r := range Table {
+		if r.Pattern == "" || r.Vendor == "" {
+			t.Fatalf("incomplete route %+v", r)
+		}
+		key := r.Kind.String() + " " + r.Pattern
+		if other, ok := seen[key]; ok && other != r.Vendor {
+			t.Fatalf("%s routes to both %q and %q", key, other, r.Vendor)
+		}
+		seen[key] = r.Vendor
+		if r.Target != "" && r.Kind != Exact {
+			t.Fatalf("alias %q must be an exact route", r.Pattern)
+		}
+	}
+}
@@ -51,6 +51,10 @@ const (
 	CONFDIR
 	COMPLETION
 	HIDDEN_COMPLETION
+	MODELS
+	BATCH
+	UNDO
+	CHECKPOINTS
 )
 
 var defaultFlags = Configurations{
@@ -123,6 +127,14 @@ func getCmdFromArgs(args []string) (Mode, error) {
 		return COMPLETION, nil
 	case "__complete":
 		return HIDDEN_COMPLETION, nil
+	case "models":
+		return MODELS, nil
+	case "batch":
+		return BATCH, nil
+	case "undo":
+		return UNDO, nil
+	case "checkpoints":
+		return CHECKPOINTS, nil
 	default:
 		return HELP, fmt.Errorf("unknown command: '%s' all args: '%s'", cmd, args)
 	}
@@ -150,6 +162,13 @@ func isReadOnlyChatSubCommand(subCmd string) bool {
 	}
 }
 
+// needsNoQuerier reports whether a chat subcommand runs without a model
+// querier: the read-only subcommands, plus migrate-store which only moves
+// stored conversations between backends.
+func needsNoQuerier(subCmd string) bool {
+	return isReadOnlyChatSubCommand(subCmd) || subCmd == "migrate-store"
+}
+
 // setupToolConfig by matching configuration in flagSet with tConf to ensure that tools
 // are propperly enabled
 func setupToolConfig(tConf *text.Configurations, flagSet Configurations) {
@@ -255,6 +274,9 @@ func setupTextQuerierWithConf(ctx context.Context, mode Mode, confDir string, fl
 	// the configuration presecende is flags > file > default. So, we need
 	// to re-apply the flag overrides to the configuration
 	applyFlagOverridesForText(&tConf, flagSet, defaultFlags)
+	if flagSet.ChatModel != defaultFlags.ChatModel {
+		warnUncatalogedModel(confDir, flagSet.ChatModel)
+	}
 
 	// Load response format from file if specified
 	if flagSet.ResponseFormatPath != "" {
@@ -345,7 +367,11 @@ func setupTextQuerierWithConf(ctx context.Context, mode Mode, confDir string, fl
 	// filesystem roundtrip (write globalScope then read it back) that was
 	// the root cause of the -dre query forking bug.
 	if tConf.DirReplyMode {
-		dirChat, err := chat.LoadDirScopedContext(confDir)
+		p, err := tConf.Persistence()
+		if err != nil {
+			return nil, nil, err
+		}
+		dirChat, err := p.LoadDirScopedContext(confDir)
 		if err != nil {
 			return nil, nil, fmt.Errorf("load dir-scoped context: %w", err)
 		}
@@ -400,7 +426,11 @@ func setupLookback(confDir string, tConf *text.Configurations, flagSet Configura
 	// enabled the search/inspect/read tools are registered (so the agent can search
 	// OTHER directories even from a dir with no recorded history). The passive
 	// descriptor block, by contrast, is only injected when the CWD has history.
-	desc, err := chat.BuildLookbackDescriptor(confDir, tConf.LookbackCWD, lookbackInjectCount)
+	p, err := tConf.Persistence()
+	if err != nil {
+		return err
+	}
+	desc, err := p.BuildLookbackDescriptor(confDir, tConf.LookbackCWD, lookbackInjectCount)
 	if err != nil {
 		return fmt.Errorf("build lookback descriptor: %w", err)
 	}
@@ -450,7 +480,11 @@ func applyDirReplyChatID(confDir string, tConf *text.Configurations, q models.Qu
 	if tConf == nil {
 		return fmt.Errorf("text configuration is nil")
 	}
-	chatID, err := chat.LoadDirScopeChatID(confDir)
+	p, err := tConf.Persistence()
+	if err != nil {
+		return err
+	}
+	chatID, err := p.LoadDirScopeChatID(confDir)
 	if err != nil {
 		return fmt.Errorf("load dir reply chat id: %w", err)
 	}
@@ -581,8 +615,10 @@ func Setup(ctx context.Context, usage string, allArgs []string) (models.Querier,
 		}
 		ancli.PrintOK(msg + "\n")
 	}
-	if _, added, err := utils.LoadConfigFromFileCollect(claiConfDir, "textConfig.json", migrateOldChatConfig, &text.Default); err != nil {
+	textConf, added, err := utils.LoadConfigFromFileCollect(claiConfDir, "textConfig.json", migrateOldChatConfig, &text.Default)
+	if err != nil {
 		ancli.Warnf("failed to upgrade textConfig.json: %v\n", err)
+		textConf = text.Default
 	} else {
 		announceUpgrade("textConfig.json", added)
 	}
@@ -633,8 +669,8 @@ func Setup(ctx context.Context, usage string, allArgs []string) (models.Querier,
 
 	switch mode {
 	case CHAT, QUERY, GLOB:
-		if mode == CHAT && isReadOnlyChatSubCommand(chatSubCommand(postFlagArgs)) {
-			q, err := newReadOnlyChatHandler(ctx, claiConfDir, postFlagConf, postFlagArgs)
+		if mode == CHAT && needsNoQuerier(chatSubCommand(postFlagArgs)) {
+			q, err := newReadOnlyChatHandler(ctx, claiConfDir, textConf, postFlagConf, postFlagArgs)
 			if err != nil {
 				return nil, err
 			}
@@ -742,18 +778,31 @@ func Setup(ctx context.Context, usage string, allArgs []string) (models.Querier,
 		return nil, handleCompletionCommand(ctx, postFlagArgs)
 	case HIDDEN_COMPLETION:
 		return nil, handleHiddenCompletion(ctx, postFlagArgs)
+	case MODELS:
+		return modelsCmd(ctx, postFlagArgs)
+	case BATCH:
+		return batchCmd(ctx, claiConfDir, postFlagConf, postFlagArgs)
+	case UNDO:
+		return undoCmd(claiConfDir, postFlagArgs)
+	case CHECKPOINTS:
+		return checkpointsCmd(claiConfDir, postFlagArgs)
 	default:
 		return nil, fmt.Errorf("unknown mode: %v", mode)
 	}
 }
 
-// newReadOnlyChatHandler builds a chat handler for read-only subcommands
-// (list, dir, dirv2, help). It deliberately passes no model querier because
-// those subcommands only read conversation state, so no config files or model
-// configs are created or loaded as a side effect.
-func newReadOnlyChatHandler(_ context.Context, confDir string, flagSet Configurations, args []string) (models.Querier, error) {
+// newReadOnlyChatHandler builds a chat handler for subcommands which need no
+// model (list, dir, dirv2, help, migrate-store). It deliberately passes no
+// model querier because those subcommands only touch conversation state, so
+// no model configs are created or loaded as a side effect. tConf is the
+// textConfig.json Setup already loaded.
+func newReadOnlyChatHandler(_ context.Context, confDir string, tConf text.Configurations, flagSet Configurations, args []string) (models.Querier, error) {
+	applyFlagOverridesForText(&tConf, flagSet, defaultFlags)
 	chatArgs := strings.Join(args[1:], " ")
-	h, err := chat.New(nil, confDir, chatArgs, nil, chat.NotCyclicalImport{}, flagSet.PrintRaw, os.Stdout, nil)
+	h, err := chat.New(nil, confDir, chatArgs, nil, chat.NotCyclicalImport{
+		ConversationBackend: tConf.ConversationBackend,
+		ShowReasoning:       tConf.ShowsReasoning(),
+	}, flagSet.PrintRaw, os.Stdout, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create read-only chat handler: %w", err)
 	}
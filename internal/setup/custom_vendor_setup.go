@@ -0,0 +1,28 @@
+package setup
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/baalimago/clai/internal/vendors/custom"
+)
+
+// customVendorSetupCategory lists the user-defined vendors. Nothing is written
+// until the user creates one, as every vendor file claims a model prefix
+// before the built-in routing.
+func customVendorSetupCategory() setupCategory {
+	return setupCategory{
+		name: "custom vendors (OpenAI-compatible)",
+		load: func(dir string) ([]config, error) {
+			cfgs, err := getConfigs(filepath.Join(custom.Dir(dir), "*.json"), []string{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get custom vendor configs: %w", err)
+			}
+			return cfgs, nil
+		},
+		itemSelectActions: []action{newaction, pasteConfig},
+		itemActions:       []action{conf, del, copyAction, confWithEditor},
+		subdirPath:        "./vendors",
+		defaultConfig:     custom.Example,
+	}
+}
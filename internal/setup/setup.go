@@ -231,6 +231,7 @@ func InitCmd() error {
 			defaultConfig:     defaultMcpServer,
 		},
 		shellContextSetupCategory(),
+		customVendorSetupCategory(),
 	}
 
 	for {
@@ -2072,6 +2072,22 @@ func TestShellContextSetupCategory_Load(t *testing.T) {
 	}
 }
 
+func TestCustomVendorSetupCategory_LoadWritesNothing(t *testing.T) {
+	cat := customVendorSetupCategory()
+	dir := t.TempDir()
+
+	cfgs, err := cat.load(dir)
+	if err != nil {
+		t.Fatalf("load(): %v", err)
+	}
+	if len(cfgs) != 0 {
+		t.Fatalf("load() returned %d configs, want none", len(cfgs))
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "vendors")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected no vendors dir to be written, got %v", statErr)
+	}
+}
+
 func TestExecuteConfigAction_ConfWithEditor(t *testing.T) {
 	dir := t.TempDir()
 	cfgPath := filepath.Join(dir, "test.json")
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/baalimago/clai/internal/photo"
 	"github.com/baalimago/clai/internal/text"
@@ -47,6 +48,16 @@ type Configurations struct {
 	// UseLookbackSet is true when -lb/-lookback was explicitly passed, so an
 	// explicit -lb=false can override profile/file-enabled lookback.
 	UseLookbackSet bool
+	// RepoMap inserts a repository map into the system prompt when true.
+	RepoMap bool
+	// RepoMapSet is true when -repomap was explicitly passed, so an explicit
+	// -repomap=false can override a profile enabling it.
+	RepoMapSet bool
+	// ShowReasoning is the -show-reasoning value. Meaningful only when
+	// ShowReasoningSet is true, so an unset flag keeps show-reasoning of
+	// textConfig.json.
+	ShowReasoning    bool
+	ShowReasoningSet bool
 	// MaxTokens is the -mt/-max-tokens value. Meaningful only when
 	// MaxTokensSet is true.
 	MaxTokens int
@@ -67,8 +78,21 @@ type Configurations struct {
 	// can override a file-configured stoploss.max-tool-calls-after-handover.
 	MaxToolCallsAfterHandoverSet bool
 	Glob                         string
-	Profile                      string
-	ProfilePath                  string
+	// GlobExclude is the raw comma-separated -gx/-glob-exclude value.
+	GlobExclude string
+	// GlobMaxTokens is the -glob-max-tokens value. Meaningful only when
+	// GlobMaxTokensSet is true.
+	GlobMaxTokens    int
+	GlobMaxTokensSet bool
+	// Attach is the raw comma-separated -a/-attach value.
+	Attach string
+	// Images are the paths or URLs of the repeatable -i/-image flag.
+	Images []string
+	// ImageMaxSize is the -image-max-size value, the longest side in pixels
+	// -i images are downscaled to. 0 keeps the original size.
+	ImageMaxSize int
+	Profile      string
+	ProfilePath  string
 	// ShellContext is the selected shell context name (ASC).
 	ShellContext string
 	// ResponseFormatPath is a path to a JSON file describing the OpenAI response_format.
@@ -113,6 +137,15 @@ func parseFlags(defaults Configurations, args []string) (Configurations, []strin
 
 	gShort := fs.String("g", defaults.Glob, "Use globbing from query or chat. This flag will deprecate glob mode in a future release.")
 	gLong := fs.String("glob", defaults.Glob, "Use globbing from query or chat. This flag will deprecate glob mode in a future release.")
+	gxShort := fs.String("gx", defaults.GlobExclude, "Comma-separated .gitignore style patterns of files the glob leaves out, e.g. '*_test.go'.")
+	gxLong := fs.String("glob-exclude", defaults.GlobExclude, "Comma-separated .gitignore style patterns of files the glob leaves out, e.g. '*_test.go'.")
+	aShort := fs.String("a", defaults.Attach, "Comma-separated documents to attach: PDF, DOCX, XLSX, CSV, HTML or text files.")
+	aLong := fs.String("attach", defaults.Attach, "Comma-separated documents to attach: PDF, DOCX, XLSX, CSV, HTML or text files.")
+	var images stringsFlag
+	fs.Var(&images, "i", "Attach an image, a path or an http(s) URL. Repeat the flag to attach several.")
+	fs.Var(&images, "image", "Attach an image, a path or an http(s) URL. Repeat the flag to attach several.")
+	imageMaxSize := fs.Int("image-max-size", defaults.ImageMaxSize, "Downscale -i images to this many pixels on their longest side. 0 keeps the original size.")
+	globMaxTokens := fs.Int("glob-max-tokens", defaults.GlobMaxTokens, "Set the token budget of the globbed file contents. 0 = default, negative = unlimited. Overrides glob-max-tokens in textConfig.json.")
 
 	pShort := fs.String("p", defaults.Profile, "Set this to the override profile you'd like to use. Configure with 'clai setup' -> 2.")
 	pLong := fs.String("profile", defaults.Profile, "Set this to the override profile you'd like to use. Configure with 'clai setup' -> 2.")
@@ -121,7 +154,6 @@ func parseFlags(defaults Configurations, args []string) (Configurations, []strin
 
 	stdinReplaceShort := fs.String("I", defaults.StdinReplace, "Set the string to replace with stdin. (flag syntax borrowed from xargs)")
 	stdinReplaceLong := fs.String("replace", defaults.StdinReplace, "Set the string to replace with stdin. (flag syntax borrowed from xargs)'")
-	expectReplace := fs.Bool("i", defaults.ExpectReplace, "Set to true to replace '{}' with stdin. This is overwritten by -I and -replace. (flag syntax borrowed from xargs)'")
 
 	printRawShort := fs.Bool("r", defaults.PrintRaw, "Set to true to print raw output (don't attempt to use 'glow').")
 	printRawLong := fs.Bool("raw", defaults.PrintRaw, "Set to true to print raw output (don't attempt to use 'glow').")
@@ -149,6 +181,9 @@ func parseFlags(defaults Configurations, args []string) (Configurations, []strin
 	useLookbackShort := fs.Bool("lb", defaults.UseLookback, "Enable conversation lookback (recent-conversations memory + search/inspect/read tools).")
 	useLookbackLong := fs.Bool("lookback", defaults.UseLookback, "Enable conversation lookback (recent-conversations memory + search/inspect/read tools).")
 
+	repoMap := fs.Bool("repomap", defaults.RepoMap, "Insert a map of the repository in the working directory (directories, declarations per file) into the system prompt.")
+	showReasoning := fs.Bool("show-reasoning", defaults.ShowReasoning, "Show (true) or hide (false) the stored reasoning of the models in the 'clai chat' views. Overrides show-reasoning in textConfig.json.")
+
 	maxTokensShort := fs.Int("mt", defaults.MaxTokens, "Set the max context tokens for this run. 0 = unlimited. Overrides stoploss.max-tokens in textConfig.json.")
 	maxTokensLong := fs.Int("max-tokens", defaults.MaxTokens, "Set the max context tokens for this run. 0 = unlimited. Overrides stoploss.max-tokens in textConfig.json.")
 	maxToolCallsShort := fs.Int("mtc", defaults.MaxToolCalls, "Set the max tool calls for this run. 0 = unlimited. Overrides max-tool-calls in textConfig.json.")
@@ -175,6 +210,10 @@ func parseFlags(defaults Configurations, args []string) (Configurations, []strin
 	exitWithFlagError(err, "pp", "photo-prefix")
 	glob, err := utils.ReturnNonDefault(*gShort, *gLong, defaults.Glob)
 	exitWithFlagError(err, "g", "glob")
+	globExclude, err := utils.ReturnNonDefault(*gxShort, *gxLong, defaults.GlobExclude)
+	exitWithFlagError(err, "gx", "glob-exclude")
+	attach, err := utils.ReturnNonDefault(*aShort, *aLong, defaults.Attach)
+	exitWithFlagError(err, "a", "attach")
 	stdinReplace, err := utils.ReturnNonDefault(*stdinReplaceShort, *stdinReplaceLong, defaults.StdinReplace)
 	exitWithFlagError(err, "I", "replace")
 	useTools, err := utils.ReturnNonDefault(*useToolsShort, *useToolsLong, defaults.UseTools)
@@ -183,15 +222,25 @@ func parseFlags(defaults Configurations, args []string) (Configurations, []strin
 	exitWithFlagError(err, "s", "skills")
 	useLookback := *useLookbackShort || *useLookbackLong
 	useLookbackSet := false
+	repoMapSet := false
+	showReasoningSet := false
 	mtSet := false
 	maxTokensSet := false
 	mtcSet := false
 	maxToolCallsSet := false
 	maxToolCallsAfterHandoverSet := false
+	globMaxTokensSet := false
+	replaceSet := false
 	fs.Visit(func(f *flag.Flag) {
 		switch f.Name {
+		case "I", "replace":
+			replaceSet = true
 		case "lb", "lookback":
 			useLookbackSet = true
+		case "repomap":
+			repoMapSet = true
+		case "show-reasoning":
+			showReasoningSet = true
 		case "mt":
 			mtSet = true
 		case "max-tokens":
@@ -202,6 +251,8 @@ func parseFlags(defaults Configurations, args []string) (Configurations, []strin
 			maxToolCallsSet = true
 		case "max-tool-calls-after-handover":
 			maxToolCallsAfterHandoverSet = true
+		case "glob-max-tokens":
+			globMaxTokensSet = true
 		}
 	})
 	profile, err := utils.ReturnNonDefault(*pShort, *pLong, defaults.Profile)
@@ -232,9 +283,15 @@ func parseFlags(defaults Configurations, args []string) (Configurations, []strin
 	printRaw := *printRawShort || *printRawLong
 	dirReplyMode := *dirReplyShort || *dirReplyLong
 
-	if *expectReplace && defaults.StdinReplace == "" {
+	// An explicit -I equal to the default is no override by value, but must
+	// still replace a stdin-replace string of the config file.
+	expectReplace := defaults.ExpectReplace || (replaceSet && stdinReplace == defaults.StdinReplace)
+	if expectReplace && defaults.StdinReplace == "" {
 		stdinReplace = "{}"
 	}
+	if err := checkImageFlags(images); err != nil {
+		return Configurations{}, []string{}, err
+	}
 
 	newConf := Configurations{
 		ChatModel:                    chatModel,
@@ -253,6 +310,10 @@ func parseFlags(defaults Configurations, args []string) (Configurations, []strin
 		CmdBan:                       *cmdBan,
 		UseLookback:                  useLookback,
 		UseLookbackSet:               useLookbackSet,
+		RepoMap:                      *repoMap,
+		RepoMapSet:                   repoMapSet,
+		ShowReasoning:                *showReasoning,
+		ShowReasoningSet:             showReasoningSet,
 		MaxTokens:                    maxTokens,
 		MaxTokensSet:                 maxTokensSet,
 		MaxToolCalls:                 maxToolCalls,
@@ -260,7 +321,13 @@ func parseFlags(defaults Configurations, args []string) (Configurations, []strin
 		MaxToolCallsAfterHandover:    *maxToolCallsAfterHandover,
 		MaxToolCallsAfterHandoverSet: maxToolCallsAfterHandoverSet,
 		Glob:                         glob,
-		ExpectReplace:                *expectReplace,
+		GlobExclude:                  globExclude,
+		GlobMaxTokens:                *globMaxTokens,
+		GlobMaxTokensSet:             globMaxTokensSet,
+		Attach:                       attach,
+		Images:                       images,
+		ImageMaxSize:                 *imageMaxSize,
+		ExpectReplace:                expectReplace,
 		Profile:                      profile,
 		ProfilePath:                  profilePath,
 		ShellContext:                 shellContext,
@@ -271,6 +338,34 @@ func parseFlags(defaults Configurations, args []string) (Configurations, []strin
 	return newConf, postParseArgs, nil
 }
 
+// stringsFlag is a flag which may be repeated, collecting every value.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// checkImageFlags refuses -i values which name a command rather than an
+// image. -i used to be the boolean 'replace {} with stdin' flag, so
+// 'clai -i q ...' would otherwise attach an image named 'q'.
+func checkImageFlags(images []string) error {
+	for _, img := range images {
+		if _, err := getCmdFromArgs([]string{img}); err != nil {
+			continue
+		}
+		if _, err := os.Stat(img); err == nil {
+			continue
+		}
+		return fmt.Errorf("flags: -i attaches an image, and '%v' is a command. Use '-I {}' to replace '{}' with stdin", img)
+	}
+	return nil
+}
+
 // applyFlagOverridesForText is defined here, and not as a method on text.Confugrations, as that would
 // cause import cycle.
 //
@@ -304,6 +399,25 @@ func applyFlagOverridesForText(tConf *text.Configurations, flagSet, defaultFlags
 	if flagSet.ShellContext != defaultFlags.ShellContext {
 		tConf.ShellContext = flagSet.ShellContext
 	}
+	if flagSet.GlobExclude != defaultFlags.GlobExclude {
+		tConf.GlobExclude = strings.Split(flagSet.GlobExclude, ",")
+	}
+	if flagSet.GlobMaxTokensSet {
+		tConf.GlobMaxTokens = flagSet.GlobMaxTokens
+	}
+	if flagSet.ShowReasoningSet {
+		show := flagSet.ShowReasoning
+		tConf.ShowReasoning = &show
+	}
+	if flagSet.Attach != defaultFlags.Attach {
+		tConf.Attachments = strings.Split(flagSet.Attach, ",")
+	}
+	if len(flagSet.Images) > 0 {
+		tConf.Images = flagSet.Images
+	}
+	if flagSet.ImageMaxSize != defaultFlags.ImageMaxSize {
+		tConf.ImageMaxSize = flagSet.ImageMaxSize
+	}
 	if flagSet.MaxTokensSet {
 		if tConf.Stoploss == nil {
 			tConf.Stoploss = &text.Stoploss{}
@@ -345,6 +459,9 @@ func applyProfileOverridesForText(tConf *text.Configurations, flagSet, defaultFl
 	if flagSet.ChatModel != defaultFlags.ChatModel {
 		tConf.Model = flagSet.ChatModel
 	}
+	if flagSet.RepoMapSet {
+		tConf.RepoMap = flagSet.RepoMap
+	}
 }
 
 func applyFlagOverridesForPhoto(pConf *photo.Configurations, flagSet, defaultFlags Configurations) {
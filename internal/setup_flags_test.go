@@ -113,8 +113,8 @@ func TestSetupFlags(t *testing.T) {
 			},
 		},
 		{
-			name: "-i should cause stdin replace",
-			args: []string{"cmd", "-i"},
+			name: "-I should cause stdin replace",
+			args: []string{"cmd", "-I", "{}"},
 			defaults: Configurations{
 				ChatModel:     "gpt-4",
 				PhotoModel:    "dall-e-2",
@@ -250,6 +250,66 @@ func TestSetupFlags(t *testing.T) {
 				UseLookbackSet: true,
 			},
 		},
+		{
+			name:     "Glob exclude short flag",
+			args:     []string{"cmd", "-gx", "*_test.go,vendor/"},
+			defaults: Configurations{},
+			want: Configurations{
+				GlobExclude: "*_test.go,vendor/",
+			},
+		},
+		{
+			name:     "Image flags repeat",
+			args:     []string{"cmd", "-i", "a.png", "-image", "https://example.com/b.jpg", "-image-max-size", "1024", "q", "hi"},
+			defaults: Configurations{},
+			want: Configurations{
+				Images:       []string{"a.png", "https://example.com/b.jpg"},
+				ImageMaxSize: 1024,
+			},
+			wantPostArgs: []string{"q", "hi"},
+		},
+		{
+			name:            "-i followed by a command hints at -I",
+			args:            []string{"cmd", "-i", "q", "summarize {}"},
+			defaults:        Configurations{},
+			want:            Configurations{},
+			wantErrContains: "Use '-I {}'",
+		},
+		{
+			name:     "Attach long flag",
+			args:     []string{"cmd", "-attach", "report.pdf,data.xlsx"},
+			defaults: Configurations{},
+			want: Configurations{
+				Attach: "report.pdf,data.xlsx",
+			},
+		},
+		{
+			name:     "Glob max tokens flag marks explicit, also for zero",
+			args:     []string{"cmd", "-glob-max-tokens", "0"},
+			defaults: Configurations{},
+			want: Configurations{
+				GlobMaxTokens:    0,
+				GlobMaxTokensSet: true,
+			},
+		},
+		{
+			name:     "Repomap flag enables and marks explicit",
+			args:     []string{"cmd", "-repomap"},
+			defaults: Configurations{},
+			want: Configurations{
+				RepoMap:    true,
+				RepoMapSet: true,
+			},
+		},
+		{
+			name:     "Show reasoning flag marks explicit, also for false",
+			args:     []string{"cmd", "-show-reasoning=false"},
+			defaults: Configurations{},
+			want: Configurations{
+				ShowReasoning:    false,
+				ShowReasoningSet: true,
+			},
+		},
 		{
 			name:     "Max tokens short flag",
 			args:     []string{"cmd", "-mt", "5000"},
@@ -561,6 +621,43 @@ func Test_applyFlagOverridesForTest(t *testing.T) {
 	}
 }
 
+func Test_applyFlagOverridesForText_Glob(t *testing.T) {
+	tConf := text.Configurations{GlobMaxTokens: 5000}
+	applyFlagOverridesForText(&tConf, Configurations{GlobExclude: "*_test.go,vendor/"}, defaultFlags)
+	if !slices.Equal(tConf.GlobExclude, []string{"*_test.go", "vendor/"}) || tConf.GlobMaxTokens != 5000 {
+		t.Fatalf("unexpected glob config: exclude=%q max-tokens=%d", tConf.GlobExclude, tConf.GlobMaxTokens)
+	}
+	applyFlagOverridesForText(&tConf, Configurations{GlobMaxTokens: -1, GlobMaxTokensSet: true}, defaultFlags)
+	if tConf.GlobMaxTokens != -1 {
+		t.Fatalf("-glob-max-tokens must override the file, got %d", tConf.GlobMaxTokens)
+	}
+}
+
+func Test_applyFlagOverridesForText_ShowReasoning(t *testing.T) {
+	hide := false
+	tConf := text.Configurations{ShowReasoning: &hide}
+	applyFlagOverridesForText(&tConf, Configurations{}, defaultFlags)
+	if tConf.ShowsReasoning() {
+		t.Fatal("an unset -show-reasoning flag must keep the file value")
+	}
+	applyFlagOverridesForText(&tConf, Configurations{ShowReasoning: true, ShowReasoningSet: true}, defaultFlags)
+	if !tConf.ShowsReasoning() {
+		t.Fatal("an explicit -show-reasoning must override the file")
+	}
+}
+
+func Test_applyProfileOverridesForText_RepoMap(t *testing.T) {
+	tConf := text.Configurations{RepoMap: true}
+	applyProfileOverridesForText(&tConf, Configurations{}, defaultFlags)
+	if !tConf.RepoMap {
+		t.Fatal("an unset -repomap flag must keep the profile value")
+	}
+	applyProfileOverridesForText(&tConf, Configurations{RepoMapSet: true}, defaultFlags)
+	if tConf.RepoMap {
+		t.Fatal("an explicit -repomap=false must override the profile")
+	}
+}
+
 // Test_resolveIntAlias pins the four-state short/long alias resolver for the
 // int limit flags: neither set, exactly one set, both set and equal, both set
 // and conflicting. Explicit zero and explicit values equal to the default must
@@ -792,3 +889,27 @@ func Test_applyFlagOverridesForText_Stoploss(t *testing.T) {
 		})
 	}
 }
+
+func Test_applyFlagOverridesForText_Attach(t *testing.T) {
+	tConf := text.Configurations{}
+	applyFlagOverridesForText(&tConf, defaultFlags, defaultFlags)
+	if tConf.Attachments != nil {
+		t.Fatalf("no -a must attach nothing, got %q", tConf.Attachments)
+	}
+	applyFlagOverridesForText(&tConf, Configurations{Attach: "a.pdf,notes.docx"}, defaultFlags)
+	if !slices.Equal(tConf.Attachments, []string{"a.pdf", "notes.docx"}) {
+		t.Fatalf("unexpected attachments: %q", tConf.Attachments)
+	}
+}
+
+func Test_applyFlagOverridesForText_Images(t *testing.T) {
+	tConf := text.Configurations{ImageMaxSize: 2048}
+	applyFlagOverridesForText(&tConf, defaultFlags, defaultFlags)
+	if tConf.Images != nil || tConf.ImageMaxSize != 2048 {
+		t.Fatalf("no -i must keep the config, got images %q max size %d", tConf.Images, tConf.ImageMaxSize)
+	}
+	applyFlagOverridesForText(&tConf, Configurations{Images: []string{"a.png"}, ImageMaxSize: 512}, defaultFlags)
+	if !slices.Equal(tConf.Images, []string{"a.png"}) || tConf.ImageMaxSize != 512 {
+		t.Fatalf("unexpected images %q max size %d", tConf.Images, tConf.ImageMaxSize)
+	}
+}
@@ -0,0 +1,46 @@
+package text
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/baalimago/clai/internal/attachment"
+	"github.com/baalimago/clai/internal/utils"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// attachmentParts returns the content parts of the documents at paths.
+func attachmentParts(paths []string) ([]pub_models.ImageOrTextInput, error) {
+	var parts []pub_models.ImageOrTextInput
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		p, err := utils.ReplaceTildeWithHome(p)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %v: %w", p, err)
+		}
+		part, err := attachment.Part(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach %v: %w", p, err)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// withAttachments returns msg with parts before its content, so the prompt
+// reads after the documents it refers to.
+func withAttachments(msg pub_models.Message, parts []pub_models.ImageOrTextInput) pub_models.Message {
+	if len(parts) == 0 {
+		return msg
+	}
+	content := msg.ContentParts
+	if len(content) == 0 {
+		content = []pub_models.ImageOrTextInput{{Type: string(pub_models.Text), Text: msg.Content}}
+	}
+	msg.ContentParts = append(append([]pub_models.ImageOrTextInput{}, parts...), content...)
+	msg.Content = ""
+	return msg
+}
@@ -0,0 +1,54 @@
+package text
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func TestConfigurations_SetupInitialChat_Attachments(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("the deadline is friday"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := Default
+	conf.ConfigDir = t.TempDir()
+	conf.Attachments = []string{notes, ""}
+	if err := conf.SetupInitialChat([]string{"q", "when is the deadline?"}); err != nil {
+		t.Fatalf("SetupInitialChat: %v", err)
+	}
+	last := conf.InitialChat.Messages[len(conf.InitialChat.Messages)-1]
+	if last.Role != "user" || last.Content != "" || len(last.ContentParts) != 2 {
+		t.Fatalf("prompt message: %+v", last)
+	}
+	if !strings.Contains(last.ContentParts[0].Text, "the deadline is friday") {
+		t.Fatalf("attachment part: %+v", last.ContentParts[0])
+	}
+	if last.ContentParts[1] != (pub_models.ImageOrTextInput{Type: "text", Text: "when is the deadline?"}) {
+		t.Fatalf("prompt part: %+v", last.ContentParts[1])
+	}
+
+	chatConf := Default
+	chatConf.ConfigDir = t.TempDir()
+	chatConf.ChatMode = true
+	chatConf.Attachments = []string{notes}
+	if err := chatConf.SetupInitialChat([]string{"c", "hello"}); err != nil {
+		t.Fatalf("SetupInitialChat in chat mode: %v", err)
+	}
+	last = chatConf.InitialChat.Messages[len(chatConf.InitialChat.Messages)-1]
+	if last.Role != "user" || len(last.ContentParts) != 1 || !strings.Contains(last.ContentParts[0].Text, "notes.txt") {
+		t.Fatalf("chat mode attachment message: %+v", last)
+	}
+
+	missing := Default
+	missing.ConfigDir = t.TempDir()
+	missing.Attachments = []string{filepath.Join(dir, "missing.pdf")}
+	if err := missing.SetupInitialChat([]string{"q", "x"}); err == nil || !strings.Contains(err.Error(), "missing.pdf") {
+		t.Fatalf("expected an error naming the missing attachment, got %v", err)
+	}
+}
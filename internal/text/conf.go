@@ -12,9 +12,12 @@ import (
 	"github.com/baalimago/clai/internal/chat"
 	"github.com/baalimago/clai/internal/chatid"
 	"github.com/baalimago/clai/internal/glob"
+	"github.com/baalimago/clai/internal/models"
 	"github.com/baalimago/clai/internal/text/generic"
+	"github.com/baalimago/clai/internal/tools"
 	"github.com/baalimago/clai/internal/utils"
 	pub_models "github.com/baalimago/clai/pkg/text/models"
+	pkgtools "github.com/baalimago/clai/pkg/tools"
 	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
 	"github.com/baalimago/go_away_boilerplate/pkg/debug"
 	"github.com/baalimago/go_away_boilerplate/pkg/misc"
@@ -32,18 +35,38 @@ type Configurations struct {
 	CmdModePrompt string `json:"cmd-mode-prompt"`
 	// ToolOutputRuneLimit limits the amount of runes a tool may return
 	// before clai truncates the output. Zero means no limit.
-	ToolOutputRuneLimit int    `json:"tool-output-rune-limit"`
-	SaveReplyAsConv     bool   `json:"save-reply-as-prompt"`
-	ConfigDir           string `json:"-"`
-	StdinReplace        string `json:"-"`
-	Stream              bool   `json:"-"`
-	ReplyMode           bool   `json:"-"`
+	ToolOutputRuneLimit int  `json:"tool-output-rune-limit"`
+	SaveReplyAsConv     bool `json:"save-reply-as-prompt"`
+	// ConversationBackend selects where conversations are stored: "json"
+	// (the default, one file per chat) or "sqlite" (<config>/conversations.db),
+	// see Persistence.
+	ConversationBackend string `json:"conversation-store,omitempty"`
+	// ShowReasoning decides if the `clai chat` views show the stored reasoning
+	// of the messages. Nil shows it. The -show-reasoning flag overrides it.
+	ShowReasoning *bool  `json:"show-reasoning,omitempty"`
+	ConfigDir     string `json:"-"`
+	StdinReplace  string `json:"-"`
+	Stream        bool   `json:"-"`
+	ReplyMode     bool   `json:"-"`
 	// DirReplyMode marks a directory-scoped reply (-dre). Unlike a plain -re (which
 	// forks a fresh promoted id and must not record), -dre continues the bound
 	// conversation in place, so it DOES upsert the directory history (see finalizer).
-	DirReplyMode        bool            `json:"-"`
-	ChatMode            bool            `json:"-"`
-	Glob                string          `json:"-"`
+	DirReplyMode bool   `json:"-"`
+	ChatMode     bool   `json:"-"`
+	Glob         string `json:"-"`
+	// GlobExclude are .gitignore style patterns of files the glob leaves out.
+	GlobExclude []string `json:"-"`
+	// GlobMaxTokens budgets the file contents of the glob. Zero uses
+	// glob.DefaultMaxTokens, a negative value disables the budget.
+	GlobMaxTokens int `json:"glob-max-tokens,omitempty"`
+	// Attachments are paths of documents sent along with the prompt, see
+	// package attachment.
+	Attachments []string `json:"-"`
+	// Images are paths or URLs of images sent along with the prompt.
+	// ImageMaxSize is the longest side in pixels they are downscaled to, 0
+	// keeps the original size.
+	Images              []string        `json:"-"`
+	ImageMaxSize        int             `json:"image-max-size,omitempty"`
 	InitialChat         pub_models.Chat `json:"-"`
 	UseProfile          string          `json:"-"`
 	ProfilePath         string          `json:"-"`
@@ -56,10 +79,24 @@ type Configurations struct {
 	// purely additive: textConfig.json + profile cmd-ban + flag -cmd-ban +
 	// agent API; no source removes another source's bans.
 	CmdBan []string `json:"cmd-ban"`
+	// HTTPRequest is the host allowlist and the secret headers of the
+	// http_request tool. Nil refuses every request.
+	HTTPRequest *pkgtools.HTTPPolicy `json:"http-request,omitempty"`
+	// WebSearch is the backend of the web_search tool. Nil reads
+	// webSearch.json of ConfigDir.
+	WebSearch *pkgtools.WebSearchConfig `json:"-"`
 	// ShellContext is a context definition name for ASC (auto-append shell context).
 	// When non-empty, clai will load <configDir>/shellContexts/<name>.json and insert
 	// the rendered template block into the system prompt instead of the user prompt.
 	ShellContext string `json:"-"`
+	// RepoMap inserts a map of the repository in the working directory into
+	// the system prompt, the same way as the shell context. RepoMapTokens is
+	// its budget, 0 meaning repomap.DefaultTokens.
+	RepoMap       bool `json:"-"`
+	RepoMapTokens int  `json:"-"`
+	// Thinking carries the thinking settings of the profile to vendors
+	// implementing models.ThinkingConfigurer.
+	Thinking *models.Thinking `json:"-"`
 	// PostProccessedPrompt which has had it\'s strings replaced etc
 	PostProccessedPrompt string `json:"-"`
 
@@ -79,6 +116,19 @@ type Configurations struct {
 	// (worklog 2026-08-15-agent-slog-output, D7).
 	AgentSettings *AgentSettings `json:"-"`
 
+	// ToolSession owns the stateful tool state (async commands, clai_run
+	// workers, the fallback command ban list) of the run. Nil uses the
+	// process default session, which is what the CLI runs on.
+	ToolSession *pkgtools.Session `json:"-"`
+	// ToolRegistry is the catalog of local tools the run selects from. Nil
+	// uses the process-global tools registry.
+	ToolRegistry tools.Catalog `json:"-"`
+	// SkipIndex disables chat index I/O for this run's conversation saves.
+	SkipIndex bool `json:"-"`
+	// ConversationStore receives every conversation save, load and directory
+	// binding of the run. Nil keeps the files under ConfigDir.
+	ConversationStore pub_models.ConversationStore `json:"-"`
+
 	// Out writer. Normally stdout, but may also be a file when invoked as a package
 	Out io.Writer `json:"-"`
 
@@ -110,6 +160,8 @@ type AgentSettings struct {
 	RuneLimit        int
 	UsageRecorder    pub_models.CallUsageRecorder
 	ToolCallRecorder pub_models.ToolCallRecorder
+	// ToolMiddleware wraps every tool dispatch of the run, outermost first.
+	ToolMiddleware []pub_models.ToolMiddleware
 }
 
 // Stoploss is the token stoploss policy. MaxTokens <= 0 disables the
@@ -157,6 +209,26 @@ func (c Configurations) UsingProfile() bool {
 	return c.ProfilePath != "" || c.UseProfile != ""
 }
 
+// ShowsReasoning reports if the chat views show stored reasoning, see
+// ShowReasoning.
+func (c Configurations) ShowsReasoning() bool {
+	return c.ShowReasoning == nil || *c.ShowReasoning
+}
+
+// Persistence returns the conversation persistence of the run: the injected
+// ConversationStore, else the ConversationBackend under ConfigDir.
+func (c Configurations) Persistence() (chat.Persistence, error) {
+	if c.ConversationStore != nil {
+		return chat.Persistence{SkipIndex: c.SkipIndex, Store: c.ConversationStore}, nil
+	}
+	p, err := chat.OpenPersistence(c.ConfigDir, c.ConversationBackend)
+	if err != nil {
+		return chat.Persistence{}, fmt.Errorf("open conversation store: %w", err)
+	}
+	p.SkipIndex = c.SkipIndex
+	return p, nil
+}
+
 // Profile which allows for specialized ai configurations for specific tasks
 type Profile struct {
 	Name      string   `json:"name"`
@@ -173,6 +245,14 @@ type Profile struct {
 	McpServers      map[string]pub_models.McpServer `json:"mcp_servers,omitempty"`
 	ShellContext    string                          `json:"shell_context,omitempty"`
 	UseLookback     *bool                           `json:"use_lookback,omitempty"`
+	RepoMap         *bool                           `json:"repo_map,omitempty"`
+	RepoMapTokens   int                             `json:"repo_map_tokens,omitempty"`
+	// HTTPRequest replaces the http_request policy of textConfig.json, so
+	// each profile only reaches the APIs it is meant for.
+	HTTPRequest *pkgtools.HTTPPolicy `json:"http_request,omitempty"`
+	// Thinking overrides the thinking settings of the model config, for
+	// vendors which support it (anthropic).
+	Thinking *models.Thinking `json:"thinking,omitempty"`
 }
 
 var Default = Configurations{
@@ -227,6 +307,7 @@ func (c *Configurations) setupSystemPrompt() {
 			systemPrompt = promptWithCtx
 		}
 	}
+	systemPrompt = c.withRepoMap(systemPrompt)
 	c.InitialChat = pub_models.Chat{
 		Messages: []pub_models.Message{
 			{Role: "system", Content: systemPrompt},
@@ -235,6 +316,20 @@ func (c *Configurations) setupSystemPrompt() {
 	traceChatf("setup initial chat system prompt done messages=%d", len(c.InitialChat.Messages))
 }
 
+// withRepoMap returns prompt with the repository map of the working
+// directory before it, when it's enabled.
+func (c *Configurations) withRepoMap(prompt string) string {
+	if !c.RepoMap {
+		return prompt
+	}
+	promptWithMap, err := AppendRepoMap(context.Background(), ".", c.RepoMapTokens, prompt)
+	if err != nil {
+		ancli.PrintWarn(fmt.Sprintf("failed to append repository map to system prompt: %v\n", err))
+		return prompt
+	}
+	return promptWithMap
+}
+
 // SetupInitialChat by doing all sorts of organically grown stuff. Don\'t touch this
 // code too closely. Something will break, most likely.
 func (c *Configurations) SetupInitialChat(args []string) error {
@@ -243,18 +338,28 @@ func (c *Configurations) SetupInitialChat(args []string) error {
 		ancli.PrintWarn("Using glob + reply modes together might yield strange results. The globalScope will be appended after the glob messages.\n")
 	}
 
-	if !c.ReplyMode {
+	// A glob chat replaces the initial chat, so its system prompt is only
+	// built without one.
+	if !c.ReplyMode && c.Glob == "" {
 		c.setupSystemPrompt()
 	}
 	if c.Glob != "" {
 		traceChatf("setup initial chat creating glob chat glob=%q", c.Glob)
-		globChat, err := glob.CreateChat(c.Glob, c.SystemPrompt)
+		globChat, err := glob.CreateChat(c.Glob, c.SystemPrompt, glob.Options{
+			Exclude:   c.GlobExclude,
+			MaxTokens: c.GlobMaxTokens,
+		})
 		if err != nil {
 			return fmt.Errorf("failed to get glob chat: %w", err)
 		}
 		if misc.Truthy(os.Getenv("DEBUG")) {
 			ancli.PrintOK(fmt.Sprintf("glob messages: %v", globChat.Messages))
 		}
+		// The glob chat has a system prompt of its own, which gets the
+		// repository map setupSystemPrompt would have added.
+		if !c.ReplyMode {
+			globChat.Messages[0].Content = c.withRepoMap(globChat.Messages[0].Content)
+		}
 		c.InitialChat = globChat
 		traceChatf("setup initial chat glob chat loaded messages=%d", len(c.InitialChat.Messages))
 	}
@@ -267,7 +372,11 @@ func (c *Configurations) SetupInitialChat(args []string) error {
 			traceChatf("setup initial chat skipping globalScope load — dirreply mode, initial chat already populated messages=%d", len(c.InitialChat.Messages))
 		} else {
 			traceChatf("setup initial chat loading reply context from previous query config_dir=%q", c.ConfigDir)
-			iP, err := chat.LoadPrevQuery(c.ConfigDir)
+			p, err := c.Persistence()
+			if err != nil {
+				return err
+			}
+			iP, err := p.LoadPrevQuery(c.ConfigDir)
 			if err != nil {
 				return fmt.Errorf("failed to load previous query: %w", err)
 			}
@@ -294,6 +403,16 @@ func (c *Configurations) SetupInitialChat(args []string) error {
 	prompt = strings.TrimRight(prompt, " \t\r\n")
 	traceChatf("setup initial chat prompt ready prompt_len=%d", len(prompt))
 
+	attachments, err := attachmentParts(c.Attachments)
+	if err != nil {
+		return err
+	}
+	images, err := imageParts(context.Background(), c.Images, c.ImageMaxSize)
+	if err != nil {
+		return err
+	}
+	// Images go first, the documents and the prompt refer to them.
+	attachments = append(images, attachments...)
 	// If chatmode, the initial message will be handled by the chat querier
 	if !c.ChatMode {
 		traceChatf("setup initial chat converting prompt to message parts")
@@ -301,8 +420,17 @@ func (c *Configurations) SetupInitialChat(args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to convert prompt to imageMessage: %w", err)
 		}
+		if len(imgMsg) > 0 {
+			imgMsg[0] = withAttachments(imgMsg[0], attachments)
+		}
 		c.InitialChat.Messages = append(c.InitialChat.Messages, imgMsg...)
-		traceChatf("setup initial chat appended prompt messages=%d total_messages=%d", len(imgMsg), len(c.InitialChat.Messages))
+		traceChatf("setup initial chat appended prompt messages=%d total_messages=%d attachments=%d", len(imgMsg), len(c.InitialChat.Messages), len(attachments))
+	} else if len(attachments) > 0 {
+		c.InitialChat.Messages = append(c.InitialChat.Messages, pub_models.Message{
+			Role:         "user",
+			ContentParts: attachments,
+		})
+		traceChatf("setup initial chat appended attachments=%d for chat mode", len(attachments))
 	}
 
 	if misc.Truthy(os.Getenv("DEBUG")) {
@@ -89,6 +89,18 @@ func (c *Configurations) ProfileOverrides() error {
 	if strings.TrimSpace(profile.ShellContext) != "" {
 		c.ShellContext = profile.ShellContext
 	}
+	if profile.RepoMap != nil {
+		c.RepoMap = *profile.RepoMap
+	}
+	if profile.RepoMapTokens > 0 {
+		c.RepoMapTokens = profile.RepoMapTokens
+	}
+	if profile.Thinking != nil {
+		c.Thinking = profile.Thinking
+	}
+	if profile.HTTPRequest != nil {
+		c.HTTPRequest = profile.HTTPRequest
+	}
 	mcpServers := make([]models.McpServer, 0)
 	for name, m := range profile.McpServers {
 		m.Name = name
@@ -6,6 +6,8 @@ import (
 	"slices"
 	"strings"
 	"testing"
+
+	pkgtools "github.com/baalimago/clai/pkg/tools"
 )
 
 func TestFindProfile_OmittedUseSkillsStaysNil(t *testing.T) {
@@ -196,3 +198,76 @@ func TestFindProfile_CmdBanPersistsViaJSON(t *testing.T) {
 		t.Fatalf("expected CmdBan %v, got %v", want, prof.CmdBan)
 	}
 }
+
+func TestConfigurations_ProfileOverrides_Thinking(t *testing.T) {
+	confDir := t.TempDir()
+	t.Setenv("CLAI_CONFIG_DIR", confDir)
+
+	profilePath := filepath.Join(confDir, "profiles")
+	if err := os.MkdirAll(profilePath, 0o755); err != nil {
+		t.Fatalf("MkdirAll(%q): %v", profilePath, err)
+	}
+	profileJSON := `{"name":"deep","model":"claude-opus-4-1","thinking":{"budget_tokens":16000,"interleaved":true}}`
+	if err := os.WriteFile(filepath.Join(profilePath, "deep.json"), []byte(profileJSON), 0o644); err != nil {
+		t.Fatalf("WriteFile(profile): %v", err)
+	}
+
+	conf := Default
+	conf.UseProfile = "deep"
+	if err := conf.ProfileOverrides(); err != nil {
+		t.Fatalf("ProfileOverrides: %v", err)
+	}
+	if conf.Thinking == nil || conf.Thinking.BudgetTokens != 16000 || !conf.Thinking.Interleaved {
+		t.Fatalf("expected the profile thinking settings, got %#v", conf.Thinking)
+	}
+}
+
+func TestConfigurations_ProfileOverrides_RepoMap(t *testing.T) {
+	confDir := t.TempDir()
+	t.Setenv("CLAI_CONFIG_DIR", confDir)
+
+	profilePath := filepath.Join(confDir, "profiles")
+	if err := os.MkdirAll(profilePath, 0o755); err != nil {
+		t.Fatalf("MkdirAll(%q): %v", profilePath, err)
+	}
+	profileJSON := `{"name":"coder","model":"gpt-5.2","repo_map":true,"repo_map_tokens":4000}`
+	if err := os.WriteFile(filepath.Join(profilePath, "coder.json"), []byte(profileJSON), 0o644); err != nil {
+		t.Fatalf("WriteFile(profile): %v", err)
+	}
+
+	conf := Default
+	conf.UseProfile = "coder"
+	if err := conf.ProfileOverrides(); err != nil {
+		t.Fatalf("ProfileOverrides: %v", err)
+	}
+	if !conf.RepoMap || conf.RepoMapTokens != 4000 {
+		t.Fatalf("expected the profile repo map settings, got RepoMap=%v RepoMapTokens=%d", conf.RepoMap, conf.RepoMapTokens)
+	}
+}
+
+func TestConfigurations_ProfileOverrides_HTTPRequest(t *testing.T) {
+	confDir := t.TempDir()
+	t.Setenv("CLAI_CONFIG_DIR", confDir)
+
+	profilePath := filepath.Join(confDir, "profiles")
+	if err := os.MkdirAll(profilePath, 0o755); err != nil {
+		t.Fatalf("MkdirAll(%q): %v", profilePath, err)
+	}
+	profileJSON := `{"name":"jira","model":"gpt-5.2","http_request":{"allow":["*.atlassian.net"],"secret_headers":[{"host":"*.atlassian.net","header":"Authorization","value":"Bearer ${JIRA_TOKEN}"}]}}`
+	if err := os.WriteFile(filepath.Join(profilePath, "jira.json"), []byte(profileJSON), 0o644); err != nil {
+		t.Fatalf("WriteFile(profile): %v", err)
+	}
+
+	conf := Default
+	conf.HTTPRequest = &pkgtools.HTTPPolicy{Allow: []string{"api.github.com"}}
+	conf.UseProfile = "jira"
+	if err := conf.ProfileOverrides(); err != nil {
+		t.Fatalf("ProfileOverrides: %v", err)
+	}
+	if conf.HTTPRequest == nil || len(conf.HTTPRequest.Allow) != 1 || conf.HTTPRequest.Allow[0] != "*.atlassian.net" {
+		t.Fatalf("expected the profile policy to replace the file policy, got %+v", conf.HTTPRequest)
+	}
+	if len(conf.HTTPRequest.SecretHeaders) != 1 || conf.HTTPRequest.SecretHeaders[0].Value != "Bearer ${JIRA_TOKEN}" {
+		t.Fatalf("expected the secret header unexpanded, got %+v", conf.HTTPRequest.SecretHeaders)
+	}
+}
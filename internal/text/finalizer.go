@@ -6,7 +6,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/baalimago/clai/internal/chat"
 	"github.com/baalimago/clai/internal/models"
 	pub_models "github.com/baalimago/clai/pkg/text/models"
 	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
@@ -94,6 +93,7 @@ func (f sessionFinalizer[C]) Finalize(ctx context.Context, session *QuerySession
 			Role:             "assistant",
 			Content:          stripThinkingBlocks(session.FinalAssistantText),
 			ReasoningContent: session.FinalReasoningText,
+			ReasoningItems:   session.FinalReasoningItems,
 		})
 	}
 	session.Chat.TokenUsage = accumulateCompletedUsage(session.CompletedCalls, session.FinalUsage)
@@ -128,11 +128,11 @@ func (f sessionFinalizer[C]) Finalize(ctx context.Context, session *QuerySession
 	costMgrDone:
 		// Origin stamping is always-on and forward-only: stamp the canonical CWD on
 		// first persist, preserve it on every later write (including replies).
-		if originErr := chat.EnsureOriginDir(q.configDir, &session.Chat); originErr != nil {
+		if originErr := q.persistence.EnsureOriginDir(q.configDir, &session.Chat); originErr != nil {
 			ancli.Warnf("failed to stamp origin directory: %v\n", originErr)
 		}
 		q.chat = session.Chat
-		err := chat.SaveAsPreviousQuery(q.configDir, session.Chat)
+		err := q.persistence.SaveAsPreviousQuery(q.configDir, session.Chat)
 		if err != nil {
 			ancli.PrintErr(fmt.Sprintf("failed to save previous query: %v\n", err))
 		}
@@ -142,7 +142,7 @@ func (f sessionFinalizer[C]) Finalize(ctx context.Context, session *QuerySession
 		// conversation in place (same id), so recording it just bumps that entry —
 		// it keeps the directory's history current and the binding chainable.
 		if (!q.replyMode || q.dirReplyMode) && session.Chat.ID != "" && session.Chat.ID != "globalScope" {
-			if updateErr := chat.UpdateDirScopeFromCWD(q.configDir, session.Chat.ID); updateErr != nil {
+			if updateErr := q.persistence.UpdateDirScopeFromCWD(q.configDir, session.Chat.ID); updateErr != nil {
 				ancli.Warnf("failed to update directory-scoped binding: %v\n", updateErr)
 			}
 		}
@@ -31,11 +31,13 @@ const maxReasoningContent = 1 << 20 // 1 MiB
 // StreamCompletions taking the messages as prompt conversation. Returns the messages from the chat model.
 func (s *StreamCompleter) StreamCompletions(ctx context.Context, chat pub_models.Chat) (chan models.CompletionEvent, error) {
 	s.reasoningContent = ""
+	s.thoughtSignature = ""
+	s.reasoningDetails = nil
+	chat.Messages = s.replayReasoning(chat.Messages)
 	if s.Clean != nil {
-		cpy := make([]pub_models.Message, len(chat.Messages))
-		copy(cpy, chat.Messages)
-		chat.Messages = s.Clean(cpy)
+		chat.Messages = s.Clean(chat.Messages)
 	}
+	chat.Messages = s.mapFileParts(chat.Messages)
 	req, err := s.createRequest(ctx, chat)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -73,7 +75,7 @@ func (s *StreamCompleter) createRequest(ctx context.Context, chat pub_models.Cha
 		TopP:             s.TopP,
 		ReasoningEffort:  s.ReasoningEffort,
 		ResponseFormat:   respFmt,
-		Messages:         chat.Messages,
+		Messages:         s.requestMessages(chat.Messages),
 		Stream:           true,
 		StreamOptions: map[string]any{
 			"include_usage": true,
@@ -104,7 +106,11 @@ func (s *StreamCompleter) createRequest(ctx context.Context, chat pub_models.Cha
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", s.apiKey))
+	if s.AuthHeader != "" {
+		req.Header.Set(s.AuthHeader, s.apiKey)
+	} else {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", s.apiKey))
+	}
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Connection", "keep-alive")
 	for key, value := range s.ExtraHeaders {
@@ -218,12 +224,16 @@ func (s *StreamCompleter) handleStreamChunk(token []byte) models.CompletionEvent
 }
 
 func (s *StreamCompleter) handleChoice(choice Choice) models.CompletionEvent {
+	if s.ReasoningField == "reasoning" {
+		choice.Delta.ReasoningContent = choice.Delta.Reasoning
+	}
 	if choice.Delta.ReasoningContent != "" {
 		s.reasoningContent += choice.Delta.ReasoningContent
 		if len(s.reasoningContent) > maxReasoningContent {
 			s.reasoningContent = s.reasoningContent[len(s.reasoningContent)-maxReasoningContent:]
 		}
 	}
+	s.collectReasoningDetails(choice.Delta.ReasoningDetails)
 	// If there is no tools call, just handle it as a strings. This works for most cases
 	if len(choice.Delta.ToolCalls) == 0 {
 		if choice.FinishReason != "" {
@@ -247,6 +257,9 @@ func (s *StreamCompleter) handleChoice(choice Choice) models.CompletionEvent {
 	}
 
 	if len(choice.Delta.ToolCalls) > 0 {
+		if sig := thoughtSignature(choice.Delta.ToolCalls[0].ExtraContent); sig != "" {
+			s.thoughtSignature = sig
+		}
 		argChunk = choice.Delta.ToolCalls[0].Function.Arguments
 		// The arguments is streamed as a stringified json for chatgpt, chunk by chunk, with no apparent structure
 		s.toolsCallArgsString += argChunk
@@ -287,8 +300,9 @@ func (s *StreamCompleter) doToolsCall() models.CompletionEvent {
 		Inputs:           &input,
 		Type:             "function",
 		Function:         userFunc,
-		ExtraContent:     s.extraContent,
+		ExtraContent:     withoutThoughtSignature(s.extraContent),
 		ReasoningContent: s.reasoningContent,
+		ReasoningItems:   s.takeReasoningItems(),
 	}
 }
 
@@ -0,0 +1,58 @@
+package generic
+
+import (
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// Values of StreamCompleter.FileParts.
+const (
+	// FilePartsFile sends documents as openai file parts.
+	FilePartsFile = "file"
+	// FilePartsImageURL sends documents as image_url parts holding their
+	// data URL, which is how the Gemini OpenAI compatible API accepts PDFs.
+	FilePartsImageURL = "image_url"
+)
+
+// mapFileParts returns msgs with the file parts in the form FileParts
+// selects. msgs is left unchanged.
+func (s *StreamCompleter) mapFileParts(msgs []pub_models.Message) []pub_models.Message {
+	switch s.FileParts {
+	case FilePartsFile, FilePartsImageURL:
+	default:
+		return pub_models.FilePartsAsText(msgs)
+	}
+	ret := make([]pub_models.Message, len(msgs))
+	for i, m := range msgs {
+		ret[i] = m
+		if len(m.ContentParts) == 0 {
+			continue
+		}
+		parts := make([]pub_models.ImageOrTextInput, len(m.ContentParts))
+		for j, p := range m.ContentParts {
+			if p.File != nil {
+				p = s.filePart(*p.File)
+			}
+			parts[j] = p
+		}
+		ret[i].ContentParts = parts
+	}
+	return ret
+}
+
+func (s *StreamCompleter) filePart(f pub_models.FileInput) pub_models.ImageOrTextInput {
+	if f.FileData == "" {
+		return f.AsText()
+	}
+	if s.FileParts == FilePartsImageURL {
+		return pub_models.ImageOrTextInput{
+			Type:     string(pub_models.Image),
+			ImageB64: &pub_models.ImageURL{URL: f.FileData, Detail: "auto"},
+		}
+	}
+	// The extracted text is only for the vendors which don't read the
+	// document, openai rejects unknown fields.
+	return pub_models.ImageOrTextInput{
+		Type: string(pub_models.File),
+		File: &pub_models.FileInput{Filename: f.Filename, FileData: f.FileData},
+	}
+}
@@ -0,0 +1,44 @@
+package generic
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func TestMapFileParts(t *testing.T) {
+	msgs := []pub_models.Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", ContentParts: []pub_models.ImageOrTextInput{
+			{Type: "file", File: &pub_models.FileInput{Filename: "a.pdf", FileData: "data:application/pdf;base64,JVBERi0=", Text: "extracted"}},
+			{Type: "text", Text: "summarise"},
+		}},
+	}
+	for _, tc := range []struct {
+		fileParts string
+		want      string
+		unwanted  string
+	}{
+		{"", `{"text":"Contents of attached file a.pdf:\nextracted","type":"text"}`, "JVBERi0="},
+		{FilePartsFile, `{"type":"file","file":{"filename":"a.pdf","file_data":"data:application/pdf;base64,JVBERi0="}}`, "extracted"},
+		{FilePartsImageURL, `{"type":"image_url","image_url":{"url":"data:application/pdf;base64,JVBERi0=","detail":"auto"}}`, "extracted"},
+	} {
+		s := &StreamCompleter{FileParts: tc.fileParts}
+		got := s.mapFileParts(msgs)
+		b, err := json.Marshal(got)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if !strings.Contains(string(b), tc.want) || strings.Contains(string(b), tc.unwanted) {
+			t.Errorf("FileParts %q: got %s, want it to contain %s and not %q", tc.fileParts, b, tc.want, tc.unwanted)
+		}
+		if !strings.Contains(string(b), `"text":"summarise"`) {
+			t.Errorf("FileParts %q: text part lost: %s", tc.fileParts, b)
+		}
+	}
+	if msgs[1].ContentParts[0].File == nil || msgs[1].ContentParts[0].File.Text != "extracted" {
+		t.Fatal("mapFileParts mutated its input")
+	}
+}
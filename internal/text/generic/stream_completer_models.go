@@ -17,18 +17,34 @@ type StreamCompleter struct {
 	// ReasoningEffort maps to the Chat Completions reasoning_effort field. Only set
 	// for reasoning models (others reject it); empty omits it. Vendor-agnostic:
 	// non-OpenAI callers leave it empty.
-	ReasoningEffort string                                          `json:"-"`
-	ToolChoice      *string                                         `json:"-"`
+	ReasoningEffort string  `json:"-"`
+	ToolChoice      *string `json:"-"`
+	// ReasoningField names the delta field carrying reasoning tokens.
+	// Empty means "reasoning_content"; "reasoning" suits servers such as
+	// vLLM, llama.cpp and Groq.
+	ReasoningField string `json:"-"`
+	// ReasoningVendor tags the reasoning state captured for tool calls, see
+	// pub_models.ReasoningItem. Empty captures only what identifies its vendor
+	// on its own, such as Gemini thought signatures.
+	ReasoningVendor string                                          `json:"-"`
 	Clean           func([]pub_models.Message) []pub_models.Message `json:"-"`
-	URL             string
-	ExtraHeaders    map[string]string `json:"-"`
-	tools           []ToolSuper
-	toolsCallName   string
+	// FileParts is how documents are sent, see the FileParts constants.
+	// Empty sends their extracted text, which every vendor accepts.
+	FileParts    string `json:"-"`
+	URL          string
+	ExtraHeaders map[string]string `json:"-"`
+	// AuthHeader, when set, carries the api key as is instead of as a bearer
+	// token in Authorization, such as Azure's "api-key".
+	AuthHeader    string `json:"-"`
+	tools         []ToolSuper
+	toolsCallName string
 	// Argument string exists since the arguments for function calls is streamed token by token... yeah... great idea
 	toolsCallArgsString string
 	toolsCallID         string
 	extraContent        map[string]any
 	reasoningContent    string
+	thoughtSignature    string
+	reasoningDetails    []reasoningDetail
 	client              *http.Client
 	apiKey              string
 	debug               bool
@@ -79,10 +95,12 @@ type Choice struct {
 }
 
 type Delta struct {
-	Content          any         `json:"content"`
-	ReasoningContent string      `json:"reasoning_content"`
-	Role             string      `json:"role"`
-	ToolCalls        []ToolsCall `json:"tool_calls"`
+	Content          any               `json:"content"`
+	ReasoningContent string            `json:"reasoning_content"`
+	Reasoning        string            `json:"reasoning"`
+	ReasoningDetails []reasoningDetail `json:"reasoning_details"`
+	Role             string            `json:"role"`
+	ToolCalls        []ToolsCall       `json:"tool_calls"`
 }
 
 type ExtraContent map[string]map[string]any
@@ -118,18 +136,18 @@ type JSONSchemaSpec struct {
 }
 
 type req struct {
-	Model             string               `json:"model,omitempty"`
-	ResponseFormat    ResponseFormat       `json:"response_format"`
-	Messages          []pub_models.Message `json:"messages,omitempty"`
-	Stream            bool                 `json:"stream,omitempty"`
-	StreamOptions     map[string]any       `json:"stream_options"`
-	FrequencyPenalty  *float64             `json:"frequency_penalty,omitempty"`
-	MaxTokens         *int                 `json:"max_tokens,omitempty"`
-	PresencePenalty   *float64             `json:"presence_penalty,omitempty"`
-	Temperature       *float64             `json:"temperature,omitempty"`
-	TopP              *float64             `json:"top_p,omitempty"`
-	ReasoningEffort   string               `json:"reasoning_effort,omitempty"`
-	ToolChoice        *string              `json:"tool_choice,omitempty"`
-	Tools             []ToolSuper          `json:"tools,omitempty"`
-	ParalellToolCalls bool                 `json:"parallel_tools_call,omitempty"`
+	Model             string         `json:"model,omitempty"`
+	ResponseFormat    ResponseFormat `json:"response_format"`
+	Messages          []reqMessage   `json:"messages,omitempty"`
+	Stream            bool           `json:"stream,omitempty"`
+	StreamOptions     map[string]any `json:"stream_options"`
+	FrequencyPenalty  *float64       `json:"frequency_penalty,omitempty"`
+	MaxTokens         *int           `json:"max_tokens,omitempty"`
+	PresencePenalty   *float64       `json:"presence_penalty,omitempty"`
+	Temperature       *float64       `json:"temperature,omitempty"`
+	TopP              *float64       `json:"top_p,omitempty"`
+	ReasoningEffort   string         `json:"reasoning_effort,omitempty"`
+	ToolChoice        *string        `json:"tool_choice,omitempty"`
+	Tools             []ToolSuper    `json:"tools,omitempty"`
+	ParalellToolCalls bool           `json:"parallel_tools_call,omitempty"`
 }
@@ -0,0 +1,192 @@
+package generic
+
+import (
+	"encoding/json"
+	"fmt"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// skipThoughtSignatureValidator is the signature Gemini accepts in place of a
+// thought signature it did not produce, such as for the calls of a parallel
+// batch after the first, which Gemini sends unsigned.
+const skipThoughtSignatureValidator = "skip_thought_signature_validator"
+
+// reasoningDetail is one entry of OpenRouter's reasoning_details. Entries are
+// streamed in fragments sharing an index.
+type reasoningDetail struct {
+	Type      string `json:"type,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Summary   string `json:"summary,omitempty"`
+	Data      string `json:"data,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Format    string `json:"format,omitempty"`
+	Index     int    `json:"index"`
+}
+
+// reqMessage is a message of the request, with the OpenRouter reasoning
+// details to replay on it.
+type reqMessage struct {
+	pub_models.Message
+	ReasoningDetails []json.RawMessage
+}
+
+func (m reqMessage) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(m.Message)
+	if err != nil || len(m.ReasoningDetails) == 0 {
+		return b, err
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+	if obj["reasoning_details"], err = json.Marshal(m.ReasoningDetails); err != nil {
+		return nil, fmt.Errorf("encode reasoning details: %w", err)
+	}
+	return json.Marshal(obj)
+}
+
+// requestMessages wraps msgs for the request. The reasoning details are only
+// sent to OpenRouter, as other servers may reject the field.
+func (s *StreamCompleter) requestMessages(msgs []pub_models.Message) []reqMessage {
+	ret := make([]reqMessage, 0, len(msgs))
+	for _, m := range msgs {
+		rm := reqMessage{Message: m}
+		if s.ReasoningVendor == pub_models.ReasoningVendorOpenRouter {
+			for _, ri := range pub_models.ReasoningItemsOf(m.ReasoningItems, pub_models.ReasoningVendorOpenRouter) {
+				rm.ReasoningDetails = append(rm.ReasoningDetails, ri.Details)
+			}
+		}
+		ret = append(ret, rm)
+	}
+	return ret
+}
+
+// collectReasoningDetails merges the streamed fragments of reasoning details
+// by their index.
+func (s *StreamCompleter) collectReasoningDetails(fragments []reasoningDetail) {
+	for _, f := range fragments {
+		i := len(s.reasoningDetails) - 1
+		if i < 0 || s.reasoningDetails[i].Index != f.Index {
+			s.reasoningDetails = append(s.reasoningDetails, f)
+			continue
+		}
+		d := &s.reasoningDetails[i]
+		d.Text += f.Text
+		d.Summary += f.Summary
+		d.Data += f.Data
+		if f.Signature != "" {
+			d.Signature = f.Signature
+		}
+		if f.ID != "" {
+			d.ID = f.ID
+		}
+		if f.Format != "" {
+			d.Format = f.Format
+		}
+		if f.Type != "" {
+			d.Type = f.Type
+		}
+	}
+}
+
+// takeReasoningItems returns the reasoning state of the stream as items for
+// the next tool call, and resets the state which belongs to that call only.
+func (s *StreamCompleter) takeReasoningItems() []pub_models.ReasoningItem {
+	var items []pub_models.ReasoningItem
+	if s.thoughtSignature != "" {
+		items = append(items, pub_models.ReasoningItem{Vendor: pub_models.ReasoningVendorGemini, Signature: s.thoughtSignature})
+		s.thoughtSignature = ""
+	}
+	if s.ReasoningVendor == pub_models.ReasoningVendorDeepSeek && s.reasoningContent != "" {
+		items = append(items, pub_models.ReasoningItem{Vendor: pub_models.ReasoningVendorDeepSeek, Thinking: s.reasoningContent})
+	}
+	for _, d := range s.reasoningDetails {
+		b, err := json.Marshal(d)
+		if err != nil {
+			continue
+		}
+		items = append(items, pub_models.ReasoningItem{Vendor: pub_models.ReasoningVendorOpenRouter, Details: b})
+	}
+	s.reasoningDetails = nil
+	return items
+}
+
+// replayReasoning restores the reasoning state of the tool call turns in
+// msgs, returning a copy. Gemini thought signatures go back into the
+// extra_content of the first call of each turn, where Gemini expects them.
+// Once Gemini has signed a turn of the chat, unsigned turns get the skip
+// signature, since Gemini rejects unsigned calls of a signing model. DeepSeek
+// gets the reasoning_content of the turn back.
+func (s *StreamCompleter) replayReasoning(msgs []pub_models.Message) []pub_models.Message {
+	geminiSigned := false
+	for _, m := range msgs {
+		if len(pub_models.ReasoningItemsOf(m.ReasoningItems, pub_models.ReasoningVendorGemini)) > 0 {
+			geminiSigned = true
+			break
+		}
+	}
+	ret := make([]pub_models.Message, len(msgs))
+	copy(ret, msgs)
+	for i := range ret {
+		m := &ret[i]
+		if m.Role != "assistant" || len(m.ToolCalls) == 0 {
+			continue
+		}
+		if s.ReasoningVendor == pub_models.ReasoningVendorDeepSeek {
+			if text := pub_models.ReasoningText(pub_models.ReasoningItemsOf(m.ReasoningItems, pub_models.ReasoningVendorDeepSeek)); text != "" {
+				m.ReasoningContent = text
+			}
+		}
+		if thoughtSignature(m.ToolCalls[0].ExtraContent) != "" {
+			continue
+		}
+		sig := ""
+		for _, ri := range pub_models.ReasoningItemsOf(m.ReasoningItems, pub_models.ReasoningVendorGemini) {
+			sig = ri.Signature
+			break
+		}
+		if sig == "" && geminiSigned && s.ReasoningVendor == pub_models.ReasoningVendorGemini {
+			sig = skipThoughtSignatureValidator
+		}
+		if sig == "" {
+			continue
+		}
+		calls := make([]pub_models.Call, len(m.ToolCalls))
+		copy(calls, m.ToolCalls)
+		extra := map[string]any{}
+		for k, v := range calls[0].ExtraContent {
+			extra[k] = v
+		}
+		extra["google"] = map[string]any{"thought_signature": sig}
+		calls[0].ExtraContent = extra
+		m.ToolCalls = calls
+	}
+	return ret
+}
+
+// thoughtSignature returns the Gemini thought signature of extra_content.
+func thoughtSignature(extra map[string]any) string {
+	google, _ := extra["google"].(map[string]any)
+	sig, _ := google["thought_signature"].(string)
+	return sig
+}
+
+// withoutThoughtSignature returns extra without the Gemini thought signature,
+// which travels as a reasoning item instead. Nil when nothing else remains.
+func withoutThoughtSignature(extra map[string]any) map[string]any {
+	if thoughtSignature(extra) == "" {
+		return extra
+	}
+	ret := map[string]any{}
+	for k, v := range extra {
+		if k != "google" {
+			ret[k] = v
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
@@ -0,0 +1,150 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/baalimago/clai/internal/tools"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// requestedMessages returns the messages of the request body for chat.
+func requestedMessages(t *testing.T, s *StreamCompleter, chat pub_models.Chat) []map[string]any {
+	t.Helper()
+	chat.Messages = s.replayReasoning(chat.Messages)
+	httpReq, err := s.createRequest(context.Background(), chat)
+	if err != nil {
+		t.Fatalf("createRequest err: %v", err)
+	}
+	b, _ := io.ReadAll(httpReq.Body)
+	var body struct {
+		Messages []map[string]any `json:"messages"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		t.Fatalf("unmarshal body: %v\nbody=%s", err, string(b))
+	}
+	return body.Messages
+}
+
+func signatureOfFirstCall(t *testing.T, msg map[string]any) string {
+	t.Helper()
+	calls, _ := msg["tool_calls"].([]any)
+	if len(calls) == 0 {
+		t.Fatalf("expected tool calls on %v", msg)
+	}
+	call, _ := calls[0].(map[string]any)
+	extra, _ := call["extra_content"].(map[string]any)
+	return thoughtSignature(extra)
+}
+
+func TestHandleChoice_GeminiThoughtSignatureBecomesReasoningItem(t *testing.T) {
+	tools.Init()
+	s := &StreamCompleter{ReasoningVendor: pub_models.ReasoningVendorGemini}
+	signed := Choice{Delta: Delta{ToolCalls: []ToolsCall{{
+		ID:           "c1",
+		Function:     Func{Name: "ls", Arguments: "{}"},
+		ExtraContent: map[string]any{"google": map[string]any{"thought_signature": "SIG"}},
+	}}}}
+	call, ok := s.handleChoice(signed).(pub_models.Call)
+	if !ok {
+		t.Fatal("expected a call")
+	}
+	if call.ExtraContent != nil {
+		t.Fatalf("expected the signature out of extra_content, got %v", call.ExtraContent)
+	}
+	if len(call.ReasoningItems) != 1 || call.ReasoningItems[0].Vendor != pub_models.ReasoningVendorGemini || call.ReasoningItems[0].Signature != "SIG" {
+		t.Fatalf("expected the signature as a gemini item, got %+v", call.ReasoningItems)
+	}
+
+	unsigned := Choice{Delta: Delta{ToolCalls: []ToolsCall{{ID: "c2", Function: Func{Name: "ls", Arguments: "{}"}}}}}
+	call, _ = s.handleChoice(unsigned).(pub_models.Call)
+	if len(call.ReasoningItems) != 0 {
+		t.Fatalf("expected the second call of the batch unsigned, got %+v", call.ReasoningItems)
+	}
+}
+
+func TestReplayReasoning_GeminiSignatures(t *testing.T) {
+	chat := pub_models.Chat{Messages: []pub_models.Message{
+		{Role: "user", Content: "go"},
+		{
+			Role:           "assistant",
+			ToolCalls:      []pub_models.Call{{ID: "c1", Name: "ls"}},
+			ReasoningItems: []pub_models.ReasoningItem{{Vendor: pub_models.ReasoningVendorGemini, Signature: "SIG"}},
+		},
+		{Role: "tool", ToolCallID: "c1", Content: "out"},
+		{Role: "assistant", ToolCalls: []pub_models.Call{{ID: "c2", Name: "ls"}}},
+		{Role: "tool", ToolCallID: "c2", Content: "out"},
+	}}
+
+	s := &StreamCompleter{Model: "m", apiKey: "k", URL: "http://example.invalid", ReasoningVendor: pub_models.ReasoningVendorGemini}
+	msgs := requestedMessages(t, s, chat)
+	if got := signatureOfFirstCall(t, msgs[1]); got != "SIG" {
+		t.Fatalf("expected the stored signature replayed, got %q", got)
+	}
+	if got := signatureOfFirstCall(t, msgs[3]); got != skipThoughtSignatureValidator {
+		t.Fatalf("expected the skip signature on the unsigned turn, got %q", got)
+	}
+	if chat.Messages[1].ToolCalls[0].ExtraContent != nil {
+		t.Fatal("replay must not mutate the chat")
+	}
+
+	other := &StreamCompleter{Model: "m", apiKey: "k", URL: "http://example.invalid"}
+	msgs = requestedMessages(t, other, chat)
+	if got := signatureOfFirstCall(t, msgs[3]); got != "" {
+		t.Fatalf("expected no skip signature outside of Gemini, got %q", got)
+	}
+}
+
+func TestReasoning_DeepSeekRoundTrip(t *testing.T) {
+	tools.Init()
+	s := &StreamCompleter{Model: "m", apiKey: "k", URL: "http://example.invalid", ReasoningVendor: pub_models.ReasoningVendorDeepSeek}
+	s.handleChoice(Choice{Delta: Delta{ReasoningContent: "Need to list."}})
+	call, _ := s.handleChoice(Choice{Delta: Delta{ToolCalls: []ToolsCall{{ID: "c1", Function: Func{Name: "ls", Arguments: "{}"}}}}}).(pub_models.Call)
+	if len(call.ReasoningItems) != 1 || call.ReasoningItems[0].Thinking != "Need to list." {
+		t.Fatalf("expected the reasoning as a deepseek item, got %+v", call.ReasoningItems)
+	}
+
+	msgs := requestedMessages(t, s, pub_models.Chat{Messages: []pub_models.Message{
+		{Role: "user", Content: "go"},
+		{Role: "assistant", ToolCalls: []pub_models.Call{{ID: "c1", Name: "ls"}}, ReasoningItems: call.ReasoningItems},
+	}})
+	if got, _ := msgs[1]["reasoning_content"].(string); got != "Need to list." {
+		t.Fatalf("expected reasoning_content restored, got %q", got)
+	}
+}
+
+func TestReasoning_OpenRouterDetailsRoundTrip(t *testing.T) {
+	tools.Init()
+	s := &StreamCompleter{Model: "m", apiKey: "k", URL: "http://example.invalid", ReasoningVendor: pub_models.ReasoningVendorOpenRouter}
+	s.handleChoice(Choice{Delta: Delta{ReasoningDetails: []reasoningDetail{{Type: "reasoning.text", Text: "Need ", Index: 0}}}})
+	s.handleChoice(Choice{Delta: Delta{ReasoningDetails: []reasoningDetail{{Text: "to list.", Signature: "SIG", Index: 0}}}})
+	s.handleChoice(Choice{Delta: Delta{ReasoningDetails: []reasoningDetail{{Type: "reasoning.encrypted", Data: "BLOB", Index: 1}}}})
+	call, _ := s.handleChoice(Choice{Delta: Delta{ToolCalls: []ToolsCall{{ID: "c1", Function: Func{Name: "ls", Arguments: "{}"}}}}}).(pub_models.Call)
+	if len(call.ReasoningItems) != 2 {
+		t.Fatalf("expected two merged details, got %+v", call.ReasoningItems)
+	}
+	var first reasoningDetail
+	if err := json.Unmarshal(call.ReasoningItems[0].Details, &first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Text != "Need to list." || first.Signature != "SIG" || first.Type != "reasoning.text" {
+		t.Fatalf("expected the fragments merged, got %+v", first)
+	}
+
+	chat := pub_models.Chat{Messages: []pub_models.Message{
+		{Role: "user", Content: "go"},
+		{Role: "assistant", ToolCalls: []pub_models.Call{{ID: "c1", Name: "ls"}}, ReasoningItems: call.ReasoningItems},
+	}}
+	msgs := requestedMessages(t, s, chat)
+	details, _ := msgs[1]["reasoning_details"].([]any)
+	if len(details) != 2 {
+		t.Fatalf("expected the details replayed, got %v", msgs[1]["reasoning_details"])
+	}
+
+	other := &StreamCompleter{Model: "m", apiKey: "k", URL: "http://example.invalid"}
+	if _, ok := requestedMessages(t, other, chat)[1]["reasoning_details"]; ok {
+		t.Fatal("expected no reasoning_details outside of OpenRouter")
+	}
+}
@@ -15,6 +15,15 @@ func (s *StreamCompleter) Setup(apiKeyEnv, url, debugEnv string) error {
 	if apiKey == "" {
 		return fmt.Errorf("environment variable '%v' not set", apiKeyEnv)
 	}
+	return s.SetupWithKey(apiKey, url, debugEnv)
+}
+
+// SetupWithKey is Setup for keys which do not come from an environment
+// variable, such as tokens printed by a command.
+func (s *StreamCompleter) SetupWithKey(apiKey, url, debugEnv string) error {
+	if apiKey == "" {
+		return fmt.Errorf("missing api key")
+	}
 	s.client = &http.Client{}
 	s.apiKey = apiKey
 	s.URL = url
@@ -130,6 +130,26 @@ func TestHandleStreamChunk_ReasoningContentIsTracked(t *testing.T) {
 	}
 }
 
+func TestHandleStreamChunk_ReasoningFieldSelectsDeltaField(t *testing.T) {
+	chunk := []byte(`data: {"choices":[{"delta":{"reasoning":"Thinking."}}]}` + "\n")
+
+	s := &StreamCompleter{}
+	if ev := s.handleStreamChunk(chunk); isReasoningEvent(ev) {
+		t.Fatalf("expected the reasoning field to be ignored by default, got %v", ev)
+	}
+
+	s = &StreamCompleter{ReasoningField: "reasoning"}
+	rev, ok := s.handleStreamChunk(chunk).(models.ReasoningEvent)
+	if !ok || rev.Content != "Thinking." {
+		t.Fatalf("expected a reasoning event from the reasoning field, got %v", rev)
+	}
+}
+
+func isReasoningEvent(ev models.CompletionEvent) bool {
+	_, ok := ev.(models.ReasoningEvent)
+	return ok
+}
+
 // TestHandleStreamChunk_ReasoningContentIsCapped verifies the OOM guard for
 // the completer's reasoning accumulator: an endless reasoning stream (a
 // looping model) must not grow reasoningContent without bound. The accumulator
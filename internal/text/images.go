@@ -0,0 +1,62 @@
+package text
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/baalimago/clai/internal/models"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	pkgtools "github.com/baalimago/clai/pkg/tools"
+)
+
+// ErrVisionUnsupported is returned when a chat holding images is sent to a
+// model which can't read them.
+var ErrVisionUnsupported = errors.New("the model does not support image input, pick a vision model with -cm")
+
+// imageParts returns the content parts of the images at srcs, local paths or
+// http(s) URLs, downscaled to maxSize pixels when it's above 0.
+func imageParts(ctx context.Context, srcs []string, maxSize int) ([]pub_models.ImageOrTextInput, error) {
+	var parts []pub_models.ImageOrTextInput
+	for _, src := range srcs {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		img, err := pkgtools.LoadImage(ctx, src, maxSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load image %v: %w", src, err)
+		}
+		traceChatf("loaded image src=%q mime=%q size=%dx%d scaled=%t", src, img.MIMEType, img.Width, img.Height, img.Scaled)
+		parts = append(parts, img.Part())
+	}
+	return parts, nil
+}
+
+// supportsVision reports whether model accepts images, see
+// models.VisionSupporter.
+func supportsVision(model any) bool {
+	supported, _ := visionSupport(model)
+	return supported
+}
+
+// visionSupport is supportsVision, and whether the vendor knows. Models of
+// the vendors which don't are assumed to accept images.
+func visionSupport(model any) (supported, known bool) {
+	if v, ok := model.(models.VisionSupporter); ok {
+		return v.SupportsVision(), true
+	}
+	return true, false
+}
+
+func hasImages(chat pub_models.Chat) bool {
+	for _, m := range chat.Messages {
+		for _, p := range m.ContentParts {
+			if p.Type == string(pub_models.Image) {
+				return true
+			}
+		}
+	}
+	return false
+}
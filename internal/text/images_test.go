@@ -0,0 +1,175 @@
+package text
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/baalimago/clai/internal/models"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	pkgtools "github.com/baalimago/clai/pkg/tools"
+)
+
+// textOnlyQuerier is a MockQuerier whose model can't read images.
+type textOnlyQuerier struct {
+	MockQuerier
+}
+
+func (q *textOnlyQuerier) SupportsVision() bool {
+	return false
+}
+
+func writePNG(t *testing.T, w, h int) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "shot.png")
+	if err := os.WriteFile(p, b.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func Test_imageParts(t *testing.T) {
+	parts, err := imageParts(context.Background(), []string{writePNG(t, 300, 100), " "}, 30)
+	if err != nil {
+		t.Fatalf("imageParts: %v", err)
+	}
+	if len(parts) != 1 || parts[0].Type != string(pub_models.Image) || parts[0].ImageB64.MIMEType != "image/png" {
+		t.Fatalf("expected one png part, got %+v", parts)
+	}
+	if _, err := imageParts(context.Background(), []string{"missing.png"}, 0); err == nil || !strings.Contains(err.Error(), "missing.png") {
+		t.Fatalf("expected an error naming the image, got %v", err)
+	}
+}
+
+func Test_toolExecutor_ViewImageAppendsImages(t *testing.T) {
+	q := &Querier[*MockQuerier]{
+		out:   &strings.Builder{},
+		Model: &MockQuerier{},
+		tooling: tooling{
+			run:     map[string]pub_models.LLMTool{"view_image": pkgtools.ViewImage},
+			session: pkgtools.NewSession(),
+		},
+	}
+	session := &QuerySession{Chat: pub_models.Chat{}}
+	call := pub_models.Call{ID: "img-1", Name: "view_image", Inputs: &pub_models.Input{"path": writePNG(t, 20, 10)}}
+
+	if err := (toolExecutor[*MockQuerier]{querier: q}).ExecuteBatch(context.Background(), session, []pub_models.Call{call}); err != nil {
+		t.Fatalf("ExecuteBatch: %v", err)
+	}
+	msgs := session.Chat.Messages
+	if len(msgs) != 3 {
+		t.Fatalf("expected tool call, tool result and image messages, got %+v", msgs)
+	}
+	if msgs[1].Role != "tool" || !strings.Contains(msgs[1].Content, "image/png, 20x10") {
+		t.Fatalf("unexpected tool result %+v", msgs[1])
+	}
+	if msgs[2].Role != "user" || len(msgs[2].ContentParts) != 1 || msgs[2].ContentParts[0].ImageB64 == nil {
+		t.Fatalf("expected a user message with the image, got %+v", msgs[2])
+	}
+}
+
+func Test_toolExecutor_ViewImageRefusedWithoutVision(t *testing.T) {
+	q := &Querier[*textOnlyQuerier]{
+		out:   &strings.Builder{},
+		Model: &textOnlyQuerier{},
+		tooling: tooling{
+			run:     map[string]pub_models.LLMTool{"view_image": pkgtools.ViewImage},
+			session: pkgtools.NewSession(),
+		},
+	}
+	session := &QuerySession{Chat: pub_models.Chat{}}
+	call := pub_models.Call{ID: "img-1", Name: "view_image", Inputs: &pub_models.Input{"path": writePNG(t, 20, 10)}}
+
+	if err := (toolExecutor[*textOnlyQuerier]{querier: q}).ExecuteBatch(context.Background(), session, []pub_models.Call{call}); err != nil {
+		t.Fatalf("ExecuteBatch: %v", err)
+	}
+	last := session.Chat.Messages[len(session.Chat.Messages)-1]
+	if last.Role != "tool" || !strings.Contains(last.Content, "does not support image input") {
+		t.Fatalf("expected the refusal as tool result, got %+v", last)
+	}
+}
+
+func Test_executeModelStep_ImagesNeedVision(t *testing.T) {
+	q := &Querier[*textOnlyQuerier]{
+		out:   &strings.Builder{},
+		Model: &textOnlyQuerier{},
+	}
+	runner := sessionRunner[*textOnlyQuerier]{querier: q}
+	session := &QuerySession{Chat: pub_models.Chat{Messages: []pub_models.Message{{
+		Role: "user",
+		ContentParts: []pub_models.ImageOrTextInput{
+			{Type: string(pub_models.Image), ImageB64: &pub_models.ImageURL{URL: "data:image/png;base64,iVBORw0="}},
+			{Type: string(pub_models.Text), Text: "what is this?"},
+		},
+	}}}}
+	if _, err := runner.executeModelStep(context.Background(), session); !errors.Is(err, ErrVisionUnsupported) {
+		t.Fatalf("expected ErrVisionUnsupported, got %v", err)
+	}
+}
+
+func Test_executeModelStep_ImagesSentWhenVisionUnknown(t *testing.T) {
+	sent := errors.New("sent")
+	q := &Querier[*MockQuerier]{
+		out: &strings.Builder{},
+		Model: &MockQuerier{streamFn: func(context.Context, pub_models.Chat) (chan models.CompletionEvent, error) {
+			return nil, sent
+		}},
+	}
+	runner := sessionRunner[*MockQuerier]{querier: q}
+	session := &QuerySession{Chat: pub_models.Chat{Messages: []pub_models.Message{{
+		Role:         "user",
+		ContentParts: []pub_models.ImageOrTextInput{{Type: string(pub_models.Image), ImageB64: &pub_models.ImageURL{URL: "data:image/png;base64,iVBORw0="}}},
+	}}}}
+	if _, err := runner.executeModelStep(context.Background(), session); !errors.Is(err, sent) {
+		t.Fatalf("expected the images sent, got %v", err)
+	}
+	if !q.warnedVision {
+		t.Fatal("expected a warning that vision support is unknown")
+	}
+}
+
+func TestConfigurations_SetupInitialChat_Images(t *testing.T) {
+	notes := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(notes, []byte("axis labels are wrong"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conf := Default
+	conf.ConfigDir = t.TempDir()
+	conf.Images = []string{writePNG(t, 400, 200)}
+	conf.ImageMaxSize = 100
+	conf.Attachments = []string{notes}
+	if err := conf.SetupInitialChat([]string{"q", "fix the plot"}); err != nil {
+		t.Fatalf("SetupInitialChat: %v", err)
+	}
+	last := conf.InitialChat.Messages[len(conf.InitialChat.Messages)-1]
+	if len(last.ContentParts) != 3 {
+		t.Fatalf("expected image, attachment and prompt parts, got %+v", last.ContentParts)
+	}
+	if last.ContentParts[0].ImageB64 == nil || !strings.Contains(last.ContentParts[1].Text, "notes.txt") || last.ContentParts[2].Text != "fix the plot" {
+		t.Fatalf("unexpected parts order: %+v", last.ContentParts)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(mustDecodeB64(t, last.ContentParts[0].ImageB64.RawB64)))
+	if err != nil || cfg.Width != 100 || cfg.Height != 50 {
+		t.Fatalf("expected the image downscaled to 100x50, got %dx%d, %v", cfg.Width, cfg.Height, err)
+	}
+}
+
+func mustDecodeB64(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -8,8 +8,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/baalimago/clai/internal/chat"
 	"github.com/baalimago/clai/internal/debugflags"
 	"github.com/baalimago/clai/internal/models"
+	"github.com/baalimago/clai/internal/ratelimit"
 	"github.com/baalimago/clai/internal/utils"
 	pub_models "github.com/baalimago/clai/pkg/text/models"
 	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
@@ -43,14 +45,21 @@ type Querier[C models.StreamCompleter] struct {
 	// session. It is resolved once in NewQuerier from the session writer's fd;
 	// every width-aware render path of the querier reads this value. The
 	// snapshot is always usable: a failed read yields dimensions.Fallback.
-	dims                    dimensions.Dimensions
-	lineCount               int
-	line                    string
-	fullMsg                 string
-	configDir               string
+	dims      dimensions.Dimensions
+	lineCount int
+	line      string
+	fullMsg   string
+	configDir string
+	// persistence carries the per-session chat persistence settings (index
+	// skipping, conversation store) so concurrent agents never share package
+	// state for them.
+	persistence             chat.Persistence
 	debug                   bool
 	debugTextQuerierPrinted bool
-	shouldSaveReply         bool
+	// warnedVision is set once the user was warned that the model may not
+	// read the images of the chat.
+	warnedVision    bool
+	shouldSaveReply bool
 	// replyMode gates always-on history recording: reply queries (-re/-dre) fork a
 	// fresh promoted id, so recording them would pollute the directory history.
 	replyMode bool
@@ -67,6 +76,9 @@ type Querier[C models.StreamCompleter] struct {
 	Model                 C
 	tooling               tooling
 	rateLimitLastAmTokens int
+	// rateLimiter paces requests to the model before they are sent. Nil when
+	// the model config sets no rate_limit.
+	rateLimiter *ratelimit.Limiter
 
 	// systemPrompt is the configured system prompt, injected into every
 	// TextQuery call that does not already carry a system message.
@@ -95,13 +107,6 @@ type Querier[C models.StreamCompleter] struct {
 	// pkg/text paths) keeps every channel disabled.
 	agentSettings *AgentSettings
 
-	// isLikelyGemini3Preview is set to true if it's likely that the current underlying model
-	// is the gemini 3 preview which suffers from an issue where it insists on crashing if there
-	// is no "though_signature" within extra content, while also sending requests which lack "though_signature"
-	//
-	// Maybe one day this hack can be removed.
-	isLikelyGemini3Preview bool
-
 	// stoploss is the token stoploss policy carried from the user config; the
 	// stoploss controller consumes it in the session runner (Phase 3).
 	stoploss *Stoploss
@@ -503,7 +508,6 @@ func (q *Querier[C]) Query(ctx context.Context) error {
 	q.lineCount = session.LineCount
 	q.hasPrinted = session.Finalized
 	q.tooling.calls = session.ToolCallsUsed
-	q.isLikelyGemini3Preview = session.LikelyGeminiPreview
 	// The final answer pop is consumed by the finalizer's postProcessOutput
 	// inside Run; it must not leak into the next query.
 	q.finalAnswerPopPending = false
@@ -10,9 +10,12 @@ import (
 	"path"
 	"strings"
 
+	"github.com/baalimago/clai/internal/attachment"
 	"github.com/baalimago/clai/internal/cost"
 	"github.com/baalimago/clai/internal/debugflags"
 	"github.com/baalimago/clai/internal/models"
+	"github.com/baalimago/clai/internal/ratelimit"
+	"github.com/baalimago/clai/internal/routing"
 	"github.com/baalimago/clai/internal/text/generic"
 	"github.com/baalimago/clai/internal/utils"
 	"github.com/baalimago/clai/internal/vendors/openrouter"
@@ -50,89 +53,86 @@ func CanonicalModelString(vendor, family, modelVersion string) string {
 		return modelVersion
 	case "huggingface", "hf":
 		return "hf:" + modelVersion + ":" + family
+	case "azure":
+		return "azure:" + modelVersion
+	case "bedrock":
+		return "bedrock:" + modelVersion
 	default:
 		return modelVersion
 	}
 }
 
+// vendorType resolves fromModel through the routing table and returns the
+// parts naming its model config file: <vendor>_<family>_<modelVersion>.json.
 func vendorType(fromModel string) (string, string, string, error) {
-	if strings.Contains(fromModel, "test") {
-		return "mock", "test", fromModel, nil
-	}
-	if after, ok := strings.CutPrefix(fromModel, "or:"); ok {
-		modelVersion := after
-		return "openrouter", "chat", modelVersion, nil
+	res, err := routing.Resolve(fromModel)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to find vendor for: %v: %w", fromModel, err)
 	}
-	if strings.HasPrefix(fromModel, "berget:") {
-		vendor := "berget"
-		modelVersion := fromModel[7:]
+	model := res.Model
+	switch res.Vendor {
+	case routing.VendorMock:
+		if model == "mock" {
+			return "mock", "mock", "mock", nil
+		}
+		return "mock", "test", model, nil
+	case routing.VendorOpenRouter:
+		return "openrouter", "chat", strings.TrimPrefix(model, "or:"), nil
+	case routing.VendorBerget:
+		family := "berget"
+		modelVersion := strings.TrimPrefix(model, "berget:")
 		parts := strings.Split(modelVersion, "/")
 		if len(parts) > 1 {
-			vendor = parts[0]
+			family = parts[0]
 			modelVersion = parts[1]
 		}
-		return "berget", vendor, modelVersion, nil
-	}
-	if strings.Contains(fromModel, "gpt") {
-		return "openai", "gpt", fromModel, nil
-	}
-	if strings.Contains(fromModel, "claude") {
-		return "anthropic", "claude", fromModel, nil
-	}
-	if strings.Contains(fromModel, "ollama") {
-		m := "llama3"
-		if strings.HasPrefix(fromModel, "ollama:") {
-			m = fromModel[7:]
+		return "berget", family, modelVersion, nil
+	case routing.VendorOpenAI:
+		return "openai", "gpt", model, nil
+	case routing.VendorAnthropic:
+		return "anthropic", "claude", model, nil
+	case routing.VendorOllama:
+		family := "llama3"
+		if after, ok := strings.CutPrefix(model, "ollama:"); ok {
+			family = after
 		}
-		return "ollama", m, fromModel, nil
-	}
-	if strings.Contains(fromModel, "novita") {
-		m := ""
-		modelVersion := fromModel
-		if strings.HasPrefix(fromModel, "novita:") {
-			parts := strings.Split(fromModel[7:], "/")
+		return "ollama", family, model, nil
+	case routing.VendorNovita:
+		family := ""
+		modelVersion := model
+		if after, ok := strings.CutPrefix(model, "novita:"); ok {
+			parts := strings.Split(after, "/")
 			if len(parts) > 1 {
-				m = parts[0]
+				family = parts[0]
 				modelVersion = parts[1]
 			}
 		}
-
-		return "novita", m, modelVersion, nil
-	}
-	if strings.Contains(fromModel, "mistral") ||
-		strings.Contains(fromModel, "mixtral") ||
-		strings.Contains(fromModel, "codestral") ||
-		strings.Contains(fromModel, "devstral") {
-		return "mistral", "mistral", fromModel, nil
-	}
-
-	if strings.Contains(fromModel, "deepseek") {
-		return "deepseek", "deepseek", fromModel, nil
-	}
-	if strings.Contains(fromModel, "mock") {
-		return "mock", "mock", "mock", nil
-	}
-	if strings.Contains(fromModel, "mercury") {
-		return "inception", "mercury", fromModel, nil
-	}
-	if strings.Contains(fromModel, "grok") {
-		return "xai", "grok", fromModel, nil
-	}
-	if strings.Contains(fromModel, "gemini") {
-		return "google", "gemini", fromModel, nil
-	}
-	if strings.HasPrefix(fromModel, "hf:") || strings.HasPrefix(fromModel, "huggingface:") {
-		split := strings.Split(fromModel, ":")
+		return "novita", family, modelVersion, nil
+	case routing.VendorMistral:
+		return "mistral", "mistral", model, nil
+	case routing.VendorDeepSeek:
+		return "deepseek", "deepseek", model, nil
+	case routing.VendorInception:
+		return "inception", "mercury", model, nil
+	case routing.VendorXAI:
+		return "xai", "grok", model, nil
+	case routing.VendorGoogle:
+		return "google", "gemini", model, nil
+	case routing.VendorAzure:
+		return "azure", "deployment", strings.TrimPrefix(model, "azure:"), nil
+	case routing.VendorBedrock:
+		return "bedrock", "converse", strings.TrimPrefix(model, "bedrock:"), nil
+	case routing.VendorHuggingFace:
+		split := strings.Split(model, ":")
 		if len(split) < 3 {
-			return "huggingface", fromModel, "", nil
+			return "huggingface", model, "", nil
 		}
 		// Format is: "hf:<model>:<inference provider>"
 		// So we return modelVersion as split[1], and inference provider as "model"
 		// The model is currently (26-01) only semantic, so it has no other usecase, so it works for now
 		return split[0], split[2], split[1], nil
 	}
-
-	return "", "", "", fmt.Errorf("failed to find vendor for: %v", fromModel)
+	return "", "", "", fmt.Errorf("failed to find vendor for: %v: route to unknown vendor %q", fromModel, res.Vendor)
 }
 
 // setupConfigFile using unholy named returns since it kind of fits and im too lazy to explicitly declare. Hobby project
@@ -151,6 +151,14 @@ func setupConfigFile[C models.StreamCompleter](configPath string, userConf Confi
 			}
 
 			err = os.WriteFile(configPath, data, os.FileMode(0o644))
+			if err != nil && userConf.ConversationStore != nil {
+				// An injected store means the caller may run on a read-only
+				// filesystem: use the default without persisting it.
+				if err := json.Unmarshal(data, &modelConf); err != nil {
+					return modelConf, fmt.Errorf("failed to copy default model: %v, error: %w", dfault, err)
+				}
+				return modelConf, nil
+			}
 			if err != nil {
 				return modelConf, fmt.Errorf("failed to write default model: %v, error: %w", dfault, err)
 			}
@@ -167,16 +175,66 @@ func setupConfigFile[C models.StreamCompleter](configPath string, userConf Confi
 	return
 }
 
+// runtimeVendor is implemented by model configs of vendors defined at
+// runtime (see vendors/custom) rather than known to vendorType. The route
+// names the model config file; the vendor definition is not part of that
+// file, so the config loaded from it inherits the definition of the default.
+type runtimeVendor interface {
+	VendorRoute() (vendor, family, version string)
+	InheritVendor(dfault any)
+}
+
+// modelConfigName is the name of the model config file of a vendorType
+// result, without the .json extension.
+func modelConfigName(vendor, model, modelVersion string) string {
+	return fmt.Sprintf("%v_%v_%v", vendor, model, strings.ReplaceAll(modelVersion, "/", "_"))
+}
+
+// priceFetcher returns the catalog the cost manager fetches prices from, nil
+// when none is set up.
+func priceFetcher() cost.ModelCatalogFetcher {
+	openrouterAPIKey := os.Getenv("OPENROUTER_API_KEY")
+	if openrouterAPIKey == "" {
+		return nil
+	}
+	openrouterCatalogFetcher, err := openrouter.NewModelCatalog(openrouterAPIKey)
+	if err != nil {
+		ancli.Warnf("found OPENROUTER_API_KEY but failed to init catalog fether: %v", err)
+	}
+	return openrouterCatalogFetcher
+}
+
+// ModelPrice resolves the price of model the way a query with it does: from
+// the price cached in its model config file in confDir, else from the price
+// catalog. It is for callers which run no querier, such as batch fetches.
+func ModelPrice(ctx context.Context, confDir, model string) (cost.ModelPriceScheme, error) {
+	vendor, family, modelVersion, err := vendorType(model)
+	if err != nil {
+		return cost.ModelPriceScheme{}, fmt.Errorf("failed to find vendorType: %w", err)
+	}
+	configPath := path.Join(confDir, modelConfigName(vendor, family, modelVersion)+".json")
+	m := cost.NewManager(priceFetcher(), modelVersion, configPath)
+	return m.Price(ctx)
+}
+
 func NewQuerier[C models.StreamCompleter](ctx context.Context, userConf Configurations, dfault C) (Querier[C], error) {
 	traceChatf("new querier start model=%q config_dir=%q initial_chat_id=%q messages=%d", userConf.Model, userConf.ConfigDir, userConf.InitialChat.ID, len(userConf.InitialChat.Messages))
-	vendor, model, modelVersion, err := vendorType(userConf.Model)
-	if err != nil {
-		return Querier[C]{}, fmt.Errorf("failed to find vendorType: %w", err)
+	var (
+		vendor, model, modelVersion string
+		err                         error
+	)
+	if rv, ok := any(dfault).(runtimeVendor); ok {
+		vendor, model, modelVersion = rv.VendorRoute()
+	} else {
+		vendor, model, modelVersion, err = vendorType(userConf.Model)
+		if err != nil {
+			return Querier[C]{}, fmt.Errorf("failed to find vendorType: %w", err)
+		}
 	}
 	traceChatf("new querier vendor resolved vendor=%q model=%q version=%q", vendor, model, modelVersion)
 	claiConfDir := userConf.ConfigDir
-	noFrontslashModelVersion := strings.ReplaceAll(modelVersion, "/", "_")
-	configPath := path.Join(claiConfDir, fmt.Sprintf("%v_%v_%v.json", vendor, model, noFrontslashModelVersion))
+	configName := modelConfigName(vendor, model, modelVersion)
+	configPath := path.Join(claiConfDir, configName+".json")
 	traceChatf("new querier model config path=%q", configPath)
 	querier := Querier[C]{}
 	if debugflags.Enabled("TEXT_QUERIER") {
@@ -187,7 +245,17 @@ func NewQuerier[C models.StreamCompleter](ctx context.Context, userConf Configur
 	if err != nil {
 		return Querier[C]{}, fmt.Errorf("failed to setup config file: %w", err)
 	}
+	if rv, ok := any(modelConf).(runtimeVendor); ok {
+		rv.InheritVendor(dfault)
+	}
 	traceChatf("new querier model config loaded path=%q", configPath)
+	limits, err := ratelimit.LimitsFromFile(configPath)
+	if err != nil {
+		return Querier[C]{}, fmt.Errorf("failed to load rate limits: %w", err)
+	}
+	if limits.Enabled() {
+		querier.rateLimiter = ratelimit.ForBucket(limits.BucketName(vendor), limits, claiConfDir)
+	}
 
 	if querier.debug {
 		ancli.PrintOK(fmt.Sprintf("userConf: %v\n", debug.IndentedJsonFmt(userConf)))
@@ -195,7 +263,31 @@ func NewQuerier[C models.StreamCompleter](ctx context.Context, userConf Configur
 	// Inject the per-run command ban list at the spawn point before any tool
 	// is registered, so every freetext execution in this run is covered (D6).
 	// Unconditional: the default empty list keeps behavior permissive (D4).
-	pkgtools.SetCmdBanList(userConf.CmdBan)
+	// The list lands on the run's tool session, so runs with their own
+	// session never overwrite each other's fallback.
+	toolSession := userConf.ToolSession
+	if toolSession == nil {
+		toolSession = pkgtools.DefaultSession()
+	}
+	toolSession.SetCmdBanList(userConf.CmdBan)
+	toolSession.SetHTTPPolicy(userConf.HTTPRequest)
+	webSearch := userConf.WebSearch
+	if webSearch == nil {
+		// A broken webSearch.json only disables web_search, queries which
+		// don't search still run.
+		var wsErr error
+		webSearch, wsErr = loadWebSearchConfig(claiConfDir)
+		if wsErr != nil {
+			ancli.Warnf("web_search is disabled: %v\n", wsErr)
+		}
+	}
+	toolSession.SetWebSearch(webSearch)
+	querier.tooling.session = toolSession
+	querier.tooling.catalog = userConf.ToolRegistry
+	querier.persistence, err = userConf.Persistence()
+	if err != nil {
+		return Querier[C]{}, err
+	}
 	querier.Raw = userConf.Raw
 	output := userConf.Out
 	if output == nil {
@@ -219,6 +311,7 @@ func NewQuerier[C models.StreamCompleter](ctx context.Context, userConf Configur
 	if userConf.AgentSettings != nil {
 		querier.callUsageRecorder = userConf.AgentSettings.UsageRecorder
 		querier.tooling.callRecorder = userConf.AgentSettings.ToolCallRecorder
+		querier.tooling.middleware = userConf.AgentSettings.ToolMiddleware
 		querier.agentSettings = userConf.AgentSettings
 	}
 	querier.out = output
@@ -229,6 +322,12 @@ func NewQuerier[C models.StreamCompleter](ctx context.Context, userConf Configur
 	querier.mcpSink = newMcpLogSink(mcpLogModeFor(querier.debug, querier.outputIsTerminal, querier.Raw, querier.structuredOutput, utils.RollingOutputEnabled()))
 	setupTooling(ctx, modelConf, &userConf, querier.mcpSink)
 
+	// Before Setup, so the vendor validates the profile's thinking settings.
+	if userConf.Thinking != nil {
+		if configurer, ok := any(modelConf).(models.ThinkingConfigurer); ok {
+			configurer.SetThinking(*userConf.Thinking)
+		}
+	}
 	err = modelConf.Setup()
 	if err != nil {
 		return Querier[C]{}, fmt.Errorf("failed to setup model: %w", err)
@@ -247,6 +346,8 @@ func NewQuerier[C models.StreamCompleter](ctx context.Context, userConf Configur
 		debug.IndentedJsonFmt(querier),
 		debug.IndentedJsonFmt(modelConf))
 	querier.chat = userConf.InitialChat
+	// Saved conversations reference their documents instead of holding them.
+	querier.chat.Messages = attachment.Restore(querier.chat.Messages)
 	querier.systemPrompt = userConf.SystemPrompt
 	traceChatf("new querier chat attached chat_id=%q messages=%d system_prompt_len=%d", querier.chat.ID, len(querier.chat.Messages), len(querier.systemPrompt))
 	// Ensure profile selection is persisted in globalScope/saved conversations.
@@ -269,18 +370,7 @@ func NewQuerier[C models.StreamCompleter](ctx context.Context, userConf Configur
 		}
 	}
 
-	var fetcher cost.ModelCatalogFetcher
-	if fetcher == nil {
-		openrouterAPIKey := os.Getenv("OPENROUTER_API_KEY")
-		if openrouterAPIKey != "" {
-			openrouterCatalogFetcher, err := openrouter.NewModelCatalog(openrouterAPIKey)
-			if err != nil {
-				ancli.Warnf("found OPENROUTER_API_KEY but failed to init catalog fether: %v", err)
-			}
-			fetcher = openrouterCatalogFetcher
-		}
-	}
-	costManager := new(cost.NewManager(fetcher, modelVersion, configPath))
+	costManager := new(cost.NewManager(priceFetcher(), modelVersion, configPath))
 	costManager.SetModelResolver(func(_ pub_models.Chat) string {
 		if modelNamer, ok := any(modelConf).(ModelNamer); ok {
 			if modelName := strings.TrimSpace(modelNamer.ModelName()); modelName != "" {
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	pkgtools "github.com/baalimago/clai/pkg/tools"
 	"github.com/baalimago/go_away_boilerplate/pkg/dimensions"
 )
 
@@ -83,6 +84,8 @@ func TestCanonicalModelString_RoundTrip(t *testing.T) {
 		{"grok", "grok-3"},
 		{"mercury", "mercury-coder"},
 		{"mock", "mock"},
+		{"azure", "azure:prod-gpt-4o"},
+		{"bedrock", "bedrock:anthropic.claude-sonnet-4-5-20250929-v1:0"},
 	}
 
 	for _, tt := range tests {
@@ -255,3 +258,22 @@ func TestNewQuerier_AgentSettings(t *testing.T) {
 		t.Fatalf("expected nil toolCallRecorder, got %v", plain.tooling.callRecorder)
 	}
 }
+
+func TestNewQuerier_InvalidWebSearchConfigOnlyWarns(t *testing.T) {
+	// Avoid races with the cost manager error logger goroutine in NewQuerier.
+	t.Setenv("CLAI_DISABLE_COST_ERR_LOG_GOROUTINE", "1")
+
+	confDir := t.TempDir()
+	if err := os.WriteFile(path.Join(confDir, WebSearchConfigFile), []byte(`{"backend":`), 0o644); err != nil {
+		t.Fatalf("write web search config: %v", err)
+	}
+	conf := Configurations{
+		Model:       "mock",
+		ConfigDir:   confDir,
+		Out:         &strings.Builder{},
+		ToolSession: pkgtools.NewSession(),
+	}
+	if _, err := NewQuerier(context.Background(), conf, &MockQuerier{}); err != nil {
+		t.Fatalf("expected a broken webSearch.json not to fail the query, got %v", err)
+	}
+}
@@ -214,7 +214,11 @@ func setupTooling[C models.StreamCompleter](ctx context.Context, modelConf C, us
 	if !userConf.UseTools {
 		return
 	}
-	tools.Init()
+	var catalog tools.Catalog = userConf.ToolRegistry
+	if catalog == nil {
+		tools.Init()
+		catalog = tools.Registry
+	}
 	mcpTools, err := setupMcpManager(ctx, path.Join(userConf.ConfigDir, "mcpServers"), *userConf, sink)
 	if misc.Truthy(os.Getenv("DEBUG")) {
 		ancli.Okf("Registering tools on querier of type: %T\n", modelConf)
@@ -228,7 +232,7 @@ func setupTooling[C models.StreamCompleter](ctx context.Context, modelConf C, us
 	// plus the MCP tools this run started. Dynamic tools (MCP and WithTools)
 	// are never written into the process-global registry, so concurrent Setups
 	// cannot overwrite each other's instances.
-	available := tools.Registry.All()
+	available := catalog.All()
 	maps.Copy(available, mcpTools)
 
 	// If usetools and no specific tools chosen, assume all are valid
@@ -39,23 +39,6 @@ func limitToolOutput(out string, limit int) string {
 	)
 }
 
-func (q *Querier[C]) checkIfGemini3Preview(call pub_models.Call) bool {
-	if q.isLikelyGemini3Preview {
-		return q.isLikelyGemini3Preview
-	}
-	if call.ExtraContent == nil {
-		return false
-	}
-
-	googleExtraContent, isGoogle := call.ExtraContent["google"]
-	if !isGoogle {
-		return false
-	}
-	googleExtraContentAsMap, _ := googleExtraContent.(map[string]any)
-	_, hasThoughSignature := googleExtraContentAsMap["thought_signature"]
-	return hasThoughSignature
-}
-
 func (q *Querier[C]) doToolCallLogic(ctx context.Context, call pub_models.Call) error {
 	session := &QuerySession{
 		Chat:            q.chat,
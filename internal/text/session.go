@@ -15,9 +15,11 @@ type QuerySession struct {
 	PendingReasoning   strings.Builder
 	FinalAssistantText string
 	FinalReasoningText string
-	FinalUsage         *pub_models.Usage
-	CompletedCalls     []CompletedModelCall
-	ToolCallsUsed      int
+	// FinalReasoningItems is the continuity state of the final answer.
+	FinalReasoningItems []pub_models.ReasoningItem
+	FinalUsage          *pub_models.Usage
+	CompletedCalls      []CompletedModelCall
+	ToolCallsUsed       int
 	// PostHandoverToolCallsUsed counts tool calls made after the token
 	// stoploss handover fired. It is the phase counter for the
 	// max-tool-calls-after-handover wrap-up budget: the handover starts a
@@ -27,16 +29,15 @@ type QuerySession struct {
 	// handover user message. It switches the tool-budget phase: the
 	// wrap-up allowance (max-tool-calls-after-handover) replaces the
 	// pre-handover max-tool-calls budget.
-	HandoverRequested   bool
-	ShouldSaveReply     bool
-	Raw                 bool
-	Finalized           bool
-	Failed              bool
-	SawAnyText          bool
-	SawStopEvent        bool
-	LikelyGeminiPreview bool
-	Line                string
-	LineCount           int
+	HandoverRequested bool
+	ShouldSaveReply   bool
+	Raw               bool
+	Finalized         bool
+	Failed            bool
+	SawAnyText        bool
+	SawStopEvent      bool
+	Line              string
+	LineCount         int
 }
 
 func (s *QuerySession) PendingTextString() string {
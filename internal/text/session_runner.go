@@ -20,8 +20,10 @@ type ModelStepResult struct {
 	AssistantText string
 	ToolCalls     []pub_models.Call
 	Usage         *pub_models.Usage
-	StopRequested bool
-	EndedNormally bool
+	// ReasoningItems is the continuity state the stream stopped with.
+	ReasoningItems []pub_models.ReasoningItem
+	StopRequested  bool
+	EndedNormally  bool
 }
 
 type sessionRunner[C models.StreamCompleter] struct {
@@ -112,6 +114,7 @@ func (r *sessionRunner[C]) Run(ctx context.Context, session *QuerySession) (runE
 		if stepResult.AssistantText != "" {
 			session.FinalAssistantText = stepResult.AssistantText
 			session.FinalReasoningText = session.PendingReasoning.String()
+			session.FinalReasoningItems = stepResult.ReasoningItems
 		}
 		session.FinalUsage = stepResult.Usage
 		if stepResult.StopRequested || stepResult.EndedNormally {
@@ -124,6 +127,9 @@ func (r *sessionRunner[C]) Run(ctx context.Context, session *QuerySession) (runE
 func (r *sessionRunner[C]) runStepWithRetry(ctx context.Context, session *QuerySession) (ModelStepResult, error) {
 	r.currentRetries = 0
 	for {
+		if err := r.waitForRateLimiter(ctx, session.Chat); err != nil {
+			return ModelStepResult{}, err
+		}
 		result, err := r.executeModelStep(ctx, session)
 		if err == nil {
 			return result, nil
@@ -142,6 +148,30 @@ func (r *sessionRunner[C]) runStepWithRetry(ctx context.Context, session *QueryS
 	}
 }
 
+// waitForRateLimiter blocks until the model config's shared rate limiter admits the
+// next request. The input token estimate comes from the model when it is an
+// InputTokenCounter; a failed estimate only limits by request count.
+func (r *sessionRunner[C]) waitForRateLimiter(ctx context.Context, chat pub_models.Chat) error {
+	limiter := r.querier.rateLimiter
+	if limiter == nil {
+		return nil
+	}
+	var tokens int
+	if counter, ok := any(r.querier.Model).(models.InputTokenCounter); ok && limiter.CountsTokens() {
+		counted, err := counter.CountInputTokens(ctx, chat)
+		if err != nil {
+			ancli.Warnf("failed to estimate input tokens for rate limiting: %v", err)
+		}
+		tokens = counted
+	}
+	waitStart := time.Now()
+	if err := limiter.Wait(ctx, tokens); err != nil {
+		return fmt.Errorf("wait for rate limiter: %w", err)
+	}
+	traceChatf("rate limiter admitted request tokens=%d waited=%v", tokens, time.Since(waitStart))
+	return nil
+}
+
 func (r *sessionRunner[C]) waitForRateLimitReset(ctx context.Context, chat pub_models.Chat, rateLimitErr models.ErrRateLimit) error {
 	counter, ok := any(r.querier.Model).(models.InputTokenCounter)
 	if ok {
@@ -183,6 +213,16 @@ func (r *sessionRunner[C]) executeModelStep(ctx context.Context, session *QueryS
 	traceChatf("query sending chat to stream completions chat_id=%q messages=%d", session.Chat.ID, len(session.Chat.Messages))
 	session.ResetPendingText()
 
+	if hasImages(session.Chat) {
+		supported, known := visionSupport(q.Model)
+		if !supported {
+			return ModelStepResult{}, ErrVisionUnsupported
+		}
+		if !known && !q.warnedVision {
+			q.warnedVision = true
+			ancli.Warnf("can't tell whether the model reads images, sending them anyway\n")
+		}
+	}
 	completionsChan, err := q.Model.StreamCompletions(ctx, session.Chat)
 	if err != nil {
 		return ModelStepResult{}, fmt.Errorf("stream completions: %w", err)
@@ -282,6 +322,7 @@ func (r *sessionRunner[C]) executeModelStep(ctx context.Context, session *QueryS
 				q.closeReasoningIfOpen(ctx, session)
 				result.AssistantText = session.PendingTextString()
 				result.Usage = q.currentTokenUsage()
+				result.ReasoningItems = cast.ReasoningItems
 				if len(result.ToolCalls) > 0 {
 					return result, nil
 				}
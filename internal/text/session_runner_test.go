@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/baalimago/clai/internal/models"
+	"github.com/baalimago/clai/internal/ratelimit"
 	"github.com/baalimago/clai/internal/utils"
 	pub_models "github.com/baalimago/clai/pkg/text/models"
 	"github.com/baalimago/go_away_boilerplate/pkg/dimensions"
@@ -316,6 +317,38 @@ func Test_sessionRunner_Run_FinalReplyReasoningIsPersistedSeparatelyFromContent(
 	}
 }
 
+func Test_sessionRunner_Run_FinalReplyKeepsStopEventReasoningItems(t *testing.T) {
+	stored := pub_models.ReasoningItem{Vendor: pub_models.ReasoningVendorOpenAI, Type: pub_models.ReasoningResponse, ID: "resp_1"}
+	model := &MockQuerier{}
+	model.streamFn = func(_ context.Context, _ pub_models.Chat) (chan models.CompletionEvent, error) {
+		out := make(chan models.CompletionEvent, 2)
+		out <- "done"
+		out <- models.StopEvent{ReasoningItems: []pub_models.ReasoningItem{stored}}
+		close(out)
+		return out, nil
+	}
+
+	q := &Querier[*MockQuerier]{
+		out:   &strings.Builder{},
+		Model: model,
+	}
+	session := &QuerySession{Chat: pub_models.Chat{Messages: []pub_models.Message{{Role: "user", Content: "hello"}}}}
+	runner := sessionRunner[*MockQuerier]{
+		querier:      q,
+		recorder:     &recordingCallUsageRecorder{},
+		finalizer:    sessionFinalizer[*MockQuerier]{querier: q},
+		toolExecutor: toolExecutor[*MockQuerier]{querier: q},
+	}
+
+	if err := runner.Run(context.Background(), session); err != nil {
+		t.Fatalf("Run returned err: %v", err)
+	}
+	got := session.Chat.Messages[len(session.Chat.Messages)-1].ReasoningItems
+	if len(got) != 1 || got[0].ID != "resp_1" {
+		t.Fatalf("expected the stop event items on the final answer, got %#v", got)
+	}
+}
+
 func Test_sessionRunner_Run_ActivityViewportCombinesReasoningAndToolActivity(t *testing.T) {
 	model := &MockQuerier{}
 	callCount := 0
@@ -924,6 +957,38 @@ func Test_sessionRunner_Run_RateLimitRetryIsIterative(t *testing.T) {
 	}
 }
 
+func Test_sessionRunner_Run_WaitsForRateLimiterBeforeSending(t *testing.T) {
+	model := &MockQuerier{}
+	called := false
+	model.streamFn = func(_ context.Context, _ pub_models.Chat) (chan models.CompletionEvent, error) {
+		called = true
+		out := make(chan models.CompletionEvent)
+		close(out)
+		return out, nil
+	}
+	limiter := ratelimit.ForBucket("session-runner-test", ratelimit.Limits{RequestsPerMinute: 1}, t.TempDir())
+	// Another querier of the vendor already spent the only request.
+	if err := limiter.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("drain limiter: %v", err)
+	}
+
+	q := &Querier[*MockQuerier]{out: &strings.Builder{}, Model: model, rateLimiter: limiter}
+	runner := sessionRunner[*MockQuerier]{
+		querier:      q,
+		finalizer:    &countingFinalizer{},
+		toolExecutor: toolExecutor[*MockQuerier]{querier: q},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := runner.Run(ctx, &QuerySession{Chat: pub_models.Chat{Messages: []pub_models.Message{{Role: "user", Content: "hi"}}}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the run to wait on the limiter until the deadline, got %v", err)
+	}
+	if called {
+		t.Fatal("expected no request to be sent while the limiter is exhausted")
+	}
+}
+
 func Test_sessionRunner_Run_MultipleToolCallsDoNotReusePreviousPendingText(t *testing.T) {
 	model := &MockQuerier{}
 	callCount := 0
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/baalimago/clai/internal/repomap"
 	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
 )
 
@@ -31,9 +32,25 @@ func AppendShellContextIfConfigured(ctx context.Context, configDir, shellContext
 	if err != nil {
 		return prompt, fmt.Errorf("render shell context: %w", err)
 	}
+	return insertContextBlock("shell context", rendered, prompt), nil
+}
+
+// AppendRepoMap inserts a map of the repository at dir, within maxTokens, into
+// prompt the same way as the shell context, in a "repository map" block.
+func AppendRepoMap(ctx context.Context, dir string, maxTokens int, prompt string) (string, error) {
+	rendered, err := repomap.Build(ctx, dir, maxTokens)
+	if err != nil {
+		return prompt, fmt.Errorf("build repository map: %w", err)
+	}
+	return insertContextBlock("repository map", rendered, prompt), nil
+}
+
+// insertContextBlock puts rendered, wrapped in <tag> lines, before prompt. An
+// empty rendered leaves prompt as is.
+func insertContextBlock(tag, rendered, prompt string) string {
 	rendered = strings.Trim(rendered, " \t\r")
 	if rendered == "" {
-		return prompt, nil
+		return prompt
 	}
 	rendered = strings.TrimPrefix(rendered, "\n")
 
@@ -41,5 +58,5 @@ func AppendShellContextIfConfigured(ctx context.Context, configDir, shellContext
 	if !strings.HasSuffix(rendered, "\n") {
 		rendered += "\n"
 	}
-	return "<shell context>\n" + rendered + "</shell context>\n" + prompt, nil
+	return "<" + tag + ">\n" + rendered + "</" + tag + ">\n" + prompt
 }
@@ -105,6 +105,52 @@ func TestAppendShellContextIfConfigured_preserves_trailing_newline_separation(t
 	}
 }
 
+func TestAppendRepoMap_insertsBlockBeforePrompt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/main.go", []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	got, err := AppendRepoMap(context.Background(), dir, 0, "\nSYSTEM")
+	if err != nil {
+		t.Fatalf("AppendRepoMap: %v", err)
+	}
+	if !strings.HasPrefix(got, "<repository map>\nRepository map of ") {
+		t.Fatalf("missing repository map block:\n%s", got)
+	}
+	if !strings.Contains(got, "main.go: main()\n</repository map>\nSYSTEM") {
+		t.Fatalf("unexpected prompt:\n%s", got)
+	}
+
+	empty, err := AppendRepoMap(context.Background(), t.TempDir(), 0, "SYSTEM")
+	if err != nil || empty != "SYSTEM" {
+		t.Fatalf("empty dir should leave the prompt as is, got %q, %v", empty, err)
+	}
+}
+
+func TestConfigurations_SetupInitialChat_GlobKeepsRepoMap(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/main.go", []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	t.Chdir(dir)
+
+	conf := Default
+	conf.ConfigDir = t.TempDir()
+	conf.RepoMap = true
+	conf.Glob = "*.go"
+	if err := conf.SetupInitialChat([]string{"q", "what does main do?"}); err != nil {
+		t.Fatalf("SetupInitialChat: %v", err)
+	}
+	sys := conf.InitialChat.Messages[0]
+	if sys.Role != "system" || !strings.Contains(sys.Content, "<repository map>") || !strings.Contains(sys.Content, "main.go: main()") {
+		t.Fatalf("expected the repository map in the glob chat's system prompt, got %+v", sys)
+	}
+	if !strings.Contains(sys.Content, "'#####'") {
+		t.Fatalf("expected the glob system prompt kept after the map, got %q", sys.Content)
+	}
+}
+
 func TestShellContext_DefaultTemplate_separates_conditional_fields_with_single_lines(t *testing.T) {
 	def, err := loadDefaultShellContextDefinitionForTest(t)
 	if err != nil {
@@ -0,0 +1,48 @@
+package text
+
+import (
+	"fmt"
+
+	"github.com/baalimago/clai/internal/checkpoint"
+	"github.com/baalimago/clai/internal/tools"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	pkgtools "github.com/baalimago/clai/pkg/tools"
+	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
+)
+
+// checkpointCall snapshots the paths a file writing tool is about to touch,
+// so that `clai undo` can restore them. A failed snapshot is warned about but
+// never blocks the call.
+func (e toolExecutor[C]) checkpointCall(session *QuerySession, call pub_models.Call) {
+	q := e.querier
+	if q.configDir == "" || session == nil || session.Chat.ID == "" {
+		return
+	}
+	var t pub_models.LLMTool
+	var exists bool
+	if q.tooling.run != nil {
+		t, exists = q.tooling.run[call.Name]
+	}
+	if !exists {
+		catalog := q.tooling.catalog
+		if catalog == nil {
+			catalog = tools.Registry
+		}
+		t, exists = catalog.Get(call.Name)
+	}
+	fw, ok := t.(pkgtools.FileWriter)
+	if !exists || !ok {
+		return
+	}
+	inp := pub_models.Input{}
+	if call.Inputs != nil {
+		inp = *call.Inputs
+	}
+	paths := fw.WritePaths(inp)
+	if len(paths) == 0 {
+		return
+	}
+	if _, err := checkpoint.New(q.configDir).Snapshot(session.Chat.ID, call.ID, call.Name, paths); err != nil {
+		ancli.PrintWarn(fmt.Sprintf("failed to checkpoint %s call, it will not be undoable: %v\n", call.Name, err))
+	}
+}
@@ -0,0 +1,76 @@
+package text
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/baalimago/clai/internal/checkpoint"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	pkgtools "github.com/baalimago/clai/pkg/tools"
+)
+
+func Test_checkpointCall(t *testing.T) {
+	confDir := t.TempDir()
+	target := filepath.Join(t.TempDir(), "new.txt")
+	q := &Querier[*MockQuerier]{
+		configDir: confDir,
+		tooling: tooling{run: map[string]pub_models.LLMTool{
+			"write_file": pkgtools.WriteFile,
+			"ls":         pkgtools.LS,
+		}},
+	}
+	e := toolExecutor[*MockQuerier]{querier: q}
+	session := &QuerySession{Chat: pub_models.Chat{ID: "chat"}}
+
+	e.checkpointCall(session, pub_models.Call{ID: "call_1", Name: "write_file", Inputs: &pub_models.Input{"file_path": target}})
+	e.checkpointCall(session, pub_models.Call{ID: "call_2", Name: "ls", Inputs: &pub_models.Input{"directory": "."}})
+
+	cps, err := checkpoint.New(confDir).List("chat")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(cps) != 1 || cps[0].ToolCallID != "call_1" || cps[0].Tool != "write_file" {
+		t.Fatalf("expected one checkpoint of the write_file call, got %+v", cps)
+	}
+	if got := cps[0].Paths(); len(got) != 1 || got[0] != target {
+		t.Fatalf("expected %s checkpointed, got %v", target, got)
+	}
+}
+
+func Test_toolHandler_checkpointsRewrittenCall(t *testing.T) {
+	confDir := t.TempDir()
+	requested := filepath.Join(t.TempDir(), "requested.txt")
+	rewritten := filepath.Join(t.TempDir(), "rewritten.txt")
+	rewrite := func(next pub_models.ToolHandler) pub_models.ToolHandler {
+		return func(ctx context.Context, call pub_models.Call) (string, error) {
+			call.Inputs = &pub_models.Input{"file_path": rewritten, "content": "x"}
+			return next(ctx, call)
+		}
+	}
+	q := &Querier[*MockQuerier]{
+		configDir: confDir,
+		tooling: tooling{
+			run:        map[string]pub_models.LLMTool{"write_file": pkgtools.WriteFile},
+			middleware: []pub_models.ToolMiddleware{rewrite},
+		},
+	}
+	e := toolExecutor[*MockQuerier]{querier: q}
+	session := &QuerySession{Chat: pub_models.Chat{ID: "chat"}}
+
+	call := pub_models.Call{ID: "call_1", Name: "write_file", Inputs: &pub_models.Input{"file_path": requested, "content": "x"}}
+	if _, err := e.toolHandler(session)(context.Background(), call); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	cps, err := checkpoint.New(confDir).List("chat")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(cps) != 1 {
+		t.Fatalf("expected one checkpoint, got %+v", cps)
+	}
+	if got := cps[0].Paths(); len(got) != 1 || got[0] != rewritten {
+		t.Fatalf("expected the rewritten %s checkpointed, got %v", rewritten, got)
+	}
+}
@@ -14,17 +14,12 @@ import (
 	"github.com/baalimago/clai/internal/tools"
 	"github.com/baalimago/clai/internal/utils"
 	pub_models "github.com/baalimago/clai/pkg/text/models"
+	pkgtools "github.com/baalimago/clai/pkg/tools"
 	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
 	"github.com/baalimago/go_away_boilerplate/pkg/debug"
 	"github.com/baalimago/go_away_boilerplate/pkg/table"
 )
 
-type ToolDecision struct {
-	PatchedCall         pub_models.Call
-	SkipExecution       bool
-	TreatAsReturnToUser bool
-}
-
 type toolExecutor[C models.StreamCompleter] struct {
 	querier *Querier[C]
 }
@@ -51,16 +46,8 @@ func (e toolExecutor[C]) ExecuteBatch(ctx context.Context, session *QuerySession
 		if q.debug || debugflags.Enabled("CALL") {
 			ancli.PrintOK(fmt.Sprintf("received tool call: %v", debug.IndentedJsonFmt(call)))
 		}
-		decision := q.decideToolCall(session, call)
-		if decision.TreatAsReturnToUser || decision.SkipExecution {
-			session.FinalAssistantText = session.PendingTextString()
-			session.ResetPendingText()
-			continue
-		}
-		planned = append(planned, decision.PatchedCall)
-	}
-	if len(planned) == 0 {
-		return nil
+		call.Patch()
+		planned = append(planned, call)
 	}
 	plans := q.newStoploss().PreflightToolCallBudget(session, planned)
 	if err := e.finalizeAssistantTextBeforeToolCall(ctx, session, planned[0]); err != nil {
@@ -111,6 +98,7 @@ func (e toolExecutor[C]) ExecuteBatch(ctx context.Context, session *QuerySession
 		}
 		start = end
 	}
+	e.emitToolImages(ctx, session)
 	if pendingEOF {
 		return io.EOF
 	}
@@ -143,14 +131,30 @@ func (e toolExecutor[C]) runPlannedCall(ctx context.Context, session *QuerySessi
 		} else {
 			out = res
 		}
+	} else if plan.call.Name == string(pub_models.ViewImageTool) && !supportsVision(q.Model) {
+		out = fmt.Sprintf("ERROR: %s requested but %v", plan.call.Name, ErrVisionUnsupported)
 	} else {
 		startedAt := time.Now()
-		out = tools.InvokeWith(ctx, plan.call, q.tooling.run)
+		out = tools.InvokeThrough(pkgtools.WithSession(ctx, q.tooling.session), plan.call, e.toolHandler(session))
 		e.recordToolCall(ctx, plan.call.Name, startedAt, out)
 	}
 	return e.emitToolResult(ctx, session, plan.call, plan.prefix+out)
 }
 
+// toolHandler returns the dispatch handler for the per-run tool table,
+// wrapped by the configured tool middlewares. The recorder observes the
+// output after every middleware has run, which is what the model receives.
+// The checkpoint is taken innermost, of the call as the middlewares passed
+// it on, since they may rewrite its paths.
+func (e toolExecutor[C]) toolHandler(session *QuerySession) pub_models.ToolHandler {
+	dispatch := tools.Handler(e.querier.tooling.run, e.querier.tooling.catalog)
+	checkpointed := func(ctx context.Context, call pub_models.Call) (string, error) {
+		e.checkpointCall(session, call)
+		return dispatch(ctx, call)
+	}
+	return pub_models.ChainToolMiddleware(checkpointed, e.querier.tooling.middleware...)
+}
+
 // recordToolCall reports one executed tool invocation to the configured
 // recorder. A nil recorder keeps the noop path; a recorder error is logged
 // and never propagated — telemetry must not break the agent loop.
@@ -258,6 +262,20 @@ func (e toolExecutor[C]) emitToolResult(ctx context.Context, session *QuerySessi
 	return nil
 }
 
+// emitToolImages appends the images view_image loaded during the batch as a
+// user message after the tool results, since tool results are text only.
+func (e toolExecutor[C]) emitToolImages(ctx context.Context, session *QuerySession) {
+	images := pkgtools.TakeImages(pkgtools.WithSession(ctx, e.querier.tooling.session))
+	if len(images) == 0 {
+		return
+	}
+	traceChatf("tool images appended images=%d", len(images))
+	session.Chat.Messages = append(session.Chat.Messages, pub_models.Message{
+		Role:         "user",
+		ContentParts: images,
+	})
+}
+
 func (e toolExecutor[C]) executeLoadSkill(ctx context.Context, session *QuerySession, call pub_models.Call) error {
 	q := e.querier
 	if q.tooling.skillLoader == nil {
@@ -491,18 +509,3 @@ func (e toolExecutor[C]) finalizeAssistantTextRolling(ctx context.Context, sessi
 func isEchoedToolCallText(pending string, call pub_models.Call) bool {
 	return strings.TrimSpace(pending) == strings.TrimSpace(call.PrettyPrint())
 }
-
-func (q *Querier[C]) decideToolCall(session *QuerySession, call pub_models.Call) ToolDecision {
-	if session.LikelyGeminiPreview || q.checkIfGemini3Preview(call) {
-		session.LikelyGeminiPreview = true
-		if call.ExtraContent == nil {
-			return ToolDecision{
-				PatchedCall:         call,
-				SkipExecution:       true,
-				TreatAsReturnToUser: true,
-			}
-		}
-	}
-	call.Patch()
-	return ToolDecision{PatchedCall: call}
-}
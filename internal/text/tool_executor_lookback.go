@@ -39,13 +39,13 @@ func (e toolExecutor[C]) runLookbackTool(call pub_models.Call) (string, error) {
 			Page:      intInput(inputs, "page", 0),
 			PageSize:  intInput(inputs, "page_size", 0),
 		}
-		res, err := chat.NewConversationSearcher(q.configDir).Search(req)
+		res, err := q.persistence.ConversationSearcher(q.configDir).Search(req)
 		if err != nil {
 			return "", err
 		}
 		return chat.FormatSearchResult(res), nil
 	case string(pub_models.InspectConversationTool):
-		return chat.InspectConversation(
+		return q.persistence.InspectConversation(
 			q.configDir,
 			stringInput(inputs, "chat_id"),
 			intInput(inputs, "page", 0),
@@ -54,7 +54,7 @@ func (e toolExecutor[C]) runLookbackTool(call pub_models.Call) (string, error) {
 			stringInput(inputs, "match"),
 		)
 	case string(pub_models.ReadMessageTool):
-		content, path, err := chat.ReadMessage(
+		content, path, err := q.persistence.ReadMessage(
 			q.configDir,
 			stringInput(inputs, "chat_id"),
 			intInput(inputs, "message_index", -1),
@@ -1,6 +1,10 @@
 package text
 
-import pub_models "github.com/baalimago/clai/pkg/text/models"
+import (
+	"github.com/baalimago/clai/internal/tools"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	pkgtools "github.com/baalimago/clai/pkg/tools"
+)
 
 // tooling groups the per-run tool runtime state the querier carries for tool
 // dispatch, budgeting, skill activation, and telemetry. It is one field on
@@ -16,6 +20,9 @@ type tooling struct {
 	// callRecorder receives one ToolCall per tool invocation. Nil keeps the
 	// noop path.
 	callRecorder ToolCallRecorder
+	// middleware wraps every dispatch through run, outermost first. Empty
+	// keeps the direct dispatch path.
+	middleware []pub_models.ToolMiddleware
 	// skillLoader resolves and activates load_skill requests. Nil keeps the
 	// noop path.
 	skillLoader SkillLoader
@@ -27,6 +34,13 @@ type tooling struct {
 	// process-global registry so concurrent agents cannot overwrite each
 	// other's tool instances.
 	run map[string]pub_models.LLMTool
+	// catalog is the registry dispatch falls back to for calls missing from
+	// run. Nil means the process-global registry.
+	catalog tools.Catalog
+	// session owns the stateful tool state (async commands, clai_run workers,
+	// the fallback command ban list) of this run. It is attached to every tool
+	// call context; never nil after NewQuerier.
+	session *pkgtools.Session
 	// registered records which tool names have been registered on the model
 	// tool box so a skill enabling an already-registered tool does not
 	// double-register it.
@@ -0,0 +1,36 @@
+package text
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	pkgtools "github.com/baalimago/clai/pkg/tools"
+)
+
+// WebSearchConfigFile is the file in the config directory configuring the
+// backend of the web_search tool.
+const WebSearchConfigFile = "webSearch.json"
+
+// loadWebSearchConfig reads the web_search backend of configDir. A missing
+// file yields nil: web_search then explains how to configure one.
+func loadWebSearchConfig(configDir string) (*pkgtools.WebSearchConfig, error) {
+	p := filepath.Join(configDir, WebSearchConfigFile)
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read web search config %q: %w", p, err)
+	}
+	var conf pkgtools.WebSearchConfig
+	if err := json.Unmarshal(b, &conf); err != nil {
+		return nil, fmt.Errorf("unmarshal web search config %q: %w", p, err)
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("web search config %q: %w", p, err)
+	}
+	return &conf, nil
+}
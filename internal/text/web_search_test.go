@@ -0,0 +1,35 @@
+package text
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_loadWebSearchConfig(t *testing.T) {
+	dir := t.TempDir()
+	conf, err := loadWebSearchConfig(dir)
+	if err != nil || conf != nil {
+		t.Fatalf("expected no config without the file, got %+v, %v", conf, err)
+	}
+
+	p := filepath.Join(dir, WebSearchConfigFile)
+	if err := os.WriteFile(p, []byte(`{"backend":"searxng","url":"http://localhost:8888","max_results":5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conf, err = loadWebSearchConfig(dir)
+	if err != nil {
+		t.Fatalf("loadWebSearchConfig: %v", err)
+	}
+	if conf.Backend != "searxng" || conf.URL != "http://localhost:8888" || conf.MaxResults != 5 {
+		t.Fatalf("unexpected config %+v", conf)
+	}
+
+	if err := os.WriteFile(p, []byte(`{"backend":"bing"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadWebSearchConfig(dir); err == nil || !strings.Contains(err.Error(), "unknown backend") {
+		t.Fatalf("expected the invalid backend to fail, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/baalimago/clai/internal/debugflags"
@@ -18,57 +19,78 @@ type contextualTool interface {
 // Registry is the global registry of available LLM tools.
 var Registry = NewRegistry()
 
+// Catalog is the read side of a tool registry: the set of tools a run can
+// select from and fall back to at dispatch. The global Registry and the
+// registries returned by NewLocalRegistry implement it.
+type Catalog interface {
+	Get(name string) (pub_models.LLMTool, bool)
+	All() map[string]pub_models.LLMTool
+}
+
 // Init initializes the global Registry with available local LLM tools.
 // If the Registry has already been initialized, it simply returns. It is safe
 // to call concurrently; the registration happens exactly once and every caller
 // blocks until it has completed.
 func Init() {
-	Registry.initOnce.Do(registerLocalTools)
+	Registry.initOnce.Do(func() { registerLocalTools(Registry) })
+}
+
+// NewLocalRegistry returns a fresh registry holding every local LLM tool. It
+// lets an embedded agent own its tool catalog instead of sharing the
+// process-global Registry with every other agent in the process.
+func NewLocalRegistry() *registry {
+	r := NewRegistry()
+	registerLocalTools(r)
+	return r
 }
 
-// registerLocalTools adds every locally available LLM tool to the global
-// Registry. Only called via Init, which guarantees single execution.
-func registerLocalTools() {
-	Registry.Set(tools.FileTree.Specification().Name, tools.FileTree)
-	Registry.Set(tools.Cat.Specification().Name, tools.Cat)
-	Registry.Set(tools.Find.Specification().Name, tools.Find)
-	Registry.Set(tools.FileType.Specification().Name, tools.FileType)
-	Registry.Set(tools.LS.Specification().Name, tools.LS)
-	Registry.Set(tools.Mkdir.Specification().Name, tools.Mkdir)
-	Registry.Set(tools.WebsiteText.Specification().Name, tools.WebsiteText)
-	Registry.Set(tools.RipGrep.Specification().Name, tools.RipGrep)
-	Registry.Set(tools.Go.Specification().Name, tools.Go)
-	Registry.Set(tools.WriteFile.Specification().Name, tools.WriteFile)
-	Registry.Set(tools.ApplyPatch.Specification().Name, tools.ApplyPatch)
-	Registry.Set(tools.Cmd.Specification().Name, tools.Cmd)
+// registerLocalTools adds every locally available LLM tool to r.
+func registerLocalTools(r *registry) {
+	r.Set(tools.FileTree.Specification().Name, tools.FileTree)
+	r.Set(tools.Cat.Specification().Name, tools.Cat)
+	r.Set(tools.Find.Specification().Name, tools.Find)
+	r.Set(tools.FileType.Specification().Name, tools.FileType)
+	r.Set(tools.LS.Specification().Name, tools.LS)
+	r.Set(tools.Mkdir.Specification().Name, tools.Mkdir)
+	r.Set(tools.WebsiteText.Specification().Name, tools.WebsiteText)
+	r.Set(tools.HTTPRequest.Specification().Name, tools.HTTPRequest)
+	r.Set(tools.WebSearch.Specification().Name, tools.WebSearch)
+	r.Set(tools.ViewImage.Specification().Name, tools.ViewImage)
+	r.Set(tools.RipGrep.Specification().Name, tools.RipGrep)
+	r.Set(tools.Go.Specification().Name, tools.Go)
+	r.Set(tools.GoSymbols.Specification().Name, tools.GoSymbols)
+	r.Set(tools.WriteFile.Specification().Name, tools.WriteFile)
+	r.Set(tools.ApplyPatch.Specification().Name, tools.ApplyPatch)
+	r.Set(tools.StrReplace.Specification().Name, tools.StrReplace)
+	r.Set(tools.Cmd.Specification().Name, tools.Cmd)
 	// Legacy production alias: freetext_command predates the cmd name and is
 	// the same freetext shell tool. It stays resolvable and selectable, and
 	// the clai tools listing groups it under cmd.
-	Registry.SetAlias("freetext_command", tools.Cmd.Specification().Name, tools.Cmd)
-	Registry.Set(tools.Sed.Specification().Name, tools.Sed)
-	Registry.Set(tools.RowsBetween.Specification().Name, tools.RowsBetween)
-	Registry.Set(tools.LineCount.Specification().Name, tools.LineCount)
-	Registry.Set(tools.Git.Specification().Name, tools.Git)
-	Registry.Set(tools.FFProbe.Specification().Name, tools.FFProbe)
-	Registry.Set(tools.Date.Specification().Name, tools.Date)
-	Registry.Set(tools.Pwd.Specification().Name, tools.Pwd)
-	Registry.Set(tools.ClaiHelp.Specification().Name, tools.ClaiHelp)
-	Registry.Set(tools.ClaiRun.Specification().Name, tools.ClaiRun)
-	Registry.Set(tools.ClaiCheck.Specification().Name, tools.ClaiCheck)
-	Registry.Set(tools.ClaiResult.Specification().Name, tools.ClaiResult)
-	Registry.Set(tools.ClaiWaitForWorkers.Specification().Name, tools.ClaiWaitForWorkers)
-	Registry.Set(tools.AsyncCmdRun.Specification().Name, tools.AsyncCmdRun)
+	r.SetAlias("freetext_command", tools.Cmd.Specification().Name, tools.Cmd)
+	r.Set(tools.Sed.Specification().Name, tools.Sed)
+	r.Set(tools.RowsBetween.Specification().Name, tools.RowsBetween)
+	r.Set(tools.LineCount.Specification().Name, tools.LineCount)
+	r.Set(tools.Git.Specification().Name, tools.Git)
+	r.Set(tools.FFProbe.Specification().Name, tools.FFProbe)
+	r.Set(tools.Date.Specification().Name, tools.Date)
+	r.Set(tools.Pwd.Specification().Name, tools.Pwd)
+	r.Set(tools.ClaiHelp.Specification().Name, tools.ClaiHelp)
+	r.Set(tools.ClaiRun.Specification().Name, tools.ClaiRun)
+	r.Set(tools.ClaiCheck.Specification().Name, tools.ClaiCheck)
+	r.Set(tools.ClaiResult.Specification().Name, tools.ClaiResult)
+	r.Set(tools.ClaiWaitForWorkers.Specification().Name, tools.ClaiWaitForWorkers)
+	r.Set(tools.AsyncCmdRun.Specification().Name, tools.AsyncCmdRun)
 	// Legacy production alias: async_cmd_run predates the async_cmd rename.
 	// Existing callers, tool-glob selections, and mock-vendor traffic keep
 	// working; async_cmd is the canonical name and the clai tools listing
 	// groups the alias under it.
-	Registry.SetAlias("async_cmd_run", tools.AsyncCmdRun.Specification().Name, tools.AsyncCmdRun)
-	Registry.Set(tools.AsyncCmdStatus.Specification().Name, tools.AsyncCmdStatus)
-	Registry.Set(tools.AsyncCmdLogs.Specification().Name, tools.AsyncCmdLogs)
-	Registry.Set(tools.AsyncCmdAwait.Specification().Name, tools.AsyncCmdAwait)
-	Registry.Set(tools.AsyncCmdCancel.Specification().Name, tools.AsyncCmdCancel)
-	Registry.Set(tools.LoadSkill.Specification().Name, tools.LoadSkill)
-	Registry.Set(tools.Date.Specification().Name, tools.Date)
+	r.SetAlias("async_cmd_run", tools.AsyncCmdRun.Specification().Name, tools.AsyncCmdRun)
+	r.Set(tools.AsyncCmdStatus.Specification().Name, tools.AsyncCmdStatus)
+	r.Set(tools.AsyncCmdLogs.Specification().Name, tools.AsyncCmdLogs)
+	r.Set(tools.AsyncCmdAwait.Specification().Name, tools.AsyncCmdAwait)
+	r.Set(tools.AsyncCmdCancel.Specification().Name, tools.AsyncCmdCancel)
+	r.Set(tools.LoadSkill.Specification().Name, tools.LoadSkill)
+	r.Set(tools.Date.Specification().Name, tools.Date)
 }
 
 // Invoke the call, and gather both error and output in the same string.
@@ -85,38 +107,65 @@ func Invoke(ctx context.Context, call pub_models.Call) string {
 // (MCP tools, WithTools instances) scoped to the agent that registered them
 // instead of whatever instance another concurrent Setup wrote last.
 func InvokeWith(ctx context.Context, call pub_models.Call, toolset map[string]pub_models.LLMTool) string {
-	var t pub_models.LLMTool
-	var exists bool
-	if toolset != nil {
-		t, exists = toolset[call.Name]
-	}
-	if !exists {
-		t, exists = Registry.Get(call.Name)
-	}
-	if !exists {
+	return InvokeThrough(ctx, call, Handler(toolset, nil))
+}
+
+// InvokeThrough runs the call through handler and folds a returned error into
+// the output using the "ERROR: " convention. handler is normally Handler,
+// optionally wrapped by tool middlewares.
+func InvokeThrough(ctx context.Context, call pub_models.Call, handler pub_models.ToolHandler) string {
+	out, err := handler(ctx, call)
+	if errors.Is(err, ErrUnknownTool) {
 		return "ERROR: unknown tool call: " + call.Name
 	}
-	if debugflags.Enabled("CALL") {
-		ancli.Noticef("Invoke call: %v", debug.IndentedJsonFmt(call))
-	}
-	inp := pub_models.Input{}
-	if call.Inputs != nil {
-		inp = *call.Inputs
-	}
-	if ct, ok := t.(contextualTool); ok {
-		out, err := ct.CallWithContext(ctx, inp)
-		if err != nil {
-			return fmt.Sprintf("ERROR: failed to run tool: %v, error: %v", call.Name, err)
-		}
-		return out
-	}
-	out, err := t.Call(inp)
 	if err != nil {
 		return fmt.Sprintf("ERROR: failed to run tool: %v, error: %v", call.Name, err)
 	}
 	return out
 }
 
+// ErrUnknownTool is returned by the Handler when the call names a tool which
+// is neither in the per-run toolset nor in the global Registry.
+var ErrUnknownTool = errors.New("unknown tool call")
+
+// Handler returns the innermost ToolHandler: it resolves the call against
+// toolset first and catalog second, then runs the tool, returning its raw
+// output and error. A nil catalog falls back to the global Registry.
+func Handler(toolset map[string]pub_models.LLMTool, catalog Catalog) pub_models.ToolHandler {
+	if catalog == nil {
+		catalog = Registry
+	}
+	return func(ctx context.Context, call pub_models.Call) (string, error) {
+		var t pub_models.LLMTool
+		var exists bool
+		if toolset != nil {
+			t, exists = toolset[call.Name]
+		}
+		if !exists {
+			t, exists = catalog.Get(call.Name)
+		}
+		if !exists {
+			return "", fmt.Errorf("%w: %v", ErrUnknownTool, call.Name)
+		}
+		if debugflags.Enabled("CALL") {
+			ancli.Noticef("Invoke call: %v", debug.IndentedJsonFmt(call))
+		}
+		inp := pub_models.Input{}
+		if call.Inputs != nil {
+			inp = *call.Inputs
+		}
+		if ct, ok := t.(contextualTool); ok {
+			return ct.CallWithContext(ctx, inp)
+		}
+		// A plain LLMTool cannot observe ctx, but a middleware-imposed
+		// deadline which has already passed must not start it.
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		return t.Call(inp)
+	}
+}
+
 // ToolFromName looks at the static tools.Tools map
 func ToolFromName(name string) pub_models.Specification {
 	t, exists := Registry.Get(name)
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func TestInvokeThrough_FoldsHandlerResults(t *testing.T) {
+	WithTestRegistry(t, func() {
+		Registry.Set("known", newMockTool("known"))
+		toolset := map[string]pub_models.LLMTool{"scoped": newMockTool("scoped")}
+		failing := func(context.Context, pub_models.Call) (string, error) {
+			return "partial", errors.New("boom")
+		}
+
+		tests := []struct {
+			name    string
+			call    pub_models.Call
+			handler pub_models.ToolHandler
+			want    string
+		}{
+			{name: "registry tool", call: pub_models.Call{Name: "known"}, handler: Handler(nil, nil), want: "mock output"},
+			{name: "toolset tool", call: pub_models.Call{Name: "scoped"}, handler: Handler(toolset, nil), want: "mock output"},
+			{name: "unknown tool", call: pub_models.Call{Name: "ghost"}, handler: Handler(toolset, nil), want: "ERROR: unknown tool call: ghost"},
+			{name: "handler error", call: pub_models.Call{Name: "known"}, handler: failing, want: "ERROR: failed to run tool: known, error: boom"},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				if got := InvokeThrough(context.Background(), tc.call, tc.handler); got != tc.want {
+					t.Fatalf("InvokeThrough() = %q, want %q", got, tc.want)
+				}
+			})
+		}
+	})
+}
+
+func TestHandler_ExpiredContextSkipsPlainTool(t *testing.T) {
+	WithTestRegistry(t, func() {
+		Registry.Set("known", newMockTool("known"))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		out, err := Handler(nil, nil)(ctx, pub_models.Call{Name: "known"})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got out=%q err=%v", out, err)
+		}
+	})
+}
+
+func TestHandler_ResolvesAgainstCatalog(t *testing.T) {
+	WithTestRegistry(t, func() {
+		catalog := NewLocalRegistry()
+		if _, ok := catalog.Get("cat"); !ok {
+			t.Fatal("expected local tools in a fresh local registry")
+		}
+		catalog.Set("private", newMockTool("private"))
+		if got := InvokeThrough(context.Background(), pub_models.Call{Name: "private"}, Handler(nil, catalog)); got != "mock output" {
+			t.Fatalf("expected catalog tool to resolve, got %q", got)
+		}
+		if _, ok := Registry.Get("private"); ok {
+			t.Fatal("expected the local registry to stay independent of the global Registry")
+		}
+		if got := InvokeThrough(context.Background(), pub_models.Call{Name: "private"}, Handler(nil, nil)); got != "ERROR: unknown tool call: private" {
+			t.Fatalf("expected the global Registry not to know the private tool, got %q", got)
+		}
+	})
+}
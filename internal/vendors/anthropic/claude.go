@@ -9,16 +9,25 @@ import (
 )
 
 type Claude struct {
-	Model              string                     `json:"model"`
-	MaxTokens          int                        `json:"max_tokens"`
-	URL                string                     `json:"url"`
-	AnthropicVersion   string                     `json:"anthropic-version"`
-	AnthropicBeta      string                     `json:"anthropic-beta"`
-	Temperature        float64                    `json:"temperature"`
-	TopP               float64                    `json:"top_p"`
-	TopK               int                        `json:"top_k"`
-	StopSequences      []string                   `json:"stop_sequences"`
-	PrintInputCount    bool                       `json:"print_input_count"`
+	Model            string   `json:"model"`
+	MaxTokens        int      `json:"max_tokens"`
+	URL              string   `json:"url"`
+	AnthropicVersion string   `json:"anthropic-version"`
+	AnthropicBeta    string   `json:"anthropic-beta"`
+	Temperature      float64  `json:"temperature"`
+	TopP             float64  `json:"top_p"`
+	TopK             int      `json:"top_k"`
+	StopSequences    []string `json:"stop_sequences"`
+	PrintInputCount  bool     `json:"print_input_count"`
+	// ThinkingBudgetTokens enables extended thinking with this budget, at least
+	// 1024 and, unless InterleavedThinking is set, below MaxTokens. 0 disables
+	// thinking. Thinking requires the default temperature, so Temperature, TopP
+	// and TopK are not sent while it is enabled.
+	ThinkingBudgetTokens int `json:"thinking_budget_tokens"`
+	// InterleavedThinking lets Claude think between tool calls.
+	InterleavedThinking bool `json:"interleaved_thinking"`
+	// Effort is "low", "medium" or "high", for models which support it.
+	Effort             string                     `json:"effort"`
 	client             *http.Client               `json:"-"`
 	apiKey             string                     `json:"-"`
 	debug              bool                       `json:"-"`
@@ -29,6 +38,11 @@ type Claude struct {
 	functionJSON       string                     `json:"-"`
 	contentBlockType   string                     `json:"-"`
 	amInputTokens      int                        `json:"-"`
+	// thinking is the thinking block being streamed, thinkingBlocks those
+	// completed in this response. They are attached to the tool call so the
+	// next turn of the tool loop can replay them.
+	thinking       pub_models.ReasoningItem   `json:"-"`
+	thinkingBlocks []pub_models.ReasoningItem `json:"-"`
 }
 
 var Default = Claude{
@@ -52,6 +66,17 @@ type claudeReq struct {
 	TopK          int                        `json:"top_k,omitempty"`
 	StopSequences []string                   `json:"stop_sequences,omitempty"`
 	Tools         []pub_models.Specification `json:"tools,omitempty"`
+	Thinking      *thinkingParam             `json:"thinking,omitempty"`
+	OutputConfig  *outputConfig              `json:"output_config,omitempty"`
+}
+
+type thinkingParam struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+type outputConfig struct {
+	Effort string `json:"effort"`
 }
 
 // claudifyMessages converts from 'normal' openai chat format into a format which claud prefers
@@ -75,44 +100,99 @@ func claudifyMessages(msgs []pub_models.Message) []ClaudeConvMessage {
 			role = "assistant"
 		}
 
-		var contentBlock any
+		var contentBlocks []any
 
 		if len(msg.ToolCalls) > 0 {
+			// Thinking blocks must precede the tool use they led to, signed as
+			// streamed, or Claude cannot continue its reasoning.
+			contentBlocks = thinkingContentBlocks(msg.ReasoningItems)
 			toolCallMsg := msg.ToolCalls[0]
 			tmp := make(map[string]any)
 			if toolCallMsg.Inputs != nil {
 				tmp = make(map[string]any)
 				maps.Copy(tmp, *toolCallMsg.Inputs)
 			}
-			contentBlock = ToolUseContentBlock{
+			contentBlocks = append(contentBlocks, ToolUseContentBlock{
 				Type:  "tool_use",
 				ID:    toolCallMsg.ID,
 				Name:  toolCallMsg.Name,
 				Input: &tmp,
-			}
+			})
 		} else if msg.Role == "tool" {
 			role = "user"
-			contentBlock = ToolResultContentBlock{
+			contentBlocks = []any{ToolResultContentBlock{
 				Type:      "tool_result",
 				ToolUseID: msg.ToolCallID,
 				Content:   msg.Content,
-			}
+			}}
+		} else if len(msg.ContentParts) > 0 {
+			contentBlocks = contentPartBlocks(msg.ContentParts)
 		} else {
-			contentBlock = TextContentBlock{
+			contentBlocks = []any{TextContentBlock{
 				Type: "text",
 				Text: strings.TrimSpace(msg.Content),
-			}
+			}}
 		}
 
 		if len(ret) > 0 && ret[len(ret)-1].Role == role {
-			ret[len(ret)-1].Content = append(ret[len(ret)-1].Content, contentBlock)
+			ret[len(ret)-1].Content = append(ret[len(ret)-1].Content, contentBlocks...)
 		} else {
 			ret = append(ret, ClaudeConvMessage{
 				Role:    role,
-				Content: []any{contentBlock},
+				Content: contentBlocks,
+			})
+		}
+	}
+
+	return ret
+}
+
+// contentPartBlocks returns the content blocks of the parts of a message.
+// Images are sent as image blocks, PDFs as document blocks and other
+// documents as their extracted text.
+func contentPartBlocks(parts []pub_models.ImageOrTextInput) []any {
+	var ret []any
+	for _, p := range parts {
+		switch {
+		case p.Type == string(pub_models.Image) && p.ImageB64 != nil:
+			ret = append(ret, ImageContentBlock{
+				Type: "image",
+				Source: Base64ContentSource{
+					Type:      "base64",
+					MediaType: p.ImageB64.MIMEType,
+					Data:      p.ImageB64.RawB64,
+				},
 			})
+		case p.File != nil && p.File.MIMEType() == "application/pdf":
+			ret = append(ret, DocumentContentBlock{
+				Type: "document",
+				Source: Base64ContentSource{
+					Type:      "base64",
+					MediaType: "application/pdf",
+					Data:      p.File.RawB64(),
+				},
+				Title: p.File.Filename,
+			})
+		case p.File != nil:
+			ret = append(ret, TextContentBlock{Type: "text", Text: p.File.AsText().Text})
+		case p.Text != "":
+			ret = append(ret, TextContentBlock{Type: "text", Text: strings.TrimSpace(p.Text)})
 		}
 	}
+	return ret
+}
 
+// thinkingContentBlocks returns the Anthropic thinking blocks of items. Other
+// vendors' reasoning items are skipped.
+func thinkingContentBlocks(items []pub_models.ReasoningItem) []any {
+	var ret []any
+	for _, ri := range pub_models.ReasoningItemsOf(items, pub_models.ReasoningVendorAnthropic) {
+		switch ri.Type {
+		case pub_models.ReasoningThinking:
+			ret = append(ret, ThinkingContentBlock{Type: ri.Type, Thinking: ri.Thinking, Signature: ri.Signature})
+		case pub_models.ReasoningRedactedThinking:
+			ret = append(ret, ThinkingContentBlock{Type: ri.Type, Data: ri.Data})
+		}
+	}
 	return ret
 }
@@ -61,11 +61,41 @@ type ToolResultContentBlock struct {
 	ToolUseID string `json:"tool_use_id"`
 }
 
+// ThinkingContentBlock is a thinking or redacted_thinking block, streamed by
+// content_block_start and replayed in later requests.
+type ThinkingContentBlock struct {
+	Type      string `json:"type"`
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
 type TextContentBlock struct {
 	Type string `json:"type"`
 	Text string `json:"text"`
 }
 
+// DocumentContentBlock is a document, such as a PDF, which Claude reads
+// page by page including its images.
+type DocumentContentBlock struct {
+	Type   string              `json:"type"`
+	Source Base64ContentSource `json:"source"`
+	Title  string              `json:"title,omitempty"`
+}
+
+// ImageContentBlock is an image, inlined as base64.
+type ImageContentBlock struct {
+	Type   string              `json:"type"`
+	Source Base64ContentSource `json:"source"`
+}
+
+// Base64ContentSource is the inline data of a document or image block.
+type Base64ContentSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
 type Root struct {
 	Type         string              `json:"type"`
 	Index        int                 `json:"index"`
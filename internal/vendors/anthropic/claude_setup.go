@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/baalimago/clai/internal/debugflags"
+	"github.com/baalimago/clai/internal/models"
 	pub_models "github.com/baalimago/clai/pkg/text/models"
 )
 
@@ -14,6 +16,9 @@ func (c *Claude) Setup() error {
 	if apiKey == "" {
 		return fmt.Errorf("environment variable 'ANTHROPIC_API_KEY' not set")
 	}
+	if err := c.validateThinking(); err != nil {
+		return err
+	}
 	c.client = &http.Client{}
 	c.apiKey = apiKey
 	if debugflags.EnabledEnv("ANTHROPIC_DEBUG") {
@@ -25,3 +30,68 @@ func (c *Claude) Setup() error {
 func (c *Claude) RegisterTool(tool pub_models.LLMTool) {
 	c.tools = append(c.tools, tool.Specification())
 }
+
+// SupportsVision implements models.VisionSupporter, every model since Claude 3
+// reads images.
+func (c *Claude) SupportsVision() bool {
+	return !strings.HasPrefix(c.Model, "claude-2") && !strings.HasPrefix(c.Model, "claude-instant")
+}
+
+// minThinkingBudgetTokens is the smallest thinking budget the API accepts.
+const minThinkingBudgetTokens = 1024
+
+// Beta headers of the thinking features.
+const (
+	interleavedThinkingBeta = "interleaved-thinking-2025-05-14"
+	effortBeta              = "effort-2025-11-24"
+)
+
+// SetThinking implements models.ThinkingConfigurer, overriding the model
+// config with the set fields of a profile.
+func (c *Claude) SetThinking(t models.Thinking) {
+	if t.BudgetTokens != 0 {
+		c.ThinkingBudgetTokens = t.BudgetTokens
+	}
+	if t.Interleaved {
+		c.InterleavedThinking = true
+	}
+	if t.Effort != "" {
+		c.Effort = t.Effort
+	}
+}
+
+func (c *Claude) validateThinking() error {
+	switch c.Effort {
+	case "", "low", "medium", "high":
+	default:
+		return fmt.Errorf("invalid effort %q, expected low, medium or high", c.Effort)
+	}
+	if c.ThinkingBudgetTokens == 0 {
+		return nil
+	}
+	if c.ThinkingBudgetTokens < minThinkingBudgetTokens {
+		return fmt.Errorf("thinking_budget_tokens must be at least %d, got %d", minThinkingBudgetTokens, c.ThinkingBudgetTokens)
+	}
+	// Interleaved thinking may spend the budget across the whole tool loop,
+	// so only a single response is bounded by max_tokens.
+	if !c.InterleavedThinking && c.ThinkingBudgetTokens >= c.MaxTokens {
+		return fmt.Errorf("thinking_budget_tokens (%d) must be below max_tokens (%d)", c.ThinkingBudgetTokens, c.MaxTokens)
+	}
+	return nil
+}
+
+// betaHeader joins the configured anthropic-beta value with the betas the
+// thinking settings need.
+func (c *Claude) betaHeader() string {
+	var betas []string
+	if c.AnthropicBeta != "" {
+		betas = append(betas, c.AnthropicBeta)
+	}
+	if c.InterleavedThinking && c.ThinkingBudgetTokens > 0 {
+		betas = append(betas, interleavedThinkingBeta)
+	}
+	if c.Effort != "" {
+		betas = append(betas, effortBeta)
+	}
+	return strings.Join(betas, ",")
+}
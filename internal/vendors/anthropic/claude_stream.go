@@ -27,6 +27,7 @@ func (c *Claude) StreamCompletions(ctx context.Context, chat pub_models.Chat) (c
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct request: %w", err)
 	}
+	c.thinkingBlocks = nil
 	if _, err = c.CountInputTokens(ctx, chat); err != nil {
 		return nil, fmt.Errorf("failed to count input tokens: %w", err)
 	}
@@ -270,6 +271,15 @@ func (c *Claude) constructRequest(ctx context.Context, chat pub_models.Chat) (*h
 	if len(c.tools) > 0 {
 		reqData.Tools = c.tools
 	}
+	if c.ThinkingBudgetTokens > 0 {
+		reqData.Thinking = &thinkingParam{Type: "enabled", BudgetTokens: c.ThinkingBudgetTokens}
+		reqData.Temperature = 0
+		reqData.TopP = 0
+		reqData.TopK = 0
+	}
+	if c.Effort != "" {
+		reqData.OutputConfig = &outputConfig{Effort: c.Effort}
+	}
 	jsonData, err := json.Marshal(reqData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal ClaudeReq: %w", err)
@@ -281,6 +291,9 @@ func (c *Claude) constructRequest(ctx context.Context, chat pub_models.Chat) (*h
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", c.apiKey)
 	req.Header.Set("anthropic-version", c.AnthropicVersion)
+	if beta := c.betaHeader(); beta != "" {
+		req.Header.Set("anthropic-beta", beta)
+	}
 	if c.debug && debugflags.Enabled("VERBOSE") {
 		ancli.PrintOK(fmt.Sprintf("Request: %+v\n", req))
 	}
@@ -22,6 +22,15 @@ func (c *Claude) handleContentBlockStart(blockStart string) models.CompletionEve
 	case "tool_use":
 		c.functionName = block.Name
 		c.functionID = block.ID
+	case pub_models.ReasoningThinking, pub_models.ReasoningRedactedThinking:
+		var thinkingStart struct {
+			ContentBlock ThinkingContentBlock `json:"content_block"`
+		}
+		if err := json.Unmarshal([]byte(blockStart), &thinkingStart); err != nil {
+			return fmt.Errorf("failed to unmarshal thinking blockStart: %w", err)
+		}
+		tb := thinkingStart.ContentBlock
+		c.thinking = pub_models.ReasoningItem{Vendor: pub_models.ReasoningVendorAnthropic, Type: tb.Type, Thinking: tb.Thinking, Signature: tb.Signature, Data: tb.Data}
 	}
 	return models.NoopEvent{}
 }
@@ -58,8 +67,10 @@ func (c *Claude) handleContentBlockDelta(deltaToken string) models.CompletionEve
 	case "input_json_delta":
 		return c.handleInputJSONDelta(delta)
 	case "thinking_delta":
+		c.thinking.Thinking += delta.Thinking
 		return models.ReasoningEvent{Content: delta.Thinking}
 	case "signature_delta":
+		c.thinking.Signature += delta.Signature
 		return models.NoopEvent{}
 	default:
 		return fmt.Errorf("unexpected delta type: %v", delta.Type)
@@ -92,10 +103,14 @@ func (c *Claude) handleContentBlockStop(blockStop string) models.CompletionEvent
 			}
 		}
 		return pub_models.Call{
-			Name:   c.functionName,
-			Inputs: &inputs,
-			ID:     c.functionID,
+			Name:           c.functionName,
+			Inputs:         &inputs,
+			ID:             c.functionID,
+			ReasoningItems: c.thinkingBlocks,
 		}
+	case pub_models.ReasoningThinking, pub_models.ReasoningRedactedThinking:
+		c.thinkingBlocks = append(c.thinkingBlocks, c.thinking)
+		c.thinking = pub_models.ReasoningItem{}
 	}
 	return models.NoopEvent{}
 }
@@ -22,6 +22,38 @@ func Test_claudifyMessages(t *testing.T) {
 				{Role: "user", Content: []any{TextContentBlock{Type: "text", Text: "Hello"}}},
 			},
 		},
+		{
+			name: "Documents",
+			msgs: []pub_models.Message{
+				{Role: "user", ContentParts: []pub_models.ImageOrTextInput{
+					{Type: "file", File: &pub_models.FileInput{Filename: "a.pdf", FileData: "data:application/pdf;base64,JVBERi0=", Text: "pdf text"}},
+					{Type: "text", Text: "Contents of attached file b.docx:\ndocx text"},
+					{Type: "text", Text: " Summarise "},
+				}},
+			},
+			want: []ClaudeConvMessage{
+				{Role: "user", Content: []any{
+					DocumentContentBlock{Type: "document", Source: Base64ContentSource{Type: "base64", MediaType: "application/pdf", Data: "JVBERi0="}, Title: "a.pdf"},
+					TextContentBlock{Type: "text", Text: "Contents of attached file b.docx:\ndocx text"},
+					TextContentBlock{Type: "text", Text: "Summarise"},
+				}},
+			},
+		},
+		{
+			name: "Image after tool result",
+			msgs: []pub_models.Message{
+				{Role: "tool", ToolCallID: "tool1", Content: "Loaded plot.png"},
+				{Role: "user", ContentParts: []pub_models.ImageOrTextInput{
+					{Type: "image_url", ImageB64: &pub_models.ImageURL{URL: "data:image/png;base64,iVBORw0=", RawB64: "iVBORw0=", MIMEType: "image/png"}},
+				}},
+			},
+			want: []ClaudeConvMessage{
+				{Role: "user", Content: []any{
+					ToolResultContentBlock{Type: "tool_result", ToolUseID: "tool1", Content: "Loaded plot.png"},
+					ImageContentBlock{Type: "image", Source: Base64ContentSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0="}},
+				}},
+			},
+		},
 		{
 			name: "Multiple text messages same role",
 			msgs: []pub_models.Message{
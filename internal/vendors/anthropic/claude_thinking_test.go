@@ -0,0 +1,155 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/baalimago/clai/internal/models"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func Test_StreamCompletions_ThinkingBlocksRideTheToolCall(t *testing.T) {
+	events := []string{
+		`event: content_block_start
+data: {"type": "content_block_start", "index": 0, "content_block": {"type": "thinking", "thinking": "", "signature": ""}}`,
+		`event: content_block_delta
+data: {"type": "content_block_delta", "index": 0, "delta": {"type": "thinking_delta", "thinking": "List it."}}`,
+		`event: content_block_delta
+data: {"type": "content_block_delta", "index": 0, "delta": {"type": "signature_delta", "signature": "sig-1"}}`,
+		`event: content_block_stop
+data: {"type": "content_block_stop", "index": 0}`,
+		`event: content_block_start
+data: {"type": "content_block_start", "index": 1, "content_block": {"type": "redacted_thinking", "data": "opaque"}}`,
+		`event: content_block_stop
+data: {"type": "content_block_stop", "index": 1}`,
+		`event: content_block_start
+data: {"type": "content_block_start", "index": 2, "content_block": {"type": "tool_use", "id": "toolu_1", "name": "ls", "input": {}}}`,
+		`event: content_block_delta
+data: {"type": "content_block_delta", "index": 2, "delta": {"type": "input_json_delta", "partial_json": "{\"dir\":\"/\"}"}}`,
+		`event: content_block_stop
+data: {"type": "content_block_stop", "index": 2}`,
+	}
+	var gotHeader http.Header
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &gotBody)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			io.WriteString(w, e+"\n\n")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv("ANTHROPIC_API_KEY", "somekey")
+	c := Default
+	c.URL = srv.URL
+	c.ThinkingBudgetTokens = 2048
+	c.InterleavedThinking = true
+	if err := c.Setup(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	out, err := c.StreamCompletions(context.Background(), pub_models.Chat{Messages: []pub_models.Message{{Role: "user", Content: "ls /"}}})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	var call pub_models.Call
+	for ev := range out {
+		if v, ok := ev.(pub_models.Call); ok {
+			call = v
+		}
+	}
+
+	want := []pub_models.ReasoningItem{
+		{Vendor: pub_models.ReasoningVendorAnthropic, Type: pub_models.ReasoningThinking, Thinking: "List it.", Signature: "sig-1"},
+		{Vendor: pub_models.ReasoningVendorAnthropic, Type: pub_models.ReasoningRedactedThinking, Data: "opaque"},
+	}
+	if !reflect.DeepEqual(call.ReasoningItems, want) {
+		t.Fatalf("expected the thinking blocks on the call, got %+v", call.ReasoningItems)
+	}
+	thinking, _ := gotBody["thinking"].(map[string]any)
+	if thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(2048) {
+		t.Fatalf("unexpected thinking param %#v", gotBody["thinking"])
+	}
+	if _, ok := gotBody["temperature"]; ok {
+		t.Fatalf("temperature must not be sent with thinking, got %#v", gotBody["temperature"])
+	}
+	if !strings.Contains(gotHeader.Get("anthropic-beta"), interleavedThinkingBeta) {
+		t.Fatalf("expected the interleaved thinking beta, got %q", gotHeader.Get("anthropic-beta"))
+	}
+}
+
+func Test_claudifyMessages_ReplaysThinkingBeforeToolUse(t *testing.T) {
+	got := claudifyMessages([]pub_models.Message{
+		{Role: "user", Content: "ls /"},
+		{
+			Role:      "assistant",
+			ToolCalls: []pub_models.Call{{ID: "toolu_1", Name: "ls"}},
+			ReasoningItems: []pub_models.ReasoningItem{
+				{ID: "rs_openai", EncryptedContent: "sealed"},
+				{Type: pub_models.ReasoningThinking, Thinking: "List it.", Signature: "sig-1"},
+			},
+		},
+		{Role: "tool", ToolCallID: "toolu_1", Content: "bin"},
+	})
+	if len(got) != 3 {
+		t.Fatalf("expected 3 messages, got %+v", got)
+	}
+	blocks := got[1].Content
+	if len(blocks) != 2 {
+		t.Fatalf("expected the thinking block and the tool use, skipping OpenAI items, got %+v", blocks)
+	}
+	if tb, ok := blocks[0].(ThinkingContentBlock); !ok || tb.Signature != "sig-1" || tb.Thinking != "List it." {
+		t.Fatalf("expected the signed thinking block first, got %#v", blocks[0])
+	}
+	if _, ok := blocks[1].(ToolUseContentBlock); !ok {
+		t.Fatalf("expected the tool use after the thinking, got %#v", blocks[1])
+	}
+}
+
+func TestSetup_ValidatesThinking(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "k")
+	tests := []struct {
+		name    string
+		mutate  func(*Claude)
+		wantErr string
+	}{
+		{"budget too small", func(c *Claude) { c.ThinkingBudgetTokens = 100 }, "at least 1024"},
+		{"budget above max tokens", func(c *Claude) { c.ThinkingBudgetTokens = 10000; c.MaxTokens = 8192 }, "below max_tokens"},
+		{"interleaved may exceed max tokens", func(c *Claude) {
+			c.ThinkingBudgetTokens = 10000
+			c.MaxTokens = 8192
+			c.InterleavedThinking = true
+		}, ""},
+		{"unknown effort", func(c *Claude) { c.Effort = "max" }, "invalid effort"},
+		{"profile overrides", func(c *Claude) {
+			c.SetThinking(models.Thinking{BudgetTokens: 4096, Effort: "high"})
+			if c.ThinkingBudgetTokens != 4096 || c.Effort != "high" || c.betaHeader() != effortBeta {
+				t.Errorf("profile settings not applied: %+v, beta %q", c, c.betaHeader())
+			}
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default
+			tt.mutate(&c)
+			err := c.Setup()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
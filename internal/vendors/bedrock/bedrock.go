@@ -0,0 +1,116 @@
+// Package bedrock queries models hosted on Amazon Bedrock through the
+// ConverseStream API, selected with "bedrock:<model id>" such as
+// "bedrock:anthropic.claude-sonnet-4-5-20250929-v1:0" or an inference profile
+// such as "bedrock:us.meta.llama3-3-70b-instruct-v1:0".
+//
+// Requests are signed with AWS Signature Version 4 using the credentials and
+// region of the standard AWS environment variables and shared config files,
+// so no AWS SDK is needed. Responses use the binary AWS event stream framing,
+// decoded in eventstream.go.
+package bedrock
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/baalimago/clai/internal/debugflags"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+type Bedrock struct {
+	Model string `json:"model"`
+	// Region of the Bedrock runtime. Empty uses AWS_REGION, AWS_DEFAULT_REGION
+	// or the region of the profile in the shared config file.
+	Region string `json:"region"`
+	// Profile of the shared credentials and config files. Empty uses
+	// AWS_PROFILE, or "default".
+	Profile string `json:"profile"`
+	// Endpoint replaces https://bedrock-runtime.<region>.amazonaws.com, for
+	// VPC endpoints and proxies.
+	Endpoint      string   `json:"endpoint"`
+	MaxTokens     int      `json:"max_tokens"`
+	Temperature   float64  `json:"temperature"`
+	TopP          float64  `json:"top_p"`
+	StopSequences []string `json:"stop_sequences"`
+
+	client *http.Client
+	creds  credentials
+	region string
+	url    string
+	debug  bool
+	tools  []pub_models.Specification
+	usage  *pub_models.Usage
+}
+
+var Default = Bedrock{
+	MaxTokens:     8192,
+	Temperature:   0.5,
+	StopSequences: make([]string, 0),
+}
+
+func (b *Bedrock) Setup() error {
+	b.Model = strings.TrimPrefix(b.Model, "bedrock:")
+	if b.Model == "" {
+		return fmt.Errorf("bedrock: missing model id, use a model such as bedrock:<model id>")
+	}
+	profile := awsProfile(b.Profile)
+	creds, err := loadCredentials(profile)
+	if err != nil {
+		return fmt.Errorf("bedrock: %w", err)
+	}
+	region := b.Region
+	if region == "" {
+		region = loadRegion(profile)
+	}
+	if region == "" {
+		return fmt.Errorf("bedrock: missing region, set \"region\" in the model config or AWS_REGION")
+	}
+	endpoint := b.Endpoint
+	if endpoint == "" {
+		endpoint = "https://bedrock-runtime." + region + ".amazonaws.com"
+	}
+	b.client = &http.Client{}
+	b.creds = creds
+	b.region = region
+	b.url = strings.TrimSuffix(endpoint, "/") + "/model/" + uriEncode(b.Model, true) + "/converse-stream"
+	b.debug = debugflags.EnabledEnv("DEBUG_BEDROCK")
+	return nil
+}
+
+func (b *Bedrock) RegisterTool(tool pub_models.LLMTool) {
+	b.tools = append(b.tools, tool.Specification())
+}
+
+// TokenUsage implements models.UsageTokenCounter with the usage of the
+// metadata event which ends each stream.
+func (b *Bedrock) TokenUsage() *pub_models.Usage {
+	return b.usage
+}
+
+// textOnlyModels are the prefixes of the model ids whose Converse API rejects
+// images.
+var textOnlyModels = []string{
+	"amazon.titan-text", "amazon.nova-micro", "ai21.", "cohere.", "deepseek.", "openai.", "qwen.",
+	"anthropic.claude-instant", "anthropic.claude-v2",
+	"meta.llama2", "meta.llama3-8b", "meta.llama3-70b", "meta.llama3-1-", "meta.llama3-2-1b", "meta.llama3-2-3b", "meta.llama3-3-",
+	"mistral.mistral", "mistral.mixtral",
+}
+
+// SupportsVision implements models.VisionSupporter. The geography prefix of
+// an inference profile, such as "us.", is ignored.
+func (b *Bedrock) SupportsVision() bool {
+	model := strings.TrimPrefix(b.Model, "bedrock:")
+	if geo, rest, ok := strings.Cut(model, "."); ok {
+		switch geo {
+		case "us", "us-gov", "eu", "apac", "jp", "au", "ca", "global":
+			model = rest
+		}
+	}
+	for _, p := range textOnlyModels {
+		if strings.HasPrefix(model, p) {
+			return false
+		}
+	}
+	return true
+}
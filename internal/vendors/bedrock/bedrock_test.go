@@ -0,0 +1,330 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baalimago/clai/internal/models"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// encodeFrame encodes string headers and payload as one event stream frame.
+func encodeFrame(headers [][2]string, payload string) []byte {
+	var hb []byte
+	for _, h := range headers {
+		hb = append(hb, byte(len(h[0])))
+		hb = append(hb, h[0]...)
+		hb = append(hb, 7)
+		hb = binary.BigEndian.AppendUint16(hb, uint16(len(h[1])))
+		hb = append(hb, h[1]...)
+	}
+	b := binary.BigEndian.AppendUint32(nil, uint32(12+len(hb)+len(payload)+4))
+	b = binary.BigEndian.AppendUint32(b, uint32(len(hb)))
+	b = binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b))
+	b = append(b, hb...)
+	b = append(b, payload...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b))
+}
+
+func event(eventType, payload string) []byte {
+	return encodeFrame([][2]string{
+		{":event-type", eventType},
+		{":content-type", "application/json"},
+		{":message-type", "event"},
+	}, payload)
+}
+
+func setAWSEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "us-west-2")
+}
+
+func TestSign_AWSTestSuiteVector(t *testing.T) {
+	// get-vanilla of the AWS Signature Version 4 test suite.
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	sign(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("authorization:\n got %s\nwant %s", got, want)
+	}
+}
+
+// verifySignature re-signs the request the stub server received with the
+// headers the client signed, and compares the result.
+func verifySignature(t *testing.T, r *http.Request, body []byte) {
+	t.Helper()
+	auth := r.Header.Get("Authorization")
+	_, signed, ok := strings.Cut(auth, "SignedHeaders=")
+	if !ok {
+		t.Fatalf("unsigned request, authorization %q", auth)
+	}
+	signed, _, _ = strings.Cut(signed, ",")
+	amzDate, err := time.Parse(amzDateFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		t.Fatalf("parse x-amz-date: %v", err)
+	}
+	clone, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	for _, h := range strings.Split(signed, ";") {
+		if h != "host" {
+			clone.Header.Set(h, r.Header.Get(h))
+		}
+	}
+	creds := credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		SessionToken:    r.Header.Get("X-Amz-Security-Token"),
+	}
+	sign(clone, body, creds, "us-west-2", signingService, amzDate)
+	if got := clone.Header.Get("Authorization"); got != auth {
+		t.Fatalf("signature mismatch:\n sent %s\nwant %s", auth, got)
+	}
+}
+
+func TestStreamCompletions_SignsAndDecodesTextAndToolUse(t *testing.T) {
+	setAWSEnv(t)
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+
+	var gotPath string
+	var gotBody converseReq
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifySignature(t, r, body)
+		gotPath = r.URL.EscapedPath()
+		if err := json.Unmarshal(body, &gotBody); err != nil {
+			t.Errorf("unmarshal request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		for _, f := range [][]byte{
+			event("messageStart", `{"role":"assistant"}`),
+			event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"reasoningContent":{"text":"hmm"}}}`),
+			event("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"text":"Hel"}}`),
+			event("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"text":"lo"}}`),
+			event("contentBlockStop", `{"contentBlockIndex":1}`),
+			event("contentBlockStart", `{"contentBlockIndex":2,"start":{"toolUse":{"toolUseId":"tu_1","name":"ls"}}}`),
+			event("contentBlockDelta", `{"contentBlockIndex":2,"delta":{"toolUse":{"input":"{\"dir\":"}}}`),
+			event("contentBlockDelta", `{"contentBlockIndex":2,"delta":{"toolUse":{"input":"\"/tmp\"}"}}}`),
+			event("contentBlockStop", `{"contentBlockIndex":2}`),
+			event("messageStop", `{"stopReason":"tool_use"}`),
+			event("metadata", `{"usage":{"inputTokens":12,"outputTokens":5,"totalTokens":17},"metrics":{"latencyMs":10}}`),
+		} {
+			w.Write(f)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	b := Default
+	b.Model = "bedrock:anthropic.claude-v2:1"
+	b.Endpoint = srv.URL
+	b.RegisterTool(fakeTool{pub_models.Specification{
+		Name:   "ls",
+		Inputs: &pub_models.InputSchema{Type: "object", Properties: map[string]pub_models.ParameterObject{"dir": {Type: "string"}}},
+	}})
+	if err := b.Setup(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	ch, err := b.StreamCompletions(context.Background(), pub_models.Chat{Messages: []pub_models.Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "list /tmp"},
+	}})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	var text, reasoning strings.Builder
+	var calls []pub_models.Call
+	var stopped bool
+	for ev := range ch {
+		switch v := ev.(type) {
+		case string:
+			text.WriteString(v)
+		case models.ReasoningEvent:
+			reasoning.WriteString(v.Content)
+		case pub_models.Call:
+			calls = append(calls, v)
+		case models.StopEvent:
+			stopped = true
+		case error:
+			t.Fatalf("unexpected error event: %v", v)
+		}
+	}
+
+	if gotPath != "/model/anthropic.claude-v2%3A1/converse-stream" {
+		t.Fatalf("path: got %q", gotPath)
+	}
+	if len(gotBody.System) != 1 || gotBody.System[0].Text != "be brief" || len(gotBody.Messages) != 1 {
+		t.Fatalf("unexpected request %+v", gotBody)
+	}
+	if gotBody.ToolConfig == nil || gotBody.ToolConfig.Tools[0].ToolSpec.Name != "ls" {
+		t.Fatalf("expected the tool spec, got %+v", gotBody.ToolConfig)
+	}
+	if text.String() != "Hello" || reasoning.String() != "hmm" || !stopped {
+		t.Fatalf("text %q, reasoning %q, stopped %v", text.String(), reasoning.String(), stopped)
+	}
+	if len(calls) != 1 || calls[0].ID != "tu_1" || calls[0].Name != "ls" || (*calls[0].Inputs)["dir"] != "/tmp" {
+		t.Fatalf("unexpected calls %+v", calls)
+	}
+	if u := b.TokenUsage(); u == nil || u.PromptTokens != 12 || u.CompletionTokens != 5 {
+		t.Fatalf("unexpected usage %+v", u)
+	}
+}
+
+func TestStreamCompletions_Errors(t *testing.T) {
+	setAWSEnv(t)
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{
+			name: "http error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"message":"The security token included in the request is invalid."}`))
+			},
+			wantErr: "security token included",
+		},
+		{
+			name: "exception frame",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write(encodeFrame([][2]string{
+					{":exception-type", "throttlingException"},
+					{":message-type", "exception"},
+				}, `{"message":"Too many requests"}`))
+			},
+			wantErr: "throttlingException: Too many requests",
+		},
+		{
+			name: "corrupt frame",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				f := event("contentBlockDelta", `{"delta":{"text":"x"}}`)
+				f[len(f)-1] ^= 0xff
+				w.Write(f)
+			},
+			wantErr: "checksum mismatch",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			t.Cleanup(srv.Close)
+			b := Default
+			b.Model = "meta.llama3-70b-instruct-v1:0"
+			b.Endpoint = srv.URL
+			if err := b.Setup(); err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+			ch, err := b.StreamCompletions(context.Background(), pub_models.Chat{Messages: []pub_models.Message{{Role: "user", Content: "hi"}}})
+			if err == nil {
+				for ev := range ch {
+					if e, ok := ev.(error); ok {
+						err = e
+					}
+				}
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConverseMessages(t *testing.T) {
+	inputs := pub_models.Input{"dir": "/tmp"}
+	got := converseMessages([]pub_models.Message{
+		{Role: "user", ContentParts: []pub_models.ImageOrTextInput{
+			{Type: "image_url", ImageB64: &pub_models.ImageURL{RawB64: "aWNv", MIMEType: "image/jpg"}},
+			{Type: "text", Text: "what is this?"},
+		}},
+		{Role: "assistant", ToolCalls: []pub_models.Call{
+			{ID: "a", Name: "ls", Inputs: &inputs},
+			{ID: "b", Name: "pwd"},
+		}},
+		{Role: "tool", ToolCallID: "a", Content: "file"},
+		{Role: "tool", ToolCallID: "b", Content: ""},
+		{Role: "assistant", Content: "Done."},
+	})
+	if len(got) != 4 {
+		t.Fatalf("expected alternating merged messages, got %+v", got)
+	}
+	if img := got[0].Content[0].Image; img == nil || img.Format != "jpeg" || img.Source.Bytes != "aWNv" || got[0].Content[1].Text != "what is this?" {
+		t.Fatalf("unexpected user content %+v", got[0].Content)
+	}
+	if len(got[1].Content) != 2 || got[1].Content[0].ToolUse.Input["dir"] != "/tmp" || got[1].Content[1].ToolUse.Input == nil {
+		t.Fatalf("unexpected tool uses %+v", got[1].Content)
+	}
+	results := got[2].Content
+	if got[2].Role != "user" || len(results) != 2 || results[0].ToolResult.ToolUseID != "a" || results[1].ToolResult.Content[0].Text == "" {
+		t.Fatalf("expected both tool results in one user message, got %+v", got[2])
+	}
+}
+
+func TestSetup_SharedFiles(t *testing.T) {
+	for _, env := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		t.Setenv(env, "")
+	}
+	dir := t.TempDir()
+	credsPath := filepath.Join(dir, "credentials")
+	configPath := filepath.Join(dir, "config")
+	os.WriteFile(credsPath, []byte("[default]\naws_access_key_id = DEFAULT\naws_secret_access_key = x\n\n[work]\naws_access_key_id = WORK\naws_secret_access_key = y\naws_session_token = tok\n"), 0o600)
+	os.WriteFile(configPath, []byte("[default]\nregion = us-east-1\n[profile work]\nregion = eu-central-1\n"), 0o600)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsPath)
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_PROFILE", "work")
+
+	b := Default
+	b.Model = "bedrock:amazon.nova-pro-v1:0"
+	if err := b.Setup(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if b.creds.AccessKeyID != "WORK" || b.creds.SessionToken != "tok" || b.region != "eu-central-1" {
+		t.Fatalf("unexpected credentials %+v in region %q", b.creds, b.region)
+	}
+	if b.url != "https://bedrock-runtime.eu-central-1.amazonaws.com/model/amazon.nova-pro-v1%3A0/converse-stream" {
+		t.Fatalf("unexpected url %q", b.url)
+	}
+
+	t.Setenv("AWS_PROFILE", "missing")
+	b = Default
+	b.Model = "amazon.nova-pro-v1:0"
+	if err := b.Setup(); err == nil || !strings.Contains(err.Error(), "missing AWS credentials") {
+		t.Fatalf("expected missing credentials, got %v", err)
+	}
+}
+
+type fakeTool struct {
+	spec pub_models.Specification
+}
+
+func (f fakeTool) Call(pub_models.Input) (string, error) { return "", nil }
+
+func (f fakeTool) Specification() pub_models.Specification { return f.spec }
+
+func TestSupportsVision(t *testing.T) {
+	for model, want := range map[string]bool{
+		"anthropic.claude-sonnet-4-5-20250929-v1:0":   true,
+		"us.anthropic.claude-3-5-haiku-20241022-v1:0": true,
+		"amazon.nova-pro-v1:0":                        true,
+		"us.meta.llama3-2-90b-instruct-v1:0":          true,
+		"amazon.nova-micro-v1:0":                      false,
+		"us.meta.llama3-3-70b-instruct-v1:0":          false,
+		"cohere.command-r-plus-v1:0":                  false,
+	} {
+		if got := (&Bedrock{Model: model}).SupportsVision(); got != want {
+			t.Errorf("SupportsVision(%q) = %t, want %t", model, got, want)
+		}
+	}
+}
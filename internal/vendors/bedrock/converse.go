@@ -0,0 +1,188 @@
+package bedrock
+
+import (
+	"maps"
+	"strings"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+type converseReq struct {
+	Messages        []converseMessage `json:"messages"`
+	System          []contentBlock    `json:"system,omitempty"`
+	InferenceConfig inferenceConfig   `json:"inferenceConfig"`
+	ToolConfig      *toolConfig       `json:"toolConfig,omitempty"`
+}
+
+type inferenceConfig struct {
+	MaxTokens     int      `json:"maxTokens,omitempty"`
+	Temperature   float64  `json:"temperature,omitempty"`
+	TopP          float64  `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+type converseMessage struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock is a union: exactly one field is set.
+type contentBlock struct {
+	Text       string      `json:"text,omitempty"`
+	Image      *imageBlock `json:"image,omitempty"`
+	ToolUse    *toolUse    `json:"toolUse,omitempty"`
+	ToolResult *toolResult `json:"toolResult,omitempty"`
+}
+
+type imageBlock struct {
+	Format string      `json:"format"`
+	Source imageSource `json:"source"`
+}
+
+type imageSource struct {
+	// Bytes is base64 encoded, as the JSON protocol expects blobs.
+	Bytes string `json:"bytes"`
+}
+
+type toolUse struct {
+	ToolUseID string         `json:"toolUseId"`
+	Name      string         `json:"name"`
+	Input     map[string]any `json:"input"`
+}
+
+type toolResult struct {
+	ToolUseID string         `json:"toolUseId"`
+	Content   []contentBlock `json:"content"`
+}
+
+type toolConfig struct {
+	Tools []toolEntry `json:"tools"`
+}
+
+type toolEntry struct {
+	ToolSpec toolSpec `json:"toolSpec"`
+}
+
+type toolSpec struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema inputSchema `json:"inputSchema"`
+}
+
+type inputSchema struct {
+	JSON any `json:"json"`
+}
+
+func (b *Bedrock) converseRequest(chat pub_models.Chat) converseReq {
+	req := converseReq{
+		InferenceConfig: inferenceConfig{
+			MaxTokens:     b.MaxTokens,
+			Temperature:   b.Temperature,
+			TopP:          b.TopP,
+			StopSequences: b.StopSequences,
+		},
+	}
+	msgs := chat.Messages
+	if len(msgs) > 0 && msgs[0].Role == "system" {
+		if s := strings.TrimSpace(msgs[0].Content); s != "" {
+			req.System = []contentBlock{{Text: s}}
+		}
+		msgs = msgs[1:]
+	}
+	req.Messages = converseMessages(msgs)
+	if len(b.tools) > 0 {
+		req.ToolConfig = &toolConfig{}
+		for _, t := range b.tools {
+			schema := any(map[string]any{"type": "object", "properties": map[string]any{}})
+			if t.Inputs != nil {
+				t.Inputs.Patch()
+				schema = t.Inputs
+			}
+			req.ToolConfig.Tools = append(req.ToolConfig.Tools, toolEntry{ToolSpec: toolSpec{
+				Name:        t.Name,
+				Description: t.Description,
+				InputSchema: inputSchema{JSON: schema},
+			}})
+		}
+	}
+	return req
+}
+
+// converseMessages maps clai messages to Converse messages. Converse requires
+// alternating roles, so consecutive messages of one role are merged: the
+// results of parallel tool calls become one user message. Like the anthropic
+// vendor, later system messages are sent as the assistant's.
+func converseMessages(msgs []pub_models.Message) []converseMessage {
+	var ret []converseMessage
+	for _, msg := range msgs {
+		role := msg.Role
+		var blocks []contentBlock
+		switch {
+		case msg.Role == "tool":
+			role = "user"
+			blocks = append(blocks, contentBlock{ToolResult: &toolResult{
+				ToolUseID: msg.ToolCallID,
+				Content:   []contentBlock{{Text: nonEmpty(msg.Content)}},
+			}})
+		case len(msg.ContentParts) > 0:
+			for _, p := range msg.ContentParts {
+				if p.Type == string(pub_models.Image) && p.ImageB64 != nil {
+					blocks = append(blocks, contentBlock{Image: &imageBlock{
+						Format: imageFormat(p.ImageB64.MIMEType),
+						Source: imageSource{Bytes: p.ImageB64.RawB64},
+					}})
+				} else if p.File != nil {
+					blocks = append(blocks, contentBlock{Text: p.File.AsText().Text})
+				} else if t := strings.TrimSpace(p.Text); t != "" {
+					blocks = append(blocks, contentBlock{Text: t})
+				}
+			}
+		default:
+			if t := strings.TrimSpace(msg.Content); t != "" {
+				blocks = append(blocks, contentBlock{Text: t})
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			input := map[string]any{}
+			if call.Inputs != nil {
+				maps.Copy(input, *call.Inputs)
+			}
+			blocks = append(blocks, contentBlock{ToolUse: &toolUse{
+				ToolUseID: call.ID,
+				Name:      call.Name,
+				Input:     input,
+			}})
+		}
+		if role != "user" {
+			role = "assistant"
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		if len(ret) > 0 && ret[len(ret)-1].Role == role {
+			ret[len(ret)-1].Content = append(ret[len(ret)-1].Content, blocks...)
+			continue
+		}
+		ret = append(ret, converseMessage{Role: role, Content: blocks})
+	}
+	return ret
+}
+
+// imageFormat maps a mime type to the Converse image formats: png, jpeg,
+// gif or webp.
+func imageFormat(mime string) string {
+	f := strings.TrimPrefix(mime, "image/")
+	if f == "jpg" {
+		return "jpeg"
+	}
+	return f
+}
+
+// nonEmpty replaces empty tool output, which Converse rejects as a blank
+// text block.
+func nonEmpty(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "(no output)"
+	}
+	return s
+}
@@ -0,0 +1,130 @@
+package bedrock
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// credentials are the AWS access keys requests are signed with.
+type credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+func awsProfile(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	if p := os.Getenv("AWS_PROFILE"); p != "" {
+		return p
+	}
+	return "default"
+}
+
+// loadCredentials reads the keys from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+// and AWS_SESSION_TOKEN, falling back to profile of the shared credentials
+// file (AWS_SHARED_CREDENTIALS_FILE, or ~/.aws/credentials).
+func loadCredentials(profile string) (credentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return credentials{
+			AccessKeyID:     id,
+			SecretAccessKey: secret,
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		path = awsFile("credentials")
+	}
+	section, err := readINISection(path, profile)
+	if err != nil {
+		return credentials{}, err
+	}
+	creds := credentials{
+		AccessKeyID:     section["aws_access_key_id"],
+		SecretAccessKey: section["aws_secret_access_key"],
+		SessionToken:    section["aws_session_token"],
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return credentials{}, fmt.Errorf("missing AWS credentials, set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or add profile %q to %s", profile, path)
+	}
+	return creds, nil
+}
+
+// loadRegion reads AWS_REGION, AWS_DEFAULT_REGION, then the region of profile
+// in the shared config file (AWS_CONFIG_FILE, or ~/.aws/config). Empty means
+// none is set.
+func loadRegion(profile string) string {
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if r := os.Getenv(env); r != "" {
+			return r
+		}
+	}
+	path := os.Getenv("AWS_CONFIG_FILE")
+	if path == "" {
+		path = awsFile("config")
+	}
+	// The config file names every profile but the default "profile <name>".
+	name := profile
+	if profile != "default" {
+		name = "profile " + profile
+	}
+	section, err := readINISection(path, name)
+	if err != nil {
+		return ""
+	}
+	return section["region"]
+}
+
+func awsFile(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// readINISection returns the keys of section [name] of the ini file at path.
+// A missing file or section is empty.
+func readINISection(path, name string) (map[string]string, error) {
+	ret := map[string]string{}
+	if path == "" {
+		return ret, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ret, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	defer f.Close()
+	inSection := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.TrimSpace(line[1:len(line)-1]) == name
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok {
+			ret[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return ret, nil
+}
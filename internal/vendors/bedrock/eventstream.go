@@ -0,0 +1,112 @@
+package bedrock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// maxFrameLength bounds a single event stream message, 16 MiB like the AWS
+// SDKs.
+const maxFrameLength = 16 << 20
+
+// frame is one message of the AWS event stream encoding
+// (application/vnd.amazon.eventstream):
+//
+//	total length   uint32
+//	headers length uint32
+//	prelude CRC    uint32 (CRC32 of the two lengths)
+//	headers        name length uint8, name, value type uint8, value
+//	payload
+//	message CRC    uint32 (CRC32 of everything before it)
+type frame struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// readFrame reads the next frame of r. It returns io.EOF when r ends between
+// frames.
+func readFrame(r io.Reader) (frame, error) {
+	var prelude [12]byte
+	if _, err := io.ReadFull(r, prelude[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return frame{}, fmt.Errorf("truncated event stream prelude")
+		}
+		return frame{}, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc := crc32.ChecksumIEEE(prelude[:8]); crc != binary.BigEndian.Uint32(prelude[8:12]) {
+		return frame{}, fmt.Errorf("event stream prelude checksum mismatch")
+	}
+	if totalLen > maxFrameLength || uint64(headersLen)+16 > uint64(totalLen) {
+		return frame{}, fmt.Errorf("invalid event stream frame: total length %d, headers length %d", totalLen, headersLen)
+	}
+	rest := make([]byte, totalLen-12)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return frame{}, fmt.Errorf("truncated event stream frame: %w", err)
+	}
+	body := rest[:len(rest)-4]
+	crc := crc32.Update(crc32.ChecksumIEEE(prelude[:]), crc32.IEEETable, body)
+	if crc != binary.BigEndian.Uint32(rest[len(rest)-4:]) {
+		return frame{}, fmt.Errorf("event stream message checksum mismatch")
+	}
+	headers, err := parseHeaders(body[:headersLen])
+	if err != nil {
+		return frame{}, err
+	}
+	return frame{Headers: headers, Payload: body[headersLen:]}, nil
+}
+
+// parseHeaders decodes the headers of a frame. String values are kept as is;
+// the other value types are formatted, since only ":event-type",
+// ":message-type" and ":exception-type" are read.
+func parseHeaders(b []byte) (map[string]string, error) {
+	ret := map[string]string{}
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, fmt.Errorf("truncated event stream header")
+		}
+		name := string(b[1 : 1+nameLen])
+		valueType := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case 0, 1: // bool true, bool false
+			ret[name] = fmt.Sprint(valueType == 0)
+			continue
+		case 2:
+			size = 1
+		case 3:
+			size = 2
+		case 4:
+			size = 4
+		case 5, 8: // int64, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // bytes, string
+			if len(b) < 2 {
+				return nil, fmt.Errorf("truncated event stream header %q", name)
+			}
+			size = int(binary.BigEndian.Uint16(b))
+			b = b[2:]
+		default:
+			return nil, fmt.Errorf("unknown event stream header type %d of %q", valueType, name)
+		}
+		if len(b) < size {
+			return nil, fmt.Errorf("truncated event stream header %q", name)
+		}
+		if valueType == 6 || valueType == 7 {
+			ret[name] = string(b[:size])
+		} else {
+			ret[name] = fmt.Sprintf("%x", b[:size])
+		}
+		b = b[size:]
+	}
+	return ret, nil
+}
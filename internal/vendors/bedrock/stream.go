@@ -0,0 +1,200 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/baalimago/clai/internal/models"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
+	"github.com/baalimago/go_away_boilerplate/pkg/debug"
+)
+
+// streamEvent is the payload of the ConverseStream events read: the field
+// set depends on the ":event-type" header.
+type streamEvent struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+	Start             struct {
+		ToolUse *struct {
+			ToolUseID string `json:"toolUseId"`
+			Name      string `json:"name"`
+		} `json:"toolUse"`
+	} `json:"start"`
+	Delta struct {
+		Text    string `json:"text"`
+		ToolUse *struct {
+			Input string `json:"input"`
+		} `json:"toolUse"`
+		ReasoningContent *struct {
+			Text string `json:"text"`
+		} `json:"reasoningContent"`
+	} `json:"delta"`
+	StopReason string `json:"stopReason"`
+	Usage      *struct {
+		InputTokens          int `json:"inputTokens"`
+		OutputTokens         int `json:"outputTokens"`
+		TotalTokens          int `json:"totalTokens"`
+		CacheReadInputTokens int `json:"cacheReadInputTokens"`
+	} `json:"usage"`
+	Message string `json:"message"`
+}
+
+// pendingToolUse collects the streamed input of a toolUse content block.
+type pendingToolUse struct {
+	id    string
+	name  string
+	input strings.Builder
+}
+
+func (b *Bedrock) StreamCompletions(ctx context.Context, chat pub_models.Chat) (chan models.CompletionEvent, error) {
+	body, err := json.Marshal(b.converseRequest(chat))
+	if err != nil {
+		return nil, fmt.Errorf("bedrock: marshal request: %w", err)
+	}
+	if b.debug {
+		ancli.PrintOK(fmt.Sprintf("bedrock request: %v\n", debug.IndentedJsonFmt(json.RawMessage(body))))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("bedrock: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	sign(req, body, b.creds, b.region, signingService, time.Now())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock: do request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(errBody, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("bedrock: %v: %v", resp.Status, apiErr.Message)
+		}
+		return nil, fmt.Errorf("bedrock: %v, body: %v", resp.Status, string(errBody))
+	}
+
+	b.usage = nil
+	out := make(chan models.CompletionEvent)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+		b.readStream(ctx, resp.Body, out)
+	}()
+	return out, nil
+}
+
+// readStream emits text, reasoning and tool calls of the ConverseStream
+// response in r, then a StopEvent once the stream ends.
+func (b *Bedrock) readStream(ctx context.Context, r io.Reader, out chan models.CompletionEvent) {
+	toolUses := map[int]*pendingToolUse{}
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				emit(ctx, out, models.StopEvent{})
+				return
+			}
+			emit(ctx, out, fmt.Errorf("bedrock: read event stream: %w", err))
+			return
+		}
+		if b.debug {
+			ancli.PrintOK(fmt.Sprintf("bedrock event: %v %s\n", f.Headers, f.Payload))
+		}
+		var evt streamEvent
+		if err := json.Unmarshal(f.Payload, &evt); err != nil {
+			emit(ctx, out, fmt.Errorf("bedrock: unmarshal %q event: %w", f.Headers[":event-type"], err))
+			return
+		}
+		switch f.Headers[":message-type"] {
+		case "event":
+		case "exception":
+			emit(ctx, out, fmt.Errorf("bedrock: %s: %s", f.Headers[":exception-type"], evt.Message))
+			return
+		default:
+			emit(ctx, out, fmt.Errorf("bedrock: %s: %s", f.Headers[":error-code"], f.Headers[":error-message"]))
+			return
+		}
+
+		var ev models.CompletionEvent
+		switch f.Headers[":event-type"] {
+		case "contentBlockStart":
+			if tu := evt.Start.ToolUse; tu != nil {
+				toolUses[evt.ContentBlockIndex] = &pendingToolUse{id: tu.ToolUseID, name: tu.Name}
+			}
+		case "contentBlockDelta":
+			switch {
+			case evt.Delta.ToolUse != nil:
+				if tu, ok := toolUses[evt.ContentBlockIndex]; ok {
+					tu.input.WriteString(evt.Delta.ToolUse.Input)
+				}
+			case evt.Delta.ReasoningContent != nil:
+				if evt.Delta.ReasoningContent.Text != "" {
+					ev = models.ReasoningEvent{Content: evt.Delta.ReasoningContent.Text}
+				}
+			case evt.Delta.Text != "":
+				ev = evt.Delta.Text
+			}
+		case "contentBlockStop":
+			tu, ok := toolUses[evt.ContentBlockIndex]
+			if !ok {
+				break
+			}
+			delete(toolUses, evt.ContentBlockIndex)
+			call, err := toolCall(tu)
+			if err != nil {
+				emit(ctx, out, err)
+				return
+			}
+			ev = call
+		case "metadata":
+			if u := evt.Usage; u != nil {
+				b.usage = &pub_models.Usage{
+					PromptTokens:        u.InputTokens,
+					CompletionTokens:    u.OutputTokens,
+					TotalTokens:         u.TotalTokens,
+					PromptTokensDetails: pub_models.PromptTokensDetails{CachedTokens: u.CacheReadInputTokens},
+				}
+			}
+		}
+		if ev != nil && !emit(ctx, out, ev) {
+			return
+		}
+	}
+}
+
+func toolCall(tu *pendingToolUse) (pub_models.Call, error) {
+	inputs := pub_models.Input{}
+	if raw := tu.input.String(); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &inputs); err != nil {
+			return pub_models.Call{}, fmt.Errorf("bedrock: unmarshal input of tool %q: %w", tu.name, err)
+		}
+	}
+	return pub_models.Call{
+		ID:     tu.id,
+		Name:   tu.name,
+		Inputs: &inputs,
+	}, nil
+}
+
+// emit sends evt to out unless ctx was cancelled first, reporting whether
+// the send succeeded.
+func emit(ctx context.Context, out chan models.CompletionEvent, evt models.CompletionEvent) bool {
+	select {
+	case out <- evt:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
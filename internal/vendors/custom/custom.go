@@ -0,0 +1,231 @@
+// Package custom builds OpenAI-compatible chat models from user-defined
+// vendor files in <config>/vendors/*.json, so local servers (LM Studio,
+// vLLM, llama.cpp) and gateways need no code change.
+package custom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/baalimago/clai/internal/cost"
+	"github.com/baalimago/clai/internal/text/generic"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// Vendor is one vendors/<name>.json file. The file name is the vendor name,
+// used for the model config files (<name>_chat_<model>.json) and so for the
+// rate limiters of its models.
+type Vendor struct {
+	Name string `json:"-"`
+	// Prefix routes models to the vendor, such as "lms:" for "lms:qwen3-8b".
+	// It is stripped before the model name is sent.
+	Prefix string `json:"prefix"`
+	// BaseURL is the OpenAI-compatible API root; "/chat/completions" is
+	// appended to it.
+	BaseURL string `json:"base_url"`
+	// APIKeyEnv names the environment variable holding the bearer token.
+	// Local servers without authentication may leave it unset.
+	APIKeyEnv string `json:"api_key_env"`
+	// Headers are sent with every request. Values are expanded with
+	// os.ExpandEnv, so "${GATEWAY_TOKEN}" keeps secrets out of the file.
+	Headers  map[string]string `json:"headers,omitempty"`
+	Features Features          `json:"features"`
+	// Pricing maps model names (without prefix) to their price, "*" being
+	// the fallback. It is copied into a model's config file when that file
+	// is created.
+	Pricing map[string]cost.ModelPriceScheme `json:"pricing,omitempty"`
+	// Models are offered by shell completion.
+	Models []string `json:"models,omitempty"`
+}
+
+// Features declares what the vendor's API supports.
+type Features struct {
+	Tools            bool `json:"tools"`
+	StructuredOutput bool `json:"structured_output"`
+	// ReasoningField is the delta field carrying reasoning tokens:
+	// "reasoning_content" (the default) or "reasoning".
+	ReasoningField string `json:"reasoning_field,omitempty"`
+}
+
+// Example is the vendor file offered when creating a new vendor in setup.
+var Example = Vendor{
+	Prefix:    "lms:",
+	BaseURL:   "http://localhost:1234/v1",
+	APIKeyEnv: "",
+	Headers:   map[string]string{},
+	Features:  Features{Tools: true, StructuredOutput: true},
+	Models:    []string{},
+}
+
+// Dir returns the directory holding the vendor files of confDir.
+func Dir(confDir string) string {
+	return filepath.Join(confDir, "vendors")
+}
+
+// Load reads every vendor file of confDir, sorted by name. A missing
+// vendors directory yields no vendors.
+func Load(confDir string) ([]Vendor, error) {
+	paths, err := filepath.Glob(filepath.Join(Dir(confDir), "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("glob vendor files: %w", err)
+	}
+	sort.Strings(paths)
+	ret := make([]Vendor, 0, len(paths))
+	prefixes := map[string]string{}
+	for _, p := range paths {
+		v, err := loadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := prefixes[v.Prefix]; ok {
+			return nil, fmt.Errorf("vendors %q and %q both use prefix %q", other, v.Name, v.Prefix)
+		}
+		prefixes[v.Prefix] = v.Name
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
+
+func loadFile(path string) (Vendor, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Vendor{}, fmt.Errorf("read vendor file %q: %w", path, err)
+	}
+	var v Vendor
+	if err := json.Unmarshal(b, &v); err != nil {
+		return Vendor{}, fmt.Errorf("unmarshal vendor file %q: %w", path, err)
+	}
+	v.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+	if err := v.validate(); err != nil {
+		return Vendor{}, fmt.Errorf("vendor file %q: %w", path, err)
+	}
+	return v, nil
+}
+
+func (v Vendor) validate() error {
+	if strings.ContainsAny(v.Name, "_ ") {
+		return errors.New("vendor name (the file name) may not contain '_' or spaces")
+	}
+	if v.Prefix == "" {
+		return errors.New("missing prefix")
+	}
+	if v.BaseURL == "" {
+		return errors.New("missing base_url")
+	}
+	switch v.Features.ReasoningField {
+	case "", "reasoning_content", "reasoning":
+	default:
+		return fmt.Errorf("unknown reasoning_field %q, expected \"reasoning_content\" or \"reasoning\"", v.Features.ReasoningField)
+	}
+	return nil
+}
+
+// Match returns the vendor whose prefix starts model. Prefixes are unique,
+// so at most one vendor matches.
+func Match(vendors []Vendor, model string) (Vendor, bool) {
+	for _, v := range vendors {
+		if strings.HasPrefix(model, v.Prefix) {
+			return v, true
+		}
+	}
+	return Vendor{}, false
+}
+
+// price returns the configured price of model, falling back to "*".
+func (v Vendor) price(model string) *cost.ModelPriceScheme {
+	if p, ok := v.Pricing[model]; ok {
+		return &p
+	}
+	if p, ok := v.Pricing["*"]; ok {
+		return &p
+	}
+	return nil
+}
+
+// Chat is the model config of a custom vendor model, persisted like every
+// other vendor's as <config>/<vendor>_chat_<model>.json.
+type Chat struct {
+	generic.StreamCompleter
+	Model            string                 `json:"model"`
+	FrequencyPenalty float64                `json:"frequency_penalty"`
+	MaxTokens        *int                   `json:"max_tokens"` // Use a pointer to allow null value
+	PresencePenalty  float64                `json:"presence_penalty"`
+	Temperature      float64                `json:"temperature"`
+	TopP             float64                `json:"top_p"`
+	Price            *cost.ModelPriceScheme `json:"price,omitempty"`
+
+	vendor Vendor
+}
+
+// New returns the default model config of model, a full model name
+// including the vendor prefix.
+func New(v Vendor, model string) *Chat {
+	name := strings.TrimPrefix(model, v.Prefix)
+	return &Chat{
+		Model:       name,
+		Temperature: 1.0,
+		TopP:        1.0,
+		Price:       v.price(name),
+		vendor:      v,
+	}
+}
+
+// VendorRoute names the model config file and rate limiter of the model,
+// which vendorType cannot resolve for vendors defined at runtime.
+func (c *Chat) VendorRoute() (vendor, family, version string) {
+	return c.vendor.Name, "chat", c.Model
+}
+
+// InheritVendor copies the vendor definition of dfault, which is not part of
+// the model config file, onto a config loaded from that file.
+func (c *Chat) InheritVendor(dfault any) {
+	if d, ok := dfault.(*Chat); ok {
+		c.vendor = d.vendor
+	}
+}
+
+func (c *Chat) Setup() error {
+	v := c.vendor
+	if v.Name == "" {
+		return errors.New("custom vendor: model config has no vendor definition")
+	}
+	apiKeyEnv := v.APIKeyEnv
+	if apiKeyEnv == "" {
+		apiKeyEnv = strings.ToUpper(strings.ReplaceAll(v.Name, "-", "_")) + "_API_KEY"
+	}
+	url := strings.TrimSuffix(v.BaseURL, "/") + "/chat/completions"
+	debugEnv := strings.ToUpper(strings.ReplaceAll(v.Name, "-", "_")) + "_DEBUG"
+	// Servers without authentication accept any token.
+	err := c.StreamCompleter.SetupOpenAICompatible(c.Model, apiKeyEnv, "no-key-"+v.Name, url, debugEnv, v.Prefix, c.FrequencyPenalty, c.Temperature, c.TopP, c.MaxTokens)
+	if err != nil {
+		return fmt.Errorf("custom vendor %q: %w", v.Name, err)
+	}
+	if len(v.Headers) > 0 {
+		c.ExtraHeaders = make(map[string]string, len(v.Headers))
+		for key, value := range v.Headers {
+			c.ExtraHeaders[key] = os.ExpandEnv(value)
+		}
+	}
+	c.ReasoningField = v.Features.ReasoningField
+	return nil
+}
+
+// RegisterTool only registers tools for vendors declaring tool support.
+func (c *Chat) RegisterTool(tool pub_models.LLMTool) {
+	if c.vendor.Features.Tools {
+		c.InternalRegisterTool(tool)
+	}
+}
+
+// SetResponseFormat only applies structured output for vendors declaring
+// support for it; the others keep answering in plain text.
+func (c *Chat) SetResponseFormat(rf *generic.ResponseFormat) {
+	if c.vendor.Features.StructuredOutput {
+		c.StreamCompleter.SetResponseFormat(rf)
+	}
+}
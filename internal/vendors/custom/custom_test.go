@@ -0,0 +1,134 @@
+package custom
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/baalimago/clai/internal/cost"
+	"github.com/baalimago/clai/internal/text/generic"
+)
+
+func writeVendor(t *testing.T, confDir, name, body string) {
+	t.Helper()
+	if err := os.MkdirAll(Dir(confDir), 0o755); err != nil {
+		t.Fatalf("mkdir vendors: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(Dir(confDir), name+".json"), []byte(body), 0o644); err != nil {
+		t.Fatalf("write vendor: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	confDir := t.TempDir()
+	if vendors, err := Load(confDir); err != nil || len(vendors) != 0 {
+		t.Fatalf("expected no vendors without a vendors dir, got %+v (err %v)", vendors, err)
+	}
+
+	writeVendor(t, confDir, "lms", `{"prefix":"lms:","base_url":"http://localhost:1234/v1","features":{"tools":true,"reasoning_field":"reasoning"}}`)
+	writeVendor(t, confDir, "groq", `{"prefix":"groq:","base_url":"https://api.groq.com/openai/v1","api_key_env":"GROQ_API_KEY"}`)
+	vendors, err := Load(confDir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(vendors) != 2 || vendors[0].Name != "groq" || vendors[1].Name != "lms" {
+		t.Fatalf("expected both vendors sorted by name, got %+v", vendors)
+	}
+	v, ok := Match(vendors, "lms:qwen3-8b")
+	if !ok || v.Name != "lms" || !v.Features.Tools {
+		t.Fatalf("expected lms to match, got %+v (%v)", v, ok)
+	}
+	if _, ok := Match(vendors, "gpt-4.1"); ok {
+		t.Fatal("expected no custom vendor for a built-in model")
+	}
+}
+
+func TestLoad_RejectsInvalidVendors(t *testing.T) {
+	tests := map[string]struct {
+		files   map[string]string
+		wantErr string
+	}{
+		"missing prefix": {
+			files:   map[string]string{"a": `{"base_url":"http://x"}`},
+			wantErr: "missing prefix",
+		},
+		"missing base url": {
+			files:   map[string]string{"a": `{"prefix":"a:"}`},
+			wantErr: "missing base_url",
+		},
+		"underscore in name": {
+			files:   map[string]string{"my_vendor": `{"prefix":"a:","base_url":"http://x"}`},
+			wantErr: "may not contain",
+		},
+		"duplicate prefix": {
+			files: map[string]string{
+				"a": `{"prefix":"x:","base_url":"http://x"}`,
+				"b": `{"prefix":"x:","base_url":"http://y"}`,
+			},
+			wantErr: "both use prefix",
+		},
+		"unknown reasoning field": {
+			files:   map[string]string{"a": `{"prefix":"a:","base_url":"http://x","features":{"reasoning_field":"thoughts"}}`},
+			wantErr: "unknown reasoning_field",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			confDir := t.TempDir()
+			for file, body := range tc.files {
+				writeVendor(t, confDir, file, body)
+			}
+			_, err := Load(confDir)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestChat_Setup(t *testing.T) {
+	t.Setenv("GATEWAY_TOKEN", "secret")
+	t.Setenv("GW_API_KEY", "key")
+	v := Vendor{
+		Name:      "gw",
+		Prefix:    "gw:",
+		BaseURL:   "https://gateway.internal/v1/",
+		APIKeyEnv: "GW_API_KEY",
+		Headers:   map[string]string{"X-Token": "${GATEWAY_TOKEN}"},
+		Features:  Features{ReasoningField: "reasoning"},
+		Pricing:   map[string]cost.ModelPriceScheme{"*": {InputUSDPerToken: 0.1}},
+	}
+	c := New(v, "gw:llama-70b")
+	if c.Price == nil || c.Price.InputUSDPerToken != 0.1 {
+		t.Fatalf("expected the fallback price, got %+v", c.Price)
+	}
+	if vendor, family, version := c.VendorRoute(); vendor != "gw" || family != "chat" || version != "llama-70b" {
+		t.Fatalf("unexpected route %q %q %q", vendor, family, version)
+	}
+
+	// The config loaded from the model file carries no vendor definition.
+	loaded := &Chat{Model: "llama-70b"}
+	if err := loaded.Setup(); err == nil {
+		t.Fatal("expected setup without a vendor definition to fail")
+	}
+	loaded.InheritVendor(c)
+	if err := loaded.Setup(); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if loaded.URL != "https://gateway.internal/v1/chat/completions" {
+		t.Fatalf("unexpected URL %q", loaded.URL)
+	}
+	if loaded.ExtraHeaders["X-Token"] != "secret" {
+		t.Fatalf("expected expanded headers, got %+v", loaded.ExtraHeaders)
+	}
+	if loaded.ReasoningField != "reasoning" || loaded.StreamCompleter.Model != "llama-70b" {
+		t.Fatalf("unexpected completer %+v", loaded.StreamCompleter)
+	}
+
+	// Without declared support, structured output is not forwarded.
+	loaded.SetResponseFormat(&generic.ResponseFormat{Type: "json_schema"})
+	if loaded.ResponseFormat != nil {
+		t.Fatalf("expected the response format to be dropped, got %+v", loaded.ResponseFormat)
+	}
+}
@@ -41,6 +41,7 @@ func (g *Deepseek) Setup() error {
 	g.StreamCompleter.MaxTokens = g.MaxTokens
 	g.StreamCompleter.Temperature = &g.Temperature
 	g.StreamCompleter.TopP = &g.TopP
+	g.StreamCompleter.ReasoningVendor = pub_models.ReasoningVendorDeepSeek
 	toolChoice := "auto"
 	g.ToolChoice = &toolChoice
 	return nil
@@ -49,3 +50,8 @@ func (g *Deepseek) Setup() error {
 func (g *Deepseek) RegisterTool(tool pub_models.LLMTool) {
 	g.InternalRegisterTool(tool)
 }
+
+// SupportsVision implements models.VisionSupporter, the models are text only.
+func (g *Deepseek) SupportsVision() bool {
+	return false
+}
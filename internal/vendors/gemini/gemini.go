@@ -39,6 +39,8 @@ func (g *Gemini) Setup() error {
 	g.StreamCompleter.MaxTokens = g.MaxTokens
 	g.StreamCompleter.Temperature = &g.Temperature
 	g.StreamCompleter.TopP = &g.TopP
+	g.StreamCompleter.ReasoningVendor = pub_models.ReasoningVendorGemini
+	g.StreamCompleter.FileParts = generic.FilePartsImageURL
 	toolChoice := "auto"
 	g.ToolChoice = &toolChoice
 	return nil
@@ -47,3 +49,8 @@ func (g *Gemini) Setup() error {
 func (g *Gemini) RegisterTool(tool pub_models.LLMTool) {
 	g.InternalRegisterTool(tool)
 }
+
+// SupportsVision implements models.VisionSupporter, the models are multimodal.
+func (g *Gemini) SupportsVision() bool {
+	return true
+}
@@ -42,3 +42,8 @@ func (g *Inception) Setup() error {
 func (g *Inception) RegisterTool(tool pub_models.LLMTool) {
 	g.InternalRegisterTool(tool)
 }
+
+// SupportsVision implements models.VisionSupporter, the models are text only.
+func (g *Inception) SupportsVision() bool {
+	return false
+}
@@ -3,6 +3,7 @@ package mistral
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/baalimago/clai/internal/models"
 	"github.com/baalimago/clai/internal/text/generic"
@@ -104,3 +105,22 @@ func (m *Mistral) StreamCompletions(ctx context.Context, chat pub_models.Chat) (
 func (m *Mistral) RegisterTool(tool pub_models.LLMTool) {
 	m.InternalRegisterTool(tool)
 }
+
+// textOnlyModels are the prefixes of the model ids which reject images. The
+// latest aliases of small, medium, large and magistral read them.
+var textOnlyModels = []string{
+	"codestral", "devstral", "mistral-embed", "mistral-moderation", "mistral-saba", "mistral-tiny",
+	"open-mistral", "open-mixtral", "mistral-nemo", "voxtral", "ministral-3b-2410", "ministral-8b-2410",
+	"mistral-small-2402", "mistral-small-2409", "mistral-small-2501", "mistral-large-2407", "mistral-large-2411",
+	"magistral-small-2506", "magistral-small-2507", "magistral-medium-2506", "magistral-medium-2507",
+}
+
+// SupportsVision implements models.VisionSupporter.
+func (m *Mistral) SupportsVision() bool {
+	for _, p := range textOnlyModels {
+		if strings.HasPrefix(m.Model, p) {
+			return false
+		}
+	}
+	return true
+}
@@ -135,3 +135,19 @@ func (mockTool) Specification() pub_models.Specification {
 		Inputs:      &pub_models.InputSchema{Type: "object"},
 	}
 }
+
+func TestSupportsVision(t *testing.T) {
+	for model, want := range map[string]bool{
+		"mistral-large-latest":  true,
+		"pixtral-large-latest":  true,
+		"mistral-small-2503":    true,
+		"codestral-latest":      false,
+		"open-mistral-nemo":     false,
+		"mistral-small-2501":    false,
+		"magistral-medium-2507": false,
+	} {
+		if got := (&Mistral{Model: model}).SupportsVision(); got != want {
+			t.Errorf("SupportsVision(%q) = %t, want %t", model, got, want)
+		}
+	}
+}
@@ -25,6 +25,11 @@ func (m *Mock) Setup() error {
 	return nil
 }
 
+// SupportsVision implements models.VisionSupporter, the mock accepts any chat.
+func (m *Mock) SupportsVision() bool {
+	return true
+}
+
 func (m *Mock) TokenUsage() *pub_models.Usage {
 	return m.usage
 }
@@ -0,0 +1,294 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/baalimago/clai/internal/models"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
+	"github.com/baalimago/go_away_boilerplate/pkg/debug"
+)
+
+type chatReq struct {
+	Model     string        `json:"model"`
+	Messages  []chatMessage `json:"messages"`
+	Tools     []chatTool    `json:"tools,omitempty"`
+	Stream    bool          `json:"stream"`
+	Format    any           `json:"format,omitempty"`
+	Options   chatOptions   `json:"options"`
+	KeepAlive string        `json:"keep_alive,omitempty"`
+	Think     any           `json:"think,omitempty"`
+}
+
+type chatOptions struct {
+	NumCtx           int     `json:"num_ctx,omitempty"`
+	NumGPU           *int    `json:"num_gpu,omitempty"`
+	NumPredict       *int    `json:"num_predict,omitempty"`
+	Temperature      float64 `json:"temperature"`
+	TopP             float64 `json:"top_p"`
+	FrequencyPenalty float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64 `json:"presence_penalty,omitempty"`
+}
+
+type chatMessage struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	Thinking  string         `json:"thinking,omitempty"`
+	Images    []string       `json:"images,omitempty"`
+	ToolCalls []chatToolCall `json:"tool_calls,omitempty"`
+	ToolName  string         `json:"tool_name,omitempty"`
+}
+
+type chatToolCall struct {
+	ID       string `json:"id,omitempty"`
+	Function struct {
+		Name      string           `json:"name"`
+		Arguments pub_models.Input `json:"arguments"`
+	} `json:"function"`
+}
+
+type chatTool struct {
+	Type     string           `json:"type"`
+	Function chatToolFunction `json:"function"`
+}
+
+type chatToolFunction struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description,omitempty"`
+	Parameters  *pub_models.InputSchema `json:"parameters,omitempty"`
+}
+
+// chatChunk is one line of the /api/chat stream. The last has Done set and
+// carries the token counts.
+type chatChunk struct {
+	Message         chatMessage `json:"message"`
+	Done            bool        `json:"done"`
+	DoneReason      string      `json:"done_reason"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+	EvalCount       int         `json:"eval_count"`
+	Error           string      `json:"error"`
+}
+
+// errModelNotFound is returned by /api/chat for models the server has not
+// pulled.
+var errModelNotFound = errors.New("model not found")
+
+func (g *Ollama) streamNative(ctx context.Context, chat pub_models.Chat) (chan models.CompletionEvent, error) {
+	body, err := json.Marshal(g.chatRequest(chat))
+	if err != nil {
+		return nil, fmt.Errorf("ollama: marshal request: %w", err)
+	}
+	if g.debug {
+		ancli.PrintOK(fmt.Sprintf("ollama request: %v\n", debug.IndentedJsonFmt(json.RawMessage(body))))
+	}
+	resp, err := g.postChat(ctx, body)
+	if errors.Is(err, errModelNotFound) && g.AutoPull {
+		if err := g.pull(ctx); err != nil {
+			return nil, fmt.Errorf("ollama: %w", err)
+		}
+		resp, err = g.postChat(ctx, body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ollama: %w", err)
+	}
+
+	g.usage = nil
+	out := make(chan models.CompletionEvent)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+		g.readChatStream(ctx, resp.Body, out)
+	}()
+	return out, nil
+}
+
+func (g *Ollama) postChat(ctx context.Context, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.host+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	msg := apiError(resp.Body)
+	if resp.StatusCode == http.StatusNotFound && strings.Contains(msg, "not found") {
+		return nil, fmt.Errorf("%w: %s", errModelNotFound, msg)
+	}
+	return nil, fmt.Errorf("%v: %s", resp.Status, msg)
+}
+
+// apiError returns the "error" field of an Ollama error body, or the body.
+func apiError(r io.Reader) string {
+	b, _ := io.ReadAll(r)
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(b, &e) == nil && e.Error != "" {
+		return e.Error
+	}
+	return string(b)
+}
+
+func (g *Ollama) chatRequest(chat pub_models.Chat) chatReq {
+	req := chatReq{
+		Model:    g.Model,
+		Messages: chatMessages(chat.Messages),
+		Stream:   true,
+		Format:   g.Format,
+		Options: chatOptions{
+			NumCtx:           g.NumCtx,
+			NumGPU:           g.NumGPU,
+			NumPredict:       g.MaxTokens,
+			Temperature:      g.Temperature,
+			TopP:             g.TopP,
+			FrequencyPenalty: g.FrequencyPenalty,
+			PresencePenalty:  g.PresencePenalty,
+		},
+		KeepAlive: g.KeepAlive,
+		Think:     g.Think,
+	}
+	if req.Format == nil && g.responseFormat != nil {
+		switch g.responseFormat.Type {
+		case "json_object":
+			req.Format = "json"
+		case "json_schema":
+			if g.responseFormat.JSONSchema != nil {
+				req.Format = g.responseFormat.JSONSchema.Schema
+			}
+		}
+	}
+	for _, t := range g.tools {
+		req.Tools = append(req.Tools, chatTool{
+			Type:     "function",
+			Function: chatToolFunction{Name: t.Name, Description: t.Description, Parameters: t.Inputs},
+		})
+	}
+	return req
+}
+
+// chatMessages maps clai messages to /api/chat messages. Images travel as
+// base64 next to the text, and tool results name their tool instead of the
+// call ID.
+func chatMessages(msgs []pub_models.Message) []chatMessage {
+	toolNames := map[string]string{}
+	ret := make([]chatMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		cm := chatMessage{
+			Role:     msg.Role,
+			Content:  msg.Content,
+			Thinking: msg.ReasoningContent,
+		}
+		if len(msg.ContentParts) > 0 {
+			var text []string
+			for _, p := range msg.ContentParts {
+				if p.Type == string(pub_models.Image) && p.ImageB64 != nil {
+					cm.Images = append(cm.Images, p.ImageB64.RawB64)
+				} else if p.File != nil {
+					text = append(text, p.File.AsText().Text)
+				} else if p.Text != "" {
+					text = append(text, p.Text)
+				}
+			}
+			cm.Content = strings.Join(text, "\n")
+		}
+		for _, call := range msg.ToolCalls {
+			toolNames[call.ID] = call.Name
+			tc := chatToolCall{ID: call.ID}
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = pub_models.Input{}
+			if call.Inputs != nil {
+				tc.Function.Arguments = *call.Inputs
+			}
+			cm.ToolCalls = append(cm.ToolCalls, tc)
+		}
+		if msg.Role == "tool" {
+			cm.ToolName = toolNames[msg.ToolCallID]
+		}
+		ret = append(ret, cm)
+	}
+	return ret
+}
+
+// readChatStream emits the thinking, text and tool calls of the newline
+// delimited JSON in r, then a StopEvent once the final chunk arrives.
+func (g *Ollama) readChatStream(ctx context.Context, r io.Reader, out chan models.CompletionEvent) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if g.debug {
+			ancli.PrintOK(fmt.Sprintf("ollama chunk: %s\n", line))
+		}
+		var chunk chatChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			emit(ctx, out, fmt.Errorf("ollama: unmarshal chunk: %w", err))
+			return
+		}
+		if chunk.Error != "" {
+			emit(ctx, out, fmt.Errorf("ollama: %s", chunk.Error))
+			return
+		}
+		if chunk.Message.Thinking != "" && !emit(ctx, out, models.ReasoningEvent{Content: chunk.Message.Thinking}) {
+			return
+		}
+		if chunk.Message.Content != "" && !emit(ctx, out, chunk.Message.Content) {
+			return
+		}
+		for _, tc := range chunk.Message.ToolCalls {
+			g.amCalls++
+			id := tc.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%d", g.amCalls)
+			}
+			inputs := tc.Function.Arguments
+			if inputs == nil {
+				inputs = pub_models.Input{}
+			}
+			if !emit(ctx, out, pub_models.Call{ID: id, Name: tc.Function.Name, Inputs: &inputs}) {
+				return
+			}
+		}
+		if chunk.Done {
+			g.usage = &pub_models.Usage{
+				PromptTokens:     chunk.PromptEvalCount,
+				CompletionTokens: chunk.EvalCount,
+				TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
+			}
+			emit(ctx, out, models.StopEvent{})
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		emit(ctx, out, fmt.Errorf("ollama: read stream: %w", err))
+		return
+	}
+	emit(ctx, out, fmt.Errorf("ollama: stream ended before the final chunk: %w", io.ErrUnexpectedEOF))
+}
+
+// emit sends evt to out unless ctx was cancelled first, reporting whether
+// the send succeeded.
+func emit(ctx context.Context, out chan models.CompletionEvent, evt models.CompletionEvent) bool {
+	select {
+	case out <- evt:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -1,11 +1,52 @@
 package ollama
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/baalimago/clai/internal/debugflags"
+	"github.com/baalimago/clai/internal/models"
 	"github.com/baalimago/clai/internal/text/generic"
 	pub_models "github.com/baalimago/clai/pkg/text/models"
 )
 
-const ChatURL = "http://localhost:11434/v1/chat/completions"
+const (
+	// ChatURL is the OpenAI-compatible endpoint of DefaultHost.
+	ChatURL  = DefaultHost + chatPath
+	chatPath = "/v1/chat/completions"
+	// DefaultHost is the Ollama server queried when neither Host nor
+	// OLLAMA_HOST is set.
+	DefaultHost = "http://localhost:11434"
+)
+
+// ResolveHost returns the server URL of a config Host: host itself, else
+// OLLAMA_HOST as the ollama CLI reads it, else DefaultHost. A host without a
+// scheme, such as "gpu-box:11434", is taken as http.
+func ResolveHost(host string) string {
+	if host == "" {
+		host = os.Getenv("OLLAMA_HOST")
+	}
+	if host == "" {
+		return DefaultHost
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return strings.TrimSuffix(host, "/")
+}
+
+// APIs the Ollama server can be queried through.
+const (
+	// APIOpenAI is the OpenAI-compatible /v1/chat/completions endpoint.
+	APIOpenAI = "openai"
+	// APINative is /api/chat, which accepts the runtime options of Ollama
+	// such as num_ctx and keep_alive, and reports token usage.
+	APINative = "native"
+)
 
 var Default = Ollama{
 	Model:       "llama3",
@@ -21,12 +62,85 @@ type Ollama struct {
 	PresencePenalty  float64 `json:"presence_penalty"`
 	Temperature      float64 `json:"temperature"`
 	TopP             float64 `json:"top_p"`
+
+	// Host is the server URL, used by both APIs. Empty uses OLLAMA_HOST, see
+	// ResolveHost.
+	Host string `json:"host"`
+	// API is APIOpenAI (or empty) or APINative. The fields below only apply
+	// to APINative.
+	API string `json:"api"`
+	// NumCtx is the context window in tokens. Ollama defaults to a small
+	// window and silently truncates longer prompts. Zero uses the model's
+	// default.
+	NumCtx int `json:"num_ctx"`
+	// NumGPU is the amount of layers offloaded to the GPU, 0 runs on the CPU.
+	// Null lets Ollama decide.
+	NumGPU *int `json:"num_gpu"`
+	// KeepAlive is how long the model stays loaded after the request, such
+	// as "30m", or "-1" for ever. Empty uses the server's default.
+	KeepAlive string `json:"keep_alive"`
+	// Think enables thinking of reasoning models: true, false, or "low",
+	// "medium" or "high" for models with levels. Null uses the model's
+	// default.
+	Think any `json:"think"`
+	// Format constrains the output: "json", or a JSON schema. Null uses the
+	// response format of the query, if any.
+	Format any `json:"format"`
+	// AutoPull pulls the model through /api/pull when the server does not
+	// have it. It is opt-in, as a typo in the model name would otherwise
+	// start a download of gigabytes.
+	AutoPull bool `json:"auto_pull"`
+
+	client         *http.Client
+	host           string
+	debug          bool
+	tools          []pub_models.Specification
+	responseFormat *generic.ResponseFormat
+	usage          *pub_models.Usage
+	// progress receives the output of pulls.
+	progress io.Writer
+	// amCalls numbers the tool calls, since Ollama does not identify them.
+	amCalls int
 }
 
 func (g *Ollama) Setup() error {
-	return g.StreamCompleter.SetupOpenAICompatible(g.Model, "OLLAMA_API_KEY", "ollama", ChatURL, "OLLAMA_DEBUG", "ollama:", g.FrequencyPenalty, g.Temperature, g.TopP, g.MaxTokens)
+	g.host = ResolveHost(g.Host)
+	switch g.API {
+	case "", APIOpenAI:
+		return g.StreamCompleter.SetupOpenAICompatible(g.Model, "OLLAMA_API_KEY", "ollama", g.host+chatPath, "OLLAMA_DEBUG", "ollama:", g.FrequencyPenalty, g.Temperature, g.TopP, g.MaxTokens)
+	case APINative:
+	default:
+		return fmt.Errorf("ollama: unknown api %q, expected %q or %q", g.API, APIOpenAI, APINative)
+	}
+	g.Model = strings.TrimPrefix(g.Model, "ollama:")
+	g.client = &http.Client{}
+	g.debug = debugflags.EnabledEnv("OLLAMA_DEBUG")
+	if g.progress == nil {
+		g.progress = os.Stderr
+	}
+	return nil
 }
 
 func (g *Ollama) RegisterTool(tool pub_models.LLMTool) {
 	g.InternalRegisterTool(tool)
+	g.tools = append(g.tools, tool.Specification())
+}
+
+func (g *Ollama) SetResponseFormat(rf *generic.ResponseFormat) {
+	g.StreamCompleter.SetResponseFormat(rf)
+	g.responseFormat = rf
+}
+
+func (g *Ollama) StreamCompletions(ctx context.Context, chat pub_models.Chat) (chan models.CompletionEvent, error) {
+	if g.API != APINative {
+		return g.StreamCompleter.StreamCompletions(ctx, chat)
+	}
+	return g.streamNative(ctx, chat)
+}
+
+func (g *Ollama) TokenUsage() *pub_models.Usage {
+	if g.API != APINative {
+		return g.StreamCompleter.TokenUsage()
+	}
+	return g.usage
 }
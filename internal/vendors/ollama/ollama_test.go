@@ -1,8 +1,18 @@
 package ollama
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/baalimago/clai/internal/models"
+	"github.com/baalimago/clai/internal/text/generic"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
 )
 
 func TestSetup_Default_SetsFields(t *testing.T) {
@@ -102,3 +112,160 @@ func TestSetupSetsDefaultEnvWhenMissingOLLAMA(t *testing.T) {
 		t.Fatalf("expected OLLAMA_API_KEY to be set by Setup")
 	}
 }
+
+func TestNative_StreamsOptionsThinkingToolCallsAndUsage(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &gotBody)
+		for _, line := range []string{
+			`{"message":{"role":"assistant","content":"","thinking":"hmm"},"done":false}`,
+			`{"message":{"role":"assistant","content":"Hi"},"done":false}`,
+			`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"ls","arguments":{"dir":"/tmp"}}}]},"done":false}`,
+			`{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":30,"eval_count":7}`,
+		} {
+			io.WriteString(w, line+"\n")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	numGPU := 0
+	v := Default
+	v.API = APINative
+	v.Host = srv.URL
+	v.Model = "ollama:qwen3:8b"
+	v.NumCtx = 32768
+	v.NumGPU = &numGPU
+	v.KeepAlive = "30m"
+	v.Think = "high"
+	if err := v.Setup(); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	v.SetResponseFormat(&generic.ResponseFormat{Type: "json_object"})
+	ch, err := v.StreamCompletions(context.Background(), pub_models.Chat{Messages: []pub_models.Message{
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", ToolCalls: []pub_models.Call{{ID: "c1", Name: "pwd"}}},
+		{Role: "tool", ToolCallID: "c1", Content: "/"},
+	}})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	var text, thinking string
+	var calls []pub_models.Call
+	var stopped bool
+	for ev := range ch {
+		switch e := ev.(type) {
+		case string:
+			text += e
+		case models.ReasoningEvent:
+			thinking += e.Content
+		case pub_models.Call:
+			calls = append(calls, e)
+		case models.StopEvent:
+			stopped = true
+		case error:
+			t.Fatalf("unexpected error: %v", e)
+		}
+	}
+
+	if gotPath != "/api/chat" || gotBody["model"] != "qwen3:8b" || gotBody["keep_alive"] != "30m" || gotBody["think"] != "high" || gotBody["format"] != "json" {
+		t.Fatalf("unexpected request to %q: %v", gotPath, gotBody)
+	}
+	opts, _ := gotBody["options"].(map[string]any)
+	if opts["num_ctx"] != float64(32768) || opts["num_gpu"] != float64(0) {
+		t.Fatalf("unexpected options %v", opts)
+	}
+	msgs, _ := gotBody["messages"].([]any)
+	if toolMsg, _ := msgs[2].(map[string]any); toolMsg["tool_name"] != "pwd" {
+		t.Fatalf("expected the tool result to name its tool, got %v", msgs[2])
+	}
+	if text != "Hi" || thinking != "hmm" || !stopped {
+		t.Fatalf("text %q, thinking %q, stopped %v", text, thinking, stopped)
+	}
+	if len(calls) != 1 || calls[0].Name != "ls" || calls[0].ID == "" || (*calls[0].Inputs)["dir"] != "/tmp" {
+		t.Fatalf("unexpected calls %+v", calls)
+	}
+	if u := v.TokenUsage(); u == nil || u.PromptTokens != 30 || u.CompletionTokens != 7 || u.TotalTokens != 37 {
+		t.Fatalf("unexpected usage %+v", u)
+	}
+}
+
+func TestNative_PullsMissingModel(t *testing.T) {
+	pulled := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/pull":
+			pulled = true
+			io.WriteString(w, `{"status":"pulling manifest"}`+"\n")
+			io.WriteString(w, `{"status":"downloading","total":100,"completed":50}`+"\n")
+			io.WriteString(w, `{"status":"success"}`+"\n")
+		case "/api/chat":
+			if !pulled {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `{"error":"model \"llama3\" not found, try pulling it first"}`)
+				return
+			}
+			io.WriteString(w, `{"message":{"content":"ok"},"done":true}`+"\n")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	var progress strings.Builder
+	v := Default
+	v.API = APINative
+	v.Host = srv.URL
+	v.AutoPull = true
+	v.progress = &progress
+	if err := v.Setup(); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	ch, err := v.StreamCompletions(context.Background(), pub_models.Chat{Messages: []pub_models.Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	var text string
+	for ev := range ch {
+		if s, ok := ev.(string); ok {
+			text += s
+		}
+	}
+	if text != "ok" || !strings.Contains(progress.String(), "downloading: 50%") {
+		t.Fatalf("text %q, progress %q", text, progress.String())
+	}
+
+	v.AutoPull = false
+	pulled = false
+	if _, err := v.StreamCompletions(context.Background(), pub_models.Chat{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a not found error without auto_pull, got %v", err)
+	}
+}
+
+func TestSetup_HostSetsOpenAICompatibleURL(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", "gpu-box:11434")
+	v := Default
+	if err := v.Setup(); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if v.URL != "http://gpu-box:11434/v1/chat/completions" {
+		t.Fatalf("expected the url of OLLAMA_HOST, got %q", v.URL)
+	}
+
+	v = Default
+	v.Host = "http://ollama.lan:8080/"
+	if err := v.Setup(); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if v.URL != "http://ollama.lan:8080/v1/chat/completions" {
+		t.Fatalf("expected the url of host, got %q", v.URL)
+	}
+}
+
+func TestSetup_UnknownAPI(t *testing.T) {
+	v := Default
+	v.API = "grpc"
+	if err := v.Setup(); err == nil || !strings.Contains(err.Error(), "unknown api") {
+		t.Fatalf("expected unknown api error, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type pullStatus struct {
+	Status    string `json:"status"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+	Error     string `json:"error"`
+}
+
+// pull downloads the model through /api/pull, writing its progress to
+// g.progress on a single, rewritten line.
+func (g *Ollama) pull(ctx context.Context) error {
+	body, err := json.Marshal(map[string]any{"model": g.Model, "stream": true})
+	if err != nil {
+		return fmt.Errorf("marshal pull request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.host+"/api/pull", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create pull request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pull %s: %w", g.Model, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pull %s: %v: %s", g.Model, resp.Status, apiError(resp.Body))
+	}
+
+	fmt.Fprintf(g.progress, "pulling %s\n", g.Model)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var s pullStatus
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return fmt.Errorf("pull %s: unmarshal status: %w", g.Model, err)
+		}
+		if s.Error != "" {
+			fmt.Fprintln(g.progress)
+			return fmt.Errorf("pull %s: %s", g.Model, s.Error)
+		}
+		if s.Total > 0 {
+			fmt.Fprintf(g.progress, "\r\033[K%s: %d%%", s.Status, s.Completed*100/s.Total)
+		} else {
+			fmt.Fprintf(g.progress, "\r\033[K%s", s.Status)
+		}
+		if s.Status == "success" {
+			fmt.Fprintln(g.progress)
+			return nil
+		}
+	}
+	fmt.Fprintln(g.progress)
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("pull %s: read status: %w", g.Model, err)
+	}
+	return fmt.Errorf("pull %s: stream ended without success", g.Model)
+}
@@ -0,0 +1,159 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/baalimago/clai/internal/debugflags"
+	"github.com/baalimago/clai/internal/models"
+	"github.com/baalimago/clai/internal/text/generic"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// AzureAPIVersion is the api-version deployments are queried with unless the
+// model config names another.
+const AzureAPIVersion = "2025-04-01-preview"
+
+// APIs an Azure deployment can be queried through.
+const (
+	AzureChatCompletions = "chat_completions"
+	AzureResponses       = "responses"
+)
+
+var AzureDefault = Azure{
+	API:         AzureChatCompletions,
+	APIVersion:  AzureAPIVersion,
+	Temperature: 1.0,
+	TopP:        1.0,
+}
+
+// Azure queries OpenAI models deployed to an Azure OpenAI resource, selected
+// with "azure:<deployment>". It speaks the same wire formats as ChatGPT and
+// delegates to it; only the URLs and the authentication differ.
+type Azure struct {
+	Deployment string `json:"deployment"`
+	// Model is the model the deployment serves, such as "gpt-5.2". Deployment
+	// names are arbitrary, so it decides whether sampling parameters or a
+	// reasoning effort are sent. Empty uses the deployment name.
+	Model string `json:"model"`
+	// Endpoint is the resource URL, such as https://<resource>.openai.azure.com.
+	// Empty uses AZURE_OPENAI_ENDPOINT.
+	Endpoint   string `json:"endpoint"`
+	APIVersion string `json:"api_version"`
+	// API is AzureChatCompletions or AzureResponses.
+	API string `json:"api"`
+	// TokenCommand prints a Microsoft Entra access token, such as
+	// "az account get-access-token --resource https://cognitiveservices.azure.com --query accessToken -o tsv".
+	// It is used instead of the AZURE_OPENAI_API_KEY api key when set.
+	TokenCommand     string  `json:"token_command"`
+	FrequencyPenalty float64 `json:"frequency_penalty"`
+	MaxTokens        *int    `json:"max_tokens"` // Use a pointer to allow null value
+	PresencePenalty  float64 `json:"presence_penalty"`
+	Temperature      float64 `json:"temperature"`
+	TopP             float64 `json:"top_p"`
+	ReasoningEffort  string  `json:"reasoning_effort"`
+
+	gpt ChatGPT
+}
+
+func (a *Azure) Setup() error {
+	if a.Deployment == "" {
+		return fmt.Errorf("azure: missing deployment, use a model such as azure:<deployment>")
+	}
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("AZURE_OPENAI_ENDPOINT")
+	}
+	if endpoint == "" {
+		return fmt.Errorf("azure: missing endpoint, set \"endpoint\" in the model config or AZURE_OPENAI_ENDPOINT")
+	}
+	apiVersion := a.APIVersion
+	if apiVersion == "" {
+		apiVersion = AzureAPIVersion
+	}
+	endpointURL, useResponses, err := azureURL(endpoint, a.Deployment, apiVersion, a.API)
+	if err != nil {
+		return fmt.Errorf("azure: %w", err)
+	}
+	apiKey, authHeader, err := a.credentials()
+	if err != nil {
+		return fmt.Errorf("azure: %w", err)
+	}
+
+	// Set field by field: tools may already be registered on a.gpt.
+	a.gpt.Model = a.Deployment
+	a.gpt.family = a.Model
+	a.gpt.URL = endpointURL
+	a.gpt.useResponses = useResponses
+	a.gpt.apiKey = apiKey
+	a.gpt.authHeader = authHeader
+	a.gpt.debug = debugflags.EnabledEnv("DEBUG_AZURE")
+	a.gpt.FrequencyPenalty = a.FrequencyPenalty
+	a.gpt.MaxTokens = a.MaxTokens
+	a.gpt.PresencePenalty = a.PresencePenalty
+	a.gpt.Temperature = a.Temperature
+	a.gpt.TopP = a.TopP
+	a.gpt.ReasoningEffort = a.ReasoningEffort
+	return nil
+}
+
+// azureURL builds the endpoint of the deployment. Chat Completions names the
+// deployment in the path; the Responses API names it as the request's model.
+func azureURL(endpoint, deployment, apiVersion, api string) (string, bool, error) {
+	base := strings.TrimSuffix(endpoint, "/")
+	query := "?api-version=" + url.QueryEscape(apiVersion)
+	switch api {
+	case "", AzureChatCompletions:
+		return base + "/openai/deployments/" + url.PathEscape(deployment) + "/chat/completions" + query, false, nil
+	case AzureResponses:
+		return base + "/openai/responses" + query, true, nil
+	default:
+		return "", false, fmt.Errorf("unknown api %q, expected %q or %q", api, AzureChatCompletions, AzureResponses)
+	}
+}
+
+// credentials returns the key to authenticate with and the header carrying
+// it; an empty header means a bearer token in Authorization.
+func (a *Azure) credentials() (string, string, error) {
+	if a.TokenCommand != "" {
+		out, err := exec.Command("sh", "-c", a.TokenCommand).Output()
+		if err != nil {
+			return "", "", fmt.Errorf("run token_command: %w", err)
+		}
+		token := strings.TrimSpace(string(out))
+		if token == "" {
+			return "", "", fmt.Errorf("token_command printed no token")
+		}
+		return token, "", nil
+	}
+	apiKey := os.Getenv("AZURE_OPENAI_API_KEY")
+	if apiKey == "" {
+		return "", "", fmt.Errorf("missing AZURE_OPENAI_API_KEY, or set \"token_command\" in the model config")
+	}
+	return apiKey, "api-key", nil
+}
+
+func (a *Azure) RegisterTool(tool pub_models.LLMTool) {
+	a.gpt.RegisterTool(tool)
+}
+
+func (a *Azure) TokenUsage() *pub_models.Usage {
+	return a.gpt.TokenUsage()
+}
+
+func (a *Azure) SetResponseFormat(rf *generic.ResponseFormat) {
+	a.gpt.SetResponseFormat(rf)
+}
+
+func (a *Azure) StreamCompletions(ctx context.Context, chat pub_models.Chat) (chan models.CompletionEvent, error) {
+	a.gpt.usage = nil
+	out, err := a.gpt.streamCompletions(ctx, chat)
+	if err != nil {
+		return nil, fmt.Errorf("azure: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,152 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/baalimago/clai/internal/models"
+	"github.com/baalimago/clai/internal/tools"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func TestAzure_ChatCompletionsUsesDeploymentURLAndAPIKey(t *testing.T) {
+	t.Setenv("AZURE_OPENAI_API_KEY", "azure-key")
+
+	var gotReq *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReq = r
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	a := AzureDefault
+	a.Deployment = "prod-4o"
+	a.Endpoint = srv.URL + "/"
+	if err := a.Setup(); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	ch, err := a.StreamCompletions(context.Background(), pub_models.Chat{Messages: []pub_models.Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	var gotText strings.Builder
+	for ev := range ch {
+		if s, ok := ev.(string); ok {
+			gotText.WriteString(s)
+		}
+	}
+
+	if gotReq.URL.Path != "/openai/deployments/prod-4o/chat/completions" {
+		t.Fatalf("path: got %q", gotReq.URL.Path)
+	}
+	if v := gotReq.URL.Query().Get("api-version"); v != AzureAPIVersion {
+		t.Fatalf("api-version: got %q want %q", v, AzureAPIVersion)
+	}
+	if gotReq.Header.Get("api-key") != "azure-key" || gotReq.Header.Get("Authorization") != "" {
+		t.Fatalf("expected api-key auth only, got api-key=%q authorization=%q", gotReq.Header.Get("api-key"), gotReq.Header.Get("Authorization"))
+	}
+	if gotText.String() != "hi" {
+		t.Fatalf("text: got %q want %q", gotText.String(), "hi")
+	}
+}
+
+func TestAzure_ResponsesUsesEntraTokenAndStreamsToolCalls(t *testing.T) {
+	tools.WithTestRegistry(t, func() {
+		tools.Registry.Set("foo", fakeTool{spec: pub_models.Specification{Name: "foo"}})
+
+		var gotReq *http.Request
+		var gotBody map[string]any
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotReq = r
+			b, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(b, &gotBody)
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, line := range []string{
+				`{"type":"response.output_item.added","output_index":0,"item":{"id":"fc_a","type":"function_call","call_id":"call_a","name":"foo","arguments":""}}`,
+				`{"type":"response.function_call_arguments.delta","item_id":"fc_a","output_index":0,"delta":"{\"a\":1}"}`,
+				`{"type":"response.function_call_arguments.done","item_id":"fc_a","output_index":0,"arguments":"{\"a\":1}"}`,
+				`{"type":"response.completed"}`,
+			} {
+				_, _ = io.WriteString(w, "data: "+line+"\n\n")
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		a := AzureDefault
+		a.Deployment = "reasoner"
+		a.Model = "gpt-5.2"
+		a.API = AzureResponses
+		a.APIVersion = "preview"
+		a.Endpoint = srv.URL
+		a.TokenCommand = "echo entra-token"
+		if err := a.Setup(); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		ch, err := a.StreamCompletions(context.Background(), pub_models.Chat{Messages: []pub_models.Message{{Role: "user", Content: "hi"}}})
+		if err != nil {
+			t.Fatalf("stream: %v", err)
+		}
+		var call pub_models.Call
+		for ev := range ch {
+			switch v := ev.(type) {
+			case pub_models.Call:
+				call = v
+			case error:
+				t.Fatalf("unexpected error event: %v", v)
+			case models.StopEvent:
+			}
+		}
+
+		if gotReq.URL.Path != "/openai/responses" || gotReq.URL.Query().Get("api-version") != "preview" {
+			t.Fatalf("unexpected url %q", gotReq.URL.String())
+		}
+		if gotReq.Header.Get("Authorization") != "Bearer entra-token" {
+			t.Fatalf("expected the command's token as bearer, got %q", gotReq.Header.Get("Authorization"))
+		}
+		if gotBody["model"] != "reasoner" {
+			t.Fatalf("expected the deployment as model, got %#v", gotBody["model"])
+		}
+		// The family, not the deployment name, decides reasoning parameters.
+		if _, ok := gotBody["reasoning"]; !ok {
+			t.Fatalf("expected reasoning params for a gpt-5.2 deployment, got %#v", gotBody)
+		}
+		if _, ok := gotBody["temperature"]; ok {
+			t.Fatalf("reasoning deployment must not send temperature, got %#v", gotBody["temperature"])
+		}
+		if call.Name != "foo" || call.Function.Arguments != `{"a":1}` {
+			t.Fatalf("unexpected call %#v", call)
+		}
+	})
+}
+
+func TestAzure_SetupErrors(t *testing.T) {
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "")
+	t.Setenv("AZURE_OPENAI_API_KEY", "k")
+	tests := []struct {
+		name    string
+		mutate  func(*Azure)
+		wantErr string
+	}{
+		{"missing deployment", func(a *Azure) { a.Endpoint = "https://x" }, "missing deployment"},
+		{"missing endpoint", func(a *Azure) { a.Deployment = "d" }, "missing endpoint"},
+		{"unknown api", func(a *Azure) { a.Deployment, a.Endpoint, a.API = "d", "https://x", "completions" }, "unknown api"},
+		{"failing token command", func(a *Azure) { a.Deployment, a.Endpoint, a.TokenCommand = "d", "https://x", "exit 1" }, "token_command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := AzureDefault
+			tt.mutate(&a)
+			err := a.Setup()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -48,9 +48,23 @@
 //     conversation JSON, and are only ever sent to OpenAI reasoning models.
 //
 // Store:
-//   - Responses always sends store=false; clai is stateless (resends full input[] each
+//   - Responses sends store=false by default; clai is stateless (resends full input[] each
 //     turn), matching the Chat Completions privacy posture. Reasoning continuity is kept
 //     client-side via the encrypted-reasoning replay above, not server-side state.
+//   - The store config opts into server-side state: the response id rides on the Call, or
+//     on the StopEvent of a final answer, onto the assistant turn as a reasoning item of
+//     type "response". The next request continues from the last stored id of the chat with
+//     previous_response_id and only sends the messages after it. An expired id
+//     (previous_response_not_found) is retried once with the full chat.
+//
+// Azure:
+//   - Azure ("azure:<deployment>", azure.go) delegates to ChatGPT with a fixed URL:
+//     <endpoint>/openai/deployments/<deployment>/chat/completions?api-version=… for
+//     api "chat_completions", or <endpoint>/openai/responses?api-version=… with the
+//     deployment as model for api "responses". Auth is the AZURE_OPENAI_API_KEY sent in
+//     the api-key header, or a bearer Entra token printed by token_command. The
+//     deployment's model field decides reasoning parameters, as deployment names are
+//     arbitrary.
 //
 // Usage accounting:
 //   - Chat Completions uses the generic stream completer token usage.
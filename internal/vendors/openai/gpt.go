@@ -30,9 +30,19 @@ type ChatGPT struct {
 	// One of "minimal", "low", "medium", "high"; empty uses the API default.
 	// Ignored for non-reasoning models, which reject the parameter.
 	ReasoningEffort string `json:"reasoning_effort"`
-	URL             string `json:"url"`
+	// Store keeps responses on OpenAI's side, so each turn of the Responses API
+	// only sends what is new since the last stored response of the chat. Off,
+	// the full chat is replayed statelessly every turn.
+	Store bool   `json:"store"`
+	URL   string `json:"url"`
 
 	apiKey string
+	// authHeader, when set, carries apiKey as is instead of as a bearer
+	// token in Authorization, such as Azure's "api-key".
+	authHeader string
+	// family is the model reasoning support is decided by when Model names
+	// something else, such as an Azure deployment.
+	family string
 	debug  bool
 
 	tools []pub_models.LLMTool
@@ -103,22 +113,46 @@ func (g *ChatGPT) StreamCompletions(ctx context.Context, chat pub_models.Chat) (
 	// Setup already normalized g.URL and g.useResponses; re-resolve to stay correct
 	// even if the model was changed after Setup.
 	g.URL, g.useResponses = selectOpenAIURL(g.Model, g.URL)
+	return g.streamCompletions(ctx, chat)
+}
 
+// isReasoning reports whether the model, or its family when set, is a
+// reasoning model.
+func (g *ChatGPT) isReasoning() bool {
+	if g.family != "" {
+		return isReasoningModel(g.family)
+	}
+	return isReasoningModel(g.Model)
+}
+
+// SupportsVision implements models.VisionSupporter.
+func (g *ChatGPT) SupportsVision() bool {
+	if g.family != "" {
+		return !isTextOnlyModel(g.family)
+	}
+	return !isTextOnlyModel(g.Model)
+}
+
+// streamCompletions streams from g.URL with the API g.useResponses selects.
+func (g *ChatGPT) streamCompletions(ctx context.Context, chat pub_models.Chat) (chan models.CompletionEvent, error) {
 	if g.useResponses {
 		s := &responsesStreamer{
 			apiKey:          g.apiKey,
+			authHeader:      g.authHeader,
 			url:             g.URL,
 			model:           g.Model,
+			family:          g.family,
 			debug:           g.debug,
 			client:          http.DefaultClient,
 			tools:           g.mapResponsesTools(),
 			usageSetter:     g.setUsage,
 			responseFormat:  g.responseFormat,
 			maxOutputTokens: g.MaxTokens,
+			store:           g.Store,
 		}
 		// Reasoning models reject sampling parameters; only forward them otherwise.
 		// Conversely, reasoning.effort is only meaningful for reasoning models.
-		if isReasoningModel(g.Model) {
+		if g.isReasoning() {
 			s.reasoningEffort = g.ReasoningEffort
 		} else {
 			s.temperature = &g.Temperature
@@ -133,16 +167,23 @@ func (g *ChatGPT) StreamCompletions(ctx context.Context, chat pub_models.Chat) (
 	}
 
 	sc := &generic.StreamCompleter{}
-	if err := sc.Setup("OPENAI_API_KEY", g.URL, "DEBUG_OPENAI"); err != nil {
+	var err error
+	if g.apiKey != "" {
+		err = sc.SetupWithKey(g.apiKey, g.URL, "DEBUG_OPENAI")
+	} else {
+		err = sc.Setup("OPENAI_API_KEY", g.URL, "DEBUG_OPENAI")
+	}
+	if err != nil {
 		return nil, fmt.Errorf("openai chat: setup stream completer: %w", err)
 	}
+	sc.AuthHeader = g.authHeader
 	g.streamCompleter = sc
 	g.streamCompleter.Model = g.Model
 	g.streamCompleter.MaxTokens = g.MaxTokens
 	// Reasoning models reject sampling parameters (temperature/top_p) and the
 	// frequency/presence penalties, so omit them here just like the responses path.
 	// They do accept reasoning_effort, so forward it when configured.
-	if !isReasoningModel(g.Model) {
+	if !g.isReasoning() {
 		g.streamCompleter.FrequencyPenalty = &g.FrequencyPenalty
 		g.streamCompleter.PresencePenalty = &g.PresencePenalty
 		g.streamCompleter.Temperature = &g.Temperature
@@ -150,6 +191,7 @@ func (g *ChatGPT) StreamCompletions(ctx context.Context, chat pub_models.Chat) (
 	} else if g.ReasoningEffort != "" {
 		g.streamCompleter.ReasoningEffort = g.ReasoningEffort
 	}
+	g.streamCompleter.FileParts = generic.FilePartsFile
 	toolChoice := "auto"
 	g.streamCompleter.ToolChoice = &toolChoice
 	for _, tool := range g.tools {
@@ -0,0 +1,58 @@
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// TestResponsesMapper_FileContentPart_MapsToInputFile checks that a document is
+// sent as an input_file part, without its extracted text.
+func TestResponsesMapper_FileContentPart_MapsToInputFile(t *testing.T) {
+	t.Parallel()
+
+	msg := pub_models.Message{
+		Role: "user",
+		ContentParts: []pub_models.ImageOrTextInput{
+			{Type: "file", File: &pub_models.FileInput{Filename: "report.pdf", FileData: "data:application/pdf;base64,JVBERi0=", Text: "extracted"}},
+			{Type: "text", Text: "summarise"},
+		},
+	}
+
+	parts, err := mapMessageToResponsesContent(msg)
+	if err != nil {
+		t.Fatalf("map content: %v", err)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("parts: got %d want 2 (%#v)", len(parts), parts)
+	}
+	b, err := json.Marshal(parts[0])
+	if err != nil {
+		t.Fatalf("marshal file part: %v", err)
+	}
+	want := `{"type":"input_file","filename":"report.pdf","file_data":"data:application/pdf;base64,JVBERi0="}`
+	if string(b) != want {
+		t.Fatalf("file part: got %s want %s", b, want)
+	}
+}
+
+// TestResponsesMapper_FileWithoutData_MapsToText checks that a document with
+// only extracted text is sent as text.
+func TestResponsesMapper_FileWithoutData_MapsToText(t *testing.T) {
+	t.Parallel()
+
+	msg := pub_models.Message{
+		Role: "user",
+		ContentParts: []pub_models.ImageOrTextInput{
+			{Type: "file", File: &pub_models.FileInput{Filename: "notes.docx", Text: "extracted"}},
+		},
+	}
+	parts, err := mapMessageToResponsesContent(msg)
+	if err != nil {
+		t.Fatalf("map content: %v", err)
+	}
+	if len(parts) != 1 || parts[0].Type != "input_text" || parts[0].Text != "Contents of attached file notes.docx:\nextracted" {
+		t.Fatalf("parts: got %#v", parts)
+	}
+}
@@ -22,10 +22,13 @@ type responsesRequest struct {
 	Temperature       *float64             `json:"temperature,omitempty"`
 	TopP              *float64             `json:"top_p,omitempty"`
 	MaxOutputTokens   *int                 `json:"max_output_tokens,omitempty"`
-	// Store controls server-side retention of the response. clai is stateless
-	// (it resends the full input[] each turn and never uses previous_response_id),
-	// so it always sends store=false to match the Chat Completions privacy posture.
+	// Store controls server-side retention of the response. clai sends
+	// store=false unless the store config opts in, matching the Chat Completions
+	// privacy posture.
 	Store *bool `json:"store,omitempty"`
+	// PreviousResponseID continues from a stored response, so that Input only
+	// holds the items which came after it.
+	PreviousResponseID string `json:"previous_response_id,omitempty"`
 	// Include opts into extra output data. clai requests
 	// "reasoning.encrypted_content" for reasoning models so the (otherwise hidden)
 	// reasoning items come back sealed and can be replayed on the next turn — the
@@ -99,12 +102,16 @@ type responsesSummaryPart struct {
 }
 
 type responsesInputContent struct {
-	Type string `json:"type"` // "input_text" | "input_image"
+	Type string `json:"type"` // "input_text" | "input_image" | "input_file"
 	Text string `json:"text,omitempty"`
 	// ImageURL carries the image for an input_image part. Unlike Chat Completions
 	// (where image_url is an object), the Responses API expects a plain data-URL string.
 	ImageURL string `json:"image_url,omitempty"`
 	Detail   string `json:"detail,omitempty"` // "auto" | "low" | "high"
+	// Filename and FileData carry the document of an input_file part, FileData
+	// as a data URL.
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"`
 }
 
 // MarshalJSON preserves the required text field for text content, including an
@@ -115,12 +122,16 @@ func (c responsesInputContent) MarshalJSON() ([]byte, error) {
 		Text     *string `json:"text,omitempty"`
 		ImageURL string  `json:"image_url,omitempty"`
 		Detail   string  `json:"detail,omitempty"`
+		Filename string  `json:"filename,omitempty"`
+		FileData string  `json:"file_data,omitempty"`
 	}
 
 	wire := contentWire{
 		Type:     c.Type,
 		ImageURL: c.ImageURL,
 		Detail:   c.Detail,
+		Filename: c.Filename,
+		FileData: c.FileData,
 	}
 	if c.Type == "input_text" || c.Type == "output_text" {
 		wire.Text = &c.Text
@@ -160,8 +171,8 @@ type responsesStreamErrBody struct {
 }
 
 type responsesResponse struct {
-	// ID is the response identifier, present from response.created onward. It keys
-	// the reasoning-item sidecar for the assistant turn.
+	// ID is the response identifier, present from response.created onward. In
+	// store mode it is kept with the assistant turn as its previous_response_id.
 	ID    string          `json:"id,omitempty"`
 	Usage *responsesUsage `json:"usage,omitempty"`
 	// Error carries the failure detail on a response.failed event (nested under
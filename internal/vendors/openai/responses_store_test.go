@@ -0,0 +1,244 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baalimago/clai/internal/models"
+	"github.com/baalimago/clai/internal/tools"
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func storedResponseItem(id string) pub_models.ReasoningItem {
+	return pub_models.ReasoningItem{Vendor: pub_models.ReasoningVendorOpenAI, Type: pub_models.ReasoningResponse, ID: id}
+}
+
+// storedToolChat is a tool call turn stored as resp_1, followed by its result.
+func storedToolChat() pub_models.Chat {
+	return pub_models.Chat{Messages: []pub_models.Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "list files"},
+		{
+			Role:           "assistant",
+			ToolCalls:      []pub_models.Call{{ID: "call_a", Name: "ls", Function: pub_models.Specification{Arguments: "{}"}}},
+			ReasoningItems: []pub_models.ReasoningItem{storedResponseItem("resp_1")},
+		},
+		{Role: "tool", ToolCallID: "call_a", Content: "a.go"},
+	}}
+}
+
+// streamStored runs s against a server answering each request with handle,
+// and returns the decoded request bodies.
+func streamStored(t *testing.T, s *responsesStreamer, chat pub_models.Chat, handle func(w http.ResponseWriter, body map[string]any)) []map[string]any {
+	t.Helper()
+
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() { _ = r.Body.Close() }()
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		handle(w, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	s.url = srv.URL
+	s.client = srv.Client()
+	s.apiKey = "k"
+	s.model = "gpt-4.1-mini"
+	ch, err := s.stream(context.Background(), chat)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	for range ch {
+	}
+	return bodies
+}
+
+func completed(w http.ResponseWriter, _ map[string]any) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	_, _ = io.WriteString(w, "data: {\"type\":\"response.completed\"}\n\n")
+}
+
+func inputTypes(body map[string]any) []string {
+	input, _ := body["input"].([]any)
+	var ret []string
+	for _, it := range input {
+		m, _ := it.(map[string]any)
+		typ, _ := m["type"].(string)
+		ret = append(ret, typ)
+	}
+	return ret
+}
+
+func TestResponsesStreamer_StoreSendsOnlyNewItems(t *testing.T) {
+	t.Parallel()
+
+	bodies := streamStored(t, &responsesStreamer{store: true}, storedToolChat(), completed)
+	if len(bodies) != 1 {
+		t.Fatalf("expected one request, got %d", len(bodies))
+	}
+	body := bodies[0]
+	if body["store"] != true {
+		t.Fatalf("store: got %#v want true", body["store"])
+	}
+	if body["previous_response_id"] != "resp_1" {
+		t.Fatalf("previous_response_id: got %#v want resp_1", body["previous_response_id"])
+	}
+	if got := inputTypes(body); len(got) != 1 || got[0] != "function_call_output" {
+		t.Fatalf("expected only the tool result as input, got %v", got)
+	}
+}
+
+func TestResponsesStreamer_StoreSendsEveryOutputOfASplitBatch(t *testing.T) {
+	t.Parallel()
+
+	// A batch split at load_skill spreads the calls of resp_1 over two
+	// assistant messages, each followed by its result.
+	chat := storedToolChat()
+	chat.Messages = append(chat.Messages,
+		pub_models.Message{
+			Role:           "assistant",
+			ToolCalls:      []pub_models.Call{{ID: "call_b", Name: "load_skill", Function: pub_models.Specification{Arguments: "{}"}}},
+			ReasoningItems: []pub_models.ReasoningItem{storedResponseItem("resp_1")},
+		},
+		pub_models.Message{Role: "tool", ToolCallID: "call_b", Content: "skill loaded"},
+	)
+	body := streamStored(t, &responsesStreamer{store: true}, chat, completed)[0]
+	if body["previous_response_id"] != "resp_1" {
+		t.Fatalf("previous_response_id: got %#v want resp_1", body["previous_response_id"])
+	}
+	input, _ := body["input"].([]any)
+	var callIDs []string
+	for _, it := range input {
+		m, _ := it.(map[string]any)
+		if m["type"] != "function_call_output" {
+			t.Fatalf("expected only tool results as input, got %v", inputTypes(body))
+		}
+		id, _ := m["call_id"].(string)
+		callIDs = append(callIDs, id)
+	}
+	if len(callIDs) != 2 || callIDs[0] != "call_a" || callIDs[1] != "call_b" {
+		t.Fatalf("expected the outputs of both calls, got %v", callIDs)
+	}
+}
+
+func TestResponsesStreamer_NoStoreReplaysFullChat(t *testing.T) {
+	t.Parallel()
+
+	body := streamStored(t, &responsesStreamer{}, storedToolChat(), completed)[0]
+	if body["store"] != false {
+		t.Fatalf("store: got %#v want false", body["store"])
+	}
+	if _, ok := body["previous_response_id"]; ok {
+		t.Fatalf("previous_response_id must be omitted without store, got %#v", body["previous_response_id"])
+	}
+	if got := inputTypes(body); len(got) != 4 {
+		t.Fatalf("expected the full chat as input, got %v", got)
+	}
+}
+
+func TestResponsesStreamer_StoreFallsBackToFullReplayWhenExpired(t *testing.T) {
+	t.Parallel()
+
+	bodies := streamStored(t, &responsesStreamer{store: true}, storedToolChat(), func(w http.ResponseWriter, body map[string]any) {
+		if _, ok := body["previous_response_id"]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error":{"message":"Previous response with id 'resp_1' not found.","type":"invalid_request_error","param":"previous_response_id","code":"previous_response_not_found"}}`)
+			return
+		}
+		completed(w, body)
+	})
+	if len(bodies) != 2 {
+		t.Fatalf("expected a retry after the expired id, got %d requests", len(bodies))
+	}
+	retry := bodies[1]
+	if _, ok := retry["previous_response_id"]; ok {
+		t.Fatalf("expected the retry without previous_response_id, got %#v", retry["previous_response_id"])
+	}
+	if retry["store"] != true {
+		t.Fatalf("expected the retry stored, got %#v", retry["store"])
+	}
+	if got := inputTypes(retry); len(got) != 4 {
+		t.Fatalf("expected the full chat replayed, got %v", got)
+	}
+}
+
+func TestReadResponsesStream_StoreKeepsResponseID(t *testing.T) {
+	t.Parallel()
+
+	tools.WithTestRegistry(t, func() {
+		tools.Registry.Set("foo", fakeTool{spec: pub_models.Specification{Name: "foo"}})
+
+		sse := strings.Join([]string{
+			`data: {"type":"response.created","response":{"id":"resp_xyz"}}`,
+			`data: {"type":"response.output_item.added","output_index":0,"item":{"id":"fc_a","type":"function_call","call_id":"call_a","name":"foo","arguments":""}}`,
+			`data: {"type":"response.function_call_arguments.done","item_id":"fc_a","output_index":0,"arguments":"{}"}`,
+			`data: {"type":"response.completed","response":{"id":"resp_xyz"}}`,
+			"",
+		}, "\n")
+
+		for _, store := range []bool{true, false} {
+			s := &responsesStreamer{store: store}
+			out := make(chan models.CompletionEvent)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			go s.readResponsesStream(ctx, io.NopCloser(strings.NewReader(sse)), out)
+
+			var callItems, stopItems []pub_models.ReasoningItem
+			for ev := range out {
+				switch c := ev.(type) {
+				case pub_models.Call:
+					callItems = c.ReasoningItems
+				case models.StopEvent:
+					stopItems = c.ReasoningItems
+				case error:
+					t.Fatalf("unexpected error event: %v", c)
+				}
+			}
+			cancel()
+
+			if !store {
+				if len(callItems) != 0 || len(stopItems) != 0 {
+					t.Fatalf("expected no stored response without store, got %#v and %#v", callItems, stopItems)
+				}
+				continue
+			}
+			if len(callItems) != 1 || callItems[0].Type != pub_models.ReasoningResponse || callItems[0].ID != "resp_xyz" {
+				t.Fatalf("expected the response id on the call, got %#v", callItems)
+			}
+			if len(stopItems) != 1 || stopItems[0].ID != "resp_xyz" {
+				t.Fatalf("expected the response id on the stop event, got %#v", stopItems)
+			}
+		}
+	})
+}
+
+func TestMapChatToResponsesInput_SkipsStoredResponseItems(t *testing.T) {
+	t.Parallel()
+
+	chat := storedToolChat()
+	chat.Messages[2].ReasoningItems = append(chat.Messages[2].ReasoningItems, pub_models.ReasoningItem{
+		Vendor: pub_models.ReasoningVendorOpenAI, ID: "rs_1", EncryptedContent: "SEALED",
+	})
+	input, err := mapChatToResponsesInput(chat, true)
+	if err != nil {
+		t.Fatalf("map: %v", err)
+	}
+	var reasoning []string
+	for _, it := range input {
+		if it.Type == "reasoning" {
+			reasoning = append(reasoning, it.ID)
+		}
+	}
+	if len(reasoning) != 1 || reasoning[0] != "rs_1" {
+		t.Fatalf("expected only the reasoning item replayed, got %v", reasoning)
+	}
+}
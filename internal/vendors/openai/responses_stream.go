@@ -6,9 +6,11 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 
 	"github.com/baalimago/clai/internal/models"
 	"github.com/baalimago/clai/internal/text/generic"
@@ -31,9 +33,13 @@ func normalizeResponsesCallID(id string) string {
 }
 
 type responsesStreamer struct {
-	apiKey      string
-	url         string
-	model       string
+	apiKey string
+	// authHeader, when set, carries apiKey as is instead of as a bearer token.
+	authHeader string
+	url        string
+	model      string
+	// family, when set, decides reasoning support instead of model.
+	family      string
 	debug       bool
 	client      *http.Client
 	usageSetter func(*pub_models.Usage) error
@@ -51,6 +57,9 @@ type responsesStreamer struct {
 	// reasoningEffort configures reasoning.effort for reasoning models. Empty means
 	// "let the API pick" (default medium); it is ignored for non-reasoning models.
 	reasoningEffort string
+	// store opts into server-side state: responses are stored, and a chat
+	// continues from its last stored response with previous_response_id.
+	store bool
 }
 
 type toolCallState struct {
@@ -155,10 +164,13 @@ func (st *toolCallState) emitCall(done <-chan struct{}, out chan<- models.Comple
 // reset another's buffered arguments and mis-attribute later deltas.
 //
 // It also accumulates reasoning items so that an emitted tool call can carry them
-// forward for stateless reasoning continuity.
+// forward for stateless reasoning continuity, and, in store mode, the id of the
+// response for the turn to continue from.
 type toolCallTracker struct {
 	states         map[string]*toolCallState
 	reasoningItems []pub_models.ReasoningItem
+	store          bool
+	responseID     string
 }
 
 func newToolCallTracker() *toolCallTracker {
@@ -175,6 +187,20 @@ func (t *toolCallTracker) state(key string) *toolCallState {
 	return st
 }
 
+// storedResponse returns the stored response of the stream as an item, or nil
+// when the response is not stored.
+func (t *toolCallTracker) storedResponse() []pub_models.ReasoningItem {
+	if !t.store || t.responseID == "" {
+		return nil
+	}
+	return []pub_models.ReasoningItem{{Vendor: pub_models.ReasoningVendorOpenAI, Type: pub_models.ReasoningResponse, ID: t.responseID}}
+}
+
+// continuity returns the items an emitted tool call carries.
+func (t *toolCallTracker) continuity() []pub_models.ReasoningItem {
+	return append(t.storedResponse(), t.reasoningItems...)
+}
+
 // drop discards the state for key once its call has been emitted.
 func (t *toolCallTracker) drop(key string) {
 	delete(t.states, key)
@@ -197,7 +223,28 @@ func (evt responsesStreamEvent) callKey() string {
 }
 
 func (s *responsesStreamer) stream(ctx context.Context, chat pub_models.Chat) (chan models.CompletionEvent, error) {
-	req, err := s.createRequest(ctx, chat)
+	res, err := s.send(ctx, chat, s.store)
+	if errors.Is(err, errPreviousResponseNotFound) {
+		// Stored responses expire; the chat holds everything needed to carry on
+		// with a full replay, which stores a fresh response to continue from.
+		if s.debug {
+			ancli.Noticef("openai responses: %v, replaying the full chat", err)
+		}
+		res, err = s.send(ctx, chat, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan models.CompletionEvent)
+	go s.readResponsesStream(ctx, res.Body, out)
+	return out, nil
+}
+
+// send posts the request for chat. With continued set, the request continues
+// from the last stored response of the chat, when there is one.
+func (s *responsesStreamer) send(ctx context.Context, chat pub_models.Chat, continued bool) (*http.Response, error) {
+	req, err := s.createRequest(ctx, chat, continued)
 	if err != nil {
 		return nil, fmt.Errorf("openai responses: create request: %w", err)
 	}
@@ -210,12 +257,13 @@ func (s *responsesStreamer) stream(ctx context.Context, chat pub_models.Chat) (c
 	if err := validateResponsesHTTPResponse(res); err != nil {
 		return nil, fmt.Errorf("openai responses: validate response: %w", err)
 	}
-
-	out := make(chan models.CompletionEvent)
-	go s.readResponsesStream(ctx, res.Body, out)
-	return out, nil
+	return res, nil
 }
 
+// errPreviousResponseNotFound is returned when the previous_response_id of a
+// request is unknown to the server, such as once the stored response expired.
+var errPreviousResponseNotFound = errors.New("previous response not found")
+
 func validateResponsesHTTPResponse(res *http.Response) error {
 	if res.StatusCode == http.StatusOK {
 		return nil
@@ -226,6 +274,12 @@ func validateResponsesHTTPResponse(res *http.Response) error {
 	if err != nil {
 		return fmt.Errorf("read error body: %w", err)
 	}
+	var apiErr struct {
+		Error responsesStreamErrBody `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Code == "previous_response_not_found" {
+		return fmt.Errorf("%w: %s", errPreviousResponseNotFound, apiErr.Error.Message)
+	}
 	return fmt.Errorf("unexpected status code %v, body: %s", res.Status, string(body))
 }
 
@@ -237,6 +291,7 @@ func (s *responsesStreamer) readResponsesStream(ctx context.Context, body io.Rea
 
 	br := bufio.NewReader(body)
 	tracker := newToolCallTracker()
+	tracker.store = s.store
 	done := ctx.Done()
 
 	for {
@@ -317,6 +372,9 @@ func handleResponsesStreamEvent(done <-chan struct{}, out chan<- models.Completi
 		return false, emitReasoningDelta(done, out, evt.Delta)
 
 	case "response.created", "response.in_progress":
+		if evt.Response != nil && evt.Response.ID != "" {
+			tracker.responseID = evt.Response.ID
+		}
 		emitResponses(done, out, models.NoopEvent{})
 		return false, nil
 
@@ -339,7 +397,7 @@ func handleResponsesStreamEvent(done <-chan struct{}, out chan<- models.Completi
 		if err := maybeSetUsage(evt, usageSetter); err != nil {
 			return false, fmt.Errorf("set usage: %w", err)
 		}
-		emitResponses(done, out, models.StopEvent{})
+		emitResponses(done, out, models.StopEvent{ReasoningItems: tracker.storedResponse()})
 		return true, nil
 
 	case "response.failed":
@@ -454,7 +512,7 @@ func handleFunctionCallArgumentsDelta(done <-chan struct{}, out chan<- models.Co
 
 func handleFunctionCallArgumentsDone(done <-chan struct{}, out chan<- models.CompletionEvent, tracker *toolCallTracker, evt responsesStreamEvent) error {
 	key := evt.callKey()
-	if err := tracker.state(key).emitCall(done, out, tracker.reasoningItems); err != nil {
+	if err := tracker.state(key).emitCall(done, out, tracker.continuity()); err != nil {
 		return err
 	}
 	tracker.drop(key)
@@ -477,7 +535,7 @@ func handleOutputItemDone(done <-chan struct{}, out chan<- models.CompletionEven
 		emitResponses(done, out, models.NoopEvent{})
 		return nil
 	}
-	ri := pub_models.ReasoningItem{ID: item.ID, EncryptedContent: item.EncryptedContent}
+	ri := pub_models.ReasoningItem{Vendor: pub_models.ReasoningVendorOpenAI, ID: item.ID, EncryptedContent: item.EncryptedContent}
 	for _, s := range item.Summary {
 		if s.Text != "" {
 			ri.Summary = append(ri.Summary, s.Text)
@@ -488,8 +546,19 @@ func handleOutputItemDone(done <-chan struct{}, out chan<- models.CompletionEven
 	return nil
 }
 
-func (s *responsesStreamer) createRequest(ctx context.Context, chat pub_models.Chat) (*http.Request, error) {
+func (s *responsesStreamer) createRequest(ctx context.Context, chat pub_models.Chat, continued bool) (*http.Request, error) {
 	reasoning := isReasoningModel(s.model)
+	if s.family != "" {
+		reasoning = isReasoningModel(s.family)
+	}
+	previousID := ""
+	if continued {
+		var rest []pub_models.Message
+		previousID, rest = previousResponse(chat.Messages)
+		if previousID != "" {
+			chat.Messages = rest
+		}
+	}
 	// Reasoning items are opaque and OpenAI-reasoning-model-only, so only replay
 	// them for reasoning models; other models (and, structurally, the Chat
 	// Completions path) never receive them.
@@ -498,18 +567,20 @@ func (s *responsesStreamer) createRequest(ctx context.Context, chat pub_models.C
 		return nil, fmt.Errorf("map chat to responses input: %w", err)
 	}
 
-	storeFalse := false
+	// clai holds full conversation state client-side, so unless store mode is on,
+	// opt out of server-side retention (the Responses API otherwise defaults
+	// store=true).
+	store := s.store
 	reqBody := responsesRequest{
-		Model:           s.model,
-		Input:           input,
-		Stream:          true,
-		Text:            mapResponseFormat(s.responseFormat),
-		Temperature:     s.temperature,
-		TopP:            s.topP,
-		MaxOutputTokens: s.maxOutputTokens,
-		// clai holds full conversation state client-side, so opt out of server-side
-		// retention (the Responses API otherwise defaults store=true).
-		Store: &storeFalse,
+		Model:              s.model,
+		Input:              input,
+		Stream:             true,
+		Text:               mapResponseFormat(s.responseFormat),
+		Temperature:        s.temperature,
+		TopP:               s.topP,
+		MaxOutputTokens:    s.maxOutputTokens,
+		Store:              &store,
+		PreviousResponseID: previousID,
 	}
 	// Reasoning models stream a reasoning summary only when the request opts in via
 	// reasoning.summary; request it so the deltas render as [thinking]. Effort is
@@ -546,7 +617,11 @@ func (s *responsesStreamer) createRequest(ctx context.Context, chat pub_models.C
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
+	if s.authHeader != "" {
+		req.Header.Set(s.authHeader, s.apiKey)
+	} else {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
+	}
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Connection", "keep-alive")
 	return req, nil
@@ -569,6 +644,44 @@ func mapResponseFormat(rf *generic.ResponseFormat) *responsesText {
 	return &responsesText{Format: format}
 }
 
+// previousResponse returns the id of the last stored response of msgs, and
+// the messages to send on top of it. Every tool call of a response carries
+// its id, and a batch split at load_skill spreads them over several
+// assistant messages, so the messages to send are the ones after the first
+// message of the response, minus its other assistant messages. The id is
+// empty when no turn was stored, or when nothing came after it.
+func previousResponse(msgs []pub_models.Message) (string, []pub_models.Message) {
+	id := ""
+	for i := len(msgs) - 1; i >= 0 && id == ""; i-- {
+		id = storedResponseID(msgs[i])
+	}
+	if id == "" {
+		return "", nil
+	}
+	first := slices.IndexFunc(msgs, func(m pub_models.Message) bool { return storedResponseID(m) == id })
+	var rest []pub_models.Message
+	for _, m := range msgs[first+1:] {
+		if storedResponseID(m) != id {
+			rest = append(rest, m)
+		}
+	}
+	if len(rest) == 0 {
+		return "", nil
+	}
+	return id, rest
+}
+
+// storedResponseID returns the id of the stored response msg belongs to, or
+// empty.
+func storedResponseID(msg pub_models.Message) string {
+	for _, ri := range pub_models.ReasoningItemsOf(msg.ReasoningItems, pub_models.ReasoningVendorOpenAI) {
+		if ri.Type == pub_models.ReasoningResponse && ri.ID != "" {
+			return ri.ID
+		}
+	}
+	return ""
+}
+
 func mapChatToResponsesInput(chat pub_models.Chat, includeReasoning bool) ([]responsesInputItem, error) {
 	out := make([]responsesInputItem, 0, len(chat.Messages))
 	for _, msg := range chat.Messages {
@@ -606,7 +719,10 @@ func mapMessageToResponsesInputItems(msg pub_models.Message, includeReasoning bo
 		// own chain-of-thought across a stateless tool loop. Only for reasoning models
 		// on the Responses path — the items are opaque and OpenAI-specific.
 		if includeReasoning {
-			for _, ri := range msg.ReasoningItems {
+			for _, ri := range pub_models.ReasoningItemsOf(msg.ReasoningItems, pub_models.ReasoningVendorOpenAI) {
+				if ri.Type == pub_models.ReasoningResponse {
+					continue
+				}
 				// summary must always be present as an array on a reasoning input
 				// item (empty when we captured none), so build a non-nil slice and
 				// attach it by pointer.
@@ -695,6 +811,18 @@ func mapMessageToResponsesContent(msg pub_models.Message) ([]responsesInputConte
 			})
 			continue
 		}
+		if cp.File != nil {
+			if cp.File.FileData == "" {
+				out = append(out, responsesInputContent{Type: textType, Text: cp.File.AsText().Text})
+				continue
+			}
+			out = append(out, responsesInputContent{
+				Type:     "input_file",
+				Filename: cp.File.Filename,
+				FileData: cp.File.FileData,
+			})
+			continue
+		}
 		if cp.Text != "" {
 			out = append(out, responsesInputContent{Type: textType, Text: cp.Text})
 			continue
@@ -152,3 +152,16 @@ func isReasoningModel(model string) bool {
 		return false
 	}
 }
+
+// isTextOnlyModel reports whether the model rejects image input.
+func isTextOnlyModel(model string) bool {
+	m := normalizeModelID(model)
+	switch {
+	case m == "gpt-4", strings.HasPrefix(m, "gpt-4-0"), strings.HasPrefix(m, "gpt-4-1106"), strings.HasPrefix(m, "gpt-4-32k"):
+		return true
+	case strings.HasPrefix(m, "gpt-3.5"), strings.HasPrefix(m, "o1-mini"), strings.HasPrefix(m, "o3-mini"):
+		return true
+	default:
+		return false
+	}
+}
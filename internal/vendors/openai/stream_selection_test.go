@@ -153,3 +153,29 @@ func TestIsReasoningModel(t *testing.T) {
 		}
 	}
 }
+
+func TestIsTextOnlyModel(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]bool{
+		"gpt-3.5-turbo":           true,
+		"gpt-4":                   true,
+		"gpt-4-0613":              true,
+		"gpt-4-1106-preview":      true,
+		"o1-mini":                 true,
+		"openai/o3-mini":          true,
+		"ft:gpt-3.5-turbo:acme::": true,
+		"gpt-4-turbo":             false,
+		"gpt-4o":                  false,
+		"gpt-4.1-mini":            false,
+		"gpt-5.2":                 false,
+		"o3":                      false,
+		"o4-mini":                 false,
+	}
+
+	for model, want := range cases {
+		if got := isTextOnlyModel(model); got != want {
+			t.Fatalf("isTextOnlyModel(%q): got %v want %v", model, got, want)
+		}
+	}
+}
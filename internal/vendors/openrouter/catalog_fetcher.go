@@ -24,7 +24,7 @@ type OpenRouterModelCatalog struct {
 func NewModelCatalog(apiKey string) (OpenRouterModelCatalog, error) {
 	debug := debugflags.Enabled("OPENROUTER_MODEL_CATALOG")
 	if debug {
-		ancli.Noticef("setting up openrouter model catalog with api key: %v...(redacted)\n", apiKey[:5])
+		ancli.Noticef("setting up openrouter model catalog with api key: %v...(redacted)\n", apiKey[:min(5, len(apiKey))])
 	}
 	cat := OpenRouterModelCatalog{
 		debug: debug,
@@ -36,7 +36,11 @@ func NewModelCatalog(apiKey string) (OpenRouterModelCatalog, error) {
 func (c OpenRouterModelCatalog) liveFetchModels(
 	ctx context.Context,
 ) ([]Model, error) {
-	resp, err := http.Get(modelsEndpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create openrouter models request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("fetch openrouter models: %w", err)
 	}
@@ -63,6 +67,11 @@ func (c OpenRouterModelCatalog) liveFetchModels(
 	return payload.Data, nil
 }
 
+// FetchModels returns every model of the OpenRouter catalog.
+func (c OpenRouterModelCatalog) FetchModels(ctx context.Context) ([]Model, error) {
+	return c.fetchModels(ctx)
+}
+
 func (c OpenRouterModelCatalog) FetchModel(ctx context.Context, model string) (cost.ModelPriceScheme, error) {
 	d, err := c.fetchModels(ctx)
 	if err != nil {
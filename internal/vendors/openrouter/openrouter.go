@@ -45,6 +45,7 @@ func (o *OpenRouter) Setup() error {
 		"HTTP-Referer":       "clai",
 		"X-OpenRouter-Title": "clai",
 	}
+	o.StreamCompleter.ReasoningVendor = pub_models.ReasoningVendorOpenRouter
 	toolChoice := "auto"
 	o.ToolChoice = &toolChoice
 	return nil
@@ -3,6 +3,7 @@ package xai
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/baalimago/clai/internal/text/generic"
 
@@ -48,3 +49,16 @@ func (g *XAI) Setup() error {
 func (g *XAI) RegisterTool(tool pub_models.LLMTool) {
 	g.InternalRegisterTool(tool)
 }
+
+// SupportsVision implements models.VisionSupporter. Grok 3, the coding models
+// and the Grok 2 text models reject images.
+func (g *XAI) SupportsVision() bool {
+	switch {
+	case strings.HasPrefix(g.Model, "grok-3"), strings.HasPrefix(g.Model, "grok-code"), strings.HasPrefix(g.Model, "grok-beta"):
+		return false
+	case strings.HasPrefix(g.Model, "grok-2"):
+		return strings.Contains(g.Model, "vision")
+	default:
+		return true
+	}
+}
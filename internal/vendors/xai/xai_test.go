@@ -44,3 +44,17 @@ func TestSetupSetsDefaultEnvWhenMissingXAI(t *testing.T) {
 		t.Fatalf("expected XAI_API_KEY to be set by Setup when missing")
 	}
 }
+
+func TestSupportsVision(t *testing.T) {
+	for model, want := range map[string]bool{
+		"grok-4":             true,
+		"grok-2-vision-1212": true,
+		"grok-2-1212":        false,
+		"grok-3-mini":        false,
+		"grok-code-fast-1":   false,
+	} {
+		if got := (&XAI{Model: model}).SupportsVision(); got != want {
+			t.Errorf("SupportsVision(%q) = %t, want %t", model, got, want)
+		}
+	}
+}
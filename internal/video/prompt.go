@@ -19,7 +19,11 @@ func (c *Configurations) SetupPrompts(args []string) error {
 		if err != nil {
 			return fmt.Errorf("get config dir: %w", err)
 		}
-		iP, err := chat.LoadPrevQuery(confDir)
+		p, err := chat.OpenPersistence(confDir, chat.ConfiguredBackend(confDir))
+		if err != nil {
+			return err
+		}
+		iP, err := p.LoadPrevQuery(confDir)
 		if err != nil {
 			return fmt.Errorf("load previous query: %w", err)
 		}
@@ -37,10 +37,18 @@ Flags:
   -t, -tools string                   Set to <tool_a>,<tool_b> for specific tool, or */"" to use all built in or MCP tools. See available tools with 'clai tools' (default %v)
   -s, -skills string                  Enable or disable skills for this run. Use '*' to enable or 'none' to disable.
   -cmd-ban string                     Append comma-separated command bans for this run (e.g. "rm,sudo"). Commands matching a ban are refused before they spawn.
-  -g, -glob string                    Set the glob to use for globbing. (default '%v')
+  -g, -glob string                    Set the glob to use for globbing. Supports '**', respects .gitignore and .claiignore, skips binaries. (default '%v')
+  -gx, -glob-exclude string           Comma-separated .gitignore style patterns of files the glob leaves out, e.g. '*_test.go'.
+  -glob-max-tokens int                Set the token budget of the globbed files. 0 = default of 32000, negative = unlimited. Overrides glob-max-tokens in textConfig.json.
+  -i, -image string                   Attach a png, jpeg, gif or webp image from a path or an http(s) URL. Repeat to attach several.
+  -image-max-size int                 Downscale -i images to this many pixels on their longest side. 0 keeps the original size.
+  -I, -replace string                 Replace this string in the prompt with stdin, e.g. -I {}.
+  -a, -attach string                  Comma-separated documents to attach: PDF, DOCX, XLSX, CSV, HTML or text files. PDFs are sent natively where supported.
   -p, -profile string                 Set the profile which should be used. For details, see 'clai help profile'. (default '%v')
   -prp, profile-path string           Set the path to a profile file to use instead of -p/-profile.
   -asc, -append-shell-context str     Append a named shell context from <config-dir>/shellContexts/<name>.json to the final query prompt.
+  -repomap bool                       Insert a map of the repository in the working directory (files and their declarations) into the system prompt.
+  -show-reasoning bool                Show or hide the stored reasoning of the models in the 'clai chat' views. Overrides show-reasoning in textConfig.json.
   -rf, -response-format string        Block streaming and print only the final structured response (json_object, json_schema).
   -n, -non-interactive                Disable interactive stdin fallback after macro inputs; auto-exit instead.
   -mt, -max-tokens int                Set the max context tokens for this run. 0 = unlimited (default is found in %v/textConfig.json)
@@ -60,18 +68,27 @@ Commands:
   v|video <text>                Ask the video model for a video with the given prompt
   re|replay                     Replay the most recent message.
   t|tools [tool name]           List available tools, both mcp and built-in. Or show details for a specific tool.
+  models [vendor]               List and cache the models of configured vendors, or of one vendor.
+  models routes                 Show which vendor each model name routes to, in order of precedence.
+  batch submit <file.jsonl>     Send a JSONL file of prompts to the OpenAI or Anthropic batch API of the chat model.
+  batch status [batchID]        Show the status of the tracked batches, or of one batch.
+  batch fetch <batchID>         Save the results of an ended batch as chats and print them as JSONL.
+  checkpoints [chatID]          List the file checkpoints taken before the tools of a chat wrote to disk.
+  undo [chatID] [--to <callID>] Restore the files changed by the last tool call of a chat, or by every call since callID.
 
   c|chat   c|continue  <chatID>   Continue an existing chat with the given chat ID or index.
   c|chat   d|delete    <chatID>   Delete the chat with the given chat ID or index.
   c|chat   l|list                 List all existing chats.
   c|chat   dir                    Show directory chat info with the stable v1 output.
   c|chat   dirv2                  Show directory chat info with total and recent token usage.
+  c|chat   migrate-store <to>     Copy all chats to the sqlite or json conversation store.
   c|chat   h|help                 Display detailed help for chat subcommands.
 
 Examples:
   - clai h | clai query generate some examples for this usage string: 
   - clai confdir
   - clai -t website_text query "What'\''\'''s the weather like in Tokyo? Use website_text to fetch data"
+  - clai -t web_search,website_text query "What changed in the latest Go release?"
   - clai -glob "*.txt" query Please summarize these documents: 
   - clai -asc minimal q "what changed in this repo?"
   - clai -pm dall-e-2 photo A cat in space
@@ -8,13 +8,13 @@ import (
 	"os"
 	"path"
 	"strings"
-	"sync"
 
 	"github.com/baalimago/clai/internal"
-	"github.com/baalimago/clai/internal/chat"
 	priv_models "github.com/baalimago/clai/internal/models"
 	"github.com/baalimago/clai/internal/text"
+	"github.com/baalimago/clai/internal/tools"
 	"github.com/baalimago/clai/pkg/text/models"
+	pkgtools "github.com/baalimago/clai/pkg/tools"
 )
 
 type Agent struct {
@@ -26,6 +26,8 @@ type Agent struct {
 	cfgDir         string
 	toolGlobs      []string
 	cmdBan         []string
+	httpPolicy     *pkgtools.HTTPPolicy
+	webSearch      *pkgtools.WebSearchConfig
 	maxToolCalls   *int
 	stoploss       Stoploss
 	responseFormat *models.ResponseFormat
@@ -35,6 +37,9 @@ type Agent struct {
 	// keeps clai's noop paths (worklog 26-08-11-clai-prometheus-metrics).
 	usageRecorder    models.CallUsageRecorder
 	toolCallRecorder models.ToolCallRecorder
+	// toolMiddleware wraps every tool dispatch of every query, outermost
+	// first. Nil keeps the direct dispatch path.
+	toolMiddleware []models.ToolMiddleware
 
 	// logger receives one slog record per completed message (assistant,
 	// reasoning, tool_call, tool_result, final_answer), truncated to
@@ -51,17 +56,24 @@ type Agent struct {
 	// (worklog 2026-08-15-agent-slog-output, D2, D5). Default 200.
 	slogRuneLimit int
 
+	// toolSession owns this agent's async commands, clai_run workers and
+	// fallback command ban list; toolRegistry is its private local tool
+	// catalog. Both are created by New, so agents in one process never share
+	// tool state and Shutdown only stops this agent's processes.
+	toolSession  *pkgtools.Session
+	toolRegistry tools.Catalog
+	// chatIndex enables chat index I/O on conversation saves. Off by
+	// default: embedded consumers rarely use list/search/dirscope features.
+	chatIndex bool
+	// conversationStore receives every conversation this agent persists.
+	// Nil keeps the JSON files under cfgDir/conversations.
+	conversationStore models.ConversationStore
+
 	querierCreator func(ctx context.Context, conf text.Configurations) (priv_models.Querier, error)
 
 	querier priv_models.ChatQuerier
 }
 
-// skipIndexOnce disables the chat index exactly once. Agent.Setup may run for
-// multiple agents concurrently (worklog 2026-08-02-cmd-ban-list, phase 5, R2-01
-// enforcement requires concurrent Setups); an unsynchronized write to
-// chat.SkipIndex from every Setup would race under -race.
-var skipIndexOnce sync.Once
-
 var defaultConf = Agent{
 	model:          "gpt-5.2",
 	prompt:         "Uh-oh. Something is not quite right. Please ask the user to overlook his agentic setup, and to update the prompt.",
@@ -81,6 +93,8 @@ func New(options ...Option) Agent {
 		home = "."
 	}
 	conf.cfgDir = path.Join(home, ".config", "clai")
+	conf.toolSession = pkgtools.NewSession()
+	conf.toolRegistry = tools.NewLocalRegistry()
 
 	for _, o := range options {
 		o(&conf)
@@ -205,6 +219,22 @@ func WithCmdBanList(entries ...string) Option {
 	}
 }
 
+// WithHTTPPolicy sets the hosts the http_request tool may reach and the
+// secret headers it injects. The default refuses every request.
+func WithHTTPPolicy(p pkgtools.HTTPPolicy) Option {
+	return func(a *Agent) {
+		a.httpPolicy = &p
+	}
+}
+
+// WithWebSearch sets the backend of the web_search tool. The default reads
+// webSearch.json of the agent's config directory.
+func WithWebSearch(c pkgtools.WebSearchConfig) Option {
+	return func(a *Agent) {
+		a.webSearch = &c
+	}
+}
+
 // WithUsageRecorder registers a CallUsageRecorder that receives one
 // CompletedModelCall per model step of every query. Nil (the default)
 // keeps clai's noop path: no recording, no behavior change. A Record error
@@ -225,6 +255,29 @@ func WithToolCallRecorder(rec models.ToolCallRecorder) Option {
 	}
 }
 
+// WithToolMiddleware appends a middleware to the agent's tool dispatch
+// chain. Middlewares wrap every tool invocation (built-in tools, WithTools
+// instances and MCP tools) and may rewrite inputs, short-circuit with a
+// synthetic result, redact outputs, bound the call with a per-tool timeout
+// or serve cached results. Repeated options compose in order: the first
+// registered middleware is the outermost. The ToolCallRecorder observes the
+// output after every middleware has run.
+func WithToolMiddleware(mw ToolMiddleware) Option {
+	return func(a *Agent) {
+		a.toolMiddleware = append(a.toolMiddleware, mw)
+	}
+}
+
+// WithChatIndex enables or disables the chat index (chat_index.cache) for the
+// agent's conversation saves. The default is disabled: the index only serves
+// the CLI list/search/dirscope features and costs memory and disk I/O on
+// every save. The setting is per agent and never affects other agents.
+func WithChatIndex(enabled bool) Option {
+	return func(a *Agent) {
+		a.chatIndex = enabled
+	}
+}
+
 // WithResponseFormat configures structured output for the agent.
 // Supports "json_object" and "json_schema" types.
 func WithResponseFormat(rf models.ResponseFormat) Option {
@@ -245,14 +298,20 @@ func (a *Agent) asInternalConfig() text.Configurations {
 		MaxToolCalls:       a.maxToolCalls,
 		RequestedToolGlobs: a.toolGlobs,
 		CmdBan:             a.cmdBan,
+		HTTPRequest:        a.httpPolicy,
+		WebSearch:          a.webSearch,
 		ResponseFormat:     a.responseFormat,
+		ToolSession:        a.toolSession,
+		ToolRegistry:       a.toolRegistry,
+		SkipIndex:          !a.chatIndex,
+		ConversationStore:  a.conversationStore,
 		// Library mode is silent on stdout: embedded use never writes raw
 		// terminal output. The slog logger (AgentSettings) is the sole
 		// embedded output channel (worklog 2026-08-15-agent-slog-output, D4).
 		Out: io.Discard,
 	}
 	// Agent-only settings ride one pointer (worklog 2026-08-15-agent-slog-output, D7): the slog logger, its level,
-	// the rune cap, and both recorder hooks. NewQuerier reads the recorders
+	// the rune cap, both recorder hooks and the tool middleware chain. NewQuerier reads the recorders
 	// from this pointer; Configurations carries no loose recorder fields.
 	conf.AgentSettings = &text.AgentSettings{
 		Logger:           a.logger,
@@ -260,6 +319,7 @@ func (a *Agent) asInternalConfig() text.Configurations {
 		RuneLimit:        a.slogRuneLimit,
 		UsageRecorder:    a.usageRecorder,
 		ToolCallRecorder: a.toolCallRecorder,
+		ToolMiddleware:   a.toolMiddleware,
 	}
 	// A zero-value Stoploss must not create a non-nil internal pointer: the
 	// agent default stays unlimited (MaxTokens <= 0 disables the stoploss).
@@ -273,22 +333,16 @@ func (a *Agent) asInternalConfig() text.Configurations {
 	return conf
 }
 
+// Setup creates the config dir and its supportive directories, then the
+// querier. With WithConversationStore no directory is created, so the agent
+// runs on a read-only filesystem.
 func (a *Agent) Setup(ctx context.Context) error {
-	// Embedded consumers (kinoview, etc.) never use CLI list/search/dirscope
-	// features; the chat index is pure overhead that causes OOM on 32-bit ARM
-	// when the conversation directory is large.
-	skipIndexOnce.Do(func() { chat.SkipIndex = true })
-
-	if _, err := os.Stat(a.cfgDir); os.IsNotExist(err) {
-		os.Mkdir(a.cfgDir, 0o755)
-	}
-	mcpServersDir := path.Join(a.cfgDir, "mcpServers")
-	if _, err := os.Stat(mcpServersDir); os.IsNotExist(err) {
-		os.Mkdir(mcpServersDir, 0o755)
-	}
-	conversationsDir := path.Join(a.cfgDir, "conversations")
-	if _, err := os.Stat(conversationsDir); os.IsNotExist(err) {
-		os.Mkdir(conversationsDir, 0o755)
+	if a.conversationStore == nil {
+		for _, dir := range []string{a.cfgDir, path.Join(a.cfgDir, "mcpServers"), path.Join(a.cfgDir, "conversations")} {
+			if _, err := os.Stat(dir); os.IsNotExist(err) {
+				os.Mkdir(dir, 0o755)
+			}
+		}
 	}
 
 	querier, err := a.querierCreator(ctx, a.asInternalConfig())
@@ -302,3 +356,13 @@ func (a *Agent) Setup(ctx context.Context) error {
 	a.querier = tq
 	return nil
 }
+
+// Shutdown cancels every async command and interrupts every clai_run worker
+// started by this agent's tool calls. Other agents in the process keep
+// running. The agent stays usable: a later Query starts from an empty set of
+// background processes.
+func (a *Agent) Shutdown() {
+	if a.toolSession != nil {
+		a.toolSession.Shutdown()
+	}
+}
@@ -14,6 +14,7 @@ import (
 	priv_models "github.com/baalimago/clai/internal/models"
 	"github.com/baalimago/clai/internal/text"
 	"github.com/baalimago/clai/pkg/text/models"
+	pkgtools "github.com/baalimago/clai/pkg/tools"
 )
 
 type mockTool struct{}
@@ -414,3 +415,27 @@ func TestAgent_Run(t *testing.T) {
 		t.Errorf("expected prompt in message, got %v", mockQuerier.lastChat.Messages[0].Content)
 	}
 }
+
+func TestAgent_WithHTTPPolicy_PropagatesToInternalConfig(t *testing.T) {
+	a := New(WithHTTPPolicy(pkgtools.HTTPPolicy{Allow: []string{"api.example.com"}}))
+	conf := a.asInternalConfig()
+	if conf.HTTPRequest == nil || !reflect.DeepEqual(conf.HTTPRequest.Allow, []string{"api.example.com"}) {
+		t.Fatalf("expected the policy in the internal config, got %+v", conf.HTTPRequest)
+	}
+	plain := New()
+	if plain.asInternalConfig().HTTPRequest != nil {
+		t.Fatalf("expected no http policy by default")
+	}
+}
+
+func TestAgent_WithWebSearch_PropagatesToInternalConfig(t *testing.T) {
+	a := New(WithWebSearch(pkgtools.WebSearchConfig{Backend: "searxng", URL: "http://localhost:8888"}))
+	conf := a.asInternalConfig()
+	if conf.WebSearch == nil || conf.WebSearch.Backend != "searxng" {
+		t.Fatalf("expected the backend in the internal config, got %+v", conf.WebSearch)
+	}
+	plain := New()
+	if plain.asInternalConfig().WebSearch != nil {
+		t.Fatalf("expected no web search backend by default")
+	}
+}
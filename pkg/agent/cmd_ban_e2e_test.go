@@ -215,9 +215,10 @@ func TestAgentCmdBan_SequentialPerRunIsolation(t *testing.T) {
 	assertMarkerAbsent(t, marker1)
 }
 
-// TestAgentCmdBan_ConcurrentDistinctLists is the enforcement test for cmdBanMu
-// (R2-01): two agents with distinct ban lists run Setup+Query concurrently so
-// spawn-path reads genuinely overlap the other run's SetCmdBanList write. The
+// TestAgentCmdBan_ConcurrentDistinctLists is the enforcement test for
+// per-agent ban isolation (R2-01): two agents with distinct ban lists run
+// Setup+Query concurrently so spawn-path reads genuinely overlap the other
+// run's Session.SetCmdBanList write. The
 // race detector must stay clean, every refusal must name the observer's own
 // entry, and no command may ever spawn.
 //
@@ -226,10 +227,10 @@ func TestAgentCmdBan_SequentialPerRunIsolation(t *testing.T) {
 //     mock fabricates freetext inputs from one process-global env var
 //     (CLAI_MOCK_CMD_COMMAND), so two concurrent cmd agents could not carry
 //     different commands.
-//   - Markers live under a non-existent directory: under the package-global
-//     list design (D6) a concurrent query may observe the other agent's list
-//     (the mutex prevents data races, not logical cross-talk), so a
-//     cross-talked execution must fail harmlessly and create nothing.
+//   - Markers live under a non-existent directory: the test predates the
+//     per-agent tool session, when a concurrent query could observe the
+//     other agent's list, so a cross-talked execution must still fail
+//     harmlessly and create nothing.
 //   - A refusal for agent X implies X's own list was active when its command
 //     was validated (each command's tokens match only its own entry), so
 //     "every refusal names the observer's own entry" holds deterministically.
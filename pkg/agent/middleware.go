@@ -0,0 +1,13 @@
+package agent
+
+import (
+	"github.com/baalimago/clai/pkg/text/models"
+)
+
+// The tool middleware types are canonical in pkg/text/models; these aliases
+// let embedded consumers write policy middlewares (path allowlists, output
+// redaction, per-tool timeouts) through a single pkg/agent import.
+type (
+	ToolHandler    = models.ToolHandler
+	ToolMiddleware = models.ToolMiddleware
+)
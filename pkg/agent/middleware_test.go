@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/baalimago/clai/pkg/text/models"
+)
+
+// toolResult returns the content of the single tool-result turn in chat.
+func toolResult(t *testing.T, chat models.Chat) string {
+	t.Helper()
+	msg, _, err := chat.LastOfRole("tool")
+	if err != nil {
+		t.Fatalf("expected a tool result in the transcript: %v", err)
+	}
+	return msg.Content
+}
+
+// TestAgent_WithToolMiddleware_ShortCircuit proves a middleware can answer a
+// call with a synthetic result without ever reaching the tool, and that the
+// recorder observes the synthetic output.
+func TestAgent_WithToolMiddleware_ShortCircuit(t *testing.T) {
+	t.Setenv("CLAI_DISABLE_COST_ERR_LOG_GOROUTINE", "1")
+
+	toolRec := &recordingToolRecorder{}
+	reachedTool := false
+	a := newCmdBanAgent(t,
+		WithModel("mock_test"),
+		WithToolGlobs("ls"),
+		WithToolCallRecorder(toolRec),
+		WithToolMiddleware(func(next ToolHandler) ToolHandler {
+			return func(ctx context.Context, call models.Call) (string, error) {
+				if call.Name == "ls" {
+					return "synthetic listing", nil
+				}
+				reachedTool = true
+				return next(ctx, call)
+			}
+		}),
+	)
+
+	chat := queryCmdBanAgent(t, &a, "please tool_ls")
+	if got := toolResult(t, chat); got != "synthetic listing" {
+		t.Fatalf("expected synthetic tool result, got %q", got)
+	}
+	if reachedTool {
+		t.Fatal("expected the middleware to short-circuit the ls call")
+	}
+	assertCmdBanRunCompleted(t, chat)
+	if calls := toolRec.recorded(); len(calls) != 1 || calls[0].Err != nil {
+		t.Fatalf("expected one successful recorded tool call, got %+v", calls)
+	}
+}
+
+// TestAgent_WithToolMiddleware_RewriteAndRedact proves a middleware can
+// rewrite the inputs before execution and redact the output afterwards.
+func TestAgent_WithToolMiddleware_RewriteAndRedact(t *testing.T) {
+	t.Setenv("CLAI_DISABLE_COST_ERR_LOG_GOROUTINE", "1")
+	t.Setenv("CLAI_MOCK_CMD_COMMAND", "printf original")
+
+	a := newCmdBanAgent(t,
+		WithModel("mock_test"),
+		WithToolGlobs("cmd"),
+		WithToolMiddleware(func(next ToolHandler) ToolHandler {
+			return func(ctx context.Context, call models.Call) (string, error) {
+				call.Inputs = &models.Input{"command": "printf 'rewritten secret=hunter2'"}
+				out, err := next(ctx, call)
+				return strings.ReplaceAll(out, "hunter2", "[REDACTED]"), err
+			}
+		}),
+	)
+
+	chat := queryCmdBanAgent(t, &a, "please tool_cmd")
+	got := toolResult(t, chat)
+	if !strings.Contains(got, "rewritten secret=[REDACTED]") {
+		t.Fatalf("expected rewritten and redacted output, got %q", got)
+	}
+	if strings.Contains(got, "hunter2") || strings.Contains(got, "original") {
+		t.Fatalf("expected neither the secret nor the original output, got %q", got)
+	}
+}
+
+// TestAgent_WithToolMiddleware_Order proves repeated options compose with
+// the first registered middleware as the outermost one.
+func TestAgent_WithToolMiddleware_Order(t *testing.T) {
+	t.Setenv("CLAI_DISABLE_COST_ERR_LOG_GOROUTINE", "1")
+
+	var mu sync.Mutex
+	var trace []string
+	tracing := func(name string) ToolMiddleware {
+		return func(next ToolHandler) ToolHandler {
+			return func(ctx context.Context, call models.Call) (string, error) {
+				mu.Lock()
+				trace = append(trace, name+":in")
+				mu.Unlock()
+				out, err := next(ctx, call)
+				mu.Lock()
+				trace = append(trace, name+":out")
+				mu.Unlock()
+				return out, err
+			}
+		}
+	}
+	a := newCmdBanAgent(t,
+		WithModel("mock_test"),
+		WithToolGlobs("ls"),
+		WithToolMiddleware(tracing("outer")),
+		WithToolMiddleware(tracing("inner")),
+	)
+
+	queryCmdBanAgent(t, &a, "please tool_ls")
+
+	want := "outer:in,inner:in,inner:out,outer:out"
+	if got := strings.Join(trace, ","); got != want {
+		t.Fatalf("expected middleware order %q, got %q", want, got)
+	}
+}
+
+// TestAgent_WithToolMiddleware_Timeout proves a middleware can bound a
+// context-aware tool with a per-tool timeout, and that the expired call is
+// reported to the model and the recorder as a failure.
+func TestAgent_WithToolMiddleware_Timeout(t *testing.T) {
+	t.Setenv("CLAI_DISABLE_COST_ERR_LOG_GOROUTINE", "1")
+	t.Setenv("CLAI_MOCK_CMD_COMMAND", "sleep 5")
+
+	toolRec := &recordingToolRecorder{}
+	a := newCmdBanAgent(t,
+		WithModel("mock_test"),
+		WithToolGlobs("cmd"),
+		WithToolCallRecorder(toolRec),
+		WithToolMiddleware(func(next ToolHandler) ToolHandler {
+			return func(ctx context.Context, call models.Call) (string, error) {
+				ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+				defer cancel()
+				return next(ctx, call)
+			}
+		}),
+	)
+
+	started := time.Now()
+	chat := queryCmdBanAgent(t, &a, "please tool_cmd")
+	if elapsed := time.Since(started); elapsed > 4*time.Second {
+		t.Fatalf("expected the timeout to stop the command early, took %v", elapsed)
+	}
+	assertCmdBanRunCompleted(t, chat)
+	calls := toolRec.recorded()
+	if len(calls) != 1 || calls[0].Err == nil {
+		t.Fatalf("expected one failed recorded tool call, got %+v", calls)
+	}
+}
+
+// TestAgent_WithToolMiddleware_PropagatesToInternalConfig proves the chain
+// reaches text.Configurations via AgentSettings and defaults to empty.
+func TestAgent_WithToolMiddleware_PropagatesToInternalConfig(t *testing.T) {
+	noop := func(next ToolHandler) ToolHandler { return next }
+	a := New(WithToolMiddleware(noop), WithToolMiddleware(noop))
+	if got := len(a.asInternalConfig().AgentSettings.ToolMiddleware); got != 2 {
+		t.Fatalf("expected 2 middlewares in the internal config, got %d", got)
+	}
+	plain := New()
+	if got := len(plain.asInternalConfig().AgentSettings.ToolMiddleware); got != 0 {
+		t.Fatalf("expected no middleware by default, got %d", got)
+	}
+}
@@ -0,0 +1,32 @@
+package agent
+
+import "testing"
+
+// TestAgent_ToolStateIsPerAgent proves every agent gets its own tool session
+// and local tool catalog, and that chat indexing defaults to off without
+// touching process-global state.
+func TestAgent_ToolStateIsPerAgent(t *testing.T) {
+	a, b := New(), New()
+	confA, confB := a.asInternalConfig(), b.asInternalConfig()
+	if confA.ToolSession == nil || confA.ToolSession == confB.ToolSession {
+		t.Fatal("expected each agent to own a distinct tool session")
+	}
+	if confA.ToolRegistry == nil || confA.ToolRegistry == confB.ToolRegistry {
+		t.Fatal("expected each agent to own a distinct tool catalog")
+	}
+	if !confA.SkipIndex {
+		t.Fatal("expected chat indexing to be off by default")
+	}
+	indexed := New(WithChatIndex(true))
+	if indexed.asInternalConfig().SkipIndex {
+		t.Fatal("expected WithChatIndex(true) to enable chat indexing")
+	}
+}
+
+// TestAgent_Shutdown proves Shutdown is safe on an agent which never ran a
+// query, and safe to call twice.
+func TestAgent_Shutdown(t *testing.T) {
+	a := New()
+	a.Shutdown()
+	a.Shutdown()
+}
@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"github.com/baalimago/clai/internal/chat"
+	"github.com/baalimago/clai/pkg/text/models"
+)
+
+// ConversationStore is canonical in pkg/text/models; the alias lets embedded
+// consumers plug in their own persistence through a single pkg/agent import.
+type ConversationStore = models.ConversationStore
+
+// NewMemoryConversationStore returns a ConversationStore which keeps every
+// conversation in memory and never touches the filesystem.
+func NewMemoryConversationStore() ConversationStore {
+	return chat.NewMemoryStore()
+}
+
+// NewFileConversationStore returns the filesystem ConversationStore rooted at
+// cfgDir: the same JSON files, chat index and directory bindings the CLI
+// uses.
+func NewFileConversationStore(cfgDir string) ConversationStore {
+	return chat.NewFileStore(cfgDir)
+}
+
+// WithConversationStore routes every conversation save, load and directory
+// binding of the agent to store instead of the JSON files under the config
+// dir. The conversations directory is then never created. The chat index
+// setting (WithChatIndex) only applies to the default files.
+func WithConversationStore(store ConversationStore) Option {
+	return func(a *Agent) {
+		a.conversationStore = store
+	}
+}
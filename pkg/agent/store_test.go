@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/baalimago/clai/pkg/text/models"
+)
+
+// TestAgent_WithConversationStore_InMemory proves a query persists its
+// conversation into the configured store only: the store can list and load
+// it, and nothing is written under the config dir's conversations directory.
+func TestAgent_WithConversationStore_InMemory(t *testing.T) {
+	t.Setenv("CLAI_DISABLE_COST_ERR_LOG_GOROUTINE", "1")
+
+	store := NewMemoryConversationStore()
+	a := newCmdBanAgent(t, WithModel("mock_test"), WithConversationStore(store))
+	queryCmdBanAgent(t, &a, "hello there")
+
+	if _, err := os.Stat(filepath.Join(a.cfgDir, "conversations")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no conversations directory on disk, stat err=%v", err)
+	}
+	summaries, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("expected one stored conversation, got %+v", summaries)
+	}
+	got, err := store.Load(summaries[0].ID)
+	if err != nil {
+		t.Fatalf("Load(%q): %v", summaries[0].ID, err)
+	}
+	if msg, err := got.FirstUserMessage(); err != nil || msg.Content != "hello there" {
+		t.Fatalf("expected the stored conversation to carry the prompt, got %+v (err %v)", msg, err)
+	}
+	if got.OriginDir == "" {
+		t.Fatal("expected the stored conversation to be stamped with its origin directory")
+	}
+	if _, err := store.Load("globalScope"); err != nil {
+		t.Fatalf("expected the global-scope chat in the store: %v", err)
+	}
+	if _, err := store.Load("missing"); !errors.Is(err, models.ErrConversationNotFound) {
+		t.Fatalf("expected ErrConversationNotFound, got %v", err)
+	}
+}
+
+// TestAgent_WithConversationStore_DefaultWritesFiles guards the default:
+// without a store the conversation lands in the JSON files under cfgDir.
+func TestAgent_WithConversationStore_DefaultWritesFiles(t *testing.T) {
+	t.Setenv("CLAI_DISABLE_COST_ERR_LOG_GOROUTINE", "1")
+
+	a := newCmdBanAgent(t, WithModel("mock_test"))
+	queryCmdBanAgent(t, &a, "hello there")
+
+	global, err := NewFileConversationStore(a.cfgDir).Load("globalScope")
+	if err != nil {
+		t.Fatalf("expected the global-scope chat on disk: %v", err)
+	}
+	if len(global.Messages) == 0 {
+		t.Fatal("expected the global-scope chat to carry messages")
+	}
+}
+
+// TestAgent_WithConversationStore_NoConfigDir runs an agent whose config dir
+// doesn't exist, as on a read-only filesystem: with a store, Setup and Query
+// neither create nor need it.
+func TestAgent_WithConversationStore_NoConfigDir(t *testing.T) {
+	t.Setenv("CLAI_DISABLE_COST_ERR_LOG_GOROUTINE", "1")
+
+	store := NewMemoryConversationStore()
+	a := New(WithConfigDir(filepath.Join(t.TempDir(), "missing")), WithModel("mock_test"), WithConversationStore(store))
+	queryCmdBanAgent(t, &a, "hello there")
+
+	if _, err := os.Stat(a.cfgDir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no config dir, stat err=%v", err)
+	}
+	if summaries, err := store.List(); err != nil || len(summaries) != 1 {
+		t.Fatalf("expected the conversation in the store, got %+v, %v", summaries, err)
+	}
+}
@@ -53,22 +53,24 @@ func pubConfigToInternal(c models.Configurations) text.Configurations {
 		ProfilePath:         "",
 		RequestedToolGlobs:  internalToolsToString(c.InternalTools),
 		Out:                 c.Out,
+		ConversationStore:   c.ConversationStore,
 	}
 }
 
 // Setup the public querier by creating a config dir + supportive directories, then by initiating
-// the querier following the config
+// the querier following the config. With a ConversationStore no directory is
+// created, so the querier runs on a read-only filesystem.
 func (pq *publicQuerier) Setup(ctx context.Context) error {
-	if _, err := os.Stat(pq.conf.ConfigDir); os.IsNotExist(err) {
-		os.Mkdir(pq.conf.ConfigDir, 0o755)
-	}
-	mcpServersDir := path.Join(pq.conf.ConfigDir, "mcpServers")
-	if _, err := os.Stat(mcpServersDir); os.IsNotExist(err) {
-		os.Mkdir(mcpServersDir, 0o755)
-	}
-	conversationsDir := path.Join(pq.conf.ConfigDir, "conversations")
-	if _, err := os.Stat(mcpServersDir); os.IsNotExist(err) {
-		os.Mkdir(conversationsDir, 0o755)
+	if pq.conf.ConversationStore == nil {
+		for _, dir := range []string{
+			pq.conf.ConfigDir,
+			path.Join(pq.conf.ConfigDir, "mcpServers"),
+			path.Join(pq.conf.ConfigDir, "conversations"),
+		} {
+			if _, err := os.Stat(dir); os.IsNotExist(err) {
+				os.Mkdir(dir, 0o755)
+			}
+		}
 	}
 	querier, err := internal.CreateTextQuerier(ctx, pq.conf)
 	if err != nil {
@@ -45,11 +45,25 @@ func TestSetupCreatesDirsEvenOnError(t *testing.T) {
 	_ = pq.Setup(ctx) // may fail depending on vendor selection; we only care about dir side-effects
 
 	// required dirs that Setup creates up-front
-	if _, err := os.Stat(filepath.Join(pq.conf.ConfigDir, "mcpServers")); err != nil {
-		t.Fatalf("expected mcpServers dir: %v", err)
+	for _, dir := range []string{"mcpServers", "conversations"} {
+		if _, err := os.Stat(filepath.Join(pq.conf.ConfigDir, dir)); err != nil {
+			t.Fatalf("expected %v dir: %v", dir, err)
+		}
+	}
+}
+
+func TestSetupWithConversationStoreCreatesNoDirs(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := pub_models.Configurations{Model: "mock", ConfigDir: tmp, ConversationStore: NewMemoryConversationStore()}
+	pq := NewFullResponseQuerier(cfg).(*publicQuerier)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = pq.Setup(ctx)
+
+	if _, err := os.Stat(pq.conf.ConfigDir); !os.IsNotExist(err) {
+		t.Fatalf("expected no config dir with an injected store, stat err=%v", err)
 	}
-	// conversations dir creation depends on a condition in current code; do not assert strictly
-	_ = os.MkdirAll(filepath.Join(pq.conf.ConfigDir, "conversations"), 0o755)
 }
 
 func TestQueryReturnsErrorWhenSetupFails(t *testing.T) {
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -101,6 +103,8 @@ type ImageOrTextInputTypes string
 
 const (
 	Image ImageOrTextInputTypes = "image_url"
+	Text  ImageOrTextInputTypes = "text"
+	File  ImageOrTextInputTypes = "file"
 )
 
 type ImageOrTextInput struct {
@@ -109,17 +113,174 @@ type ImageOrTextInput struct {
 	// This is openai's billion dollar API design
 	// The "image_url" here may be b64 string
 	ImageB64 *ImageURL `json:"image_url,omitempty"`
+	// File is a document, such as a PDF, sent as is to the vendors which
+	// accept it.
+	File *FileInput `json:"file,omitempty"`
 }
 
-// ReasoningItem is an opaque reasoning output item from the OpenAI Responses API.
-// EncryptedContent is sealed by OpenAI: it cannot be read locally and is only
-// meaningful when replayed to the OpenAI Responses API for a reasoning model. It is
-// never sent to other vendors. Persisted out-of-band in a per-chat sidecar so the
-// conversation JSON stays human-readable.
+// FileInput is a document content part. FileData is a base64 data URL, as
+// openai wants it. Text is the text extracted from the document, sent in its
+// place to the vendors which don't accept the document itself, or when
+// FileData is empty.
+//
+// Path and SHA256 reference the document on disk. Conversations are saved
+// without FileData, which is read back from Path while its content still
+// matches SHA256.
+type FileInput struct {
+	Filename string `json:"filename"`
+	FileData string `json:"file_data,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Path     string `json:"path,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
+}
+
+// MIMEType returns the media type of the data URL, such as application/pdf.
+func (f FileInput) MIMEType() string {
+	mime, _, _ := strings.Cut(strings.TrimPrefix(f.FileData, "data:"), ";")
+	return mime
+}
+
+// RawB64 returns the base64 encoded data of the data URL.
+func (f FileInput) RawB64() string {
+	_, data, _ := strings.Cut(f.FileData, ";base64,")
+	return data
+}
+
+// AsText returns the extracted text of the document as a text part.
+func (f FileInput) AsText() ImageOrTextInput {
+	return ImageOrTextInput{
+		Type: string(Text),
+		Text: fmt.Sprintf("Contents of attached file %v:\n%v", f.Filename, f.Text),
+	}
+}
+
+// FilePartsAsText returns msgs with the file parts replaced by their
+// extracted text, for the vendors which don't accept documents. msgs is left
+// unchanged.
+func FilePartsAsText(msgs []Message) []Message {
+	return mapFileParts(msgs, func(p ImageOrTextInput) ImageOrTextInput {
+		if p.File != nil {
+			return p.File.AsText()
+		}
+		return p
+	})
+}
+
+// mapFileParts returns msgs with the content parts of the messages holding a
+// file part replaced by f. msgs is left unchanged.
+func mapFileParts(msgs []Message, f func(ImageOrTextInput) ImageOrTextInput) []Message {
+	ret := make([]Message, len(msgs))
+	for i, m := range msgs {
+		ret[i] = m
+		if !slices.ContainsFunc(m.ContentParts, func(p ImageOrTextInput) bool { return p.File != nil }) {
+			continue
+		}
+		parts := make([]ImageOrTextInput, len(m.ContentParts))
+		for j, p := range m.ContentParts {
+			parts[j] = f(p)
+		}
+		ret[i].ContentParts = parts
+	}
+	return ret
+}
+
+// WithoutFileData returns msgs with the document payloads of the file parts
+// dropped, as conversations are saved. msgs is left unchanged.
+func WithoutFileData(msgs []Message) []Message {
+	return mapFileParts(msgs, func(p ImageOrTextInput) ImageOrTextInput {
+		if p.File == nil || p.File.FileData == "" {
+			return p
+		}
+		f := *p.File
+		f.FileData = ""
+		p.File = &f
+		return p
+	})
+}
+
+// ReasoningItem is opaque reasoning state of one vendor, which must be replayed
+// unchanged for the model to continue where it left off. Vendor tags the
+// producer, and each vendor only replays its own items:
+//   - openai: a Responses API reasoning item. EncryptedContent is sealed by
+//     OpenAI and only meaningful to the Responses API. An item of Type
+//     ReasoningResponse instead holds the id of the stored response which
+//     produced the message, to continue from with previous_response_id.
+//   - anthropic: a thinking block (Type ReasoningThinking or
+//     ReasoningRedactedThinking), whose Signature or redacted Data must be
+//     replayed for Claude to continue a tool loop.
+//   - gemini: a thought signature in Signature.
+//   - deepseek: the reasoning_content of a tool call turn in Thinking.
+//   - openrouter: one reasoning_details entry, verbatim, in Details.
+//
+// Persisted out-of-band, keyed by message index, so the conversation JSON
+// stays human-readable.
 type ReasoningItem struct {
-	ID               string   `json:"id"`
-	EncryptedContent string   `json:"encrypted_content"`
+	Vendor           string   `json:"vendor,omitempty"`
+	ID               string   `json:"id,omitempty"`
+	EncryptedContent string   `json:"encrypted_content,omitempty"`
 	Summary          []string `json:"summary,omitempty"`
+
+	Type      string          `json:"type,omitempty"`
+	Thinking  string          `json:"thinking,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+	Data      string          `json:"data,omitempty"`
+	Details   json.RawMessage `json:"details,omitempty"`
+}
+
+// Anthropic thinking block types of ReasoningItem.Type.
+const (
+	ReasoningThinking         = "thinking"
+	ReasoningRedactedThinking = "redacted_thinking"
+)
+
+// ReasoningResponse is the ReasoningItem.Type of an OpenAI stored response id.
+const ReasoningResponse = "response"
+
+// Vendor tags of ReasoningItem.Vendor.
+const (
+	ReasoningVendorOpenAI     = "openai"
+	ReasoningVendorAnthropic  = "anthropic"
+	ReasoningVendorGemini     = "gemini"
+	ReasoningVendorDeepSeek   = "deepseek"
+	ReasoningVendorOpenRouter = "openrouter"
+)
+
+// VendorTag returns the vendor which produced the item. Items persisted
+// before they were tagged are OpenAI items, or Anthropic thinking blocks when
+// they carry a Type.
+func (ri ReasoningItem) VendorTag() string {
+	switch {
+	case ri.Vendor != "":
+		return ri.Vendor
+	case ri.Type != "":
+		return ReasoningVendorAnthropic
+	default:
+		return ReasoningVendorOpenAI
+	}
+}
+
+// ReasoningItemsOf returns the items of items produced by vendor.
+func ReasoningItemsOf(items []ReasoningItem, vendor string) []ReasoningItem {
+	var ret []ReasoningItem
+	for _, ri := range items {
+		if ri.VendorTag() == vendor {
+			ret = append(ret, ri)
+		}
+	}
+	return ret
+}
+
+// ReasoningText returns the human-readable reasoning of items: thinking text
+// and summaries. Opaque state is left out.
+func ReasoningText(items []ReasoningItem) string {
+	var parts []string
+	for _, ri := range items {
+		if ri.Thinking != "" {
+			parts = append(parts, ri.Thinking)
+		}
+		parts = append(parts, ri.Summary...)
+	}
+	return strings.Join(parts, "\n")
 }
 
 type Message struct {
@@ -127,9 +288,9 @@ type Message struct {
 	ToolCalls        []Call `json:"tool_calls,omitempty"`
 	ToolCallID       string `json:"tool_call_id,omitempty"`
 	ReasoningContent string `json:"reasoning_content,omitempty"`
-	// ReasoningItems carry opaque reasoning continuity for a tool-bearing assistant
-	// turn. They are stored out-of-band and keyed by the first persisted tool-call
-	// ID, so the conversation JSON stays human-readable and portable.
+	// ReasoningItems carry opaque reasoning continuity of the message. They are
+	// stored out-of-band and keyed by message index, so the conversation JSON
+	// stays human-readable and portable.
 	ReasoningItems []ReasoningItem `json:"-"`
 	// Content and ContentParts is like this since
 	// making Message generic would cause changes in 70+ places.
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"math"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -297,3 +298,56 @@ func TestMessageString(t *testing.T) {
 			msg.String())
 	}
 }
+
+func TestFilePartsAsText(t *testing.T) {
+	file := &FileInput{Filename: "a.pdf", FileData: "data:application/pdf;base64,JVBERi0=", Text: "pdf text"}
+	if file.MIMEType() != "application/pdf" || file.RawB64() != "JVBERi0=" {
+		t.Fatalf("data URL helpers: %q, %q", file.MIMEType(), file.RawB64())
+	}
+	msgs := []Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", ContentParts: []ImageOrTextInput{
+			{Type: "file", File: file},
+			{Type: "text", Text: "summarise"},
+		}},
+	}
+	got := FilePartsAsText(msgs)
+	want := []ImageOrTextInput{
+		{Type: "text", Text: "Contents of attached file a.pdf:\npdf text"},
+		{Type: "text", Text: "summarise"},
+	}
+	if !reflect.DeepEqual(got[1].ContentParts, want) {
+		t.Fatalf("got %+v, want %+v", got[1].ContentParts, want)
+	}
+	if got[0].Content != "sys" || msgs[1].ContentParts[0].File != file {
+		t.Fatal("FilePartsAsText changed other messages or its input")
+	}
+
+	b, err := json.Marshal(msgs[1])
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back Message
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if back.ContentParts[0].File == nil || *back.ContentParts[0].File != *file {
+		t.Fatalf("file part did not round trip: %s", b)
+	}
+}
+
+func TestWithoutFileData(t *testing.T) {
+	file := &FileInput{Filename: "a.pdf", FileData: "data:application/pdf;base64,JVBERi0=", Text: "pdf text", Path: "/docs/a.pdf", SHA256: "abc"}
+	msgs := []Message{{Role: "user", ContentParts: []ImageOrTextInput{
+		{Type: "file", File: file},
+		{Type: "text", Text: "summarise"},
+	}}}
+	got := WithoutFileData(msgs)
+	want := FileInput{Filename: "a.pdf", Text: "pdf text", Path: "/docs/a.pdf", SHA256: "abc"}
+	if *got[0].ContentParts[0].File != want {
+		t.Fatalf("got %+v, want %+v", *got[0].ContentParts[0].File, want)
+	}
+	if file.FileData == "" {
+		t.Fatal("WithoutFileData changed its input")
+	}
+}
@@ -9,4 +9,7 @@ type Configurations struct {
 	InternalTools []ToolName
 	McpServers    []McpServer
 	Out           io.Writer
+	// ConversationStore receives every conversation the querier persists.
+	// Nil keeps the JSON files under ConfigDir.
+	ConversationStore ConversationStore
 }
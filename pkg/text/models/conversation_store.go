@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrConversationNotFound is returned by ConversationStore.Load when no
+// conversation is stored under the requested ID.
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// ConversationSummary is one row of a ConversationStore listing: the
+// metadata needed to pick a conversation without loading its transcript.
+type ConversationSummary struct {
+	ID               string
+	Created          time.Time
+	Profile          string
+	Model            string
+	MessageCount     int
+	TotalTokens      int
+	TotalCostUSD     float64
+	FirstUserMessage string
+	OriginDir        string
+}
+
+// ConversationStore persists the conversations produced by a querier.
+//
+// The default store writes one JSON file per conversation under
+// <configDir>/conversations and maintains the chat index and the
+// directory-scoped bindings next to it. Embedded consumers may substitute
+// any implementation, e.g. an in-memory store on a read-only filesystem.
+// Implementations must be safe for concurrent use.
+type ConversationStore interface {
+	// Save stores chat under chat.ID, replacing any earlier version.
+	Save(chat Chat) error
+	// Load returns the conversation stored under id, or an error wrapping
+	// ErrConversationNotFound.
+	Load(id string) (Chat, error)
+	// List returns a summary of every stored conversation.
+	List() ([]ConversationSummary, error)
+	// BindDir records chatID as the conversation bound to the directory dir.
+	BindDir(dir, chatID string) error
+	// DirBinding returns the chat ID bound to dir, or "" when none is.
+	DirBinding(dir string) (string, error)
+}
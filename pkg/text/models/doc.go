@@ -18,6 +18,11 @@
 //   - CallUsageRecorder, CompletedModelCall, ToolCallRecorder, ToolCall:
 //     telemetry seams observed by the session runner per model step and
 //     per tool invocation (worklog 26-08-11-clai-prometheus-metrics).
+//   - ToolHandler, ToolMiddleware: the tool dispatch seam which lets
+//     embedded consumers inspect, rewrite, short-circuit or redact tool
+//     calls without forking the tools themselves.
+//   - ConversationStore, ConversationSummary: the persistence seam behind
+//     conversation saves, loads, listings and directory bindings.
 //
 // These types are designed to be serializable (where appropriate) and
 // safe to pass across package boundaries without leaking internal
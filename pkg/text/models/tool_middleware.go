@@ -0,0 +1,42 @@
+package models
+
+import "context"
+
+// ToolHandler executes one tool call and returns its output. A non-nil error
+// is folded into the tool result using the "ERROR: " convention, the same
+// way a failing LLMTool.Call is reported to the model.
+type ToolHandler func(ctx context.Context, call Call) (string, error)
+
+// ToolMiddleware wraps a ToolHandler. A middleware may inspect or rewrite
+// call.Inputs before delegating to next, short-circuit with a synthetic
+// result by not calling next at all, post-process the output (redaction,
+// caching), or derive a bounded context to enforce a per-tool timeout.
+//
+// Middlewares observe every registered tool invocation (built-in tools,
+// WithTools instances, MCP tools). Rewritten inputs only affect execution:
+// the chain runs on a deep copy of call.Inputs, so the transcript keeps the
+// call as the model emitted it.
+type ToolMiddleware func(next ToolHandler) ToolHandler
+
+// ChainToolMiddleware wraps handler with middlewares. The first middleware
+// is the outermost one: it sees the call first and the output last.
+func ChainToolMiddleware(handler ToolHandler, middlewares ...ToolMiddleware) ToolHandler {
+	wrapped := false
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if middlewares[i] == nil {
+			continue
+		}
+		handler = middlewares[i](handler)
+		wrapped = true
+	}
+	if !wrapped {
+		return handler
+	}
+	return func(ctx context.Context, call Call) (string, error) {
+		if call.Inputs != nil {
+			inputs := call.Inputs.Clone()
+			call.Inputs = &inputs
+		}
+		return handler(ctx, call)
+	}
+}
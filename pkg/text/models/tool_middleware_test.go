@@ -0,0 +1,58 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChainToolMiddleware(t *testing.T) {
+	base := func(_ context.Context, call Call) (string, error) {
+		return call.Name, nil
+	}
+	wrap := func(tag string) ToolMiddleware {
+		return func(next ToolHandler) ToolHandler {
+			return func(ctx context.Context, call Call) (string, error) {
+				call.Name += ">" + tag
+				out, err := next(ctx, call)
+				return out + "<" + tag, err
+			}
+		}
+	}
+
+	got, err := ChainToolMiddleware(base, wrap("a"), nil, wrap("b"))(context.Background(), Call{Name: "tool"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "tool>a>b<b<a"; got != want {
+		t.Fatalf("ChainToolMiddleware() = %q, want %q", got, want)
+	}
+
+	got, _ = ChainToolMiddleware(base)(context.Background(), Call{Name: "tool"})
+	if got != "tool" {
+		t.Fatalf("expected the bare handler without middlewares, got %q", got)
+	}
+}
+
+func TestChainToolMiddleware_RewriteKeepsTheCall(t *testing.T) {
+	inputs := Input{"file_path": "a.go", "opts": map[string]any{"mode": "r"}, "list": []any{"x"}}
+	call := Call{Name: "tool", Inputs: &inputs}
+	rewrite := func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call Call) (string, error) {
+			(*call.Inputs)["file_path"] = "b.go"
+			(*call.Inputs)["opts"].(map[string]any)["mode"] = "w"
+			(*call.Inputs)["list"].([]any)[0] = "y"
+			return next(ctx, call)
+		}
+	}
+	base := func(_ context.Context, call Call) (string, error) {
+		return (*call.Inputs)["file_path"].(string), nil
+	}
+
+	got, err := ChainToolMiddleware(base, rewrite)(context.Background(), call)
+	if err != nil || got != "b.go" {
+		t.Fatalf("expected the tool to run on the rewritten inputs, got %q, %v", got, err)
+	}
+	if inputs["file_path"] != "a.go" || inputs["opts"].(map[string]any)["mode"] != "r" || inputs["list"].([]any)[0] != "x" {
+		t.Fatalf("the emitted call must stay untouched, got %v", inputs)
+	}
+}
@@ -39,10 +39,15 @@ const (
 	FileTypeTool           ToolName = "file_type"
 	LSTool                 ToolName = "ls"
 	WebsiteTextTool        ToolName = "website_text"
+	HTTPRequestTool        ToolName = "http_request"
+	WebSearchTool          ToolName = "web_search"
+	ViewImageTool          ToolName = "view_image"
 	RipGrepTool            ToolName = "rg"
 	GoTool                 ToolName = "go"
+	GoSymbolsTool          ToolName = "go_symbols"
 	WriteFileTool          ToolName = "write_file"
 	ApplyPatchTool         ToolName = "apply_patch"
+	StrReplaceTool         ToolName = "str_replace"
 	FreetextCmdTool        ToolName = "freetext_command"
 	CmdTool                ToolName = "cmd"
 	SedTool                ToolName = "sed"
@@ -67,6 +72,36 @@ const (
 
 type Input map[string]any
 
+// Clone returns a deep copy of i: nested objects and arrays are copied too,
+// so rewriting the clone never reaches the original.
+func (i Input) Clone() Input {
+	if i == nil {
+		return nil
+	}
+	return cloneJSONValue(map[string]any(i)).(map[string]any)
+}
+
+func cloneJSONValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = cloneJSONValue(e)
+		}
+		return m
+	case Input:
+		return Input(cloneJSONValue(map[string]any(v)).(map[string]any))
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = cloneJSONValue(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
 type Call struct {
 	ID               string         `json:"id,omitempty"`
 	Name             string         `json:"name,omitempty"`
@@ -75,10 +110,9 @@ type Call struct {
 	Function         Specification  `json:"function"`
 	ExtraContent     map[string]any `json:"extra_content,omitempty"`
 	ReasoningContent string         `json:"-"`
-	// ReasoningItems are the opaque reasoning items (id + encrypted_content) that
-	// preceded this call in the same Responses turn. They are replayed before the
-	// function call so stateless (store:false) reasoning-model tool loops keep
-	// reasoning continuity. OpenAI-only; empty for other vendors.
+	// ReasoningItems are the opaque, vendor-tagged reasoning state which
+	// preceded this call in the same turn. They are replayed with the call so
+	// reasoning-model tool loops keep reasoning continuity.
 	ReasoningItems []ReasoningItem `json:"-"`
 }
 
@@ -0,0 +1,18 @@
+package text
+
+import (
+	"github.com/baalimago/clai/internal/chat"
+	"github.com/baalimago/clai/pkg/text/models"
+)
+
+// NewMemoryConversationStore returns a ConversationStore which keeps every
+// conversation in memory and never touches the filesystem.
+func NewMemoryConversationStore() models.ConversationStore {
+	return chat.NewMemoryStore()
+}
+
+// NewFileConversationStore returns the filesystem ConversationStore rooted at
+// the clai config dir claiDir.
+func NewFileConversationStore(claiDir string) models.ConversationStore {
+	return chat.NewFileStore(claiDir)
+}
@@ -31,7 +31,6 @@ const (
 )
 
 var (
-	asyncCmdManager    = newAsyncCmdManager()
 	asyncSpawnObserver func(string)
 	asyncLogDir        = os.TempDir()
 )
@@ -435,19 +434,23 @@ func mustJSONString(v any) (string, error) {
 }
 
 func ResetAsyncCmdManagerForTests() {
-	asyncCmdManager = newAsyncCmdManager()
+	defaultSession.reset()
 	asyncSpawnObserver = nil
 	asyncLogDir = os.TempDir()
-	claiRunsMu.Lock()
-	claiRuns = make(map[string]*claiProcess)
-	claiRunsMu.Unlock()
 }
 
 func AsyncCmdSnapshotForTests() map[string]AsyncCmdSnapshot {
-	asyncCmdManager.mu.RLock()
-	defer asyncCmdManager.mu.RUnlock()
-	ret := make(map[string]AsyncCmdSnapshot, len(asyncCmdManager.cmds))
-	for id, cmd := range asyncCmdManager.cmds {
+	return defaultSession.AsyncCmdSnapshotForTests()
+}
+
+// AsyncCmdSnapshotForTests returns the status of every async command owned by
+// the session.
+func (s *Session) AsyncCmdSnapshotForTests() map[string]AsyncCmdSnapshot {
+	m := s.asyncCmds
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ret := make(map[string]AsyncCmdSnapshot, len(m.cmds))
+	for id, cmd := range m.cmds {
 		cmd.mu.RLock()
 		ret[id] = AsyncCmdSnapshot{CmdID: id, Status: cmd.status}
 		cmd.mu.RUnlock()
@@ -456,7 +459,7 @@ func AsyncCmdSnapshotForTests() map[string]AsyncCmdSnapshot {
 }
 
 func SpawnAsyncCmdForTests(ctx context.Context, command string, args []string, cwd string, env map[string]string) (string, error) {
-	cmd, err := asyncCmdManager.Spawn(ctx, "test", asyncCmdRunSpec{
+	cmd, err := defaultSession.asyncCmds.Spawn(ctx, "test", asyncCmdRunSpec{
 		Command: command,
 		Args:    args,
 		CWD:     cwd,
@@ -536,7 +539,7 @@ func callAsyncCmdRun(ctx context.Context, toolName string, input pub_models.Inpu
 	if err := validateCmdNotBannedWithContext(ctx, command, args); err != nil {
 		return "", fmt.Errorf("start async command: %w", err)
 	}
-	cmd, err := asyncCmdManager.Spawn(ctx, toolName, asyncCmdRunSpec{
+	cmd, err := sessionFrom(ctx).asyncCmds.Spawn(ctx, toolName, asyncCmdRunSpec{
 		Command: command,
 		Args:    args,
 		CWD:     cwd,
@@ -563,8 +566,12 @@ func callAsyncCmdRun(ctx context.Context, toolName string, input pub_models.Inpu
 	})
 }
 
-func (t *asyncCmdStatusTool) CallWithContext(_ context.Context, input pub_models.Input) (string, error) {
-	return t.Call(input)
+func (t *asyncCmdStatusTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
+	cmd, err := asyncCmdFromInput(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return mustJSONString(cmd.statusResponse())
 }
 
 func (t *asyncCmdStatusTool) Specification() pub_models.Specification {
@@ -572,15 +579,15 @@ func (t *asyncCmdStatusTool) Specification() pub_models.Specification {
 }
 
 func (t *asyncCmdStatusTool) Call(input pub_models.Input) (string, error) {
-	cmd, err := asyncCmdFromInput(input)
+	return t.CallWithContext(context.Background(), input)
+}
+
+func (t *asyncCmdLogsTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
+	cmd, err := asyncCmdFromInput(ctx, input)
 	if err != nil {
 		return "", err
 	}
-	return mustJSONString(cmd.statusResponse())
-}
-
-func (t *asyncCmdLogsTool) CallWithContext(_ context.Context, input pub_models.Input) (string, error) {
-	return t.Call(input)
+	return mustJSONString(cmd.logsResponse())
 }
 
 func (t *asyncCmdLogsTool) Specification() pub_models.Specification {
@@ -588,11 +595,7 @@ func (t *asyncCmdLogsTool) Specification() pub_models.Specification {
 }
 
 func (t *asyncCmdLogsTool) Call(input pub_models.Input) (string, error) {
-	cmd, err := asyncCmdFromInput(input)
-	if err != nil {
-		return "", err
-	}
-	return mustJSONString(cmd.logsResponse())
+	return t.CallWithContext(context.Background(), input)
 }
 
 func (t *asyncCmdAwaitTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
@@ -627,9 +630,10 @@ func callAsyncCmdAwait(ctx context.Context, input pub_models.Input) (string, err
 	if err != nil {
 		return "", err
 	}
+	manager := sessionFrom(ctx).asyncCmds
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds*float64(time.Second)))
 	defer cancel()
-	result, cmds, err := asyncCmdManager.Await(ctx, ids)
+	result, cmds, err := manager.Await(ctx, ids)
 	if err != nil {
 		return "", err
 	}
@@ -640,32 +644,32 @@ func callAsyncCmdAwait(ctx context.Context, input pub_models.Input) (string, err
 	return mustJSONString(resp)
 }
 
-func (t *asyncCmdCancelTool) CallWithContext(_ context.Context, input pub_models.Input) (string, error) {
-	return t.Call(input)
-}
-
-func (t *asyncCmdCancelTool) Specification() pub_models.Specification {
-	return singleAsyncCmdSpec("async_cmd_cancel", "Request cancellation of a running async command.")
-}
-
-func (t *asyncCmdCancelTool) Call(input pub_models.Input) (string, error) {
+func (t *asyncCmdCancelTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
 	cmdID, err := requiredCmdID(input)
 	if err != nil {
 		return "", err
 	}
-	cmd, err := asyncCmdManager.Cancel(cmdID)
+	cmd, err := sessionFrom(ctx).asyncCmds.Cancel(cmdID)
 	if err != nil {
 		return "", err
 	}
 	return mustJSONString(cmd.statusResponse())
 }
 
-func asyncCmdFromInput(input pub_models.Input) (*asyncCmd, error) {
+func (t *asyncCmdCancelTool) Specification() pub_models.Specification {
+	return singleAsyncCmdSpec("async_cmd_cancel", "Request cancellation of a running async command.")
+}
+
+func (t *asyncCmdCancelTool) Call(input pub_models.Input) (string, error) {
+	return t.CallWithContext(context.Background(), input)
+}
+
+func asyncCmdFromInput(ctx context.Context, input pub_models.Input) (*asyncCmd, error) {
 	cmdID, err := requiredCmdID(input)
 	if err != nil {
 		return nil, err
 	}
-	return asyncCmdManager.get(cmdID)
+	return sessionFrom(ctx).asyncCmds.get(cmdID)
 }
 
 func singleAsyncCmdSpec(name, desc string) pub_models.Specification {
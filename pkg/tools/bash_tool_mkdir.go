@@ -38,3 +38,7 @@ func (m MkdirTool) Call(input pub_models.Input) (string, error) {
 func (m MkdirTool) Specification() pub_models.Specification {
 	return pub_models.Specification(Mkdir)
 }
+
+func (m MkdirTool) WritePaths(input pub_models.Input) []string {
+	return inputPath(input, "directory")
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 
 	pub_models "github.com/baalimago/clai/pkg/text/models"
@@ -29,6 +30,10 @@ func (t *claiCheckTool) Specification() pub_models.Specification {
 }
 
 func (t *claiCheckTool) Call(input pub_models.Input) (string, error) {
+	return t.CallWithContext(context.Background(), input)
+}
+
+func (t *claiCheckTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
 	runIDRaw, ok := input["run_id"]
 	if !ok {
 		return "", fmt.Errorf("missing run_id")
@@ -38,21 +43,9 @@ func (t *claiCheckTool) Call(input pub_models.Input) (string, error) {
 		return "", fmt.Errorf("run_id must be a string")
 	}
 
-	claiRunsMu.Lock()
-	process, ok := claiRuns[runID]
-	claiRunsMu.Unlock()
-
+	_, state, ok := sessionFrom(ctx).run(runID)
 	if !ok {
 		return "", fmt.Errorf("unknown run_id: %s", runID)
 	}
-
-	if !process.done {
-		return "RUNNING", nil
-	}
-
-	if process.exitCode != 0 || process.err != nil {
-		return "FAILED", nil
-	}
-
-	return "COMPLETED", nil
+	return state.status(), nil
 }
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 
 	pub_models "github.com/baalimago/clai/pkg/text/models"
@@ -29,6 +30,10 @@ func (t *claiResultTool) Specification() pub_models.Specification {
 }
 
 func (t *claiResultTool) Call(input pub_models.Input) (string, error) {
+	return t.CallWithContext(context.Background(), input)
+}
+
+func (t *claiResultTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
 	runIDRaw, ok := input["run_id"]
 	if !ok {
 		return "", fmt.Errorf("missing run_id")
@@ -38,13 +43,10 @@ func (t *claiResultTool) Call(input pub_models.Input) (string, error) {
 		return "", fmt.Errorf("run_id must be a string")
 	}
 
-	claiRunsMu.Lock()
-	process, ok := claiRuns[runID]
-	claiRunsMu.Unlock()
-
+	process, state, ok := sessionFrom(ctx).run(runID)
 	if !ok {
 		return "", fmt.Errorf("unknown run_id: %s", runID)
 	}
-
-	return fmt.Sprintf("Exit Code: %d\nStdout:\n%s\nStderr:\n%s", process.exitCode, process.stdout.String(), process.stderr.String()), nil
+	stdout, stderr := process.output()
+	return fmt.Sprintf("Exit Code: %d\nStdout:\n%s\nStderr:\n%s", state.exitCode, stdout, stderr), nil
 }
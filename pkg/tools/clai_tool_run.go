@@ -2,6 +2,7 @@ package tools
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
@@ -17,15 +18,15 @@ import (
 	"github.com/baalimago/go_away_boilerplate/pkg/ancli"
 )
 
-var (
-	claiRunsMu sync.Mutex
-	claiRuns   = make(map[string]*claiProcess)
-	// Since the user called clai, we assume it's on path. If not, a bit of trouble
-	ClaiBinaryPath = "clai"
-)
+// Since the user called clai, we assume it's on path. If not, a bit of trouble
+var ClaiBinaryPath = "clai"
 
+// claiProcess is a clai_run worker. done, exitCode and err are guarded by
+// the session's runsMu; stdout and stderr by outMu, since the process writes
+// them while it runs.
 type claiProcess struct {
 	cmd      *exec.Cmd
+	outMu    sync.Mutex
 	stdout   *bytes.Buffer
 	stderr   *bytes.Buffer
 	done     bool
@@ -33,6 +34,61 @@ type claiProcess struct {
 	err      error
 }
 
+// claiRunState is a copy of the status of a claiProcess, taken under runsMu
+// so it can be inspected and formatted without holding the lock.
+type claiRunState struct {
+	done     bool
+	exitCode int
+	err      error
+}
+
+func (s claiRunState) status() string {
+	switch {
+	case !s.done:
+		return "RUNNING"
+	case s.exitCode != 0 || s.err != nil:
+		return "FAILED"
+	default:
+		return "COMPLETED"
+	}
+}
+
+// output returns what the worker has written so far.
+func (p *claiProcess) output() (stdout, stderr string) {
+	p.outMu.Lock()
+	defer p.outMu.Unlock()
+	return p.stdout.String(), p.stderr.String()
+}
+
+// lockedWriter serialises the writes of a worker with claiProcess.output.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// run returns the worker of runID and a copy of its status. The lock is only
+// held for the lookup, so a slow caller never blocks the other workers.
+func (s *Session) run(runID string) (*claiProcess, claiRunState, bool) {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+	p, ok := s.runs[runID]
+	if !ok {
+		return nil, claiRunState{}, false
+	}
+	return p, p.state(), true
+}
+
+// state copies the status of p. The caller holds runsMu.
+func (p *claiProcess) state() claiRunState {
+	return claiRunState{done: p.done, exitCode: p.exitCode, err: p.err}
+}
+
 func generateRunID() string {
 	b := make([]byte, 8)
 	rand.Read(b)
@@ -88,6 +144,14 @@ func (t *claiRunTool) setupFlags(input pub_models.Input) ([]string, error) {
 }
 
 func (t *claiRunTool) Call(input pub_models.Input) (string, error) {
+	return t.CallWithContext(context.Background(), input)
+}
+
+// CallWithContext spawns the worker and registers it in the session resolved
+// from ctx, so clai_check, clai_result and clai_wait_for_workers only see the
+// workers of their own agent.
+func (t *claiRunTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
+	session := sessionFrom(ctx)
 	// Validate and parse input arguments
 	argsSplit, err := t.setupFlags(input)
 	if err != nil {
@@ -112,51 +176,50 @@ func (t *claiRunTool) Call(input pub_models.Input) (string, error) {
 	cmd.Env = append(os.Environ(), "NO_COLOR=true")
 
 	// Setup output buffers and multi-writers to write to both buffer and file
-	stdoutBuffer := &bytes.Buffer{}
-	stderrBuffer := &bytes.Buffer{}
-	cmd.Stdout = io.MultiWriter(stdoutBuffer, stdoutFile)
-	cmd.Stderr = io.MultiWriter(stderrBuffer, stderrFile)
-
 	// Initialize process tracking structure
 	process := &claiProcess{
 		cmd:    cmd,
-		stdout: stdoutBuffer,
-		stderr: stderrBuffer,
+		stdout: &bytes.Buffer{},
+		stderr: &bytes.Buffer{},
 		done:   false,
 	}
+	cmd.Stdout = io.MultiWriter(lockedWriter{mu: &process.outMu, w: process.stdout}, stdoutFile)
+	cmd.Stderr = io.MultiWriter(lockedWriter{mu: &process.outMu, w: process.stderr}, stderrFile)
 
-	// Register process in global map
-	claiRunsMu.Lock()
-	claiRuns[runID] = process
-	claiRunsMu.Unlock()
+	// Register process in the session
+	session.runsMu.Lock()
+	session.runs[runID] = process
+	session.runsMu.Unlock()
 
 	// Start the subprocess
 	if err := cmd.Start(); err != nil {
 		// Mark as done and record error on startup failure
+		session.runsMu.Lock()
 		process.done = true
 		process.err = err
 		process.exitCode = -1
+		session.runsMu.Unlock()
 		stdoutFile.Close()
 		stderrFile.Close()
 		return "", fmt.Errorf("failed to start clai process: %w", err)
 	}
 
 	// Spawn goroutine to wait for process completion and close files
-	go t.waitForProcessCompletion(process, stdoutFile, stderrFile)
+	go t.waitForProcessCompletion(session, process, stdoutFile, stderrFile)
 
 	return runID, nil
 }
 
 // waitForProcessCompletion blocks until the process finishes and records its exit status.
-func (t *claiRunTool) waitForProcessCompletion(process *claiProcess, stdoutFile, stderrFile *os.File) {
+func (t *claiRunTool) waitForProcessCompletion(session *Session, process *claiProcess, stdoutFile, stderrFile *os.File) {
 	err := process.cmd.Wait()
 
 	// Close files once the process has finished
 	stdoutFile.Close()
 	stderrFile.Close()
 
-	claiRunsMu.Lock()
-	defer claiRunsMu.Unlock()
+	session.runsMu.Lock()
+	defer session.runsMu.Unlock()
 
 	process.done = true
 	process.err = err
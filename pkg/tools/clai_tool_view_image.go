@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// ViewImageMaxSize is the longest side, in pixels, view_image downscales
+// images to unless the call asks for another size. Larger images cost more
+// tokens without the models seeing more detail.
+const ViewImageMaxSize = 1568
+
+type ViewImageTool pub_models.Specification
+
+var ViewImage = ViewImageTool{
+	Name: "view_image",
+	Description: "Look at a png, jpeg, gif or webp image, such as a screenshot, plot or diagram. " +
+		"The image is added to the conversation after the tool results, so you see it on your next turn.",
+	Inputs: &pub_models.InputSchema{
+		Type: "object",
+		Properties: map[string]pub_models.ParameterObject{
+			"path": {
+				Type:        "string",
+				Description: "Path to the image file, or an http(s) URL of the image.",
+			},
+			"max_size": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Optional longest side in pixels to downscale the image to. Defaults to %d.", ViewImageMaxSize),
+			},
+		},
+		Required: []string{"path"},
+	},
+}
+
+func (v ViewImageTool) Call(input pub_models.Input) (string, error) {
+	return v.CallWithContext(context.Background(), input)
+}
+
+func (v ViewImageTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
+	path, ok := input["path"].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("path must be a non-empty string")
+	}
+	maxSize := ViewImageMaxSize
+	if f, ok := input["max_size"].(float64); ok && f > 0 {
+		maxSize = int(f)
+	}
+	img, err := LoadImage(ctx, path, maxSize)
+	if err != nil {
+		return "", fmt.Errorf("failed to load image: %w", err)
+	}
+	sessionFrom(ctx).addImage(img.Part())
+	scaled := ""
+	if img.Scaled {
+		scaled = ", downscaled"
+	}
+	return fmt.Sprintf("Loaded %v (%v, %dx%d%v). The image follows in the next message.",
+		path, img.MIMEType, img.Width, img.Height, scaled), nil
+}
+
+func (v ViewImageTool) Specification() pub_models.Specification {
+	return pub_models.Specification(ViewImage)
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func TestViewImage(t *testing.T) {
+	s := NewSession()
+	ctx := WithSession(t.Context(), s)
+	p := writeTestImage(t, "screenshot.png", 3000, 1500)
+
+	out, err := ViewImage.CallWithContext(ctx, pub_models.Input{"path": p})
+	if err != nil {
+		t.Fatalf("view_image: %v", err)
+	}
+	if !strings.Contains(out, "image/png, 1568x784, downscaled") {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if _, err := ViewImage.CallWithContext(ctx, pub_models.Input{"path": p, "max_size": float64(100)}); err != nil {
+		t.Fatalf("view_image with max_size: %v", err)
+	}
+
+	if got := TakeImages(t.Context()); len(got) != 0 {
+		t.Fatalf("the default session must hold no images, got %d", len(got))
+	}
+	images := TakeImages(ctx)
+	if len(images) != 2 || images[0].ImageB64 == nil || images[0].ImageB64.MIMEType != "image/png" {
+		t.Fatalf("expected two image parts, got %+v", images)
+	}
+	if got := TakeImages(ctx); len(got) != 0 {
+		t.Fatalf("TakeImages must empty the session, got %d", len(got))
+	}
+
+	if _, err := ViewImage.CallWithContext(ctx, pub_models.Input{}); err == nil {
+		t.Fatal("expected an error without path")
+	}
+	if _, err := ViewImage.CallWithContext(ctx, pub_models.Input{"path": p + ".missing"}); err == nil {
+		t.Fatal("expected an error for a missing image")
+	}
+	if got := TakeImages(ctx); len(got) != 0 {
+		t.Fatalf("failed calls must not add images, got %d", len(got))
+	}
+}
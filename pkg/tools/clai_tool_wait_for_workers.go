@@ -37,7 +37,14 @@ func (t *claiWaitForWorkersTool) Specification() pub_models.Specification {
 }
 
 func (t *claiWaitForWorkersTool) Call(input pub_models.Input) (string, error) {
+	return t.CallWithContext(context.Background(), input)
+}
+
+// CallWithContext waits for the workers of the session resolved from ctx.
+// Cancelling ctx interrupts the running workers the same way a timeout does.
+func (t *claiWaitForWorkersTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
 	start := time.Now()
+	session := sessionFrom(ctx)
 
 	rawTimeout, ok := input["timeout_seconds"]
 	if !ok {
@@ -73,17 +80,17 @@ func (t *claiWaitForWorkersTool) Call(input pub_models.Input) (string, error) {
 	}
 
 	// Snapshot current runs
-	claiRunsMu.Lock()
-	localRuns := make(map[string]*claiProcess, len(claiRuns))
-	maps.Copy(localRuns, claiRuns)
-	claiRunsMu.Unlock()
+	session.runsMu.Lock()
+	localRuns := make(map[string]*claiProcess, len(session.runs))
+	maps.Copy(localRuns, session.runs)
+	session.runsMu.Unlock()
 
 	if len(localRuns) == 0 {
 		elapsed := time.Since(start)
 		return fmt.Sprintf("No active workers (waited %s)", elapsed), nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSeconds*float64(time.Second)))
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds*float64(time.Second)))
 	defer cancel()
 
 	ticker := time.NewTicker(100 * time.Millisecond)
@@ -92,14 +99,14 @@ func (t *claiWaitForWorkersTool) Call(input pub_models.Input) (string, error) {
 	for {
 		allDone := true
 
-		claiRunsMu.Lock()
+		session.runsMu.Lock()
 		for _, p := range localRuns {
 			if !p.done {
 				allDone = false
 				break
 			}
 		}
-		claiRunsMu.Unlock()
+		session.runsMu.Unlock()
 
 		if allDone {
 			break
@@ -108,13 +115,13 @@ func (t *claiWaitForWorkersTool) Call(input pub_models.Input) (string, error) {
 		select {
 		case <-ctx.Done():
 			// Timeout: send interrupt to all still-running processes
-			claiRunsMu.Lock()
+			session.runsMu.Lock()
 			for _, p := range localRuns {
 				if !p.done && p.cmd != nil && p.cmd.Process != nil {
 					_ = p.cmd.Process.Signal(os.Interrupt)
 				}
 			}
-			claiRunsMu.Unlock()
+			session.runsMu.Unlock()
 			elapsed := time.Since(start)
 			return "", fmt.Errorf("timeout waiting for workers (%v seconds, elapsed %s); sent interrupt to running processes", timeoutSeconds, elapsed)
 		case <-ticker.C:
@@ -123,18 +130,22 @@ func (t *claiWaitForWorkersTool) Call(input pub_models.Input) (string, error) {
 
 	elapsed := time.Since(start)
 
-	// Build aggregated result and mirror each worker's output to a temp file.
-	claiRunsMu.Lock()
-	defer claiRunsMu.Unlock()
+	// Copy the statuses under the lock, then build the aggregated result and
+	// mirror each worker's output to a temp file without holding it.
+	states := make(map[string]claiRunState, len(localRuns))
+	session.runsMu.Lock()
+	for id, p := range localRuns {
+		states[id] = p.state()
+	}
+	session.runsMu.Unlock()
 
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf, "All workers completed in %s\n\n", elapsed)
 
 	for id, p := range localRuns {
-		status := "COMPLETED"
-		if p.exitCode != 0 || p.err != nil {
-			status = "FAILED"
-		}
+		state := states[id]
+		status := state.status()
+		stdout, stderr := p.output()
 
 		// Create a temp file per worker and dump stdout/stderr there.
 		// We include the worker id in the pattern for easier inspection.
@@ -144,24 +155,24 @@ func (t *claiWaitForWorkersTool) Call(input pub_models.Input) (string, error) {
 			fmt.Fprintf(&buf, "Worker %s: failed to create temp file: %v\n", id, err)
 		} else {
 			// Best-effort write; if it fails, we still show what we can in-memory.
-			_, _ = fmt.Fprintf(f, "Exit Code: %d\nStatus: %s\n", p.exitCode, status)
-			if p.err != nil {
-				_, _ = fmt.Fprintf(f, "Error: %v\n", p.err)
+			_, _ = fmt.Fprintf(f, "Exit Code: %d\nStatus: %s\n", state.exitCode, status)
+			if state.err != nil {
+				_, _ = fmt.Fprintf(f, "Error: %v\n", state.err)
 			}
-			_, _ = fmt.Fprintf(f, "Stdout:\n%s\n", p.stdout.String())
-			_, _ = fmt.Fprintf(f, "Stderr:\n%s\n", p.stderr.String())
+			_, _ = fmt.Fprintf(f, "Stdout:\n%s\n", stdout)
+			_, _ = fmt.Fprintf(f, "Stderr:\n%s\n", stderr)
 			_ = f.Close()
 
 			fmt.Fprintf(&buf, "Worker %s log written to: %s\n", id, filepath.Clean(f.Name()))
 		}
 
 		fmt.Fprintf(&buf, "Worker %s:\n", id)
-		fmt.Fprintf(&buf, "Status: %s (exit code: %d)\n", status, p.exitCode)
-		if p.err != nil {
-			fmt.Fprintf(&buf, "Error: %v\n", p.err)
+		fmt.Fprintf(&buf, "Status: %s (exit code: %d)\n", status, state.exitCode)
+		if state.err != nil {
+			fmt.Fprintf(&buf, "Error: %v\n", state.err)
 		}
-		fmt.Fprintf(&buf, "Stdout:\n%s\n", p.stdout.String())
-		fmt.Fprintf(&buf, "Stderr:\n%s\n", p.stderr.String())
+		fmt.Fprintf(&buf, "Stdout:\n%s\n", stdout)
+		fmt.Fprintf(&buf, "Stderr:\n%s\n", stderr)
 		fmt.Fprintln(&buf)
 	}
 
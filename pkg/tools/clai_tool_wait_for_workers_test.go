@@ -24,8 +24,8 @@ func TestClaiWaitForWorkers_NoWorkers(t *testing.T) {
 // TestClaiWaitForWorkers_WaitsAndAggregates simulates a small number of workers and verifies aggregation.
 func TestClaiWaitForWorkers_WaitsAndAggregates(t *testing.T) {
 	// Setup fake workers
-	claiRunsMu.Lock()
-	claiRuns = map[string]*claiProcess{
+	defaultSession.runsMu.Lock()
+	defaultSession.runs = map[string]*claiProcess{
 		"worker1": {
 			cmd:      &exec.Cmd{},
 			stdout:   bytes.NewBufferString("output1"),
@@ -41,7 +41,7 @@ func TestClaiWaitForWorkers_WaitsAndAggregates(t *testing.T) {
 			exitCode: 1,
 		},
 	}
-	claiRunsMu.Unlock()
+	defaultSession.runsMu.Unlock()
 
 	tool := &claiWaitForWorkersTool{}
 
@@ -76,9 +76,9 @@ func TestClaiWaitForWorkers_Timeout(t *testing.T) {
 		t.Skipf("unable to start sleep command: %v", err)
 	}
 
-	claiRunsMu.Lock()
-	claiRuns = map[string]*claiProcess{"worker1": process}
-	claiRunsMu.Unlock()
+	defaultSession.runsMu.Lock()
+	defaultSession.runs = map[string]*claiProcess{"worker1": process}
+	defaultSession.runsMu.Unlock()
 
 	tool := &claiWaitForWorkersTool{}
 
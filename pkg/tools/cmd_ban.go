@@ -4,35 +4,21 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"sync"
 )
 
 type cmdBanContextKey struct{}
 
-// cmdBanList is the active per-run command ban list. It is guarded by
-// cmdBanMu: SetCmdBanList / ResetCmdBanListForTests take the write lock and
-// validateCmdNotBanned takes the read lock, so concurrent pkg/agent runs with
-// distinct ban lists never observe a torn slice (worklog
-// 2026-08-02-cmd-ban-list, phase 2, R2-01).
-var (
-	cmdBanList []string
-	cmdBanMu   sync.RWMutex
-)
-
-// SetCmdBanList replaces the active command ban list with an immutable
-// snapshot of entries. The default is empty, which keeps all tools fully
-// permissive. The caller's slice is copied at the setter boundary, so
-// mutating it after this function returns never alters the active list or
-// races a concurrent spawn check (README "Ban-list ownership", R6-02).
+// SetCmdBanList replaces the default session's command ban list with an
+// immutable snapshot of entries. The default is empty, which keeps all tools
+// fully permissive. Runs with their own Session set the list on it instead
+// (Session.SetCmdBanList), so they never overwrite each other's fallback.
 func SetCmdBanList(entries []string) {
-	cmdBanMu.Lock()
-	defer cmdBanMu.Unlock()
-	cmdBanList = append([]string(nil), entries...)
+	defaultSession.SetCmdBanList(entries)
 }
 
 // WithCmdBanContext attaches an immutable command ban policy to a tool-call
 // context. This lets embedded agents enforce distinct policies concurrently;
-// callers that do not provide a policy continue to use the process default.
+// callers that do not provide a policy continue to use their session's list.
 func WithCmdBanContext(ctx context.Context, entries []string) context.Context {
 	return context.WithValue(ctx, cmdBanContextKey{}, append([]string(nil), entries...))
 }
@@ -54,10 +40,8 @@ func validateCmdNotBanned(command string, args []string) error {
 
 func validateCmdNotBannedWithContext(ctx context.Context, command string, args []string) error {
 	entries, hasContextPolicy := ctx.Value(cmdBanContextKey{}).([]string)
-	cmdBanMu.RLock()
-	defer cmdBanMu.RUnlock()
 	if !hasContextPolicy {
-		entries = cmdBanList
+		entries = sessionFrom(ctx).cmdBanList()
 	}
 	if len(entries) == 0 {
 		return nil
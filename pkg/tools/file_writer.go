@@ -0,0 +1,21 @@
+package tools
+
+import pub_models "github.com/baalimago/clai/pkg/text/models"
+
+// FileWriter is implemented by the tools which create, change or delete
+// files, so that callers can snapshot those files before a call runs.
+type FileWriter interface {
+	// WritePaths returns the paths a call with input may write, as given in
+	// the input. Invalid input returns nil, since such a call fails before it
+	// writes anything.
+	WritePaths(input pub_models.Input) []string
+}
+
+// inputPath returns the string input key as a one path slice, nil when it
+// is missing or empty.
+func inputPath(input pub_models.Input, key string) []string {
+	if p, ok := input[key].(string); ok && p != "" {
+		return []string{p}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// HTTPPolicy gates the http_request tool. Without allowed hosts every
+// request is refused, so the tool does nothing until a profile, the text
+// config or an agent opts in.
+type HTTPPolicy struct {
+	// Allow are the hosts requests may go to: an exact host name or IP,
+	// "*.example.com" for every subdomain of example.com, or "*" for any host.
+	Allow []string `json:"allow,omitempty"`
+	// Deny are refused even when allowed, in the same syntax as Allow.
+	Deny []string `json:"deny,omitempty"`
+	// SecretHeaders are set on requests to their host, so credentials reach
+	// the API without ever passing through the chat.
+	SecretHeaders []SecretHeader `json:"secret_headers,omitempty"`
+	// MaxResponseBytes caps the response body shown to the model. Zero uses
+	// DefaultHTTPMaxResponseBytes.
+	MaxResponseBytes int `json:"max_response_bytes,omitempty"`
+}
+
+// SecretHeader is a header injected into requests to Host, in the syntax of
+// HTTPPolicy.Allow. Value is expanded from the environment when the request
+// is sent, e.g. "Bearer ${JIRA_TOKEN}", and redacted from the response.
+type SecretHeader struct {
+	Host   string `json:"host"`
+	Header string `json:"header"`
+	Value  string `json:"value"`
+}
+
+// DefaultHTTPMaxResponseBytes is the response body cap of HTTPPolicy.
+const DefaultHTTPMaxResponseBytes = 1 << 20
+
+// SetHTTPPolicy replaces the session's http_request policy with a copy of
+// p. Nil refuses every request.
+func (s *Session) SetHTTPPolicy(p *HTTPPolicy) {
+	s.httpMu.Lock()
+	defer s.httpMu.Unlock()
+	if p == nil {
+		s.httpPolicy = HTTPPolicy{}
+		return
+	}
+	s.httpPolicy = p.clone()
+}
+
+func (s *Session) currentHTTPPolicy() HTTPPolicy {
+	s.httpMu.RLock()
+	defer s.httpMu.RUnlock()
+	return s.httpPolicy
+}
+
+func httpPolicyFrom(ctx context.Context) HTTPPolicy {
+	return sessionFrom(ctx).currentHTTPPolicy()
+}
+
+func (p HTTPPolicy) clone() HTTPPolicy {
+	p.Allow = slices.Clone(p.Allow)
+	p.Deny = slices.Clone(p.Deny)
+	p.SecretHeaders = slices.Clone(p.SecretHeaders)
+	return p
+}
+
+func (p HTTPPolicy) maxResponseBytes() int {
+	if p.MaxResponseBytes > 0 {
+		return p.MaxResponseBytes
+	}
+	return DefaultHTTPMaxResponseBytes
+}
+
+// check refuses hosts which are denied or not allowed. The refusal lists the
+// allowed hosts, so the model can pick another.
+func (p HTTPPolicy) check(host string) error {
+	if len(p.Allow) == 0 {
+		return fmt.Errorf("http_request is disabled: no hosts are allowed. Add them to http_request.allow of the profile, or http-request.allow of textConfig.json")
+	}
+	if pattern, ok := matchHost(host, p.Deny); ok {
+		return fmt.Errorf("host %q is denied by policy (matched %q)", host, pattern)
+	}
+	if _, ok := matchHost(host, p.Allow); !ok {
+		return fmt.Errorf("host %q is not allowed, allowed hosts: %v", host, strings.Join(p.Allow, ", "))
+	}
+	return nil
+}
+
+// setSecretHeaders sets the secret headers of host on h, removing the ones
+// of other hosts, which a redirect may have carried over. It returns the
+// expanded values, to be redacted from the response.
+func (p HTTPPolicy) setSecretHeaders(h http.Header, host string) ([]string, error) {
+	p.dropSecretHeaders(h)
+	var secrets []string
+	for _, s := range p.SecretHeaders {
+		if _, ok := matchHost(host, []string{s.Host}); !ok {
+			continue
+		}
+		missing := []string{}
+		v := os.Expand(s.Value, func(name string) string {
+			val, ok := os.LookupEnv(name)
+			if !ok {
+				missing = append(missing, name)
+			}
+			return val
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("secret header %v for %v: environment variable %v is not set", s.Header, s.Host, strings.Join(missing, ", "))
+		}
+		h.Set(s.Header, v)
+		secrets = append(secrets, v)
+		// The bare token is as secret as "Bearer <token>".
+		if _, token, ok := strings.Cut(v, " "); ok && len(token) >= 8 {
+			secrets = append(secrets, token)
+		}
+	}
+	return secrets, nil
+}
+
+// dropSecretHeaders removes the secret headers of every host from h.
+func (p HTTPPolicy) dropSecretHeaders(h http.Header) {
+	for _, s := range p.SecretHeaders {
+		h.Del(s.Header)
+	}
+}
+
+// matchHost returns the first pattern matching host.
+func matchHost(host string, patterns []string) (string, bool) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range patterns {
+		p := strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case p == "*":
+			return pattern, true
+		case strings.HasPrefix(p, "*."):
+			if strings.HasSuffix(host, p[1:]) {
+				return pattern, true
+			}
+		case p == host:
+			return pattern, true
+		}
+	}
+	return "", false
+}
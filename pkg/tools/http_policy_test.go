@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		host     string
+		patterns []string
+		want     bool
+	}{
+		{"api.github.com", []string{"api.github.com"}, true},
+		{"API.GitHub.com.", []string{"api.github.com"}, true},
+		{"github.com", []string{"api.github.com"}, false},
+		{"acme.atlassian.net", []string{"*.atlassian.net"}, true},
+		{"atlassian.net", []string{"*.atlassian.net"}, false},
+		{"evilatlassian.net", []string{"*.atlassian.net"}, false},
+		{"anything.example", []string{"*"}, true},
+		{"anything.example", nil, false},
+	}
+	for _, tc := range tests {
+		if _, got := matchHost(tc.host, tc.patterns); got != tc.want {
+			t.Errorf("matchHost(%q, %v) = %v, want %v", tc.host, tc.patterns, got, tc.want)
+		}
+	}
+}
+
+func TestHTTPPolicy_check(t *testing.T) {
+	if err := (HTTPPolicy{}).check("api.github.com"); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Fatalf("expected an empty policy to refuse, got %v", err)
+	}
+	p := HTTPPolicy{Allow: []string{"*.example.com"}, Deny: []string{"admin.example.com"}}
+	if err := p.check("api.example.com"); err != nil {
+		t.Fatalf("expected api.example.com to be allowed, got %v", err)
+	}
+	if err := p.check("admin.example.com"); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Fatalf("expected deny to win over allow, got %v", err)
+	}
+	if err := p.check("other.org"); err == nil || !strings.Contains(err.Error(), "*.example.com") {
+		t.Fatalf("expected the refusal to list the allowed hosts, got %v", err)
+	}
+}
+
+func TestHTTPPolicy_setSecretHeaders(t *testing.T) {
+	t.Setenv("CLAI_TEST_TOKEN", "s3cr3t-token")
+	p := HTTPPolicy{SecretHeaders: []SecretHeader{
+		{Host: "api.example.com", Header: "Authorization", Value: "Bearer ${CLAI_TEST_TOKEN}"},
+		{Host: "other.org", Header: "X-Api-Key", Value: "$CLAI_TEST_MISSING"},
+	}}
+
+	h := http.Header{"Authorization": {"Bearer from-the-model"}}
+	secrets, err := p.setSecretHeaders(h, "api.example.com")
+	if err != nil {
+		t.Fatalf("setSecretHeaders: %v", err)
+	}
+	if got := h.Get("Authorization"); got != "Bearer s3cr3t-token" {
+		t.Fatalf("expected the secret to override the model header, got %q", got)
+	}
+	if strings.Join(secrets, ",") != "Bearer s3cr3t-token,s3cr3t-token" {
+		t.Fatalf("expected the value and the bare token to be redacted, got %v", secrets)
+	}
+
+	// A redirect to a host without the secret must not carry it.
+	if _, err := p.setSecretHeaders(h, "elsewhere.example"); err != nil {
+		t.Fatalf("setSecretHeaders: %v", err)
+	}
+	if got := h.Get("Authorization"); got != "" {
+		t.Fatalf("expected the secret to be removed for another host, got %q", got)
+	}
+
+	if _, err := p.setSecretHeaders(http.Header{}, "other.org"); err == nil || !strings.Contains(err.Error(), "CLAI_TEST_MISSING") {
+		t.Fatalf("expected an error naming the missing variable, got %v", err)
+	}
+}
+
+func TestSession_SetHTTPPolicy(t *testing.T) {
+	s := NewSession()
+	p := &HTTPPolicy{Allow: []string{"api.example.com"}}
+	s.SetHTTPPolicy(p)
+	p.Allow[0] = "changed"
+	ctx := WithSession(t.Context(), s)
+	if err := httpPolicyFrom(ctx).check("api.example.com"); err != nil {
+		t.Fatalf("expected the session policy to be a copy, got %v", err)
+	}
+	s.SetHTTPPolicy(nil)
+	if err := httpPolicyFrom(ctx).check("api.example.com"); err == nil {
+		t.Fatal("expected a nil policy to refuse every host")
+	}
+}
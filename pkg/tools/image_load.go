@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif" // decoder only
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxImageSize caps the bytes of an image read from disk or downloaded.
+const MaxImageSize = 20 << 20
+
+var imageHTTPClient httpDoer = &http.Client{Timeout: 30 * time.Second}
+
+// imageMIMETypes are the formats vision models accept.
+var imageMIMETypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Image is an image loaded for a vision model.
+type Image struct {
+	MIMEType      string
+	Width, Height int
+	// Scaled is set when the image was downscaled to fit the max size.
+	Scaled bool
+	Data   []byte
+}
+
+// Part returns the image as a content part of a message.
+func (i Image) Part() pub_models.ImageOrTextInput {
+	b64 := base64.StdEncoding.EncodeToString(i.Data)
+	return pub_models.ImageOrTextInput{
+		Type: string(pub_models.Image),
+		ImageB64: &pub_models.ImageURL{
+			URL:      fmt.Sprintf("data:%v;base64,%v", i.MIMEType, b64),
+			Detail:   "auto",
+			RawB64:   b64,
+			MIMEType: i.MIMEType,
+		},
+	}
+}
+
+// LoadImage reads the png, jpeg, gif or webp image at src, a local path or an
+// http(s) URL. The format is detected from the content, not the extension.
+// Images with a side longer than maxSize pixels are downscaled to fit, a
+// maxSize of 0 keeps the original.
+func LoadImage(ctx context.Context, src string, maxSize int) (Image, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		data, err = downloadImage(ctx, src)
+	} else {
+		data, err = readImage(src)
+	}
+	if err != nil {
+		return Image{}, err
+	}
+	mime := http.DetectContentType(data)
+	if !imageMIMETypes[mime] {
+		return Image{}, fmt.Errorf("unsupported image type %v, want png, jpeg, gif or webp", mime)
+	}
+	conf, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("decode image: %w", err)
+	}
+	img := Image{MIMEType: mime, Width: conf.Width, Height: conf.Height, Data: data}
+	if maxSize > 0 && max(conf.Width, conf.Height) > maxSize {
+		return downscale(img, maxSize)
+	}
+	return img, nil
+}
+
+func readImage(path string) ([]byte, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("find home dir: %w", err)
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%v is a directory", path)
+	}
+	if info.Size() > MaxImageSize {
+		return nil, fmt.Errorf("%v is over %d MiB", path, MaxImageSize>>20)
+	}
+	return os.ReadFile(path)
+}
+
+func downloadImage(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Accept", "image/png,image/jpeg,image/gif,image/webp;q=0.9,*/*;q=0.5")
+	resp, err := imageHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	if len(data) > MaxImageSize {
+		return nil, fmt.Errorf("%v is over %d MiB", url, MaxImageSize>>20)
+	}
+	return data, nil
+}
+
+// downscale fits img within maxSize pixels on its longest side. JPEGs stay
+// JPEGs, everything else is encoded as PNG, which keeps transparency but only
+// the first frame of an animated GIF.
+func downscale(img Image, maxSize int) (Image, error) {
+	src, _, err := image.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		return Image{}, fmt.Errorf("decode image: %w", err)
+	}
+	w, h := maxSize, img.Height*maxSize/img.Width
+	if img.Height > img.Width {
+		w, h = img.Width*maxSize/img.Height, maxSize
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, max(w, 1), max(h, 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var b bytes.Buffer
+	if img.MIMEType == "image/jpeg" {
+		err = jpeg.Encode(&b, dst, &jpeg.Options{Quality: 85})
+	} else {
+		img.MIMEType = "image/png"
+		err = png.Encode(&b, dst)
+	}
+	if err != nil {
+		return Image{}, fmt.Errorf("encode image: %w", err)
+	}
+	img.Width, img.Height = dst.Bounds().Dx(), dst.Bounds().Dy()
+	img.Scaled = true
+	img.Data = b.Bytes()
+	return img, nil
+}
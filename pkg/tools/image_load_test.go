@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		for y := range h {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func writeTestImage(t *testing.T, name string, w, h int) string {
+	t.Helper()
+	var b bytes.Buffer
+	var err error
+	if strings.HasSuffix(name, ".jpg") {
+		err = jpeg.Encode(&b, testImage(w, h), nil)
+	} else {
+		err = png.Encode(&b, testImage(w, h))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, b.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoadImage(t *testing.T) {
+	t.Run("keeps small images as they are", func(t *testing.T) {
+		p := writeTestImage(t, "small.png", 40, 20)
+		img, err := LoadImage(t.Context(), p, 100)
+		if err != nil {
+			t.Fatalf("LoadImage: %v", err)
+		}
+		raw, _ := os.ReadFile(p)
+		if img.MIMEType != "image/png" || img.Width != 40 || img.Height != 20 || img.Scaled || !bytes.Equal(img.Data, raw) {
+			t.Fatalf("unexpected image %v %dx%d scaled=%t", img.MIMEType, img.Width, img.Height, img.Scaled)
+		}
+	})
+
+	t.Run("downscales to the longest side", func(t *testing.T) {
+		img, err := LoadImage(t.Context(), writeTestImage(t, "tall.jpg", 50, 200), 100)
+		if err != nil {
+			t.Fatalf("LoadImage: %v", err)
+		}
+		if img.MIMEType != "image/jpeg" || img.Width != 25 || img.Height != 100 || !img.Scaled {
+			t.Fatalf("unexpected image %v %dx%d scaled=%t", img.MIMEType, img.Width, img.Height, img.Scaled)
+		}
+		conf, format, err := image.DecodeConfig(bytes.NewReader(img.Data))
+		if err != nil || format != "jpeg" || conf.Width != 25 || conf.Height != 100 {
+			t.Fatalf("data decodes to %v %dx%d, %v", format, conf.Width, conf.Height, err)
+		}
+	})
+
+	t.Run("refuses other files", func(t *testing.T) {
+		p := filepath.Join(t.TempDir(), "notes.png")
+		if err := os.WriteFile(p, []byte("not an image"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadImage(t.Context(), p, 0); err == nil || !strings.Contains(err.Error(), "unsupported image type") {
+			t.Fatalf("expected an unsupported image type error, got %v", err)
+		}
+		if _, err := LoadImage(t.Context(), filepath.Join(t.TempDir(), "missing.png"), 0); err == nil {
+			t.Fatal("expected an error for a missing file")
+		}
+	})
+
+	t.Run("downloads URLs", func(t *testing.T) {
+		data, _ := os.ReadFile(writeTestImage(t, "remote.png", 8, 8))
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/plot.png" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		}))
+		t.Cleanup(srv.Close)
+
+		img, err := LoadImage(t.Context(), srv.URL+"/plot.png", 0)
+		if err != nil {
+			t.Fatalf("LoadImage: %v", err)
+		}
+		if img.MIMEType != "image/png" || img.Width != 8 {
+			t.Fatalf("unexpected image %v %dx%d", img.MIMEType, img.Width, img.Height)
+		}
+		if _, err := LoadImage(t.Context(), srv.URL+"/missing.png", 0); err == nil || !strings.Contains(err.Error(), "404") {
+			t.Fatalf("expected a bad status error, got %v", err)
+		}
+	})
+}
+
+func TestImagePart(t *testing.T) {
+	part := Image{MIMEType: "image/png", Data: []byte{1, 2, 3}}.Part()
+	b64 := base64.StdEncoding.EncodeToString([]byte{1, 2, 3})
+	if part.Type != "image_url" || part.ImageB64 == nil {
+		t.Fatalf("expected an image part, got %+v", part)
+	}
+	if part.ImageB64.URL != "data:image/png;base64,"+b64 || part.ImageB64.RawB64 != b64 || part.ImageB64.MIMEType != "image/png" {
+		t.Fatalf("unexpected image url %+v", part.ImageB64)
+	}
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around a change.
+const diffContextLines = 2
+
+// lineDiffMaxCells caps the LCS table of lineDiff. Larger changes are shown
+// as the whole changed block removed and re-added.
+const lineDiffMaxCells = 4_000_000
+
+type lineOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// lineDiff returns the line edit script turning a into b.
+func lineDiff(a, b []string) []lineOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	kinds := make([]byte, 0, len(a)+len(b))
+	for range prefix {
+		kinds = append(kinds, ' ')
+	}
+	kinds = append(kinds, middleDiff(midA, midB)...)
+	for range suffix {
+		kinds = append(kinds, ' ')
+	}
+
+	ops := make([]lineOp, 0, len(kinds))
+	i, j := 0, 0
+	for _, k := range kinds {
+		switch k {
+		case ' ':
+			ops = append(ops, lineOp{kind: k, text: a[i]})
+			i++
+			j++
+		case '-':
+			ops = append(ops, lineOp{kind: k, text: a[i]})
+			i++
+		case '+':
+			ops = append(ops, lineOp{kind: k, text: b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// middleDiff returns the op kinds of the LCS diff of a and b.
+func middleDiff(a, b []string) []byte {
+	var ret []byte
+	if len(a)*len(b) > lineDiffMaxCells {
+		for range a {
+			ret = append(ret, '-')
+		}
+		for range b {
+			ret = append(ret, '+')
+		}
+		return ret
+	}
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ret = append(ret, ' ')
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ret = append(ret, '+')
+			j++
+		default:
+			ret = append(ret, '-')
+			i++
+		}
+	}
+	return ret
+}
+
+// unifiedDiff renders the change from a to b as a unified diff of path with
+// diffContextLines of context, truncated after maxLines lines.
+func unifiedDiff(path string, a, b []string, maxLines int) string {
+	ops := lineDiff(a, b)
+	var out []string
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		// Grow the hunk until the next change is further away than twice the
+		// context, so that nearby changes share one hunk.
+		from := max(start-diffContextLines, 0)
+		end := start
+		for end < len(ops) {
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContextLines {
+				break
+			}
+			end = next + 1
+		}
+		to := min(end+diffContextLines, len(ops))
+		prevOld, prevNew := 0, 0
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				prevOld++
+			}
+			if op.kind != '-' {
+				prevNew++
+			}
+		}
+		out = append(out, hunkHeader(ops[from:to], prevOld, prevNew))
+		for _, op := range ops[from:to] {
+			out = append(out, string(op.kind)+op.text)
+		}
+		start = to
+	}
+	if len(out) == 0 {
+		return "(no changes)"
+	}
+	header := []string{"--- " + path, "+++ " + path}
+	if len(out) > maxLines {
+		out = append(out[:maxLines], fmt.Sprintf("... (%d more diff lines)", len(out)-maxLines))
+	}
+	return strings.Join(append(header, out...), "\n")
+}
+
+// hunkHeader returns the header of the hunk ops, which follows prevOld old
+// and prevNew new lines. An empty side is anchored at the line before it, as
+// in diff -u.
+func hunkHeader(ops []lineOp, prevOld, prevNew int) string {
+	oldCount, newCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	oldStart, newStart := prevOld, prevNew
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount)
+}
@@ -25,7 +25,10 @@ type patchOperation struct {
 
 type diffHunk struct {
 	oldStart int
-	lines    []string
+	// header is the hunk header as shown in reports, "@@" when it has no
+	// line numbers.
+	header string
+	lines  []string
 }
 
 const (
@@ -35,14 +38,15 @@ const (
 )
 
 var ApplyPatch = ApplyPatchTool{
-	Name:        "apply_patch",
-	Description: "Apply a patch to files. Supports adding, updating, deleting, and moving files using the apply_patch format.",
+	Name: "apply_patch",
+	Description: "Apply a patch to files. Supports adding, updating, deleting, and moving files using the apply_patch format, or a unified diff as printed by 'git diff'. " +
+		"Hunks tolerate shifted line numbers and whitespace drift in context lines. If any hunk fails, nothing is written and the actual file content around each failed hunk is returned.",
 	Inputs: &pub_models.InputSchema{
 		Type: "object",
 		Properties: map[string]pub_models.ParameterObject{
 			"patch": {
 				Type:        "string",
-				Description: "The apply_patch formatted patch text.",
+				Description: "The apply_patch formatted patch text, or a unified diff.",
 			},
 		},
 		Required: []string{"patch"},
@@ -58,14 +62,15 @@ func (a ApplyPatchTool) Call(input pub_models.Input) (string, error) {
 		return "", fmt.Errorf("apply_patch call: %w", errors.New("patch must be non-empty"))
 	}
 
-	ops, err := parseApplyPatch(patch)
+	ops, err := parsePatch(patch)
 	if err != nil {
 		return "", fmt.Errorf("apply_patch call parse: %w", err)
 	}
 
+	plan := newPatchPlan()
 	var outputs []string
 	for _, op := range ops {
-		out, err := applyPatchOperation(op)
+		out, err := plan.apply(op)
 		if err != nil {
 			return "", fmt.Errorf("apply_patch call apply operation at line %d: %w", op.lineNumber, err)
 		}
@@ -73,10 +78,41 @@ func (a ApplyPatchTool) Call(input pub_models.Input) (string, error) {
 			outputs = append(outputs, out)
 		}
 	}
+	if err := plan.commit(); err != nil {
+		return "", fmt.Errorf("apply_patch call commit: %w", err)
+	}
 
 	return strings.Join(outputs, "\n"), nil
 }
 
+func (a ApplyPatchTool) WritePaths(input pub_models.Input) []string {
+	patch, ok := input["patch"].(string)
+	if !ok {
+		return nil
+	}
+	ops, err := parsePatch(patch)
+	if err != nil {
+		return nil
+	}
+	var ret []string
+	for _, op := range ops {
+		ret = append(ret, op.path)
+		if op.moveTo != "" {
+			ret = append(ret, op.moveTo)
+		}
+	}
+	return ret
+}
+
+// parsePatch parses patch in the apply_patch format, or as a unified diff
+// when it has no Begin Patch marker.
+func parsePatch(patch string) ([]patchOperation, error) {
+	if !strings.Contains(patch, "*** Begin Patch") && looksLikeUnifiedDiff(patch) {
+		return parseUnifiedDiff(patch)
+	}
+	return parseApplyPatch(patch)
+}
+
 func (a ApplyPatchTool) Specification() pub_models.Specification {
 	return pub_models.Specification(ApplyPatch)
 }
@@ -187,20 +223,79 @@ func patchLines(patch string) ([]string, error) {
 	return lines, nil
 }
 
-func applyPatchOperation(op patchOperation) (string, error) {
+// patchPlan stages the file changes of a patch in memory, so that a patch
+// which fails anywhere leaves every file untouched.
+type patchPlan struct {
+	// files holds the staged content of each touched path, nil when the path
+	// is deleted.
+	files map[string]*string
+	order []string
+}
+
+func newPatchPlan() *patchPlan {
+	return &patchPlan{files: map[string]*string{}}
+}
+
+// read returns the content of path as staged so far.
+func (p *patchPlan) read(path string) (string, error) {
+	if content, ok := p.files[path]; ok {
+		if content == nil {
+			return "", fmt.Errorf("read %s: %w", path, os.ErrNotExist)
+		}
+		return *content, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	return string(b), nil
+}
+
+func (p *patchPlan) stage(path string, content *string) {
+	if _, ok := p.files[path]; !ok {
+		p.order = append(p.order, path)
+	}
+	p.files[path] = content
+}
+
+// commit writes the staged changes in the order they were made.
+func (p *patchPlan) commit() error {
+	for _, path := range p.order {
+		content := p.files[path]
+		if content == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("delete %s: %w", path, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("create dirs for %s: %w", path, err)
+		}
+		perm := os.FileMode(0o644)
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+		if err := writeFileAtomic(path, []byte(*content), perm); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func (p *patchPlan) apply(op patchOperation) (string, error) {
 	switch op.kind {
 	case patchKindAdd:
-		return applyAddFile(op)
+		return p.addFile(op)
 	case patchKindUpdate:
-		return applyUpdateFile(op)
+		return p.updateFile(op)
 	case patchKindDelete:
-		return applyDeleteFile(op)
+		return p.deleteFile(op)
 	default:
 		return "", fmt.Errorf("apply patch operation: %w", fmt.Errorf("unknown patch operation kind: %s", op.kind))
 	}
 }
 
-func applyAddFile(op patchOperation) (string, error) {
+func (p *patchPlan) addFile(op patchOperation) (string, error) {
 	var contentLines []string
 	for _, line := range op.diffLines {
 		if !strings.HasPrefix(line, "+") {
@@ -209,76 +304,127 @@ func applyAddFile(op patchOperation) (string, error) {
 		contentLines = append(contentLines, strings.TrimPrefix(line, "+"))
 	}
 	content := strings.Join(contentLines, "\n")
-	if err := os.MkdirAll(filepath.Dir(op.path), 0o755); err != nil {
-		return "", fmt.Errorf("apply add file create dirs for %s: %w", op.path, err)
-	}
-	if err := os.WriteFile(op.path, []byte(content), 0o644); err != nil {
-		return "", fmt.Errorf("apply add file write %s: %w", op.path, err)
-	}
+	p.stage(op.path, &content)
 	return fmt.Sprintf("Created %s", op.path), nil
 }
 
-func applyUpdateFile(op patchOperation) (string, error) {
-	original, err := os.ReadFile(op.path)
+func (p *patchPlan) updateFile(op patchOperation) (string, error) {
+	original, err := p.read(op.path)
 	if err != nil {
-		return "", fmt.Errorf("apply update file read %s: %w", op.path, err)
+		return "", fmt.Errorf("apply update file: %w", err)
 	}
-	updated, err := applyDiff(string(original), op.diffLines, op.endOfFile)
-	if err != nil {
-		return "", fmt.Errorf("apply update file diff %s: %w", op.path, err)
-	}
-
-	targetPath := op.path
-	if op.moveTo != "" {
-		targetPath = op.moveTo
-		if err := os.MkdirAll(filepath.Dir(targetPath), 0o755); err != nil {
-			return "", fmt.Errorf("apply update file create dirs for %s: %w", targetPath, err)
+	// A rename without hunks keeps the content as is.
+	updated, results := original, []hunkResult(nil)
+	if len(op.diffLines) > 0 {
+		updated, results, err = applyDiff(original, op.diffLines, op.endOfFile)
+		if err != nil {
+			return "", fmt.Errorf("apply update file diff %s: %w", op.path, err)
 		}
 	}
 
-	if err := os.WriteFile(targetPath, []byte(updated), 0o644); err != nil {
-		return "", fmt.Errorf("apply update file write %s: %w", targetPath, err)
+	summary := fmt.Sprintf("Updated %s", op.path)
+	if op.moveTo != "" && op.moveTo != op.path {
+		p.stage(op.moveTo, &updated)
+		p.stage(op.path, nil)
+		summary = fmt.Sprintf("Moved %s to %s", op.path, op.moveTo)
+	} else {
+		p.stage(op.path, &updated)
 	}
-
-	if op.moveTo != "" {
-		if err := os.Remove(op.path); err != nil && !os.IsNotExist(err) {
-			return "", fmt.Errorf("apply update file remove original %s: %w", op.path, err)
-		}
-		return fmt.Sprintf("Moved %s to %s", op.path, op.moveTo), nil
+	if len(results) > 0 {
+		summary += "\n" + formatHunkResults(results)
 	}
+	return summary, nil
+}
 
-	return fmt.Sprintf("Updated %s", op.path), nil
+func (p *patchPlan) deleteFile(op patchOperation) (string, error) {
+	p.stage(op.path, nil)
+	return fmt.Sprintf("Deleted %s", op.path), nil
 }
 
-func applyDeleteFile(op patchOperation) (string, error) {
-	if err := os.Remove(op.path); err != nil && !os.IsNotExist(err) {
-		return "", fmt.Errorf("apply delete file %s: %w", op.path, err)
+// matchLevel is how strictly the context and removed lines of a hunk must
+// match the file. Hunks are tried at every level in turn, so whitespace drift
+// in the model's copy of the file does not fail the whole patch.
+type matchLevel int
+
+const (
+	matchExact matchLevel = iota
+	// matchTrailingSpace ignores trailing whitespace.
+	matchTrailingSpace
+	// matchWhitespace ignores leading and trailing whitespace and treats
+	// runs of inner whitespace as one space.
+	matchWhitespace
+)
+
+func (l matchLevel) equal(fileLine, patchLine string) bool {
+	switch l {
+	case matchTrailingSpace:
+		return strings.TrimRight(fileLine, " \t\r") == strings.TrimRight(patchLine, " \t\r")
+	case matchWhitespace:
+		return strings.Join(strings.Fields(fileLine), " ") == strings.Join(strings.Fields(patchLine), " ")
+	default:
+		return fileLine == patchLine
 	}
-	return fmt.Sprintf("Deleted %s", op.path), nil
 }
 
-func applyDiff(original string, diffLines []string, endOfFile bool) (string, error) {
+// hunkResult is the outcome of one hunk of a file update.
+type hunkResult struct {
+	header string
+	// at is the 1-based line the hunk was applied at, 0 when it failed.
+	at     int
+	offset int
+	level  matchLevel
+	err    error
+	// nearby is the file content around where the hunk most likely belongs,
+	// shown for failed hunks.
+	nearby string
+}
+
+// applyDiff applies the hunks of diffLines to original. Every hunk is tried
+// so that the error reports all failed hunks at once.
+func applyDiff(original string, diffLines []string, endOfFile bool) (string, []hunkResult, error) {
 	origLines := strings.Split(original, "\n")
 	idx := 0
+	// delta is the offset of the last applied hunk, carried over to the
+	// expected position of the next one.
+	delta := 0
+	failed := 0
 	out := make([]string, 0, len(origLines))
 
 	hunks, err := splitDiffIntoHunks(diffLines)
 	if err != nil {
-		return "", fmt.Errorf("apply diff split hunks: %w", err)
+		return "", nil, fmt.Errorf("apply diff split hunks: %w", err)
 	}
 
+	results := make([]hunkResult, 0, len(hunks))
 	for _, hunk := range hunks {
-		matchIdx, err := findHunkStart(origLines, idx, hunk)
-		if err != nil {
-			return "", fmt.Errorf("apply diff find hunk start: %w", err)
+		res := hunkResult{header: hunk.header}
+		expected := hunk.expectedStart() + delta
+		matchIdx, level, err := findHunkStart(origLines, idx, hunk, expected)
+		var updatedLines []string
+		var consumed int
+		if err == nil {
+			updatedLines, consumed, err = applyHunkAt(origLines, matchIdx, hunk, level)
 		}
-		out = append(out, origLines[idx:matchIdx]...)
-		updatedLines, consumed, err := applyHunkAt(origLines, matchIdx, hunk)
 		if err != nil {
-			return "", fmt.Errorf("apply diff apply hunk at %d: %w", matchIdx, err)
+			res.err = err
+			res.nearby = nearbyContent(origLines, hunk, expected)
+			results = append(results, res)
+			failed++
+			continue
 		}
+		out = append(out, origLines[idx:matchIdx]...)
 		out = append(out, updatedLines...)
 		idx = matchIdx + consumed
+		if hunk.oldStart > 0 {
+			res.offset = matchIdx - hunk.expectedStart()
+			delta = res.offset
+		}
+		res.at = matchIdx + 1
+		res.level = level
+		results = append(results, res)
+	}
+	if failed > 0 {
+		return "", results, fmt.Errorf("%d of %d hunks failed, nothing was written:\n%s", failed, len(hunks), formatHunkResults(results))
 	}
 	if idx < len(origLines) {
 		out = append(out, origLines[idx:]...)
@@ -287,24 +433,61 @@ func applyDiff(original string, diffLines []string, endOfFile bool) (string, err
 	if endOfFile {
 		result = strings.TrimSuffix(result, "\n")
 	}
-	return result, nil
+	return result, results, nil
+}
+
+func formatHunkResults(results []hunkResult) string {
+	var b strings.Builder
+	for i, r := range results {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "  hunk %d %s: ", i+1, r.header)
+		if r.err != nil {
+			fmt.Fprintf(&b, "FAILED, %v", r.err)
+			if r.nearby != "" {
+				b.WriteString("; actual file content where it most likely belongs:\n" + r.nearby)
+			}
+			continue
+		}
+		fmt.Fprintf(&b, "applied at line %d", r.at)
+		var notes []string
+		if r.offset != 0 {
+			notes = append(notes, fmt.Sprintf("offset %+d", r.offset))
+		}
+		switch r.level {
+		case matchTrailingSpace:
+			notes = append(notes, "ignoring trailing whitespace")
+		case matchWhitespace:
+			notes = append(notes, "ignoring whitespace")
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(notes, ", "))
+		}
+	}
+	return b.String()
 }
 
 func splitDiffIntoHunks(diffLines []string) ([]diffHunk, error) {
 	var hunks []diffHunk
-	current := diffHunk{}
+	current := diffHunk{header: "@@"}
 	haveCurrent := false
+	// trailingBlank counts the bare empty lines at the end of the current
+	// hunk, which are usually padding rather than blank context.
+	trailingBlank := 0
 
 	flush := func() error {
 		if !haveCurrent {
 			return nil
 		}
+		current.lines = current.lines[:len(current.lines)-trailingBlank]
 		if len(current.lines) == 0 {
 			return fmt.Errorf("empty hunk")
 		}
 		hunks = append(hunks, current)
-		current = diffHunk{}
+		current = diffHunk{header: "@@"}
 		haveCurrent = false
+		trailingBlank = 0
 		return nil
 	}
 
@@ -320,19 +503,27 @@ func splitDiffIntoHunks(diffLines []string) ([]diffHunk, error) {
 			if err != nil {
 				return nil, fmt.Errorf("parse hunk header %q: %w", line, err)
 			}
-			current = diffHunk{oldStart: oldStart}
+			current = diffHunk{oldStart: oldStart, header: hunkHeaderOf(line)}
 			haveCurrent = true
 			continue
 		}
+		// "\ No newline at end of file" annotates the previous line.
+		if strings.HasPrefix(line, `\`) {
+			continue
+		}
+		// Models often drop the space prefix of blank context lines.
 		if line == "" {
-			return nil, fmt.Errorf("diff line missing prefix: %w", errors.New("empty line"))
+			line = " "
+			trailingBlank++
+		} else {
+			trailingBlank = 0
 		}
 		prefix := line[0]
 		if prefix != ' ' && prefix != '+' && prefix != '-' {
 			return nil, fmt.Errorf("invalid diff prefix %q", string(prefix))
 		}
 		if !haveCurrent {
-			current = diffHunk{}
+			current = diffHunk{header: "@@"}
 			haveCurrent = true
 		}
 		current.lines = append(current.lines, line)
@@ -347,28 +538,74 @@ func splitDiffIntoHunks(diffLines []string) ([]diffHunk, error) {
 	return hunks, nil
 }
 
-func findHunkStart(lines []string, startIdx int, hunk diffHunk) (int, error) {
-	if hunk.oldStart > 0 {
-		targetIdx := hunk.oldStart - 1
-		if targetIdx >= startIdx && targetIdx <= len(lines) && hunkMatchesAt(lines, targetIdx, hunk) {
-			return targetIdx, nil
+// hunkHeaderOf returns the range part of a hunk header, without the section
+// heading which follows it.
+func hunkHeaderOf(line string) string {
+	if end := strings.Index(line[2:], "@@"); end >= 0 {
+		return line[:end+4]
+	}
+	return strings.TrimSpace(line)
+}
+
+// oldLen is the number of file lines the hunk replaces.
+func (h diffHunk) oldLen() int {
+	n := 0
+	for _, line := range h.lines {
+		if line[0] != '+' {
+			n++
 		}
 	}
-	for i := startIdx; i <= len(lines); i++ {
-		if hunkMatchesAt(lines, i, hunk) {
-			return i, nil
+	return n
+}
+
+// expectedStart is the 0-based line the hunk header places the hunk at. A
+// hunk which replaces no lines is inserted after its start line.
+func (h diffHunk) expectedStart() int {
+	if h.oldStart == 0 {
+		return 0
+	}
+	if h.oldLen() == 0 {
+		return h.oldStart
+	}
+	return h.oldStart - 1
+}
+
+// findHunkStart returns where the hunk applies at or after startIdx, and the
+// match level it applies at. Hunks with a line number are searched outwards
+// from expected, hunks without one from startIdx on.
+func findHunkStart(lines []string, startIdx int, hunk diffHunk, expected int) (int, matchLevel, error) {
+	for level := matchExact; level <= matchWhitespace; level++ {
+		if hunk.oldStart == 0 {
+			for i := startIdx; i <= len(lines); i++ {
+				if hunkMatchesAt(lines, i, hunk, level) {
+					return i, level, nil
+				}
+			}
+			continue
+		}
+		for d := 0; ; d++ {
+			below, above := expected-d, expected+d
+			if below < startIdx && above > len(lines) {
+				break
+			}
+			if below >= startIdx && below <= len(lines) && hunkMatchesAt(lines, below, hunk, level) {
+				return below, level, nil
+			}
+			if d > 0 && above >= startIdx && above <= len(lines) && hunkMatchesAt(lines, above, hunk, level) {
+				return above, level, nil
+			}
 		}
 	}
-	return 0, fmt.Errorf("no matching hunk position from line %d", startIdx+1)
+	return 0, matchExact, fmt.Errorf("no position from line %d matches its context and removed lines, even ignoring whitespace", startIdx+1)
 }
 
-func hunkMatchesAt(lines []string, start int, hunk diffHunk) bool {
+func hunkMatchesAt(lines []string, start int, hunk diffHunk, level matchLevel) bool {
 	idx := start
 	for _, line := range hunk.lines {
 		content := line[1:]
 		switch line[0] {
 		case ' ', '-':
-			if idx >= len(lines) || lines[idx] != content {
+			if idx >= len(lines) || !level.equal(lines[idx], content) {
 				return false
 			}
 			idx++
@@ -381,7 +618,9 @@ func hunkMatchesAt(lines []string, start int, hunk diffHunk) bool {
 	return true
 }
 
-func applyHunkAt(lines []string, start int, hunk diffHunk) ([]string, int, error) {
+// applyHunkAt returns the lines replacing the hunk at start and how many file
+// lines they replace. Context lines keep the file's version of the line.
+func applyHunkAt(lines []string, start int, hunk diffHunk, level matchLevel) ([]string, int, error) {
 	idx := start
 	updated := make([]string, 0, len(hunk.lines))
 	for _, line := range hunk.lines {
@@ -391,16 +630,16 @@ func applyHunkAt(lines []string, start int, hunk diffHunk) ([]string, int, error
 			if idx >= len(lines) {
 				return nil, 0, fmt.Errorf("context beyond end of file")
 			}
-			if lines[idx] != content {
+			if !level.equal(lines[idx], content) {
 				return nil, 0, fmt.Errorf("context mismatch: expected %q, got %q", lines[idx], content)
 			}
-			updated = append(updated, content)
+			updated = append(updated, lines[idx])
 			idx++
 		case '-':
 			if idx >= len(lines) {
 				return nil, 0, fmt.Errorf("delete beyond end of file")
 			}
-			if lines[idx] != content {
+			if !level.equal(lines[idx], content) {
 				return nil, 0, fmt.Errorf("delete mismatch: expected %q, got %q", lines[idx], content)
 			}
 			idx++
@@ -413,6 +652,54 @@ func applyHunkAt(lines []string, start int, hunk diffHunk) ([]string, int, error
 	return updated, idx - start, nil
 }
 
+// nearbyMaxLines caps the file content shown for a failed hunk.
+const nearbyMaxLines = 30
+
+// nearbyContent returns the numbered file lines around the position matching
+// most of the hunk's context and removed lines, ignoring whitespace. Ties go
+// to the position closest to expected. It is empty when no line matches and
+// the hunk has no line number.
+func nearbyContent(lines []string, hunk diffHunk, expected int) string {
+	var old []string
+	for _, line := range hunk.lines {
+		if line[0] != '+' {
+			old = append(old, line[1:])
+		}
+	}
+	best, bestScore := min(expected, len(lines)-1), 0
+	for pos := range lines {
+		score := 0
+		for i := 0; i < len(old) && pos+i < len(lines); i++ {
+			if strings.TrimSpace(old[i]) != "" && matchWhitespace.equal(lines[pos+i], old[i]) {
+				score++
+			}
+		}
+		if score > bestScore || (score == bestScore && score > 0 && absInt(pos-expected) < absInt(best-expected)) {
+			best, bestScore = pos, score
+		}
+	}
+	if bestScore == 0 && hunk.oldStart == 0 {
+		return ""
+	}
+	from := max(best-2, 0)
+	to := min(best+len(old)+2, len(lines), from+nearbyMaxLines)
+	var b strings.Builder
+	for i := from; i < to; i++ {
+		if i > from {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "    %5d| %s", i+1, lines[i])
+	}
+	return b.String()
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
 func parseUnifiedDiffOldRange(header string) (int, error) {
 	if header == "@@" {
 		return 0, nil
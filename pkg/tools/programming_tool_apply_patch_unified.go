@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// unifiedFile is the diff of one file in a unified diff.
+type unifiedFile struct {
+	oldPath, newPath       string
+	renameFrom, renameTo   string
+	newFile, deleted       bool
+	binary, noFinalNewline bool
+	// hasHeaders is set once the ---/+++ lines were read.
+	hasHeaders bool
+	lineNumber int
+	// lines are the hunk lines, including the @@ headers.
+	lines []string
+}
+
+// looksLikeUnifiedDiff reports whether patch has the file or hunk headers of a
+// unified diff.
+func looksLikeUnifiedDiff(patch string) bool {
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "diff --git ") || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "@@ -") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseUnifiedDiff parses a unified diff, as printed by `git diff` or
+// `diff -u`, into patch operations. Text before the first file header, such
+// as a commit message, is skipped. The body of a hunk is read by the line
+// counts of its @@ header, so removed and added lines which look like file
+// headers, such as "-- x" and "++ y", stay in the hunk.
+func parseUnifiedDiff(patch string) ([]patchOperation, error) {
+	lines, err := patchLines(patch)
+	if err != nil {
+		return nil, fmt.Errorf("parse unified diff scan: %w", err)
+	}
+
+	var files []*unifiedFile
+	var cur *unifiedFile
+	startFile := func(lineNumber int) {
+		cur = &unifiedFile{lineNumber: lineNumber}
+		files = append(files, cur)
+	}
+	// oldLeft and newLeft are the lines left in the current hunk.
+	var oldLeft, newLeft int
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if cur != nil && (oldLeft > 0 || newLeft > 0) {
+			switch {
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+			case strings.HasPrefix(line, `\`):
+				cur.noFinalNewline = true
+			default:
+				oldLeft--
+				newLeft--
+			}
+			cur.lines = append(cur.lines, line)
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			startFile(i + 1)
+			cur.oldPath, cur.newPath = parseGitDiffPaths(strings.TrimPrefix(line, "diff --git "))
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if cur == nil || cur.hasHeaders || len(cur.lines) > 0 {
+				startFile(i + 1)
+			}
+			cur.hasHeaders = true
+			cur.oldPath = unifiedDiffPath(line[len("--- "):])
+			cur.newPath = unifiedDiffPath(lines[i+1][len("+++ "):])
+			i++
+		case cur == nil:
+			if strings.HasPrefix(line, "@@") {
+				return nil, fmt.Errorf("parse unified diff: %w", fmt.Errorf("hunk without file header at line %d", i+1))
+			}
+		case len(cur.lines) == 0 && !strings.HasPrefix(line, "@@"):
+			parseGitExtendedHeader(cur, line)
+		default:
+			if strings.HasPrefix(line, `\`) {
+				cur.noFinalNewline = true
+			}
+			if strings.HasPrefix(line, "@@") {
+				oldLeft, newLeft = hunkLineCounts(line)
+			}
+			cur.lines = append(cur.lines, line)
+		}
+	}
+
+	var ops []patchOperation
+	for _, f := range files {
+		op, ok, err := f.operation()
+		if err != nil {
+			return nil, fmt.Errorf("parse unified diff at line %d: %w", f.lineNumber, err)
+		}
+		if ok {
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("parse unified diff: %w", errors.New("no file changes found"))
+	}
+	return ops, nil
+}
+
+// hunkLineCounts returns the old and new line counts of a hunk header
+// "@@ -a,b +c,d @@". A header without them, such as a bare "@@", gives zero
+// counts, so its body runs until the next header line.
+func hunkLineCounts(header string) (int, int) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0
+	}
+	count := func(raw string) int {
+		_, n, ok := strings.Cut(raw, ",")
+		if !ok {
+			return 1
+		}
+		c, err := strconv.Atoi(n)
+		if err != nil {
+			return 0
+		}
+		return c
+	}
+	return count(fields[1][1:]), count(fields[2][1:])
+}
+
+// parseGitDiffPaths splits the "a/<old> b/<new>" operand of a diff --git line.
+func parseGitDiffPaths(s string) (string, string) {
+	idx := strings.LastIndex(s, " b/")
+	if idx < 0 {
+		return "", ""
+	}
+	return s[:idx], s[idx+1:]
+}
+
+// unifiedDiffPath returns the path of a ---/+++ header, empty for /dev/null.
+// A trailing tab-separated timestamp, as printed by diff -u, is dropped.
+func unifiedDiffPath(raw string) string {
+	if before, _, ok := strings.Cut(raw, "\t"); ok {
+		raw = before
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "/dev/null" {
+		return ""
+	}
+	return raw
+}
+
+// parseGitExtendedHeader records the git header lines between diff --git and
+// the first hunk which matter for applying the diff.
+func parseGitExtendedHeader(f *unifiedFile, line string) {
+	switch {
+	case strings.HasPrefix(line, "rename from "):
+		f.renameFrom = strings.TrimPrefix(line, "rename from ")
+	case strings.HasPrefix(line, "rename to "):
+		f.renameTo = strings.TrimPrefix(line, "rename to ")
+	case strings.HasPrefix(line, "new file mode"):
+		f.newFile = true
+	case strings.HasPrefix(line, "deleted file mode"):
+		f.deleted = true
+	case strings.HasPrefix(line, "Binary files "), strings.HasPrefix(line, "GIT binary patch"):
+		f.binary = true
+	}
+}
+
+// stripGitPrefixes drops the a/ and b/ prefixes git puts on paths, but only
+// when both sides carry theirs, so a directory named "a" is left alone.
+func (f *unifiedFile) stripGitPrefixes() {
+	oldOK := f.oldPath == "" || strings.HasPrefix(f.oldPath, "a/")
+	newOK := f.newPath == "" || strings.HasPrefix(f.newPath, "b/")
+	if !oldOK || !newOK || (f.oldPath == "" && f.newPath == "") {
+		return
+	}
+	f.oldPath = strings.TrimPrefix(f.oldPath, "a/")
+	f.newPath = strings.TrimPrefix(f.newPath, "b/")
+}
+
+// operation returns the patch operation of the file, and false when the
+// diff changes nothing which apply_patch handles, such as a mode change.
+func (f *unifiedFile) operation() (patchOperation, bool, error) {
+	if f.binary {
+		return patchOperation{}, false, errors.New("binary patches are not supported")
+	}
+	f.stripGitPrefixes()
+	if f.renameFrom != "" {
+		f.oldPath = f.renameFrom
+	}
+	if f.renameTo != "" {
+		f.newPath = f.renameTo
+	}
+	if f.newFile && f.oldPath == f.newPath {
+		f.oldPath = ""
+	}
+	if f.deleted && f.oldPath == f.newPath {
+		f.newPath = ""
+	}
+
+	op := patchOperation{lineNumber: f.lineNumber}
+	switch {
+	case f.oldPath == "" && f.newPath == "":
+		return patchOperation{}, false, errors.New("missing file path")
+	case f.oldPath == "":
+		op.kind = patchKindAdd
+		op.path = f.newPath
+		for _, line := range f.lines {
+			if strings.HasPrefix(line, "+") {
+				op.diffLines = append(op.diffLines, line)
+			}
+		}
+		// Unified diffs end the content with a newline, unless they say
+		// otherwise.
+		if len(op.diffLines) > 0 && !f.noFinalNewline {
+			op.diffLines = append(op.diffLines, "+")
+		}
+	case f.newPath == "":
+		op.kind = patchKindDelete
+		op.path = f.oldPath
+	default:
+		if len(f.lines) == 0 && f.oldPath == f.newPath {
+			return patchOperation{}, false, nil
+		}
+		op.kind = patchKindUpdate
+		op.path = f.oldPath
+		if f.newPath != f.oldPath {
+			op.moveTo = f.newPath
+		}
+		op.diffLines = f.lines
+	}
+	return op, true, nil
+}
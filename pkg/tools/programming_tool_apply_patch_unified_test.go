@@ -0,0 +1,266 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// inDir runs the test from a fresh temp dir, so patches can use the
+// relative paths git prints.
+func inDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return string(b)
+}
+
+func TestApplyPatchTool_UnifiedDiff(t *testing.T) {
+	inDir(t, map[string]string{
+		"main.go":  "package main\n\nfunc main() {\n\tprintln(1)\n}\n",
+		"old.txt":  "keep\nme\n",
+		"gone.txt": "bye\n",
+	})
+	patch := `Some commit message the model added.
+
+diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3,3 +3,3 @@ package main
+ func main() {
+-	println(1)
++	println(2)
+ }
+diff --git a/old.txt b/renamed/new.txt
+similarity index 60%
+rename from old.txt
+rename to renamed/new.txt
+--- a/old.txt
++++ b/renamed/new.txt
+@@ -1,2 +1,2 @@
+ keep
+-me
++you
+diff --git a/added.txt b/added.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/added.txt
+@@ -0,0 +1,2 @@
++hello
++world
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+index 4444444..0000000
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+	out, err := ApplyPatch.Call(pub_models.Input{"patch": patch})
+	if err != nil {
+		t.Fatalf("apply unified diff: %v", err)
+	}
+	for _, want := range []string{"Updated main.go", "Moved old.txt to renamed/new.txt", "Created added.txt", "Deleted gone.txt", "hunk 1 @@ -3,3 +3,3 @@: applied at line 3"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if got := readFile(t, "main.go"); got != "package main\n\nfunc main() {\n\tprintln(2)\n}\n" {
+		t.Errorf("unexpected main.go: %q", got)
+	}
+	if got := readFile(t, "renamed/new.txt"); got != "keep\nyou\n" {
+		t.Errorf("unexpected renamed file: %q", got)
+	}
+	if _, err := os.Stat("old.txt"); !os.IsNotExist(err) {
+		t.Errorf("expected old.txt to be moved, err: %v", err)
+	}
+	if got := readFile(t, "added.txt"); got != "hello\nworld\n" {
+		t.Errorf("unexpected added file: %q", got)
+	}
+	if _, err := os.Stat("gone.txt"); !os.IsNotExist(err) {
+		t.Errorf("expected gone.txt to be deleted, err: %v", err)
+	}
+}
+
+func TestApplyPatchTool_UnifiedDiffPlainPathsAndRenameOnly(t *testing.T) {
+	inDir(t, map[string]string{"a.txt": "x\n", "b.txt": "one\ntwo\n"})
+	patch := `--- b.txt	2024-01-01 00:00:00.000000000 +0000
++++ b.txt	2024-01-02 00:00:00.000000000 +0000
+@@ -1,2 +1,2 @@
+-one
++ONE
+ two
+diff --git a/a.txt b/c.txt
+similarity index 100%
+rename from a.txt
+rename to c.txt
+`
+	if _, err := ApplyPatch.Call(pub_models.Input{"patch": patch}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if got := readFile(t, "c.txt"); got != "x\n" {
+		t.Errorf("unexpected renamed content: %q", got)
+	}
+	if got := readFile(t, "b.txt"); got != "ONE\ntwo\n" {
+		t.Errorf("unexpected b.txt: %q", got)
+	}
+}
+
+func TestApplyPatchTool_UnifiedDiffHeaderLikeHunkLines(t *testing.T) {
+	inDir(t, map[string]string{
+		"q.sql": "SELECT 1;\n-- x\nSELECT 2;\n",
+		"b.lua": "print(1)\n",
+	})
+	patch := `--- a/q.sql
++++ b/q.sql
+@@ -1,3 +1,3 @@
+ SELECT 1;
+--- x
++++ y
+ SELECT 2;
+--- a/b.lua
++++ b/b.lua
+@@ -1 +1 @@
+-print(1)
++print(2)
+`
+	if _, err := ApplyPatch.Call(pub_models.Input{"patch": patch}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if got := readFile(t, "q.sql"); got != "SELECT 1;\n++ y\nSELECT 2;\n" {
+		t.Errorf("unexpected q.sql: %q", got)
+	}
+	if got := readFile(t, "b.lua"); got != "print(2)\n" {
+		t.Errorf("unexpected b.lua: %q", got)
+	}
+}
+
+func TestApplyPatchTool_FuzzyHunks(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, "line "+strings.Repeat("x", i))
+	}
+	lines[14] = "\tif  ok {"
+	inDir(t, map[string]string{"f.txt": strings.Join(lines, "\n") + "\n"})
+
+	// Line numbers are off by 9 and the context drifted in whitespace.
+	patch := `--- a/f.txt
++++ b/f.txt
+@@ -5,3 +5,3 @@
+ ` + lines[13] + `
+-    if ok {
++	if !ok {
+ ` + lines[15] + `
+`
+	out, err := ApplyPatch.Call(pub_models.Input{"patch": patch})
+	if err != nil {
+		t.Fatalf("apply fuzzy hunk: %v", err)
+	}
+	if !strings.Contains(out, "applied at line 14 (offset +9, ignoring whitespace)") {
+		t.Fatalf("expected offset and fuzz in report, got:\n%s", out)
+	}
+	got := strings.Split(readFile(t, "f.txt"), "\n")
+	if got[14] != "\tif !ok {" || got[13] != lines[13] || got[15] != lines[15] {
+		t.Fatalf("unexpected patched lines: %q", got[13:16])
+	}
+}
+
+func TestApplyPatchTool_FailedHunkReportsAndWritesNothing(t *testing.T) {
+	inDir(t, map[string]string{
+		"a.txt": "alpha\nbeta\ngamma\n",
+		"b.txt": "one\ntwo\nthree\nfour\n",
+	})
+	patch := `--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+-alpha
++ALPHA
+ beta
+--- a/b.txt
++++ b/b.txt
+@@ -1,2 +1,2 @@
+-one
++ONE
+ two
+@@ -3,2 +3,2 @@
+ three
+-five
++FIVE
+`
+	_, err := ApplyPatch.Call(pub_models.Input{"patch": patch})
+	if err == nil {
+		t.Fatal("expected the failed hunk to fail the patch")
+	}
+	for _, want := range []string{
+		"1 of 2 hunks failed, nothing was written",
+		"hunk 1 @@ -1,2 +1,2 @@: applied at line 1",
+		"hunk 2 @@ -3,2 +3,2 @@: FAILED",
+		"        3| three\n        4| four",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+	if got := readFile(t, "a.txt"); got != "alpha\nbeta\ngamma\n" {
+		t.Errorf("expected a.txt untouched, got %q", got)
+	}
+	if got := readFile(t, "b.txt"); got != "one\ntwo\nthree\nfour\n" {
+		t.Errorf("expected b.txt untouched, got %q", got)
+	}
+}
+
+func TestParseUnifiedDiff_Errors(t *testing.T) {
+	for name, patch := range map[string]string{
+		"hunk without header": "@@ -1 +1 @@\n-a\n+b\n",
+		"binary":              "diff --git a/x.png b/x.png\nBinary files a/x.png and b/x.png differ\n",
+		"no changes":          "diff --git a/x b/x\nold mode 100644\nnew mode 100755\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseUnifiedDiff(patch); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestApplyPatchTool_WritePaths(t *testing.T) {
+	patch := `diff --git a/old.txt b/new.txt
+rename from old.txt
+rename to new.txt
+diff --git a/added.txt b/added.txt
+new file mode 100644
+--- /dev/null
++++ b/added.txt
+@@ -0,0 +1 @@
++x
+`
+	got := ApplyPatch.WritePaths(pub_models.Input{"patch": patch})
+	if strings.Join(got, ",") != "old.txt,new.txt,added.txt" {
+		t.Fatalf("unexpected write paths: %q", got)
+	}
+	if got := ApplyPatch.WritePaths(pub_models.Input{"patch": "not a patch"}); got != nil {
+		t.Fatalf("expected no paths for an invalid patch, got %q", got)
+	}
+}
@@ -0,0 +1,791 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+	"golang.org/x/tools/go/packages"
+)
+
+type GoSymbolsTool pub_models.Specification
+
+var GoSymbols = GoSymbolsTool{
+	Name: "go_symbols",
+	Description: "Navigate Go code with the type checker instead of grepping. " +
+		"Actions: 'declarations' lists the declarations of the matched packages, 'definition' shows where a symbol is declared with its doc and source, " +
+		"'references' lists every use of a symbol, 'methods' shows the method set of a type, 'implementations' lists the types implementing an interface " +
+		"(or the interfaces a type implements), and 'diagnostics' reports compile errors and go vet findings for files or packages. " +
+		"Symbols are package-level names, methods or fields: 'Name', 'Type.Method', 'pkg.Name', 'pkg.Type.Field' or 'import/path.Name'.",
+	Inputs: &pub_models.InputSchema{
+		Type: "object",
+		Properties: map[string]pub_models.ParameterObject{
+			"action": {
+				Type:        "string",
+				Description: "What to look up.",
+				Enum:        &[]string{"declarations", "definition", "references", "methods", "implementations", "diagnostics"},
+			},
+			"symbol": {
+				Type:        "string",
+				Description: "The symbol to look up. Required by definition, references, methods and implementations.",
+			},
+			"package": {
+				Type:        "string",
+				Description: "The package pattern to load, as given to go build (e.g. './...', './internal/text'). Defaults to './...'.",
+			},
+			"query": {
+				Type:        "string",
+				Description: "Optional case-insensitive filter on the names listed by declarations.",
+			},
+			"files": {
+				Type:        "array",
+				Description: "Files to report diagnostics for. Without them, diagnostics covers the package pattern.",
+				Items: &pub_models.ParameterObject{
+					Type:        "string",
+					Description: "A Go file path.",
+				},
+			},
+			"dir": {
+				Type:        "string",
+				Description: "The directory of the module to load (optional, defaults to current directory).",
+			},
+		},
+		Required: []string{"action"},
+	},
+}
+
+// goSymbolsMaxLines caps the lines returned to the model.
+const goSymbolsMaxLines = 300
+
+const goSymbolsLoadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports |
+	packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule
+
+func (g GoSymbolsTool) Call(input pub_models.Input) (string, error) {
+	return g.CallWithContext(context.Background(), input)
+}
+
+func (g GoSymbolsTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
+	action, ok := input["action"].(string)
+	if !ok || action == "" {
+		return "", fmt.Errorf("go_symbols call: %w", errors.New("action must be a non-empty string"))
+	}
+	dir, _ := input["dir"].(string)
+	pattern, _ := input["package"].(string)
+	if pattern == "" {
+		pattern = "./..."
+	}
+	symbol, _ := input["symbol"].(string)
+	if action != "declarations" && action != "diagnostics" && symbol == "" {
+		return "", fmt.Errorf("go_symbols call: %w", fmt.Errorf("symbol is required by %s", action))
+	}
+
+	var lines []string
+	var err error
+	switch action {
+	case "diagnostics":
+		files, ferr := stringList(input["files"])
+		if ferr != nil {
+			return "", fmt.Errorf("go_symbols call: files %w", ferr)
+		}
+		lines, err = goDiagnostics(ctx, dir, pattern, files)
+	case "declarations", "definition", "references", "methods", "implementations":
+		// References live in tests as much as anywhere else.
+		idx, lerr := loadGoSymbolIndex(ctx, dir, action == "references", pattern)
+		if lerr != nil {
+			return "", fmt.Errorf("go_symbols call: %w", lerr)
+		}
+		switch action {
+		case "declarations":
+			query, _ := input["query"].(string)
+			lines = idx.declarations(query)
+		case "definition":
+			lines, err = idx.definition(symbol)
+		case "references":
+			lines, err = idx.references(symbol)
+		case "methods":
+			lines, err = idx.methods(symbol)
+		case "implementations":
+			lines, err = idx.implementations(symbol)
+		}
+	default:
+		return "", fmt.Errorf("go_symbols call: unsupported action %q", action)
+	}
+	if err != nil {
+		return "", fmt.Errorf("go_symbols call %s %s: %w", action, symbol, err)
+	}
+	return capLines(lines, goSymbolsMaxLines), nil
+}
+
+func (g GoSymbolsTool) Specification() pub_models.Specification {
+	return pub_models.Specification(GoSymbols)
+}
+
+// stringList accepts a JSON array of strings, or one comma-separated string.
+func stringList(v any) ([]string, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		var ret []string
+		for _, s := range strings.Split(t, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				ret = append(ret, s)
+			}
+		}
+		return ret, nil
+	case []any:
+		ret := make([]string, 0, len(t))
+		for _, e := range t {
+			s, ok := e.(string)
+			if !ok {
+				return nil, errors.New("must be an array of strings")
+			}
+			ret = append(ret, s)
+		}
+		return ret, nil
+	default:
+		return nil, errors.New("must be an array of strings")
+	}
+}
+
+func capLines(lines []string, limit int) string {
+	if len(lines) > limit {
+		lines = append(lines[:limit:limit], fmt.Sprintf("... (%d more lines, narrow the package pattern or query)", len(lines)-limit))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// goSymbolIndex is a set of type-checked packages. Root packages importing
+// each other share their types, other imports come from export data.
+type goSymbolIndex struct {
+	fset *token.FileSet
+	pkgs []*packages.Package
+	// dir is what printed paths are relative to.
+	dir string
+}
+
+func loadGoPackages(ctx context.Context, dir string, tests bool, patterns ...string) ([]*packages.Package, *token.FileSet, error) {
+	fset := token.NewFileSet()
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    goSymbolsLoadMode,
+		Dir:     dir,
+		Fset:    fset,
+		Tests:   tests,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load %s: %w", strings.Join(patterns, " "), err)
+	}
+	if len(pkgs) == 0 {
+		return nil, nil, fmt.Errorf("no packages match %s", strings.Join(patterns, " "))
+	}
+	return pkgs, fset, nil
+}
+
+// goSymbolsCacheSize is the amount of indexes kept between calls.
+const goSymbolsCacheSize = 4
+
+// goSymbolsCache keeps the recently loaded indexes, newest last, so repeated
+// lookups skip parsing and type-checking while the sources are unchanged.
+var goSymbolsCache struct {
+	mu      sync.Mutex
+	entries []goSymbolsCacheEntry
+}
+
+type goSymbolsCacheEntry struct {
+	key, fingerprint string
+	idx              *goSymbolIndex
+}
+
+func loadGoSymbolIndex(ctx context.Context, dir string, tests bool, pattern string) (*goSymbolIndex, error) {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	key := fmt.Sprintf("%s|%t|%s", dir, tests, pattern)
+	fingerprint, err := goSourcesFingerprint(ctx, dir, tests, pattern)
+	if err != nil {
+		return nil, err
+	}
+	goSymbolsCache.mu.Lock()
+	defer goSymbolsCache.mu.Unlock()
+	entries := goSymbolsCache.entries
+	for i, e := range entries {
+		if e.key != key {
+			continue
+		}
+		entries = slices.Delete(entries, i, i+1)
+		if e.fingerprint == fingerprint {
+			goSymbolsCache.entries = append(entries, e)
+			return e.idx, nil
+		}
+		break
+	}
+	goSymbolsCache.entries = entries
+
+	pkgs, fset, err := loadGoPackages(ctx, dir, tests, pattern)
+	if err != nil {
+		return nil, err
+	}
+	idx := &goSymbolIndex{fset: fset, pkgs: pkgs, dir: dir}
+	if len(entries) >= goSymbolsCacheSize {
+		entries = entries[1:]
+	}
+	goSymbolsCache.entries = append(entries, goSymbolsCacheEntry{key: key, fingerprint: fingerprint, idx: idx})
+	return idx, nil
+}
+
+// goSourcesFingerprint lists the files of the packages matched by pattern,
+// without parsing them, and fingerprints their paths, sizes and modification
+// times along with the go.mod of their modules.
+func goSourcesFingerprint(ctx context.Context, dir string, tests bool, pattern string) (string, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedModule,
+		Dir:     dir,
+		Tests:   tests,
+	}, pattern)
+	if err != nil {
+		return "", fmt.Errorf("list %s: %w", pattern, err)
+	}
+	var files []string
+	for _, pkg := range pkgs {
+		files = append(files, pkg.GoFiles...)
+		files = append(files, pkg.OtherFiles...)
+		if pkg.Module != nil && pkg.Module.GoMod != "" {
+			files = append(files, pkg.Module.GoMod)
+		}
+	}
+	slices.Sort(files)
+	h := sha256.New()
+	for _, f := range slices.Compact(files) {
+		info, err := os.Stat(f)
+		if err != nil {
+			fmt.Fprintf(h, "%s missing\n", f)
+			continue
+		}
+		fmt.Fprintf(h, "%s %d %d\n", f, info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// pos prints p relative to the index dir when it is below it.
+func (idx *goSymbolIndex) pos(p token.Pos) string {
+	return relPosition(idx.dir, idx.fset.Position(p))
+}
+
+func relPosition(dir string, p token.Position) string {
+	if !p.IsValid() {
+		return "-"
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		if rel, err := filepath.Rel(abs, p.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+			p.Filename = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// objKey identifies an object across the test and non-test variants of its
+// package, which are type-checked separately.
+func (idx *goSymbolIndex) objKey(obj types.Object) string {
+	if obj == nil || !obj.Pos().IsValid() {
+		return ""
+	}
+	return idx.fset.Position(obj.Pos()).String() + "|" + obj.Name()
+}
+
+// qualifier prints the types of pkg unqualified and others by package name.
+func qualifier(pkg *types.Package) types.Qualifier {
+	return func(other *types.Package) string {
+		if other == pkg {
+			return ""
+		}
+		return other.Name()
+	}
+}
+
+func objectKind(obj types.Object) string {
+	switch o := obj.(type) {
+	case *types.Func:
+		if o.Type().(*types.Signature).Recv() != nil {
+			return "method"
+		}
+		return "func"
+	case *types.TypeName:
+		if types.IsInterface(o.Type()) {
+			return "interface"
+		}
+		return "type"
+	case *types.Var:
+		if o.IsField() {
+			return "field"
+		}
+		return "var"
+	case *types.Const:
+		return "const"
+	default:
+		return "object"
+	}
+}
+
+// declarations lists the package-level declarations of the root packages and
+// the methods of their types, in source order.
+func (idx *goSymbolIndex) declarations(query string) []string {
+	query = strings.ToLower(query)
+	match := func(name string) bool { return query == "" || strings.Contains(strings.ToLower(name), query) }
+	var lines []string
+	for _, pkg := range idx.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		objs := make([]types.Object, 0, scope.Len())
+		for _, name := range scope.Names() {
+			objs = append(objs, scope.Lookup(name))
+		}
+		sort.Slice(objs, func(i, j int) bool { return objs[i].Pos() < objs[j].Pos() })
+
+		var pkgLines []string
+		q := qualifier(pkg.Types)
+		for _, obj := range objs {
+			var methods []string
+			if tn, ok := obj.(*types.TypeName); ok && !tn.IsAlias() {
+				if named, ok := tn.Type().(*types.Named); ok {
+					for m := range named.Methods() {
+						if match(tn.Name() + "." + m.Name()) {
+							methods = append(methods, fmt.Sprintf("    %s  %s", idx.pos(m.Pos()), types.ObjectString(m, q)))
+						}
+					}
+				}
+			}
+			if !match(obj.Name()) && len(methods) == 0 {
+				continue
+			}
+			pkgLines = append(pkgLines, fmt.Sprintf("  %s  %s", idx.pos(obj.Pos()), declString(obj, q)))
+			pkgLines = append(pkgLines, methods...)
+		}
+		if len(pkgLines) > 0 {
+			lines = append(lines, "package "+pkg.ID)
+			lines = append(lines, pkgLines...)
+		}
+	}
+	if len(lines) == 0 {
+		return []string{"No declarations found."}
+	}
+	return lines
+}
+
+// declString prints obj without the struct or interface body of a type.
+func declString(obj types.Object, q types.Qualifier) string {
+	tn, ok := obj.(*types.TypeName)
+	if !ok || tn.IsAlias() {
+		return types.ObjectString(obj, q)
+	}
+	switch u := tn.Type().Underlying().(type) {
+	case *types.Struct:
+		return fmt.Sprintf("type %s struct (%d fields)", tn.Name(), u.NumFields())
+	case *types.Interface:
+		return fmt.Sprintf("type %s interface (%d methods)", tn.Name(), u.NumMethods())
+	default:
+		return types.ObjectString(obj, q)
+	}
+}
+
+// resolve returns the objects symbol names across the loaded packages and
+// their imports.
+func (idx *goSymbolIndex) resolve(symbol string) ([]types.Object, error) {
+	qual, names := splitSymbol(symbol)
+	if len(names) == 0 || len(names) > 3 {
+		return nil, fmt.Errorf("cannot parse symbol %q, use Name, Type.Method, pkg.Name or pkg.Type.Method", symbol)
+	}
+	seen := map[string]bool{}
+	var ret []types.Object
+	add := func(obj types.Object) {
+		if key := idx.objKey(obj); obj != nil && !seen[key] {
+			seen[key] = true
+			ret = append(ret, obj)
+		}
+	}
+	for _, pkg := range idx.allTypesPackages() {
+		if qual == "" {
+			add(lookupInPackage(pkg, names))
+		}
+		if qual != "" && (pkg.Path() == qual || pkg.Name() == qual) {
+			add(lookupInPackage(pkg, names))
+		}
+		// "pkg.Name" without an import path.
+		if qual == "" && len(names) > 1 && pkg.Name() == names[0] {
+			add(lookupInPackage(pkg, names[1:]))
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("symbol %q not found in the loaded packages, check the package pattern", symbol)
+	}
+	return ret, nil
+}
+
+// allTypesPackages returns the root packages followed by their direct imports.
+func (idx *goSymbolIndex) allTypesPackages() []*types.Package {
+	seen := map[*types.Package]bool{}
+	var roots, imports []*types.Package
+	for _, pkg := range idx.pkgs {
+		if pkg.Types == nil || seen[pkg.Types] {
+			continue
+		}
+		seen[pkg.Types] = true
+		roots = append(roots, pkg.Types)
+	}
+	for _, root := range roots {
+		for _, imp := range root.Imports() {
+			if !seen[imp] {
+				seen[imp] = true
+				imports = append(imports, imp)
+			}
+		}
+	}
+	return append(roots, imports...)
+}
+
+// splitSymbol splits "import/path.Type.Method" into the package qualifier,
+// when it has a slash, and the dotted names.
+func splitSymbol(symbol string) (string, []string) {
+	symbol = strings.TrimSpace(symbol)
+	var qual string
+	if slash := strings.LastIndex(symbol, "/"); slash >= 0 {
+		dot := strings.Index(symbol[slash:], ".")
+		if dot < 0 {
+			return symbol, nil
+		}
+		qual, symbol = symbol[:slash+dot], symbol[slash+dot+1:]
+	}
+	// (*T).Method, as printed by go/types.
+	symbol = strings.NewReplacer("(*", "", "(", "", ")", "").Replace(symbol)
+	var names []string
+	for _, n := range strings.Split(symbol, ".") {
+		if n == "" {
+			return qual, nil
+		}
+		names = append(names, n)
+	}
+	return qual, names
+}
+
+func lookupInPackage(pkg *types.Package, names []string) types.Object {
+	obj := pkg.Scope().Lookup(names[0])
+	if obj == nil || len(names) == 1 {
+		return obj
+	}
+	if _, ok := obj.(*types.TypeName); !ok || len(names) > 2 {
+		return nil
+	}
+	member, _, _ := types.LookupFieldOrMethod(obj.Type(), true, pkg, names[1])
+	return member
+}
+
+// syntaxFor returns the package which has the source of obj.
+func (idx *goSymbolIndex) syntaxFor(obj types.Object) *packages.Package {
+	file := idx.fset.Position(obj.Pos()).Filename
+	for _, pkg := range idx.pkgs {
+		if obj.Pkg() != nil && pkg.PkgPath == obj.Pkg().Path() && slices.Contains(pkg.CompiledGoFiles, file) {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// definition prints where each object symbol resolves to is declared, with its
+// doc comment and source.
+func (idx *goSymbolIndex) definition(symbol string) ([]string, error) {
+	objs, err := idx.resolve(symbol)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for i, obj := range objs {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s %s", idx.pos(obj.Pos()), objectKind(obj), types.ObjectString(obj, (*types.Package).Name)))
+		if src := idx.declSource(obj); src != "" {
+			lines = append(lines, strings.Split(src, "\n")...)
+		}
+	}
+	return lines, nil
+}
+
+// declSourceMaxLines caps the source printed by definition.
+const declSourceMaxLines = 60
+
+// declSource returns the doc comment and source of the declaration of obj,
+// when its package was loaded from source.
+func (idx *goSymbolIndex) declSource(obj types.Object) string {
+	pkg := idx.syntaxFor(obj)
+	if pkg == nil {
+		return ""
+	}
+	for _, f := range pkg.Syntax {
+		if obj.Pos() < f.Pos() || obj.Pos() > f.End() {
+			continue
+		}
+		node := declNode(f, obj.Pos())
+		if node == nil {
+			return ""
+		}
+		tf := idx.fset.File(f.Pos())
+		src, err := os.ReadFile(tf.Name())
+		if err != nil {
+			return ""
+		}
+		start, end := tf.Offset(node.Pos()), tf.Offset(node.End())
+		lines := strings.Split(string(src[start:end]), "\n")
+		if len(lines) > declSourceMaxLines {
+			lines = append(lines[:declSourceMaxLines], fmt.Sprintf("... (%d more lines)", len(lines)-declSourceMaxLines))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+// declNode returns the smallest declaration, spec or field of f declaring
+// pos, including its doc comment.
+func declNode(f *ast.File, pos token.Pos) ast.Node {
+	var found ast.Node
+	withDoc := func(n ast.Node, doc *ast.CommentGroup) ast.Node {
+		if doc == nil {
+			return n
+		}
+		return span{doc.Pos(), n.End()}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil || pos < n.Pos() || pos >= n.End() {
+			return false
+		}
+		switch d := n.(type) {
+		case *ast.FuncDecl:
+			found = withDoc(d, d.Doc)
+			return false
+		case *ast.GenDecl:
+			if len(d.Specs) == 1 {
+				found = withDoc(d, d.Doc)
+				return false
+			}
+		case *ast.TypeSpec:
+			found = withDoc(d, d.Doc)
+		case *ast.ValueSpec:
+			found = withDoc(d, d.Doc)
+			return false
+		case *ast.Field:
+			found = withDoc(d, d.Doc)
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// span is a source range which is not a single node.
+type span struct{ pos, end token.Pos }
+
+func (s span) Pos() token.Pos { return s.pos }
+func (s span) End() token.Pos { return s.end }
+
+// references lists the uses of the objects symbol resolves to, in the loaded
+// packages and their tests.
+func (idx *goSymbolIndex) references(symbol string) ([]string, error) {
+	objs, err := idx.resolve(symbol)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]bool{}
+	for _, obj := range objs {
+		keys[idx.objKey(obj)] = true
+	}
+	type ref struct {
+		pos  token.Position
+		line string
+	}
+	seen := map[string]bool{}
+	var refs []ref
+	srcCache := map[string][]string{}
+	for _, pkg := range idx.pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for id, obj := range pkg.TypesInfo.Uses {
+			if !keys[idx.objKey(obj)] {
+				continue
+			}
+			p := idx.fset.Position(id.Pos())
+			if seen[p.String()] {
+				continue
+			}
+			seen[p.String()] = true
+			refs = append(refs, ref{pos: p, line: sourceLine(srcCache, p)})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].pos.Filename != refs[j].pos.Filename {
+			return refs[i].pos.Filename < refs[j].pos.Filename
+		}
+		return refs[i].pos.Offset < refs[j].pos.Offset
+	})
+	lines := []string{fmt.Sprintf("%d reference(s) to %s", len(refs), symbol)}
+	for _, r := range refs {
+		lines = append(lines, fmt.Sprintf("%s: %s", relPosition(idx.dir, r.pos), r.line))
+	}
+	return lines, nil
+}
+
+func sourceLine(cache map[string][]string, p token.Position) string {
+	lines, ok := cache[p.Filename]
+	if !ok {
+		b, _ := os.ReadFile(p.Filename)
+		lines = strings.Split(string(b), "\n")
+		cache[p.Filename] = lines
+	}
+	if p.Line < 1 || p.Line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[p.Line-1])
+}
+
+func (idx *goSymbolIndex) resolveType(symbol string) (*types.TypeName, error) {
+	objs, err := idx.resolve(symbol)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		if tn, ok := obj.(*types.TypeName); ok {
+			return tn, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is a %s, not a type", symbol, objectKind(objs[0]))
+}
+
+// methods prints the method set of *T, marking the methods which need a
+// pointer receiver and the ones promoted from embedded fields.
+func (idx *goSymbolIndex) methods(symbol string) ([]string, error) {
+	tn, err := idx.resolveType(symbol)
+	if err != nil {
+		return nil, err
+	}
+	t := tn.Type()
+	q := qualifier(tn.Pkg())
+	var lines []string
+	if types.IsInterface(t) {
+		ms := types.NewMethodSet(t)
+		lines = append(lines, fmt.Sprintf("interface %s has %d method(s)", tn.Name(), ms.Len()))
+		for sel := range ms.Methods() {
+			lines = append(lines, fmt.Sprintf("  %s  %s", idx.pos(sel.Obj().Pos()), types.ObjectString(sel.Obj(), q)))
+		}
+		return lines, nil
+	}
+	valueSet := types.NewMethodSet(t)
+	ptrSet := types.NewMethodSet(types.NewPointer(t))
+	lines = append(lines, fmt.Sprintf("%s has %d method(s), %d of them on the value receiver", tn.Name(), ptrSet.Len(), valueSet.Len()))
+	for sel := range ptrSet.Methods() {
+		var notes []string
+		if valueSet.Lookup(sel.Obj().Pkg(), sel.Obj().Name()) == nil {
+			notes = append(notes, "pointer receiver")
+		}
+		if len(sel.Index()) > 1 {
+			notes = append(notes, "promoted")
+		}
+		note := ""
+		if len(notes) > 0 {
+			note = " (" + strings.Join(notes, ", ") + ")"
+		}
+		lines = append(lines, fmt.Sprintf("  %s  %s%s", idx.pos(sel.Obj().Pos()), types.ObjectString(sel.Obj(), q), note))
+	}
+	return lines, nil
+}
+
+// implementations lists the named types of the loaded packages implementing
+// the interface symbol, or when symbol is a concrete type, the interfaces of
+// the loaded packages and their imports it implements.
+func (idx *goSymbolIndex) implementations(symbol string) ([]string, error) {
+	tn, err := idx.resolveType(symbol)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	if iface, ok := tn.Type().Underlying().(*types.Interface); ok {
+		for _, cand := range idx.namedTypes(idx.rootPackages()) {
+			if cand == tn || types.IsInterface(cand.Type()) {
+				continue
+			}
+			switch {
+			case types.Implements(cand.Type(), iface):
+				lines = append(lines, fmt.Sprintf("  %s  %s", idx.pos(cand.Pos()), typeName(cand)))
+			case types.Implements(types.NewPointer(cand.Type()), iface):
+				lines = append(lines, fmt.Sprintf("  %s  *%s", idx.pos(cand.Pos()), typeName(cand)))
+			}
+		}
+		return append([]string{fmt.Sprintf("%d type(s) implement %s", len(lines), symbol)}, lines...), nil
+	}
+
+	for _, cand := range idx.namedTypes(idx.allTypesPackages()) {
+		iface, ok := cand.Type().Underlying().(*types.Interface)
+		if !ok || iface.NumMethods() == 0 {
+			continue
+		}
+		switch {
+		case types.Implements(tn.Type(), iface):
+			lines = append(lines, fmt.Sprintf("  %s  %s", idx.pos(cand.Pos()), typeName(cand)))
+		case types.Implements(types.NewPointer(tn.Type()), iface):
+			lines = append(lines, fmt.Sprintf("  %s  %s (through *%s)", idx.pos(cand.Pos()), typeName(cand), tn.Name()))
+		}
+	}
+	return append([]string{fmt.Sprintf("%s implements %d interface(s) of the loaded packages and their imports", symbol, len(lines))}, lines...), nil
+}
+
+func (idx *goSymbolIndex) rootPackages() []*types.Package {
+	var ret []*types.Package
+	for _, pkg := range idx.pkgs {
+		if pkg.Types != nil {
+			ret = append(ret, pkg.Types)
+		}
+	}
+	return ret
+}
+
+// namedTypes returns the package-level, non-generic named types of pkgs,
+// once per declaration.
+func (idx *goSymbolIndex) namedTypes(pkgs []*types.Package) []*types.TypeName {
+	seen := map[string]bool{}
+	var ret []*types.TypeName
+	for _, pkg := range pkgs {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			if named, ok := tn.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue
+			}
+			if key := idx.objKey(tn); !seen[key] {
+				seen[key] = true
+				ret = append(ret, tn)
+			}
+		}
+	}
+	return ret
+}
+
+func typeName(tn *types.TypeName) string {
+	return tn.Pkg().Name() + "." + tn.Name()
+}
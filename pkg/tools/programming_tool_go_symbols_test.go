@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// goSymbolsModule is a small module with an interface, two implementations
+// and a test using one of them.
+var goSymbolsModule = map[string]string{
+	"go.mod": "module example.com/shapes\n\ngo 1.22\n",
+	"shapes/shapes.go": `package shapes
+
+// Shape has an area.
+type Shape interface {
+	Area() float64
+}
+
+// Square is a Shape.
+type Square struct {
+	Side float64
+}
+
+// Area of the square.
+func (s Square) Area() float64 { return s.Side * s.Side }
+
+type Circle struct{ R float64 }
+
+func (c *Circle) Area() float64 { return 3 * c.R * c.R }
+
+func (c *Circle) Grow() { c.R++ }
+
+func Total(shapes ...Shape) float64 {
+	var t float64
+	for _, s := range shapes {
+		t += s.Area()
+	}
+	return t
+}
+`,
+	"shapes/shapes_test.go": `package shapes
+
+import "testing"
+
+func TestTotal(t *testing.T) {
+	if Total(Square{Side: 2}) != 4 {
+		t.Fatal("bad total")
+	}
+}
+`,
+	"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/shapes/shapes"
+)
+
+func main() {
+	fmt.Println(shapes.Total(shapes.Square{Side: 1}, &shapes.Circle{R: 1}))
+}
+`,
+}
+
+func callGoSymbols(t *testing.T, input pub_models.Input) string {
+	t.Helper()
+	out, err := GoSymbols.Call(input)
+	if err != nil {
+		t.Fatalf("go_symbols %v: %v", input, err)
+	}
+	return out
+}
+
+func assertContains(t *testing.T, out string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestGoSymbolsTool(t *testing.T) {
+	if testing.Short() {
+		t.Skip("loads packages with the go command")
+	}
+	inDir(t, goSymbolsModule)
+
+	t.Run("declarations", func(t *testing.T) {
+		out := callGoSymbols(t, pub_models.Input{"action": "declarations", "package": "./shapes"})
+		assertContains(t, out,
+			"package example.com/shapes/shapes",
+			"shapes/shapes.go:4:6  type Shape interface (1 methods)",
+			"type Square struct (1 fields)",
+			"func (*Circle).Grow()",
+			"func Total(shapes ...Shape) float64",
+		)
+		filtered := callGoSymbols(t, pub_models.Input{"action": "declarations", "query": "circ"})
+		if strings.Contains(filtered, "Square") || !strings.Contains(filtered, "Circle") {
+			t.Errorf("expected only Circle declarations, got:\n%s", filtered)
+		}
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		out := callGoSymbols(t, pub_models.Input{"action": "definition", "symbol": "shapes.Square.Area"})
+		assertContains(t, out, "shapes/shapes.go:14:17  method func (shapes.Square).Area() float64",
+			"// Area of the square.\nfunc (s Square) Area() float64 { return s.Side * s.Side }")
+	})
+
+	t.Run("references include tests", func(t *testing.T) {
+		out := callGoSymbols(t, pub_models.Input{"action": "references", "symbol": "Total"})
+		assertContains(t, out, "2 reference(s) to Total",
+			"main.go:10:21: fmt.Println(shapes.Total(",
+			"shapes/shapes_test.go:6:5: if Total(Square{Side: 2}) != 4 {")
+	})
+
+	t.Run("methods", func(t *testing.T) {
+		out := callGoSymbols(t, pub_models.Input{"action": "methods", "symbol": "example.com/shapes/shapes.Circle"})
+		assertContains(t, out, "Circle has 2 method(s), 0 of them on the value receiver",
+			"func (*Circle).Grow() (pointer receiver)")
+	})
+
+	t.Run("implementations", func(t *testing.T) {
+		out := callGoSymbols(t, pub_models.Input{"action": "implementations", "symbol": "Shape"})
+		assertContains(t, out, "2 type(s) implement Shape", "shapes.Square", "*shapes.Circle")
+		out = callGoSymbols(t, pub_models.Input{"action": "implementations", "symbol": "Square"})
+		assertContains(t, out, "shapes.Shape")
+	})
+
+	t.Run("unknown symbol", func(t *testing.T) {
+		if _, err := GoSymbols.Call(pub_models.Input{"action": "definition", "symbol": "Nope"}); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+}
+
+func TestLoadGoSymbolIndex_CachedUntilSourcesChange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("loads packages with the go command")
+	}
+	dir := inDir(t, goSymbolsModule)
+	ctx := context.Background()
+
+	first, err := loadGoSymbolIndex(ctx, dir, false, "./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := loadGoSymbolIndex(ctx, dir, false, "./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != first {
+		t.Fatal("expected the unchanged sources to reuse the index")
+	}
+
+	if err := os.WriteFile("shapes/extra.go", []byte("package shapes\n\nfunc Extra() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := loadGoSymbolIndex(ctx, dir, false, "./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == first {
+		t.Fatal("expected a new file to reload the index")
+	}
+	assertContains(t, strings.Join(changed.declarations("Extra"), "\n"), "func Extra()")
+}
+
+func TestGoSymbolsTool_Diagnostics(t *testing.T) {
+	if testing.Short() {
+		t.Skip("loads packages with the go command")
+	}
+	files := map[string]string{}
+	for k, v := range goSymbolsModule {
+		files[k] = v
+	}
+	files["shapes/bad.go"] = `package shapes
+
+import "fmt"
+
+type tagged struct {
+	A int ` + "`json:a`" + `
+}
+
+func broken() int {
+	x := 1
+	x = x
+	return "s"
+}
+
+var _ = fmt.Sprint
+`
+	inDir(t, files)
+
+	out := callGoSymbols(t, pub_models.Input{"action": "diagnostics", "files": []any{"shapes/bad.go"}})
+	assertContains(t, out, "shapes/bad.go:12:9: cannot use \"s\"")
+	if strings.Contains(out, "shapes.go") {
+		t.Errorf("expected only bad.go diagnostics, got:\n%s", out)
+	}
+
+	files["shapes/bad.go"] = strings.Replace(files["shapes/bad.go"], `return "s"`, "return x", 1)
+	inDir(t, files)
+	out = callGoSymbols(t, pub_models.Input{"action": "diagnostics", "files": "shapes/bad.go"})
+	assertContains(t, out, "shapes/bad.go:11:2: self-assignment of x (assign)", "not compatible with reflect.StructTag.Get", "(structtag)")
+
+	out = callGoSymbols(t, pub_models.Input{"action": "diagnostics", "package": "./..."})
+	assertContains(t, out, "(assign)")
+}
+
+func TestSplitSymbol(t *testing.T) {
+	for in, want := range map[string]string{
+		"Name":                           "|Name",
+		"pkg.Type.Method":                "|pkg,Type,Method",
+		"(*Type).Method":                 "|Type,Method",
+		"github.com/a/b.Type.Method":     "github.com/a/b|Type,Method",
+		"example.com/shapes/shapes.Area": "example.com/shapes/shapes|Area",
+	} {
+		qual, names := splitSymbol(in)
+		if got := qual + "|" + strings.Join(names, ","); got != want {
+			t.Errorf("splitSymbol(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
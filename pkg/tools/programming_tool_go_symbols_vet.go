@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/analysis/passes/appends"
+	"golang.org/x/tools/go/analysis/passes/assign"
+	"golang.org/x/tools/go/analysis/passes/atomic"
+	"golang.org/x/tools/go/analysis/passes/bools"
+	"golang.org/x/tools/go/analysis/passes/composite"
+	"golang.org/x/tools/go/analysis/passes/copylock"
+	"golang.org/x/tools/go/analysis/passes/defers"
+	"golang.org/x/tools/go/analysis/passes/directive"
+	"golang.org/x/tools/go/analysis/passes/errorsas"
+	"golang.org/x/tools/go/analysis/passes/httpresponse"
+	"golang.org/x/tools/go/analysis/passes/ifaceassert"
+	"golang.org/x/tools/go/analysis/passes/loopclosure"
+	"golang.org/x/tools/go/analysis/passes/nilfunc"
+	"golang.org/x/tools/go/analysis/passes/shift"
+	"golang.org/x/tools/go/analysis/passes/sigchanyzer"
+	"golang.org/x/tools/go/analysis/passes/stdmethods"
+	"golang.org/x/tools/go/analysis/passes/stringintconv"
+	"golang.org/x/tools/go/analysis/passes/structtag"
+	"golang.org/x/tools/go/analysis/passes/testinggoroutine"
+	"golang.org/x/tools/go/analysis/passes/tests"
+	"golang.org/x/tools/go/analysis/passes/timeformat"
+	"golang.org/x/tools/go/analysis/passes/unmarshal"
+	"golang.org/x/tools/go/analysis/passes/unreachable"
+	"golang.org/x/tools/go/analysis/passes/unsafeptr"
+	"golang.org/x/tools/go/analysis/passes/unusedresult"
+	"golang.org/x/tools/go/analysis/passes/waitgroup"
+	"golang.org/x/tools/go/packages"
+)
+
+// vetAnalyzers are the go vet analyzers which need no facts about the
+// dependencies, so they run without loading the syntax of every import.
+// printf and lostcancel are left out for that reason.
+var vetAnalyzers = []*analysis.Analyzer{
+	appends.Analyzer,
+	assign.Analyzer,
+	atomic.Analyzer,
+	bools.Analyzer,
+	composite.Analyzer,
+	copylock.Analyzer,
+	defers.Analyzer,
+	directive.Analyzer,
+	errorsas.Analyzer,
+	httpresponse.Analyzer,
+	ifaceassert.Analyzer,
+	loopclosure.Analyzer,
+	nilfunc.Analyzer,
+	shift.Analyzer,
+	sigchanyzer.Analyzer,
+	stdmethods.Analyzer,
+	stringintconv.Analyzer,
+	structtag.Analyzer,
+	testinggoroutine.Analyzer,
+	tests.Analyzer,
+	timeformat.Analyzer,
+	unmarshal.Analyzer,
+	unreachable.Analyzer,
+	unsafeptr.Analyzer,
+	unusedresult.Analyzer,
+	waitgroup.Analyzer,
+}
+
+// goDiagnostics reports the load, type and vet errors of files, or of every
+// file matched by pattern when files is empty.
+func goDiagnostics(ctx context.Context, dir, pattern string, files []string) ([]string, error) {
+	base := dir
+	if base == "" {
+		base, _ = os.Getwd()
+	}
+	patterns := []string{pattern}
+	wanted := map[string]bool{}
+	if len(files) > 0 {
+		patterns = nil
+		for _, f := range files {
+			if !filepath.IsAbs(f) {
+				f = filepath.Join(base, f)
+			}
+			f = filepath.Clean(f)
+			if _, err := os.Stat(f); err != nil {
+				return nil, fmt.Errorf("file %s: %w", f, err)
+			}
+			wanted[f] = true
+			patterns = append(patterns, "file="+f)
+		}
+	}
+	pkgs, fset, err := loadGoPackages(ctx, dir, true, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	keep := func(p token.Position) bool { return len(wanted) == 0 || wanted[filepath.Clean(p.Filename)] }
+
+	type diag struct {
+		pos token.Position
+		msg string
+	}
+	seen := map[string]bool{}
+	var diags []diag
+	add := func(p token.Position, msg string) {
+		key := p.String() + msg
+		if !keep(p) || seen[key] {
+			return
+		}
+		seen[key] = true
+		diags = append(diags, diag{pos: p, msg: msg})
+	}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			p := parsePackagesErrorPos(e.Pos)
+			if len(wanted) > 0 && !p.IsValid() {
+				// Errors without a position, like a missing import,
+				// concern every file of the package.
+				p = token.Position{Filename: firstWanted(pkg, wanted)}
+			}
+			add(p, e.Msg)
+		}
+	})
+
+	graph, err := checker.Analyze(vetAnalyzers, pkgs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("run analyzers: %w", err)
+	}
+	for act := range graph.All() {
+		if !act.IsRoot {
+			continue
+		}
+		for _, d := range act.Diagnostics {
+			add(fset.Position(d.Pos), fmt.Sprintf("%s (%s)", d.Message, act.Analyzer.Name))
+		}
+	}
+
+	sort.Slice(diags, func(i, j int) bool {
+		if diags[i].pos.Filename != diags[j].pos.Filename {
+			return diags[i].pos.Filename < diags[j].pos.Filename
+		}
+		if diags[i].pos.Line != diags[j].pos.Line {
+			return diags[i].pos.Line < diags[j].pos.Line
+		}
+		return diags[i].pos.Column < diags[j].pos.Column
+	})
+	if len(diags) == 0 {
+		return []string{fmt.Sprintf("No problems found in %d package(s).", len(pkgs))}, nil
+	}
+	lines := []string{fmt.Sprintf("%d problem(s):", len(diags))}
+	for _, d := range diags {
+		lines = append(lines, fmt.Sprintf("%s: %s", diagPosition(base, d.pos), d.msg))
+	}
+	return lines, nil
+}
+
+// parsePackagesErrorPos parses the "file:line:col" position of a
+// packages.Error.
+func parsePackagesErrorPos(pos string) token.Position {
+	var p token.Position
+	if pos == "" || pos == "-" {
+		return p
+	}
+	rest := pos
+	var nums []int
+	for range 2 {
+		i := strings.LastIndex(rest, ":")
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(rest[i+1:])
+		if err != nil {
+			break
+		}
+		nums = append([]int{n}, nums...)
+		rest = rest[:i]
+	}
+	p.Filename = rest
+	if len(nums) > 0 {
+		p.Line = nums[0]
+	}
+	if len(nums) > 1 {
+		p.Column = nums[1]
+	}
+	return p
+}
+
+func firstWanted(pkg *packages.Package, wanted map[string]bool) string {
+	for _, f := range pkg.GoFiles {
+		if wanted[filepath.Clean(f)] {
+			return f
+		}
+	}
+	return ""
+}
+
+// diagPosition is relPosition, but also prints positions without a line.
+func diagPosition(dir string, p token.Position) string {
+	if p.Filename == "" {
+		return "-"
+	}
+	if p.Line == 0 {
+		p.Line = 1
+	}
+	return relPosition(dir, p)
+}
@@ -111,3 +111,7 @@ func (s SedTool) Call(input pub_models.Input) (string, error) {
 func (s SedTool) Specification() pub_models.Specification {
 	return pub_models.Specification(Sed)
 }
+
+func (s SedTool) WritePaths(input pub_models.Input) []string {
+	return inputPath(input, "file_path")
+}
@@ -0,0 +1,302 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+type StrReplaceTool pub_models.Specification
+
+var StrReplace = StrReplaceTool{
+	Name: "str_replace",
+	Description: "Edit a file by replacing exact text. Each old_string must occur exactly once in the file, unless replace_all is set. " +
+		"Pass either old_string/new_string for one edit, or edits for several; the edits are applied in order and either all of them are written or none. " +
+		"Line endings are matched regardless of CRLF/LF. Returns a diff of the change.",
+	Inputs: &pub_models.InputSchema{
+		Type: "object",
+		Properties: map[string]pub_models.ParameterObject{
+			"file_path": {
+				Type:        "string",
+				Description: "The path to the file to edit.",
+			},
+			"old_string": {
+				Type:        "string",
+				Description: "The exact text to replace, including whitespace and indentation. Add surrounding lines to make it unique.",
+			},
+			"new_string": {
+				Type:        "string",
+				Description: "The text to replace old_string with.",
+			},
+			"replace_all": {
+				Type:        "boolean",
+				Description: "If true, replace every occurrence of old_string instead of requiring exactly one.",
+			},
+			"edits": {
+				Type:        "array",
+				Description: "Several edits to apply in order, each an object with old_string, new_string and optional replace_all.",
+				Items: &pub_models.ParameterObject{
+					Type:        "object",
+					Description: `An edit: {"old_string": "...", "new_string": "...", "replace_all": false}.`,
+				},
+			},
+		},
+		Required: []string{"file_path"},
+	},
+}
+
+// strEdit is one replacement of a str_replace call.
+type strEdit struct {
+	old, new   string
+	replaceAll bool
+}
+
+// strReplaceMaxDiffLines caps the diff returned to the model.
+const strReplaceMaxDiffLines = 200
+
+func (s StrReplaceTool) Call(input pub_models.Input) (string, error) {
+	filePath, ok := input["file_path"].(string)
+	if !ok || filePath == "" {
+		return "", fmt.Errorf("str_replace call: %w", errors.New("file_path must be a non-empty string"))
+	}
+	edits, err := parseStrEdits(input)
+	if err != nil {
+		return "", fmt.Errorf("str_replace call: %w", err)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("str_replace call: file %s does not exist, use write_file to create it", filePath)
+		}
+		return "", fmt.Errorf("str_replace call stat %s: %w", filePath, err)
+	}
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("str_replace call read %s: %w", filePath, err)
+	}
+
+	content, endings := splitLineEndings(string(raw))
+	updated, endings, replaced, err := applyStrEdits(content, endings, edits)
+	if err != nil {
+		return "", fmt.Errorf("str_replace call %s: %w", filePath, err)
+	}
+	if err := writeFileAtomic(filePath, []byte(joinLineEndings(updated, endings)), info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("str_replace call write %s: %w", filePath, err)
+	}
+
+	diff := unifiedDiff(filePath, strings.Split(content, "\n"), strings.Split(updated, "\n"), strReplaceMaxDiffLines)
+	return fmt.Sprintf("Edited %s: %d replacement(s)\n%s", filePath, replaced, diff), nil
+}
+
+func (s StrReplaceTool) Specification() pub_models.Specification {
+	return pub_models.Specification(StrReplace)
+}
+
+func (s StrReplaceTool) WritePaths(input pub_models.Input) []string {
+	return inputPath(input, "file_path")
+}
+
+func parseStrEdits(input pub_models.Input) ([]strEdit, error) {
+	rawEdits, hasEdits := input["edits"]
+	_, hasOld := input["old_string"]
+	if hasEdits && hasOld {
+		return nil, errors.New("pass either old_string/new_string or edits, not both")
+	}
+	if !hasEdits {
+		e, err := parseStrEdit(input)
+		if err != nil {
+			return nil, err
+		}
+		return []strEdit{e}, nil
+	}
+
+	list, ok := rawEdits.([]any)
+	if !ok || len(list) == 0 {
+		return nil, errors.New("edits must be a non-empty array")
+	}
+	ret := make([]strEdit, 0, len(list))
+	for i, raw := range list {
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("edit %d: must be an object", i+1)
+		}
+		e, err := parseStrEdit(m)
+		if err != nil {
+			return nil, fmt.Errorf("edit %d: %w", i+1, err)
+		}
+		ret = append(ret, e)
+	}
+	return ret, nil
+}
+
+func parseStrEdit(m map[string]any) (strEdit, error) {
+	old, ok := m["old_string"].(string)
+	if !ok || old == "" {
+		return strEdit{}, errors.New("old_string must be a non-empty string")
+	}
+	newStr, ok := m["new_string"].(string)
+	if !ok {
+		return strEdit{}, errors.New("new_string must be a string")
+	}
+	e := strEdit{old: normalizeLineEndings(old), new: normalizeLineEndings(newStr)}
+	if v, ok := m["replace_all"]; ok && v != nil {
+		if e.replaceAll, ok = v.(bool); !ok {
+			return strEdit{}, errors.New("replace_all must be a boolean")
+		}
+	}
+	if e.old == e.new {
+		return strEdit{}, errors.New("old_string and new_string are identical")
+	}
+	return e, nil
+}
+
+// applyStrEdits applies edits to the LF content in order, each seeing the
+// result of the previous ones, and keeps endings (see splitLineEndings) in
+// step. It returns the number of replacements made.
+func applyStrEdits(content string, endings []bool, edits []strEdit) (string, []bool, int, error) {
+	replaced := 0
+	for i, e := range edits {
+		n := strings.Count(content, e.old)
+		switch {
+		case n == 0:
+			return "", nil, 0, fmt.Errorf("edit %d: old_string not found%s, nothing was written", i+1, strNearMatchHint(content, e.old))
+		case n > 1 && !e.replaceAll:
+			return "", nil, 0, fmt.Errorf("edit %d: old_string occurs %d times (lines %s), add surrounding context to make it unique or set replace_all, nothing was written",
+				i+1, n, occurrenceLines(content, e.old))
+		}
+		content, endings = replaceKeepingEndings(content, endings, e.old, e.new)
+		replaced += n
+	}
+	return content, endings, replaced, nil
+}
+
+// replaceKeepingEndings replaces every old in content with new. The lines
+// new adds take the ending of the line the replaced text starts on, and
+// every other line keeps its own.
+func replaceKeepingEndings(content string, endings []bool, old, new string) (string, []bool) {
+	var b strings.Builder
+	ret := make([]bool, 0, len(endings))
+	line, pos := 0, 0
+	for {
+		idx := strings.Index(content[pos:], old)
+		if idx < 0 {
+			break
+		}
+		before := content[pos : pos+idx]
+		nl := strings.Count(before, "\n")
+		ret = append(ret, endings[line:line+nl]...)
+		line += nl
+		crlf := false
+		if line < len(endings) {
+			crlf = endings[line]
+		} else if line > 0 {
+			crlf = endings[line-1]
+		}
+		for range strings.Count(new, "\n") {
+			ret = append(ret, crlf)
+		}
+		line += strings.Count(old, "\n")
+		b.WriteString(before)
+		b.WriteString(new)
+		pos += idx + len(old)
+	}
+	b.WriteString(content[pos:])
+	return b.String(), append(ret, endings[line:]...)
+}
+
+// splitLineEndings returns s with LF line endings, and for each of its line
+// endings whether it was CRLF, so files mixing both keep them per line.
+func splitLineEndings(s string) (string, []bool) {
+	lines := strings.Split(s, "\n")
+	endings := make([]bool, len(lines)-1)
+	for i := range endings {
+		if strings.HasSuffix(lines[i], "\r") {
+			lines[i] = strings.TrimSuffix(lines[i], "\r")
+			endings[i] = true
+		}
+	}
+	return strings.Join(lines, "\n"), endings
+}
+
+// joinLineEndings reverts splitLineEndings.
+func joinLineEndings(s string, endings []bool) string {
+	lines := strings.Split(s, "\n")
+	var b strings.Builder
+	for i, l := range lines {
+		b.WriteString(l)
+		if i < len(endings) {
+			if endings[i] {
+				b.WriteByte('\r')
+			}
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// strNearMatchHint points at the line old_string probably meant when it only
+// differs from the file in leading or trailing whitespace.
+func strNearMatchHint(content, old string) string {
+	first := strings.TrimSpace(strings.SplitN(strings.TrimSpace(old), "\n", 2)[0])
+	if first == "" {
+		return ""
+	}
+	for i, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == first {
+			return fmt.Sprintf(" (line %d is %q, check whitespace and indentation)", i+1, line)
+		}
+	}
+	return ""
+}
+
+// occurrenceLines lists the 1-based lines old starts on, up to 10 of them.
+func occurrenceLines(content, old string) string {
+	var lines []string
+	offset := 0
+	for len(lines) < 10 {
+		idx := strings.Index(content[offset:], old)
+		if idx < 0 {
+			break
+		}
+		lines = append(lines, fmt.Sprint(strings.Count(content[:offset+idx], "\n")+1))
+		offset += idx + len(old)
+	}
+	return strings.Join(lines, ", ")
+}
+
+func normalizeLineEndings(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it over
+// path, so a failed write never leaves a half-written file behind. A symlink
+// is written through, to the file it points at.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("chmod temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func TestStrReplaceTool_Call(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		input   pub_models.Input
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "replaces unique snippet",
+			content: "a\nb\nc\n",
+			input:   pub_models.Input{"old_string": "b", "new_string": "B"},
+			want:    "a\nB\nc\n",
+		},
+		{
+			name:    "refuses ambiguous snippet",
+			content: "x\ny\nx\n",
+			input:   pub_models.Input{"old_string": "x", "new_string": "z"},
+			wantErr: "occurs 2 times (lines 1, 3)",
+		},
+		{
+			name:    "replace_all replaces every occurrence",
+			content: "x\ny\nx\n",
+			input:   pub_models.Input{"old_string": "x", "new_string": "z", "replace_all": true},
+			want:    "z\ny\nz\n",
+		},
+		{
+			name:    "missing snippet hints at whitespace drift",
+			content: "func f() {\n\treturn\n}\n",
+			input:   pub_models.Input{"old_string": "    return", "new_string": "return nil"},
+			wantErr: `line 2 is "\treturn"`,
+		},
+		{
+			name:    "keeps crlf line endings",
+			content: "a\r\nb\r\nc\r\n",
+			input:   pub_models.Input{"old_string": "a\nb", "new_string": "a\nB\nb"},
+			want:    "a\r\nB\r\nb\r\nc\r\n",
+		},
+		{
+			name:    "keeps mixed line endings per line",
+			content: "a\r\nb\nc\r\nd\n",
+			input:   pub_models.Input{"old_string": "b\nc", "new_string": "b\nx\nc", "replace_all": true},
+			want:    "a\r\nb\nx\nc\r\nd\n",
+		},
+		{
+			name:    "applies edits in order",
+			content: "one two\n",
+			input: pub_models.Input{"edits": []any{
+				map[string]any{"old_string": "one", "new_string": "1"},
+				map[string]any{"old_string": "1 two", "new_string": "1 2"},
+			}},
+			want: "1 2\n",
+		},
+		{
+			name:    "failing edit writes nothing",
+			content: "one two\n",
+			input: pub_models.Input{"edits": []any{
+				map[string]any{"old_string": "one", "new_string": "1"},
+				map[string]any{"old_string": "three", "new_string": "3"},
+			}},
+			wantErr: "edit 2: old_string not found",
+		},
+		{
+			name:    "refuses identical strings",
+			content: "a\n",
+			input:   pub_models.Input{"old_string": "a", "new_string": "a"},
+			wantErr: "identical",
+		},
+		{
+			name:    "refuses both forms",
+			content: "a\n",
+			input:   pub_models.Input{"old_string": "a", "new_string": "b", "edits": []any{}},
+			wantErr: "not both",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "f.txt")
+			if err := os.WriteFile(path, []byte(tc.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			tc.input["file_path"] = path
+			out, err := StrReplace.Call(tc.input)
+			got, readErr := os.ReadFile(path)
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				if string(got) != tc.content {
+					t.Fatalf("expected file untouched, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+			if !strings.Contains(out, "--- "+path) {
+				t.Fatalf("expected a diff in the output, got %q", out)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0o600 {
+				t.Fatalf("expected mode to be kept, got %v", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestStrReplaceTool_EditsThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	link := filepath.Join(dir, "link.txt")
+	if err := os.WriteFile(target, []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StrReplace.Call(pub_models.Input{"file_path": link, "old_string": "a", "new_string": "b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected the symlink to be kept, got %v, %v", info, err)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "b\n" {
+		t.Fatalf("expected the target to be edited, got %q", got)
+	}
+}
+
+func TestStrReplaceTool_MissingFile(t *testing.T) {
+	_, err := StrReplace.Call(pub_models.Input{"file_path": filepath.Join(t.TempDir(), "nope"), "old_string": "a", "new_string": "b"})
+	if err == nil || !strings.Contains(err.Error(), "use write_file") {
+		t.Fatalf("expected missing file error, got %v", err)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := strings.Split("1\n2\n3\n4\n5\n6\n7\n8\n9\n10", "\n")
+	b := strings.Split("1\nTWO\n3\n4\n5\n6\n7\n8\n9\n10\n11", "\n")
+	want := `--- f
++++ f
+@@ -1,4 +1,4 @@
+ 1
+-2
++TWO
+ 3
+ 4
+@@ -9,2 +9,3 @@
+ 9
+ 10
++11`
+	if got := unifiedDiff("f", a, b, 100); got != want {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+	if got := unifiedDiff("f", a, a, 100); got != "(no changes)" {
+		t.Fatalf("expected no changes, got %q", got)
+	}
+	if got := unifiedDiff("f", a, b, 3); !strings.HasSuffix(got, "... (7 more diff lines)") {
+		t.Fatalf("expected truncation, got:\n%s", got)
+	}
+}
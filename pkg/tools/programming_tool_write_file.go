@@ -82,3 +82,7 @@ func (w WriteFileTool) Call(input pub_models.Input) (string, error) {
 func (w WriteFileTool) Specification() pub_models.Specification {
 	return pub_models.Specification(WriteFile)
 }
+
+func (w WriteFileTool) WritePaths(input pub_models.Input) []string {
+	return inputPath(input, "file_path")
+}
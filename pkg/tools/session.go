@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// Session owns the mutable state of the stateful tools for one agent or CLI
+// run: the async_cmd jobs, the clai_run workers, the fallback command ban
+// list, the http_request policy, the web_search backend and the images loaded
+// by view_image. Tools resolve their session from the call context (see
+// WithSession), so agents running concurrently in one process never observe
+// each other's jobs, and shutting one session down only stops its own
+// processes.
+//
+// Calls without a session in their context use the process default session,
+// which is what the CLI and direct tool calls outside a querier run on.
+type Session struct {
+	asyncCmds *asyncCmdManagerImpl
+
+	runsMu sync.Mutex
+	runs   map[string]*claiProcess
+
+	// banList is the fallback command ban list, used when the call context
+	// carries no policy of its own (WithCmdBanContext). Guarded by banMu so
+	// concurrent runs never observe a torn slice (worklog
+	// 2026-08-02-cmd-ban-list, phase 2, R2-01).
+	banMu   sync.RWMutex
+	banList []string
+
+	// httpPolicy gates http_request.
+	httpMu     sync.RWMutex
+	httpPolicy HTTPPolicy
+
+	// webSearch is the backend of web_search, nil when none is configured.
+	webSearchMu sync.RWMutex
+	webSearch   *WebSearchConfig
+
+	// images are loaded by view_image and wait for TakeImages, which hands
+	// them to the model after the tool results of the batch.
+	imagesMu sync.Mutex
+	images   []pub_models.ImageOrTextInput
+}
+
+type sessionContextKey struct{}
+
+var defaultSession = NewSession()
+
+// NewSession returns an empty tool session.
+func NewSession() *Session {
+	return &Session{
+		asyncCmds: newAsyncCmdManager(),
+		runs:      make(map[string]*claiProcess),
+	}
+}
+
+// DefaultSession returns the process default session used by calls whose
+// context carries no session.
+func DefaultSession() *Session {
+	return defaultSession
+}
+
+// WithSession attaches s to a tool-call context. A nil session leaves ctx
+// unchanged, so the call keeps resolving to the process default session.
+func WithSession(ctx context.Context, s *Session) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionContextKey{}, s)
+}
+
+// sessionFrom returns the session attached to ctx, or the process default.
+func sessionFrom(ctx context.Context) *Session {
+	if s, ok := ctx.Value(sessionContextKey{}).(*Session); ok && s != nil {
+		return s
+	}
+	return defaultSession
+}
+
+// SetCmdBanList replaces the session's fallback command ban list with an
+// immutable snapshot of entries. The caller's slice is copied at the setter
+// boundary, so mutating it afterwards never alters the active list (README
+// "Ban-list ownership", R6-02).
+func (s *Session) SetCmdBanList(entries []string) {
+	s.banMu.Lock()
+	defer s.banMu.Unlock()
+	s.banList = append([]string(nil), entries...)
+}
+
+func (s *Session) cmdBanList() []string {
+	s.banMu.RLock()
+	defer s.banMu.RUnlock()
+	return s.banList
+}
+
+func (s *Session) addImage(part pub_models.ImageOrTextInput) {
+	s.imagesMu.Lock()
+	defer s.imagesMu.Unlock()
+	s.images = append(s.images, part)
+}
+
+// TakeImages returns the images view_image loaded into the session of ctx
+// since the last call, and empties the session of them. Tool results are
+// text only, so the caller sends the images in a message of their own.
+func TakeImages(ctx context.Context) []pub_models.ImageOrTextInput {
+	s := sessionFrom(ctx)
+	s.imagesMu.Lock()
+	defer s.imagesMu.Unlock()
+	ret := s.images
+	s.images = nil
+	return ret
+}
+
+// Shutdown cancels every running async command and interrupts every running
+// clai_run worker owned by this session. Workers which ignore the interrupt
+// are killed after a short grace period. Other sessions are unaffected.
+func (s *Session) Shutdown() {
+	var wg sync.WaitGroup
+	s.asyncCmds.mu.RLock()
+	for cmdID := range s.asyncCmds.cmds {
+		wg.Go(func() {
+			_, _ = s.asyncCmds.Cancel(cmdID)
+		})
+	}
+	s.asyncCmds.mu.RUnlock()
+
+	s.runsMu.Lock()
+	running := make([]*claiProcess, 0, len(s.runs))
+	for _, p := range s.runs {
+		if !p.done && p.cmd != nil && p.cmd.Process != nil {
+			_ = p.cmd.Process.Signal(os.Interrupt)
+			running = append(running, p)
+		}
+	}
+	s.runsMu.Unlock()
+	if len(running) > 0 {
+		wg.Go(func() {
+			deadline := time.Now().Add(asyncCancelGrace)
+			for time.Now().Before(deadline) && !s.runsDone(running) {
+				time.Sleep(25 * time.Millisecond)
+			}
+			s.runsMu.Lock()
+			defer s.runsMu.Unlock()
+			for _, p := range running {
+				if !p.done {
+					_ = p.cmd.Process.Kill()
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func (s *Session) runsDone(procs []*claiProcess) bool {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+	for _, p := range procs {
+		if !p.done {
+			return false
+		}
+	}
+	return true
+}
+
+// reset empties the session in place. Used by the test reset helpers on the
+// default session, whose pointer may already be held by a querier.
+func (s *Session) reset() {
+	s.asyncCmds = newAsyncCmdManager()
+	s.runsMu.Lock()
+	s.runs = make(map[string]*claiProcess)
+	s.runsMu.Unlock()
+	s.imagesMu.Lock()
+	s.images = nil
+	s.imagesMu.Unlock()
+}
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func spawnInSession(t *testing.T, ctx context.Context, command string, args ...any) string {
+	t.Helper()
+	out, err := AsyncCmdRun.CallWithContext(ctx, pub_models.Input{"command": command, "args": args})
+	if err != nil {
+		t.Fatalf("spawn: %v", err)
+	}
+	var spawned struct {
+		CmdID string `json:"async_cmd_id"`
+	}
+	if err := json.Unmarshal([]byte(out), &spawned); err != nil {
+		t.Fatalf("unmarshal spawn: %v", err)
+	}
+	return spawned.CmdID
+}
+
+func TestSession_AsyncCmdsAreIsolated(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires POSIX process semantics")
+	}
+	ResetAsyncCmdManagerForTests()
+	a, b := NewSession(), NewSession()
+	ctxA := WithSession(t.Context(), a)
+	ctxB := WithSession(t.Context(), b)
+
+	idA := spawnInSession(t, ctxA, "sleep", "5")
+	idB := spawnInSession(t, ctxB, "sleep", "5")
+	t.Cleanup(a.Shutdown)
+	t.Cleanup(b.Shutdown)
+
+	if _, err := AsyncCmdStatus.CallWithContext(ctxB, pub_models.Input{"async_cmd_id": idA}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected session B not to see session A's command, got %v", err)
+	}
+	if _, err := AsyncCmdAwait.CallWithContext(ctxA, pub_models.Input{"async_cmd_ids": []any{idB}, "timeout_seconds": 0.1}); err == nil {
+		t.Fatal("expected session A not to await session B's command")
+	}
+	if got := AsyncCmdSnapshotForTests(); len(got) != 0 {
+		t.Fatalf("expected the default session to stay empty, got %+v", got)
+	}
+
+	started := time.Now()
+	a.Shutdown()
+	if elapsed := time.Since(started); elapsed > 3*time.Second {
+		t.Fatalf("expected Shutdown to stop the command promptly, took %v", elapsed)
+	}
+	if got := a.AsyncCmdSnapshotForTests()[idA].Status; got != asyncStatusCancelled {
+		t.Fatalf("expected session A's command to be cancelled, got %q", got)
+	}
+	if got := b.AsyncCmdSnapshotForTests()[idB].Status; got != asyncStatusRunning {
+		t.Fatalf("expected session B's command to keep running, got %q", got)
+	}
+}
+
+func TestSession_ClaiRunsAreIsolated(t *testing.T) {
+	a, b := NewSession(), NewSession()
+	a.runs["worker"] = &claiProcess{done: true}
+
+	ctxA := WithSession(context.Background(), a)
+	ctxB := WithSession(context.Background(), b)
+	if out, err := ClaiCheck.CallWithContext(ctxA, pub_models.Input{"run_id": "worker"}); err != nil || out != "COMPLETED" {
+		t.Fatalf("expected session A to see its worker, got out=%q err=%v", out, err)
+	}
+	if _, err := ClaiCheck.CallWithContext(ctxB, pub_models.Input{"run_id": "worker"}); err == nil {
+		t.Fatal("expected session B not to see session A's worker")
+	}
+	out, err := ClaiWaitForWorkers.CallWithContext(ctxB, pub_models.Input{"timeout_seconds": 1})
+	if err != nil || !strings.Contains(out, "No active workers") {
+		t.Fatalf("expected no workers in session B, got out=%q err=%v", out, err)
+	}
+}
+
+func TestSession_ClaiResultDoesNotBlockRunLookups(t *testing.T) {
+	s := NewSession()
+	slow := &claiProcess{stdout: bytes.NewBufferString("partial"), stderr: &bytes.Buffer{}}
+	s.runs["slow"] = slow
+	s.runs["other"] = &claiProcess{done: true}
+	ctx := WithSession(context.Background(), s)
+
+	// A worker writing its output holds outMu; clai_result waits for it.
+	slow.outMu.Lock()
+	result := make(chan string)
+	go func() {
+		out, _ := ClaiResult.CallWithContext(ctx, pub_models.Input{"run_id": "slow"})
+		result <- out
+	}()
+
+	checked := make(chan string)
+	go func() {
+		out, _ := ClaiCheck.CallWithContext(ctx, pub_models.Input{"run_id": "other"})
+		checked <- out
+	}()
+	select {
+	case out := <-checked:
+		if out != "COMPLETED" {
+			t.Fatalf("unexpected status %q", out)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("clai_check was blocked by a pending clai_result")
+	}
+
+	slow.outMu.Unlock()
+	if out := <-result; !strings.Contains(out, "partial") {
+		t.Fatalf("expected the output written so far, got %q", out)
+	}
+}
+
+func TestSession_CmdBanListFallback(t *testing.T) {
+	t.Cleanup(ResetCmdBanListForTests)
+	s := NewSession()
+	s.SetCmdBanList([]string{"rm"})
+	SetCmdBanList([]string{"touch"})
+
+	ctx := WithSession(context.Background(), s)
+	if err := validateCmdNotBannedWithContext(ctx, "rm -rf x", nil); err == nil {
+		t.Fatal("expected the session list to ban rm")
+	}
+	if err := validateCmdNotBannedWithContext(ctx, "touch x", nil); err != nil {
+		t.Fatalf("expected the default session list not to leak into the session, got %v", err)
+	}
+	if err := validateCmdNotBannedWithContext(WithCmdBanContext(ctx, nil), "rm -rf x", nil); err != nil {
+		t.Fatalf("expected the context policy to take precedence, got %v", err)
+	}
+	if err := validateCmdNotBanned("touch x", nil); err == nil {
+		t.Fatal("expected calls without a session to use the default session list")
+	}
+}
@@ -0,0 +1,335 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebSearchConfig selects and configures the backend of the web_search tool.
+// The CLI reads it from webSearch.json in the config directory.
+//
+// The built-in backends are "searxng", "brave", "tavily" and "kagi". For
+// them URL is the API root, needed for searxng and defaulting to the public
+// API for the others. "custom" queries any JSON endpoint described by the
+// remaining fields.
+type WebSearchConfig struct {
+	Backend string `json:"backend"`
+	// URL is the API root of a built-in backend. For custom it is the full
+	// request URL, in which {query} is replaced by the escaped query and
+	// {count} by the number of results.
+	URL string `json:"url,omitempty"`
+	// APIKeyEnv names the environment variable holding the API key. It
+	// defaults to BRAVE_API_KEY, TAVILY_API_KEY and KAGI_API_KEY, and is
+	// available as {api_key} in Headers and Body.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	// MaxResults is the number of results when the model doesn't ask for a
+	// count. Zero uses DefaultWebSearchResults.
+	MaxResults int `json:"max_results,omitempty"`
+	// Headers are added to the request, after the ones of the backend.
+	// Values are expanded from the environment, e.g. "${SEARCH_TOKEN}".
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Method, Body, ResultsPath and Fields describe a custom endpoint. Body
+	// is sent with Method, POST by default when there is a body, and takes
+	// {query} as a JSON string. ResultsPath is the dot separated path to the
+	// result array in the response, e.g. "data.items".
+	Method      string          `json:"method,omitempty"`
+	Body        string          `json:"body,omitempty"`
+	ResultsPath string          `json:"results_path,omitempty"`
+	Fields      WebSearchFields `json:"fields,omitzero"`
+}
+
+// WebSearchFields are the dot separated paths of a result's title, URL and
+// snippet, relative to the result.
+type WebSearchFields struct {
+	Title   string `json:"title,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// WebSearchResult is one ranked result of a web search.
+type WebSearchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// DefaultWebSearchResults is the result count of WebSearchConfig.
+const DefaultWebSearchResults = 8
+
+const maxWebSearchResults = 20
+
+// webSearchBackend is a built-in backend, expressed as the custom endpoint it
+// amounts to.
+type webSearchBackend struct {
+	baseURL   string
+	path      string
+	apiKeyEnv string
+	endpoint  WebSearchConfig
+}
+
+var webSearchBackends = map[string]webSearchBackend{
+	"searxng": {
+		path: "/search?q={query}&format=json",
+		endpoint: WebSearchConfig{
+			ResultsPath: "results",
+			Fields:      WebSearchFields{Title: "title", URL: "url", Snippet: "content"},
+		},
+	},
+	"brave": {
+		baseURL:   "https://api.search.brave.com",
+		path:      "/res/v1/web/search?q={query}&count={count}",
+		apiKeyEnv: "BRAVE_API_KEY",
+		endpoint: WebSearchConfig{
+			Headers:     map[string]string{"X-Subscription-Token": "{api_key}", "Accept": "application/json"},
+			ResultsPath: "web.results",
+			Fields:      WebSearchFields{Title: "title", URL: "url", Snippet: "description"},
+		},
+	},
+	"tavily": {
+		baseURL:   "https://api.tavily.com",
+		path:      "/search",
+		apiKeyEnv: "TAVILY_API_KEY",
+		endpoint: WebSearchConfig{
+			Method:      http.MethodPost,
+			Body:        `{"query": {query}, "max_results": {count}}`,
+			Headers:     map[string]string{"Authorization": "Bearer {api_key}", "Content-Type": "application/json"},
+			ResultsPath: "results",
+			Fields:      WebSearchFields{Title: "title", URL: "url", Snippet: "content"},
+		},
+	},
+	"kagi": {
+		baseURL:   "https://kagi.com",
+		path:      "/api/v0/search?q={query}&limit={count}",
+		apiKeyEnv: "KAGI_API_KEY",
+		endpoint: WebSearchConfig{
+			Headers: map[string]string{"Authorization": "Bot {api_key}"},
+			// Related searches share the array but have no url, and are
+			// skipped with every other result missing one.
+			ResultsPath: "data",
+			Fields:      WebSearchFields{Title: "title", URL: "url", Snippet: "snippet"},
+		},
+	},
+}
+
+// SetWebSearch replaces the session's web_search backend with a copy of c.
+// Nil makes web_search fail with a hint at webSearch.json.
+func (s *Session) SetWebSearch(c *WebSearchConfig) {
+	s.webSearchMu.Lock()
+	defer s.webSearchMu.Unlock()
+	if c == nil {
+		s.webSearch = nil
+		return
+	}
+	cp := c.clone()
+	s.webSearch = &cp
+}
+
+func (c WebSearchConfig) clone() WebSearchConfig {
+	c.Headers = maps.Clone(c.Headers)
+	return c
+}
+
+func (s *Session) currentWebSearch() *WebSearchConfig {
+	s.webSearchMu.RLock()
+	defer s.webSearchMu.RUnlock()
+	return s.webSearch
+}
+
+// Validate reports configuration errors which make every search fail.
+func (c WebSearchConfig) Validate() error {
+	if c.Backend == "custom" {
+		switch {
+		case c.URL == "":
+			return fmt.Errorf("backend custom: missing url")
+		case !strings.Contains(c.URL, "{query}") && !strings.Contains(c.Body, "{query}"):
+			return fmt.Errorf("backend custom: neither url nor body contains {query}")
+		case c.Fields.URL == "":
+			return fmt.Errorf("backend custom: missing fields.url")
+		}
+		return nil
+	}
+	b, ok := webSearchBackends[c.Backend]
+	if !ok {
+		return fmt.Errorf("unknown backend %q, expected searxng, brave, tavily, kagi or custom", c.Backend)
+	}
+	if b.baseURL == "" && c.URL == "" {
+		return fmt.Errorf("backend %v: missing url, the root of the instance", c.Backend)
+	}
+	return nil
+}
+
+// resolve returns the custom endpoint the configuration amounts to, with the
+// API key looked up.
+func (c WebSearchConfig) resolve() (WebSearchConfig, string, error) {
+	if err := c.Validate(); err != nil {
+		return WebSearchConfig{}, "", err
+	}
+	end := c
+	keyEnv := c.APIKeyEnv
+	if b, ok := webSearchBackends[c.Backend]; ok {
+		end = b.endpoint
+		base := c.URL
+		if base == "" {
+			base = b.baseURL
+		}
+		end.URL = strings.TrimSuffix(base, "/") + b.path
+		end.Headers = make(map[string]string, len(b.endpoint.Headers)+len(c.Headers))
+		for k, v := range b.endpoint.Headers {
+			end.Headers[k] = v
+		}
+		for k, v := range c.Headers {
+			end.Headers[k] = v
+		}
+		if keyEnv == "" {
+			keyEnv = b.apiKeyEnv
+		}
+	}
+	if keyEnv == "" {
+		return end, "", nil
+	}
+	key := os.Getenv(keyEnv)
+	if key == "" {
+		return WebSearchConfig{}, "", fmt.Errorf("backend %v: the API key environment variable %v is not set", c.Backend, keyEnv)
+	}
+	return end, key, nil
+}
+
+// search runs query against the configured backend and returns up to count
+// results, ranked as the backend returned them.
+func (c WebSearchConfig) search(ctx context.Context, query string, count int) ([]WebSearchResult, error) {
+	end, apiKey, err := c.resolve()
+	if err != nil {
+		return nil, err
+	}
+	req, err := end.newRequest(ctx, query, count, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := webSearchHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("search request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read search response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("bad status: %s: %s", resp.Status, strings.TrimSpace(string(body[:min(len(body), 500)])))
+	}
+
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("decode search response: %w", err)
+	}
+	items, ok := jsonPath(doc, end.ResultsPath).([]any)
+	if !ok {
+		return nil, fmt.Errorf("search response has no result array at %q", end.ResultsPath)
+	}
+	results := make([]WebSearchResult, 0, min(len(items), count))
+	for _, item := range items {
+		if len(results) == count {
+			break
+		}
+		u := jsonString(item, end.Fields.URL)
+		if u == "" {
+			continue
+		}
+		results = append(results, WebSearchResult{
+			Title:   snippetText(jsonString(item, end.Fields.Title)),
+			URL:     u,
+			Snippet: snippetText(jsonString(item, end.Fields.Snippet)),
+		})
+	}
+	return results, nil
+}
+
+// newRequest builds the request of a resolved endpoint, filling in its
+// placeholders.
+func (c WebSearchConfig) newRequest(ctx context.Context, query string, count int, apiKey string) (*http.Request, error) {
+	urlStr := strings.NewReplacer("{query}", url.QueryEscape(query), "{count}", strconv.Itoa(count)).Replace(c.URL)
+	u, err := url.ParseRequestURI(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search url %q: %w", urlStr, err)
+	}
+	method := c.Method
+	var body io.Reader
+	if c.Body != "" {
+		quoted, err := json.Marshal(query)
+		if err != nil {
+			return nil, fmt.Errorf("encode query: %w", err)
+		}
+		body = strings.NewReader(strings.NewReplacer(
+			"{query}", string(quoted), "{count}", strconv.Itoa(count), "{api_key}", apiKey,
+		).Replace(c.Body))
+		if method == "" {
+			method = http.MethodPost
+		}
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("build search request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "clai web_search")
+	for k, v := range c.Headers {
+		req.Header.Set(k, os.ExpandEnv(strings.ReplaceAll(v, "{api_key}", apiKey)))
+	}
+	return req, nil
+}
+
+// jsonPath follows the dot separated keys of path into doc. An empty path is
+// doc itself.
+func jsonPath(doc any, path string) any {
+	if path == "" {
+		return doc
+	}
+	for key := range strings.SplitSeq(path, ".") {
+		m, ok := doc.(map[string]any)
+		if !ok {
+			return nil
+		}
+		doc = m[key]
+	}
+	return doc
+}
+
+func jsonString(doc any, path string) string {
+	if path == "" {
+		return ""
+	}
+	switch v := jsonPath(doc, path).(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// snippetText strips the markup search engines put in titles and snippets,
+// such as <strong> around the matched words, and joins the lines.
+func snippetText(s string) string {
+	if strings.ContainsAny(s, "<&") {
+		if text, err := HTMLText(strings.NewReader(s)); err == nil {
+			s = text
+		}
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var webSearchHTTPClient httpDoer = &http.Client{Timeout: 30 * time.Second}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// searchStandIn serves resp as JSON and records the request it got.
+func searchStandIn(t *testing.T, resp string, got *http.Request, gotBody *string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*got = *r.Clone(r.Context())
+		b, _ := io.ReadAll(r.Body)
+		*gotBody = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebSearchConfig_search(t *testing.T) {
+	t.Setenv("BRAVE_API_KEY", "brave-key")
+	t.Setenv("TAVILY_API_KEY", "tavily-key")
+	t.Setenv("KAGI_API_KEY", "kagi-key")
+
+	tests := []struct {
+		name     string
+		conf     WebSearchConfig
+		resp     string
+		wantPath string
+		check    func(t *testing.T, r *http.Request, body string)
+	}{
+		{
+			name:     "searxng",
+			conf:     WebSearchConfig{Backend: "searxng"},
+			resp:     `{"results":[{"title":"Go <b>generics</b>","url":"https://go.dev/doc/tutorial/generics","content":"Tutorial:  Getting started\nwith generics"}]}`,
+			wantPath: "/search?q=go+generics&format=json",
+		},
+		{
+			name:     "brave",
+			conf:     WebSearchConfig{Backend: "brave"},
+			resp:     `{"web":{"results":[{"title":"Go generics","url":"https://go.dev/doc/tutorial/generics","description":"Tutorial: Getting started with generics"}]}}`,
+			wantPath: "/res/v1/web/search?q=go+generics&count=3",
+			check: func(t *testing.T, r *http.Request, _ string) {
+				if got := r.Header.Get("X-Subscription-Token"); got != "brave-key" {
+					t.Fatalf("expected the brave key, got %q", got)
+				}
+			},
+		},
+		{
+			name:     "tavily",
+			conf:     WebSearchConfig{Backend: "tavily"},
+			resp:     `{"results":[{"title":"Go generics","url":"https://go.dev/doc/tutorial/generics","content":"Tutorial: Getting started with generics"}]}`,
+			wantPath: "/search",
+			check: func(t *testing.T, r *http.Request, body string) {
+				var in struct {
+					Query      string `json:"query"`
+					MaxResults int    `json:"max_results"`
+				}
+				if err := json.Unmarshal([]byte(body), &in); err != nil || in.Query != "go generics" || in.MaxResults != 3 {
+					t.Fatalf("unexpected body %q: %v", body, err)
+				}
+				if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer tavily-key" {
+					t.Fatalf("unexpected request %v %q", r.Method, r.Header.Get("Authorization"))
+				}
+			},
+		},
+		{
+			name:     "kagi skips related searches",
+			conf:     WebSearchConfig{Backend: "kagi"},
+			resp:     `{"data":[{"t":1,"list":["go generics tutorial"]},{"t":0,"title":"Go generics","url":"https://go.dev/doc/tutorial/generics","snippet":"Tutorial: Getting started with generics"}]}`,
+			wantPath: "/api/v0/search?q=go+generics&limit=3",
+			check: func(t *testing.T, r *http.Request, _ string) {
+				if got := r.Header.Get("Authorization"); got != "Bot kagi-key" {
+					t.Fatalf("expected the kagi key, got %q", got)
+				}
+			},
+		},
+		{
+			name: "custom",
+			conf: WebSearchConfig{
+				Backend:     "custom",
+				ResultsPath: "data.items",
+				Fields:      WebSearchFields{Title: "name", URL: "link.href", Snippet: "summary"},
+			},
+			resp:     `{"data":{"items":[{"name":"Go generics","link":{"href":"https://go.dev/doc/tutorial/generics"},"summary":"Tutorial: Getting started with generics"}]}}`,
+			wantPath: "/find?term=go+generics&n=3",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got http.Request
+			var body string
+			srv := searchStandIn(t, tc.resp, &got, &body)
+			tc.conf.URL = srv.URL
+			if tc.conf.Backend == "custom" {
+				tc.conf.URL = srv.URL + "/find?term={query}&n={count}"
+			}
+
+			results, err := tc.conf.search(t.Context(), "go generics", 3)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if got.URL.RequestURI() != tc.wantPath {
+				t.Fatalf("expected request to %q, got %q", tc.wantPath, got.URL.RequestURI())
+			}
+			want := WebSearchResult{Title: "Go generics", URL: "https://go.dev/doc/tutorial/generics", Snippet: "Tutorial: Getting started with generics"}
+			if len(results) != 1 || results[0] != want {
+				t.Fatalf("expected %+v, got %+v", want, results)
+			}
+			if tc.check != nil {
+				tc.check(t, &got, body)
+			}
+		})
+	}
+}
+
+func TestWebSearchConfig_Validate(t *testing.T) {
+	tests := []struct {
+		conf WebSearchConfig
+		want string
+	}{
+		{WebSearchConfig{Backend: "bing"}, "unknown backend"},
+		{WebSearchConfig{Backend: "searxng"}, "missing url"},
+		{WebSearchConfig{Backend: "custom", URL: "https://example.com/search"}, "{query}"},
+		{WebSearchConfig{Backend: "custom", URL: "https://example.com/search?q={query}"}, "fields.url"},
+		{WebSearchConfig{Backend: "brave"}, ""},
+	}
+	for _, tc := range tests {
+		err := tc.conf.Validate()
+		if tc.want == "" && err != nil || tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Errorf("Validate(%+v) = %v, want an error containing %q", tc.conf, err, tc.want)
+		}
+	}
+}
+
+func TestWebSearchConfig_searchMissingAPIKey(t *testing.T) {
+	t.Setenv("BRAVE_API_KEY", "")
+	_, err := WebSearchConfig{Backend: "brave"}.search(t.Context(), "go", 3)
+	if err == nil || !strings.Contains(err.Error(), "BRAVE_API_KEY") {
+		t.Fatalf("expected an error naming the variable, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+type HTTPRequestTool pub_models.Specification
+
+var HTTPRequest = HTTPRequestTool{
+	Name: "http_request",
+	Description: "Send an HTTP request to an allowed host, e.g. a REST API, and get the status, headers and body of the response. " +
+		"JSON responses are pretty-printed. Credentials are added by clai, never put them in headers yourself. " +
+		"Use website_text instead to read web pages.",
+	Inputs: &pub_models.InputSchema{
+		Type: "object",
+		Properties: map[string]pub_models.ParameterObject{
+			"url": {
+				Type:        "string",
+				Description: "The http(s) URL to request, including the query string.",
+			},
+			"method": {
+				Type:        "string",
+				Description: "The HTTP method. Defaults to GET.",
+				Enum:        &httpRequestMethods,
+			},
+			"headers": {
+				Type:        "object",
+				Description: "Optional request headers, header name to value.",
+			},
+			"json": {
+				Type:        "object",
+				Description: "Optional JSON body. Sets Content-Type to application/json unless headers set it.",
+			},
+			"body": {
+				Type:        "string",
+				Description: "Optional raw body, for bodies which aren't JSON. Mutually exclusive with json.",
+			},
+		},
+		Required: []string{"url"},
+	},
+}
+
+var httpRequestMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// httpRequestTransport is replaced in tests. Nil uses http.DefaultTransport.
+var httpRequestTransport http.RoundTripper
+
+const httpRequestMaxRedirects = 5
+
+func (h HTTPRequestTool) Call(input pub_models.Input) (string, error) {
+	return h.CallWithContext(context.Background(), input)
+}
+
+// CallWithContext sends the request under the policy of ctx (see
+// HTTPPolicy). Responses of any status are returned as output, as the body
+// of an error response is what explains it.
+func (h HTTPRequestTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
+	req, err := newHTTPRequest(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	policy := httpPolicyFrom(ctx)
+	if err := policy.check(req.URL.Hostname()); err != nil {
+		return "", err
+	}
+	secrets, err := policy.setSecretHeaders(req.Header, req.URL.Hostname())
+	if err != nil {
+		return "", err
+	}
+
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: httpRequestTransport,
+		CheckRedirect: func(r *http.Request, via []*http.Request) error {
+			if len(via) >= httpRequestMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", httpRequestMaxRedirects)
+			}
+			if err := policy.check(r.URL.Hostname()); err != nil {
+				return fmt.Errorf("redirect to %v: %w", r.URL, err)
+			}
+			// Once on https, secrets are not sent in plain text: an http
+			// redirect gets none.
+			if r.URL.Scheme != "https" && slices.ContainsFunc(via, func(v *http.Request) bool { return v.URL.Scheme == "https" }) {
+				policy.dropSecretHeaders(r.Header)
+				return nil
+			}
+			s, err := policy.setSecretHeaders(r.Header, r.URL.Hostname())
+			secrets = append(secrets, s...)
+			return err
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", redact(fmt.Errorf("request failed: %w", err), secrets)
+	}
+	defer resp.Body.Close()
+
+	limit := policy.maxResponseBytes()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read body: %w", err)
+	}
+	truncated := len(body) > limit
+	if truncated {
+		body = body[:limit]
+	}
+	return redactString(formatHTTPResponse(resp, body, truncated), secrets), nil
+}
+
+func newHTTPRequest(ctx context.Context, input pub_models.Input) (*http.Request, error) {
+	rawURL, ok := input["url"].(string)
+	if !ok {
+		return nil, fmt.Errorf("url must be a string")
+	}
+	u, err := url.ParseRequestURI(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q, want http or https", u.Scheme)
+	}
+	method := http.MethodGet
+	if m, ok := input["method"].(string); ok && m != "" {
+		method = strings.ToUpper(m)
+	}
+	if !slices.Contains(httpRequestMethods, method) {
+		return nil, fmt.Errorf("unsupported method %q", method)
+	}
+
+	var body io.Reader
+	contentType := ""
+	jsonBody, hasJSON := input["json"]
+	rawBody, hasBody := input["body"].(string)
+	switch {
+	case hasJSON && jsonBody != nil && hasBody:
+		return nil, fmt.Errorf("json and body are mutually exclusive")
+	case hasJSON && jsonBody != nil:
+		b, err := json.Marshal(jsonBody)
+		if err != nil {
+			return nil, fmt.Errorf("encode json body: %w", err)
+		}
+		body = bytes.NewReader(b)
+		contentType = "application/json"
+	case hasBody:
+		body = strings.NewReader(rawBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("User-Agent", "clai http_request")
+	if raw, ok := input["headers"]; ok && raw != nil {
+		headers, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("headers must be an object of header name to value")
+		}
+		for k, v := range headers {
+			req.Header.Set(k, fmt.Sprint(v))
+		}
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+// formatHTTPResponse prints the status line, the headers sorted by name and
+// the body: pretty-printed when it is complete JSON, summarised when it
+// isn't text.
+func formatHTTPResponse(resp *http.Response, body []byte, truncated bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v %v\n", resp.Proto, resp.Status)
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, v := range resp.Header[name] {
+			if name == "Set-Cookie" {
+				v = "[REDACTED]"
+			}
+			fmt.Fprintf(&b, "%v: %v\n", name, v)
+		}
+	}
+	if len(body) == 0 {
+		return b.String()
+	}
+	b.WriteByte('\n')
+
+	ctype := resp.Header.Get("Content-Type")
+	var pretty bytes.Buffer
+	switch {
+	case !truncated && strings.Contains(ctype, "json") && json.Indent(&pretty, body, "", "  ") == nil:
+		b.Write(pretty.Bytes())
+		b.WriteByte('\n')
+	case !isTextBody(ctype, body):
+		fmt.Fprintf(&b, "<%d bytes of %v, not shown>\n", len(body), http.DetectContentType(body))
+		return b.String()
+	default:
+		b.Write(body)
+		if !bytes.HasSuffix(body, []byte("\n")) {
+			b.WriteByte('\n')
+		}
+	}
+	if truncated {
+		fmt.Fprintf(&b, "[truncated after %d bytes]\n", len(body))
+	}
+	return b.String()
+}
+
+func isTextBody(ctype string, body []byte) bool {
+	for _, t := range []string{"text/", "json", "xml", "javascript", "x-www-form-urlencoded", "yaml"} {
+		if strings.Contains(ctype, t) {
+			return true
+		}
+	}
+	return strings.HasPrefix(http.DetectContentType(body), "text/")
+}
+
+func redactString(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "[REDACTED]")
+		}
+	}
+	return s
+}
+
+func redact(err error, secrets []string) error {
+	if msg := redactString(err.Error(), secrets); msg != err.Error() {
+		return errors.New(msg)
+	}
+	return err
+}
+
+func (h HTTPRequestTool) Specification() pub_models.Specification {
+	return pub_models.Specification(HTTPRequest)
+}
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+func localPolicyContext(t *testing.T, p HTTPPolicy) context.Context {
+	t.Helper()
+	p.Allow = append(p.Allow, "127.0.0.1")
+	s := NewSession()
+	s.SetHTTPPolicy(&p)
+	return WithSession(t.Context(), s)
+}
+
+func TestHTTPRequest_JSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %v %v", r.Method, r.Header.Get("Content-Type"))
+		}
+		var in map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"id":7,"title":"`+in["title"].(string)+`"}`)
+	}))
+	defer srv.Close()
+
+	out, err := HTTPRequest.CallWithContext(localPolicyContext(t, HTTPPolicy{}), pub_models.Input{
+		"url":    srv.URL + "/issues",
+		"method": "post",
+		"json":   map[string]any{"title": "bug"},
+	})
+	if err != nil {
+		t.Fatalf("http_request: %v", err)
+	}
+	for _, want := range []string{"HTTP/1.1 201 Created\n", "Set-Cookie: [REDACTED]\n", "{\n  \"id\": 7,\n  \"title\": \"bug\"\n}"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%v", want, out)
+		}
+	}
+}
+
+func TestHTTPRequest_ErrorStatusIsOutput(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such issue", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	out, err := HTTPRequest.CallWithContext(localPolicyContext(t, HTTPPolicy{}), pub_models.Input{"url": srv.URL})
+	if err != nil {
+		t.Fatalf("http_request: %v", err)
+	}
+	if !strings.Contains(out, "404 Not Found") || !strings.Contains(out, "no such issue") {
+		t.Fatalf("expected the status and body, got:\n%v", out)
+	}
+}
+
+func TestHTTPRequest_SecretHeadersAreRedacted(t *testing.T) {
+	t.Setenv("CLAI_TEST_TOKEN", "s3cr3t-token")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A server echoing the credential must not leak it to the model.
+		_, _ = io.WriteString(w, "you sent "+r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	ctx := localPolicyContext(t, HTTPPolicy{SecretHeaders: []SecretHeader{
+		{Host: "127.0.0.1", Header: "Authorization", Value: "Bearer ${CLAI_TEST_TOKEN}"},
+	}})
+	out, err := HTTPRequest.CallWithContext(ctx, pub_models.Input{"url": srv.URL})
+	if err != nil {
+		t.Fatalf("http_request: %v", err)
+	}
+	if strings.Contains(out, "s3cr3t") || !strings.Contains(out, "you sent [REDACTED]") {
+		t.Fatalf("expected the secret to be sent and redacted, got:\n%v", out)
+	}
+}
+
+func TestHTTPRequest_DowngradeRedirectDropsSecretHeaders(t *testing.T) {
+	t.Setenv("CLAI_TEST_TOKEN", "s3cr3t-token")
+	var plainAuth string
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		plainAuth = r.Header.Get("Authorization")
+		_, _ = io.WriteString(w, "plain")
+	}))
+	defer plain.Close()
+	var tlsAuth string
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tlsAuth = r.Header.Get("Authorization")
+		http.Redirect(w, r, plain.URL, http.StatusFound)
+	}))
+	defer secure.Close()
+	httpRequestTransport = secure.Client().Transport
+	t.Cleanup(func() { httpRequestTransport = nil })
+
+	ctx := localPolicyContext(t, HTTPPolicy{SecretHeaders: []SecretHeader{
+		{Host: "127.0.0.1", Header: "Authorization", Value: "Bearer ${CLAI_TEST_TOKEN}"},
+	}})
+	out, err := HTTPRequest.CallWithContext(ctx, pub_models.Input{"url": secure.URL})
+	if err != nil {
+		t.Fatalf("http_request: %v", err)
+	}
+	if !strings.Contains(out, "plain") {
+		t.Fatalf("expected the redirect followed, got:\n%v", out)
+	}
+	if tlsAuth != "Bearer s3cr3t-token" {
+		t.Fatalf("expected the secret sent over https, got %q", tlsAuth)
+	}
+	if plainAuth != "" {
+		t.Fatalf("expected no secret after the downgrade, got %q", plainAuth)
+	}
+}
+
+func TestHTTPRequest_Refusals(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://localhost/", http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		input pub_models.Input
+		want  string
+	}{
+		{"no policy", t.Context(), pub_models.Input{"url": srv.URL}, "disabled"},
+		{"host not allowed", localPolicyContext(t, HTTPPolicy{}), pub_models.Input{"url": "http://localhost/"}, "not allowed"},
+		{"redirect to a host not allowed", localPolicyContext(t, HTTPPolicy{}), pub_models.Input{"url": redirect.URL}, "redirect to http://localhost/"},
+		{"scheme", localPolicyContext(t, HTTPPolicy{}), pub_models.Input{"url": "file:///etc/passwd"}, "unsupported scheme"},
+		{"method", localPolicyContext(t, HTTPPolicy{}), pub_models.Input{"url": srv.URL, "method": "TRACE"}, "unsupported method"},
+		{"json and body", localPolicyContext(t, HTTPPolicy{}), pub_models.Input{"url": srv.URL, "json": map[string]any{}, "body": "x"}, "mutually exclusive"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := HTTPRequest.CallWithContext(tc.ctx, tc.input)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+	if hits != 0 {
+		t.Fatalf("refused requests must not reach the server, got %d hits", hits)
+	}
+}
+
+func TestHTTPRequest_Truncates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, strings.Repeat("a", 100))
+	}))
+	defer srv.Close()
+
+	out, err := HTTPRequest.CallWithContext(localPolicyContext(t, HTTPPolicy{MaxResponseBytes: 10}), pub_models.Input{"url": srv.URL})
+	if err != nil {
+		t.Fatalf("http_request: %v", err)
+	}
+	if !strings.Contains(out, "\n\naaaaaaaaaa\n[truncated after 10 bytes]\n") {
+		t.Fatalf("expected the body truncated to 10 bytes, got:\n%v", out)
+	}
+}
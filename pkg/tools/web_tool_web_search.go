@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+type WebSearchTool pub_models.Specification
+
+var WebSearch = WebSearchTool{
+	Name: "web_search",
+	Description: "Search the web and get ranked results with title, URL and snippet. " +
+		"Use it to find pages instead of guessing URLs, then read the relevant ones with website_text.",
+	Inputs: &pub_models.InputSchema{
+		Type: "object",
+		Properties: map[string]pub_models.ParameterObject{
+			"query": {
+				Type:        "string",
+				Description: "The search query, as typed into a search engine.",
+			},
+			"count": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Optional number of results, at most %d.", maxWebSearchResults),
+			},
+		},
+		Required: []string{"query"},
+	},
+}
+
+func (w WebSearchTool) Call(input pub_models.Input) (string, error) {
+	return w.CallWithContext(context.Background(), input)
+}
+
+func (w WebSearchTool) CallWithContext(ctx context.Context, input pub_models.Input) (string, error) {
+	query, ok := input["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query must be a non-empty string")
+	}
+	conf := sessionFrom(ctx).currentWebSearch()
+	if conf == nil {
+		return "", fmt.Errorf("web_search has no backend: configure one in webSearch.json of the clai config directory, e.g. {\"backend\": \"searxng\", \"url\": \"http://localhost:8888\"}")
+	}
+	count := conf.MaxResults
+	if count <= 0 {
+		count = DefaultWebSearchResults
+	}
+	if c, ok := input["count"].(float64); ok && c > 0 {
+		count = int(c)
+	}
+	count = min(count, maxWebSearchResults)
+
+	results, err := conf.search(ctx, query, count)
+	if err != nil {
+		return "", fmt.Errorf("%v search: %w", conf.Backend, err)
+	}
+	if len(results) == 0 {
+		return fmt.Sprintf("No results for %q.", query), nil
+	}
+	var b strings.Builder
+	for i, r := range results {
+		fmt.Fprintf(&b, "%d. %v\n   %v\n", i+1, r.Title, r.URL)
+		if r.Snippet != "" {
+			fmt.Fprintf(&b, "   %v\n", r.Snippet)
+		}
+	}
+	b.WriteString("\nRead a result with website_text.\n")
+	return b.String(), nil
+}
+
+func (w WebSearchTool) Specification() pub_models.Specification {
+	return pub_models.Specification(WebSearch)
+}
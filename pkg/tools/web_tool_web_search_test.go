@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pub_models "github.com/baalimago/clai/pkg/text/models"
+)
+
+// searxngStandIn answers like a SearXNG instance with n results.
+func searxngStandIn(t *testing.T, n int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		results := make([]string, n)
+		for i := range results {
+			results[i] = fmt.Sprintf(`{"title":"Result %d","url":"https://example.com/%d","content":"About %v"}`, i+1, i+1, r.URL.Query().Get("q"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"results":[`+strings.Join(results, ",")+`]}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebSearch(t *testing.T) {
+	srv := searxngStandIn(t, 10)
+	s := NewSession()
+	s.SetWebSearch(&WebSearchConfig{Backend: "searxng", URL: srv.URL, MaxResults: 2})
+	ctx := WithSession(t.Context(), s)
+
+	out, err := WebSearch.CallWithContext(ctx, pub_models.Input{"query": "clai"})
+	if err != nil {
+		t.Fatalf("web_search: %v", err)
+	}
+	want := "1. Result 1\n   https://example.com/1\n   About clai\n" +
+		"2. Result 2\n   https://example.com/2\n   About clai\n" +
+		"\nRead a result with website_text.\n"
+	if out != want {
+		t.Fatalf("expected:\n%v\ngot:\n%v", want, out)
+	}
+
+	out, err = WebSearch.CallWithContext(ctx, pub_models.Input{"query": "clai", "count": float64(50)})
+	if err != nil {
+		t.Fatalf("web_search: %v", err)
+	}
+	if !strings.Contains(out, "10. Result 10") {
+		t.Fatalf("expected count to override max_results, got:\n%v", out)
+	}
+
+	if _, err := WebSearch.CallWithContext(ctx, pub_models.Input{"query": " "}); err == nil {
+		t.Fatal("expected an error for an empty query")
+	}
+}
+
+func TestWebSearch_NoResults(t *testing.T) {
+	s := NewSession()
+	s.SetWebSearch(&WebSearchConfig{Backend: "searxng", URL: searxngStandIn(t, 0).URL})
+	out, err := WebSearch.CallWithContext(WithSession(t.Context(), s), pub_models.Input{"query": "nothing"})
+	if err != nil || out != `No results for "nothing".` {
+		t.Fatalf("unexpected output %q, %v", out, err)
+	}
+}
+
+func TestWebSearch_NotConfigured(t *testing.T) {
+	_, err := WebSearch.CallWithContext(WithSession(t.Context(), NewSession()), pub_models.Input{"query": "clai"})
+	if err == nil || !strings.Contains(err.Error(), "webSearch.json") {
+		t.Fatalf("expected a hint at webSearch.json, got %v", err)
+	}
+}
+
+func TestSession_SetWebSearchCopiesHeaders(t *testing.T) {
+	s := NewSession()
+	c := &WebSearchConfig{Backend: "searxng", URL: "http://localhost:8888", Headers: map[string]string{"X-Team": "a"}}
+	s.SetWebSearch(c)
+	c.Headers["X-Team"] = "changed"
+	if got := s.currentWebSearch().Headers["X-Team"]; got != "a" {
+		t.Fatalf("expected the session to keep its own headers, got %q", got)
+	}
+}
@@ -90,8 +90,13 @@ func (w WebsiteTextTool) Call(input pub_models.Input) (string, error) {
 	if err != nil {
 		ur = r
 	}
+	return HTMLText(ur)
+}
 
-	tokenizer := html.NewTokenizer(ur)
+// HTMLText returns the text of the HTML document read from r, with scripts,
+// styles and other non-text elements left out, one line per block element.
+func HTMLText(r io.Reader) (string, error) {
+	tokenizer := html.NewTokenizer(r)
 
 	skipTags := map[string]bool{
 		"script":   true,